@@ -7,9 +7,11 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/node/api"
 	"gitlab.com/NebulousLabs/Sia/node/api/client"
 	"gitlab.com/NebulousLabs/Sia/types"
 
@@ -18,7 +20,7 @@ import (
 
 var (
 	hostAnnounceCmd = &cobra.Command{
-		Use:   "announce",
+		Use:   "announce [address...]",
 		Short: "Announce yourself as a host",
 		Long: `Announce yourself as a host on the network.
 Announcing will also configure the host to start accepting contracts.
@@ -26,7 +28,13 @@ You can revert this by running:
 	siac host config acceptingcontracts false
 You may also supply a specific address to be announced, e.g.:
 	siac host announce my-host-domain.com:9001
-Doing so will override the standard connectivity checks.`,
+Doing so will override the standard connectivity checks.
+Several addresses can be announced in priority order, e.g. an IPv4 address,
+an IPv6 address and a hostname:
+	siac host announce 1.2.3.4:9982 [2001:db8::1]:9982 my-host-domain.com:9982
+Renters try the addresses in order. Renters that don't support this only use
+the first address. The --expiry flag makes the alternate addresses expire after
+the given duration, e.g. 12w.`,
 		Run: hostannouncecmd,
 	}
 
@@ -50,6 +58,10 @@ Available settings:
      netaddress:           string
      windowsize:           blocks
 
+     maxcontractbandwidth: bytes
+     maxdownloadspeed:     bytes / second
+     maxuploadspeed:       bytes / second
+
      collateral:       currency
      collateralbudget: currency
      maxcollateral:    currency
@@ -59,12 +71,35 @@ Available settings:
      minstorageprice:           currency / TB / Month
      minuploadbandwidthprice:   currency / TB
 
+     autopricing:                   boolean
+     contractpriceceiling:          currency
+     contractpricefloor:            currency
+     downloadbandwidthpriceceiling: currency / TB
+     downloadbandwidthpricefloor:   currency / TB
+     storagepriceceiling:           currency / TB / Month
+     storagepricefloor:             currency / TB / Month
+     uploadbandwidthpriceceiling:   currency / TB
+     uploadbandwidthpricefloor:     currency / TB
+
 Currency units can be specified, e.g. 10SC; run 'siac help wallet' for details.
 
 Durations (maxduration and windowsize) must be specified in either blocks (b),
 hours (h), days (d), or weeks (w). A block is approximately 10 minutes, so one
 hour is six blocks, a day is 144 blocks, and a week is 1008 blocks.
 
+Bandwidth limits (maxcontractbandwidth, maxdownloadspeed, and maxuploadspeed)
+must be specified with a unit, e.g. 10MB; a value of 0B disables the limit.
+The download speed is the speed at which the host receives data, the upload
+speed is the speed at which it sends data. maxcontractbandwidth limits the
+data transferred using a single contract per day; renewing a contract doesn't
+reset its quota.
+
+With autopricing enabled, the host periodically sets its prices from the
+median prices of the other hosts on the network, its storage utilization, and
+the usage of its collateral budget. The floors and ceilings bound the automatic
+prices; a ceiling of 0 means that the price has no upper bound. Run
+'siac host pricing' to see the automatic price changes.
+
 For a description of each parameter, see doc/API.md.
 
 To configure the host to accept new contracts, set acceptingcontracts to true:
@@ -94,8 +129,18 @@ Available output types:
 
 	hostFolderCmd = &cobra.Command{
 		Use:   "folder",
-		Short: "Add, remove, or resize a storage folder",
-		Long:  "Add, remove, or resize a storage folder.",
+		Short: "Add, remove, resize, or migrate a storage folder",
+		Long:  "Add, remove, resize, or migrate a storage folder.",
+	}
+
+	hostFolderMigrateCmd = &cobra.Command{
+		Use:   "migrate [source] [destination]",
+		Short: "Move the data of a storage folder into another storage folder",
+		Long: `Move all of the data stored in the source folder into the destination folder,
+for example to move the host to a new disk. The data remains available while it
+is being moved, and nothing is lost if the destination runs out of space. Once
+the migration has completed, the source folder can be removed.`,
+		Run: wrap(hostfoldermigratecmd),
 	}
 
 	hostFolderRemoveCmd = &cobra.Command{
@@ -116,11 +161,79 @@ other storage folders.`,
 		Run: wrap(hostfolderresizecmd),
 	}
 
+	hostMaintenanceCmd = &cobra.Command{
+		Use:   "maintenance [on|off]",
+		Short: "Enable or disable maintenance mode",
+		Long: `Enable or disable maintenance mode, to prepare the host for planned
+downtime. In maintenance mode, the host rejects new contracts, renewals and
+uploads, but keeps serving downloads. The --duration flag announces how long the
+maintenance takes, e.g. 6h; renters don't consider the host offline during the
+announced window.`,
+		Run: wrap(hostmaintenancecmd),
+	}
+
+	hostPricingCmd = &cobra.Command{
+		Use:   "pricing",
+		Short: "Show the automatic price changes of the host",
+		Long: `Show the price changes that were made by automatic pricing, together
+with the network median prices they were based on.`,
+		Run: wrap(hostpricingcmd),
+	}
+
+	hostProofsCmd = &cobra.Command{
+		Use:   "proofs",
+		Short: "Show whether the host is ready for its storage proofs",
+		Long: `Show the result of the most recent storage proof simulation. The host
+periodically builds the storage proof of every contract for a random segment
+and validates it without submitting it, to find the contracts that would fail
+their storage proof. With --simulate, a new simulation is run first.`,
+		Run: wrap(hostproofscmd),
+	}
+
+	hostRentersCmd = &cobra.Command{
+		Use:   "renters",
+		Short: "Show the reputations of the renters",
+		Long: `Show the renters that formed contracts with the host, how they used their
+contracts, and the policies that the host enforces for them.`,
+		Run: wrap(hostrenterscmd),
+	}
+
+	hostRentersPolicyCmd = &cobra.Command{
+		Use:   "policy [publickey]",
+		Short: "Set the policy of a renter",
+		Long: `Set the policy that the host enforces for a renter, identified by its public
+key. A denied renter can't form, renew or revise contracts. The host's prices
+for renewals, uploads and downloads are multiplied by the price multiplier for
+the renter, and the renter can't store more than the maximum storage. Omitted limits fall back to the host's
+defaults, so running the command without flags resets the policy.`,
+		Run: wrap(hostrenterspolicycmd),
+	}
+
+	hostReportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "Show a monthly profitability report",
+		Long: `Show the revenue, losses and profit of the contracts that the host resolved,
+grouped by the month in which they were resolved. The profit is the revenue
+minus the transaction fees and the collateral that was lost.`,
+		Run: wrap(hostreportcmd),
+	}
+
 	hostSectorCmd = &cobra.Command{
 		Use:   "sector",
-		Short: "Add or delete a sector (add not supported)",
-		Long: `Add or delete a sector. Adding is not currently supported. Note that
-deleting a sector may impact host revenue.`,
+		Short: "Add, audit or delete a sector (add not supported)",
+		Long: `Add, audit or delete a sector. Adding is not currently supported. Note
+that deleting a sector may impact host revenue.`,
+	}
+
+	hostSectorAuditCmd = &cobra.Command{
+		Use:   "audit",
+		Short: "Audit the reference counts of the sectors",
+		Long: `Compare the reference counts of the stored sectors with the storage
+obligations of the host. Orphaned sectors are not used by any obligation and
+waste storage, under-referenced sectors will be removed before their last
+obligation ends. With --fix, the reference counts are corrected and the
+orphaned sectors are removed.`,
+		Run: wrap(hostsectorauditcmd),
 	}
 
 	hostSectorDeleteCmd = &cobra.Command{
@@ -199,6 +312,10 @@ Host Internal Settings:
 	netaddress:           %v
 	windowsize:           %v Hours
 
+	maxcontractbandwidth: %v
+	maxdownloadspeed:     %v
+	maxuploadspeed:       %v
+
 	collateral:       %v / TB / Month
 	collateralbudget: %v
 	maxcollateral:    %v Per Contract
@@ -208,6 +325,16 @@ Host Internal Settings:
 	minstorageprice:           %v / TB / Month
 	minuploadbandwidthprice:   %v / TB
 
+	autopricing:                   %v
+	contractpriceceiling:          %v
+	contractpricefloor:            %v
+	downloadbandwidthpriceceiling: %v / TB
+	downloadbandwidthpricefloor:   %v / TB
+	storagepriceceiling:           %v / TB / Month
+	storagepricefloor:             %v / TB / Month
+	uploadbandwidthpriceceiling:   %v / TB
+	uploadbandwidthpricefloor:     %v / TB
+
 Host Financials:
 	Contract Count:               %v
 	Transaction Fee Compensation: %v
@@ -234,6 +361,13 @@ RPC Stats:
 	Revise Calls:       %v
 	Settings Calls:     %v
 	FormContract Calls: %v
+	Recover Calls:      %v
+
+Bandwidth:
+	Downloaded:     %v
+	Uploaded:       %v
+	Download Speed: %v/s
+	Upload Speed:   %v/s
 `,
 			connectabilityString,
 
@@ -242,6 +376,10 @@ RPC Stats:
 			filesizeUnits(int64(is.MaxReviseBatchSize)), netaddr,
 			is.WindowSize/6,
 
+			bandwidthLimitUnits(int64(is.MaxContractBandwidth), ""),
+			bandwidthLimitUnits(is.MaxDownloadSpeed, "/s"),
+			bandwidthLimitUnits(is.MaxUploadSpeed, "/s"),
+
 			currencyUnits(is.Collateral.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.CollateralBudget),
 			currencyUnits(is.MaxCollateral),
@@ -251,6 +389,16 @@ RPC Stats:
 			currencyUnits(is.MinStoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
 			currencyUnits(is.MinUploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
 
+			yesNo(is.AutoPricing),
+			currencyUnits(is.ContractPriceCeiling),
+			currencyUnits(is.ContractPriceFloor),
+			currencyUnits(is.DownloadBandwidthPriceCeiling.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(is.DownloadBandwidthPriceFloor.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(is.StoragePriceCeiling.Mul(modules.BlockBytesPerMonthTerabyte)),
+			currencyUnits(is.StoragePriceFloor.Mul(modules.BlockBytesPerMonthTerabyte)),
+			currencyUnits(is.UploadBandwidthPriceCeiling.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(is.UploadBandwidthPriceFloor.Mul(modules.BytesPerTerabyte)),
+
 			fm.ContractCount, currencyUnits(fm.ContractCompensation),
 			currencyUnits(fm.PotentialContractCompensation),
 			currencyUnits(fm.TransactionFeeExpenses),
@@ -269,7 +417,12 @@ RPC Stats:
 
 			nm.ErrorCalls, nm.UnrecognizedCalls, nm.DownloadCalls,
 			nm.RenewCalls, nm.ReviseCalls, nm.SettingsCalls,
-			nm.FormContractCalls)
+			nm.FormContractCalls, nm.RecoverCalls,
+
+			filesizeUnits(int64(nm.DownloadBandwidth)),
+			filesizeUnits(int64(nm.UploadBandwidth)),
+			filesizeUnits(int64(nm.DownloadSpeed)),
+			filesizeUnits(int64(nm.UploadSpeed)))
 	} else {
 		fmt.Printf(`Host info:
 	Connectability Status: %v
@@ -294,6 +447,13 @@ RPC Stats:
 			currencyUnits(totalRevenue))
 	}
 
+	if is.MaintenanceMode {
+		fmt.Println("\nMaintenance:\n	Host is in maintenance mode and only serves downloads.")
+		if is.MaintenanceEnd != 0 {
+			fmt.Printf("	Announced end: %v\n", time.Unix(int64(is.MaintenanceEnd), 0).Format(time.RFC822))
+		}
+	}
+
 	// if wallet is locked print warning
 	walletstatus, walleterr := httpClient.WalletGet()
 	if walleterr != nil {
@@ -329,14 +489,16 @@ func hostconfigcmd(param, value string) {
 	var err error
 	switch param {
 	// currency (convert to hastings)
-	case "collateralbudget", "maxcollateral", "mincontractprice":
+	case "collateralbudget", "maxcollateral", "mincontractprice", "contractpriceceiling", "contractpricefloor":
 		value, err = parseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
 		}
 
 	// currency/TB (convert to hastings/byte)
-	case "mindownloadbandwidthprice", "minuploadbandwidthprice":
+	case "mindownloadbandwidthprice", "minuploadbandwidthprice",
+		"downloadbandwidthpriceceiling", "downloadbandwidthpricefloor",
+		"uploadbandwidthpriceceiling", "uploadbandwidthpricefloor":
 		hastings, err := parseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -346,7 +508,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// currency/TB/month (convert to hastings/byte/block)
-	case "collateral", "minstorageprice":
+	case "collateral", "minstorageprice", "storagepriceceiling", "storagepricefloor":
 		hastings, err := parseCurrency(value)
 		if err != nil {
 			die("Could not parse "+param+":", err)
@@ -356,7 +518,7 @@ func hostconfigcmd(param, value string) {
 		value = c.String()
 
 	// bool (allow "yes" and "no")
-	case "acceptingcontracts":
+	case "acceptingcontracts", "autopricing":
 		switch strings.ToLower(value) {
 		case "yes":
 			value = "true"
@@ -371,6 +533,13 @@ func hostconfigcmd(param, value string) {
 			die("Could not parse "+param+":", err)
 		}
 
+	// bytes or bytes per second
+	case "maxcontractbandwidth", "maxdownloadspeed", "maxuploadspeed":
+		value, err = parseFilesize(strings.TrimSuffix(value, "/s"))
+		if err != nil {
+			die("Could not parse "+param+":", err)
+		}
+
 	// other valid settings
 	case "maxdownloadbatchsize", "maxrevisebatchsize", "netaddress":
 
@@ -424,19 +593,291 @@ func hostcontractcmd() {
 	w.Flush()
 }
 
+// hostmaintenancecmd is the handler for the command `siac host maintenance
+// [on|off]`. Enables or disables maintenance mode.
+func hostmaintenancecmd(mode string) {
+	var enabled bool
+	switch strings.ToLower(mode) {
+	case "on":
+		enabled = true
+	case "off":
+	default:
+		die("Maintenance mode must be either 'on' or 'off'")
+	}
+	var end types.Timestamp
+	if enabled && hostMaintenanceDuration != "" {
+		d, err := time.ParseDuration(hostMaintenanceDuration)
+		if err != nil || d <= 0 {
+			die("Could not parse duration:", hostMaintenanceDuration)
+		}
+		end = types.Timestamp(time.Now().Add(d).Unix())
+	} else if !enabled && hostMaintenanceDuration != "" {
+		die("A duration can only be announced when enabling maintenance mode")
+	}
+	err := httpClient.HostMaintenancePost(enabled, end)
+	if err != nil {
+		die("Could not update maintenance mode:", err)
+	}
+	if !enabled {
+		fmt.Println("Host left maintenance mode.")
+	} else if end == 0 {
+		fmt.Println("Host is in maintenance mode.")
+	} else {
+		fmt.Printf("Host is in maintenance mode until %v.\n", time.Unix(int64(end), 0).Format(time.RFC822))
+	}
+}
+
+// hostpricingcmd is the handler for the command `siac host pricing`.
+// Prints the automatic price changes of the host.
+func hostpricingcmd() {
+	hpg, err := httpClient.HostPricingGet()
+	if err != nil {
+		die("Could not fetch host pricing history:", err)
+	}
+	fmt.Println("Automatic pricing:", yesNo(hpg.AutoPricing))
+	if len(hpg.History) == 0 {
+		fmt.Println("No automatic price changes.")
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Height\tStorage (/TB/Month)\tUpload (/TB)\tDownload (/TB)\tContract\tMedian Storage\tHosts\tUtilization\tCollateral Usage")
+	for _, pc := range hpg.History {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%.1f%%\t%.1f%%\n", pc.BlockHeight,
+			currencyUnits(pc.NewPrices.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+			currencyUnits(pc.NewPrices.UploadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(pc.NewPrices.DownloadBandwidthPrice.Mul(modules.BytesPerTerabyte)),
+			currencyUnits(pc.NewPrices.ContractPrice),
+			currencyUnits(pc.NetworkMedian.StoragePrice.Mul(modules.BlockBytesPerMonthTerabyte)),
+			pc.NetworkSamples, 100*pc.Utilization, 100*pc.CollateralBudgetUsage)
+	}
+	w.Flush()
+}
+
+// renterPolicyString returns a short description of a renter policy.
+func renterPolicyString(policy modules.RenterPolicy) string {
+	if policy.Deny {
+		return "denied"
+	}
+	var limits []string
+	if policy.PriceMultiplier != 0 {
+		limits = append(limits, fmt.Sprintf("prices x%v", policy.PriceMultiplier))
+	}
+	if policy.MaxStorage != 0 {
+		limits = append(limits, "max "+filesizeUnits(int64(policy.MaxStorage)))
+	}
+	if len(limits) == 0 {
+		return "default"
+	}
+	return strings.Join(limits, ", ")
+}
+
+// hostrenterscmd is the handler for the command `siac host renters`.
+// Prints the reputations and the policies of the renters.
+func hostrenterscmd() {
+	hrg, err := httpClient.HostRentersGet()
+	if err != nil {
+		die("Could not fetch host renters:", err)
+	}
+	if len(hrg.Renters) == 0 {
+		fmt.Println("No renters.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Public Key\tContracts\tActive\tAbandoned\tStored\tBandwidth\tLast Seen\tPolicy")
+	for _, rr := range hrg.Renters {
+		lastSeen := "-"
+		if !rr.LastSeen.IsZero() {
+			lastSeen = rr.LastSeen.Format("2006-01-02")
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", rr.PublicKey, rr.Contracts, rr.ActiveContracts,
+			rr.AbandonedContracts, filesizeUnits(int64(rr.DataStored)), filesizeUnits(int64(rr.BandwidthUsed)),
+			lastSeen, renterPolicyString(rr.Policy))
+	}
+	w.Flush()
+}
+
+// hostrenterspolicycmd is the handler for the command
+// `siac host renters policy [publickey]`. Sets the policy of a renter.
+func hostrenterspolicycmd(publicKey string) {
+	var renter types.SiaPublicKey
+	renter.LoadString(publicKey)
+	if renter.Key == nil {
+		die("Could not parse public key, expected a key of the form ed25519:<hex>")
+	}
+	policy := modules.RenterPolicy{
+		Deny: hostRenterDeny,
+	}
+	if hostRenterPriceFactor != "" {
+		_, err := fmt.Sscan(hostRenterPriceFactor, &policy.PriceMultiplier)
+		if err != nil {
+			die("Could not parse price multiplier:", err)
+		}
+	}
+	if hostRenterMaxStorage != "" {
+		maxStorage, err := parseFilesize(hostRenterMaxStorage)
+		if err != nil {
+			die("Could not parse max storage:", err)
+		}
+		fmt.Sscan(maxStorage, &policy.MaxStorage)
+	}
+	err := httpClient.HostRentersPolicyPost(renter, policy)
+	if err != nil {
+		die("Could not set renter policy:", err)
+	}
+	fmt.Printf("Set the policy of %v to: %v\n", renter, renterPolicyString(policy))
+}
+
+// hostMonthReport contains the outcomes of the contracts that the host
+// resolved in a month.
+type hostMonthReport struct {
+	contracts      int
+	succeeded      int
+	failed         int
+	revenue        types.Currency
+	lostRevenue    types.Currency
+	lostCollateral types.Currency
+	fees           types.Currency
+}
+
+// add adds the outcome of a resolved contract to the report.
+func (r *hostMonthReport) add(aso modules.ArchivedStorageObligation) {
+	r.contracts++
+	switch aso.ObligationStatus {
+	case "obligationSucceeded":
+		r.succeeded++
+	case "obligationFailed":
+		r.failed++
+	}
+	r.revenue = r.revenue.Add(aso.Revenue)
+	r.lostRevenue = r.lostRevenue.Add(aso.LostRevenue)
+	r.lostCollateral = r.lostCollateral.Add(aso.LostCollateral)
+	r.fees = r.fees.Add(aso.TransactionFees)
+}
+
+// profit returns the revenue minus the fees and the lost collateral, which
+// can be negative.
+func (r hostMonthReport) profit() string {
+	profit := r.revenue.Big()
+	profit.Sub(profit, r.fees.Big())
+	profit.Sub(profit, r.lostCollateral.Big())
+	if profit.Sign() < 0 {
+		return "-" + currencyUnits(types.NewCurrency(profit.Neg(profit)))
+	}
+	return currencyUnits(types.NewCurrency(profit))
+}
+
+// hostreportcmd is the handler for the command `siac host report`.
+// Prints the monthly profitability of the contracts resolved by the host.
+func hostreportcmd() {
+	if hostReportMonths < 1 {
+		die("The report needs to include at least one month")
+	}
+	now := time.Now()
+	start := time.Date(now.Year(), now.Month()-time.Month(hostReportMonths-1), 1, 0, 0, 0, 0, time.Local)
+	chg, err := httpClient.HostContractHistoryGet(start, time.Time{}, "")
+	if err != nil {
+		die("Could not fetch host contract history:", err)
+	}
+
+	months := make([]hostMonthReport, hostReportMonths)
+	var total hostMonthReport
+	for _, aso := range chg.Contracts {
+		resolvedAt := aso.ResolvedAt.Local()
+		i := (resolvedAt.Year()-start.Year())*12 + int(resolvedAt.Month()) - int(start.Month())
+		if i < 0 || i >= len(months) {
+			continue
+		}
+		months[i].add(aso)
+		total.add(aso)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Month\tContracts\tSucceeded\tFailed\tRevenue\tLost Revenue\tLost Collateral\tFees\tProfit")
+	for i, r := range months {
+		month := start.AddDate(0, i, 0).Format("2006-01")
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", month, r.contracts, r.succeeded, r.failed,
+			currencyUnits(r.revenue), currencyUnits(r.lostRevenue), currencyUnits(r.lostCollateral), currencyUnits(r.fees), r.profit())
+	}
+	fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n", "Total", total.contracts, total.succeeded, total.failed,
+		currencyUnits(total.revenue), currencyUnits(total.lostRevenue), currencyUnits(total.lostCollateral), currencyUnits(total.fees), total.profit())
+	w.Flush()
+}
+
+// hostproofscmd is the handler for the command `siac host proofs`. Prints
+// the storage proof simulations of the unresolved contracts of the host.
+func hostproofscmd() {
+	var spg api.StorageProofsGET
+	var err error
+	if hostProofsSimulate {
+		spg, err = httpClient.HostStorageProofsPost()
+	} else {
+		spg, err = httpClient.HostStorageProofsGet()
+	}
+	if err != nil {
+		die("Could not get the storage proof simulation:", err)
+	}
+	if spg.SimulatedAt.IsZero() {
+		fmt.Println("The storage proofs have not been simulated yet. Run 'siac host proofs --simulate' to simulate them now.")
+		return
+	}
+
+	fmt.Println("Simulated at:     ", spg.SimulatedAt.Local().Format(time.RFC822))
+	fmt.Println("Contracts:        ", len(spg.Obligations))
+	fmt.Println("Failed:           ", spg.FailedObligations)
+	fmt.Println("Risked Collateral:", currencyUnits(spg.RiskedCollateral))
+	if len(spg.Obligations) == 0 {
+		return
+	}
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Contract\tProof Deadline\tSegment\tCollateral\tStatus")
+	for _, sim := range spg.Obligations {
+		status := "ready"
+		if !sim.Ready {
+			status = "failed: " + sim.Error
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", sim.ObligationID, sim.ProofDeadLine, sim.SegmentIndex, currencyUnits(sim.RiskedCollateral), status)
+	}
+	w.Flush()
+}
+
 // hostannouncecmd is the handler for the command `siac host announce`.
 // Announces yourself as a host to the network. Optionally takes an address to
 // announce as.
 func hostannouncecmd(cmd *cobra.Command, args []string) {
+	var expiry types.BlockHeight
+	if hostAnnounceExpiry != "" {
+		if len(args) == 0 {
+			die("An expiry can only be set for specific addresses")
+		}
+		blocks, err := parsePeriod(hostAnnounceExpiry)
+		if err != nil {
+			die("Could not parse expiry:", err)
+		}
+		cg, err := httpClient.ConsensusGet()
+		if err != nil {
+			die("Could not get the current block height:", err)
+		}
+		_, err = fmt.Sscan(blocks, &expiry)
+		if err != nil {
+			die("Could not parse expiry:", err)
+		}
+		expiry += cg.Height
+	}
+
 	var err error
-	switch len(args) {
-	case 0:
+	switch {
+	case len(args) == 0:
 		err = httpClient.HostAnnouncePost()
-	case 1:
+	case len(args) == 1 && expiry == 0:
 		err = httpClient.HostAnnounceAddrPost(modules.NetAddress(args[0]))
 	default:
-		cmd.UsageFunc()(cmd)
-		os.Exit(exitCodeUsage)
+		addrs := make([]modules.NetAddress, 0, len(args))
+		for _, arg := range args {
+			addrs = append(addrs, modules.NetAddress(arg))
+		}
+		err = httpClient.HostAnnounceAddressesPost(addrs, expiry)
 	}
 	if err != nil {
 		die("Could not announce host:", err)
@@ -472,6 +913,15 @@ func hostfolderaddcmd(path, size string) {
 	fmt.Println("Added folder", path)
 }
 
+// hostfoldermigratecmd moves the data of a folder into another folder.
+func hostfoldermigratecmd(source, destination string) {
+	err := httpClient.HostStorageFoldersMigratePost(abs(source), abs(destination))
+	if err != nil {
+		die("Could not migrate folder:", err)
+	}
+	fmt.Printf("Migrated folder %v to %v\n", source, destination)
+}
+
 // hostfolderremovecmd removes a folder from the host.
 func hostfolderremovecmd(path string) {
 	err := httpClient.HostStorageFoldersRemovePost(abs(path))
@@ -500,6 +950,52 @@ func hostfolderresizecmd(path, newsize string) {
 	fmt.Printf("Resized folder %v to %v\n", path, newsize)
 }
 
+// hostsectorauditcmd is the handler for the command `siac host sector audit`.
+// Prints the sectors whose reference counts don't match the storage
+// obligations of the host, optionally fixing them.
+func hostsectorauditcmd() {
+	var sag api.StorageAuditGET
+	var err error
+	if hostAuditFix {
+		sag, err = httpClient.HostStorageAuditPost()
+	} else {
+		sag, err = httpClient.HostStorageAuditGet()
+	}
+	if err != nil {
+		die("Could not audit host storage:", err)
+	}
+
+	fmt.Println("Audited sectors:", sag.TotalSectors)
+	fmt.Println("Orphaned:       ", len(sag.OrphanedSectors))
+	fmt.Println("Under-referenced:", len(sag.UnderReferencedSectors))
+	fmt.Println("Over-referenced: ", len(sag.OverReferencedSectors))
+	fmt.Println("Missing:        ", len(sag.MissingSectors))
+	sectors := append(append(append([]modules.AuditedSector(nil), sag.OrphanedSectors...), sag.UnderReferencedSectors...), sag.OverReferencedSectors...)
+	if len(sectors) > 0 {
+		fmt.Println()
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Sector\tFolder\tCount\tReferences")
+		for _, as := range sectors {
+			fmt.Fprintf(w, "%v\t%v\t%v\t%v\n", as.ID, as.Path, as.Count, as.References)
+		}
+		w.Flush()
+	}
+	if len(sag.MissingSectors) > 0 {
+		fmt.Println()
+		fmt.Println("Sectors that are referenced by storage obligations but not stored:")
+		for _, root := range sag.MissingSectors {
+			fmt.Println("  ", root)
+		}
+	}
+	if sag.Fixed {
+		fmt.Println()
+		fmt.Println("The reference counts were corrected.")
+	} else if len(sectors) > 0 {
+		fmt.Println()
+		fmt.Println("Run 'siac host sector audit --fix' to correct the reference counts.")
+	}
+}
+
 // hostsectordeletecmd deletes a sector from the host.
 func hostsectordeletecmd(root string) {
 	var hash crypto.Hash
@@ -331,5 +331,16 @@ func hostdbviewcmd(pubkey string) {
 	fmt.Println("\n  Scan History Length:", len(info.Entry.ScanHistory))
 	fmt.Printf("  Overall Uptime:      %.3f\n", uptimeRatio)
 
+	// Print the misbehavior evidence of the host, if any.
+	if len(info.Entry.Misbehavior) > 0 {
+		fmt.Println("\n  Misbehavior:")
+		w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "\t\tHeight\tType\tContract\tDescription")
+		for _, m := range info.Entry.Misbehavior {
+			fmt.Fprintf(w, "\t\t%v\t%v\t%v\t%v\n", m.BlockHeight, m.Type, m.ContractID, m.Description)
+		}
+		w.Flush()
+	}
+
 	fmt.Println()
 }
@@ -10,21 +10,63 @@ import (
 	"github.com/spf13/cobra"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/node/api/client"
 )
 
 var (
 	// Flags.
-	hostContractOutputType string // output type for host contracts
-	hostVerbose            bool   // display additional host info
-	initForce              bool   // destroy and re-encrypt the wallet on init if it already exists
-	initPassword           bool   // supply a custom password when creating a wallet
-	renterAllContracts     bool   // Show all active and expired contracts
-	renterDownloadAsync    bool   // Downloads files asynchronously
-	renterListVerbose      bool   // Show additional info about uploaded files.
-	renterShowHistory      bool   // Show download history in addition to download queue.
-	siaDir                 string // Path to sia data dir
-	walletRawTxn           bool   // Encode/decode transactions in base64-encoded binary.
+	hostContractOutputType     string // output type for host contracts
+	hostMaintenanceDuration    string // announced duration of the maintenance
+	hostAnnounceExpiry         string // duration after which the announced addresses expire
+	hostAuditFix               bool   // fix the sectors found by the storage audit
+	hostProofsSimulate         bool   // run a new storage proof simulation
+	hostRenterDeny             bool   // deny the renter in the renter policy
+	hostRenterMaxStorage       string // storage limit of the renter policy
+	hostRenterPriceFactor      string // price multiplier of the renter policy
+	hostReportMonths           int    // number of months in the host profitability report
+	hostVerbose                bool   // display additional host info
+	initForce                  bool   // destroy and re-encrypt the wallet on init if it already exists
+	initPassword               bool   // supply a custom password when creating a wallet
+	renterAllContracts         bool   // Show all active and expired contracts
+	renterDownloadAsync        bool   // Downloads files asynchronously
+	renterExpectedDownload     string // Expected download per month of the allowance.
+	renterExpectedRedundancy   string // Expected redundancy of the allowance.
+	renterExpectedStorage      string // Expected storage of the allowance.
+	renterExpectedUpload       string // Expected upload per month of the allowance.
+	renterListVerbose          bool   // Show additional info about uploaded files.
+	renterReportFormat         string // Output format of the contract report.
+	renterShowHistory          bool   // Show download history in addition to download queue.
+	siaDir                     string // Path to sia data dir
+	walletExportEndDate        string // Last day of the exported transactions.
+	walletExportEndHeight      int    // Last block height of the exported transactions.
+	walletExportFormat         string // Format of the exported transactions.
+	walletExportStartDate      string // First day of the exported transactions.
+	walletExportStartHeight    uint64 // First block height of the exported transactions.
+	walletMultisigUnused       bool   // The multisig address has not appeared in the blockchain.
+	walletPSTxBroadcast        bool   // Broadcast the finalized partially signed transaction.
+	walletRawTxn               bool   // Encode/decode transactions in base64-encoded binary.
+	walletRequestConfirmations uint64 // Number of confirmations that a payment request needs.
+	walletRequestExpiry        uint64 // Number of blocks after which a payment request expires.
+	walletRequestMemo          string // Memo of a payment request.
+	walletRequestSince         uint64 // Sequence number of the last seen payment request event.
+	walletRequestWebhook       string // URL that status changes of a payment request are posted to.
+	walletScheduleCount        uint64 // Number of payments of a recurring scheduled payment.
+	walletScheduleDailyLimit   string // Daily limit of the scheduled payments.
+	walletScheduleDate         string // Date of a scheduled payment.
+	walletScheduleEvery        string // Interval of a recurring scheduled payment.
+	walletScheduleHeight       uint64 // Block height of a scheduled payment.
+	walletScheduleMemo         string // Memo of a scheduled payment.
+	walletScheduleSince        uint64 // Sequence number of the last seen scheduled payment execution.
+	walletScheduleThreshold    string // Approval threshold of the scheduled payments.
+	walletSendChange           string // Change address of the selected inputs.
+	walletSendInputs           string // Comma-separated ids of the outputs that fund the transaction.
+	walletSendSpendAll         bool   // Spend all of the selected inputs.
+	walletTxnCategory          string // Category of the listed transactions.
+	walletTxnLabel             string // Label of the addresses of the listed transactions.
+	walletWatchOnlyCount       uint64 // Number of exported watch-only keys.
+	walletWatchOnlyStart       uint64 // Seed index of the first exported watch-only key.
+	walletWatchOnlyUnused      bool   // The watch-only keys have not appeared in the blockchain.
 )
 
 var (
@@ -94,11 +136,20 @@ func main() {
 	updateCmd.AddCommand(updateCheckCmd)
 
 	root.AddCommand(hostCmd)
-	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostContractCmd, hostSectorCmd)
-	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
-	hostSectorCmd.AddCommand(hostSectorDeleteCmd)
+	hostCmd.AddCommand(hostConfigCmd, hostAnnounceCmd, hostFolderCmd, hostContractCmd, hostMaintenanceCmd, hostPricingCmd, hostProofsCmd, hostRentersCmd, hostReportCmd, hostSectorCmd)
+	hostFolderCmd.AddCommand(hostFolderAddCmd, hostFolderMigrateCmd, hostFolderRemoveCmd, hostFolderResizeCmd)
+	hostRentersCmd.AddCommand(hostRentersPolicyCmd)
+	hostSectorCmd.AddCommand(hostSectorAuditCmd, hostSectorDeleteCmd)
 	hostCmd.Flags().BoolVarP(&hostVerbose, "verbose", "v", false, "Display detailed host info")
+	hostAnnounceCmd.Flags().StringVarP(&hostAnnounceExpiry, "expiry", "e", "", "Duration after which the alternate addresses expire, e.g. 12w")
 	hostContractCmd.Flags().StringVarP(&hostContractOutputType, "type", "t", "value", "Select output type")
+	hostMaintenanceCmd.Flags().StringVarP(&hostMaintenanceDuration, "duration", "d", "", "Announced duration of the maintenance, e.g. 6h")
+	hostProofsCmd.Flags().BoolVarP(&hostProofsSimulate, "simulate", "s", false, "Run a new storage proof simulation")
+	hostRentersPolicyCmd.Flags().BoolVarP(&hostRenterDeny, "deny", "d", false, "Deny contracts with the renter")
+	hostRentersPolicyCmd.Flags().StringVarP(&hostRenterMaxStorage, "max-storage", "s", "", "Maximum amount of data that the renter can store")
+	hostRentersPolicyCmd.Flags().StringVarP(&hostRenterPriceFactor, "price-multiplier", "p", "", "Multiplier of the host's prices for the renter")
+	hostSectorAuditCmd.Flags().BoolVarP(&hostAuditFix, "fix", "f", false, "Correct the reference counts of the sectors that were found")
+	hostReportCmd.Flags().IntVarP(&hostReportMonths, "months", "m", 12, "Number of months to include in the report")
 
 	root.AddCommand(hostdbCmd)
 	hostdbCmd.AddCommand(hostdbViewCmd)
@@ -109,15 +160,54 @@ func main() {
 	minerCmd.AddCommand(minerStartCmd, minerStopCmd)
 
 	root.AddCommand(walletCmd)
-	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletInitCmd, walletInitSeedCmd,
-		walletLoadCmd, walletLockCmd, walletSeedsCmd, walletSendCmd, walletSweepCmd, walletSignCmd,
-		walletBalanceCmd, walletBroadcastCmd, walletTransactionsCmd, walletUnlockCmd)
+	walletCmd.AddCommand(walletAddressCmd, walletAddressesCmd, walletChangepasswordCmd, walletExportCmd, walletInitCmd, walletInitSeedCmd, walletInitWatchOnlyCmd,
+		walletLabelCmd, walletLabelsCmd, walletLoadCmd, walletLockCmd, walletMultisigCmd, walletNoteCmd, walletPaymentRequestsCmd, walletPSTxCmd, walletScheduleCmd, walletSeedsCmd, walletSendCmd, walletSwapCmd, walletSweepCmd, walletSignCmd,
+		walletBalanceCmd, walletBroadcastCmd, walletBumpCmd, walletTransactionsCmd, walletUnlockCmd, walletWatchOnlyCmd)
+	walletExportCmd.Flags().StringVarP(&walletExportFormat, "format", "", "csv", "Format of the export, 'csv' or 'json'")
+	walletExportCmd.Flags().Uint64VarP(&walletExportStartHeight, "start-height", "", 0, "Export transactions from this block height on")
+	walletExportCmd.Flags().IntVarP(&walletExportEndHeight, "end-height", "", -1, "Export transactions up to this block height")
+	walletExportCmd.Flags().StringVarP(&walletExportStartDate, "start-date", "", "", "Export transactions from this date on (YYYY-MM-DD)")
+	walletExportCmd.Flags().StringVarP(&walletExportEndDate, "end-date", "", "", "Export transactions up to and including this date (YYYY-MM-DD)")
 	walletInitCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Prompt for a custom password")
 	walletInitCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet and re-encrypt")
 	walletInitSeedCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
+	walletInitWatchOnlyCmd.Flags().BoolVarP(&initForce, "force", "", false, "destroy the existing wallet")
 	walletLoadCmd.AddCommand(walletLoad033xCmd, walletLoadSeedCmd, walletLoadSiagCmd)
+	walletMultisigCmd.AddCommand(walletMultisigCreateCmd, walletMultisigKeyCmd, walletMultisigMergeCmd, walletMultisigSendCmd, walletMultisigSignCmd)
+	walletMultisigCreateCmd.Flags().BoolVarP(&walletMultisigUnused, "unused", "", false, "Skip the rescan because the address has not appeared in the blockchain")
+	walletMultisigMergeCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode merged transaction as base64 instead of JSON")
+	walletMultisigSendCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode transaction as base64 instead of JSON")
+	walletMultisigSignCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode signed transaction as base64 instead of JSON")
+	walletPaymentRequestsCmd.AddCommand(walletPaymentRequestsCreateCmd, walletPaymentRequestsEventsCmd)
+	walletPaymentRequestsCreateCmd.Flags().Uint64VarP(&walletRequestExpiry, "expiry", "", uint64(modules.DefaultPaymentRequestExpiry), "Number of blocks after which the request expires if it is not paid")
+	walletPaymentRequestsCreateCmd.Flags().Uint64VarP(&walletRequestConfirmations, "confirmations", "", modules.DefaultPaymentRequestConfirmations, "Number of confirmations that the payments need")
+	walletPaymentRequestsCreateCmd.Flags().StringVarP(&walletRequestMemo, "memo", "", "", "Memo of the request, e.g. an invoice number")
+	walletPaymentRequestsCreateCmd.Flags().StringVarP(&walletRequestWebhook, "webhook", "", "", "URL that status changes of the request are posted to")
+	walletPaymentRequestsEventsCmd.Flags().Uint64VarP(&walletRequestSince, "since", "", 0, "Only show events after this sequence number")
+	walletPSTxCmd.AddCommand(walletPSTxCombineCmd, walletPSTxCreateCmd, walletPSTxFinalizeCmd, walletPSTxSignCmd, walletPSTxViewCmd)
+	walletPSTxFinalizeCmd.Flags().BoolVarP(&walletPSTxBroadcast, "broadcast", "b", false, "Broadcast the transaction instead of printing it")
+	walletPSTxFinalizeCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode the transactions as base64 instead of JSON")
+	walletScheduleCmd.AddCommand(walletScheduleAddCmd, walletScheduleApproveCmd, walletScheduleCancelCmd, walletScheduleLimitsCmd, walletScheduleLogCmd)
+	walletScheduleAddCmd.Flags().Uint64VarP(&walletScheduleHeight, "height", "", 0, "Block height of the payment")
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleDate, "date", "", "", "Date of the payment (YYYY-MM-DD or 'YYYY-MM-DD HH:MM')")
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleEvery, "every", "", "", "Repeat the payment at this interval, e.g. 144b or 30d")
+	walletScheduleAddCmd.Flags().Uint64VarP(&walletScheduleCount, "count", "", 0, "Number of payments of a recurring payment (0 for no limit)")
+	walletScheduleAddCmd.Flags().StringVarP(&walletScheduleMemo, "memo", "", "", "Memo of the payment")
+	walletScheduleLimitsCmd.Flags().StringVarP(&walletScheduleThreshold, "approval-threshold", "", "", "Hold payments above this amount for approval (0 for no threshold)")
+	walletScheduleLimitsCmd.Flags().StringVarP(&walletScheduleDailyLimit, "daily-limit", "", "", "Hold payments above this amount per 24 hours for approval (0 for no limit)")
+	walletScheduleLogCmd.Flags().Uint64VarP(&walletScheduleSince, "since", "", 0, "Only show payments after this sequence number")
 	walletSendCmd.AddCommand(walletSendSiacoinsCmd, walletSendSiafundsCmd)
+	walletSwapCmd.AddCommand(walletSwapAcceptCmd, walletSwapClaimCmd, walletSwapFundCmd, walletSwapOfferCmd, walletSwapPresignCmd, walletSwapRequestCmd, walletSwapViewCmd)
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendInputs, "inputs", "", "", "Comma-separated ids of the outputs that fund the transaction")
+	walletSendSiacoinsCmd.Flags().StringVarP(&walletSendChange, "change", "", "", "Address that receives the change of the selected inputs")
+	walletSendSiacoinsCmd.Flags().BoolVarP(&walletSendSpendAll, "spend-all", "", false, "Spend all of the selected inputs, even if fewer would cover the amount")
+	walletTransactionsCmd.Flags().StringVarP(&walletTxnCategory, "category", "", "", "Only list transactions of this category")
+	walletTransactionsCmd.Flags().StringVarP(&walletTxnLabel, "label", "", "", "Only list transactions of addresses with this label")
 	walletUnlockCmd.Flags().BoolVarP(&initPassword, "password", "p", false, "Display interactive password prompt even if SIA_WALLET_PASSWORD is set")
+	walletWatchOnlyCmd.AddCommand(walletWatchOnlyExportCmd, walletWatchOnlyImportCmd, walletWatchOnlySendCmd)
+	walletWatchOnlyExportCmd.Flags().Uint64VarP(&walletWatchOnlyStart, "start", "", 0, "Seed index of the first exported key")
+	walletWatchOnlyExportCmd.Flags().Uint64VarP(&walletWatchOnlyCount, "count", "", 0, "Number of exported keys")
+	walletWatchOnlyImportCmd.Flags().BoolVarP(&walletWatchOnlyUnused, "unused", "", false, "Skip the rescan because the keys have not appeared in the blockchain")
 	walletBroadcastCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Decode transaction as base64 instead of JSON")
 	walletSignCmd.Flags().BoolVarP(&walletRawTxn, "raw", "", false, "Encode signed transaction as base64 instead of JSON")
 
@@ -128,11 +218,16 @@ func main() {
 		renterFilesUploadCmd, renterUploadsCmd, renterExportCmd,
 		renterPricesCmd)
 
-	renterContractsCmd.AddCommand(renterContractsViewCmd)
+	renterContractsCmd.AddCommand(renterContractsViewCmd, renterContractsRecoverCmd, renterContractsReportCmd)
 	renterAllowanceCmd.AddCommand(renterAllowanceCancelCmd)
 
 	renterCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
 	renterContractsCmd.Flags().BoolVarP(&renterAllContracts, "all", "A", false, "Show all expired contracts in addition to active contracts")
+	renterContractsReportCmd.Flags().StringVarP(&renterReportFormat, "format", "f", "table", "Output format of the report: table, csv or json")
+	renterSetAllowanceCmd.Flags().StringVarP(&renterExpectedStorage, "expected-storage", "", "", "Amount of data expected to be stored, e.g. 500GB")
+	renterSetAllowanceCmd.Flags().StringVarP(&renterExpectedUpload, "expected-upload", "", "", "Amount of data expected to be uploaded per month, e.g. 100GB")
+	renterSetAllowanceCmd.Flags().StringVarP(&renterExpectedDownload, "expected-download", "", "", "Amount of data expected to be downloaded per month, e.g. 100GB")
+	renterSetAllowanceCmd.Flags().StringVarP(&renterExpectedRedundancy, "expected-redundancy", "", "", "Expected average redundancy of the uploaded files, e.g. 3")
 	renterDownloadsCmd.Flags().BoolVarP(&renterShowHistory, "history", "H", false, "Show download history in addition to the download queue")
 	renterFilesDownloadCmd.Flags().BoolVarP(&renterDownloadAsync, "async", "A", false, "Download file asynchronously")
 	renterFilesListCmd.Flags().BoolVarP(&renterListVerbose, "verbose", "v", false, "Show additional file info such as redundancy")
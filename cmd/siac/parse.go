@@ -27,6 +27,16 @@ func filesizeUnits(size int64) string {
 	return fmt.Sprintf("%.*f %s", i, float64(size)/math.Pow10(3*i), sizes[i])
 }
 
+// bandwidthLimitUnits returns a string that displays a bandwidth limit in
+// human-readable units, followed by the suffix. A limit of zero is displayed
+// as "unlimited".
+func bandwidthLimitUnits(limit int64, suffix string) string {
+	if limit == 0 {
+		return "unlimited"
+	}
+	return filesizeUnits(limit) + suffix
+}
+
 // parseFilesize converts strings of form 10GB to a size in bytes. Fractional
 // sizes are truncated at the byte size.
 func parseFilesize(strSize string) (string, error) {
@@ -201,3 +211,40 @@ func parseTxn(s string) (types.Transaction, error) {
 	}
 	return txn, nil
 }
+
+// parseJSON decodes JSON from s, or from the file at path s, into v.
+func parseJSON(s string, v interface{}) error {
+	data := []byte(s)
+	if _, err := os.Stat(s); err == nil {
+		data, err = ioutil.ReadFile(s)
+		if err != nil {
+			return errors.New("could not read file: " + err.Error())
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// parsePSTx decodes a partially signed transaction from s, which can be the
+// encoded string, JSON, or a path to a file containing either encoding.
+func parsePSTx(s string) (types.PartialTransaction, error) {
+	// encoded partially signed transactions are often too long to be file
+	// names, so s is only treated as a file if it exists
+	ptBytes := []byte(s)
+	if _, err := os.Stat(s); err == nil {
+		ptBytes, err = ioutil.ReadFile(s)
+		if err != nil {
+			return types.PartialTransaction{}, errors.New("could not read partially signed transaction file: " + err.Error())
+		}
+	}
+	var pt types.PartialTransaction
+	if json.Valid(ptBytes) {
+		if err := json.Unmarshal(ptBytes, &pt); err != nil {
+			return types.PartialTransaction{}, errors.New("could not decode JSON partially signed transaction: " + err.Error())
+		}
+		return pt, nil
+	}
+	if err := pt.LoadString(strings.TrimSpace(string(ptBytes))); err != nil {
+		return types.PartialTransaction{}, errors.New("could not decode partially signed transaction: " + err.Error())
+	}
+	return pt, nil
+}
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"encoding/json"
 	"math/big"
 	"testing"
 
@@ -122,3 +123,28 @@ func TestCurrencyUnits(t *testing.T) {
 		}
 	}
 }
+
+// TestParsePSTx checks that parsePSTx decodes both the string and the JSON
+// encoding of a partially signed transaction.
+func TestParsePSTx(t *testing.T) {
+	pt := types.NewPartialTransaction(types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: types.SiacoinOutputID{1}}},
+	})
+	pt.Inputs[0].Value = types.NewCurrency64(10)
+	js, err := json.Marshal(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{pt.String(), pt.String() + "\n", string(js)} {
+		decoded, err := parsePSTx(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if decoded.String() != pt.String() {
+			t.Fatal("decoded partially signed transaction does not match")
+		}
+	}
+	if _, err := parsePSTx("not a transaction"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
@@ -4,6 +4,8 @@ package main
 // not handle this very gracefully.
 
 import (
+	"encoding/csv"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -50,6 +52,27 @@ var (
 		Run:   wrap(rentercontractscmd),
 	}
 
+	renterContractsRecoverCmd = &cobra.Command{
+		Use:   "recover",
+		Short: "Recover contracts from the wallet seed",
+		Long: `Scan the blockchain for contracts that were formed using the wallet seed and
+recover the ones that are missing, e.g. because the contracts directory was
+lost. The most recent revisions and the sector roots of the contracts are
+requested from the hosts. Scanning the blockchain can take a while, the
+progress is shown until the recovery is done.`,
+		Run: wrap(rentercontractsrecovercmd),
+	}
+
+	renterContractsReportCmd = &cobra.Command{
+		Use:   "report",
+		Short: "View the spending per host and period",
+		Long: `View the spending of the Renter broken down by host and period, including
+storage, upload and download spending, fees and the funds that are returned
+when the contracts end. Use --format csv or --format json to export the report.
+Amounts in exported reports are denominated in hastings.`,
+		Run: wrap(rentercontractsreportcmd),
+	}
+
 	renterContractsViewCmd = &cobra.Command{
 		Use:   "view [contract-id]",
 		Short: "View details of the specified contract",
@@ -131,6 +154,11 @@ Allowance can be automatically renewed periodically. If the current
 blockheight + the renew window >= the end height the contract,
 then the contract is renewed automatically.
 
+The expected usage of the renter can be provided with the --expected-storage,
+--expected-upload, --expected-download and --expected-redundancy flags. It is
+used to determine how much money is put into each contract. Upload and download
+are given per month. If no usage is provided, a default usage is assumed.
+
 Note that setting the allowance will cause siad to immediately begin forming
 contracts! You should only set the allowance once you are fully synced and you
 have a reasonable number (>30) of hosts in your hostdb.`,
@@ -274,6 +302,19 @@ func renterallowancecmd() {
 	Hosts:        %v
 `, currencyUnits(allowance.Funds), allowance.Period, allowance.RenewWindow, allowance.Hosts)
 
+	// Show the expected usage next to the observed usage.
+	u := rg.Usage
+	fmt.Printf(`
+Usage:                  Expected         Observed
+  Storage:              %-16v %v
+  Upload (per month):   %-16v %v
+  Download (per month): %-16v %v
+  Redundancy:           %v
+`, filesizeUnits(int64(u.ExpectedStorage)), filesizeUnits(int64(u.ObservedStorage)),
+		filesizeUnits(int64(u.ExpectedUpload*4320)), filesizeUnits(int64(u.ObservedUpload*4320)),
+		filesizeUnits(int64(u.ExpectedDownload*4320)), filesizeUnits(int64(u.ObservedDownload*4320)),
+		allowance.ExpectedRedundancy)
+
 	// Show spending detail
 	fm := rg.FinancialMetrics
 	totalSpent := fm.ContractFees.Add(fm.UploadSpending).
@@ -392,6 +433,44 @@ func rentersetallowancecmd(cmd *cobra.Command, args []string) {
 			die("Could not parse renew window:", err)
 		}
 	}
+	if renterExpectedStorage != "" {
+		storage, err := parseFilesize(renterExpectedStorage)
+		if err != nil {
+			die("Could not parse expected storage:", err)
+		}
+		_, err = fmt.Sscan(storage, &allowance.ExpectedStorage)
+		if err != nil {
+			die("Could not parse expected storage:", err)
+		}
+	}
+	if renterExpectedUpload != "" {
+		upload, err := parseFilesize(renterExpectedUpload)
+		if err != nil {
+			die("Could not parse expected upload:", err)
+		}
+		_, err = fmt.Sscan(upload, &allowance.ExpectedUpload)
+		if err != nil {
+			die("Could not parse expected upload:", err)
+		}
+		allowance.ExpectedUpload /= 4320 // blocks per month
+	}
+	if renterExpectedDownload != "" {
+		download, err := parseFilesize(renterExpectedDownload)
+		if err != nil {
+			die("Could not parse expected download:", err)
+		}
+		_, err = fmt.Sscan(download, &allowance.ExpectedDownload)
+		if err != nil {
+			die("Could not parse expected download:", err)
+		}
+		allowance.ExpectedDownload /= 4320 // blocks per month
+	}
+	if renterExpectedRedundancy != "" {
+		_, err = fmt.Sscan(renterExpectedRedundancy, &allowance.ExpectedRedundancy)
+		if err != nil {
+			die("Could not parse expected redundancy:", err)
+		}
+	}
 	err = httpClient.RenterPostAllowance(allowance)
 	if err != nil {
 		die("Could not set allowance:", err)
@@ -557,6 +636,108 @@ func rentercontractscmd() {
 	}
 }
 
+// rentercontractsrecovercmd is the handler for the command `siac renter
+// contracts recover`. It recovers contracts that were formed using the wallet
+// seed, and prints the progress of the recovery until it is done.
+func rentercontractsrecovercmd() {
+	err := httpClient.RenterContractsRecoverPost()
+	if err != nil {
+		die("Could not recover contracts:", err)
+	}
+	cg, err := httpClient.ConsensusGet()
+	if err != nil {
+		die("Could not get consensus height:", err)
+	}
+	for range time.Tick(OutputRefreshRate) {
+		rcr, err := httpClient.RenterContractsRecoverGet()
+		if err != nil {
+			continue // benign
+		}
+		if !rcr.Active {
+			if rcr.Error != "" {
+				fmt.Println()
+				die("Could not recover contracts:", rcr.Error)
+			}
+			fmt.Printf("\rRecovered %v of %v contracts.                    \n", rcr.Recovered, rcr.Found)
+			return
+		}
+		if rcr.ScannedHeight < cg.Height {
+			fmt.Printf("\rScanning blockchain... block %v of %v", rcr.ScannedHeight, cg.Height)
+		} else {
+			fmt.Printf("\rRecovering contracts... %v of %v     ", rcr.Recovered, rcr.Found)
+		}
+	}
+}
+
+// rentercontractsreportcmd is the handler for the command `siac renter
+// contracts report`. It prints the spending of the renter per host and period
+// as a table, as CSV or as JSON.
+func rentercontractsreportcmd() {
+	rcr, err := httpClient.RenterContractsReportGet()
+	if err != nil {
+		die("Could not get contract report:", err)
+	}
+
+	switch renterReportFormat {
+	case "json":
+		js, err := json.MarshalIndent(rcr.Report, "", "\t")
+		if err != nil {
+			die("Could not encode contract report:", err)
+		}
+		fmt.Println(string(js))
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write([]string{"host", "netaddress", "periodstart", "periodend", "contracts",
+			"storagespending", "uploadspending", "downloadspending", "fees", "returnedfunds", "totalcost"})
+		for _, r := range rcr.Report {
+			w.Write([]string{
+				r.HostPublicKey.String(),
+				string(r.NetAddress),
+				fmt.Sprint(r.PeriodStart),
+				fmt.Sprint(r.PeriodEnd),
+				fmt.Sprint(r.Contracts),
+				r.StorageSpending.String(),
+				r.UploadSpending.String(),
+				r.DownloadSpending.String(),
+				r.Fees.String(),
+				r.ReturnedFunds.String(),
+				r.TotalCost.String(),
+			})
+		}
+		w.Flush()
+		if err := w.Error(); err != nil {
+			die("Could not write contract report:", err)
+		}
+	case "table":
+		if len(rcr.Report) == 0 {
+			fmt.Println("No contracts.")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 2, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "Period\tHost\tContracts\tStorage\tUpload\tDownload\tFees\tReturned\tTotal Cost")
+		for _, r := range rcr.Report {
+			address := r.NetAddress
+			if address == "" {
+				address = "Host Removed"
+			}
+			fmt.Fprintf(w, "%v-%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\t%v\n",
+				r.PeriodStart,
+				r.PeriodEnd,
+				address,
+				r.Contracts,
+				currencyUnits(r.StorageSpending),
+				currencyUnits(r.UploadSpending),
+				currencyUnits(r.DownloadSpending),
+				currencyUnits(r.Fees),
+				currencyUnits(r.ReturnedFunds),
+				currencyUnits(r.TotalCost))
+		}
+		w.Flush()
+	default:
+		die("Unknown report format:", renterReportFormat, "(must be table, csv or json)")
+	}
+}
+
 // rentercontractsviewcmd is the handler for the command `siac renter contracts <id>`.
 // It lists details of a specific contract.
 func rentercontractsviewcmd(cid string) {
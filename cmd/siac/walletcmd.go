@@ -2,15 +2,18 @@ package main
 
 import (
 	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"math"
 	"math/big"
 	"os"
 	"strconv"
 	"strings"
 	"syscall"
+	"text/tabwriter"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -54,6 +57,16 @@ be valid. txn may be either JSON, base64, or a file containing either.`,
 		Run: wrap(walletbroadcastcmd),
 	}
 
+	walletBumpCmd = &cobra.Command{
+		Use:   "bump [txid]",
+		Short: "Bump the fee of an unconfirmed transaction",
+		Long: `Raise the fee of an unconfirmed wallet transaction that is stuck because its fee
+is too low. A child transaction spends an output of the transaction, or of its
+unconfirmed parents, back to the wallet and pays the fee for the whole set
+(child-pays-for-parent).`,
+		Run: wrap(walletbumpcmd),
+	}
+
 	walletChangepasswordCmd = &cobra.Command{
 		Use:   "change-password",
 		Short: "Change the wallet password",
@@ -80,6 +93,20 @@ The smallest unit of siacoins is the hasting. One siacoin is 10^24 hastings. Oth
 		Run: wrap(walletbalancecmd),
 	}
 
+	walletExportCmd = &cobra.Command{
+		Use:   "export [destination]",
+		Short: "Export the transaction history",
+		Long: `Export the confirmed transactions of the wallet to a CSV or JSON file that tax
+and bookkeeping tools can import. Every transaction has its net value, the fees
+paid by the wallet, the addresses of the counterparties, its categories, its
+note and its confirmation height and time. Siacoin amounts are in SC in CSV
+files and in hastings in JSON files.
+
+The range of the export can be limited by block height and by date. Dates are
+in the format YYYY-MM-DD in local time, and the end date is inclusive.`,
+		Run: wrap(walletexportcmd),
+	}
+
 	walletInitCmd = &cobra.Command{
 		Use:   "init",
 		Short: "Initialize and encrypt a new wallet",
@@ -95,6 +122,32 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletinitseedcmd),
 	}
 
+	walletInitWatchOnlyCmd = &cobra.Command{
+		Use:   "init-watchonly",
+		Short: "Initialize and encrypt a new watch-only wallet",
+		Long: `Initialize a watch-only wallet, which has no seed and never holds secret keys.
+It tracks the keys of a cold wallet that are imported with 'wallet watchonly
+import', and builds unsigned transactions for the cold wallet to sign. The
+wallet must be encrypted with a password.`,
+		Run: wrap(walletinitwatchonlycmd),
+	}
+
+	walletLabelCmd = &cobra.Command{
+		Use:   "label [address] [label]",
+		Short: "Label an address",
+		Long: `Label an address, e.g. with the name of the customer that it belongs to. The
+address doesn't have to belong to the wallet. An empty label removes the label.
+Transactions can be filtered by label with 'siac wallet transactions --label'.`,
+		Run: wrap(walletlabelcmd),
+	}
+
+	walletLabelsCmd = &cobra.Command{
+		Use:   "labels",
+		Short: "List the labeled addresses",
+		Long:  "List the labeled addresses and their labels.",
+		Run:   wrap(walletlabelscmd),
+	}
+
 	walletLoad033xCmd = &cobra.Command{
 		Use:   "033x [filepath]",
 		Short: "Load a v0.3.3.x wallet",
@@ -131,6 +184,208 @@ By default the wallet encryption / unlock password is the same as the generated
 		Run:   wrap(walletlockcmd),
 	}
 
+	walletMultisigCmd = &cobra.Command{
+		Use:   "multisig",
+		Short: "View multisig addresses",
+		Long:  "View the multisig addresses that the wallet tracks, and their balances.",
+		Run:   wrap(walletmultisigcmd),
+	}
+
+	walletMultisigCreateCmd = &cobra.Command{
+		Use:   "create [required] [publickey,...]",
+		Short: "Create a multisig address",
+		Long: `Create an address that requires 'required' signatures from the comma-separated
+list of public keys, and start tracking it. At least one of the keys must belong
+to the wallet; run 'wallet multisig key' to get one. All co-signers must create
+the address from the same keys in the same order.`,
+		Example: "siac wallet multisig create 2 ed25519:1234...,ed25519:5678...,ed25519:9abc...",
+		Run:     wrap(walletmultisigcreatecmd),
+	}
+
+	walletMultisigKeyCmd = &cobra.Command{
+		Use:   "key",
+		Short: "Get a public key for a multisig address",
+		Long:  "Generate a new public key of the wallet that can be shared with co-signers to create a multisig address.",
+		Run:   wrap(walletmultisigkeycmd),
+	}
+
+	walletMultisigMergeCmd = &cobra.Command{
+		Use:   "merge [txn] [txn...]",
+		Short: "Merge the signatures of a multisig transaction",
+		Long: `Combine the signatures of copies of the same transaction that were signed by
+different co-signers. Each txn may be either JSON, base64, or a file containing
+either.`,
+		Run: walletmultisigmergecmd,
+	}
+
+	walletMultisigSendCmd = &cobra.Command{
+		Use:   "send [address] [amount] [dest] [fee]",
+		Short: "Send siacoins from a multisig address",
+		Long: `Create a transaction that sends siacoins from a multisig address to 'dest', and
+sign it with the keys of the wallet. The change is returned to the multisig
+address. 'amount' and 'fee' can be specified in units, e.g. 1.23KS.
+
+The transaction is printed so that it can be passed to the co-signers, who sign
+it with 'wallet multisig sign'. Once enough signatures have been added, the
+transaction can be sent with 'wallet broadcast'.`,
+		Run: wrap(walletmultisigsendcmd),
+	}
+
+	walletMultisigSignCmd = &cobra.Command{
+		Use:   "sign [txn]",
+		Short: "Co-sign a multisig transaction",
+		Long: `Add the signatures of the wallet to the inputs of a transaction that spend from
+multisig addresses. txn may be either JSON, base64, or a file containing either.`,
+		Run: wrap(walletmultisigsigncmd),
+	}
+
+	walletNoteCmd = &cobra.Command{
+		Use:   "note [txid] [note]",
+		Short: "Add a note to a transaction",
+		Long:  "Add a note to a confirmed or unconfirmed transaction of the wallet. An empty note removes the note.",
+		Run:   wrap(walletnotecmd),
+	}
+
+	walletPaymentRequestsCmd = &cobra.Command{
+		Use:   "requests",
+		Short: "View payment requests",
+		Long: `View the payment requests of the wallet. A payment request asks for an amount
+to a dedicated address of the wallet, and moves from 'unpaid' to
+'partially paid', 'paid' and 'confirmed' as payments arrive and confirm.
+Requests that are not paid before their expiry height expire, but late
+payments to them are still tracked.`,
+		Run: wrap(walletpaymentrequestscmd),
+	}
+
+	walletPaymentRequestsCreateCmd = &cobra.Command{
+		Use:   "create [amount]",
+		Short: "Create a payment request",
+		Long: `Create a payment request for 'amount' to a new address of the wallet.
+'amount' can be specified in units, e.g. 1.23KS. If --webhook is set, every
+status change and late payment of the request is posted to the URL as JSON, in
+order.`,
+		Example: "siac wallet requests create 100SC --memo \"invoice 42\" --webhook https://example.com/paid",
+		Run:     wrap(walletpaymentrequestscreatecmd),
+	}
+
+	walletPaymentRequestsEventsCmd = &cobra.Command{
+		Use:   "events",
+		Short: "View the status changes of payment requests",
+		Long: `View the recent status changes and late payments of the payment requests.
+Each event has a sequence number; pass the last seen number with --since to
+only view newer events.`,
+		Run: wrap(walletpaymentrequestseventscmd),
+	}
+
+	walletScheduleCmd = &cobra.Command{
+		Use:   "schedule",
+		Short: "View scheduled payments",
+		Long: `View the scheduled and recurring payments of the wallet. Payments are made
+when they are due and the wallet is unlocked. Payments above the approval
+threshold, or above the daily limit, are held until they are approved.`,
+		Run: wrap(walletschedulecmd),
+	}
+
+	walletScheduleAddCmd = &cobra.Command{
+		Use:   "add [amount] [dest]",
+		Short: "Schedule a payment",
+		Long: `Schedule a payment of 'amount' to 'dest'. The payment is made at the block
+height given with --height, or with the first block after the date given with
+--date. With --every, the payment is repeated at the interval, e.g. 144b or
+30d; --count limits the number of payments.`,
+		Example: "siac wallet schedule add 1KS <address> --date 2019-01-01 --every 30d --memo hosting",
+		Run:     wrap(walletscheduleaddcmd),
+	}
+
+	walletScheduleApproveCmd = &cobra.Command{
+		Use:   "approve [id]",
+		Short: "Approve a held scheduled payment",
+		Long:  "Make a scheduled payment that is held for approval.",
+		Run:   wrap(walletscheduleapprovecmd),
+	}
+
+	walletScheduleCancelCmd = &cobra.Command{
+		Use:   "cancel [id]",
+		Short: "Cancel a scheduled payment",
+		Long:  "Cancel a scheduled payment. Payments that were already made are not affected.",
+		Run:   wrap(walletschedulecancelcmd),
+	}
+
+	walletScheduleLimitsCmd = &cobra.Command{
+		Use:   "limits",
+		Short: "View or set the limits of scheduled payments",
+		Long: `View or set the limits of scheduled payments. Payments above the approval
+threshold, or that would bring the scheduled payments of the last 24 hours
+above the daily limit, are held until they are approved. A limit of 0 means no
+limit.`,
+		Example: "siac wallet schedule limits --approval-threshold 10KS --daily-limit 50KS",
+		Run:     wrap(walletschedulelimitscmd),
+	}
+
+	walletScheduleLogCmd = &cobra.Command{
+		Use:   "log",
+		Short: "View the recent scheduled payments",
+		Long: `View the recent attempts to make scheduled payments, with the IDs of their
+transactions or the reason that they failed. Pass the last seen sequence
+number with --since to only view newer attempts.`,
+		Run: wrap(walletschedulelogcmd),
+	}
+
+	walletPSTxCmd = &cobra.Command{
+		Use:   "pstx",
+		Short: "Create, sign and finalize partially signed transactions",
+		Long: `Create, sign and finalize partially signed transactions. A partially signed
+transaction carries the transaction together with its parents, the values of the
+spent outputs and hints about which keys are expected to sign each input, so
+that offline and multi-party signers can check what they sign.
+
+Wherever a pstx argument is expected, it may be either the encoded string, JSON,
+or a file containing either.`,
+		// Run field is not set, as the pstx command itself is not a valid command.
+		// A subcommand must be provided.
+	}
+
+	walletPSTxCombineCmd = &cobra.Command{
+		Use:   "combine [pstx] [pstx...]",
+		Short: "Combine partially signed transactions",
+		Long:  "Combine copies of the same partially signed transaction that were signed by different parties.",
+		Run:   walletpstxcombinecmd,
+	}
+
+	walletPSTxCreateCmd = &cobra.Command{
+		Use:   "create [txn] [parent...]",
+		Short: "Create a partially signed transaction",
+		Long: `Create a partially signed transaction from an unsigned transaction and its
+parents. The wallet fills in the values and key hints of the inputs it knows.
+txn and the parents may be either JSON, base64, or a file containing either.`,
+		Run: walletpstxcreatecmd,
+	}
+
+	walletPSTxFinalizeCmd = &cobra.Command{
+		Use:   "finalize [pstx]",
+		Short: "Finalize a partially signed transaction",
+		Long: `Check that a partially signed transaction is fully signed and valid, and print
+the transaction set that consists of its parents and the transaction.`,
+		Run: wrap(walletpstxfinalizecmd),
+	}
+
+	walletPSTxSignCmd = &cobra.Command{
+		Use:   "sign [pstx]",
+		Short: "Sign a partially signed transaction",
+		Long: `Sign every input of a partially signed transaction that the wallet holds keys
+for. The wallet refuses to sign if the value of one of its inputs doesn't match
+the output that it spends.`,
+		Run: wrap(walletpstxsigncmd),
+	}
+
+	walletPSTxViewCmd = &cobra.Command{
+		Use:   "view [pstx]",
+		Short: "View a partially signed transaction",
+		Long: `View the inputs, outputs and missing signatures of a partially signed
+transaction. This command does not need siad.`,
+		Run: wrap(walletpstxviewcmd),
+	}
+
 	walletSeedsCmd = &cobra.Command{
 		Use:   "seeds",
 		Short: "View information about your seeds",
@@ -153,7 +408,13 @@ By default the wallet encryption / unlock password is the same as the generated
 'amount' can be specified in units, e.g. 1.23KS. Run 'wallet --help' for a list of units.
 If no unit is supplied, hastings will be assumed.
 
-A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.`,
+A dynamic transaction fee is applied depending on the size of the transaction and how busy the network is.
+
+Use --inputs to fund the transaction only from the given outputs, e.g. to keep
+the funds of different addresses apart. The /wallet/unspent API lists the
+outputs of the wallet. The outputs are spent largest first until they cover the amount
+and fee, or all of them with --spend-all. The change goes to --change, or to a
+new address of the wallet.`,
 		Run: wrap(walletsendsiacoinscmd),
 	}
 
@@ -180,6 +441,86 @@ provided, the wallet will fill in every TransactionSignature it has keys for.`,
 		Run: walletsigncmd,
 	}
 
+	walletSwapCmd = &cobra.Command{
+		Use:   "swap",
+		Short: "View atomic swaps",
+		Long: `View the atomic swaps of the wallet. In an atomic swap, the sender locks
+siacoins in an output that needs the signatures of both parties. The receiver
+claims the siacoins with a signature that reveals its secret to the sender, so
+that the sender can claim the other side of the swap, e.g. on another chain. If
+the receiver doesn't claim the siacoins before the refund height, the wallet of
+the sender refunds them automatically.
+
+A swap is negotiated in the following steps:
+  1. The receiver creates a request with 'siac wallet swap request'.
+  2. The sender answers with an offer from 'siac wallet swap offer'.
+  3. The receiver signs the refund with 'siac wallet swap accept'.
+  4. The sender funds the swap with 'siac wallet swap fund'.
+  5. Once the swap is funded, the sender presigns the claim with
+     'siac wallet swap presign'.
+  6. The receiver claims the siacoins with 'siac wallet swap claim'.
+
+Wherever a request or offer argument is expected, it may be either JSON or a
+file containing JSON.`,
+		Run: wrap(walletswapcmd),
+	}
+
+	walletSwapAcceptCmd = &cobra.Command{
+		Use:   "accept [offer]",
+		Short: "Accept an atomic swap offer",
+		Long: `Accept the offer of a sender to a request of the wallet. Prints the signature
+of the refund transaction, which the sender needs to fund the swap.`,
+		Run: wrap(walletswapacceptcmd),
+	}
+
+	walletSwapClaimCmd = &cobra.Command{
+		Use:   "claim [id] [adaptorsignature]",
+		Short: "Claim the siacoins of an atomic swap",
+		Long: `Complete the presigned claim of the sender and broadcast it. This reveals the
+secret of the swap to the sender.`,
+		Run: wrap(walletswapclaimcmd),
+	}
+
+	walletSwapFundCmd = &cobra.Command{
+		Use:   "fund [id] [refundsignature]",
+		Short: "Fund an atomic swap",
+		Long: `Add the refund signature of the receiver to an offer of the wallet, and
+broadcast the transactions that fund the swap.`,
+		Run: wrap(walletswapfundcmd),
+	}
+
+	walletSwapOfferCmd = &cobra.Command{
+		Use:   "offer [request] [amount] [timeout]",
+		Short: "Offer to send siacoins in an atomic swap",
+		Long: `Offer to send 'amount' to the receiver of a request. The siacoins can be
+refunded after 'timeout', e.g. 144b or 2d. The receiver has to claim them before
+then.`,
+		Run: wrap(walletswapoffercmd),
+	}
+
+	walletSwapPresignCmd = &cobra.Command{
+		Use:   "presign [id]",
+		Short: "Presign the claim of an atomic swap",
+		Long: `Print the adaptor signature of the claim of a funded swap. The receiver can
+only complete it with its secret, which the wallet learns once the claim is
+confirmed.`,
+		Run: wrap(walletswappresigncmd),
+	}
+
+	walletSwapRequestCmd = &cobra.Command{
+		Use:   "request",
+		Short: "Request siacoins in an atomic swap",
+		Long:  `Create a request to receive siacoins in an atomic swap, which is sent to the sender.`,
+		Run:   wrap(walletswaprequestcmd),
+	}
+
+	walletSwapViewCmd = &cobra.Command{
+		Use:   "view [id]",
+		Short: "View an atomic swap",
+		Long:  `View the details of an atomic swap, including its secret once it is known.`,
+		Run:   wrap(walletswapviewcmd),
+	}
+
 	walletSweepCmd = &cobra.Command{
 		Use:   "sweep",
 		Short: "Sweep siacoins and siafunds from a seed.",
@@ -191,8 +532,12 @@ will be sent to your wallet.`,
 	walletTransactionsCmd = &cobra.Command{
 		Use:   "transactions",
 		Short: "View transactions",
-		Long:  "View transactions related to addresses spendable by the wallet, providing a net flow of siacoins and siafunds for each transaction",
-		Run:   wrap(wallettransactionscmd),
+		Long: `View transactions related to addresses spendable by the wallet, providing a net flow of siacoins and siafunds for each transaction.
+
+The transactions can be filtered by category, which is one of 'contract
+formation', 'contract revision', 'fee bump', 'miner payout', 'siafund claim'
+and 'storage proof', and by the label of their addresses.`,
+		Run: wrap(wallettransactionscmd),
 	}
 
 	walletUnlockCmd = &cobra.Command{
@@ -204,6 +549,48 @@ SIA_WALLET_PASSWORD environment variable is set, the unlock command will
 use it instead of displaying the typical interactive prompt.`,
 		Run: wrap(walletunlockcmd),
 	}
+
+	walletWatchOnlyCmd = &cobra.Command{
+		Use:   "watchonly",
+		Short: "View the watch-only keys of the wallet",
+		Long: `View the keys that the wallet watches on behalf of a cold wallet, and their
+balance. A watch-only wallet tracks the keys exported by the cold wallet and
+builds unsigned transactions for it, without ever holding its secret keys. The
+outputs of the keys are not part of the regular balance of the wallet.`,
+		Run: wrap(walletwatchonlycmd),
+	}
+
+	walletWatchOnlyExportCmd = &cobra.Command{
+		Use:   "export [destination]",
+		Short: "Export public keys for a watch-only wallet",
+		Long: `Export public keys of the primary seed to a file, to be imported into a
+watch-only wallet with 'wallet watchonly import'. Run this on the cold wallet.
+Run 'wallet watchonly' on the watch-only wallet to see which keys it needs.`,
+		Example: "siac wallet watchonly export --start 0 --count 5000 keys.json",
+		Run:     wrap(walletwatchonlyexportcmd),
+	}
+
+	walletWatchOnlyImportCmd = &cobra.Command{
+		Use:   "import [source]",
+		Short: "Import public keys exported by a cold wallet",
+		Long: `Import public keys that were exported by a cold wallet with
+'wallet watchonly export', and start tracking them. Unless --unused is set, the
+wallet rescans the blockchain to find the outputs of the keys.`,
+		Run: wrap(walletwatchonlyimportcmd),
+	}
+
+	walletWatchOnlySendCmd = &cobra.Command{
+		Use:   "send [amount] [dest] [fee]",
+		Short: "Create an unsigned transaction from the watch-only keys",
+		Long: `Create a transaction that sends siacoins from the watch-only keys to 'dest'.
+The change is returned to the next watch-only key. 'amount' and 'fee' can be
+specified in units, e.g. 1.23KS.
+
+The transaction is printed as a partially signed transaction. Sign it on the
+cold wallet with 'wallet pstx sign', then broadcast it with
+'wallet pstx finalize --broadcast'.`,
+		Run: wrap(walletwatchonlysendcmd),
+	}
 )
 
 const askPasswordText = "We need to encrypt the new data using the current wallet password, please provide: "
@@ -299,6 +686,20 @@ func walletinitcmd() {
 	}
 }
 
+// walletinitwatchonlycmd initializes a watch-only wallet.
+func walletinitwatchonlycmd() {
+	password, err := passwordPrompt("Wallet password: ")
+	if err != nil {
+		die("Reading password failed:", err)
+	} else if err = confirmPassword(password); err != nil {
+		die(err)
+	}
+	if err := httpClient.WalletInitWatchOnlyPost(password, initForce); err != nil {
+		die("Could not initialize watch-only wallet:", err)
+	}
+	fmt.Println("Watch-only wallet encrypted with given password")
+}
+
 // walletinitseedcmd initializes the wallet from a preexisting seed.
 func walletinitseedcmd() {
 	seed, err := passwordPrompt("Seed: ")
@@ -368,6 +769,89 @@ func walletloadsiagcmd(keyfiles string) {
 	fmt.Println("Wallet loading successful.")
 }
 
+// walletexportcmd exports the transaction history of the wallet.
+func walletexportcmd(destination string) {
+	var startTime, endTime types.Timestamp
+	if walletExportStartDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", walletExportStartDate, time.Local)
+		if err != nil {
+			die("Could not parse start date:", err)
+		}
+		startTime = types.Timestamp(t.Unix())
+	}
+	if walletExportEndDate != "" {
+		t, err := time.ParseInLocation("2006-01-02", walletExportEndDate, time.Local)
+		if err != nil {
+			die("Could not parse end date:", err)
+		}
+		endTime = types.Timestamp(t.AddDate(0, 0, 1).Unix() - 1)
+	}
+	startHeight, endHeight := types.BlockHeight(walletExportStartHeight), types.BlockHeight(math.MaxInt64)
+	if walletExportEndHeight >= 0 {
+		endHeight = types.BlockHeight(walletExportEndHeight)
+	}
+
+	var data []byte
+	switch walletExportFormat {
+	case "csv":
+		var err error
+		data, err = httpClient.WalletExportCSVGet(startHeight, endHeight, startTime, endTime)
+		if err != nil {
+			die("Could not export transactions:", err)
+		}
+	case "json":
+		weg, err := httpClient.WalletExportGet(startHeight, endHeight, startTime, endTime)
+		if err != nil {
+			die("Could not export transactions:", err)
+		}
+		data, err = json.MarshalIndent(weg.Records, "", "  ")
+		if err != nil {
+			die("Could not export transactions:", err)
+		}
+	default:
+		die("Unknown export format:", walletExportFormat)
+	}
+	destination = abs(destination)
+	if err := ioutil.WriteFile(destination, data, 0600); err != nil {
+		die("Could not export to file:", err)
+	}
+	fmt.Println("Exported transaction history to", destination)
+}
+
+// walletlabelcmd sets the label of an address.
+func walletlabelcmd(addr, label string) {
+	var address types.UnlockHash
+	if err := address.LoadString(addr); err != nil {
+		die("Could not parse address:", err)
+	}
+	if err := httpClient.WalletLabelPost(address, label); err != nil {
+		die("Could not label address:", err)
+	}
+	if label == "" {
+		fmt.Println("Removed the label of", address)
+	} else {
+		fmt.Println("Labeled", address, "as", label)
+	}
+}
+
+// walletlabelscmd lists the labeled addresses.
+func walletlabelscmd() {
+	wlg, err := httpClient.WalletLabelsGet()
+	if err != nil {
+		die("Could not get labels:", err)
+	}
+	if len(wlg.Labels) == 0 {
+		fmt.Println("No labeled addresses.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tLabel")
+	for _, al := range wlg.Labels {
+		fmt.Fprintf(w, "%v\t%v\n", al.Address, al.Label)
+	}
+	w.Flush()
+}
+
 // walletlockcmd locks the wallet
 func walletlockcmd() {
 	err := httpClient.WalletLockPost()
@@ -376,6 +860,698 @@ func walletlockcmd() {
 	}
 }
 
+// printMultisigTxn prints a multisig transaction, and notes whether it has
+// enough signatures to be broadcast.
+func printMultisigTxn(mt modules.MultisigTransaction) {
+	if walletRawTxn {
+		base64.NewEncoder(base64.StdEncoding, os.Stdout).Write(encoding.Marshal(mt.Transaction))
+	} else {
+		json.NewEncoder(os.Stdout).Encode(mt.Transaction)
+	}
+	fmt.Println()
+	if mt.Complete {
+		fmt.Fprintln(os.Stderr, "The transaction is fully signed and can be broadcast.")
+	} else {
+		fmt.Fprintln(os.Stderr, "The transaction needs more signatures before it can be broadcast.")
+	}
+}
+
+// walletmultisigcmd lists the multisig addresses of the wallet.
+func walletmultisigcmd() {
+	wmag, err := httpClient.WalletMultisigAddressesGet()
+	if err != nil {
+		die("Could not get multisig addresses:", err)
+	}
+	if len(wmag.Addresses) == 0 {
+		fmt.Println("No multisig addresses.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tRequired\tLocal Keys\tSiacoins\tSiafunds")
+	for _, ma := range wmag.Addresses {
+		fmt.Fprintf(w, "%v\t%v of %v\t%v\t%v\t%v SF\n", ma.Address, ma.UnlockConditions.SignaturesRequired,
+			len(ma.UnlockConditions.PublicKeys), len(ma.LocalKeys), currencyUnits(ma.SiacoinBalance), ma.SiafundBalance)
+	}
+	w.Flush()
+}
+
+// walletmultisigcreatecmd creates a multisig address.
+func walletmultisigcreatecmd(required, keys string) {
+	n, err := strconv.ParseUint(required, 10, 64)
+	if err != nil {
+		die("Could not parse the number of required signatures:", err)
+	}
+	var publicKeys []types.SiaPublicKey
+	for _, s := range strings.Split(keys, ",") {
+		var spk types.SiaPublicKey
+		spk.LoadString(s)
+		if spk.Key == nil {
+			die("Could not parse public key", s)
+		}
+		publicKeys = append(publicKeys, spk)
+	}
+	ma, err := httpClient.WalletMultisigAddressesPost(n, publicKeys, walletMultisigUnused)
+	if err != nil {
+		die("Could not create multisig address:", err)
+	}
+	fmt.Printf("Created %v-of-%v multisig address %v\n", n, len(publicKeys), ma.Address)
+}
+
+// walletmultisigkeycmd prints a new public key of the wallet.
+func walletmultisigkeycmd() {
+	wmkg, err := httpClient.WalletMultisigKeyGet()
+	if err != nil {
+		die("Could not get multisig key:", err)
+	}
+	fmt.Println(wmkg.PublicKey)
+}
+
+// walletmultisigmergecmd merges the signatures of copies of a multisig
+// transaction.
+func walletmultisigmergecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var txns []types.Transaction
+	for _, arg := range args {
+		txn, err := parseTxn(arg)
+		if err != nil {
+			die("Could not decode transaction:", err)
+		}
+		txns = append(txns, txn)
+	}
+	mt, err := httpClient.WalletMultisigMergePost(txns)
+	if err != nil {
+		die("Could not merge transactions:", err)
+	}
+	printMultisigTxn(mt)
+}
+
+// walletmultisigsendcmd creates a transaction that sends siacoins from a
+// multisig address.
+func walletmultisigsendcmd(addr, amount, dest, fee string) {
+	var address, destination types.UnlockHash
+	if err := address.LoadString(addr); err != nil {
+		die("Failed to parse multisig address:", err)
+	}
+	if err := destination.LoadString(dest); err != nil {
+		die("Failed to parse destination address:", err)
+	}
+	var value, minerFee types.Currency
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	hastings, err = parseCurrency(fee)
+	if err != nil {
+		die("Could not parse fee:", err)
+	}
+	if _, err := fmt.Sscan(hastings, &minerFee); err != nil {
+		die("Failed to parse fee", err)
+	}
+	outputs := []types.SiacoinOutput{{Value: value, UnlockHash: destination}}
+	mt, err := httpClient.WalletMultisigTransactionPost(address, outputs, minerFee)
+	if err != nil {
+		die("Could not create transaction:", err)
+	}
+	printMultisigTxn(mt)
+}
+
+// walletmultisigsigncmd co-signs a multisig transaction.
+func walletmultisigsigncmd(txnStr string) {
+	txn, err := parseTxn(txnStr)
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	mt, err := httpClient.WalletMultisigSignPost(txn)
+	if err != nil {
+		die("Could not sign transaction:", err)
+	}
+	printMultisigTxn(mt)
+}
+
+// walletpaymentrequestscmd lists the payment requests of the wallet.
+func walletpaymentrequestscmd() {
+	wprg, err := httpClient.WalletPaymentRequestsGet()
+	if err != nil {
+		die("Could not get payment requests:", err)
+	}
+	if len(wprg.PaymentRequests) == 0 {
+		fmt.Println("No payment requests.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Address\tAmount\tReceived\tStatus\tExpiry Height\tMemo")
+	for _, pr := range wprg.PaymentRequests {
+		status := string(pr.Status)
+		if pr.Status == modules.PaymentRequestPaid && pr.Confirmations > 0 {
+			status = fmt.Sprintf("%v (%v of %v confirmations)", status, pr.Confirmations, pr.RequiredConfirmations)
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", pr.Address, currencyUnits(pr.Amount), currencyUnits(pr.Received),
+			status, pr.ExpiryHeight, pr.Memo)
+	}
+	w.Flush()
+}
+
+// walletpaymentrequestscreatecmd creates a payment request.
+func walletpaymentrequestscreatecmd(amount string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	pr, err := httpClient.WalletPaymentRequestsPost(value, types.BlockHeight(walletRequestExpiry), walletRequestConfirmations, walletRequestMemo, walletRequestWebhook)
+	if err != nil {
+		die("Could not create payment request:", err)
+	}
+	fmt.Printf("Created payment request for %v, expiring at height %v.\n", currencyUnits(pr.Amount), pr.ExpiryHeight)
+	fmt.Println("Payment address:", pr.Address)
+}
+
+// walletpaymentrequestseventscmd lists the recent status changes of the
+// payment requests.
+func walletpaymentrequestseventscmd() {
+	wpreg, err := httpClient.WalletPaymentRequestEventsGet(walletRequestSince)
+	if err != nil {
+		die("Could not get payment request events:", err)
+	}
+	if len(wpreg.Events) == 0 {
+		fmt.Println("No new payment request events.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Sequence\tTime\tAddress\tPrevious Status\tStatus\tReceived")
+	for _, event := range wpreg.Events {
+		pr := event.PaymentRequest
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", event.Sequence, time.Unix(int64(event.Timestamp), 0).Format("2006-01-02 15:04"),
+			pr.Address, event.PreviousStatus, pr.Status, currencyUnits(pr.Received))
+	}
+	w.Flush()
+}
+
+// walletschedulecmd lists the scheduled payments of the wallet.
+func walletschedulecmd() {
+	wspg, err := httpClient.WalletScheduledPaymentsGet()
+	if err != nil {
+		die("Could not get scheduled payments:", err)
+	}
+	if len(wspg.ScheduledPayments) == 0 {
+		fmt.Println("No scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAmount\tNext Payment\tInterval\tPayments\tStatus\tMemo")
+	for _, sp := range wspg.ScheduledPayments {
+		var amount types.Currency
+		for _, sco := range sp.Outputs {
+			amount = amount.Add(sco.Value)
+		}
+		next := fmt.Sprintf("height %v", sp.Schedule.Height)
+		interval := fmt.Sprintf("%v blocks", sp.Schedule.Interval)
+		if sp.Schedule.Time != 0 {
+			next = time.Unix(int64(sp.Schedule.Time), 0).Format("2006-01-02 15:04")
+			interval = (time.Duration(sp.Schedule.Interval) * time.Second).String()
+		}
+		if sp.Schedule.Interval == 0 {
+			interval = "once"
+		}
+		payments := fmt.Sprint(sp.Payments)
+		if sp.Schedule.Count != 0 {
+			payments = fmt.Sprintf("%v of %v", sp.Payments, sp.Schedule.Count)
+		}
+		status := string(sp.Status)
+		if sp.HoldReason != "" {
+			status = fmt.Sprintf("%v (%v)", status, sp.HoldReason)
+		}
+		if sp.Status == modules.ScheduledPaymentCompleted || sp.Status == modules.ScheduledPaymentCancelled {
+			next = "-"
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\t%v\n", sp.ID, currencyUnits(amount), next, interval, payments, status, sp.Memo)
+	}
+	w.Flush()
+}
+
+// walletscheduleaddcmd schedules a payment.
+func walletscheduleaddcmd(amount, dest string) {
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	var addr types.UnlockHash
+	if err := addr.LoadString(dest); err != nil {
+		die("Could not parse destination address:", err)
+	}
+
+	var schedule modules.PaymentSchedule
+	switch {
+	case walletScheduleHeight != 0 && walletScheduleDate != "":
+		die("Only one of --height and --date can be set")
+	case walletScheduleHeight != 0:
+		schedule.Height = types.BlockHeight(walletScheduleHeight)
+	case walletScheduleDate != "":
+		t, err := time.ParseInLocation("2006-01-02 15:04", walletScheduleDate, time.Local)
+		if err != nil {
+			t, err = time.ParseInLocation("2006-01-02", walletScheduleDate, time.Local)
+		}
+		if err != nil {
+			die("Could not parse date (YYYY-MM-DD or 'YYYY-MM-DD HH:MM'):", err)
+		}
+		schedule.Time = types.Timestamp(t.Unix())
+	default:
+		die("Either --height or --date must be set")
+	}
+	if walletScheduleEvery != "" {
+		blocks, err := parsePeriod(walletScheduleEvery)
+		if err != nil {
+			die("Could not parse interval:", err)
+		}
+		if _, err := fmt.Sscan(blocks, &schedule.Interval); err != nil {
+			die("Could not parse interval:", err)
+		}
+		// Intervals of time schedules are in seconds. parsePeriod counts
+		// ten-minute blocks, so days and weeks convert exactly.
+		if schedule.Time != 0 {
+			schedule.Interval *= 600
+		}
+	}
+	schedule.Count = walletScheduleCount
+
+	outputs := []types.SiacoinOutput{{Value: value, UnlockHash: addr}}
+	sp, err := httpClient.WalletScheduledPaymentsPost(outputs, schedule, walletScheduleMemo)
+	if err != nil {
+		die("Could not schedule payment:", err)
+	}
+	fmt.Printf("Scheduled payment %v of %v to %v.\n", sp.ID, currencyUnits(value), addr)
+}
+
+// walletscheduleapprovecmd makes a held scheduled payment.
+func walletscheduleapprovecmd(idStr string) {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		die("Could not parse id:", err)
+	}
+	execution, err := httpClient.WalletScheduledPaymentApprovePost(id)
+	if err != nil {
+		die("Could not approve scheduled payment:", err)
+	}
+	if execution.Error != "" {
+		die("Scheduled payment failed:", execution.Error)
+	}
+	fmt.Printf("Sent %v in transactions:\n", currencyUnits(execution.Amount))
+	for _, txid := range execution.TransactionIDs {
+		fmt.Println("  ", txid)
+	}
+}
+
+// walletschedulecancelcmd cancels a scheduled payment.
+func walletschedulecancelcmd(idStr string) {
+	id, err := strconv.ParseUint(idStr, 10, 64)
+	if err != nil {
+		die("Could not parse id:", err)
+	}
+	if err := httpClient.WalletScheduledPaymentCancelPost(id); err != nil {
+		die("Could not cancel scheduled payment:", err)
+	}
+	fmt.Println("Cancelled scheduled payment", id)
+}
+
+// walletschedulelimitscmd views or sets the limits of the scheduled payments.
+func walletschedulelimitscmd() {
+	limits, err := httpClient.WalletScheduledPaymentLimitsGet()
+	if err != nil {
+		die("Could not get scheduled payment limits:", err)
+	}
+	if walletScheduleThreshold != "" || walletScheduleDailyLimit != "" {
+		for _, limit := range []struct {
+			value string
+			c     *types.Currency
+		}{
+			{walletScheduleThreshold, &limits.ApprovalThreshold},
+			{walletScheduleDailyLimit, &limits.DailyLimit},
+		} {
+			if limit.value == "" {
+				continue
+			}
+			hastings, err := parseCurrency(limit.value)
+			if err != nil {
+				die("Could not parse limit:", err)
+			}
+			if _, err := fmt.Sscan(hastings, limit.c); err != nil {
+				die("Could not parse limit:", err)
+			}
+		}
+		if err := httpClient.WalletScheduledPaymentLimitsPost(limits); err != nil {
+			die("Could not set scheduled payment limits:", err)
+		}
+	}
+	limitString := func(c types.Currency) string {
+		if c.IsZero() {
+			return "none"
+		}
+		return currencyUnits(c)
+	}
+	fmt.Printf(`Approval Threshold: %v
+Daily Limit:        %v
+`, limitString(limits.ApprovalThreshold), limitString(limits.DailyLimit))
+}
+
+// walletschedulelogcmd lists the recent attempts to make scheduled payments.
+func walletschedulelogcmd() {
+	wspeg, err := httpClient.WalletScheduledPaymentExecutionsGet(walletScheduleSince)
+	if err != nil {
+		die("Could not get scheduled payment log:", err)
+	}
+	if len(wspeg.Executions) == 0 {
+		fmt.Println("No new scheduled payments.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Sequence\tTime\tHeight\tPayment\tAmount\tResult")
+	for _, e := range wspeg.Executions {
+		result := e.Error
+		if result == "" {
+			ids := make([]string, len(e.TransactionIDs))
+			for i, txid := range e.TransactionIDs {
+				ids[i] = txid.String()
+			}
+			result = strings.Join(ids, ", ")
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\t%v\n", e.Sequence, time.Unix(int64(e.Timestamp), 0).Format("2006-01-02 15:04"),
+			e.Height, e.PaymentID, currencyUnits(e.Amount), result)
+	}
+	w.Flush()
+}
+
+// parseAtomicSwapID parses the id of an atomic swap.
+func parseAtomicSwapID(idStr string) crypto.Hash {
+	var id crypto.Hash
+	if err := id.LoadString(idStr); err != nil {
+		die("Could not parse id:", err)
+	}
+	return id
+}
+
+// printJSON prints v as indented JSON.
+func printJSON(v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		die("Could not encode JSON:", err)
+	}
+	fmt.Println(string(data))
+}
+
+// walletswapcmd lists the atomic swaps of the wallet.
+func walletswapcmd() {
+	wasg, err := httpClient.WalletAtomicSwapsGet()
+	if err != nil {
+		die("Could not get atomic swaps:", err)
+	}
+	if len(wasg.AtomicSwaps) == 0 {
+		fmt.Println("No atomic swaps.")
+		return
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tRole\tAmount\tRefund Height\tStatus")
+	for _, swap := range wasg.AtomicSwaps {
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v\t%v\n", swap.ID, swap.Role, currencyUnits(swap.Amount), swap.RefundHeight, swap.Status)
+	}
+	w.Flush()
+}
+
+// walletswapacceptcmd accepts an atomic swap offer.
+func walletswapacceptcmd(offerStr string) {
+	var offer modules.AtomicSwapOffer
+	if err := parseJSON(offerStr, &offer); err != nil {
+		die("Could not decode offer:", err)
+	}
+	wasap, err := httpClient.WalletAtomicSwapsAcceptPost(offer)
+	if err != nil {
+		die("Could not accept atomic swap:", err)
+	}
+	fmt.Println("Accepted atomic swap", offer.ID)
+	fmt.Println("Refund signature:", wasap.RefundSignature)
+}
+
+// walletswapclaimcmd claims the siacoins of an atomic swap.
+func walletswapclaimcmd(idStr, asigStr string) {
+	id := parseAtomicSwapID(idStr)
+	b, err := hex.DecodeString(asigStr)
+	if err != nil || len(b) != crypto.AdaptorSignatureSize {
+		die("Could not parse adaptor signature")
+	}
+	var asig crypto.AdaptorSignature
+	copy(asig[:], b)
+	wascp, err := httpClient.WalletAtomicSwapClaimPost(id, asig)
+	if err != nil {
+		die("Could not claim atomic swap:", err)
+	}
+	fmt.Println("Broadcast claim transaction", wascp.Transaction.ID())
+}
+
+// walletswapfundcmd funds an atomic swap.
+func walletswapfundcmd(idStr, refundSig string) {
+	id := parseAtomicSwapID(idStr)
+	if err := httpClient.WalletAtomicSwapFundPost(id, refundSig); err != nil {
+		die("Could not fund atomic swap:", err)
+	}
+	fmt.Println("Funded atomic swap", id)
+}
+
+// walletswapoffercmd offers to send siacoins in an atomic swap.
+func walletswapoffercmd(reqStr, amount, timeout string) {
+	var req modules.AtomicSwapRequest
+	if err := parseJSON(reqStr, &req); err != nil {
+		die("Could not decode request:", err)
+	}
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	var value types.Currency
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	blocks, err := parsePeriod(timeout)
+	if err != nil {
+		die("Could not parse timeout:", err)
+	}
+	var refundHeight types.BlockHeight
+	if _, err := fmt.Sscan(blocks, &refundHeight); err != nil {
+		die("Could not parse timeout:", err)
+	}
+	cg, err := httpClient.ConsensusGet()
+	if err != nil {
+		die("Could not get current height:", err)
+	}
+	offer, err := httpClient.WalletAtomicSwapsOfferPost(req, value, cg.Height+refundHeight)
+	if err != nil {
+		die("Could not offer atomic swap:", err)
+	}
+	printJSON(offer)
+}
+
+// walletswappresigncmd presigns the claim of an atomic swap.
+func walletswappresigncmd(idStr string) {
+	waspp, err := httpClient.WalletAtomicSwapPresignPost(parseAtomicSwapID(idStr))
+	if err != nil {
+		die("Could not presign atomic swap claim:", err)
+	}
+	fmt.Println("Adaptor signature:", waspp.AdaptorSignature)
+}
+
+// walletswaprequestcmd requests siacoins in an atomic swap.
+func walletswaprequestcmd() {
+	req, err := httpClient.WalletAtomicSwapsRequestPost()
+	if err != nil {
+		die("Could not request atomic swap:", err)
+	}
+	printJSON(req)
+}
+
+// walletswapviewcmd prints the details of an atomic swap.
+func walletswapviewcmd(idStr string) {
+	swap, err := httpClient.WalletAtomicSwapGet(parseAtomicSwapID(idStr))
+	if err != nil {
+		die("Could not get atomic swap:", err)
+	}
+	fmt.Printf(`ID:             %v
+Role:           %v
+Status:         %v
+Amount:         %v
+Fee:            %v
+Refund Height:  %v
+Claim Address:  %v
+Refund Address: %v
+Output:         %v
+`, swap.ID, swap.Role, swap.Status, currencyUnits(swap.Amount), currencyUnits(swap.Fee), swap.RefundHeight,
+		swap.ClaimAddress, swap.RefundAddress, swap.OutputID)
+	if swap.SpendTransactionID != (types.TransactionID{}) {
+		fmt.Println("Spent In:      ", swap.SpendTransactionID)
+	}
+	if swap.Secret != (crypto.AdaptorSecret{}) {
+		fmt.Println("Secret:        ", swap.Secret)
+	}
+}
+
+// printPSTx prints the encoded partially signed transaction, and notes how
+// many signatures are still missing.
+func printPSTx(pt types.PartialTransaction) {
+	fmt.Println(pt.String())
+	if needed := pt.SignaturesNeeded(); needed > 0 {
+		fmt.Fprintf(os.Stderr, "The transaction needs %v more signatures.\n", needed)
+	} else {
+		fmt.Fprintln(os.Stderr, "The transaction is fully signed and can be finalized.")
+	}
+}
+
+// walletpstxcombinecmd combines copies of a partially signed transaction.
+func walletpstxcombinecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 2 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	var pts []types.PartialTransaction
+	for _, arg := range args {
+		pt, err := parsePSTx(arg)
+		if err != nil {
+			die("Could not decode partially signed transaction:", err)
+		}
+		pts = append(pts, pt)
+	}
+	wpr, err := httpClient.WalletPSTxCombinePost(pts)
+	if err != nil {
+		die("Could not combine partially signed transactions:", err)
+	}
+	printPSTx(wpr.PartialTransaction)
+}
+
+// walletpstxcreatecmd creates a partially signed transaction.
+func walletpstxcreatecmd(cmd *cobra.Command, args []string) {
+	if len(args) < 1 {
+		cmd.UsageFunc()(cmd)
+		os.Exit(exitCodeUsage)
+	}
+	txn, err := parseTxn(args[0])
+	if err != nil {
+		die("Could not decode transaction:", err)
+	}
+	var parents []types.Transaction
+	for _, arg := range args[1:] {
+		parent, err := parseTxn(arg)
+		if err != nil {
+			die("Could not decode parent transaction:", err)
+		}
+		parents = append(parents, parent)
+	}
+	wpr, err := httpClient.WalletPSTxPost(txn, parents)
+	if err != nil {
+		die("Could not create partially signed transaction:", err)
+	}
+	printPSTx(wpr.PartialTransaction)
+}
+
+// walletpstxfinalizecmd finalizes a partially signed transaction.
+func walletpstxfinalizecmd(ptStr string) {
+	pt, err := parsePSTx(ptStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	wpfp, err := httpClient.WalletPSTxFinalizePost(pt, walletPSTxBroadcast)
+	if err != nil {
+		die("Could not finalize partially signed transaction:", err)
+	}
+	if walletPSTxBroadcast {
+		fmt.Println("Transaction has been broadcast successfully")
+		return
+	}
+	for _, txn := range wpfp.Transactions {
+		if walletRawTxn {
+			base64.NewEncoder(base64.StdEncoding, os.Stdout).Write(encoding.Marshal(txn))
+		} else {
+			json.NewEncoder(os.Stdout).Encode(txn)
+		}
+		fmt.Println()
+	}
+}
+
+// walletpstxsigncmd signs a partially signed transaction.
+func walletpstxsigncmd(ptStr string) {
+	pt, err := parsePSTx(ptStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	wpr, err := httpClient.WalletPSTxSignPost(pt)
+	if err != nil {
+		die("Could not sign partially signed transaction:", err)
+	}
+	printPSTx(wpr.PartialTransaction)
+}
+
+// walletpstxviewcmd prints a summary of a partially signed transaction.
+func walletpstxviewcmd(ptStr string) {
+	pt, err := parsePSTx(ptStr)
+	if err != nil {
+		die("Could not decode partially signed transaction:", err)
+	}
+	if err := pt.Validate(); err != nil {
+		die("Invalid partially signed transaction:", err)
+	}
+	txn := pt.Transaction
+	signatures := make(map[crypto.Hash]uint64)
+	for _, sig := range txn.TransactionSignatures {
+		signatures[sig.ParentID]++
+	}
+
+	fmt.Printf("Transaction:  %v\n", txn.ID())
+	fmt.Printf("Parents:      %v\n", len(pt.Parents))
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Input\tAddress\tValue\tSignatures\tKey Hints")
+	for _, input := range pt.Inputs {
+		value := "unknown"
+		if !input.Value.IsZero() {
+			value = input.Value.String()
+		}
+		var hints []string
+		for _, hint := range input.KeyHints {
+			hints = append(hints, fmt.Sprint(hint.PublicKeyIndex))
+		}
+		fmt.Fprintf(w, "%v\t%v\t%v\t%v of %v\t%v\n", input.ParentID, input.UnlockConditions.UnlockHash(), value,
+			signatures[input.ParentID], input.UnlockConditions.SignaturesRequired, strings.Join(hints, ","))
+	}
+	w.Flush()
+	fmt.Println()
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "Output\tValue")
+	for _, sco := range txn.SiacoinOutputs {
+		fmt.Fprintf(w, "%v\t%v\n", sco.UnlockHash, currencyUnits(sco.Value))
+	}
+	for _, sfo := range txn.SiafundOutputs {
+		fmt.Fprintf(w, "%v\t%v SF\n", sfo.UnlockHash, sfo.Value)
+	}
+	w.Flush()
+	fmt.Println()
+
+	var fees types.Currency
+	for _, fee := range txn.MinerFees {
+		fees = fees.Add(fee)
+	}
+	fmt.Printf("Known Inputs:        %v\n", currencyUnits(pt.SiacoinInputSum()))
+	fmt.Printf("Miner Fees:          %v\n", currencyUnits(fees))
+	fmt.Printf("Missing Signatures:  %v\n", pt.SignaturesNeeded())
+}
+
 // walletseedcmd returns the current seed {
 func walletseedscmd() {
 	seedInfo, err := httpClient.WalletSeedsGet()
@@ -413,7 +1589,28 @@ func walletsendsiacoinscmd(amount, dest string) {
 	if _, err := fmt.Sscan(dest, &hash); err != nil {
 		die("Failed to parse destination address", err)
 	}
-	_, err = httpClient.WalletSiacoinsPost(value, hash)
+	if walletSendInputs == "" {
+		if walletSendChange != "" || walletSendSpendAll {
+			die("--change and --spend-all can only be used together with --inputs")
+		}
+		_, err = httpClient.WalletSiacoinsPost(value, hash)
+	} else {
+		var selection modules.SiacoinSelection
+		for _, s := range strings.Split(walletSendInputs, ",") {
+			var id crypto.Hash
+			if err := id.LoadString(s); err != nil {
+				die("Could not parse output id", s)
+			}
+			selection.Outputs = append(selection.Outputs, types.SiacoinOutputID(id))
+		}
+		if walletSendChange != "" {
+			if _, err := fmt.Sscan(walletSendChange, &selection.ChangeAddress); err != nil {
+				die("Failed to parse change address", err)
+			}
+		}
+		selection.SpendAll = walletSendSpendAll
+		_, err = httpClient.WalletSiacoinsFromOutputsPost(value, hash, selection)
+	}
 	if err != nil {
 		die("Could not send siacoins:", err)
 	}
@@ -495,6 +1692,35 @@ func walletbroadcastcmd(txnStr string) {
 	fmt.Println("Transaction has been broadcast successfully")
 }
 
+// walletbumpcmd bumps the fee of an unconfirmed transaction.
+func walletbumpcmd(txidStr string) {
+	var txid types.TransactionID
+	if err := txid.UnmarshalJSON([]byte("\"" + txidStr + "\"")); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	wtbp, err := httpClient.WalletTransactionBumpPost(txid)
+	if err != nil {
+		die("Could not bump transaction fee:", err)
+	}
+	fmt.Println("Bumped the fee of", txid, "with transaction", wtbp.TransactionIDs[len(wtbp.TransactionIDs)-1])
+}
+
+// walletnotecmd sets the note of a transaction.
+func walletnotecmd(txidStr, note string) {
+	var txid types.TransactionID
+	if err := txid.UnmarshalJSON([]byte("\"" + txidStr + "\"")); err != nil {
+		die("Could not parse transaction id:", err)
+	}
+	if err := httpClient.WalletTransactionNotePost(txid, note); err != nil {
+		die("Could not set transaction note:", err)
+	}
+	if note == "" {
+		fmt.Println("Removed the note of", txid)
+	} else {
+		fmt.Println("Added note to", txid)
+	}
+}
+
 // walletsweepcmd sweeps coins and funds from a seed.
 func walletsweepcmd() {
 	seed, err := passwordPrompt("Seed: ")
@@ -588,7 +1814,7 @@ func walletsigncmdoffline(txn *types.Transaction, toSign []crypto.Hash) {
 // wallettransactionscmd lists all of the transactions related to the wallet,
 // providing a net flow of siacoins and siafunds for each.
 func wallettransactionscmd() {
-	wtg, err := httpClient.WalletTransactionsGet(0, math.MaxInt64)
+	wtg, err := httpClient.WalletTransactionsFilterGet(0, math.MaxInt64, modules.TransactionCategory(walletTxnCategory), walletTxnLabel)
 	if err != nil {
 		die("Could not fetch transaction history:", err)
 	}
@@ -644,6 +1870,9 @@ func wallettransactionscmd() {
 		} else {
 			fmt.Printf("-%14v SF\n", outgoingSiafunds.Sub(incomingSiafunds))
 		}
+		if txn.Note != "" {
+			fmt.Printf("%24v%v\n", "", txn.Note)
+		}
 	}
 }
 
@@ -671,3 +1900,88 @@ func walletunlockcmd() {
 		die("Could not unlock wallet:", err)
 	}
 }
+
+// walletwatchonlycmd prints the state of the watch-only keys of the wallet.
+func walletwatchonlycmd() {
+	status, err := httpClient.WalletWatchOnlyGet()
+	if err != nil {
+		die("Could not get watch-only status:", err)
+	}
+	fmt.Printf(`Keys:             %v
+Used Keys:        %v
+Lookahead:        %v
+Siacoin Balance:  %v
+Siafund Balance:  %v SF
+`, status.Keys, status.Progress, status.Lookahead, currencyUnits(status.SiacoinBalance), status.SiafundBalance)
+	if status.KeysNeeded > 0 {
+		fmt.Println()
+		fmt.Printf("The lookahead is missing %v keys. Export them on the cold wallet with\n", status.KeysNeeded)
+		fmt.Printf("  siac wallet watchonly export --start %v --count %v [destination]\n", status.Keys, status.KeysNeeded)
+	}
+}
+
+// walletwatchonlyexportcmd exports public keys of the primary seed to a file.
+func walletwatchonlyexportcmd(destination string) {
+	if walletWatchOnlyCount == 0 {
+		die("The number of keys to export has to be set with --count")
+	}
+	keys, err := httpClient.WalletWatchOnlyExportGet(walletWatchOnlyStart, walletWatchOnlyCount)
+	if err != nil {
+		die("Could not export watch-only keys:", err)
+	}
+	data, err := json.MarshalIndent(keys, "", "  ")
+	if err != nil {
+		die("Could not export watch-only keys:", err)
+	}
+	destination = abs(destination)
+	if err := ioutil.WriteFile(destination, data, 0600); err != nil {
+		die("Could not export to file:", err)
+	}
+	fmt.Printf("Exported %v keys starting at index %v to %v\n", len(keys.PublicKeys), keys.StartIndex, destination)
+}
+
+// walletwatchonlyimportcmd imports public keys exported by a cold wallet.
+func walletwatchonlyimportcmd(source string) {
+	data, err := ioutil.ReadFile(abs(source))
+	if err != nil {
+		die("Could not read keys:", err)
+	}
+	var keys modules.WatchOnlyKeySet
+	if err := json.Unmarshal(data, &keys); err != nil {
+		die("Could not decode keys:", err)
+	}
+	if err := httpClient.WalletWatchOnlyImportPost(keys, walletWatchOnlyUnused); err != nil {
+		die("Could not import watch-only keys:", err)
+	}
+	fmt.Printf("Imported %v keys starting at index %v\n", len(keys.PublicKeys), keys.StartIndex)
+}
+
+// walletwatchonlysendcmd creates an unsigned transaction that sends siacoins
+// from the watch-only keys.
+func walletwatchonlysendcmd(amount, dest, fee string) {
+	var destination types.UnlockHash
+	if err := destination.LoadString(dest); err != nil {
+		die("Failed to parse destination address:", err)
+	}
+	var value, minerFee types.Currency
+	hastings, err := parseCurrency(amount)
+	if err != nil {
+		die("Could not parse amount:", err)
+	}
+	if _, err := fmt.Sscan(hastings, &value); err != nil {
+		die("Failed to parse amount", err)
+	}
+	hastings, err = parseCurrency(fee)
+	if err != nil {
+		die("Could not parse fee:", err)
+	}
+	if _, err := fmt.Sscan(hastings, &minerFee); err != nil {
+		die("Failed to parse fee", err)
+	}
+	outputs := []types.SiacoinOutput{{Value: value, UnlockHash: destination}}
+	wpr, err := httpClient.WalletWatchOnlyTransactionPost(outputs, minerFee)
+	if err != nil {
+		die("Could not create transaction:", err)
+	}
+	printPSTx(wpr.PartialTransaction)
+}
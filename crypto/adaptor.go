@@ -0,0 +1,254 @@
+package crypto
+
+// adaptor.go implements adaptor signatures for Ed25519. An adaptor signature
+// is a signature that is encrypted with an adaptor point T = tG. Anyone can
+// verify that it becomes a valid Ed25519 signature once it is completed with
+// the secret t, and anyone who sees both the adaptor signature and the
+// completed signature learns t. This makes it possible to tie the revelation
+// of a secret to the publication of a signature, which consensus can't do
+// without a hash-lock opcode.
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+
+	"filippo.io/edwards25519"
+)
+
+const (
+	// AdaptorPointSize is the size of an adaptor point in bytes.
+	AdaptorPointSize = 32
+
+	// AdaptorSecretSize is the size of an adaptor secret in bytes.
+	AdaptorSecretSize = 32
+
+	// AdaptorSignatureSize is the size of an adaptor signature in bytes.
+	AdaptorSignatureSize = SignatureSize
+)
+
+var (
+	// ErrAdaptorMismatch is returned if a signature is not the completion of
+	// an adaptor signature.
+	ErrAdaptorMismatch = errors.New("signature does not complete the adaptor signature")
+
+	// ErrInvalidAdaptorPoint is returned if an adaptor point is not a valid
+	// curve point.
+	ErrInvalidAdaptorPoint = errors.New("invalid adaptor point")
+
+	// ErrInvalidAdaptorSignature is returned if an adaptor signature does not
+	// match the data, public key and adaptor point.
+	ErrInvalidAdaptorSignature = errors.New("invalid adaptor signature")
+)
+
+type (
+	// AdaptorPoint is the public point T = tG that an adaptor signature is
+	// encrypted with.
+	AdaptorPoint [AdaptorPointSize]byte
+
+	// AdaptorSecret is the secret scalar t that completes an adaptor
+	// signature.
+	AdaptorSecret [AdaptorSecretSize]byte
+
+	// AdaptorSignature is an Ed25519 signature (R, s') whose scalar is
+	// missing the adaptor secret. The nonce point R already includes the
+	// adaptor point, so that (R, s' + t) is a valid signature.
+	AdaptorSignature [AdaptorSignatureSize]byte
+)
+
+// challenge returns the Ed25519 challenge scalar of a signature with nonce
+// point R by the public key over the data.
+func challenge(r []byte, pk PublicKey, data Hash) *edwards25519.Scalar {
+	h := sha512.New()
+	h.Write(r)
+	h.Write(pk[:])
+	h.Write(data[:])
+	e, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+	return e
+}
+
+// GenerateAdaptorSecret derives an adaptor secret and its adaptor point from
+// the input entropy.
+func GenerateAdaptorSecret(entropy [EntropySize]byte) (t AdaptorSecret, T AdaptorPoint) {
+	h := sha512.Sum512(entropy[:])
+	s, _ := edwards25519.NewScalar().SetUniformBytes(h[:])
+	copy(t[:], s.Bytes())
+	copy(T[:], new(edwards25519.Point).ScalarBaseMult(s).Bytes())
+	return
+}
+
+// Validate checks that the adaptor point is a valid curve point that is not of
+// small order. The secret of a small-order point can't be learned from a
+// completed signature, and neither can a point that isn't on the curve be used
+// to create an adaptor signature.
+func (T AdaptorPoint) Validate() error {
+	p, err := new(edwards25519.Point).SetBytes(T[:])
+	if err != nil {
+		return ErrInvalidAdaptorPoint
+	}
+	if new(edwards25519.Point).MultByCofactor(p).Equal(edwards25519.NewIdentityPoint()) == 1 {
+		return ErrInvalidAdaptorPoint
+	}
+	return nil
+}
+
+// AdaptorSignHash creates an adaptor signature of the data with the secret
+// key, encrypted with the adaptor point. The nonce is derived
+// deterministically from the key, the adaptor point and the data.
+func AdaptorSignHash(data Hash, sk SecretKey, T AdaptorPoint) (asig AdaptorSignature, err error) {
+	tPoint, err := new(edwards25519.Point).SetBytes(T[:])
+	if err != nil {
+		return AdaptorSignature{}, ErrInvalidAdaptorPoint
+	}
+	// Expand the secret key the same way Ed25519 does.
+	digest := sha512.Sum512(sk[:EntropySize])
+	a, err := edwards25519.NewScalar().SetBytesWithClamping(digest[:32])
+	if err != nil {
+		return AdaptorSignature{}, err
+	}
+	h := sha512.New()
+	h.Write(digest[32:])
+	h.Write(T[:])
+	h.Write(data[:])
+	r, _ := edwards25519.NewScalar().SetUniformBytes(h.Sum(nil))
+
+	R := new(edwards25519.Point).ScalarBaseMult(r)
+	R.Add(R, tPoint)
+	e := challenge(R.Bytes(), sk.PublicKey(), data)
+	s := edwards25519.NewScalar().MultiplyAdd(e, a, r)
+	copy(asig[:32], R.Bytes())
+	copy(asig[32:], s.Bytes())
+	return asig, nil
+}
+
+// VerifyAdaptorHash checks that an adaptor signature of the data by the
+// public key becomes a valid signature when it is completed with the secret
+// of the adaptor point.
+func VerifyAdaptorHash(data Hash, pk PublicKey, T AdaptorPoint, asig AdaptorSignature) error {
+	A, err := new(edwards25519.Point).SetBytes(pk[:])
+	if err != nil {
+		return ErrInvalidAdaptorSignature
+	}
+	tPoint, err := new(edwards25519.Point).SetBytes(T[:])
+	if err != nil {
+		return ErrInvalidAdaptorPoint
+	}
+	R, err := new(edwards25519.Point).SetBytes(asig[:32])
+	if err != nil {
+		return ErrInvalidAdaptorSignature
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(asig[32:])
+	if err != nil {
+		return ErrInvalidAdaptorSignature
+	}
+	// s'G - eA must equal R - T.
+	e := challenge(asig[:32], pk, data)
+	lhs := new(edwards25519.Point).VarTimeDoubleScalarBaseMult(edwards25519.NewScalar().Negate(e), A, s)
+	rhs := new(edwards25519.Point).Subtract(R, tPoint)
+	if lhs.Equal(rhs) != 1 {
+		return ErrInvalidAdaptorSignature
+	}
+	return nil
+}
+
+// Complete adds the adaptor secret to the adaptor signature, which turns it
+// into an Ed25519 signature.
+func (asig AdaptorSignature) Complete(t AdaptorSecret) (sig Signature, err error) {
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(asig[32:])
+	if err != nil {
+		return Signature{}, ErrInvalidAdaptorSignature
+	}
+	tScalar, err := edwards25519.NewScalar().SetCanonicalBytes(t[:])
+	if err != nil {
+		return Signature{}, err
+	}
+	copy(sig[:32], asig[:32])
+	copy(sig[32:], s.Add(s, tScalar).Bytes())
+	return sig, nil
+}
+
+// RecoverSecret returns the adaptor secret that completed the adaptor
+// signature into the signature.
+func (asig AdaptorSignature) RecoverSecret(sig Signature, T AdaptorPoint) (t AdaptorSecret, err error) {
+	if !bytes.Equal(sig[:32], asig[:32]) {
+		return AdaptorSecret{}, ErrAdaptorMismatch
+	}
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(sig[32:])
+	if err != nil {
+		return AdaptorSecret{}, ErrAdaptorMismatch
+	}
+	sPrime, err := edwards25519.NewScalar().SetCanonicalBytes(asig[32:])
+	if err != nil {
+		return AdaptorSecret{}, ErrInvalidAdaptorSignature
+	}
+	tScalar := edwards25519.NewScalar().Subtract(s, sPrime)
+	if !bytes.Equal(new(edwards25519.Point).ScalarBaseMult(tScalar).Bytes(), T[:]) {
+		return AdaptorSecret{}, ErrAdaptorMismatch
+	}
+	copy(t[:], tScalar.Bytes())
+	return t, nil
+}
+
+// String prints the adaptor point in hex.
+func (T AdaptorPoint) String() string {
+	return hex.EncodeToString(T[:])
+}
+
+// MarshalJSON marshals an adaptor point as a hex string.
+func (T AdaptorPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(T.String())
+}
+
+// UnmarshalJSON decodes the json hex string of an adaptor point.
+func (T *AdaptorPoint) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONHex(b, T[:])
+}
+
+// String prints the adaptor secret in hex.
+func (t AdaptorSecret) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+// MarshalJSON marshals an adaptor secret as a hex string.
+func (t AdaptorSecret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.String())
+}
+
+// UnmarshalJSON decodes the json hex string of an adaptor secret.
+func (t *AdaptorSecret) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONHex(b, t[:])
+}
+
+// String prints the adaptor signature in hex.
+func (asig AdaptorSignature) String() string {
+	return hex.EncodeToString(asig[:])
+}
+
+// MarshalJSON marshals an adaptor signature as a hex string.
+func (asig AdaptorSignature) MarshalJSON() ([]byte, error) {
+	return json.Marshal(asig.String())
+}
+
+// UnmarshalJSON decodes the json hex string of an adaptor signature.
+func (asig *AdaptorSignature) UnmarshalJSON(b []byte) error {
+	return unmarshalJSONHex(b, asig[:])
+}
+
+// unmarshalJSONHex decodes a json hex string into dst, which it has to fill
+// exactly.
+func unmarshalJSONHex(b []byte, dst []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	decoded, err := hex.DecodeString(s)
+	if err != nil {
+		return errors.New("could not unmarshal hex string: " + err.Error())
+	} else if len(decoded) != len(dst) {
+		return errors.New("hex string has the wrong length")
+	}
+	copy(dst, decoded)
+	return nil
+}
@@ -0,0 +1,88 @@
+package crypto
+
+import (
+	"encoding/json"
+	"testing"
+
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestAdaptorSignatures checks that adaptor signatures verify, complete into
+// valid signatures, and reveal their secret.
+func TestAdaptorSignatures(t *testing.T) {
+	sk, pk := GenerateKeyPair()
+	var entropy [EntropySize]byte
+	fastrand.Read(entropy[:])
+	secret, point := GenerateAdaptorSecret(entropy)
+	var data Hash
+	fastrand.Read(data[:])
+
+	asig, err := AdaptorSignHash(data, sk, point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyAdaptorHash(data, pk, point, asig); err != nil {
+		t.Fatal(err)
+	}
+	// The adaptor signature is not a valid signature by itself.
+	if err := VerifyHash(data, pk, Signature(asig)); err == nil {
+		t.Fatal("adaptor signature should not be a valid signature")
+	}
+	// It doesn't verify for other data or another adaptor point.
+	var other Hash
+	fastrand.Read(other[:])
+	if err := VerifyAdaptorHash(other, pk, point, asig); err != ErrInvalidAdaptorSignature {
+		t.Fatal("expected ErrInvalidAdaptorSignature, got", err)
+	}
+	_, otherPoint := GenerateAdaptorSecret(Hash(other))
+	if err := VerifyAdaptorHash(data, pk, otherPoint, asig); err != ErrInvalidAdaptorSignature {
+		t.Fatal("expected ErrInvalidAdaptorSignature, got", err)
+	}
+
+	// Completing the adaptor signature produces a valid signature, which
+	// reveals the secret.
+	sig, err := asig.Complete(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := VerifyHash(data, pk, sig); err != nil {
+		t.Fatal("completed adaptor signature is invalid:", err)
+	}
+	recovered, err := asig.RecoverSecret(sig, point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if recovered != secret {
+		t.Fatal("recovered the wrong secret")
+	}
+	if _, err := asig.RecoverSecret(SignHash(data, sk), point); err != ErrAdaptorMismatch {
+		t.Fatal("expected ErrAdaptorMismatch, got", err)
+	}
+
+	// Only points on the curve that are not of small order are valid.
+	if err := point.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	var identity AdaptorPoint
+	identity[0] = 1
+	if err := identity.Validate(); err != ErrInvalidAdaptorPoint {
+		t.Fatal("expected ErrInvalidAdaptorPoint for the identity, got", err)
+	}
+	invalid := AdaptorPoint{2} // y = 2 is not on the curve
+	if err := invalid.Validate(); err != ErrInvalidAdaptorPoint {
+		t.Fatal("expected ErrInvalidAdaptorPoint for a point off the curve, got", err)
+	}
+
+	// Adaptor types are encoded as hex in JSON.
+	b, err := json.Marshal(asig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded AdaptorSignature
+	if err := json.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded != asig {
+		t.Fatal("adaptor signature changed after JSON encoding")
+	}
+}
@@ -1,12 +1,20 @@
 package modules
 
 import (
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 const (
 	// HostDir names the directory that contains the host persistence.
 	HostDir = "host"
+
+	// MaxRenterPriceMultiplier is the largest price multiplier that a renter
+	// policy can have. Renters reject settings with prices above the prices
+	// in their hostdb times this multiplier.
+	MaxRenterPriceMultiplier = 10
 )
 
 var (
@@ -86,6 +94,25 @@ type (
 		NetAddress           NetAddress        `json:"netaddress"`
 		WindowSize           types.BlockHeight `json:"windowsize"`
 
+		// MaxDownloadSpeed and MaxUploadSpeed limit the number of bytes per
+		// second that the host receives and sends over all of its RPC
+		// connections. MaxContractBandwidth limits the number of bytes that
+		// can be uploaded to and downloaded from the host using a single
+		// contract per bandwidth quota period. A renewed contract continues
+		// the quota of the contract it renews. A value of zero means that
+		// there is no limit.
+		MaxContractBandwidth uint64 `json:"maxcontractbandwidth"`
+		MaxDownloadSpeed     int64  `json:"maxdownloadspeed"`
+		MaxUploadSpeed       int64  `json:"maxuploadspeed"`
+
+		// MaintenanceMode prepares the host for planned downtime. The host
+		// rejects new contracts, renewals and uploads, but keeps serving
+		// downloads. MaintenanceEnd is the time at which the host expects to
+		// leave maintenance mode, which is announced to renters so that they
+		// don't consider the host offline until then.
+		MaintenanceMode bool            `json:"maintenancemode"`
+		MaintenanceEnd  types.Timestamp `json:"maintenanceend"`
+
 		Collateral       types.Currency `json:"collateral"`
 		CollateralBudget types.Currency `json:"collateralbudget"`
 		MaxCollateral    types.Currency `json:"maxcollateral"`
@@ -94,18 +121,166 @@ type (
 		MinDownloadBandwidthPrice types.Currency `json:"mindownloadbandwidthprice"`
 		MinStoragePrice           types.Currency `json:"minstorageprice"`
 		MinUploadBandwidthPrice   types.Currency `json:"minuploadbandwidthprice"`
+
+		// AutoPricing makes the host set its prices automatically, based on
+		// the median prices of the other hosts on the network, its storage
+		// utilization and the usage of its collateral budget. The automatic
+		// prices are kept between the floors and ceilings below, a ceiling of
+		// zero means that the price has no upper bound.
+		AutoPricing                   bool           `json:"autopricing"`
+		ContractPriceCeiling          types.Currency `json:"contractpriceceiling"`
+		ContractPriceFloor            types.Currency `json:"contractpricefloor"`
+		DownloadBandwidthPriceCeiling types.Currency `json:"downloadbandwidthpriceceiling"`
+		DownloadBandwidthPriceFloor   types.Currency `json:"downloadbandwidthpricefloor"`
+		StoragePriceCeiling           types.Currency `json:"storagepriceceiling"`
+		StoragePriceFloor             types.Currency `json:"storagepricefloor"`
+		UploadBandwidthPriceCeiling   types.Currency `json:"uploadbandwidthpriceceiling"`
+		UploadBandwidthPriceFloor     types.Currency `json:"uploadbandwidthpricefloor"`
 	}
 
 	// HostNetworkMetrics reports the quantity of each type of RPC call that
-	// has been made to the host.
+	// has been made to the host, and the bandwidth used by the RPCs.
 	HostNetworkMetrics struct {
 		DownloadCalls     uint64 `json:"downloadcalls"`
 		ErrorCalls        uint64 `json:"errorcalls"`
 		FormContractCalls uint64 `json:"formcontractcalls"`
+		RecoverCalls      uint64 `json:"recovercalls"`
 		RenewCalls        uint64 `json:"renewcalls"`
 		ReviseCalls       uint64 `json:"revisecalls"`
 		SettingsCalls     uint64 `json:"settingscalls"`
 		UnrecognizedCalls uint64 `json:"unrecognizedcalls"`
+
+		// DownloadBandwidth and UploadBandwidth are the total number of bytes
+		// received and sent by the host since startup. DownloadSpeed and
+		// UploadSpeed are the current throughput in bytes per second.
+		DownloadBandwidth uint64 `json:"downloadbandwidth"`
+		DownloadSpeed     uint64 `json:"downloadspeed"`
+		UploadBandwidth   uint64 `json:"uploadbandwidth"`
+		UploadSpeed       uint64 `json:"uploadspeed"`
+	}
+
+	// AtRiskStorageObligation is an unresolved storage obligation that has
+	// sectors which were found to be corrupted by the storage scrub. The host
+	// will fail the storage proof of the obligation if one of the corrupted
+	// sectors is selected for the proof.
+	AtRiskStorageObligation struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		CorruptSectors   []crypto.Hash        `json:"corruptsectors"`
+		SectorRootsCount uint64               `json:"sectorrootscount"`
+		RiskedCollateral types.Currency       `json:"riskedcollateral"`
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		ProofDeadLine    types.BlockHeight    `json:"proofdeadline"`
+	}
+
+	// StorageProofSimulation is the result of a dry-run of the storage proof
+	// of an unresolved storage obligation. The host picks a random segment
+	// the way consensus would, builds the proof from the stored sector and
+	// validates it against the Merkle root of the contract. Error describes
+	// why the proof could not be built or why it is invalid.
+	StorageProofSimulation struct {
+		ObligationID     types.FileContractID `json:"obligationid"`
+		SegmentIndex     uint64               `json:"segmentindex"`
+		SectorRoot       crypto.Hash          `json:"sectorroot"`
+		RiskedCollateral types.Currency       `json:"riskedcollateral"`
+		ExpirationHeight types.BlockHeight    `json:"expirationheight"`
+		ProofDeadLine    types.BlockHeight    `json:"proofdeadline"`
+		Ready            bool                 `json:"ready"`
+		Error            string               `json:"error,omitempty"`
+	}
+
+	// StorageProofReport contains the storage proof simulations of all
+	// unresolved storage obligations of the host. FailedObligations is the
+	// number of obligations that would fail their storage proof, and
+	// RiskedCollateral is the collateral of those obligations.
+	StorageProofReport struct {
+		Obligations       []StorageProofSimulation `json:"obligations"`
+		FailedObligations uint64                   `json:"failedobligations"`
+		RiskedCollateral  types.Currency           `json:"riskedcollateral"`
+		SimulatedAt       time.Time                `json:"simulatedat"`
+	}
+
+	// ArchivedStorageObligation contains the outcome of a storage obligation
+	// that the host has resolved. Revenue is the income that the host earned
+	// from the obligation, LostRevenue and LostCollateral are the income and
+	// the collateral that the host lost by failing the storage proof.
+	ArchivedStorageObligation struct {
+		ObligationId     types.FileContractID `json:"obligationid"`
+		ObligationStatus string               `json:"obligationstatus"`
+		DataSize         uint64               `json:"datasize"`
+
+		NegotiationHeight types.BlockHeight `json:"negotiationheight"`
+		ExpirationHeight  types.BlockHeight `json:"expirationheight"`
+		ProofDeadLine     types.BlockHeight `json:"proofdeadline"`
+		ResolutionHeight  types.BlockHeight `json:"resolutionheight"`
+		ResolvedAt        time.Time         `json:"resolvedat"`
+
+		ContractCost             types.Currency `json:"contractcost"`
+		PotentialDownloadRevenue types.Currency `json:"potentialdownloadrevenue"`
+		PotentialStorageRevenue  types.Currency `json:"potentialstoragerevenue"`
+		PotentialUploadRevenue   types.Currency `json:"potentialuploadrevenue"`
+		LockedCollateral         types.Currency `json:"lockedcollateral"`
+		RiskedCollateral         types.Currency `json:"riskedcollateral"`
+		TransactionFees          types.Currency `json:"transactionfees"`
+
+		Revenue        types.Currency `json:"revenue"`
+		LostRevenue    types.Currency `json:"lostrevenue"`
+		LostCollateral types.Currency `json:"lostcollateral"`
+	}
+
+	// HostPrices are the prices that a host charges its renters.
+	HostPrices struct {
+		ContractPrice          types.Currency `json:"contractprice"`
+		DownloadBandwidthPrice types.Currency `json:"downloadbandwidthprice"`
+		StoragePrice           types.Currency `json:"storageprice"`
+		UploadBandwidthPrice   types.Currency `json:"uploadbandwidthprice"`
+	}
+
+	// HostPriceChange records an automatic change of the host's prices,
+	// together with the inputs that were used to compute the new prices.
+	// Utilization is the fraction of the host's storage that is in use,
+	// CollateralBudgetUsage is the fraction of the collateral budget that is
+	// locked or risked in contracts.
+	HostPriceChange struct {
+		BlockHeight           types.BlockHeight `json:"blockheight"`
+		Timestamp             time.Time         `json:"timestamp"`
+		OldPrices             HostPrices        `json:"oldprices"`
+		NewPrices             HostPrices        `json:"newprices"`
+		NetworkMedian         HostPrices        `json:"networkmedian"`
+		NetworkSamples        int               `json:"networksamples"`
+		Utilization           float64           `json:"utilization"`
+		CollateralBudgetUsage float64           `json:"collateralbudgetusage"`
+	}
+
+	// RenterPolicy is a policy that the host operator has set for a renter. A
+	// denied renter can't form, renew or revise contracts with the host. The
+	// prices of the host are multiplied by PriceMultiplier for the renter,
+	// and MaxStorage limits the amount of data that the renter can store with
+	// the host. A zero PriceMultiplier or MaxStorage means that the host's
+	// defaults apply. Renters are identified by the renter key of their
+	// contracts, and new contracts are formed at the host's regular prices.
+	RenterPolicy struct {
+		Deny            bool    `json:"deny"`
+		MaxStorage      uint64  `json:"maxstorage"`
+		PriceMultiplier float64 `json:"pricemultiplier"`
+	}
+
+	// RenterReputation is the history of a renter with the host. Renters are
+	// identified by the public key in the unlock conditions of their
+	// contracts. AbandonedContracts counts the contracts that never made it
+	// into the blockchain, or that expired without the renter ever uploading
+	// or downloading data. DataStored is the size of the renter's active
+	// contracts, and BandwidthUsed is the upload and download bandwidth that
+	// the renter paid for.
+	RenterReputation struct {
+		PublicKey          types.SiaPublicKey `json:"publickey"`
+		Contracts          uint64             `json:"contracts"`
+		ActiveContracts    uint64             `json:"activecontracts"`
+		AbandonedContracts uint64             `json:"abandonedcontracts"`
+		DataStored         uint64             `json:"datastored"`
+		BandwidthUsed      uint64             `json:"bandwidthused"`
+		FirstSeen          time.Time          `json:"firstseen"`
+		LastSeen           time.Time          `json:"lastseen"`
+		Policy             RenterPolicy       `json:"policy"`
 	}
 
 	// StorageObligation contains information about a storage obligation that
@@ -158,6 +333,19 @@ type (
 		// AnnounceAddress submits an announcement using the given address.
 		AnnounceAddress(NetAddress) error
 
+		// AnnounceAddresses submits a versioned announcement using the given
+		// addresses in priority order, which expire at the given height.
+		AnnounceAddresses([]NetAddress, types.BlockHeight) error
+
+		// AuditStorage compares the reference counts of the stored sectors
+		// with the sector roots of the unresolved storage obligations, and
+		// optionally corrects the reference counts.
+		AuditStorage(fix bool) (StorageAudit, error)
+
+		// AtRiskStorageObligations returns the unresolved storage
+		// obligations that contain sectors which were found to be corrupted.
+		AtRiskStorageObligations() ([]AtRiskStorageObligation, error)
+
 		// ExternalSettings returns the settings of the host as seen by an
 		// untrusted node querying the host for settings.
 		ExternalSettings() HostExternalSettings
@@ -173,16 +361,42 @@ type (
 		// have been made to the host.
 		NetworkMetrics() HostNetworkMetrics
 
+		// PriceHistory returns the automatic price changes of the host,
+		// oldest first.
+		PriceHistory() ([]HostPriceChange, error)
+
 		// PublicKey returns the public key of the host.
 		PublicKey() types.SiaPublicKey
 
+		// RenterReputations returns the reputations of the renters that have
+		// formed contracts with the host or that have a policy.
+		RenterReputations() ([]RenterReputation, error)
+
 		// SetInternalSettings sets the hosting parameters of the host.
 		SetInternalSettings(HostInternalSettings) error
 
+		// SetRenterPolicy sets the policy that the host enforces for the
+		// renter with the given public key.
+		SetRenterPolicy(types.SiaPublicKey, RenterPolicy) error
+
+		// SimulateStorageProofs builds and validates a storage proof for
+		// every unresolved storage obligation without submitting it.
+		SimulateStorageProofs() (StorageProofReport, error)
+
+		// StorageProofReport returns the report of the most recent storage
+		// proof simulation.
+		StorageProofReport() StorageProofReport
+
 		// StorageObligations returns the set of storage obligations held by
 		// the host.
 		StorageObligations() []StorageObligation
 
+		// StorageObligationHistory returns the storage obligations that were
+		// resolved between start and end, oldest first. A zero end time
+		// doesn't limit the history, and an empty status returns obligations
+		// with any final status.
+		StorageObligationHistory(start, end time.Time, status string) ([]ArchivedStorageObligation, error)
+
 		// ConnectabilityStatus returns the connectability status of the host, that
 		// is, if it can connect to itself on the configured NetAddress.
 		ConnectabilityStatus() HostConnectabilityStatus
@@ -6,6 +6,7 @@ import (
 
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
 	"gitlab.com/NebulousLabs/errors"
 )
 
@@ -17,6 +18,10 @@ var (
 	// errUnknownAddress is returned if the host is unable to determine a
 	// public address for itself to use in the announcement.
 	errUnknownAddress = errors.New("host cannot announce, does not seem to have a valid address")
+
+	// errAnnExpired is returned if the host is asked to announce addresses
+	// that have already expired.
+	errAnnExpired = errors.New("cannot announce addresses with an expiry that has already passed")
 )
 
 // differentTypeIPs is a helper that returns true if two IPs are of a different
@@ -52,11 +57,25 @@ func (h *Host) staticVerifyAnnouncementAddress(addr modules.NetAddress) error {
 	return nil
 }
 
-// managedAnnounce creates an announcement transaction and submits it to the network.
-func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
-	// Verify address first.
-	if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
-		return err
+// managedAnnounce creates an announcement transaction and submits it to the
+// network. A single address without an expiry is announced with a regular
+// announcement, otherwise a versioned announcement is used.
+func (h *Host) managedAnnounce(addrs []modules.NetAddress, expiry types.BlockHeight) (err error) {
+	// Verify the addresses first. Every address is verified on its own, so
+	// that an IPv4 and an IPv6 address can be announced together.
+	if len(addrs) == 0 {
+		return modules.ErrAnnNoAddresses
+	}
+	for _, addr := range addrs {
+		if err := h.staticVerifyAnnouncementAddress(addr); err != nil {
+			return err
+		}
+	}
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	h.mu.RUnlock()
+	if expiry != 0 && expiry <= blockHeight {
+		return errAnnExpired
 	}
 
 	// The wallet needs to be unlocked to add fees to the transaction, and the
@@ -81,7 +100,14 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 
 	// Create the announcement that's going to be added to the arbitrary data
 	// field of the transaction.
-	signedAnnouncement, err := modules.CreateAnnouncement(addr, pubKey, secKey)
+	var signedAnnouncement []byte
+	txnSize := uint64(600) // Estimated txn size (in bytes) of a host announcement.
+	if len(addrs) == 1 && expiry == 0 {
+		signedAnnouncement, err = modules.CreateAnnouncement(addrs[0], pubKey, secKey)
+	} else {
+		signedAnnouncement, err = modules.CreateVersionedAnnouncement(addrs, expiry, pubKey, secKey)
+		txnSize += uint64(len(signedAnnouncement))
+	}
 	if err != nil {
 		return err
 	}
@@ -97,7 +123,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 		}
 	}()
 	_, fee := h.tpool.FeeEstimation()
-	fee = fee.Mul64(600) // Estimated txn size (in bytes) of a host announcement.
+	fee = fee.Mul64(txnSize)
 	err = txnBuilder.FundSiacoins(fee)
 	if err != nil {
 		return err
@@ -118,7 +144,7 @@ func (h *Host) managedAnnounce(addr modules.NetAddress) (err error) {
 	h.mu.Lock()
 	h.announced = true
 	h.mu.Unlock()
-	h.log.Printf("INFO: Successfully announced as %v", addr)
+	h.log.Printf("INFO: Successfully announced as %v", addrs)
 	return nil
 }
 
@@ -151,7 +177,7 @@ func (h *Host) Announce() error {
 	}
 
 	// Address has cleared inspection, perform the announcement.
-	return h.managedAnnounce(annAddr)
+	return h.managedAnnounce([]modules.NetAddress{annAddr}, 0)
 }
 
 // AnnounceAddress submits a host announcement to the blockchain to announce a
@@ -165,7 +191,7 @@ func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
 	defer h.tg.Done()
 
 	// Attempt the actual announcement.
-	err = h.managedAnnounce(addr)
+	err = h.managedAnnounce([]modules.NetAddress{addr}, 0)
 	if err != nil {
 		return build.ExtendErr("unable to perform manual host announcement", err)
 	}
@@ -177,3 +203,27 @@ func (h *Host) AnnounceAddress(addr modules.NetAddress) error {
 	h.mu.Unlock()
 	return nil
 }
+
+// AnnounceAddresses submits a versioned host announcement to the blockchain to
+// announce several addresses in priority order, which expire at the provided
+// height. An expiry of zero means that the addresses don't expire. If there is
+// no error, the host's address will be updated to the first address.
+func (h *Host) AnnounceAddresses(addrs []modules.NetAddress, expiry types.BlockHeight) error {
+	err := h.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	err = h.managedAnnounce(addrs, expiry)
+	if err != nil {
+		return build.ExtendErr("unable to perform manual host announcement", err)
+	}
+
+	// Renters that don't recognize versioned announcements use the first
+	// address, which is the host's internal net address.
+	h.mu.Lock()
+	h.settings.NetAddress = addrs[0]
+	h.mu.Unlock()
+	return nil
+}
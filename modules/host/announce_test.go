@@ -14,8 +14,10 @@ import (
 type announcementFinder struct {
 	cs modules.ConsensusSet
 
-	// Announcements that have been seen. The two slices are wedded.
+	// Announcements that have been seen. The slices are wedded.
 	netAddresses []modules.NetAddress
+	allAddresses [][]modules.NetAddress
+	expiries     []types.BlockHeight
 	publicKeys   []types.SiaPublicKey
 }
 
@@ -25,9 +27,11 @@ func (af *announcementFinder) ProcessConsensusChange(cc modules.ConsensusChange)
 	for _, block := range cc.AppliedBlocks {
 		for _, txn := range block.Transactions {
 			for _, arb := range txn.ArbitraryData {
-				addr, pubKey, err := modules.DecodeAnnouncement(arb)
+				addrs, expiry, pubKey, err := modules.DecodeVersionedAnnouncement(arb)
 				if err == nil {
-					af.netAddresses = append(af.netAddresses, addr)
+					af.netAddresses = append(af.netAddresses, addrs[0])
+					af.allAddresses = append(af.allAddresses, addrs)
+					af.expiries = append(af.expiries, expiry)
 					af.publicKeys = append(af.publicKeys, pubKey)
 				}
 			}
@@ -271,3 +275,48 @@ func TestHostVerifyAnnouncementAddress(t *testing.T) {
 		t.Error("Announcing host8 should have failed but didn't")
 	}
 }
+
+// TestHostAnnounceAddresses checks that the host can announce several
+// addresses in a versioned announcement.
+func TestHostAnnounceAddresses(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	af, err := newAnnouncementFinder(ht.cs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer af.Close()
+
+	// An expiry that has passed is rejected.
+	addrs := []modules.NetAddress{"1.2.3.4:1234", "[2001:db8::1]:1234"}
+	if err := ht.host.AnnounceAddresses(addrs, 1); err == nil {
+		t.Fatal("announcement with a passed expiry should be rejected")
+	}
+
+	expiry := ht.host.blockHeight + 100
+	err = ht.host.AnnounceAddresses(addrs, expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = ht.miner.AddBlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(af.allAddresses) != 1 {
+		t.Fatal("could not find host announcement in blockchain")
+	}
+	if len(af.allAddresses[0]) != 2 || af.allAddresses[0][1] != addrs[1] || af.expiries[0] != expiry {
+		t.Fatal("announcement has wrong addresses:", af.allAddresses[0], af.expiries[0])
+	}
+	if ht.host.InternalSettings().NetAddress != addrs[0] {
+		t.Error("net address of the host was not updated")
+	}
+}
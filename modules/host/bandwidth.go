@@ -0,0 +1,126 @@
+package host
+
+import (
+	"net"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errContractBandwidthQuota is returned if a renter tries to transfer
+	// more data using a contract than the MaxContractBandwidth setting of the
+	// host allows.
+	errContractBandwidthQuota = ErrorCommunication("contract exceeded the bandwidth quota of the host, try again later")
+)
+
+// contractBandwidthUsage tracks the bandwidth that was used by a contract
+// during the current quota period.
+type contractBandwidthUsage struct {
+	periodStart time.Time
+	used        uint64
+}
+
+// monitoredConn wraps a net.Conn and counts the bytes that are read and
+// written by the host.
+type monitoredConn struct {
+	net.Conn
+	h *Host
+}
+
+// Read reads from the underlying connection and adds the number of read bytes
+// to the download bandwidth of the host.
+func (c *monitoredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	atomic.AddUint64(&c.h.atomicDownloadBandwidth, uint64(n))
+	return n, err
+}
+
+// Write writes to the underlying connection and adds the number of written
+// bytes to the upload bandwidth of the host.
+func (c *monitoredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	atomic.AddUint64(&c.h.atomicUploadBandwidth, uint64(n))
+	return n, err
+}
+
+// setRateLimits applies the bandwidth limits of the host's settings to its
+// RPC connections.
+func (h *Host) setRateLimits() {
+	h.rl.SetLimits(h.settings.MaxDownloadSpeed, h.settings.MaxUploadSpeed, hostRateLimitPacketSize)
+}
+
+// managedUseContractBandwidth adds the bandwidth to the usage of the contract
+// in the current quota period. If the usage would exceed the
+// MaxContractBandwidth setting of the host, errContractBandwidthQuota is
+// returned and the usage is not updated.
+func (h *Host) managedUseContractBandwidth(id types.FileContractID, bandwidth uint64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.settings.MaxContractBandwidth == 0 {
+		h.renterBandwidth[id] += bandwidth
+		return nil
+	}
+	usage, exists := h.contractBandwidth[id]
+	if !exists || time.Since(usage.periodStart) > contractBandwidthQuotaPeriod {
+		usage = &contractBandwidthUsage{periodStart: time.Now()}
+		h.contractBandwidth[id] = usage
+	}
+	if usage.used+bandwidth > h.settings.MaxContractBandwidth {
+		return errContractBandwidthQuota
+	}
+	usage.used += bandwidth
+	h.renterBandwidth[id] += bandwidth
+	return nil
+}
+
+// managedRenewContractBandwidth carries the bandwidth usage of a renewed
+// contract over to the contract that renewed it, so that renewing a contract
+// doesn't reset its quota.
+func (h *Host) managedRenewContractBandwidth(oldID, newID types.FileContractID) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	usage, exists := h.contractBandwidth[oldID]
+	if !exists {
+		return
+	}
+	delete(h.contractBandwidth, oldID)
+	h.contractBandwidth[newID] = usage
+}
+
+// threadedTrackThroughput periodically samples the bandwidth used by the
+// host's RPC connections to compute the current throughput of the host. It
+// also forgets the bandwidth usage of contracts whose quota period has ended.
+func (h *Host) threadedTrackThroughput(closeChan chan struct{}) {
+	defer close(closeChan)
+
+	prevDownload := atomic.LoadUint64(&h.atomicDownloadBandwidth)
+	prevUpload := atomic.LoadUint64(&h.atomicUploadBandwidth)
+	prevTime := time.Now()
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(throughputSampleInterval):
+		}
+
+		download := atomic.LoadUint64(&h.atomicDownloadBandwidth)
+		upload := atomic.LoadUint64(&h.atomicUploadBandwidth)
+		now := time.Now()
+		elapsed := now.Sub(prevTime).Seconds()
+		if elapsed > 0 {
+			atomic.StoreUint64(&h.atomicDownloadSpeed, uint64(float64(download-prevDownload)/elapsed))
+			atomic.StoreUint64(&h.atomicUploadSpeed, uint64(float64(upload-prevUpload)/elapsed))
+		}
+		prevDownload, prevUpload, prevTime = download, upload, now
+
+		h.mu.Lock()
+		for id, usage := range h.contractBandwidth {
+			if time.Since(usage.periodStart) > contractBandwidthQuotaPeriod {
+				delete(h.contractBandwidth, id)
+			}
+		}
+		h.mu.Unlock()
+	}
+}
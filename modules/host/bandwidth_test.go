@@ -0,0 +1,171 @@
+package host
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestContractBandwidthQuota checks that the bandwidth of a contract is
+// limited by the MaxContractBandwidth setting.
+func TestContractBandwidthQuota(t *testing.T) {
+	h := &Host{
+		contractBandwidth: make(map[types.FileContractID]*contractBandwidthUsage),
+		renterBandwidth:   make(map[types.FileContractID]uint64),
+	}
+	id1 := types.FileContractID{1}
+	id2 := types.FileContractID{2}
+
+	// Without a quota, any amount of bandwidth can be used.
+	if err := h.managedUseContractBandwidth(id1, 1e12); err != nil {
+		t.Fatal(err)
+	}
+
+	// With a quota, the bandwidth of each contract is limited separately.
+	h.settings.MaxContractBandwidth = 100
+	if err := h.managedUseContractBandwidth(id1, 60); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedUseContractBandwidth(id1, 40); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedUseContractBandwidth(id1, 1); err != errContractBandwidthQuota {
+		t.Fatal("expected errContractBandwidthQuota, got", err)
+	}
+	if err := h.managedUseContractBandwidth(id2, 100); err != nil {
+		t.Fatal(err)
+	}
+
+	// The usage is reset after the quota period.
+	h.contractBandwidth[id1].periodStart = time.Now().Add(-contractBandwidthQuotaPeriod - time.Second)
+	if err := h.managedUseContractBandwidth(id1, 100); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.managedUseContractBandwidth(id2, 1); err != errContractBandwidthQuota {
+		t.Fatal("expected errContractBandwidthQuota, got", err)
+	}
+}
+
+// TestRenewContractBandwidth checks that renewing a contract doesn't reset its
+// bandwidth quota.
+func TestRenewContractBandwidth(t *testing.T) {
+	h := &Host{
+		contractBandwidth: make(map[types.FileContractID]*contractBandwidthUsage),
+		renterBandwidth:   make(map[types.FileContractID]uint64),
+	}
+	h.settings.MaxContractBandwidth = 100
+	oldID := types.FileContractID{1}
+	newID := types.FileContractID{2}
+	if err := h.managedUseContractBandwidth(oldID, 80); err != nil {
+		t.Fatal(err)
+	}
+
+	// The renewed contract should continue the usage of the old contract.
+	h.managedRenewContractBandwidth(oldID, newID)
+	if _, exists := h.contractBandwidth[oldID]; exists {
+		t.Fatal("usage of the old contract was not removed")
+	}
+	if err := h.managedUseContractBandwidth(newID, 30); err != errContractBandwidthQuota {
+		t.Fatal("expected errContractBandwidthQuota, got", err)
+	}
+	if err := h.managedUseContractBandwidth(newID, 20); err != nil {
+		t.Fatal(err)
+	}
+
+	// Renewing a contract without any usage is a no-op.
+	h.managedRenewContractBandwidth(types.FileContractID{3}, types.FileContractID{4})
+	if _, exists := h.contractBandwidth[types.FileContractID{4}]; exists {
+		t.Fatal("usage was created for a contract without usage")
+	}
+}
+
+// TestMonitoredConn checks that a monitoredConn counts the bytes that are
+// read and written.
+func TestMonitoredConn(t *testing.T) {
+	h := new(Host)
+	c1, c2 := net.Pipe()
+	defer c1.Close()
+	defer c2.Close()
+	conn := &monitoredConn{Conn: c1, h: h}
+
+	go func() {
+		c2.Write(make([]byte, 10))
+		io.ReadFull(c2, make([]byte, 25))
+	}()
+	if _, err := io.ReadFull(conn, make([]byte, 10)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write(make([]byte, 25)); err != nil {
+		t.Fatal(err)
+	}
+	if n := atomic.LoadUint64(&h.atomicDownloadBandwidth); n != 10 {
+		t.Fatal("expected 10 bytes downloaded, got", n)
+	}
+	if n := atomic.LoadUint64(&h.atomicUploadBandwidth); n != 25 {
+		t.Fatal("expected 25 bytes uploaded, got", n)
+	}
+}
+
+// TestSetRateLimits checks that the bandwidth limits of the host can be set
+// using its internal settings.
+func TestSetRateLimits(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	settings := ht.host.InternalSettings()
+	settings.MaxDownloadSpeed = -1
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Fatal("negative bandwidth limit should be rejected")
+	}
+	settings.MaxDownloadSpeed = 1e6
+	settings.MaxUploadSpeed = 2e6
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	readBPS, writeBPS, _ := ht.host.rl.Limits()
+	if readBPS != 1e6 || writeBPS != 2e6 {
+		t.Fatal("rate limits were not applied:", readBPS, writeBPS)
+	}
+
+	// The bandwidth of an RPC should be reported in the network metrics.
+	conn, err := net.Dial("tcp", string(ht.host.ExternalSettings().NetAddress))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := encoding.WriteObject(conn, modules.RPCSettings); err != nil {
+		t.Fatal(err)
+	}
+	var hes modules.HostExternalSettings
+	var pk crypto.PublicKey
+	copy(pk[:], ht.host.PublicKey().Key)
+	if err := crypto.ReadSignedObject(conn, &hes, modules.NegotiateMaxHostExternalSettingsLen, pk); err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		nm := ht.host.NetworkMetrics()
+		if nm.DownloadBandwidth == 0 || nm.UploadBandwidth == 0 {
+			return errors.New("bandwidth of the RPC was not counted")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -9,6 +9,23 @@ import (
 )
 
 const (
+	// autoPriceCollateralWeight determines how much the automatic storage
+	// price rises when the collateral budget of the host is used up. At full
+	// usage, the price is 1+autoPriceCollateralWeight times as high as with
+	// an unused budget.
+	autoPriceCollateralWeight = 0.5
+
+	// autoPriceScanTimeout is the amount of time that the host waits for
+	// another host to respond when it samples the prices of the network.
+	autoPriceScanTimeout = 30 * time.Second
+
+	// autoPriceUtilizationWeight determines how much the automatic storage
+	// price depends on the utilization of the host's storage. A host that is
+	// half full charges the network median, an empty host charges
+	// autoPriceUtilizationWeight/2 less and a full host
+	// autoPriceUtilizationWeight/2 more.
+	autoPriceUtilizationWeight = 0.5
+
 	// defaultMaxDuration defines the maximum number of blocks into the future
 	// that the host will accept for the duration of an incoming file contract
 	// obligation. 6 months is chosen because hosts are expected to be
@@ -21,11 +38,24 @@ const (
 	// necessary to limit the impact of DoS attacks.
 	fileContractNegotiationTimeout = 120 * time.Second
 
+	// hostRateLimitPacketSize is the packet size that is used to limit the
+	// bandwidth of the host's RPC connections.
+	hostRateLimitPacketSize = 4 * 4096
+
 	// iteratedConnectionTime is the amount of time that is allowed to pass
 	// before the host will stop accepting new iterations on an iterated
 	// connection.
 	iteratedConnectionTime = 1200 * time.Second
 
+	// maxHostAnnouncements is the number of recent announcements that the
+	// host keeps for every announced host, so that the previous address of a
+	// host is restored if the block of its latest announcement is reverted.
+	maxHostAnnouncements = 10
+
+	// maxPriceHistory is the maximum number of automatic price changes that
+	// the host keeps in its price history.
+	maxPriceHistory = 1000
+
 	// resubmissionTimeout defines the number of blocks that a host will wait
 	// before attempting to resubmit a transaction to the blockchain.
 	// Typically, this transaction will contain either a file contract, a file
@@ -34,6 +64,30 @@ const (
 )
 
 var (
+	// autoPriceFrequency defines how often the host recomputes its prices if
+	// automatic pricing is enabled.
+	autoPriceFrequency = build.Select(build.Var{
+		Standard: time.Hour * 6,
+		Dev:      time.Minute * 5,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// autoPriceMinSamples is the minimum number of hosts that need to report
+	// their prices for the host to compute the network median.
+	autoPriceMinSamples = build.Select(build.Var{
+		Standard: 10,
+		Dev:      1,
+		Testing:  1,
+	}).(int)
+
+	// autoPriceSampleSize is the maximum number of announced hosts that are
+	// queried for their prices to compute the network median.
+	autoPriceSampleSize = build.Select(build.Var{
+		Standard: 50,
+		Dev:      10,
+		Testing:  10,
+	}).(int)
+
 	// connectablityCheckFirstWait defines how often the host's connectability
 	// check is run.
 	connectabilityCheckFirstWait = build.Select(build.Var{
@@ -58,6 +112,14 @@ var (
 		Testing:  time.Second * 90,
 	}).(time.Duration)
 
+	// contractBandwidthQuotaPeriod is the period over which the bandwidth of
+	// a contract is limited by the MaxContractBandwidth setting.
+	contractBandwidthQuotaPeriod = build.Select(build.Var{
+		Standard: time.Hour * 24,
+		Dev:      time.Hour,
+		Testing:  time.Second * 10,
+	}).(time.Duration)
+
 	// defaultCollateral defines the amount of money that the host puts up as
 	// collateral per-byte by default. The collateral should be considered as
 	// an absolute instead of as a percentage, because low prices result in
@@ -197,6 +259,31 @@ var (
 		Testing:  time.Millisecond,
 	}).(time.Duration)
 
+	// storageIntegrityCheckFrequency defines how often the host checks
+	// whether any of its storage obligations contain sectors that were found
+	// to be corrupted by the storage scrub.
+	storageIntegrityCheckFrequency = build.Select(build.Var{
+		Standard: time.Hour,
+		Dev:      time.Minute * 5,
+		Testing:  time.Second * 2,
+	}).(time.Duration)
+
+	// storageProofSimulationFrequency defines how often the host simulates
+	// the storage proofs of its storage obligations.
+	storageProofSimulationFrequency = build.Select(build.Var{
+		Standard: time.Hour * 6,
+		Dev:      time.Minute * 10,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
+
+	// throughputSampleInterval is the interval at which the host samples the
+	// bandwidth used by its RPC connections to compute its throughput.
+	throughputSampleInterval = build.Select(build.Var{
+		Dev:      time.Second * 5,
+		Standard: time.Second * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
 	// workingStatusFirstCheck defines how frequently the Host's working status
 	// check runs
 	workingStatusFirstCheck = build.Select(build.Var{
@@ -225,6 +312,17 @@ var (
 // All of the following variables define the names of buckets used by the host
 // in the database.
 var (
+	// bucketAnnouncedHosts maps the public keys of the hosts that announced
+	// themselves on the blockchain to their most recent announcements. The
+	// host samples these hosts to compute the median prices of the network.
+	bucketAnnouncedHosts = []byte("BucketAnnouncedHosts")
+
+	// bucketAnnouncementScan contains the ID of the last consensus change
+	// whose announcements were added to bucketAnnouncedHosts, under
+	// keyAnnouncementScanChange. It is empty until the host first scans the
+	// blockchain for announcements.
+	bucketAnnouncementScan = []byte("BucketAnnouncementScan")
+
 	// bucketActionItems maps a blockchain height to a list of storage
 	// obligations that need to be managed in some way at that height. The
 	// height is stored as a big endian uint64, which means that bolt will
@@ -235,9 +333,27 @@ var (
 	// using the id.
 	bucketActionItems = []byte("BucketActionItems")
 
+	// bucketPriceHistory contains the automatic price changes of the host,
+	// keyed by a big endian sequence number.
+	bucketPriceHistory = []byte("BucketPriceHistory")
+
+	// bucketRenters contains the reputations and the policies of the
+	// renters, keyed by the string form of their public keys.
+	bucketRenters = []byte("BucketRenters")
+
+	// bucketStorageObligationHistory contains the outcomes of the resolved
+	// storage obligations. The keys are the big endian resolution time in
+	// nanoseconds followed by the file contract id, which keeps the history
+	// sorted by time.
+	bucketStorageObligationHistory = []byte("BucketStorageObligationHistory")
+
 	// bucketStorageObligations contains a set of serialized
 	// 'storageObligations' sorted by their file contract id.
 	bucketStorageObligations = []byte("BucketStorageObligations")
+
+	// keyAnnouncementScanChange is the key of the consensus change ID in
+	// bucketAnnouncementScan.
+	keyAnnouncementScanChange = []byte("RecentChange")
 )
 
 // init runs a series of sanity checks to verify that the constants have sane
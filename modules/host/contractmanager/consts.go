@@ -128,4 +128,29 @@ var (
 		Standard: time.Second * 60 * 5,
 		Testing:  time.Second * 8,
 	}).(time.Duration)
+
+	// scrubBytesPerSecond limits the rate at which the storage scrub reads
+	// sectors from disk, so that it doesn't compete with renters for the
+	// disk.
+	scrubBytesPerSecond = build.Select(build.Var{
+		Dev:      uint64(50e6),
+		Standard: uint64(20e6),
+		Testing:  uint64(1 << 30),
+	}).(uint64)
+
+	// scrubInitialDelay is the amount of time that the contract manager waits
+	// after startup before it starts scrubbing the stored sectors.
+	scrubInitialDelay = build.Select(build.Var{
+		Dev:      time.Minute,
+		Standard: time.Minute * 10,
+		Testing:  time.Second,
+	}).(time.Duration)
+
+	// scrubInterval is the amount of time between two passes of the storage
+	// scrub.
+	scrubInterval = build.Select(build.Var{
+		Dev:      time.Hour,
+		Standard: time.Hour * 24 * 7,
+		Testing:  time.Second * 3,
+	}).(time.Duration)
 )
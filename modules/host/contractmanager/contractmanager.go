@@ -93,6 +93,10 @@ type ContractManager struct {
 	// or modified.
 	lockedSectors map[sectorID]*sectorLock
 
+	// scrub contains the progress and results of the background scrub that
+	// verifies the integrity of the stored sectors.
+	scrub scrubState
+
 	// Utilities.
 	dependencies modules.Dependencies
 	log          *persist.Logger
@@ -115,6 +119,10 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 
 		lockedSectors: make(map[sectorID]*sectorLock),
 
+		scrub: scrubState{
+			corruptSectors: make(map[sectorID]modules.CorruptSector),
+		},
+
 		dependencies: dependencies,
 		persistDir:   persistDir,
 	}
@@ -212,6 +220,14 @@ func newContractManager(dependencies modules.Dependencies, persistDir string) (*
 	// and adds them if they are discovered.
 	go cm.threadedFolderRecheck()
 
+	// Spin up the thread that periodically verifies the integrity of the
+	// stored sectors.
+	go cm.threadedScrub()
+
+	// Resume any storage folder migrations that were interrupted by an
+	// unclean shutdown.
+	go cm.threadedResumeStorageFolderMigrations()
+
 	// Simulate an error to make sure the cleanup code is triggered correctly.
 	if cm.dependencies.Disrupt("erroredStartup") {
 		err = errors.New("startup disrupted")
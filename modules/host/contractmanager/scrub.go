@@ -0,0 +1,213 @@
+package contractmanager
+
+import (
+	"encoding/hex"
+	"errors"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errSectorRootMismatch is recorded for sectors whose data doesn't match
+	// the Merkle root that the sector was stored under.
+	errSectorRootMismatch = errors.New("sector data does not match the sector root")
+)
+
+// scrubState contains the results of the storage scrub, which periodically
+// reads every sector and verifies it against its Merkle root. The results are
+// not persistent, corrupted sectors are found again during the first pass
+// after startup.
+type scrubState struct {
+	corruptSectors    map[sectorID]modules.CorruptSector
+	lastPassCompleted time.Time
+	passesCompleted   uint64
+	scrubbedSectors   uint64
+	totalSectors      uint64
+	mu                sync.Mutex
+}
+
+// managedScrubSector reads a sector from disk and checks that its data still
+// matches the sector id. Sectors that can't be read or don't match are
+// recorded as corrupted.
+func (cm *ContractManager) managedScrubSector(id sectorID) {
+	err := cm.tg.Add()
+	if err != nil {
+		return
+	}
+	defer cm.tg.Done()
+	cm.wal.managedLockSector(id)
+	defer cm.wal.managedUnlockSector(id)
+
+	cm.wal.mu.Lock()
+	sl, exists1 := cm.sectorLocations[id]
+	sf, exists2 := cm.storageFolders[sl.storageFolder]
+	var path string
+	if exists2 {
+		path = sf.path
+	}
+	cm.wal.mu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+		// The sector was removed since the pass started, or its storage
+		// folder is missing, which is already reported as such.
+		return
+	}
+
+	var corruption error
+	sectorData, err := readSector(sf.sectorFile, sl.index)
+	if err != nil {
+		atomic.AddUint64(&sf.atomicFailedReads, 1)
+		corruption = err
+	} else {
+		atomic.AddUint64(&sf.atomicSuccessfulReads, 1)
+		if cm.managedSectorID(crypto.MerkleRoot(sectorData)) != id {
+			corruption = errSectorRootMismatch
+		}
+	}
+
+	cm.scrub.mu.Lock()
+	defer cm.scrub.mu.Unlock()
+	if corruption == nil {
+		// The sector may have been repaired or rewritten since it was found
+		// to be corrupted.
+		delete(cm.scrub.corruptSectors, id)
+		return
+	}
+	if _, exists := cm.scrub.corruptSectors[id]; exists {
+		return
+	}
+	cm.scrub.corruptSectors[id] = modules.CorruptSector{
+		ID:            hex.EncodeToString(id[:]),
+		StorageFolder: sl.storageFolder,
+		Path:          path,
+		DetectedAt:    time.Now(),
+		Error:         corruption.Error(),
+	}
+	cm.log.Printf("WARN: sector %x in storage folder %v is corrupted: %v", id, path, corruption)
+}
+
+// managedScrubPass verifies every sector that is stored at the beginning of
+// the pass. The reads are spread out to stay below scrubBytesPerSecond.
+func (cm *ContractManager) managedScrubPass() {
+	cm.wal.mu.Lock()
+	ids := make([]sectorID, 0, len(cm.sectorLocations))
+	for id := range cm.sectorLocations {
+		ids = append(ids, id)
+	}
+	cm.wal.mu.Unlock()
+
+	cm.scrub.mu.Lock()
+	cm.scrub.scrubbedSectors = 0
+	cm.scrub.totalSectors = uint64(len(ids))
+	cm.scrub.mu.Unlock()
+
+	sectorTime := time.Duration(float64(time.Second) * float64(modules.SectorSize) / float64(scrubBytesPerSecond))
+	for _, id := range ids {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		default:
+		}
+		start := time.Now()
+		cm.managedScrubSector(id)
+		cm.scrub.mu.Lock()
+		cm.scrub.scrubbedSectors++
+		cm.scrub.mu.Unlock()
+
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(sectorTime - time.Since(start)):
+		}
+	}
+
+	// Forget about the corrupted sectors that were removed in the meantime.
+	cm.wal.mu.Lock()
+	cm.scrub.mu.Lock()
+	for id := range cm.scrub.corruptSectors {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			delete(cm.scrub.corruptSectors, id)
+		}
+	}
+	cm.scrub.lastPassCompleted = time.Now()
+	cm.scrub.passesCompleted++
+	cm.scrub.mu.Unlock()
+	cm.wal.mu.Unlock()
+}
+
+// threadedScrub periodically scrubs all of the sectors stored by the
+// contract manager.
+func (cm *ContractManager) threadedScrub() {
+	// Don't spawn the loop if 'noScrub' disruption is set.
+	if cm.dependencies.Disrupt("noScrub") {
+		return
+	}
+
+	wait := scrubInitialDelay
+	for {
+		select {
+		case <-cm.tg.StopChan():
+			return
+		case <-time.After(wait):
+		}
+		cm.managedScrubPass()
+		wait = scrubInterval
+	}
+}
+
+// CorruptSectors returns the roots of the given sectors that were found to be
+// corrupted by the storage scrub.
+func (cm *ContractManager) CorruptSectors(roots []crypto.Hash) []crypto.Hash {
+	err := cm.tg.Add()
+	if err != nil {
+		return nil
+	}
+	defer cm.tg.Done()
+
+	cm.scrub.mu.Lock()
+	defer cm.scrub.mu.Unlock()
+	if len(cm.scrub.corruptSectors) == 0 {
+		return nil
+	}
+	var corrupt []crypto.Hash
+	for _, root := range roots {
+		if _, exists := cm.scrub.corruptSectors[cm.managedSectorID(root)]; exists {
+			corrupt = append(corrupt, root)
+		}
+	}
+	return corrupt
+}
+
+// ScrubStatus returns the progress of the current storage scrub pass and the
+// sectors that were found to be corrupted, in the order they were found.
+func (cm *ContractManager) ScrubStatus() modules.StorageScrubStatus {
+	err := cm.tg.Add()
+	if err != nil {
+		return modules.StorageScrubStatus{}
+	}
+	defer cm.tg.Done()
+
+	cm.scrub.mu.Lock()
+	defer cm.scrub.mu.Unlock()
+	status := modules.StorageScrubStatus{
+		LastPassCompleted: cm.scrub.lastPassCompleted,
+		PassesCompleted:   cm.scrub.passesCompleted,
+		ScrubbedSectors:   cm.scrub.scrubbedSectors,
+		TotalSectors:      cm.scrub.totalSectors,
+		CorruptSectors:    make([]modules.CorruptSector, 0, len(cm.scrub.corruptSectors)),
+	}
+	for _, cs := range cm.scrub.corruptSectors {
+		status.CorruptSectors = append(status.CorruptSectors, cs)
+	}
+	sort.Slice(status.CorruptSectors, func(i, j int) bool {
+		if status.CorruptSectors[i].DetectedAt.Equal(status.CorruptSectors[j].DetectedAt) {
+			return status.CorruptSectors[i].ID < status.CorruptSectors[j].ID
+		}
+		return status.CorruptSectors[i].DetectedAt.Before(status.CorruptSectors[j].DetectedAt)
+	})
+	return status
+}
@@ -0,0 +1,123 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// dependencyNoScrub is a mocked dependency that prevents the background
+// storage scrub from running, so that tests can run the scrub manually.
+type dependencyNoScrub struct {
+	modules.ProductionDependencies
+}
+
+// Disrupt will prevent the scrub loop from being spawned.
+func (d *dependencyNoScrub) Disrupt(s string) bool {
+	return s == "noScrub"
+}
+
+// TestScrub checks that the storage scrub finds sectors whose data was
+// corrupted on disk, and forgets about them once they are repaired.
+func TestScrub(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newMockedContractManagerTester(&dependencyNoScrub{}, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add a few sectors.
+	var roots []crypto.Hash
+	var datas [][]byte
+	for i := 0; i < 5; i++ {
+		root, data := randSector()
+		err = cmt.cm.AddSector(root, data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		roots = append(roots, root)
+		datas = append(datas, data)
+	}
+
+	// A scrub of the intact sectors shouldn't find anything.
+	cmt.cm.managedScrubPass()
+	status := cmt.cm.ScrubStatus()
+	if status.PassesCompleted != 1 || status.ScrubbedSectors != 5 || status.TotalSectors != 5 {
+		t.Fatal("unexpected scrub progress:", status)
+	}
+	if len(status.CorruptSectors) != 0 || len(cmt.cm.CorruptSectors(roots)) != 0 {
+		t.Fatal("intact sectors reported as corrupted")
+	}
+
+	// Corrupt the data of a sector on disk.
+	id := cmt.cm.managedSectorID(roots[2])
+	cmt.cm.wal.mu.Lock()
+	sl := cmt.cm.sectorLocations[id]
+	sf := cmt.cm.storageFolders[sl.storageFolder]
+	cmt.cm.wal.mu.Unlock()
+	err = writeSector(sf.sectorFile, sl.index, fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The scrub should find the corrupted sector.
+	cmt.cm.managedScrubPass()
+	status = cmt.cm.ScrubStatus()
+	if len(status.CorruptSectors) != 1 {
+		t.Fatal("expected one corrupt sector, got", len(status.CorruptSectors))
+	}
+	if cs := status.CorruptSectors[0]; cs.Path != storageFolderDir || cs.Error != errSectorRootMismatch.Error() {
+		t.Fatal("corrupt sector reported incorrectly:", cs)
+	}
+	corrupt := cmt.cm.CorruptSectors(roots)
+	if len(corrupt) != 1 || corrupt[0] != roots[2] {
+		t.Fatal("wrong sectors reported as corrupted:", corrupt)
+	}
+
+	// Repair the sector, the next scrub should clear the report.
+	err = writeSector(sf.sectorFile, sl.index, datas[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.managedScrubPass()
+	if status := cmt.cm.ScrubStatus(); len(status.CorruptSectors) != 0 || status.PassesCompleted != 3 {
+		t.Fatal("repaired sector still reported as corrupted:", status)
+	}
+
+	// Corrupt the sector again and remove it, the report should disappear
+	// after the next pass.
+	err = writeSector(sf.sectorFile, sl.index, fastrand.Bytes(int(modules.SectorSize)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.managedScrubPass()
+	if len(cmt.cm.ScrubStatus().CorruptSectors) != 1 {
+		t.Fatal("corrupted sector not found")
+	}
+	err = cmt.cm.RemoveSector(roots[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm.managedScrubPass()
+	if len(cmt.cm.ScrubStatus().CorruptSectors) != 0 {
+		t.Fatal("removed sector still reported as corrupted")
+	}
+}
@@ -0,0 +1,179 @@
+package contractmanager
+
+import (
+	"encoding/hex"
+	"math"
+	"sort"
+	"sync/atomic"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// managedSetSectorCount will set the reference count of a sector, removing
+// the sector if the count is zero.
+func (wal *writeAheadLog) managedSetSectorCount(id sectorID, count uint16) error {
+	// Inform the WAL of the new reference count.
+	var location sectorLocation
+	var oldCount uint16
+	var su sectorUpdate
+	var sf *storageFolder
+	var syncChan chan struct{}
+	err := func() error {
+		wal.mu.Lock()
+		defer wal.mu.Unlock()
+
+		var exists bool
+		location, exists = wal.cm.sectorLocations[id]
+		if !exists {
+			return ErrSectorNotFound
+		}
+		sf, exists = wal.cm.storageFolders[location.storageFolder]
+		if !exists || atomic.LoadUint64(&sf.atomicUnavailable) == 1 {
+			return errStorageFolderNotFound
+		}
+
+		// Inform the WAL of the sector update.
+		oldCount = location.count
+		location.count = count
+		su = sectorUpdate{
+			Count:  count,
+			ID:     id,
+			Folder: location.storageFolder,
+			Index:  location.index,
+		}
+		wal.appendChange(stateChange{
+			SectorUpdates: []sectorUpdate{su},
+		})
+
+		// Update the in-memory representation of the sector.
+		if count == 0 {
+			delete(wal.cm.sectorLocations, id)
+			sf.availableSectors[id] = location.index
+		} else {
+			wal.cm.sectorLocations[id] = location
+		}
+		syncChan = wal.syncChan
+		return nil
+	}()
+	if err != nil {
+		return err
+	}
+	// Synchronize before updating the metadata or clearing the usage.
+	<-syncChan
+
+	if count != 0 {
+		err = wal.writeSectorMetadata(sf, su)
+		if err != nil {
+			// Revert the previous change.
+			wal.mu.Lock()
+			su.Count = oldCount
+			location.count = oldCount
+			wal.appendChange(stateChange{
+				SectorUpdates: []sectorUpdate{su},
+			})
+			wal.cm.sectorLocations[id] = location
+			wal.mu.Unlock()
+			return build.ExtendErr("failed to write sector metadata", err)
+		}
+		return nil
+	}
+
+	// Only clear the usage after the removal has been committed to disk, to
+	// prevent the sector data from being overwritten in the event of an
+	// unclean shutdown.
+	wal.mu.Lock()
+	sf.clearUsage(location.index)
+	delete(sf.availableSectors, id)
+	wal.mu.Unlock()
+	return nil
+}
+
+// AuditSectors compares the reference count of every stored sector with the
+// number of references that the caller expects for the sector. The host
+// references a sector once for every storage obligation that contains it, but
+// the reference counts can drift if an obligation fails halfway through an
+// operation. If fix is set, the reference counts are corrected, and sectors
+// without any references are removed. The caller should prevent sectors from
+// being added or removed during the audit.
+func (cm *ContractManager) AuditSectors(references map[crypto.Hash]uint64, fix bool) (audit modules.StorageAudit, err error) {
+	err = cm.tg.Add()
+	if err != nil {
+		return modules.StorageAudit{}, err
+	}
+	defer cm.tg.Done()
+
+	// Convert the roots into the ids that are used by the contract manager.
+	// The reference count of a sector can't exceed the maximum of a uint16.
+	expected := make(map[sectorID]uint64, len(references))
+	roots := make(map[sectorID]crypto.Hash, len(references))
+	for root, count := range references {
+		id := cm.managedSectorID(root)
+		if count > math.MaxUint16 {
+			count = math.MaxUint16
+		}
+		expected[id] = count
+		roots[id] = root
+	}
+
+	// Compare the reference counts with the expected references.
+	fixes := make(map[sectorID]uint16)
+	cm.wal.mu.Lock()
+	audit.TotalSectors = uint64(len(cm.sectorLocations))
+	for id, sl := range cm.sectorLocations {
+		refs := expected[id]
+		if uint64(sl.count) == refs {
+			continue
+		}
+		as := modules.AuditedSector{
+			ID:            hex.EncodeToString(id[:]),
+			StorageFolder: sl.storageFolder,
+			Count:         sl.count,
+			References:    refs,
+		}
+		if sf, exists := cm.storageFolders[sl.storageFolder]; exists {
+			as.Path = sf.path
+		}
+		switch {
+		case refs == 0:
+			audit.OrphanedSectors = append(audit.OrphanedSectors, as)
+		case uint64(sl.count) < refs:
+			audit.UnderReferencedSectors = append(audit.UnderReferencedSectors, as)
+		default:
+			audit.OverReferencedSectors = append(audit.OverReferencedSectors, as)
+		}
+		fixes[id] = uint16(refs)
+	}
+	for id := range expected {
+		if _, exists := cm.sectorLocations[id]; !exists {
+			audit.MissingSectors = append(audit.MissingSectors, roots[id])
+		}
+	}
+	cm.wal.mu.Unlock()
+
+	for _, sectors := range [][]modules.AuditedSector{audit.OrphanedSectors, audit.UnderReferencedSectors, audit.OverReferencedSectors} {
+		sort.Slice(sectors, func(i, j int) bool {
+			return sectors[i].ID < sectors[j].ID
+		})
+	}
+	sort.Slice(audit.MissingSectors, func(i, j int) bool {
+		return audit.MissingSectors[i].String() < audit.MissingSectors[j].String()
+	})
+	if !fix {
+		return audit, nil
+	}
+
+	// Correct the reference counts.
+	for id, count := range fixes {
+		cm.wal.managedLockSector(id)
+		err := cm.wal.managedSetSectorCount(id, count)
+		cm.wal.managedUnlockSector(id)
+		if err != nil && err != ErrSectorNotFound {
+			return audit, build.ExtendErr("unable to fix the reference count of a sector", err)
+		}
+	}
+	audit.Fixed = true
+	cm.log.Printf("Storage audit fixed %v orphaned, %v under-referenced and %v over-referenced sectors\n", len(audit.OrphanedSectors), len(audit.UnderReferencedSectors), len(audit.OverReferencedSectors))
+	return audit, nil
+}
@@ -0,0 +1,122 @@
+package contractmanager
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// TestAuditSectors checks that the sector audit finds sectors whose reference
+// counts don't match the expected references, and that it can fix them.
+func TestAuditSectors(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	storageFolderDir := filepath.Join(cmt.persistDir, "storageFolderOne")
+	err = os.MkdirAll(storageFolderDir, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderDir, modules.SectorSize*64)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add an over-referenced sector, an under-referenced sector, an orphaned
+	// sector and a sector with the correct reference count.
+	over, overData := randSector()
+	under, underData := randSector()
+	orphan, orphanData := randSector()
+	ok, okData := randSector()
+	err = addSectors(cmt.cm, []crypto.Hash{over, under, orphan, ok}, [][]byte{overData, underData, orphanData, okData})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddSectorBatch([]crypto.Hash{over, ok})
+	if err != nil {
+		t.Fatal(err)
+	}
+	missing, _ := randSector()
+	references := map[crypto.Hash]uint64{
+		over:    1,
+		under:   3,
+		ok:      2,
+		missing: 1,
+	}
+
+	audit, err := cmt.cm.AuditSectors(references, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.TotalSectors != 4 || audit.Fixed {
+		t.Fatal("wrong audit totals:", audit.TotalSectors, audit.Fixed)
+	}
+	if len(audit.OrphanedSectors) != 1 || audit.OrphanedSectors[0].Count != 1 || audit.OrphanedSectors[0].Path != storageFolderDir {
+		t.Fatal("orphaned sector was not found:", audit.OrphanedSectors)
+	}
+	if len(audit.UnderReferencedSectors) != 1 || audit.UnderReferencedSectors[0].Count != 1 || audit.UnderReferencedSectors[0].References != 3 {
+		t.Fatal("under-referenced sector was not found:", audit.UnderReferencedSectors)
+	}
+	if len(audit.OverReferencedSectors) != 1 || audit.OverReferencedSectors[0].Count != 2 || audit.OverReferencedSectors[0].References != 1 {
+		t.Fatal("over-referenced sector was not found:", audit.OverReferencedSectors)
+	}
+	if len(audit.MissingSectors) != 1 || audit.MissingSectors[0] != missing {
+		t.Fatal("missing sector was not found:", audit.MissingSectors)
+	}
+
+	// Fix the reference counts, after which the audit should be clean.
+	audit, err = cmt.cm.AuditSectors(references, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !audit.Fixed {
+		t.Fatal("audit was not fixed")
+	}
+	audit, err = cmt.cm.AuditSectors(references, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.TotalSectors != 3 || len(audit.OrphanedSectors) != 0 || len(audit.UnderReferencedSectors) != 0 || len(audit.OverReferencedSectors) != 0 {
+		t.Fatal("audit should be clean after the fix:", audit)
+	}
+	if _, err := cmt.cm.ReadSector(orphan); err != ErrSectorNotFound {
+		t.Fatal("orphaned sector should have been removed:", err)
+	}
+
+	// The fixed reference counts should survive a restart.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, err = cmt.cm.AuditSectors(references, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.TotalSectors != 3 || len(audit.UnderReferencedSectors) != 0 || len(audit.OverReferencedSectors) != 0 {
+		t.Fatal("fixed reference counts were not persisted:", audit)
+	}
+
+	// Removing the sector once more than it is referenced deletes it.
+	for i := 0; i < 3; i++ {
+		if err := cmt.cm.RemoveSector(under); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := cmt.cm.ReadSector(under); err != ErrSectorNotFound {
+		t.Fatal("under-referenced sector should be removed after its last reference:", err)
+	}
+}
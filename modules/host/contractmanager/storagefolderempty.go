@@ -16,8 +16,9 @@ var (
 )
 
 // managedMoveSector will move a sector from its current storage folder to
-// another.
-func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
+// another. If a destination is provided, the sector will only be moved into
+// the destination storage folder.
+func (wal *writeAheadLog) managedMoveSector(id sectorID, destination *storageFolder) error {
 	wal.managedLockSector(id)
 	defer wal.managedUnlockSector(id)
 
@@ -48,9 +49,14 @@ func (wal *writeAheadLog) managedMoveSector(id sectorID) error {
 	}
 
 	// Place the sector into its new folder and add the atomic move to the WAL.
-	wal.mu.Lock()
-	storageFolders := wal.cm.availableStorageFolders()
-	wal.mu.Unlock()
+	var storageFolders []*storageFolder
+	if destination != nil {
+		storageFolders = []*storageFolder{destination}
+	} else {
+		wal.mu.Lock()
+		storageFolders = wal.cm.availableStorageFolders()
+		wal.mu.Unlock()
+	}
 	for len(storageFolders) >= 1 {
 		var storageFolderIndex int
 		err := func() error {
@@ -192,7 +198,7 @@ func (wal *writeAheadLog) managedEmptyStorageFolder(sfIndex uint16, startingPoin
 			for {
 				select {
 				case id := <-workChan:
-					err := wal.managedMoveSector(id)
+					err := wal.managedMoveSector(id, nil)
 					if err != nil {
 						atomic.AddUint64(&errCount, 1)
 						wal.cm.log.Println("Unable to write sector:", err)
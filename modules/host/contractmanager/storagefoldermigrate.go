@@ -0,0 +1,224 @@
+package contractmanager
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	// errInsufficientStorageForMigration is returned if the destination of a
+	// storage folder migration does not have room for all of the sectors in
+	// the source storage folder.
+	errInsufficientStorageForMigration = errors.New("destination storage folder does not have enough room for the sectors of the source storage folder")
+
+	// errMigrationInterrupted is returned if a storage folder migration was
+	// stopped before all of the sectors were moved. The migration is resumed
+	// if the WAL is recovered at startup.
+	errMigrationInterrupted = errors.New("storage folder migration was interrupted")
+
+	// errMigrationSameFolder is returned if the source and the destination of
+	// a storage folder migration are the same storage folder.
+	errMigrationSameFolder = errors.New("cannot migrate a storage folder into itself")
+)
+
+type (
+	// storageFolderMigration is the data saved to the WAL to indicate that
+	// the sectors of the source storage folder are being moved into the
+	// destination storage folder.
+	storageFolderMigration struct {
+		Source      uint16
+		Destination uint16
+	}
+)
+
+// findUnfinishedStorageFolderMigrations will scroll through a set of state
+// changes and pull out all of the storage folder migrations which have not
+// yet finished.
+func findUnfinishedStorageFolderMigrations(scs []stateChange) []storageFolderMigration {
+	// Use a map to figure out what unfinished storage folder migrations exist
+	// and use it to remove the ones that have terminated.
+	usfmMap := make(map[uint16]storageFolderMigration)
+	for _, sc := range scs {
+		for _, usfm := range sc.UnfinishedStorageFolderMigrations {
+			usfmMap[usfm.Source] = usfm
+		}
+		for _, index := range sc.FinishedStorageFolderMigrations {
+			delete(usfmMap, index)
+		}
+		for _, sfr := range sc.StorageFolderRemovals {
+			for source, usfm := range usfmMap {
+				if usfm.Source == sfr.Index || usfm.Destination == sfr.Index {
+					delete(usfmMap, source)
+				}
+			}
+		}
+	}
+
+	// Return the active unfinished storage folder migrations as a slice.
+	usfms := make([]storageFolderMigration, 0, len(usfmMap))
+	for _, usfm := range usfmMap {
+		usfms = append(usfms, usfm)
+	}
+	return usfms
+}
+
+// recoverUnfinishedStorageFolderMigrations will prepare the storage folder
+// migrations that were interrupted by an unclean shutdown to be resumed once
+// the contract manager has started.
+func (wal *writeAheadLog) recoverUnfinishedStorageFolderMigrations(scs []stateChange) {
+	usfms := findUnfinishedStorageFolderMigrations(scs)
+	if len(usfms) == 0 {
+		return
+	}
+
+	// Carry the migrations over into the new WAL, they are only finished once
+	// they have been resumed.
+	wal.unfinishedMigrations = usfms
+	wal.appendChange(stateChange{
+		UnfinishedStorageFolderMigrations: usfms,
+	})
+}
+
+// managedMigrateStorageFolder will move all of the sectors in the source
+// storage folder into the destination storage folder. The source storage
+// folder is locked for the duration of the migration, which prevents new
+// sectors from being added to it, but the sectors in the source storage
+// folder remain available while they are waiting to be moved.
+func (wal *writeAheadLog) managedMigrateStorageFolder(source, destination uint16) (err error) {
+	if source == destination {
+		return errMigrationSameFolder
+	}
+
+	// Signal in the WAL that the migration has finished, unless it was
+	// interrupted and needs to be resumed.
+	defer func() {
+		if err == errMigrationInterrupted {
+			return
+		}
+		wal.mu.Lock()
+		wal.appendChange(stateChange{
+			FinishedStorageFolderMigrations: []uint16{source},
+		})
+		syncChan := wal.syncChan
+		wal.mu.Unlock()
+		<-syncChan
+	}()
+
+	// Retrieve the specified storage folders.
+	wal.mu.Lock()
+	sf, exists1 := wal.cm.storageFolders[source]
+	dsf, exists2 := wal.cm.storageFolders[destination]
+	wal.mu.Unlock()
+	if !exists1 || !exists2 || atomic.LoadUint64(&sf.atomicUnavailable) == 1 || atomic.LoadUint64(&dsf.atomicUnavailable) == 1 {
+		return errStorageFolderNotFound
+	}
+
+	// Lock the source storage folder for the duration of the operation.
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	// Collect the sectors that need to be moved, and make sure that they all
+	// fit into the destination storage folder.
+	wal.mu.Lock()
+	var ids []sectorID
+	for id, sl := range wal.cm.sectorLocations {
+		if sl.storageFolder == source {
+			ids = append(ids, id)
+		}
+	}
+	vacancy := uint64(len(dsf.usage))*storageFolderGranularity - dsf.sectors
+	wal.mu.Unlock()
+	if uint64(len(ids)) > vacancy {
+		return errInsufficientStorageForMigration
+	}
+
+	// Write the intention to migrate the storage folder to the WAL, so that
+	// the migration can be resumed after an unclean shutdown.
+	wal.mu.Lock()
+	wal.appendChange(stateChange{
+		UnfinishedStorageFolderMigrations: []storageFolderMigration{{
+			Source:      source,
+			Destination: destination,
+		}},
+	})
+	syncChan := wal.syncChan
+	wal.mu.Unlock()
+	<-syncChan
+
+	// Move the sectors one at a time, so that the migration doesn't starve
+	// the renters that are using the host in the meantime.
+	atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+	atomic.StoreUint64(&sf.atomicProgressDenominator, uint64(len(ids)))
+	defer func() {
+		atomic.StoreUint64(&sf.atomicProgressNumerator, 0)
+		atomic.StoreUint64(&sf.atomicProgressDenominator, 0)
+	}()
+	var errCount uint64
+	for _, id := range ids {
+		select {
+		case <-wal.cm.tg.StopChan():
+			return errMigrationInterrupted
+		default:
+		}
+		// Simulate power failure at this point for some testing scenarios.
+		if wal.cm.dependencies.Disrupt("interruptStorageFolderMigration") {
+			return errMigrationInterrupted
+		}
+
+		err := wal.managedMoveSector(id, dsf)
+		if err == errInsufficientStorageForSector {
+			return errInsufficientStorageForMigration
+		} else if err != nil {
+			// The move is expected to fail if the sector was removed in the
+			// meantime.
+			wal.mu.Lock()
+			sl, exists := wal.cm.sectorLocations[id]
+			wal.mu.Unlock()
+			if exists && sl.storageFolder == source {
+				errCount++
+				wal.cm.log.Println("Unable to migrate sector:", err)
+			}
+		}
+		atomic.AddUint64(&sf.atomicProgressNumerator, 1)
+	}
+
+	// Return ErrPartialRelocation if not every sector was migrated out
+	// successfully.
+	if errCount > 0 {
+		return ErrPartialRelocation
+	}
+	return nil
+}
+
+// threadedResumeStorageFolderMigrations resumes the storage folder migrations
+// that were interrupted by an unclean shutdown.
+func (cm *ContractManager) threadedResumeStorageFolderMigrations() {
+	cm.wal.mu.Lock()
+	usfms := append([]storageFolderMigration(nil), cm.wal.unfinishedMigrations...)
+	cm.wal.mu.Unlock()
+
+	for _, usfm := range usfms {
+		if cm.tg.Add() != nil {
+			return
+		}
+		cm.log.Printf("Resuming the migration of storage folder %v into storage folder %v\n", usfm.Source, usfm.Destination)
+		err := cm.wal.managedMigrateStorageFolder(usfm.Source, usfm.Destination)
+		if err != nil {
+			cm.log.Printf("ERROR: unable to resume the migration of storage folder %v into storage folder %v: %v\n", usfm.Source, usfm.Destination, err)
+		}
+		cm.tg.Done()
+	}
+}
+
+// MigrateStorageFolder will move all of the sectors in the source storage
+// folder into the destination storage folder. The contract manager keeps
+// serving the sectors while they are moved, and an unclean shutdown will not
+// lose any of the progress, the migration is resumed at startup.
+func (cm *ContractManager) MigrateStorageFolder(source, destination uint16) error {
+	err := cm.tg.Add()
+	if err != nil {
+		return err
+	}
+	defer cm.tg.Done()
+	return cm.wal.managedMigrateStorageFolder(source, destination)
+}
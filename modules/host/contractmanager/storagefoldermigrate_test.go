@@ -0,0 +1,248 @@
+package contractmanager
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// migrationTester adds a storage folder filled with sectors to a contract
+// manager tester, followed by a second, empty storage folder. The roots and
+// data of the sectors are returned.
+func migrationTester(cmt *contractManagerTester, sectors int) (roots []crypto.Hash, datas [][]byte, err error) {
+	for _, name := range []string{"storageFolderOne", "storageFolderTwo"} {
+		err = os.MkdirAll(filepath.Join(cmt.persistDir, name), 0700)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	err = cmt.cm.AddStorageFolder(filepath.Join(cmt.persistDir, "storageFolderOne"), modules.SectorSize*storageFolderGranularity*2)
+	if err != nil {
+		return nil, nil, err
+	}
+	roots = make([]crypto.Hash, sectors)
+	datas = make([][]byte, sectors)
+	for i := range roots {
+		roots[i], datas[i] = randSector()
+	}
+	err = addSectors(cmt.cm, roots, datas)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Add the second storage folder after the sectors, so that all of the
+	// sectors are stored in the first one.
+	err = cmt.cm.AddStorageFolder(filepath.Join(cmt.persistDir, "storageFolderTwo"), modules.SectorSize*storageFolderGranularity*2)
+	return roots, datas, err
+}
+
+// addSectors adds the sectors to the contract manager in parallel.
+func addSectors(cm *ContractManager, roots []crypto.Hash, datas [][]byte) error {
+	errs := make([]error, len(roots))
+	var wg sync.WaitGroup
+	for i := range roots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = cm.AddSector(roots[i], datas[i])
+		}(i)
+	}
+	wg.Wait()
+	return build.JoinErrors(errs, "; ")
+}
+
+// migrationFolders returns the metadata of the source and destination storage
+// folders of the migration tester.
+func migrationFolders(cm *ContractManager) (source, destination modules.StorageFolderMetadata) {
+	for _, sf := range cm.StorageFolders() {
+		switch filepath.Base(sf.Path) {
+		case "storageFolderOne":
+			source = sf
+		case "storageFolderTwo":
+			destination = sf
+		}
+	}
+	return source, destination
+}
+
+// TestMigrateStorageFolder checks that all of the sectors of a storage folder
+// can be moved into another storage folder.
+func TestMigrateStorageFolder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	cmt, err := newContractManagerTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	roots, datas, err := migrationTester(cmt, storageFolderGranularity+5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, destination := migrationFolders(cmt.cm)
+	if source.CapacityRemaining != source.Capacity-uint64(len(roots))*modules.SectorSize {
+		t.Fatal("the sectors were not added to the source folder")
+	}
+
+	// A storage folder can't be migrated into itself.
+	if err := cmt.cm.MigrateStorageFolder(source.Index, source.Index); err != errMigrationSameFolder {
+		t.Fatal("expected errMigrationSameFolder, got", err)
+	}
+
+	// The sectors don't fit into a smaller storage folder.
+	storageFolderThree := filepath.Join(cmt.persistDir, "storageFolderThree")
+	err = os.MkdirAll(storageFolderThree, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmt.cm.AddStorageFolder(storageFolderThree, modules.SectorSize*storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var smallIndex uint16
+	for _, sf := range cmt.cm.StorageFolders() {
+		if sf.Path == storageFolderThree {
+			smallIndex = sf.Index
+		}
+	}
+	if err := cmt.cm.MigrateStorageFolder(source.Index, smallIndex); err != errInsufficientStorageForMigration {
+		t.Fatal("expected errInsufficientStorageForMigration, got", err)
+	}
+
+	// Migrate the sectors while renters keep reading them.
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range roots {
+			data, err := cmt.cm.ReadSector(roots[i])
+			if err != nil || !bytes.Equal(data, datas[i]) {
+				t.Error("sector unavailable during migration:", err)
+			}
+		}
+	}()
+	err = cmt.cm.MigrateStorageFolder(source.Index, destination.Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wg.Wait()
+
+	source, destination = migrationFolders(cmt.cm)
+	if source.CapacityRemaining != source.Capacity {
+		t.Error("source folder should be empty after the migration")
+	}
+	if destination.CapacityRemaining != destination.Capacity-uint64(len(roots))*modules.SectorSize {
+		t.Error("destination folder should hold all of the sectors")
+	}
+	if source.ProgressNumerator != 0 || source.ProgressDenominator != 0 {
+		t.Error("progress should be reset after the migration")
+	}
+	for i := range roots {
+		data, err := cmt.cm.ReadSector(roots[i])
+		if err != nil || !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector was lost during the migration:", err)
+		}
+	}
+}
+
+// dependencyInterruptMigration interrupts a storage folder migration after a
+// number of sectors have been moved, and leaves the WAL behind at shutdown.
+type dependencyInterruptMigration struct {
+	modules.ProductionDependencies
+	moves int
+	mu    sync.Mutex
+}
+
+// Disrupt will interrupt the migration after 10 sectors and prevent the WAL
+// file from being removed.
+func (d *dependencyInterruptMigration) Disrupt(s string) bool {
+	if s == "cleanWALFile" {
+		return true
+	}
+	if s == "interruptStorageFolderMigration" {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		d.moves++
+		return d.moves > 10
+	}
+	return false
+}
+
+// TestMigrateStorageFolderRecovery checks that a storage folder migration that
+// was interrupted by an unclean shutdown is resumed after restart.
+func TestMigrateStorageFolderRecovery(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	d := new(dependencyInterruptMigration)
+	cmt, err := newMockedContractManagerTester(d, t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmt.panicClose()
+
+	roots, datas, err := migrationTester(cmt, storageFolderGranularity)
+	if err != nil {
+		t.Fatal(err)
+	}
+	source, destination := migrationFolders(cmt.cm)
+	err = cmt.cm.MigrateStorageFolder(source.Index, destination.Index)
+	if err != errMigrationInterrupted {
+		t.Fatal("expected errMigrationInterrupted, got", err)
+	}
+	source, _ = migrationFolders(cmt.cm)
+	if source.CapacityRemaining != source.Capacity-uint64(len(roots)-10)*modules.SectorSize {
+		t.Fatal("expected 10 sectors to be migrated before the interruption")
+	}
+
+	// Restart the contract manager, the migration should be resumed.
+	err = cmt.cm.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmt.cm, err = New(filepath.Join(cmt.persistDir, modules.ContractManagerDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		source, _ := migrationFolders(cmt.cm)
+		if source.CapacityRemaining != source.Capacity {
+			return errors.New("source folder is not empty")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range roots {
+		data, err := cmt.cm.ReadSector(roots[i])
+		if err != nil || !bytes.Equal(data, datas[i]) {
+			t.Fatal("sector was lost during the migration:", err)
+		}
+	}
+
+	// The finished migration should be cleared from the WAL.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		cmt.cm.wal.mu.Lock()
+		defer cmt.cm.wal.mu.Unlock()
+		if len(cmt.cm.wal.unfinishedMigrations) != 0 {
+			return errors.New("migration is not finished")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -57,6 +57,15 @@ type (
 		UnfinishedStorageFolderAdditions  []savedStorageFolder
 		UnfinishedStorageFolderExtensions []unfinishedStorageFolderExtension
 
+		// Storage folder migrations move every sector of a storage folder
+		// into another storage folder. The sectors are moved one at a time
+		// through regular sector updates, the migration itself only needs to
+		// be tracked so that it can be resumed after an unclean shutdown.
+		// Once all sectors have been moved, or the migration has given up,
+		// the source folder index is listed as a finished migration.
+		FinishedStorageFolderMigrations   []uint16
+		UnfinishedStorageFolderMigrations []storageFolderMigration
+
 		// Updates to the sector metadata. Careful ordering of events ensures
 		// that a sector update will not make it into the synced WAL unless the
 		// sector data is already on-disk and synced.
@@ -96,6 +105,11 @@ type (
 		uncommittedChanges []stateChange
 		committedSettings  savedSettings
 
+		// unfinishedMigrations contains the storage folder migrations that
+		// are in progress. Migrations can span many syncs of the WAL, so they
+		// are rewritten into every new WAL file until they have finished.
+		unfinishedMigrations []storageFolderMigration
+
 		// Utilities. The WAL needs access to the ContractManager because all
 		// mutations to ACID fields of the contract manager happen through the
 		// WAL.
@@ -250,6 +264,7 @@ func (wal *writeAheadLog) recoverWAL(walFile modules.File) error {
 	// completed.
 	wal.cleanupUnfinishedStorageFolderAdditions(scs)
 	wal.cleanupUnfinishedStorageFolderExtensions(scs)
+	wal.recoverUnfinishedStorageFolderMigrations(scs)
 	return nil
 }
 
@@ -206,6 +206,9 @@ func (wal *writeAheadLog) commit() {
 		// Extract any unfinished long-running jobs from the list of WAL items.
 		unfinishedAdditions := findUnfinishedStorageFolderAdditions(wal.uncommittedChanges)
 		unfinishedExtensions := findUnfinishedStorageFolderExtensions(wal.uncommittedChanges)
+		wal.unfinishedMigrations = findUnfinishedStorageFolderMigrations(append([]stateChange{{
+			UnfinishedStorageFolderMigrations: wal.unfinishedMigrations,
+		}}, wal.uncommittedChanges...))
 
 		// Recreate the wal file so that it can receive new updates.
 		var err error
@@ -224,6 +227,7 @@ func (wal *writeAheadLog) commit() {
 		wal.appendChange(stateChange{
 			UnfinishedStorageFolderAdditions:  unfinishedAdditions,
 			UnfinishedStorageFolderExtensions: unfinishedExtensions,
+			UnfinishedStorageFolderMigrations: wal.unfinishedMigrations,
 		})
 
 		// Clear the set of uncommitted changes.
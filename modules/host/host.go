@@ -77,6 +77,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/persist"
 	siasync "gitlab.com/NebulousLabs/Sia/sync"
 	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/ratelimit"
 )
 
 const (
@@ -120,11 +121,20 @@ type Host struct {
 	atomicDownloadCalls     uint64
 	atomicErroredCalls      uint64
 	atomicFormContractCalls uint64
+	atomicRecoverCalls      uint64
 	atomicRenewCalls        uint64
 	atomicReviseCalls       uint64
 	atomicSettingsCalls     uint64
 	atomicUnrecognizedCalls uint64
 
+	// Bandwidth metrics. The bandwidth is the number of bytes received and
+	// sent over RPC connections, the speed is the most recently sampled
+	// throughput in bytes per second. These values are not persistent.
+	atomicDownloadBandwidth uint64
+	atomicDownloadSpeed     uint64
+	atomicUploadBandwidth   uint64
+	atomicUploadSpeed       uint64
+
 	// Error management. There are a few different types of errors returned by
 	// the host. These errors intentionally not persistent, so that the logging
 	// limits of each error type will be reset each time the host is reset.
@@ -167,6 +177,32 @@ type Host struct {
 	// be locked separately.
 	lockedStorageObligations map[types.FileContractID]*siasync.TryMutex
 
+	// contractBandwidth tracks the bandwidth used by each contract during the
+	// current quota period, which is limited by the MaxContractBandwidth
+	// setting. The usage is carried over to renewed contracts, but it is not
+	// persistent.
+	contractBandwidth map[types.FileContractID]*contractBandwidthUsage
+
+	// renterBandwidth contains the bandwidth that was used by each contract
+	// since the contract was last modified. It is added to the reputation of
+	// the renter when the storage obligation is updated.
+	renterBandwidth map[types.FileContractID]uint64
+
+	// reportedAtRisk contains the number of corrupted sectors of each storage
+	// obligation that were already reported in the log, so that each
+	// obligation is only reported again if more of its sectors get corrupted.
+	reportedAtRisk map[types.FileContractID]int
+
+	// storageProofReport is the report of the most recent storage proof
+	// simulation. The report is not persistent.
+	storageProofReport modules.StorageProofReport
+
+	// announcementScan protects the subscription of the announcement
+	// scanner, which is made the first time the host needs the announced
+	// hosts. announcementsScanned is set once the scanner is subscribed.
+	announcementScan     sync.Mutex
+	announcementsScanned bool
+
 	// Utilities.
 	db         *persist.BoltDatabase
 	listener   net.Listener
@@ -174,6 +210,7 @@ type Host struct {
 	mu         sync.RWMutex
 	persistDir string
 	port       string
+	rl         *ratelimit.RateLimit
 	tg         siasync.ThreadGroup
 }
 
@@ -239,9 +276,13 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, g modul
 		wallet:       wallet,
 		dependencies: dependencies,
 
+		contractBandwidth:        make(map[types.FileContractID]*contractBandwidthUsage),
 		lockedStorageObligations: make(map[types.FileContractID]*siasync.TryMutex),
+		renterBandwidth:          make(map[types.FileContractID]uint64),
+		reportedAtRisk:           make(map[types.FileContractID]int),
 
 		persistDir: persistDir,
+		rl:         ratelimit.NewRateLimit(0, 0, 0),
 	}
 
 	// Call stop in the event of a partial startup.
@@ -309,6 +350,30 @@ func newHost(dependencies modules.Dependencies, cs modules.ConsensusSet, g modul
 		h.log.Println("Could not initialize host networking:", err)
 		return nil, err
 	}
+
+	// Periodically check for storage obligations that are at risk because of
+	// corrupted sectors.
+	threadedCheckStorageIntegrityClosedChan := make(chan struct{})
+	go h.threadedCheckStorageIntegrity(threadedCheckStorageIntegrityClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedCheckStorageIntegrityClosedChan
+	})
+
+	// Periodically simulate the storage proofs of the host to find the
+	// obligations that would fail their proof.
+	threadedSimulateStorageProofsClosedChan := make(chan struct{})
+	go h.threadedSimulateStorageProofs(threadedSimulateStorageProofsClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedSimulateStorageProofsClosedChan
+	})
+
+	// Periodically update the prices of the host if automatic pricing is
+	// enabled.
+	threadedAutoPriceClosedChan := make(chan struct{})
+	go h.threadedAutoPrice(threadedAutoPriceClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedAutoPriceClosedChan
+	})
 	return h, nil
 }
 
@@ -400,6 +465,21 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 		}
 	}
 
+	if settings.MaxDownloadSpeed < 0 || settings.MaxUploadSpeed < 0 {
+		return errors.New("internal settings not updated, bandwidth limits can't be negative")
+	}
+
+	if err := checkPriceBounds(settings); err != nil {
+		return errors.New("internal settings not updated, invalid price bounds: " + err.Error())
+	}
+
+	// A new maintenance end has to be in the future. An end that has passed
+	// during the maintenance is kept, so that other settings can be changed.
+	newEnd := settings.MaintenanceEnd != h.settings.MaintenanceEnd
+	if settings.MaintenanceMode && newEnd && settings.MaintenanceEnd != 0 && settings.MaintenanceEnd <= types.CurrentTimestamp() {
+		return errors.New("internal settings not updated: " + errMaintenanceEndPassed.Error())
+	}
+
 	// Check if the net address for the host has changed. If it has, and it's
 	// not equal to the auto address, then the host is going to need to make
 	// another blockchain announcement.
@@ -409,6 +489,7 @@ func (h *Host) SetInternalSettings(settings modules.HostInternalSettings) error
 
 	h.settings = settings
 	h.revisionNumber++
+	h.setRateLimits()
 
 	err = h.saveSync()
 	if err != nil {
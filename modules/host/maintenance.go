@@ -0,0 +1,29 @@
+package host
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+var (
+	// errMaintenanceMode is returned to renters that try to renew a contract
+	// or upload data while the host is in maintenance mode.
+	errMaintenanceMode = errors.New("host is in maintenance mode and only serves downloads")
+
+	// errMaintenanceEndPassed is returned if maintenance mode is enabled with
+	// an end that has already passed.
+	errMaintenanceEndPassed = errors.New("maintenance end is in the past")
+)
+
+// maintenanceStatus returns the maintenance status that the host announces in
+// its external settings.
+func (h *Host) maintenanceStatus() modules.HostMaintenance {
+	if !h.settings.MaintenanceMode {
+		return modules.HostMaintenance{}
+	}
+	return modules.HostMaintenance{
+		Active: true,
+		End:    h.settings.MaintenanceEnd,
+	}
+}
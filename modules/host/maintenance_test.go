@@ -0,0 +1,61 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestMaintenanceMode checks that the host announces maintenance mode in its
+// external settings and stops accepting contracts.
+func TestMaintenanceMode(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	settings := ht.host.InternalSettings()
+	settings.AcceptingContracts = true
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if es := ht.host.ExternalSettings(); es.Maintenance.Active {
+		t.Fatal("host should not start in maintenance mode")
+	}
+
+	// An end in the past is rejected.
+	settings.MaintenanceMode = true
+	settings.MaintenanceEnd = types.Timestamp(time.Now().Add(-time.Hour).Unix())
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Fatal("maintenance end in the past should be rejected")
+	}
+
+	end := types.Timestamp(time.Now().Add(time.Hour).Unix())
+	settings.MaintenanceEnd = end
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	es := ht.host.ExternalSettings()
+	if !es.Maintenance.Active || es.Maintenance.End != end {
+		t.Fatal("maintenance mode was not announced:", es.Maintenance)
+	}
+	if es.AcceptingContracts {
+		t.Fatal("host in maintenance mode should not accept contracts")
+	}
+
+	// Leaving maintenance mode restores the settings.
+	settings.MaintenanceMode = false
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	es = ht.host.ExternalSettings()
+	if es.Maintenance.Active || es.Maintenance.End != 0 || !es.AcceptingContracts {
+		t.Fatal("host did not leave maintenance mode:", es)
+	}
+}
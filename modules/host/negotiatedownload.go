@@ -25,8 +25,11 @@ var (
 // managedDownloadIteration is responsible for managing a single iteration of
 // the download loop for RPCDownload.
 func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) error {
-	// Exchange settings with the renter.
-	err := h.managedRPCSettings(conn)
+	// Exchange settings with the renter, with the policy that the host
+	// operator has set for the renter applied.
+	renter, _ := so.renterKey()
+	settings, policyErr := h.managedRenterExternalSettings(renter)
+	err := h.managedWriteSettings(conn, settings)
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
@@ -43,11 +46,10 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	}
 
 	// Grab a set of variables that will be useful later in the function.
-	h.mu.Lock()
+	h.mu.RLock()
 	blockHeight := h.blockHeight
 	secretKey := h.secretKey
-	settings := h.externalSettings()
-	h.mu.Unlock()
+	h.mu.RUnlock()
 
 	// Read the download requests, followed by the file contract revision that
 	// pays for them.
@@ -67,6 +69,11 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 	existingRevision := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1].FileContractRevisions[0]
 	var payload [][]byte
 	err = func() error {
+		// Reject the renter if the host operator has denied it.
+		if policyErr != nil {
+			return policyErr
+		}
+
 		// Check that the length of each file is in-bounds, and that the total
 		// size being requested is acceptable.
 		var totalSize uint64
@@ -88,6 +95,13 @@ func (h *Host) managedDownloadIteration(conn net.Conn, so *storageObligation) er
 			return extendErr("payment verification failed: ", err)
 		}
 
+		// Check that the download doesn't exceed the bandwidth quota of the
+		// contract.
+		err = h.managedUseContractBandwidth(so.id(), totalSize)
+		if err != nil {
+			return extendErr("download iteration batch failed: ", err)
+		}
+
 		// Load the sectors and build the data payload.
 		for _, request := range requests {
 			sectorData, err := h.ReadSector(request.MerkleRoot)
@@ -69,16 +69,16 @@ func (h *Host) managedAddCollateral(settings modules.HostExternalSettings, txnSe
 // the blockchain.
 func (h *Host) managedRPCFormContract(conn net.Conn) error {
 	// Send the host settings to the renter.
-	err := h.managedRPCSettings(conn)
+	h.mu.Lock()
+	settings := h.externalSettings()
+	h.mu.Unlock()
+	err := h.managedWriteSettings(conn, settings)
 	if err != nil {
 		return extendErr("failed RPCSettings: ", err)
 	}
 	// If the host is not accepting contracts, the connection can be closed.
 	// The renter has been given enough information in the host settings to
 	// understand that the connection is going to be closed.
-	h.mu.Lock()
-	settings := h.externalSettings()
-	h.mu.Unlock()
 	if !settings.AcceptingContracts {
 		h.log.Debugln("Turning down contract because the host is not accepting contracts.")
 		return nil
@@ -109,8 +109,17 @@ func (h *Host) managedRPCFormContract(conn net.Conn) error {
 		return extendErr("could not read renter public key: ", ErrorConnection(err.Error()))
 	}
 
-	// The host verifies that the file contract coming over the wire is
-	// acceptable.
+	// The host checks that the host operator didn't deny the renter, and then
+	// verifies that the file contract coming over the wire is acceptable. The
+	// renter is only known now, after it has seen the host's regular
+	// settings, so the contract is verified against those. The price
+	// multiplier of the renter's policy applies to the revisions of the
+	// contract.
+	_, err = h.managedRenterSettings(types.Ed25519PublicKey(renterPK), settings)
+	if err != nil {
+		modules.WriteNegotiationRejection(conn, err) // Error ignored to preserve type in extendErr
+		return extendErr("renter policy rejected the contract: ", err)
+	}
 	err = h.managedVerifyNewContract(txnSet, renterPK, settings)
 	if err != nil {
 		// The incoming file contract is not acceptable to the host, indicate
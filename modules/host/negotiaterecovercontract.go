@@ -0,0 +1,35 @@
+package host
+
+import (
+	"net"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// managedRPCRecoverContract sends the most recent file contract revision and
+// the sector roots of a contract to a renter that lost its copy of the
+// contract. The renter has to prove that it owns the contract by signing the
+// challenge of the recent revision exchange.
+func (h *Host) managedRPCRecoverContract(conn net.Conn) error {
+	// Perform the file contract revision exchange, giving the renter the most
+	// recent file contract revision.
+	_, so, err := h.managedRPCRecentRevision(conn)
+	if err != nil {
+		return extendErr("failed RPCRecentRevision during RPCRecoverContract: ", err)
+	}
+	// The storage obligation is received with a lock on it. Defer a call to
+	// unlock the storage obligation.
+	defer func() {
+		h.managedUnlockStorageObligation(so.id())
+	}()
+
+	// Send the sector roots of the contract.
+	conn.SetDeadline(time.Now().Add(modules.NegotiateRecoverContractTime))
+	err = encoding.WriteObject(conn, so.SectorRoots)
+	if err != nil {
+		return extendErr("failed to write sector roots: ", ErrorConnection(err.Error()))
+	}
+	return nil
+}
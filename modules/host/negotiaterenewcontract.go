@@ -90,8 +90,12 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 		h.managedUnlockStorageObligation(so.id())
 	}()
 
-	// Perform the host settings exchange with the renter.
-	err = h.managedRPCSettings(conn)
+	// Perform the host settings exchange with the renter. The renter is
+	// identified by the contract that it renews, the policy that the host
+	// operator has set for it applies to the renewal.
+	renter, _ := so.renterKey()
+	settings, policyErr := h.managedRenterExternalSettings(renter)
+	err = h.managedWriteSettings(conn, settings)
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
@@ -121,12 +125,19 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 		return extendErr("unable to read renter public key: ", ErrorConnection(err.Error()))
 	}
 
-	h.mu.Lock()
-	settings := h.externalSettings()
-	h.mu.Unlock()
+	// Reject the renter if the host operator has denied it.
+	if policyErr != nil {
+		modules.WriteNegotiationRejection(conn, policyErr) // Error is ignored to preserve type for extendErr
+		return extendErr("renter policy rejected the renewal: ", policyErr)
+	}
+	// A host in maintenance mode doesn't renew contracts.
+	if settings.Maintenance.Active {
+		modules.WriteNegotiationRejection(conn, errMaintenanceMode) // Error is ignored to preserve type for extendErr
+		return extendErr("renewal rejected: ", errMaintenanceMode)
+	}
 
 	// Verify that the transaction coming over the wire is a proper renewal.
-	err = h.managedVerifyRenewedContract(so, txnSet, renterPK)
+	err = h.managedVerifyRenewedContract(so, txnSet, renterPK, settings)
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored to preserve type for extendErr
 		return extendErr("verification of renewal failed: ", err)
@@ -195,6 +206,13 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 		return extendErr("failed to finalize contract: ", err)
 	}
 	defer h.managedUnlockStorageObligation(newSOID)
+	// The data of the old contract is now protected by the renewed contract.
+	err = h.managedRenewRenterContract(so, types.Ed25519PublicKey(renterPK))
+	if err != nil {
+		h.log.Println("Unable to update the reputation of the renter:", err)
+	}
+	// The renewed contract continues the bandwidth quota of the old contract.
+	h.managedRenewContractBandwidth(so.id(), newSOID)
 	err = modules.WriteNegotiationAcceptance(conn)
 	if err != nil {
 		return extendErr("failed to write acceptance: ", ErrorConnection(err.Error()))
@@ -214,7 +232,7 @@ func (h *Host) managedRPCRenewContract(conn net.Conn) error {
 
 // managedVerifyRenewedContract checks that the contract renewal matches the
 // previous contract and makes all of the appropriate payments.
-func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types.Transaction, renterPK crypto.PublicKey) error {
+func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types.Transaction, renterPK crypto.PublicKey, externalSettings modules.HostExternalSettings) error {
 	// Check that the transaction set is not empty.
 	if len(txnSet) < 1 {
 		return extendErr("zero-length transaction set: ", errEmptyObject)
@@ -226,7 +244,6 @@ func (h *Host) managedVerifyRenewedContract(so storageObligation, txnSet []types
 
 	h.mu.Lock()
 	blockHeight := h.blockHeight
-	externalSettings := h.externalSettings()
 	internalSettings := h.settings
 	lockedStorageCollateral := h.financialMetrics.LockedStorageCollateral
 	publicKey := h.publicKey
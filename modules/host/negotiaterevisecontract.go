@@ -16,10 +16,13 @@ import (
 // performance optimization, multiple iterations of revisions are allowed to be
 // made over the same connection.
 func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, finalIter bool) error {
-	// Send the settings to the renter. The host will keep going even if it is
+	// Send the settings to the renter, with the policy that the host operator
+	// has set for the renter applied. The host will keep going even if it is
 	// not accepting contracts, because in this case the contract already
 	// exists.
-	err := h.managedRPCSettings(conn)
+	renter, _ := so.renterKey()
+	settings, policyErr := h.managedRenterExternalSettings(renter)
+	err := h.managedWriteSettings(conn, settings)
 	if err != nil {
 		return extendErr("RPCSettings failed: ", err)
 	}
@@ -38,11 +41,10 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	}
 
 	// Read some variables from the host for use later in the function.
-	h.mu.Lock()
-	settings := h.externalSettings()
+	h.mu.RLock()
 	secretKey := h.secretKey
 	blockHeight := h.blockHeight
-	h.mu.Unlock()
+	h.mu.RUnlock()
 
 	// The renter is going to send its intended modifications, followed by the
 	// file contract revision that pays for them.
@@ -67,6 +69,16 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 	var sectorsGained []crypto.Hash
 	var gainedSectorData [][]byte
 	err = func() error {
+		// A host in maintenance mode doesn't accept uploads.
+		if settings.Maintenance.Active {
+			return errMaintenanceMode
+		}
+
+		// Reject the renter if the host operator has denied it.
+		if policyErr != nil {
+			return policyErr
+		}
+
 		for _, modification := range modifications {
 			// Check that the index points to an existing sector root. If the type
 			// is ActionInsert, we permit inserting at the end.
@@ -136,8 +148,27 @@ func (h *Host) managedRevisionIteration(conn net.Conn, so *storageObligation, fi
 				return errUnknownModification
 			}
 		}
+		// Check that the renter doesn't store more data than its policy
+		// allows.
+		if len(sectorsGained) > len(sectorsRemoved) {
+			err := h.managedCheckRenterStorage(*so, uint64(len(so.SectorRoots))*modules.SectorSize)
+			if err != nil {
+				return err
+			}
+		}
+
 		newRevenue := storageRevenue.Add(bandwidthRevenue)
-		return extendErr("unable to verify updated contract: ", verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral))
+		if err := verifyRevision(*so, revision, blockHeight, newRevenue, newCollateral); err != nil {
+			return extendErr("unable to verify updated contract: ", err)
+		}
+
+		// Check that the upload doesn't exceed the bandwidth quota of the
+		// contract.
+		var uploadBandwidth uint64
+		for _, modification := range modifications {
+			uploadBandwidth += uint64(len(modification.Data))
+		}
+		return extendErr("upload batch failed: ", h.managedUseContractBandwidth(so.id(), uploadBandwidth))
 	}()
 	if err != nil {
 		modules.WriteNegotiationRejection(conn, err) // Error is ignored so that the error type can be preserved in extendErr.
@@ -7,6 +7,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 // capacity returns the amount of storage still available on the machine. The
@@ -44,7 +45,7 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 	}
 
 	return modules.HostExternalSettings{
-		AcceptingContracts:   h.settings.AcceptingContracts,
+		AcceptingContracts:   h.settings.AcceptingContracts && !h.settings.MaintenanceMode,
 		MaxDownloadBatchSize: h.settings.MaxDownloadBatchSize,
 		MaxDuration:          h.settings.MaxDuration,
 		MaxReviseBatchSize:   h.settings.MaxReviseBatchSize,
@@ -65,14 +66,13 @@ func (h *Host) externalSettings() modules.HostExternalSettings {
 
 		RevisionNumber: h.revisionNumber,
 		Version:        build.Version,
+
+		Maintenance: h.maintenanceStatus(),
 	}
 }
 
 // managedRPCSettings is an rpc that returns the host's settings.
 func (h *Host) managedRPCSettings(conn net.Conn) error {
-	// Set the negotiation deadline.
-	conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime))
-
 	// The revision number is updated so that the renter can be certain that
 	// they have the most recent copy of the settings. The revision number and
 	// signature can be compared against other settings objects that the renter
@@ -80,15 +80,33 @@ func (h *Host) managedRPCSettings(conn net.Conn) error {
 	// suspect foul play. Largely, the revision number is in place to enable
 	// renters to share host settings with each other, a feature that has not
 	// yet been implemented.
-	//
-	// While updating the revision number, also grab the secret key and
-	// external settings.
-	var hes modules.HostExternalSettings
-	var secretKey crypto.SecretKey
 	h.mu.Lock()
-	secretKey = h.secretKey
-	hes = h.externalSettings()
+	hes := h.externalSettings()
 	h.mu.Unlock()
+	return h.managedWriteSettings(conn, hes)
+}
+
+// managedRenterExternalSettings returns the host's settings with the policy of
+// the renter applied, so that the renter pays the prices that its payments are
+// verified against. If the policy doesn't accept the renter, the host's
+// regular settings are returned together with the error of the policy, which
+// the caller reports to the renter after the renter has responded to the
+// settings.
+func (h *Host) managedRenterExternalSettings(renter types.SiaPublicKey) (modules.HostExternalSettings, error) {
+	h.mu.Lock()
+	hes := h.externalSettings()
+	h.mu.Unlock()
+	return h.managedRenterSettings(renter, hes)
+}
+
+// managedWriteSettings writes the signed settings to the renter.
+func (h *Host) managedWriteSettings(conn net.Conn, hes modules.HostExternalSettings) error {
+	// Set the negotiation deadline.
+	conn.SetDeadline(time.Now().Add(modules.NegotiateSettingsTime))
+
+	h.mu.RLock()
+	secretKey := h.secretKey
+	h.mu.RUnlock()
 
 	// Write the settings to the renter. If the write fails, return a
 	// connection error.
@@ -23,6 +23,7 @@ import (
 	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/ratelimit"
 )
 
 // rpcSettingsDeprecated is a specifier for a deprecated settings request.
@@ -227,6 +228,13 @@ func (h *Host) initNetworking(address string) (err error) {
 		})
 	}()
 
+	// Track the throughput of the host.
+	threadedTrackThroughputClosedChan := make(chan struct{})
+	go h.threadedTrackThroughput(threadedTrackThroughputClosedChan)
+	h.tg.OnStop(func() {
+		<-threadedTrackThroughputClosedChan
+	})
+
 	// Launch the listener.
 	go h.threadedListen(threadedListenerClosedChan)
 	return nil
@@ -241,6 +249,10 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	}
 	defer h.tg.Done()
 
+	// Count the bandwidth used by the connection and apply the host's
+	// bandwidth limits.
+	conn = ratelimit.NewRLConn(&monitoredConn{Conn: conn, h: h}, h.rl, h.tg.StopChan())
+
 	// Close the conn on host.Close or when the method terminates, whichever comes
 	// first.
 	connCloseChan := make(chan struct{})
@@ -279,6 +291,9 @@ func (h *Host) threadedHandleConn(conn net.Conn) {
 	case modules.RPCFormContract:
 		atomic.AddUint64(&h.atomicFormContractCalls, 1)
 		err = extendErr("incoming RPCFormContract failed: ", h.managedRPCFormContract(conn))
+	case modules.RPCRecoverContract:
+		atomic.AddUint64(&h.atomicRecoverCalls, 1)
+		err = extendErr("incoming RPCRecoverContract failed: ", h.managedRPCRecoverContract(conn))
 	case modules.RPCReviseContract:
 		atomic.AddUint64(&h.atomicReviseCalls, 1)
 		err = extendErr("incoming RPCReviseContract failed: ", h.managedRPCReviseContract(conn))
@@ -341,9 +356,15 @@ func (h *Host) NetworkMetrics() modules.HostNetworkMetrics {
 		DownloadCalls:     atomic.LoadUint64(&h.atomicDownloadCalls),
 		ErrorCalls:        atomic.LoadUint64(&h.atomicErroredCalls),
 		FormContractCalls: atomic.LoadUint64(&h.atomicFormContractCalls),
+		RecoverCalls:      atomic.LoadUint64(&h.atomicRecoverCalls),
 		RenewCalls:        atomic.LoadUint64(&h.atomicRenewCalls),
 		ReviseCalls:       atomic.LoadUint64(&h.atomicReviseCalls),
 		SettingsCalls:     atomic.LoadUint64(&h.atomicSettingsCalls),
 		UnrecognizedCalls: atomic.LoadUint64(&h.atomicUnrecognizedCalls),
+
+		DownloadBandwidth: atomic.LoadUint64(&h.atomicDownloadBandwidth),
+		DownloadSpeed:     atomic.LoadUint64(&h.atomicDownloadSpeed),
+		UploadBandwidth:   atomic.LoadUint64(&h.atomicUploadBandwidth),
+		UploadSpeed:       atomic.LoadUint64(&h.atomicUploadSpeed),
 	}
 }
@@ -0,0 +1,124 @@
+package host
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errUnknownObligationStatus is returned if the storage obligation
+	// history is filtered by a status that a resolved storage obligation can't
+	// have.
+	errUnknownObligationStatus = errors.New("unknown storage obligation status, use obligationRejected, obligationSucceeded or obligationFailed")
+)
+
+// obligationHistoryKey returns the key of a resolved storage obligation in the
+// storage obligation history.
+func obligationHistoryKey(resolvedAt time.Time, id types.FileContractID) []byte {
+	key := make([]byte, 8, 8+len(id))
+	binary.BigEndian.PutUint64(key, uint64(resolvedAt.UnixNano()))
+	return append(key, id[:]...)
+}
+
+// archivedStorageObligation returns the outcome of a storage obligation that
+// was resolved with the provided status.
+func archivedStorageObligation(so storageObligation, sos storageObligationStatus, height types.BlockHeight, resolvedAt time.Time) modules.ArchivedStorageObligation {
+	aso := modules.ArchivedStorageObligation{
+		ObligationId:     so.id(),
+		ObligationStatus: sos.String(),
+		DataSize:         so.fileSize(),
+
+		NegotiationHeight: so.NegotiationHeight,
+		ExpirationHeight:  so.expiration(),
+		ProofDeadLine:     so.proofDeadline(),
+		ResolutionHeight:  height,
+		ResolvedAt:        resolvedAt,
+
+		ContractCost:             so.ContractCost,
+		PotentialDownloadRevenue: so.PotentialDownloadRevenue,
+		PotentialStorageRevenue:  so.PotentialStorageRevenue,
+		PotentialUploadRevenue:   so.PotentialUploadRevenue,
+		LockedCollateral:         so.LockedCollateral,
+		RiskedCollateral:         so.RiskedCollateral,
+		TransactionFees:          so.TransactionFeesAdded,
+	}
+	revenue := so.ContractCost.Add(so.PotentialStorageRevenue).Add(so.PotentialDownloadRevenue).Add(so.PotentialUploadRevenue)
+	switch sos {
+	case obligationRejected:
+		// The transactions of a rejected obligation never made it into the
+		// blockchain, so the host didn't pay their fees.
+		aso.TransactionFees = types.ZeroCurrency
+	case obligationSucceeded:
+		aso.Revenue = revenue
+	case obligationFailed:
+		aso.LostRevenue = revenue
+		aso.LostCollateral = so.RiskedCollateral
+	}
+	return aso
+}
+
+// archiveStorageObligation adds the outcome of a resolved storage obligation to
+// the storage obligation history.
+func archiveStorageObligation(tx *bolt.Tx, so storageObligation, sos storageObligationStatus, height types.BlockHeight, resolvedAt time.Time) error {
+	aso := archivedStorageObligation(so, sos, height, resolvedAt)
+	asoBytes, err := json.Marshal(aso)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketStorageObligationHistory).Put(obligationHistoryKey(resolvedAt, aso.ObligationId), asoBytes)
+}
+
+// StorageObligationHistory returns the storage obligations that were resolved
+// between start and end, oldest first. A zero end time doesn't limit the
+// history, and an empty status returns obligations with any final status.
+func (h *Host) StorageObligationHistory(start, end time.Time, status string) (history []modules.ArchivedStorageObligation, err error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	switch status {
+	case "", obligationRejected.String(), obligationSucceeded.String(), obligationFailed.String():
+	default:
+		return nil, errUnknownObligationStatus
+	}
+
+	var startKey []byte
+	if !start.IsZero() {
+		startKey = obligationHistoryKey(start, types.FileContractID{})
+	}
+	var endKey []byte
+	if !end.IsZero() {
+		endKey = obligationHistoryKey(end.Add(time.Nanosecond), types.FileContractID{})
+	}
+	err = h.db.View(func(tx *bolt.Tx) error {
+		c := tx.Bucket(bucketStorageObligationHistory).Cursor()
+		k, v := c.First()
+		if startKey != nil {
+			k, v = c.Seek(startKey)
+		}
+		for ; k != nil; k, v = c.Next() {
+			if endKey != nil && bytes.Compare(k, endKey) >= 0 {
+				break
+			}
+			var aso modules.ArchivedStorageObligation
+			if err := json.Unmarshal(v, &aso); err != nil {
+				return err
+			}
+			if status != "" && aso.ObligationStatus != status {
+				continue
+			}
+			history = append(history, aso)
+		}
+		return nil
+	})
+	return history, err
+}
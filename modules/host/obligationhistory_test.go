@@ -0,0 +1,125 @@
+package host
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestArchivedStorageObligation checks that the outcome of a resolved storage
+// obligation is computed correctly for every final status.
+func TestArchivedStorageObligation(t *testing.T) {
+	so := storageObligation{
+		ContractCost:             types.NewCurrency64(1),
+		PotentialDownloadRevenue: types.NewCurrency64(2),
+		PotentialStorageRevenue:  types.NewCurrency64(3),
+		PotentialUploadRevenue:   types.NewCurrency64(4),
+		RiskedCollateral:         types.NewCurrency64(5),
+		TransactionFeesAdded:     types.NewCurrency64(6),
+		OriginTransactionSet:     []types.Transaction{{FileContracts: []types.FileContract{{}}}},
+	}
+
+	aso := archivedStorageObligation(so, obligationSucceeded, 10, time.Now())
+	if !aso.Revenue.Equals64(10) || !aso.LostRevenue.IsZero() || !aso.LostCollateral.IsZero() || !aso.TransactionFees.Equals64(6) {
+		t.Error("wrong outcome for a succeeded obligation:", aso)
+	}
+	if aso.ObligationStatus != "obligationSucceeded" || aso.ResolutionHeight != 10 {
+		t.Error("wrong status or height for a succeeded obligation:", aso)
+	}
+	aso = archivedStorageObligation(so, obligationFailed, 10, time.Now())
+	if !aso.Revenue.IsZero() || !aso.LostRevenue.Equals64(10) || !aso.LostCollateral.Equals64(5) {
+		t.Error("wrong outcome for a failed obligation:", aso)
+	}
+	aso = archivedStorageObligation(so, obligationRejected, 10, time.Now())
+	if !aso.Revenue.IsZero() || !aso.LostRevenue.IsZero() || !aso.TransactionFees.IsZero() {
+		t.Error("wrong outcome for a rejected obligation:", aso)
+	}
+}
+
+// TestStorageObligationHistory checks that resolved storage obligations are
+// archived, and that the history can be filtered by time and status.
+func TestStorageObligationHistory(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add two empty storage obligations, which succeed without a storage
+	// proof.
+	start := time.Now()
+	for i := 0; i < 2; i++ {
+		so, err := ht.newTesterStorageObligation()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ht.host.managedLockStorageObligation(so.id())
+		err = ht.host.managedAddStorageObligation(so)
+		ht.host.managedUnlockStorageObligation(so.id())
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if history, err := ht.host.StorageObligationHistory(time.Time{}, time.Time{}, ""); err != nil || len(history) != 0 {
+		t.Fatal("unresolved obligations should not be in the history:", history, err)
+	}
+
+	// Mine until the obligations are resolved.
+	for i := types.BlockHeight(0); i <= revisionSubmissionBuffer*2+2; i++ {
+		_, err := ht.miner.AddBlock()
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = ht.host.tg.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	end := time.Now()
+
+	history, err := ht.host.StorageObligationHistory(time.Time{}, time.Time{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatal("expected two resolved obligations, got", len(history))
+	}
+	for _, aso := range history {
+		if aso.ObligationStatus != obligationSucceeded.String() {
+			t.Fatal("obligation was not archived as succeeded:", aso)
+		}
+		if aso.ResolvedAt.Before(start) || aso.ResolvedAt.After(end) {
+			t.Fatal("wrong resolution time:", aso.ResolvedAt)
+		}
+	}
+	if history[1].ResolvedAt.Before(history[0].ResolvedAt) {
+		t.Fatal("history should be sorted by resolution time")
+	}
+
+	// Filter by status.
+	if history, err := ht.host.StorageObligationHistory(time.Time{}, time.Time{}, obligationSucceeded.String()); err != nil || len(history) != 2 {
+		t.Fatal("expected two succeeded obligations:", len(history), err)
+	}
+	if history, err := ht.host.StorageObligationHistory(time.Time{}, time.Time{}, obligationFailed.String()); err != nil || len(history) != 0 {
+		t.Fatal("expected no failed obligations:", len(history), err)
+	}
+	if _, err := ht.host.StorageObligationHistory(time.Time{}, time.Time{}, "obligationUnresolved"); err != errUnknownObligationStatus {
+		t.Fatal("expected errUnknownObligationStatus, got", err)
+	}
+
+	// Filter by time.
+	if history, err := ht.host.StorageObligationHistory(start, end, ""); err != nil || len(history) != 2 {
+		t.Fatal("expected two obligations within the time range:", len(history), err)
+	}
+	if history, err := ht.host.StorageObligationHistory(history[1].ResolvedAt, time.Time{}, ""); err != nil || len(history) != 1 {
+		t.Fatal("expected one obligation after the start time:", len(history), err)
+	}
+	if history, err := ht.host.StorageObligationHistory(time.Time{}, start, ""); err != nil || len(history) != 0 {
+		t.Fatal("expected no obligations before the end time:", len(history), err)
+	}
+}
@@ -106,6 +106,7 @@ func (h *Host) loadPersistObject(p *persistence) {
 		h.log.Printf("WARN: NetAddress '%v' loaded from persist is invalid: %v", p.Settings.NetAddress, err)
 		h.settings.NetAddress = ""
 	}
+	h.setRateLimits()
 	h.unlockHash = p.UnlockHash
 }
 
@@ -129,6 +130,11 @@ func (h *Host) initDB() (err error) {
 		// database needs to be initialized. Create the database buckets.
 		buckets := [][]byte{
 			bucketActionItems,
+			bucketAnnouncedHosts,
+			bucketAnnouncementScan,
+			bucketPriceHistory,
+			bucketRenters,
+			bucketStorageObligationHistory,
 			bucketStorageObligations,
 		}
 		for _, bucket := range buckets {
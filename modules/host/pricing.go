@@ -0,0 +1,478 @@
+package host
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errAutoPriceBounds is returned if the price floor of an automatic
+	// price is higher than its ceiling.
+	errAutoPriceBounds = errors.New("price floor can't be higher than the price ceiling")
+
+	// errInsufficientPriceSamples is returned if not enough hosts reported
+	// their prices to compute the median prices of the network.
+	errInsufficientPriceSamples = errors.New("not enough hosts responded to compute the network median prices")
+)
+
+// currentPrices returns the prices that the host is currently charging.
+func (h *Host) currentPrices() modules.HostPrices {
+	return modules.HostPrices{
+		ContractPrice:          h.settings.MinContractPrice,
+		DownloadBandwidthPrice: h.settings.MinDownloadBandwidthPrice,
+		StoragePrice:           h.settings.MinStoragePrice,
+		UploadBandwidthPrice:   h.settings.MinUploadBandwidthPrice,
+	}
+}
+
+// boundPrice returns the price, raised to the floor or lowered to the
+// ceiling if it is out of bounds. A ceiling of zero is ignored.
+func boundPrice(price, floor, ceiling types.Currency) types.Currency {
+	if price.Cmp(floor) < 0 {
+		return floor
+	}
+	if !ceiling.IsZero() && price.Cmp(ceiling) > 0 {
+		return ceiling
+	}
+	return price
+}
+
+// checkPriceBounds checks that none of the automatic price floors in the
+// settings is higher than the corresponding ceiling.
+func checkPriceBounds(settings modules.HostInternalSettings) error {
+	bounds := [][2]types.Currency{
+		{settings.ContractPriceFloor, settings.ContractPriceCeiling},
+		{settings.DownloadBandwidthPriceFloor, settings.DownloadBandwidthPriceCeiling},
+		{settings.StoragePriceFloor, settings.StoragePriceCeiling},
+		{settings.UploadBandwidthPriceFloor, settings.UploadBandwidthPriceCeiling},
+	}
+	for _, b := range bounds {
+		if !b[1].IsZero() && b[0].Cmp(b[1]) > 0 {
+			return errAutoPriceBounds
+		}
+	}
+	return nil
+}
+
+// autoPrices computes the prices of the host from the median prices of the
+// network. The bandwidth and contract prices follow the median, the storage
+// price is raised as the host fills up and as its collateral budget gets
+// used up. All prices are kept between the floors and ceilings of the
+// settings.
+func autoPrices(settings modules.HostInternalSettings, median modules.HostPrices, utilization, collateralUsage float64) modules.HostPrices {
+	storageFactor := (1 + autoPriceUtilizationWeight*(utilization-0.5)) * (1 + autoPriceCollateralWeight*collateralUsage)
+	return modules.HostPrices{
+		ContractPrice:          boundPrice(median.ContractPrice, settings.ContractPriceFloor, settings.ContractPriceCeiling),
+		DownloadBandwidthPrice: boundPrice(median.DownloadBandwidthPrice, settings.DownloadBandwidthPriceFloor, settings.DownloadBandwidthPriceCeiling),
+		StoragePrice:           boundPrice(median.StoragePrice.MulFloat(storageFactor), settings.StoragePriceFloor, settings.StoragePriceCeiling),
+		UploadBandwidthPrice:   boundPrice(median.UploadBandwidthPrice, settings.UploadBandwidthPriceFloor, settings.UploadBandwidthPriceCeiling),
+	}
+}
+
+// pricesEqual returns true if two sets of prices are the same.
+func pricesEqual(a, b modules.HostPrices) bool {
+	return a.ContractPrice.Equals(b.ContractPrice) &&
+		a.DownloadBandwidthPrice.Equals(b.DownloadBandwidthPrice) &&
+		a.StoragePrice.Equals(b.StoragePrice) &&
+		a.UploadBandwidthPrice.Equals(b.UploadBandwidthPrice)
+}
+
+// medianCurrency returns the median of a non-empty set of currencies. The
+// set is sorted in place.
+func medianCurrency(cs []types.Currency) types.Currency {
+	sort.Slice(cs, func(i, j int) bool {
+		return cs[i].Cmp(cs[j]) < 0
+	})
+	if len(cs)%2 == 0 {
+		return cs[len(cs)/2-1].Add(cs[len(cs)/2]).Div64(2)
+	}
+	return cs[len(cs)/2]
+}
+
+// medianPrices returns the median of each price reported by a set of hosts.
+func medianPrices(settings []modules.HostExternalSettings) (median modules.HostPrices) {
+	contract := make([]types.Currency, len(settings))
+	download := make([]types.Currency, len(settings))
+	storage := make([]types.Currency, len(settings))
+	upload := make([]types.Currency, len(settings))
+	for i, s := range settings {
+		contract[i] = s.ContractPrice
+		download[i] = s.DownloadBandwidthPrice
+		storage[i] = s.StoragePrice
+		upload[i] = s.UploadBandwidthPrice
+	}
+	median.ContractPrice = medianCurrency(contract)
+	median.DownloadBandwidthPrice = medianCurrency(download)
+	median.StoragePrice = medianCurrency(storage)
+	median.UploadBandwidthPrice = medianCurrency(upload)
+	return median
+}
+
+// A hostAnnouncement is an announcement of another host on the blockchain.
+type hostAnnouncement struct {
+	BlockID types.BlockID
+	Address modules.NetAddress
+}
+
+// An announcementScanner is a consensus set subscriber that tracks the hosts
+// that announced themselves on the blockchain. It is subscribed separately
+// from the host, so that the announcements can be scanned from the beginning
+// of the blockchain the first time the host needs them.
+type announcementScanner struct {
+	h *Host
+}
+
+// ProcessConsensusChange removes the announcements of the reverted blocks
+// from the announced hosts and adds those of the applied blocks.
+func (as announcementScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
+	h := as.h
+	if err := h.tg.Add(); err != nil {
+		return
+	}
+	defer h.tg.Done()
+
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		for _, b := range cc.RevertedBlocks {
+			if err := updateAnnouncements(tx, b, h.publicKey, true); err != nil {
+				return err
+			}
+		}
+		for _, b := range cc.AppliedBlocks {
+			if err := updateAnnouncements(tx, b, h.publicKey, false); err != nil {
+				return err
+			}
+		}
+		return tx.Bucket(bucketAnnouncementScan).Put(keyAnnouncementScanChange, cc.ID[:])
+	})
+	if err != nil {
+		h.log.Println("ERROR: unable to update the announced hosts:", err)
+	}
+}
+
+// updateAnnouncements applies or reverts the announcements of other hosts in
+// a block. The announcements of ownKey are ignored.
+func updateAnnouncements(tx *bolt.Tx, b types.Block, ownKey types.SiaPublicKey, revert bool) error {
+	bah := tx.Bucket(bucketAnnouncedHosts)
+	for _, txn := range b.Transactions {
+		for _, arb := range txn.ArbitraryData {
+			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			if err != nil || pubKey.String() == ownKey.String() {
+				continue
+			}
+			key := []byte(pubKey.String())
+			var announcements []hostAnnouncement
+			if v := bah.Get(key); v != nil {
+				if err := encoding.Unmarshal(v, &announcements); err != nil {
+					return err
+				}
+			}
+			if revert {
+				var kept []hostAnnouncement
+				for _, ha := range announcements {
+					if ha.BlockID != b.ID() {
+						kept = append(kept, ha)
+					}
+				}
+				announcements = kept
+			} else {
+				announcements = append(announcements, hostAnnouncement{BlockID: b.ID(), Address: addr})
+				if len(announcements) > maxHostAnnouncements {
+					announcements = announcements[len(announcements)-maxHostAnnouncements:]
+				}
+			}
+			if len(announcements) == 0 {
+				err = bah.Delete(key)
+			} else {
+				err = bah.Put(key, encoding.Marshal(announcements))
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resetAnnouncedHosts removes all announced hosts and the progress of the
+// announcement scan.
+func resetAnnouncedHosts(tx *bolt.Tx) error {
+	if err := tx.DeleteBucket(bucketAnnouncedHosts); err != nil {
+		return err
+	}
+	if _, err := tx.CreateBucket(bucketAnnouncedHosts); err != nil {
+		return err
+	}
+	return tx.Bucket(bucketAnnouncementScan).Delete(keyAnnouncementScanChange)
+}
+
+// managedScanAnnouncements subscribes the announcement scanner to the
+// consensus set the first time the host needs the announced hosts. The first
+// scan starts at the beginning of the blockchain, later ones resume from the
+// last processed change. This is a blocking call that will not return until
+// the scanner has caught up to the current block.
+func (h *Host) managedScanAnnouncements() error {
+	h.announcementScan.Lock()
+	defer h.announcementScan.Unlock()
+	if h.announcementsScanned {
+		return nil
+	}
+
+	change := modules.ConsensusChangeBeginning
+	err := h.db.Update(func(tx *bolt.Tx) error {
+		if id := tx.Bucket(bucketAnnouncementScan).Get(keyAnnouncementScanChange); id != nil {
+			copy(change[:], id)
+			return nil
+		}
+		return resetAnnouncedHosts(tx)
+	})
+	if err != nil {
+		return err
+	}
+	scanner := announcementScanner{h}
+	err = h.cs.ConsensusSetSubscribe(scanner, change, h.tg.StopChan())
+	if err == modules.ErrInvalidConsensusChangeID {
+		// Rescan the blockchain if the consensus set doesn't know the change.
+		if err := h.db.Update(resetAnnouncedHosts); err != nil {
+			return err
+		}
+		err = h.cs.ConsensusSetSubscribe(scanner, modules.ConsensusChangeBeginning, h.tg.StopChan())
+	}
+	if err != nil {
+		return err
+	}
+	h.tg.OnStop(func() {
+		h.cs.Unsubscribe(scanner)
+	})
+	h.announcementsScanned = true
+	return nil
+}
+
+// managedFetchSettings requests the external settings of another host.
+func (h *Host) managedFetchSettings(addr modules.NetAddress, pubKey types.SiaPublicKey) (settings modules.HostExternalSettings, err error) {
+	conn, err := h.dependencies.DialTimeout(addr, autoPriceScanTimeout)
+	if err != nil {
+		return settings, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(autoPriceScanTimeout))
+
+	err = encoding.WriteObject(conn, modules.RPCSettings)
+	if err != nil {
+		return settings, err
+	}
+	var pk crypto.PublicKey
+	copy(pk[:], pubKey.Key)
+	err = crypto.ReadSignedObject(conn, &settings, modules.NegotiateMaxHostExternalSettingsLen, pk)
+	return settings, err
+}
+
+// managedNetworkMedianPrices queries a random sample of the announced hosts
+// for their prices and returns the median prices of the hosts that responded
+// and are accepting contracts, together with the number of those hosts.
+func (h *Host) managedNetworkMedianPrices() (modules.HostPrices, int, error) {
+	type announcedHost struct {
+		addr   modules.NetAddress
+		pubKey types.SiaPublicKey
+	}
+	if err := h.managedScanAnnouncements(); err != nil {
+		return modules.HostPrices{}, 0, errors.AddContext(err, "unable to scan the host announcements")
+	}
+	var hosts []announcedHost
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketAnnouncedHosts).ForEach(func(k, v []byte) error {
+			var announcements []hostAnnouncement
+			if err := encoding.Unmarshal(v, &announcements); err != nil || len(announcements) == 0 {
+				return err
+			}
+			var pubKey types.SiaPublicKey
+			pubKey.LoadString(string(k))
+			hosts = append(hosts, announcedHost{addr: announcements[len(announcements)-1].Address, pubKey: pubKey})
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.HostPrices{}, 0, err
+	}
+
+	perm := fastrand.Perm(len(hosts))
+	if len(perm) > autoPriceSampleSize {
+		perm = perm[:autoPriceSampleSize]
+	}
+	var samples []modules.HostExternalSettings
+	for _, i := range perm {
+		select {
+		case <-h.tg.StopChan():
+			return modules.HostPrices{}, 0, errHostClosed
+		default:
+		}
+		settings, err := h.managedFetchSettings(hosts[i].addr, hosts[i].pubKey)
+		if err != nil {
+			h.log.Debugf("Unable to fetch the prices of host %v: %v", hosts[i].addr, err)
+			continue
+		}
+		if settings.AcceptingContracts {
+			samples = append(samples, settings)
+		}
+	}
+	if len(samples) < autoPriceMinSamples {
+		return modules.HostPrices{}, len(samples), errInsufficientPriceSamples
+	}
+	return medianPrices(samples), len(samples), nil
+}
+
+// managedUtilization returns the fraction of the host's storage that is in
+// use.
+func (h *Host) managedUtilization() float64 {
+	var capacity, remaining uint64
+	for _, sf := range h.StorageFolders() {
+		capacity += sf.Capacity
+		remaining += sf.CapacityRemaining
+	}
+	if capacity == 0 {
+		return 0
+	}
+	return float64(capacity-remaining) / float64(capacity)
+}
+
+// collateralBudgetUsage returns the fraction of the host's collateral budget
+// that is locked or risked in contracts.
+func (h *Host) collateralBudgetUsage() float64 {
+	if h.settings.CollateralBudget.IsZero() {
+		return 0
+	}
+	used := h.financialMetrics.LockedStorageCollateral.Add(h.financialMetrics.RiskedStorageCollateral)
+	usage, _ := new(big.Rat).SetFrac(used.Big(), h.settings.CollateralBudget.Big()).Float64()
+	if usage > 1 {
+		usage = 1
+	}
+	return usage
+}
+
+// addPriceChange adds a price change to the price history of the host,
+// removing the oldest change if the history is full.
+func addPriceChange(tx *bolt.Tx, change modules.HostPriceChange) error {
+	bph := tx.Bucket(bucketPriceHistory)
+	seq, err := bph.NextSequence()
+	if err != nil {
+		return err
+	}
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, seq)
+	changeBytes, err := json.Marshal(change)
+	if err != nil {
+		return err
+	}
+	if err := bph.Put(key, changeBytes); err != nil {
+		return err
+	}
+
+	// Remove the oldest change once the history is full.
+	if seq <= maxPriceHistory {
+		return nil
+	}
+	binary.BigEndian.PutUint64(key, seq-maxPriceHistory)
+	return bph.Delete(key)
+}
+
+// managedAutoPrice recomputes the prices of the host from the network median
+// and its own utilization and collateral budget usage. If the prices change,
+// the new prices are applied and the change is added to the price history.
+func (h *Host) managedAutoPrice() error {
+	median, samples, err := h.managedNetworkMedianPrices()
+	if err != nil {
+		return err
+	}
+	utilization := h.managedUtilization()
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.settings.AutoPricing {
+		return nil
+	}
+	collateralUsage := h.collateralBudgetUsage()
+	oldPrices := h.currentPrices()
+	newPrices := autoPrices(h.settings, median, utilization, collateralUsage)
+	if pricesEqual(newPrices, oldPrices) {
+		return nil
+	}
+
+	change := modules.HostPriceChange{
+		BlockHeight:           h.blockHeight,
+		Timestamp:             time.Now(),
+		OldPrices:             oldPrices,
+		NewPrices:             newPrices,
+		NetworkMedian:         median,
+		NetworkSamples:        samples,
+		Utilization:           utilization,
+		CollateralBudgetUsage: collateralUsage,
+	}
+	err = h.db.Update(func(tx *bolt.Tx) error {
+		return addPriceChange(tx, change)
+	})
+	if err != nil {
+		return errors.AddContext(err, "unable to record price change")
+	}
+
+	h.settings.MinContractPrice = newPrices.ContractPrice
+	h.settings.MinDownloadBandwidthPrice = newPrices.DownloadBandwidthPrice
+	h.settings.MinStoragePrice = newPrices.StoragePrice
+	h.settings.MinUploadBandwidthPrice = newPrices.UploadBandwidthPrice
+	h.revisionNumber++
+	return h.saveSync()
+}
+
+// threadedAutoPrice periodically recomputes the prices of the host while
+// automatic pricing is enabled.
+func (h *Host) threadedAutoPrice(closeChan chan struct{}) {
+	defer close(closeChan)
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(autoPriceFrequency):
+		}
+
+		h.mu.RLock()
+		enabled := h.settings.AutoPricing
+		h.mu.RUnlock()
+		if !enabled {
+			continue
+		}
+		if err := h.managedAutoPrice(); err != nil {
+			h.log.Println("WARN: unable to update the prices of the host automatically:", err)
+		}
+	}
+}
+
+// PriceHistory returns the automatic price changes of the host, oldest
+// first.
+func (h *Host) PriceHistory() (history []modules.HostPriceChange, err error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketPriceHistory).ForEach(func(_, v []byte) error {
+			var change modules.HostPriceChange
+			if err := json.Unmarshal(v, &change); err != nil {
+				return err
+			}
+			history = append(history, change)
+			return nil
+		})
+	})
+	return history, err
+}
@@ -0,0 +1,289 @@
+package host
+
+import (
+	"path/filepath"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+// TestAutoPrices checks that the automatic prices follow the network median
+// and respect the floors and ceilings of the settings.
+func TestAutoPrices(t *testing.T) {
+	median := modules.HostPrices{
+		ContractPrice:          types.NewCurrency64(1000),
+		DownloadBandwidthPrice: types.NewCurrency64(2000),
+		StoragePrice:           types.NewCurrency64(3000),
+		UploadBandwidthPrice:   types.NewCurrency64(4000),
+	}
+
+	// A half full host without collateral in use charges the median.
+	var settings modules.HostInternalSettings
+	if prices := autoPrices(settings, median, 0.5, 0); !pricesEqual(prices, median) {
+		t.Fatal("host should charge the median prices:", prices)
+	}
+
+	// The storage price should rise with the utilization and the collateral
+	// budget usage.
+	empty := autoPrices(settings, median, 0, 0)
+	full := autoPrices(settings, median, 1, 0)
+	fullCollateral := autoPrices(settings, median, 1, 1)
+	if empty.StoragePrice.Cmp(median.StoragePrice) >= 0 {
+		t.Error("empty host should charge less than the median:", empty.StoragePrice)
+	}
+	if full.StoragePrice.Cmp(median.StoragePrice) <= 0 {
+		t.Error("full host should charge more than the median:", full.StoragePrice)
+	}
+	if fullCollateral.StoragePrice.Cmp(full.StoragePrice) <= 0 {
+		t.Error("used collateral budget should raise the price:", fullCollateral.StoragePrice)
+	}
+	if !full.UploadBandwidthPrice.Equals(median.UploadBandwidthPrice) {
+		t.Error("bandwidth prices should follow the median")
+	}
+
+	// The prices should be bounded by the floors and ceilings.
+	settings.ContractPriceFloor = types.NewCurrency64(1500)
+	settings.StoragePriceCeiling = types.NewCurrency64(3100)
+	settings.UploadBandwidthPriceCeiling = types.NewCurrency64(5000)
+	prices := autoPrices(settings, median, 1, 1)
+	if !prices.ContractPrice.Equals(settings.ContractPriceFloor) {
+		t.Error("contract price should be raised to the floor:", prices.ContractPrice)
+	}
+	if !prices.StoragePrice.Equals(settings.StoragePriceCeiling) {
+		t.Error("storage price should be lowered to the ceiling:", prices.StoragePrice)
+	}
+	if !prices.UploadBandwidthPrice.Equals(median.UploadBandwidthPrice) {
+		t.Error("upload price within the bounds should not change:", prices.UploadBandwidthPrice)
+	}
+}
+
+// TestMedianPrices checks the computation of the network median prices.
+func TestMedianPrices(t *testing.T) {
+	settings := []modules.HostExternalSettings{
+		{StoragePrice: types.NewCurrency64(30), ContractPrice: types.NewCurrency64(4)},
+		{StoragePrice: types.NewCurrency64(10), ContractPrice: types.NewCurrency64(1)},
+		{StoragePrice: types.NewCurrency64(20), ContractPrice: types.NewCurrency64(3)},
+	}
+	median := medianPrices(settings)
+	if !median.StoragePrice.Equals64(20) || !median.ContractPrice.Equals64(3) {
+		t.Fatal("wrong median for an odd number of hosts:", median)
+	}
+	settings = append(settings, modules.HostExternalSettings{StoragePrice: types.NewCurrency64(40), ContractPrice: types.NewCurrency64(2)})
+	median = medianPrices(settings)
+	if !median.StoragePrice.Equals64(25) || !median.ContractPrice.Equals64(2) {
+		t.Fatal("wrong median for an even number of hosts:", median)
+	}
+}
+
+// TestPriceBounds checks that the host rejects settings with price floors
+// that are higher than their ceilings.
+func TestPriceBounds(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	settings := ht.host.InternalSettings()
+	settings.StoragePriceFloor = types.NewCurrency64(2)
+	settings.StoragePriceCeiling = types.NewCurrency64(1)
+	if err := ht.host.SetInternalSettings(settings); err == nil {
+		t.Fatal("expected settings with a floor above the ceiling to be rejected")
+	}
+
+	// A ceiling of zero doesn't bound the price.
+	settings.StoragePriceCeiling = types.ZeroCurrency
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestAutoPricing checks that the host learns about other hosts from their
+// announcements and sets its prices from their median prices.
+func TestAutoPricing(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Without any announced hosts, the median can't be computed.
+	if _, _, err := ht.host.managedNetworkMedianPrices(); err != errInsufficientPriceSamples {
+		t.Fatal("expected errInsufficientPriceSamples, got", err)
+	}
+
+	// Create and announce a second host that accepts contracts.
+	h2, err := New(ht.cs, ht.gateway, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, "host2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+	settings2 := h2.InternalSettings()
+	settings2.AcceptingContracts = true
+	settings2.MinStoragePrice = types.NewCurrency64(1000)
+	settings2.MinUploadBandwidthPrice = types.NewCurrency64(2000)
+	if err := h2.SetInternalSettings(settings2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Announce(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The host should learn the prices of the second host.
+	median, samples, err := ht.host.managedNetworkMedianPrices()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if samples != 1 {
+		t.Fatal("expected one sample, got", samples)
+	}
+	if !median.StoragePrice.Equals(settings2.MinStoragePrice) || !median.UploadBandwidthPrice.Equals(settings2.MinUploadBandwidthPrice) {
+		t.Fatal("median doesn't match the prices of the other host:", median)
+	}
+
+	// Prices shouldn't change while automatic pricing is disabled.
+	oldSettings := ht.host.InternalSettings()
+	if err := ht.host.managedAutoPrice(); err != nil {
+		t.Fatal(err)
+	}
+	if history, err := ht.host.PriceHistory(); err != nil || len(history) != 0 {
+		t.Fatal("prices changed while automatic pricing was disabled", history, err)
+	}
+
+	// Enable automatic pricing with a floor for the upload price.
+	settings := oldSettings
+	settings.AutoPricing = true
+	settings.UploadBandwidthPriceFloor = types.NewCurrency64(3000)
+	if err := ht.host.SetInternalSettings(settings); err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedAutoPrice(); err != nil {
+		t.Fatal(err)
+	}
+	newSettings := ht.host.InternalSettings()
+	if !newSettings.MinUploadBandwidthPrice.Equals(settings.UploadBandwidthPriceFloor) {
+		t.Error("upload price should be set to the floor:", newSettings.MinUploadBandwidthPrice)
+	}
+	if !newSettings.MinDownloadBandwidthPrice.Equals(settings2.MinDownloadBandwidthPrice) {
+		t.Error("download price should be set to the median:", newSettings.MinDownloadBandwidthPrice)
+	}
+	es := ht.host.ExternalSettings()
+	if !es.UploadBandwidthPrice.Equals(newSettings.MinUploadBandwidthPrice) {
+		t.Error("external settings don't reflect the new prices")
+	}
+
+	// The change should be recorded in the history.
+	history, err := ht.host.PriceHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 {
+		t.Fatal("expected one price change, got", len(history))
+	}
+	change := history[0]
+	if !change.OldPrices.UploadBandwidthPrice.Equals(oldSettings.MinUploadBandwidthPrice) ||
+		!change.NewPrices.UploadBandwidthPrice.Equals(newSettings.MinUploadBandwidthPrice) ||
+		!change.NetworkMedian.StoragePrice.Equals(settings2.MinStoragePrice) || change.NetworkSamples != 1 {
+		t.Fatal("price change was not recorded correctly:", change)
+	}
+
+	// Recomputing the same prices shouldn't add another change.
+	if err := ht.host.managedAutoPrice(); err != nil {
+		t.Fatal(err)
+	}
+	if history, err := ht.host.PriceHistory(); err != nil || len(history) != 1 {
+		t.Fatal("unchanged prices should not be recorded", len(history), err)
+	}
+}
+
+// TestAnnouncementScan checks that the host finds the hosts that announced
+// themselves before it first needed them, and that reverted announcements
+// are removed.
+func TestAnnouncementScan(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Announce a second host before the host scans the announcements.
+	h2, err := New(ht.cs, ht.gateway, ht.tpool, ht.wallet, "localhost:0", filepath.Join(ht.persistDir, "host2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h2.Close()
+	settings2 := h2.InternalSettings()
+	settings2.AcceptingContracts = true
+	if err := h2.SetInternalSettings(settings2); err != nil {
+		t.Fatal(err)
+	}
+	if err := h2.Announce(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.miner.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+	if _, samples, err := ht.host.managedNetworkMedianPrices(); err != nil || samples != 1 {
+		t.Fatal("host announced before the first scan was not found:", samples, err)
+	}
+
+	// Reverting the latest announcement of a host restores its previous
+	// address, reverting all of them removes the host.
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	announce := func(addr modules.NetAddress) types.Block {
+		ann, err := modules.CreateAnnouncement(addr, spk, sk)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.Block{Nonce: types.BlockNonce{byte(len(addr))}, Transactions: []types.Transaction{{ArbitraryData: [][]byte{ann}}}}
+	}
+	b1, b2 := announce("foo.com:1234"), announce("foobar.com:1234")
+	announcements := func() (has []hostAnnouncement) {
+		t.Helper()
+		err := ht.host.db.View(func(tx *bolt.Tx) error {
+			v := tx.Bucket(bucketAnnouncedHosts).Get([]byte(spk.String()))
+			if v == nil {
+				return nil
+			}
+			return encoding.Unmarshal(v, &has)
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return has
+	}
+	scanner := announcementScanner{ht.host}
+	scanner.ProcessConsensusChange(modules.ConsensusChange{AppliedBlocks: []types.Block{b1, b2}})
+	if has := announcements(); len(has) != 2 || has[1].Address != "foobar.com:1234" {
+		t.Fatal("wrong announcements:", has)
+	}
+	scanner.ProcessConsensusChange(modules.ConsensusChange{RevertedBlocks: []types.Block{b2}})
+	if has := announcements(); len(has) != 1 || has[0].Address != "foo.com:1234" {
+		t.Fatal("wrong announcements after revert:", has)
+	}
+	scanner.ProcessConsensusChange(modules.ConsensusChange{RevertedBlocks: []types.Block{b1}})
+	if has := announcements(); len(has) != 0 {
+		t.Fatal("reverted host was not removed:", has)
+	}
+}
@@ -0,0 +1,287 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errNegativePriceMultiplier is returned if a renter policy has a
+	// negative price multiplier.
+	errNegativePriceMultiplier = errors.New("price multiplier of a renter policy cannot be negative")
+
+	// errPriceMultiplierTooHigh is returned if a renter policy has a price
+	// multiplier above modules.MaxRenterPriceMultiplier.
+	errPriceMultiplierTooHigh = fmt.Errorf("price multiplier of a renter policy cannot exceed %v", modules.MaxRenterPriceMultiplier)
+
+	// errRenterDenied is returned if a renter that was denied by the host
+	// operator tries to form, renew or revise a contract.
+	errRenterDenied = ErrorCommunication("host does not accept contracts from this renter")
+
+	// errRenterStorageLimit is returned if a revision would make the renter
+	// store more data with the host than its policy allows.
+	errRenterStorageLimit = ErrorCommunication("renter has reached the storage limit of the host")
+)
+
+// renterRecord is the persisted reputation of a renter, along with the file
+// sizes of its unresolved contracts, keyed by the string form of the contract
+// ids. Contracts that were renewed are removed from the set, the data that
+// they protect is now protected by the renewed contract.
+type renterRecord struct {
+	Reputation      modules.RenterReputation
+	ActiveContracts map[string]uint64
+}
+
+// renterKey returns the public key of the renter of the storage obligation.
+// The key is unknown if the obligation doesn't have a revision.
+//
+// The key is the renter key in the unlock conditions of the contract, which
+// renters may choose per contract. The host has no other way to recognize a
+// renter, so reputations and policies apply per key: a renter that forms a
+// contract with a new key appears as a new renter. Renewals carry the policy
+// over to the key of the renewed contract.
+func (so storageObligation) renterKey() (types.SiaPublicKey, bool) {
+	if len(so.RevisionTransactionSet) == 0 {
+		return types.SiaPublicKey{}, false
+	}
+	txn := so.RevisionTransactionSet[len(so.RevisionTransactionSet)-1]
+	if len(txn.FileContractRevisions) == 0 || len(txn.FileContractRevisions[0].UnlockConditions.PublicKeys) == 0 {
+		return types.SiaPublicKey{}, false
+	}
+	return txn.FileContractRevisions[0].UnlockConditions.PublicKeys[0], true
+}
+
+// getRenterRecord returns the record of the renter, or an empty record if the
+// host doesn't know the renter yet.
+func getRenterRecord(tx *bolt.Tx, renter types.SiaPublicKey) (rr renterRecord, err error) {
+	rrBytes := tx.Bucket(bucketRenters).Get([]byte(renter.String()))
+	if rrBytes != nil {
+		err = json.Unmarshal(rrBytes, &rr)
+		if err != nil {
+			return renterRecord{}, err
+		}
+	}
+	rr.Reputation.PublicKey = renter
+	if rr.ActiveContracts == nil {
+		rr.ActiveContracts = make(map[string]uint64)
+	}
+	return rr, nil
+}
+
+// putRenterRecord places the record of a renter into the database, updating
+// the statistics that are derived from its active contracts.
+func putRenterRecord(tx *bolt.Tx, rr renterRecord) error {
+	rr.Reputation.ActiveContracts = uint64(len(rr.ActiveContracts))
+	rr.Reputation.DataStored = 0
+	for _, size := range rr.ActiveContracts {
+		rr.Reputation.DataStored += size
+	}
+	rrBytes, err := json.Marshal(rr)
+	if err != nil {
+		return err
+	}
+	return tx.Bucket(bucketRenters).Put([]byte(rr.Reputation.PublicKey.String()), rrBytes)
+}
+
+// addRenterContract adds a new storage obligation to the reputation of its
+// renter.
+func addRenterContract(tx *bolt.Tx, so storageObligation) error {
+	renter, ok := so.renterKey()
+	if !ok {
+		return nil
+	}
+	rr, err := getRenterRecord(tx, renter)
+	if err != nil {
+		return err
+	}
+	rr.ActiveContracts[so.id().String()] = so.fileSize()
+	rr.Reputation.Contracts++
+	rr.Reputation.LastSeen = time.Now()
+	if rr.Reputation.FirstSeen.IsZero() {
+		rr.Reputation.FirstSeen = rr.Reputation.LastSeen
+	}
+	return putRenterRecord(tx, rr)
+}
+
+// updateRenterContract adds a modified storage obligation, and the bandwidth
+// that was used by it, to the reputation of its renter.
+func updateRenterContract(tx *bolt.Tx, so storageObligation, bandwidth uint64) error {
+	renter, ok := so.renterKey()
+	if !ok {
+		return nil
+	}
+	rr, err := getRenterRecord(tx, renter)
+	if err != nil {
+		return err
+	}
+	soid := so.id().String()
+	if _, exists := rr.ActiveContracts[soid]; exists {
+		rr.ActiveContracts[soid] = so.fileSize()
+	}
+	rr.Reputation.BandwidthUsed += bandwidth
+	rr.Reputation.LastSeen = time.Now()
+	return putRenterRecord(tx, rr)
+}
+
+// resolveRenterContract removes a resolved storage obligation from the active
+// contracts of its renter. An obligation that never made it into the
+// blockchain, or whose renter never uploaded or downloaded any data, counts
+// as abandoned. Renewed contracts are not active anymore, and are therefore
+// never counted as abandoned.
+func resolveRenterContract(tx *bolt.Tx, so storageObligation, sos storageObligationStatus) error {
+	renter, ok := so.renterKey()
+	if !ok {
+		return nil
+	}
+	rr, err := getRenterRecord(tx, renter)
+	if err != nil {
+		return err
+	}
+	soid := so.id().String()
+	if _, exists := rr.ActiveContracts[soid]; !exists {
+		return nil
+	}
+	delete(rr.ActiveContracts, soid)
+	unused := so.fileSize() == 0 && so.PotentialUploadRevenue.IsZero() && so.PotentialDownloadRevenue.IsZero()
+	if sos == obligationRejected || unused {
+		rr.Reputation.AbandonedContracts++
+	}
+	return putRenterRecord(tx, rr)
+}
+
+// managedRenewRenterContract removes a storage obligation that was renewed
+// from the active contracts of its renter. If the renter protects the renewed
+// contract with a different key, the policy of the renter is carried over to
+// that key, unless the key already has a policy.
+func (h *Host) managedRenewRenterContract(so storageObligation, newRenter types.SiaPublicKey) error {
+	renter, ok := so.renterKey()
+	if !ok {
+		return nil
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		rr, err := getRenterRecord(tx, renter)
+		if err != nil {
+			return err
+		}
+		delete(rr.ActiveContracts, so.id().String())
+		err = putRenterRecord(tx, rr)
+		if err != nil || newRenter.String() == renter.String() || rr.Reputation.Policy == (modules.RenterPolicy{}) {
+			return err
+		}
+		nrr, err := getRenterRecord(tx, newRenter)
+		if err != nil {
+			return err
+		}
+		if nrr.Reputation.Policy != (modules.RenterPolicy{}) {
+			return nil
+		}
+		nrr.Reputation.Policy = rr.Reputation.Policy
+		return putRenterRecord(tx, nrr)
+	})
+}
+
+// managedRenterSettings applies the policy of the renter to the settings of
+// the host. errRenterDenied is returned if the host doesn't accept the renter.
+func (h *Host) managedRenterSettings(renter types.SiaPublicKey, settings modules.HostExternalSettings) (modules.HostExternalSettings, error) {
+	var policy modules.RenterPolicy
+	err := h.db.View(func(tx *bolt.Tx) error {
+		rr, err := getRenterRecord(tx, renter)
+		policy = rr.Reputation.Policy
+		return err
+	})
+	if err != nil {
+		return settings, ErrorInternal(err.Error())
+	}
+	if policy.Deny {
+		return settings, errRenterDenied
+	}
+	if policy.PriceMultiplier != 0 {
+		settings.ContractPrice = settings.ContractPrice.MulFloat(policy.PriceMultiplier)
+		settings.DownloadBandwidthPrice = settings.DownloadBandwidthPrice.MulFloat(policy.PriceMultiplier)
+		settings.StoragePrice = settings.StoragePrice.MulFloat(policy.PriceMultiplier)
+		settings.UploadBandwidthPrice = settings.UploadBandwidthPrice.MulFloat(policy.PriceMultiplier)
+	}
+	return settings, nil
+}
+
+// managedCheckRenterStorage returns errRenterStorageLimit if the renter would
+// store more data than its policy allows once the storage obligation has
+// grown to the given file size.
+func (h *Host) managedCheckRenterStorage(so storageObligation, fileSize uint64) error {
+	renter, ok := so.renterKey()
+	if !ok {
+		return nil
+	}
+	return h.db.View(func(tx *bolt.Tx) error {
+		rr, err := getRenterRecord(tx, renter)
+		if err != nil {
+			return ErrorInternal(err.Error())
+		}
+		if rr.Reputation.Policy.MaxStorage == 0 {
+			return nil
+		}
+		stored := fileSize
+		for soid, size := range rr.ActiveContracts {
+			if soid != so.id().String() {
+				stored += size
+			}
+		}
+		if stored > rr.Reputation.Policy.MaxStorage {
+			return errRenterStorageLimit
+		}
+		return nil
+	})
+}
+
+// RenterReputations returns the reputations of the renters that have formed
+// contracts with the host or that have a policy.
+func (h *Host) RenterReputations() (reputations []modules.RenterReputation, err error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketRenters).ForEach(func(_, rrBytes []byte) error {
+			var rr renterRecord
+			if err := json.Unmarshal(rrBytes, &rr); err != nil {
+				return err
+			}
+			reputations = append(reputations, rr.Reputation)
+			return nil
+		})
+	})
+	return reputations, err
+}
+
+// SetRenterPolicy sets the policy that the host enforces for the renter with
+// the given public key. The policy applies to new contracts, renewals and
+// revisions, existing contracts are not affected otherwise.
+func (h *Host) SetRenterPolicy(renter types.SiaPublicKey, policy modules.RenterPolicy) error {
+	if err := h.tg.Add(); err != nil {
+		return err
+	}
+	defer h.tg.Done()
+
+	if policy.PriceMultiplier < 0 {
+		return errNegativePriceMultiplier
+	}
+	if policy.PriceMultiplier > modules.MaxRenterPriceMultiplier {
+		return errPriceMultiplierTooHigh
+	}
+	return h.db.Update(func(tx *bolt.Tx) error {
+		rr, err := getRenterRecord(tx, renter)
+		if err != nil {
+			return err
+		}
+		rr.Reputation.Policy = policy
+		return putRenterRecord(tx, rr)
+	})
+}
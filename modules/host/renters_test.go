@@ -0,0 +1,262 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// newTesterRenterObligation creates a storage obligation that belongs to the
+// provided renter and adds it to the host.
+func (ht *hostTester) newTesterRenterObligation(renter types.SiaPublicKey) (storageObligation, error) {
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		return storageObligation{}, err
+	}
+	fc := so.OriginTransactionSet[len(so.OriginTransactionSet)-1].FileContracts[0]
+	so.RevisionTransactionSet = []types.Transaction{{
+		FileContractRevisions: []types.FileContractRevision{{
+			ParentID: so.id(),
+			UnlockConditions: types.UnlockConditions{
+				PublicKeys: []types.SiaPublicKey{renter, ht.host.publicKey},
+			},
+			NewFileSize:           fc.FileSize,
+			NewWindowStart:        fc.WindowStart,
+			NewWindowEnd:          fc.WindowEnd,
+			NewValidProofOutputs:  fc.ValidProofOutputs,
+			NewMissedProofOutputs: fc.MissedProofOutputs,
+		}},
+	}}
+	ht.host.managedLockStorageObligation(so.id())
+	defer ht.host.managedUnlockStorageObligation(so.id())
+	return so, ht.host.managedAddStorageObligation(so)
+}
+
+// renterReputation returns the reputation of the renter.
+func (ht *hostTester) renterReputation(renter types.SiaPublicKey) (modules.RenterReputation, error) {
+	reputations, err := ht.host.RenterReputations()
+	if err != nil {
+		return modules.RenterReputation{}, err
+	}
+	for _, rr := range reputations {
+		if rr.PublicKey.String() == renter.String() {
+			return rr, nil
+		}
+	}
+	return modules.RenterReputation{}, errNoStorageObligation
+}
+
+// TestRenterReputation checks that the host tracks the contracts, the stored
+// data and the bandwidth of its renters.
+func TestRenterReputation(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	_, pk := crypto.GenerateKeyPair()
+	renter := types.Ed25519PublicKey(pk)
+	so1, err := ht.newTesterRenterObligation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	so2, err := ht.newTesterRenterObligation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err := ht.renterReputation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Contracts != 2 || rr.ActiveContracts != 2 || rr.DataStored != 0 || rr.FirstSeen.IsZero() {
+		t.Fatal("new contracts were not added to the reputation:", rr)
+	}
+
+	// Upload a sector to the first contract.
+	ht.host.managedLockStorageObligation(so1.id())
+	err = ht.host.managedUseContractBandwidth(so1.id(), modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, data := randSector()
+	so1.SectorRoots = []crypto.Hash{root}
+	so1.RevisionTransactionSet[0].FileContractRevisions[0].NewFileSize = modules.SectorSize
+	so1.PotentialUploadRevenue = types.NewCurrency64(1)
+	ht.host.mu.Lock()
+	err = ht.host.modifyStorageObligation(so1, nil, []crypto.Hash{root}, [][]byte{data})
+	ht.host.mu.Unlock()
+	ht.host.managedUnlockStorageObligation(so1.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err = ht.renterReputation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.DataStored != modules.SectorSize || rr.BandwidthUsed != modules.SectorSize {
+		t.Fatal("revision was not added to the reputation:", rr)
+	}
+
+	// Resolve both contracts. The unused contract was abandoned.
+	ht.host.mu.Lock()
+	err = composeErrors(ht.host.removeStorageObligation(so1, obligationSucceeded), ht.host.removeStorageObligation(so2, obligationSucceeded))
+	ht.host.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err = ht.renterReputation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Contracts != 2 || rr.ActiveContracts != 0 || rr.AbandonedContracts != 1 || rr.DataStored != 0 {
+		t.Fatal("resolved contracts were not removed from the reputation:", rr)
+	}
+
+	// A renewed contract is never abandoned.
+	so3, err := ht.newTesterRenterObligation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ht.host.managedRenewRenterContract(so3, renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.mu.Lock()
+	err = ht.host.removeStorageObligation(so3, obligationSucceeded)
+	ht.host.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err = ht.renterReputation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Contracts != 3 || rr.ActiveContracts != 0 || rr.AbandonedContracts != 1 {
+		t.Fatal("renewed contract was counted as abandoned:", rr)
+	}
+}
+
+// TestRenterPolicy checks that the host enforces the policies that were set
+// for its renters.
+func TestRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	_, pk := crypto.GenerateKeyPair()
+	renter := types.Ed25519PublicKey(pk)
+	if err := ht.host.SetRenterPolicy(renter, modules.RenterPolicy{PriceMultiplier: -1}); err != errNegativePriceMultiplier {
+		t.Fatal("expected errNegativePriceMultiplier, got", err)
+	}
+	if err := ht.host.SetRenterPolicy(renter, modules.RenterPolicy{PriceMultiplier: modules.MaxRenterPriceMultiplier + 1}); err != errPriceMultiplierTooHigh {
+		t.Fatal("expected errPriceMultiplierTooHigh, got", err)
+	}
+
+	// Without a policy, the renter gets the host's settings.
+	settings := ht.host.ExternalSettings()
+	rs, err := ht.host.managedRenterSettings(renter, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.StoragePrice.Equals(settings.StoragePrice) || !rs.ContractPrice.Equals(settings.ContractPrice) {
+		t.Fatal("renter without a policy should get the host's prices")
+	}
+
+	// A price multiplier applies to all of the prices.
+	err = ht.host.SetRenterPolicy(renter, modules.RenterPolicy{PriceMultiplier: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err = ht.host.managedRenterSettings(renter, settings)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !rs.StoragePrice.Equals(settings.StoragePrice.Mul64(2)) || !rs.ContractPrice.Equals(settings.ContractPrice.Mul64(2)) ||
+		!rs.UploadBandwidthPrice.Equals(settings.UploadBandwidthPrice.Mul64(2)) || !rs.DownloadBandwidthPrice.Equals(settings.DownloadBandwidthPrice.Mul64(2)) {
+		t.Fatal("price multiplier was not applied")
+	}
+	if !rs.Collateral.Equals(settings.Collateral) {
+		t.Fatal("price multiplier should not change the collateral")
+	}
+
+	// A denied renter is rejected.
+	err = ht.host.SetRenterPolicy(renter, modules.RenterPolicy{Deny: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ht.host.managedRenterSettings(renter, settings); err != errRenterDenied {
+		t.Fatal("expected errRenterDenied, got", err)
+	}
+
+	// The storage limit applies to all of the contracts of the renter.
+	err = ht.host.SetRenterPolicy(renter, modules.RenterPolicy{MaxStorage: 3 * modules.SectorSize})
+	if err != nil {
+		t.Fatal(err)
+	}
+	so1, err := ht.newTesterRenterObligation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	so2, err := ht.newTesterRenterObligation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so1.id())
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so1.SectorRoots = []crypto.Hash{root1, root2}
+	so1.RevisionTransactionSet[0].FileContractRevisions[0].NewFileSize = 2 * modules.SectorSize
+	ht.host.mu.Lock()
+	err = ht.host.modifyStorageObligation(so1, nil, []crypto.Hash{root1, root2}, [][]byte{data1, data2})
+	ht.host.mu.Unlock()
+	ht.host.managedUnlockStorageObligation(so1.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ht.host.managedCheckRenterStorage(so2, modules.SectorSize); err != nil {
+		t.Fatal("renter should be able to store one more sector:", err)
+	}
+	if err := ht.host.managedCheckRenterStorage(so2, 2*modules.SectorSize); err != errRenterStorageLimit {
+		t.Fatal("expected errRenterStorageLimit, got", err)
+	}
+	if err := ht.host.managedCheckRenterStorage(so1, 3*modules.SectorSize); err != nil {
+		t.Fatal("growing a contract should only count its new size:", err)
+	}
+
+	// The policy is part of the reputation.
+	rr, err := ht.renterReputation(renter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Policy.MaxStorage != 3*modules.SectorSize || rr.Policy.Deny {
+		t.Fatal("wrong policy in the reputation:", rr.Policy)
+	}
+
+	// Renewing a contract with a new key carries the policy over to the key.
+	_, newPK := crypto.GenerateKeyPair()
+	newRenter := types.Ed25519PublicKey(newPK)
+	err = ht.host.managedRenewRenterContract(so2, newRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr, err = ht.renterReputation(newRenter)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rr.Policy.MaxStorage != 3*modules.SectorSize {
+		t.Fatal("policy was not carried over to the renewed contract:", rr.Policy)
+	}
+}
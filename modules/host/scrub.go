@@ -0,0 +1,85 @@
+package host
+
+import (
+	"encoding/json"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+
+	"github.com/coreos/bbolt"
+)
+
+// managedAtRiskStorageObligations returns the unresolved storage obligations
+// that contain sectors which were found to be corrupted by the storage scrub
+// of the storage manager.
+func (h *Host) managedAtRiskStorageObligations() (atRisk []modules.AtRiskStorageObligation, err error) {
+	if len(h.ScrubStatus().CorruptSectors) == 0 {
+		return nil, nil
+	}
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			corrupt := h.CorruptSectors(so.SectorRoots)
+			if len(corrupt) == 0 {
+				return nil
+			}
+			atRisk = append(atRisk, modules.AtRiskStorageObligation{
+				ObligationID:     so.id(),
+				CorruptSectors:   corrupt,
+				SectorRootsCount: uint64(len(so.SectorRoots)),
+				RiskedCollateral: so.RiskedCollateral,
+				ExpirationHeight: so.expiration(),
+				ProofDeadLine:    so.proofDeadline(),
+			})
+			return nil
+		})
+	})
+	return atRisk, err
+}
+
+// threadedCheckStorageIntegrity periodically looks for storage obligations
+// that are at risk of a failed storage proof because some of their sectors
+// are corrupted, and warns about them in the log.
+func (h *Host) threadedCheckStorageIntegrity(closeChan chan struct{}) {
+	defer close(closeChan)
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(storageIntegrityCheckFrequency):
+		}
+
+		atRisk, err := h.managedAtRiskStorageObligations()
+		if err != nil {
+			h.log.Println("ERROR: unable to check the storage obligations for corrupted sectors:", err)
+			continue
+		}
+		h.mu.Lock()
+		for _, so := range atRisk {
+			if h.reportedAtRisk[so.ObligationID] >= len(so.CorruptSectors) {
+				continue
+			}
+			h.reportedAtRisk[so.ObligationID] = len(so.CorruptSectors)
+			h.log.Printf("WARN: storage obligation %v is at risk of a failed storage proof, %v of its %v sectors are corrupted", so.ObligationID, len(so.CorruptSectors), so.SectorRootsCount)
+		}
+		h.mu.Unlock()
+	}
+}
+
+// AtRiskStorageObligations returns the unresolved storage obligations that
+// contain sectors which were found to be corrupted.
+func (h *Host) AtRiskStorageObligations() ([]modules.AtRiskStorageObligation, error) {
+	if err := h.tg.Add(); err != nil {
+		return nil, err
+	}
+	defer h.tg.Done()
+	return h.managedAtRiskStorageObligations()
+}
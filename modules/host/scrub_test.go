@@ -0,0 +1,100 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// corruptStorageManager wraps a storage manager and reports a fixed set of
+// sectors as corrupted.
+type corruptStorageManager struct {
+	modules.StorageManager
+	corrupt map[crypto.Hash]struct{}
+}
+
+// CorruptSectors returns the roots that are marked as corrupted.
+func (sm *corruptStorageManager) CorruptSectors(roots []crypto.Hash) (corrupt []crypto.Hash) {
+	for _, root := range roots {
+		if _, exists := sm.corrupt[root]; exists {
+			corrupt = append(corrupt, root)
+		}
+	}
+	return corrupt
+}
+
+// ScrubStatus reports the sectors that are marked as corrupted.
+func (sm *corruptStorageManager) ScrubStatus() (status modules.StorageScrubStatus) {
+	for root := range sm.corrupt {
+		status.CorruptSectors = append(status.CorruptSectors, modules.CorruptSector{ID: root.String()})
+	}
+	return status
+}
+
+// TestAtRiskStorageObligations checks that the host reports the storage
+// obligations that contain corrupted sectors.
+func TestAtRiskStorageObligations(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation with two sectors.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so.SectorRoots = []crypto.Hash{root1, root2}
+	ht.host.mu.Lock()
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{root1, root2}, [][]byte{data1, data2})
+	ht.host.mu.Unlock()
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without corrupted sectors, no obligation is at risk.
+	atRisk, err := ht.host.AtRiskStorageObligations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(atRisk) != 0 {
+		t.Fatal("no obligation should be at risk:", atRisk)
+	}
+
+	// Mark the second sector as corrupted.
+	sm := &corruptStorageManager{
+		StorageManager: ht.host.StorageManager,
+		corrupt:        map[crypto.Hash]struct{}{root2: {}},
+	}
+	ht.host.mu.Lock()
+	ht.host.StorageManager = sm
+	ht.host.mu.Unlock()
+
+	atRisk, err = ht.host.AtRiskStorageObligations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(atRisk) != 1 {
+		t.Fatal("expected one obligation at risk, got", len(atRisk))
+	}
+	if atRisk[0].ObligationID != so.id() || len(atRisk[0].CorruptSectors) != 1 || atRisk[0].CorruptSectors[0] != root2 || atRisk[0].SectorRootsCount != 2 {
+		t.Fatal("obligation at risk reported incorrectly:", atRisk[0])
+	}
+	if atRisk[0].ProofDeadLine != so.proofDeadline() {
+		t.Fatal("wrong proof deadline:", atRisk[0].ProofDeadLine)
+	}
+}
@@ -0,0 +1,57 @@
+package host
+
+import (
+	"encoding/json"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+
+	"github.com/coreos/bbolt"
+)
+
+// AuditStorage compares the reference counts of the sectors in the storage
+// manager with the sector roots of the unresolved storage obligations. Every
+// obligation that contains a sector holds one reference to it. If fix is set,
+// the reference counts are corrected to match the obligations, which removes
+// the orphaned sectors. The host doesn't modify any obligations during the
+// audit.
+func (h *Host) AuditStorage(fix bool) (modules.StorageAudit, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.StorageAudit{}, err
+	}
+	defer h.tg.Done()
+
+	// Sectors are only added and removed under the host lock, holding it
+	// keeps the references consistent with the storage manager.
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	references := make(map[crypto.Hash]uint64)
+	err := h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			if err := json.Unmarshal(soBytes, &so); err != nil {
+				return err
+			}
+			if so.ObligationStatus != obligationUnresolved {
+				return nil
+			}
+			for _, root := range so.SectorRoots {
+				references[root]++
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.StorageAudit{}, err
+	}
+
+	audit, err := h.AuditSectors(references, fix)
+	if err != nil {
+		return audit, err
+	}
+	if len(audit.MissingSectors) > 0 {
+		h.log.Printf("WARN: storage audit found %v sectors that are referenced by storage obligations but missing from storage\n", len(audit.MissingSectors))
+	}
+	return audit, nil
+}
@@ -0,0 +1,72 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+)
+
+// TestAuditStorage checks that the host compares the sector roots of its
+// storage obligations with the reference counts of the storage manager.
+func TestAuditStorage(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add a storage obligation with two sectors.
+	so, err := ht.newTesterStorageObligation()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ht.host.managedLockStorageObligation(so.id())
+	err = ht.host.managedAddStorageObligation(so)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so.SectorRoots = []crypto.Hash{root1, root2}
+	ht.host.mu.Lock()
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{root1, root2}, [][]byte{data1, data2})
+	ht.host.mu.Unlock()
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	audit, err := ht.host.AuditStorage(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.TotalSectors != 2 || len(audit.OrphanedSectors) != 0 || len(audit.UnderReferencedSectors) != 0 || len(audit.MissingSectors) != 0 {
+		t.Fatal("audit of consistent storage should be clean:", audit)
+	}
+
+	// Drift the reference counts by adding a sector that no obligation
+	// references, and by adding a second reference to one of the sectors.
+	orphan, orphanData := randSector()
+	err = composeErrors(ht.host.AddSector(orphan, orphanData), ht.host.AddSectorBatch([]crypto.Hash{root1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	audit, err = ht.host.AuditStorage(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(audit.OrphanedSectors) != 1 || len(audit.OverReferencedSectors) != 1 || !audit.Fixed {
+		t.Fatal("audit did not find the drifted sectors:", audit)
+	}
+	audit, err = ht.host.AuditStorage(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if audit.TotalSectors != 2 || len(audit.OrphanedSectors) != 0 || len(audit.OverReferencedSectors) != 0 {
+		t.Fatal("audit should be clean after the fix:", audit)
+	}
+}
@@ -35,6 +35,7 @@ import (
 	"encoding/json"
 	"errors"
 	"strconv"
+	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/crypto"
@@ -102,6 +103,10 @@ var (
 	// is not found in the database.
 	errNoStorageObligation = errors.New("storage obligation not found in database")
 
+	// errProofSegmentOutOfRange is returned if the segment of a storage
+	// proof is not part of the sectors of the storage obligation.
+	errProofSegmentOutOfRange = errors.New("storage proof segment is outside of the sectors of the obligation")
+
 	// errObligationUnlocked is returned when a storage obligation is being
 	// removed from lock, but is already unlocked.
 	errObligationUnlocked = errors.New("storage obligation is unlocked, and should not be getting unlocked")
@@ -383,7 +388,11 @@ func (h *Host) managedAddStorageObligation(so storageObligation) error {
 			if err != nil {
 				return err
 			}
-			return bso.Put(soid[:], soBytes)
+			err = bso.Put(soid[:], soBytes)
+			if err != nil {
+				return err
+			}
+			return addRenterContract(tx, so)
 		})
 		if err != nil {
 			return err
@@ -506,7 +515,11 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 		}
 
 		// Store the new storage obligation to replace the old one.
-		return putStorageObligation(tx, so)
+		err = putStorageObligation(tx, so)
+		if err != nil {
+			return err
+		}
+		return updateRenterContract(tx, so, h.renterBandwidth[soid])
 	})
 	if err != nil {
 		// Because there was an error, all of the sectors that got added need
@@ -518,6 +531,8 @@ func (h *Host) modifyStorageObligation(so storageObligation, sectorsRemoved []cr
 		}
 		return err
 	}
+	delete(h.renterBandwidth, soid)
+
 	// Call removeSector for all of the sectors that have been removed.
 	for k := range sectorsRemoved {
 		// Error is not checkeed because there's nothing useful that can be
@@ -617,13 +632,61 @@ func (h *Host) removeStorageObligation(so storageObligation, sos storageObligati
 	// ended up, and the sector roots are removed because they are large
 	// objects with little purpose once storage proofs are no longer needed.
 	h.financialMetrics.ContractCount--
+	delete(h.renterBandwidth, so.id())
 	so.ObligationStatus = sos
 	so.SectorRoots = nil
 	return h.db.Update(func(tx *bolt.Tx) error {
+		err := archiveStorageObligation(tx, so, sos, h.blockHeight, time.Now())
+		if err != nil {
+			return err
+		}
+		err = resolveRenterContract(tx, so, sos)
+		if err != nil {
+			return err
+		}
 		return putStorageObligation(tx, so)
 	})
 }
 
+// managedBuildStorageProof builds the storage proof of the storage
+// obligation for the segment with the provided index. Only the sector that
+// contains the segment is read from disk, the rest of the proof is built from
+// the sector roots of the obligation.
+func (h *Host) managedBuildStorageProof(so storageObligation, segmentIndex uint64) (types.StorageProof, error) {
+	sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
+	if sectorIndex >= uint64(len(so.SectorRoots)) {
+		return types.StorageProof{}, errProofSegmentOutOfRange
+	}
+	// Pull the corresponding sector into memory.
+	sectorRoot := so.SectorRoots[sectorIndex]
+	sectorBytes, err := h.ReadSector(sectorRoot)
+	if err != nil {
+		return types.StorageProof{}, err
+	}
+
+	// Build the storage proof for just the sector.
+	sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
+	base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
+
+	// Using the sector, build a cached root.
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.SetIndex(segmentIndex)
+	for _, root := range so.SectorRoots {
+		ct.Push(root)
+	}
+	hashSet := ct.Prove(base, cachedHashSet)
+	sp := types.StorageProof{
+		ParentID: so.id(),
+		HashSet:  hashSet,
+	}
+	copy(sp.Segment[:], base)
+	return sp, nil
+}
+
 // threadedHandleActionItem will look at a storage obligation and determine
 // which action is necessary for the storage obligation to succeed.
 func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
@@ -812,36 +875,12 @@ func (h *Host) threadedHandleActionItem(soid types.FileContractID) {
 			h.log.Debugln("Host got an error when fetching a storage proof segment:", err)
 			return
 		}
-		sectorIndex := segmentIndex / (modules.SectorSize / crypto.SegmentSize)
-		// Pull the corresponding sector into memory.
-		sectorRoot := so.SectorRoots[sectorIndex]
-		sectorBytes, err := h.ReadSector(sectorRoot)
+		sp, err := h.managedBuildStorageProof(so, segmentIndex)
 		if err != nil {
 			h.log.Debugln(err)
 			return
 		}
 
-		// Build the storage proof for just the sector.
-		sectorSegment := segmentIndex % (modules.SectorSize / crypto.SegmentSize)
-		base, cachedHashSet := crypto.MerkleProof(sectorBytes, sectorSegment)
-
-		// Using the sector, build a cached root.
-		log2SectorSize := uint64(0)
-		for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
-			log2SectorSize++
-		}
-		ct := crypto.NewCachedTree(log2SectorSize)
-		ct.SetIndex(segmentIndex)
-		for _, root := range so.SectorRoots {
-			ct.Push(root)
-		}
-		hashSet := ct.Prove(base, cachedHashSet)
-		sp := types.StorageProof{
-			ParentID: so.id(),
-			HashSet:  hashSet,
-		}
-		copy(sp.Segment[:], base)
-
 		// Create and build the transaction with the storage proof.
 		builder, err := h.wallet.StartTransaction()
 		if err != nil {
@@ -0,0 +1,195 @@
+package host
+
+import (
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+
+	"github.com/coreos/bbolt"
+)
+
+var (
+	// errInvalidSimulatedProof is returned if a simulated storage proof does
+	// not verify against the Merkle root of the file contract.
+	errInvalidSimulatedProof = errors.New("storage proof does not match the Merkle root of the contract")
+)
+
+// simulatedProofSegment returns the index of the segment that consensus would
+// select for the storage proof of the contract if the provided block
+// triggered the proof window. The host can't know the trigger block in
+// advance, so the simulation uses a random one.
+func simulatedProofSegment(fcid types.FileContractID, fileSize uint64, triggerID types.BlockID) uint64 {
+	seed := crypto.HashAll(triggerID, fcid)
+	numSegments := int64(crypto.CalculateLeaves(fileSize))
+	seedInt := new(big.Int).SetBytes(seed[:])
+	return seedInt.Mod(seedInt, big.NewInt(numSegments)).Uint64()
+}
+
+// verifySimulatedProof checks the storage proof against the file size and
+// Merkle root of the latest revision of the storage obligation, using the
+// same rules as consensus.
+func verifySimulatedProof(so storageObligation, sp types.StorageProof, segmentIndex uint64) error {
+	fileSize := so.fileSize()
+	leaves := crypto.CalculateLeaves(fileSize)
+	segmentLen := uint64(crypto.SegmentSize)
+
+	// If this segment chosen is the final segment, it should only be as
+	// long as necessary to complete the filesize.
+	if segmentIndex == leaves-1 {
+		segmentLen = fileSize % crypto.SegmentSize
+	}
+	if segmentLen == 0 {
+		segmentLen = uint64(crypto.SegmentSize)
+	}
+
+	verified := crypto.VerifySegment(
+		sp.Segment[:segmentLen],
+		sp.HashSet,
+		leaves,
+		segmentIndex,
+		so.merkleRoot(),
+	)
+	if !verified && fileSize > 0 {
+		return errInvalidSimulatedProof
+	}
+	return nil
+}
+
+// managedSimulateStorageProof builds and validates the storage proof of the
+// storage obligation for a random segment, without submitting it.
+func (h *Host) managedSimulateStorageProof(so storageObligation) modules.StorageProofSimulation {
+	sim := modules.StorageProofSimulation{
+		ObligationID:     so.id(),
+		RiskedCollateral: so.RiskedCollateral,
+		ExpirationHeight: so.expiration(),
+		ProofDeadLine:    so.proofDeadline(),
+	}
+	// The host doesn't submit a storage proof for an obligation without
+	// sectors.
+	if len(so.SectorRoots) == 0 {
+		sim.Ready = true
+		return sim
+	}
+
+	var triggerID types.BlockID
+	fastrand.Read(triggerID[:])
+	sim.SegmentIndex = simulatedProofSegment(so.id(), so.fileSize(), triggerID)
+	if sectorIndex := sim.SegmentIndex / (modules.SectorSize / crypto.SegmentSize); sectorIndex < uint64(len(so.SectorRoots)) {
+		sim.SectorRoot = so.SectorRoots[sectorIndex]
+	}
+	sp, err := h.managedBuildStorageProof(so, sim.SegmentIndex)
+	if err == nil {
+		err = verifySimulatedProof(so, sp, sim.SegmentIndex)
+	}
+	if err != nil {
+		sim.Error = err.Error()
+		return sim
+	}
+	sim.Ready = true
+	return sim
+}
+
+// managedSimulateStorageProofs simulates the storage proofs of all unresolved
+// storage obligations, and stores the report as the most recent one.
+func (h *Host) managedSimulateStorageProofs() (report modules.StorageProofReport, err error) {
+	h.mu.RLock()
+	blockHeight := h.blockHeight
+	h.mu.RUnlock()
+
+	// Collect the obligations first, so that the database isn't held open
+	// while the sectors are read from disk.
+	var sos []storageObligation
+	err = h.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketStorageObligations).ForEach(func(_, soBytes []byte) error {
+			var so storageObligation
+			err := json.Unmarshal(soBytes, &so)
+			if err != nil {
+				return build.ExtendErr("unable to unmarshal storage obligation:", err)
+			}
+			// Obligations whose proof window has closed can't be proven
+			// anymore and will be removed by the host.
+			if so.ObligationStatus != obligationUnresolved || so.proofDeadline() < blockHeight {
+				return nil
+			}
+			sos = append(sos, so)
+			return nil
+		})
+	})
+	if err != nil {
+		return modules.StorageProofReport{}, err
+	}
+
+	for _, so := range sos {
+		sim := h.managedSimulateStorageProof(so)
+		if !sim.Ready {
+			report.FailedObligations++
+			report.RiskedCollateral = report.RiskedCollateral.Add(sim.RiskedCollateral)
+		}
+		report.Obligations = append(report.Obligations, sim)
+	}
+	sort.Slice(report.Obligations, func(i, j int) bool {
+		return report.Obligations[i].ProofDeadLine < report.Obligations[j].ProofDeadLine
+	})
+	report.SimulatedAt = time.Now()
+
+	h.mu.Lock()
+	h.storageProofReport = report
+	h.mu.Unlock()
+	return report, nil
+}
+
+// threadedSimulateStorageProofs periodically simulates the storage proofs of
+// the host, and warns about the obligations that would fail their proof in
+// the log.
+func (h *Host) threadedSimulateStorageProofs(closeChan chan struct{}) {
+	defer close(closeChan)
+	for {
+		select {
+		case <-h.tg.StopChan():
+			return
+		case <-time.After(storageProofSimulationFrequency):
+		}
+
+		if err := h.tg.Add(); err != nil {
+			return
+		}
+		report, err := h.managedSimulateStorageProofs()
+		h.tg.Done()
+		if err != nil {
+			h.log.Println("ERROR: unable to simulate the storage proofs:", err)
+			continue
+		}
+		for _, sim := range report.Obligations {
+			if !sim.Ready {
+				h.log.Printf("WARN: storage obligation %v would fail its storage proof at segment %v: %v", sim.ObligationID, sim.SegmentIndex, sim.Error)
+			}
+		}
+	}
+}
+
+// SimulateStorageProofs builds and validates a storage proof for every
+// unresolved storage obligation of the host, using a random segment for each
+// obligation. The proofs are not submitted.
+func (h *Host) SimulateStorageProofs() (modules.StorageProofReport, error) {
+	if err := h.tg.Add(); err != nil {
+		return modules.StorageProofReport{}, err
+	}
+	defer h.tg.Done()
+	return h.managedSimulateStorageProofs()
+}
+
+// StorageProofReport returns the report of the most recent storage proof
+// simulation. The report is empty if no simulation has run yet.
+func (h *Host) StorageProofReport() modules.StorageProofReport {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.storageProofReport
+}
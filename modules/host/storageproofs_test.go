@@ -0,0 +1,91 @@
+package host
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestSimulateStorageProofs checks that the host builds valid storage proofs
+// for its obligations, and that it reports the obligations whose proofs would
+// fail.
+func TestSimulateStorageProofs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	ht, err := newHostTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ht.Close()
+
+	// Add an obligation with two sectors, and a Merkle root that matches the
+	// sectors.
+	_, pk := crypto.GenerateKeyPair()
+	so, err := ht.newTesterRenterObligation(types.Ed25519PublicKey(pk))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root1, data1 := randSector()
+	root2, data2 := randSector()
+	so.SectorRoots = []crypto.Hash{root1, root2}
+	log2SectorSize := uint64(0)
+	for 1<<log2SectorSize < (modules.SectorSize / crypto.SegmentSize) {
+		log2SectorSize++
+	}
+	ct := crypto.NewCachedTree(log2SectorSize)
+	ct.Push(root1)
+	ct.Push(root2)
+	so.RevisionTransactionSet[0].FileContractRevisions[0].NewFileSize = 2 * modules.SectorSize
+	so.RevisionTransactionSet[0].FileContractRevisions[0].NewFileMerkleRoot = ct.Root()
+	ht.host.managedLockStorageObligation(so.id())
+	ht.host.mu.Lock()
+	err = ht.host.modifyStorageObligation(so, nil, []crypto.Hash{root1, root2}, [][]byte{data1, data2})
+	ht.host.mu.Unlock()
+	ht.host.managedUnlockStorageObligation(so.id())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Every segment of the obligation should be provable.
+	for _, segmentIndex := range []uint64{0, 1, modules.SectorSize / crypto.SegmentSize, 2*modules.SectorSize/crypto.SegmentSize - 1} {
+		sp, err := ht.host.managedBuildStorageProof(so, segmentIndex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := verifySimulatedProof(so, sp, segmentIndex); err != nil {
+			t.Fatal("proof of segment", segmentIndex, "is invalid:", err)
+		}
+	}
+	report, err := ht.host.SimulateStorageProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Obligations) != 1 || report.FailedObligations != 0 || !report.Obligations[0].Ready {
+		t.Fatal("obligation should be ready for its storage proof:", report)
+	}
+	if sim := report.Obligations[0]; sim.SectorRoot != so.SectorRoots[sim.SegmentIndex/(modules.SectorSize/crypto.SegmentSize)] {
+		t.Fatal("wrong sector root in the simulation:", sim)
+	}
+	if ht.host.StorageProofReport().SimulatedAt != report.SimulatedAt {
+		t.Fatal("report of the simulation was not stored")
+	}
+
+	// An obligation whose Merkle root doesn't match its sectors fails.
+	so.RevisionTransactionSet[0].FileContractRevisions[0].NewFileMerkleRoot = crypto.Hash{1}
+	sim := ht.host.managedSimulateStorageProof(so)
+	if sim.Ready || sim.Error != errInvalidSimulatedProof.Error() {
+		t.Fatal("proof with the wrong Merkle root should fail:", sim)
+	}
+
+	// An obligation with a missing sector fails.
+	missing, _ := randSector()
+	so.SectorRoots = []crypto.Hash{missing, missing}
+	sim = ht.host.managedSimulateStorageProof(so)
+	if sim.Ready || sim.Error == "" {
+		t.Fatal("proof with a missing sector should fail:", sim)
+	}
+}
@@ -65,7 +65,7 @@ func (h *Host) managedLearnHostname() {
 	// address has changed.
 	if hostAcceptingContracts || hostContractCount > 0 {
 		h.log.Println("Host external IP address changed from", hostAutoAddress, "to", autoAddress, "- performing host announcement.")
-		err = h.managedAnnounce(autoAddress)
+		err = h.managedAnnounce([]modules.NetAddress{autoAddress}, 0)
 		if err != nil {
 			// Set h.announced to false, as the address has changed yet the
 			// renewed annoucement has failed.
@@ -69,6 +69,14 @@ const (
 	// encoded HostExternalSettings.
 	NegotiateMaxHostExternalSettingsLen = 16000
 
+	// HostAnnouncementVersion is the version of the HostAnnouncementExtension
+	// that is created by this node.
+	HostAnnouncementVersion = 2
+
+	// MaxAnnouncementAddresses is the maximum number of addresses in a
+	// versioned host announcement.
+	MaxAnnouncementAddresses = 8
+
 	// NegotiateMaxSiaPubkeySize defines the maximum size that a SiaPubkey is
 	// allowed to be when being sent over the wire during negotiation.
 	NegotiateMaxSiaPubkeySize = 1e3
@@ -90,6 +98,12 @@ const (
 	// running Tor.
 	NegotiateRecentRevisionTime = 120 * time.Second
 
+	// NegotiateRecoverContractTime defines the amount of time that the renter
+	// and host have to exchange the most recent revision and the sector roots
+	// of a contract that the renter is recovering. The time is high enough
+	// that the sector roots of a large contract can be sent over Tor.
+	NegotiateRecoverContractTime = 600 * time.Second
+
 	// NegotiateRenewContractTime defines the minimum amount of time that the
 	// renter and host have to negotiate a final contract renewal. The time is
 	// high enough that the negotiation can occur over a Tor connection, and
@@ -132,6 +146,18 @@ var (
 	// announcement is not a type of signature that is recognized.
 	ErrAnnUnrecognizedSignature = errors.New("the signature provided in the host announcement is not recognized")
 
+	// ErrAnnNoAddresses is returned when a versioned host announcement is
+	// created without any addresses.
+	ErrAnnNoAddresses = errors.New("host announcement needs at least one address")
+
+	// ErrAnnTooManyAddresses is returned when a versioned host announcement is
+	// created with more than MaxAnnouncementAddresses addresses.
+	ErrAnnTooManyAddresses = errors.New("host announcement has too many addresses")
+
+	// ErrAnnDuplicateAddress is returned when a versioned host announcement is
+	// created with the same address twice.
+	ErrAnnDuplicateAddress = errors.New("host announcement contains the same address twice")
+
 	// ErrRevisionCoveredFields is returned if there is a covered fields object
 	// in a transaction signature which has the 'WholeTransaction' field set to
 	// true, meaning that miner fees cannot be added to the transaction without
@@ -157,6 +183,10 @@ var (
 	// RPCFormContract is the specifier for forming a contract with a host.
 	RPCFormContract = types.Specifier{'F', 'o', 'r', 'm', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
+	// RPCRecoverContract is the specifier for fetching the most recent
+	// revision and the sector roots of a contract that the renter lost.
+	RPCRecoverContract = types.Specifier{'R', 'e', 'c', 'o', 'v', 'e', 'r', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't'}
+
 	// RPCRenewContract is the specifier to renewing an existing contract.
 	RPCRenewContract = types.Specifier{'R', 'e', 'n', 'e', 'w', 'C', 'o', 'n', 't', 'r', 'a', 'c', 't', 2}
 
@@ -201,6 +231,22 @@ type (
 		PublicKey  types.SiaPublicKey
 	}
 
+	// HostAnnouncementExtension adds alternate addresses to a
+	// HostAnnouncement, for example an IPv6 address next to an IPv4 address
+	// and a hostname. The extension follows the signature of the
+	// announcement, so that nodes that don't recognize it still decode the
+	// announcement, and is itself followed by a signature of the
+	// announcement, its signature and the extension. The addresses are in
+	// priority order, the first address is the NetAddress of the
+	// announcement. The alternate addresses expire at the Expiry height,
+	// after which only the NetAddress is used. An Expiry of zero means that
+	// the addresses don't expire.
+	HostAnnouncementExtension struct {
+		Version   uint64
+		Addresses []NetAddress
+		Expiry    types.BlockHeight
+	}
+
 	// HostExternalSettings are the parameters advertised by the host. These
 	// are the values that the renter will request from the host in order to
 	// build its database.
@@ -260,6 +306,22 @@ type (
 		// which is the most recent.
 		RevisionNumber uint64 `json:"revisionnumber"`
 		Version        string `json:"version"`
+
+		// Maintenance announces that the host is in maintenance mode. It
+		// must be the last field, because hosts that don't support
+		// maintenance mode don't send it.
+		Maintenance HostMaintenance `json:"maintenance"`
+	}
+
+	// HostMaintenance is the maintenance status of a host. A host in
+	// maintenance mode doesn't form or renew contracts and doesn't accept
+	// uploads, but it still serves downloads. End is the time at which the
+	// host expects to leave maintenance mode, renters don't consider the host
+	// offline until then. An End of zero means that the host didn't announce
+	// a window.
+	HostMaintenance struct {
+		Active bool            `json:"active"`
+		End    types.Timestamp `json:"end"`
 	}
 
 	// A RevisionAction is a description of an edit to be performed on a file
@@ -282,6 +344,30 @@ type (
 	}
 )
 
+// MarshalSia implements the encoding.SiaMarshaler interface.
+func (hm HostMaintenance) MarshalSia(w io.Writer) error {
+	return encoding.NewEncoder(w).EncodeAll(hm.Active, hm.End)
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface. The settings
+// of hosts that don't support maintenance mode end before the maintenance
+// status, in which case the host is not in maintenance.
+func (hm *HostMaintenance) UnmarshalSia(r io.Reader) error {
+	var active [1]byte
+	_, err := io.ReadFull(r, active[:])
+	if err == io.EOF {
+		*hm = HostMaintenance{}
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if active[0] > 1 {
+		return errors.New("invalid maintenance status")
+	}
+	hm.Active = active[0] == 1
+	return encoding.NewDecoder(r).Decode(&hm.End)
+}
+
 // ReadNegotiationAcceptance reads an accept/reject response from r (usually a
 // net.Conn). If the response is not AcceptResponse, ReadNegotiationAcceptance
 // returns the response as an error. If the response is StopResponse,
@@ -351,6 +437,87 @@ func CreateAnnouncement(addr NetAddress, pk types.SiaPublicKey, sk crypto.Secret
 	return append(annBytes, sig[:]...), nil
 }
 
+// CreateVersionedAnnouncement creates a host announcement for several
+// addresses in priority order, which expire at the provided height. Nodes that
+// don't recognize versioned announcements only see the first address.
+func CreateVersionedAnnouncement(addrs []NetAddress, expiry types.BlockHeight, pk types.SiaPublicKey, sk crypto.SecretKey) (signedAnnouncement []byte, err error) {
+	if len(addrs) == 0 {
+		return nil, ErrAnnNoAddresses
+	} else if len(addrs) > MaxAnnouncementAddresses {
+		return nil, ErrAnnTooManyAddresses
+	}
+	seen := make(map[NetAddress]struct{})
+	for _, addr := range addrs {
+		if err := addr.IsValid(); err != nil {
+			return nil, err
+		}
+		if _, exists := seen[addr]; exists {
+			return nil, ErrAnnDuplicateAddress
+		}
+		seen[addr] = struct{}{}
+	}
+
+	// Create the regular announcement for the first address, and extend it
+	// with all of the addresses.
+	annBytes, err := CreateAnnouncement(addrs[0], pk, sk)
+	if err != nil {
+		return nil, err
+	}
+	annBytes = append(annBytes, encoding.Marshal(HostAnnouncementExtension{
+		Version:   HostAnnouncementVersion,
+		Addresses: addrs,
+		Expiry:    expiry,
+	})...)
+
+	// Sign the full announcement.
+	sig := crypto.SignHash(crypto.HashBytes(annBytes), sk)
+	return append(annBytes, sig[:]...), nil
+}
+
+// DecodeVersionedAnnouncement decodes announcement bytes into the announced
+// addresses in priority order, their expiry height and the public key of the
+// host. The announcement itself is verified like in DecodeAnnouncement. If the
+// announcement doesn't have a valid extension, only the NetAddress of the
+// announcement is returned, with an expiry of zero.
+func DecodeVersionedAnnouncement(fullAnnouncement []byte) (addrs []NetAddress, expiry types.BlockHeight, spk types.SiaPublicKey, err error) {
+	na, spk, err := DecodeAnnouncement(fullAnnouncement)
+	if err != nil {
+		return nil, 0, types.SiaPublicKey{}, err
+	}
+	addrs = []NetAddress{na}
+
+	// Find the extension after the signature of the announcement.
+	annLen := len(encoding.Marshal(HostAnnouncement{
+		Specifier:  PrefixHostAnnouncement,
+		NetAddress: na,
+		PublicKey:  spk,
+	})) + crypto.SignatureSize
+	if len(fullAnnouncement) <= annLen {
+		return addrs, 0, spk, nil
+	}
+	var ext HostAnnouncementExtension
+	dec := encoding.NewDecoder(bytes.NewReader(fullAnnouncement[annLen:]))
+	if err := dec.Decode(&ext); err != nil {
+		return addrs, 0, spk, nil
+	}
+	var sig crypto.Signature
+	if err := dec.Decode(&sig); err != nil {
+		return addrs, 0, spk, nil
+	}
+
+	// Verify the extension and its signature.
+	if ext.Version != HostAnnouncementVersion || len(ext.Addresses) == 0 || len(ext.Addresses) > MaxAnnouncementAddresses || ext.Addresses[0] != na {
+		return addrs, 0, spk, nil
+	}
+	extLen := len(encoding.Marshal(ext))
+	var pk crypto.PublicKey
+	copy(pk[:], spk.Key)
+	if crypto.VerifyHash(crypto.HashBytes(fullAnnouncement[:annLen+extLen]), pk, sig) != nil {
+		return addrs, 0, spk, nil
+	}
+	return ext.Addresses, ext.Expiry, spk, nil
+}
+
 // DecodeAnnouncement decodes announcement bytes into a host announcement,
 // verifying the prefix and the signature.
 func DecodeAnnouncement(fullAnnouncement []byte) (na NetAddress, spk types.SiaPublicKey, err error) {
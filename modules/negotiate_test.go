@@ -2,9 +2,11 @@ package modules
 
 import (
 	"bytes"
+	"fmt"
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/types"
 )
 
@@ -125,3 +127,108 @@ func TestNegotiationResponses(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+// TestHostMaintenanceEncoding checks that the maintenance status survives an
+// encoding round trip, and that the settings of hosts that don't send the
+// status can still be decoded.
+func TestHostMaintenanceEncoding(t *testing.T) {
+	settings := HostExternalSettings{
+		AcceptingContracts: true,
+		Version:            "1.3.7",
+		Maintenance: HostMaintenance{
+			Active: true,
+			End:    types.CurrentTimestamp(),
+		},
+	}
+	var decoded HostExternalSettings
+	if err := encoding.Unmarshal(encoding.Marshal(settings), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Maintenance != settings.Maintenance || decoded.Version != settings.Version {
+		t.Fatal("maintenance status did not survive the round trip:", decoded.Maintenance)
+	}
+
+	// Strip the maintenance status, as sent by older hosts.
+	b := encoding.Marshal(settings)
+	b = b[:len(b)-len(encoding.Marshal(settings.Maintenance))]
+	decoded = HostExternalSettings{}
+	if err := encoding.Unmarshal(b, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.Maintenance.Active || decoded.Version != settings.Version || !decoded.AcceptingContracts {
+		t.Fatal("settings without maintenance status were decoded incorrectly:", decoded)
+	}
+}
+
+// TestVersionedAnnouncement checks that versioned announcements carry all of
+// their addresses, and that nodes that only decode regular announcements see
+// the first address.
+func TestVersionedAnnouncement(t *testing.T) {
+	t.Parallel()
+
+	sk, pk := crypto.GenerateKeyPair()
+	spk := types.Ed25519PublicKey(pk)
+	addrs := []NetAddress{"1.2.3.4:9982", "[2001:db8::1]:9982", "foo.com:9982"}
+
+	annBytes, err := CreateVersionedAnnouncement(addrs, 100, spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, expiry, decPubKey, err := DecodeVersionedAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != len(addrs) || decAddrs[1] != addrs[1] || decAddrs[2] != addrs[2] || expiry != 100 {
+		t.Fatal("decoded announcement has the wrong addresses:", decAddrs, expiry)
+	}
+	if !bytes.Equal(decPubKey.Key, spk.Key) {
+		t.Fatal("decoded announcement has the wrong public key")
+	}
+
+	// The regular decoder sees the first address.
+	decAddr, _, err := DecodeAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decAddr != addrs[0] {
+		t.Fatal("regular decoding returned the wrong address:", decAddr)
+	}
+
+	// A regular announcement decodes to a single address without expiry.
+	regular, err := CreateAnnouncement(addrs[0], spk, sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decAddrs, expiry, _, err = DecodeVersionedAnnouncement(regular)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || decAddrs[0] != addrs[0] || expiry != 0 {
+		t.Fatal("regular announcement was decoded incorrectly:", decAddrs, expiry)
+	}
+
+	// An extension with a bad signature is ignored.
+	annBytes[len(annBytes)-1]++
+	decAddrs, expiry, _, err = DecodeVersionedAnnouncement(annBytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(decAddrs) != 1 || expiry != 0 {
+		t.Fatal("extension with a bad signature was not ignored:", decAddrs)
+	}
+
+	// Invalid sets of addresses are rejected.
+	if _, err := CreateVersionedAnnouncement(nil, 0, spk, sk); err != ErrAnnNoAddresses {
+		t.Fatal("expected ErrAnnNoAddresses, got", err)
+	}
+	if _, err := CreateVersionedAnnouncement([]NetAddress{addrs[0], addrs[0]}, 0, spk, sk); err != ErrAnnDuplicateAddress {
+		t.Fatal("expected ErrAnnDuplicateAddress, got", err)
+	}
+	tooMany := make([]NetAddress, MaxAnnouncementAddresses+1)
+	for i := range tooMany {
+		tooMany[i] = NetAddress(fmt.Sprintf("foo%d.com:9982", i))
+	}
+	if _, err := CreateVersionedAnnouncement(tooMany, 0, spk, sk); err != ErrAnnTooManyAddresses {
+		t.Fatal("expected ErrAnnTooManyAddresses, got", err)
+	}
+}
@@ -20,6 +20,11 @@ var (
 		Hosts:       uint64(PriceEstimationScope),
 		Period:      types.BlockHeight(12096),
 		RenewWindow: types.BlockHeight(4032),
+
+		ExpectedStorage:    100e9,                // 100 GB
+		ExpectedUpload:     uint64(50e9) / 4320,  // 50 GB per month
+		ExpectedDownload:   uint64(100e9) / 4320, // 100 GB per month
+		ExpectedRedundancy: 3.0,
 	}
 
 	// ErrHostFault is an error that is usually extended to indicate that an error
@@ -35,43 +40,8 @@ var (
 		Dev:      int(12),
 		Testing:  int(4),
 	}).(int)
-
-	// DefaultUsageGuideLines is a sane set of guidelines.
-	DefaultUsageGuideLines = UsageGuidelines{
-		ExpectedStorage:           25e9,
-		ExpectedUploadFrequency:   24192,
-		ExpectedDownloadFrequency: 12096,
-		ExpectedRedundancy:        3.0,
-	}
 )
 
-// UsageGuidelines is a temporary helper struct.
-// TODO: These values should be rolled into the allowance, instead of being a
-// separate struct that we pass in.
-//
-// expectedStorage is the amount of data that we expect to have in a contract.
-//
-// expectedUploadFrequency is the expected number of blocks between each
-// complete re-upload of the filesystem. This will be a combination of the rate
-// at which a user uploads files, the rate at which a user replaces files, and
-// the rate at which a user has to repair files due to host churn. If the
-// expected storage is 25 GB and the expected upload frequency is 24 weeks, it
-// means the user is expected to do about 1 GB of upload per week on average
-// throughout the life of the contract.
-//
-// expectedDownloadFrequency is the expected number of blocks between each
-// complete download of the filesystem. This should include the user
-// downloading, streaming, and repairing files.
-//
-// expectedDataPieces and expectedParityPieces are used to give information
-// about the redundancy of the files being uploaded.
-type UsageGuidelines struct {
-	ExpectedStorage           uint64
-	ExpectedUploadFrequency   uint64
-	ExpectedDownloadFrequency uint64
-	ExpectedRedundancy        float64
-}
-
 // IsHostsFault indicates if a returned error is the host's fault.
 func IsHostsFault(err error) bool {
 	return errors.Contains(err, ErrHostFault)
@@ -127,6 +97,50 @@ type Allowance struct {
 	Hosts       uint64            `json:"hosts"`
 	Period      types.BlockHeight `json:"period"`
 	RenewWindow types.BlockHeight `json:"renewwindow"`
+
+	// The expected usage is used to determine how much money is put into
+	// each contract. ExpectedStorage is the amount of data that the renter
+	// expects to store, ExpectedUpload and ExpectedDownload are the amount of
+	// data that the renter expects to upload and download per block. All
+	// three are measured before redundancy is applied. ExpectedRedundancy is
+	// the expected average redundancy of the renter's files.
+	ExpectedStorage    uint64  `json:"expectedstorage"`
+	ExpectedUpload     uint64  `json:"expectedupload"`
+	ExpectedDownload   uint64  `json:"expecteddownload"`
+	ExpectedRedundancy float64 `json:"expectedredundancy"`
+}
+
+// WithDefaultUsage returns the allowance with the expected usage of the
+// DefaultAllowance if the allowance doesn't specify any expected usage.
+func (a Allowance) WithDefaultUsage() Allowance {
+	if a.ExpectedStorage == 0 && a.ExpectedUpload == 0 && a.ExpectedDownload == 0 && a.ExpectedRedundancy == 0 {
+		a.ExpectedStorage = DefaultAllowance.ExpectedStorage
+		a.ExpectedUpload = DefaultAllowance.ExpectedUpload
+		a.ExpectedDownload = DefaultAllowance.ExpectedDownload
+		a.ExpectedRedundancy = DefaultAllowance.ExpectedRedundancy
+	}
+	return a
+}
+
+// ExpectedContractUsage returns the amount of data that a single contract is
+// expected to store, as well as the amount of data that is expected to be
+// uploaded to and downloaded from it per block. The usage is assumed to be
+// spread evenly across all of the allowance's hosts. If the allowance doesn't
+// specify any expected usage, the default usage is assumed.
+func (a Allowance) ExpectedContractUsage() (storage, upload, download uint64) {
+	a = a.WithDefaultUsage()
+	hosts := a.Hosts
+	if hosts == 0 {
+		hosts = 1
+	}
+	redundancy := a.ExpectedRedundancy
+	if redundancy == 0 {
+		redundancy = 1
+	}
+	storage = uint64(float64(a.ExpectedStorage) * redundancy / float64(hosts))
+	upload = uint64(float64(a.ExpectedUpload) * redundancy / float64(hosts))
+	download = a.ExpectedDownload / hosts
+	return
 }
 
 // ContractUtility contains metrics internal to the contractor that reflect the
@@ -205,9 +219,22 @@ type HostDBEntry struct {
 	IPNets          []string  `json:"ipnets"`
 	LastIPNetChange time.Time `json:"lastipnetchange"`
 
+	// NetAddresses are the addresses of a versioned host announcement in
+	// priority order, which are used instead of the NetAddress until the
+	// AnnouncementExpiry height. WorkingAddress is the address at which the
+	// host was last reached.
+	NetAddresses       []NetAddress      `json:"netaddresses"`
+	AnnouncementExpiry types.BlockHeight `json:"announcementexpiry"`
+	WorkingAddress     NetAddress        `json:"workingaddress"`
+
 	// The public key of the host, stored separately to minimize risk of certain
 	// MitM based vulnerabilities.
 	PublicKey types.SiaPublicKey `json:"publickey"`
+
+	// Misbehavior is the evidence of cryptographically provable misbehavior
+	// that the renter has collected about the host. A host with recent
+	// misbehavior is not selected for new contracts.
+	Misbehavior []HostMisbehavior `json:"misbehavior"`
 }
 
 // HostDBScan represents a single scan event.
@@ -216,6 +243,47 @@ type HostDBScan struct {
 	Success   bool      `json:"success"`
 }
 
+// DialAddresses returns the addresses at which the host can be reached at the
+// provided height, in the order in which they should be tried.
+func (he HostDBEntry) DialAddresses(height types.BlockHeight) []NetAddress {
+	if len(he.NetAddresses) == 0 || (he.AnnouncementExpiry != 0 && height >= he.AnnouncementExpiry) {
+		return []NetAddress{he.NetAddress}
+	}
+	return he.NetAddresses
+}
+
+// HostMisbehaviorType identifies the kind of misbehavior a host was caught at.
+type HostMisbehaviorType string
+
+const (
+	// HostMisbehaviorBadSectorData indicates that the host served sector data
+	// which did not match the requested Merkle root.
+	HostMisbehaviorBadSectorData HostMisbehaviorType = "badsectordata"
+
+	// HostMisbehaviorBadRevision indicates that the host presented a contract
+	// revision which does not belong to the contract we formed with it.
+	HostMisbehaviorBadRevision HostMisbehaviorType = "badrevision"
+
+	// HostMisbehaviorBadSignature indicates that the host signed a contract
+	// revision with an invalid signature.
+	HostMisbehaviorBadSignature HostMisbehaviorType = "badsignature"
+
+	// HostMisbehaviorMissedStorageProof indicates that the host did not
+	// submit a storage proof for a contract that was storing data.
+	HostMisbehaviorMissedStorageProof HostMisbehaviorType = "missedstorageproof"
+)
+
+// HostMisbehavior is a single piece of evidence that a host has misbehaved.
+// Only failures that can be proven cryptographically, and therefore can't be
+// caused by network issues on the renter's side, are recorded.
+type HostMisbehavior struct {
+	Type        HostMisbehaviorType  `json:"type"`
+	ContractID  types.FileContractID `json:"contractid"`
+	BlockHeight types.BlockHeight    `json:"blockheight"`
+	Timestamp   time.Time            `json:"timestamp"`
+	Description string               `json:"description"`
+}
+
 // HostScoreBreakdown provides a piece-by-piece explanation of why a host has
 // the score that they do.
 //
@@ -256,11 +324,12 @@ type RenterPriceEstimation struct {
 
 // RenterSettings control the behavior of the Renter.
 type RenterSettings struct {
-	Allowance         Allowance `json:"allowance"`
-	IPViolationsCheck bool      `json:"ipviolationcheck"`
-	MaxUploadSpeed    int64     `json:"maxuploadspeed"`
-	MaxDownloadSpeed  int64     `json:"maxdownloadspeed"`
-	StreamCacheSize   uint64    `json:"streamcachesize"`
+	Allowance                  Allowance         `json:"allowance"`
+	IPViolationsCheck          bool              `json:"ipviolationcheck"`
+	MaxUploadSpeed             int64             `json:"maxuploadspeed"`
+	MaxDownloadSpeed           int64             `json:"maxdownloadspeed"`
+	MisbehaviorBlacklistPeriod types.BlockHeight `json:"misbehaviorblacklistperiod"`
+	StreamCacheSize            uint64            `json:"streamcachesize"`
 }
 
 // HostDBScans represents a sortable slice of scans.
@@ -386,6 +455,62 @@ type ContractorSpending struct {
 	PreviousSpending types.Currency `json:"previousspending"`
 }
 
+// ContractorUsage compares the usage that the allowance expects with the usage
+// that has been observed during the current period. All values are measured
+// before redundancy; observed values are converted using the allowance's
+// expected redundancy.
+type ContractorUsage struct {
+	ExpectedStorage  uint64 `json:"expectedstorage"`  // bytes
+	ObservedStorage  uint64 `json:"observedstorage"`  // bytes
+	ExpectedUpload   uint64 `json:"expectedupload"`   // bytes per block
+	ObservedUpload   uint64 `json:"observedupload"`   // bytes per block
+	ExpectedDownload uint64 `json:"expecteddownload"` // bytes per block
+	ObservedDownload uint64 `json:"observeddownload"` // bytes per block
+}
+
+// ContractRecoveryStatus is the progress of the recovery of contracts that
+// were formed using the wallet seed. The blockchain has been scanned for
+// contracts up to ScannedHeight. Found is the number of contracts that can be
+// recovered, and Recovered is the number of contracts that were fetched from
+// their hosts so far. Error is set if the recovery failed.
+type ContractRecoveryStatus struct {
+	Active        bool              `json:"active"`
+	ScannedHeight types.BlockHeight `json:"scannedheight"`
+	Found         int               `json:"found"`
+	Recovered     int               `json:"recovered"`
+	Error         string            `json:"error"`
+}
+
+// HostSpendingReport contains the spending of the renter with a single host
+// during a single period. The report is built from the renter's current and
+// old contracts; contracts are assigned to the period in which they started.
+type HostSpendingReport struct {
+	HostPublicKey types.SiaPublicKey `json:"hostpublickey"`
+	PeriodStart   types.BlockHeight  `json:"periodstart"`
+	PeriodEnd     types.BlockHeight  `json:"periodend"`
+
+	// Contracts is the number of contracts with the host that started during
+	// the period.
+	Contracts int `json:"contracts"`
+
+	// Money spent on storage, uploads and downloads.
+	DownloadSpending types.Currency `json:"downloadspending"`
+	StorageSpending  types.Currency `json:"storagespending"`
+	UploadSpending   types.Currency `json:"uploadspending"`
+
+	// Fees is the sum of the contract fees, transaction fees and siafund fees
+	// of the contracts.
+	Fees types.Currency `json:"fees"`
+
+	// ReturnedFunds are the funds that the renter locked in the contracts but
+	// didn't spend, which are returned to the renter when the contracts end.
+	ReturnedFunds types.Currency `json:"returnedfunds"`
+
+	// TotalCost is the total amount of money that the renter put into the
+	// contracts.
+	TotalCost types.Currency `json:"totalcost"`
+}
+
 // A Renter uploads, tracks, repairs, and downloads a set of files for the
 // user.
 type Renter interface {
@@ -402,6 +527,10 @@ type Renter interface {
 	// CancelContract cancels a specific contract of the renter.
 	CancelContract(id types.FileContractID) error
 
+	// ContractReport returns the spending of the renter broken down by host
+	// and period.
+	ContractReport() []HostSpendingReport
+
 	// Contracts returns the staticContracts of the renter's hostContractor.
 	Contracts() []RenterContract
 
@@ -411,6 +540,10 @@ type Renter interface {
 	// ContractUtility provides the contract utility for a given host key.
 	ContractUtility(pk types.SiaPublicKey) (ContractUtility, bool)
 
+	// ContractRecoveryStatus returns the progress of the most recent
+	// contract recovery.
+	ContractRecoveryStatus() ContractRecoveryStatus
+
 	// CurrentPeriod returns the height at which the current allowance period
 	// began.
 	CurrentPeriod() types.BlockHeight
@@ -419,6 +552,16 @@ type Renter interface {
 	// billing period.
 	PeriodSpending() ContractorSpending
 
+	// PeriodUsage compares the usage expected by the allowance with the usage
+	// observed during the current period.
+	PeriodUsage() ContractorUsage
+
+	// RecoverContracts starts recovering the contracts that were formed
+	// using the wallet seed but are missing from the renter's contracts. The
+	// recovery runs in the background, its progress is reported by
+	// ContractRecoveryStatus.
+	RecoverContracts() error
+
 	// DeleteFile deletes a file entry from the renter.
 	DeleteFile(path string) error
 
@@ -40,6 +40,7 @@ func (c *Contractor) SetAllowance(a modules.Allowance) error {
 	if reflect.DeepEqual(a, modules.Allowance{}) {
 		return c.managedCancelAllowance()
 	}
+	a = allowanceWithDefaultUsage(a)
 	if reflect.DeepEqual(a, c.allowance) {
 		return nil
 	}
@@ -258,6 +258,12 @@ func (c *Contractor) managedMarkContractsUtility() error {
 				u.GoodForRenew = false
 				return
 			}
+			// Contract has no utility if the host was caught misbehaving.
+			if c.hdb.Blacklisted(contract.HostPublicKey) {
+				u.GoodForUpload = false
+				u.GoodForRenew = false
+				return
+			}
 			// Contract has no utility if the score is poor.
 			if !minScore.IsZero() && c.hdb.ScoreBreakdown(host).Score.Cmp(minScore) < 0 {
 				u.GoodForUpload = false
@@ -317,15 +323,23 @@ func (c *Contractor) managedNewContract(host modules.HostDBEntry, contractFundin
 	if err != nil {
 		return types.ZeroCurrency, modules.RenterContract{}, err
 	}
+	// get the renter seed to derive the contract keys from
+	renterSeed, err := c.managedRenterSeed()
+	if err != nil {
+		return types.ZeroCurrency, modules.RenterContract{}, err
+	}
 
 	// create contract params
 	c.mu.RLock()
+	expectedStorage, _, _ := c.allowance.ExpectedContractUsage()
 	params := proto.ContractParams{
-		Host:          host,
-		Funding:       contractFunding,
-		StartHeight:   c.blockHeight,
-		EndHeight:     endHeight,
-		RefundAddress: uc.UnlockHash(),
+		Host:            host,
+		Funding:         contractFunding,
+		StartHeight:     c.blockHeight,
+		EndHeight:       endHeight,
+		RefundAddress:   uc.UnlockHash(),
+		ExpectedStorage: expectedStorage,
+		RenterSeed:      renterSeed,
 	}
 	c.mu.RUnlock()
 
@@ -445,15 +459,23 @@ func (c *Contractor) managedRenew(sc *proto.SafeContract, contractFunding types.
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	// get the renter seed to tag the renewed contract with
+	renterSeed, err := c.managedRenterSeed()
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
 
 	// create contract params
 	c.mu.RLock()
+	expectedStorage, _, _ := c.allowance.ExpectedContractUsage()
 	params := proto.ContractParams{
-		Host:          host,
-		Funding:       contractFunding,
-		StartHeight:   c.blockHeight,
-		EndHeight:     newEndHeight,
-		RefundAddress: uc.UnlockHash(),
+		Host:            host,
+		Funding:         contractFunding,
+		StartHeight:     c.blockHeight,
+		EndHeight:       newEndHeight,
+		RefundAddress:   uc.UnlockHash(),
+		ExpectedStorage: expectedStorage,
+		RenterSeed:      renterSeed,
 	}
 	c.mu.RUnlock()
 
@@ -727,21 +749,18 @@ func (c *Contractor) threadedContractMaintenance() {
 		sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
 		percentRemaining, _ := big.NewRat(0, 1).SetFrac(contract.RenterFunds.Big(), contract.TotalCost.Big()).Float64()
 		if contract.RenterFunds.Cmp(sectorPrice.Mul64(3)) < 0 || percentRemaining < minContractFundRenewalThreshold {
-			// Renew the contract with double the amount of funds that the
-			// contract had previously. The reason that we double the funding
-			// instead of doing anything more clever is that we don't know what
-			// the usage pattern has been. The spending could have all occurred
-			// in one burst recently, and the user might need a contract that
-			// has substantially more money in it.
-			//
-			// We double so that heavily used contracts can grow in funding
-			// quickly without consuming too many transaction fees, however this
-			// does mean that a larger percentage of funds get locked away from
-			// the user in the event that the user stops uploading immediately
-			// after the renew.
+			// Refresh the contract with enough funds to last until the end
+			// of the period. The funding is based on the expected usage of
+			// the allowance, unless the contract has been used more heavily
+			// than expected, so that the contract doesn't need to be
+			// refreshed again before the period ends.
+			refreshAmount, err := c.managedEstimateRefreshFundingRequirements(contract, blockHeight, endHeight, allowance)
+			if err != nil {
+				continue
+			}
 			refreshSet = append(refreshSet, fileContractRenewal{
 				id:     contract.ID,
-				amount: contract.TotalCost.Mul64(2),
+				amount: refreshAmount,
 			})
 		}
 	}
@@ -804,8 +823,13 @@ func (c *Contractor) threadedContractMaintenance() {
 		}
 	}
 	for _, renewal := range refreshSet {
-		// Skip this renewal if we don't have enough funds remaining.
+		// Use the remaining funds if there are not enough funds for the
+		// estimated amount. Skip this renewal if the remaining funds are below
+		// the minimum funding of a contract.
 		if renewal.amount.Cmp(fundsRemaining) > 0 {
+			renewal.amount = fundsRemaining
+		}
+		if renewal.amount.Cmp(clampContractFunding(types.ZeroCurrency, allowance)) < 0 {
 			continue
 		}
 
@@ -854,7 +878,6 @@ func (c *Contractor) threadedContractMaintenance() {
 			addressBlacklist = append(addressBlacklist, contract.HostPublicKey)
 		}
 	}
-	initialContractFunds := c.allowance.Funds.Div64(c.allowance.Hosts).Div64(3)
 	c.mu.RUnlock()
 	hosts, err := c.hdb.RandomHosts(neededContracts*2+randomHostsBufferForScore, blacklist, addressBlacklist)
 	if err != nil {
@@ -865,7 +888,10 @@ func (c *Contractor) threadedContractMaintenance() {
 	// Form contracts with the hosts one at a time, until we have enough
 	// contracts.
 	for _, host := range hosts {
-		// Determine if we have enough money to form a new contract.
+		// Determine if we have enough money to form a new contract. The
+		// contract is funded with enough money to cover the usage that the
+		// allowance expects from the host.
+		initialContractFunds := c.managedEstimateFormationFundingRequirements(host, blockHeight, endHeight, allowance)
 		if fundsRemaining.Cmp(initialContractFunds) < 0 {
 			c.log.Println("WARN: need to form new contracts, but unable to because of a low allowance")
 			break
@@ -48,6 +48,11 @@ type Contractor struct {
 	currentPeriod types.BlockHeight
 	lastChange    modules.ConsensusChangeID
 
+	// periodUpload and periodDownload are the number of bytes that were
+	// uploaded to and downloaded from hosts during the current period.
+	periodUpload   uint64
+	periodDownload uint64
+
 	downloaders         map[types.FileContractID]*hostDownloader
 	editors             map[types.FileContractID]*hostEditor
 	numFailedRenews     map[types.FileContractID]types.BlockHeight
@@ -61,6 +66,12 @@ type Contractor struct {
 	oldContracts    map[types.FileContractID]modules.RenterContract
 	renewedFrom     map[types.FileContractID]types.FileContractID
 	renewedTo       map[types.FileContractID]types.FileContractID
+
+	// recoveryStatus is the progress of the most recent contract recovery.
+	// It is protected by its own mutex, because the recovery scanner updates
+	// it while the consensus set is locked.
+	recoveryMu     sync.Mutex
+	recoveryStatus modules.ContractRecoveryStatus
 }
 
 // Allowance returns the current allowance.
@@ -25,6 +25,7 @@ func (newStub) Unsubscribe(modules.ConsensusSetSubscriber) { return }
 
 // wallet stubs
 func (newStub) NextAddress() (uc types.UnlockConditions, err error)          { return }
+func (newStub) PrimarySeed() (s modules.Seed, p uint64, err error)           { return }
 func (newStub) StartTransaction() (tb modules.TransactionBuilder, err error) { return }
 
 // transaction pool stubs
@@ -34,6 +35,7 @@ func (newStub) FeeEstimation() (a types.Currency, b types.Currency) { return }
 // hdb stubs
 func (newStub) AllHosts() []modules.HostDBEntry                                 { return nil }
 func (newStub) ActiveHosts() []modules.HostDBEntry                              { return nil }
+func (newStub) Blacklisted(types.SiaPublicKey) bool                             { return false }
 func (newStub) CheckForIPViolations([]types.SiaPublicKey) []types.SiaPublicKey  { return nil }
 func (newStub) Host(types.SiaPublicKey) (settings modules.HostDBEntry, ok bool) { return }
 func (newStub) IncrementSuccessfulInteractions(key types.SiaPublicKey)          { return }
@@ -44,7 +46,9 @@ func (newStub) RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) ([]m
 func (newStub) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
-func (newStub) SetAllowance(allowance modules.Allowance) error { return nil }
+func (newStub) RecordMisbehavior(types.SiaPublicKey, modules.HostMisbehavior) {}
+func (newStub) UpdateWorkingAddress(types.SiaPublicKey, modules.NetAddress)   {}
+func (newStub) SetAllowance(allowance modules.Allowance) error                { return nil }
 
 // TestNew tests the New function.
 func TestNew(t *testing.T) {
@@ -110,6 +114,7 @@ type stubHostDB struct{}
 
 func (stubHostDB) AllHosts() (hs []modules.HostDBEntry)                           { return }
 func (stubHostDB) ActiveHosts() (hs []modules.HostDBEntry)                        { return }
+func (stubHostDB) Blacklisted(types.SiaPublicKey) bool                            { return false }
 func (stubHostDB) CheckForIPViolations([]types.SiaPublicKey) []types.SiaPublicKey { return nil }
 func (stubHostDB) Host(types.SiaPublicKey) (h modules.HostDBEntry, ok bool)       { return }
 func (stubHostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey)         { return }
@@ -121,7 +126,9 @@ func (stubHostDB) RandomHosts(int, []types.SiaPublicKey, []types.SiaPublicKey) (
 func (stubHostDB) ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown {
 	return modules.HostScoreBreakdown{}
 }
-func (stubHostDB) SetAllowance(allowance modules.Allowance) error { return nil }
+func (stubHostDB) RecordMisbehavior(types.SiaPublicKey, modules.HostMisbehavior) {}
+func (stubHostDB) UpdateWorkingAddress(types.SiaPublicKey, modules.NetAddress)   {}
+func (stubHostDB) SetAllowance(allowance modules.Allowance) error                { return nil }
 
 // TestAllowanceSpending verifies that the contractor will not spend more or
 // less than the allowance if uploading causes repeated early renewal, and that
@@ -162,6 +169,11 @@ func TestAllowanceSpending(t *testing.T) {
 		if len(hosts) == 0 {
 			return errors.New("host has not been scanned yet")
 		}
+		// contracts are funded according to the host's prices, so the
+		// upload price needs to be up to date
+		if !hosts[0].UploadBandwidthPrice.Equals(settings.MinUploadBandwidthPrice) {
+			return errors.New("host's upload price has not been updated yet")
+		}
 		return nil
 	})
 	if err != nil {
@@ -236,8 +248,9 @@ func TestAllowanceSpending(t *testing.T) {
 		t.Fatal("contractor spent too much money: spent", spent.HumanString(), "allowance funds:", testAllowance.Funds.HumanString())
 	}
 
-	// we should have spent at least the allowance minus the cost of one more refresh
-	refreshCost := c.Contracts()[0].TotalCost.Mul64(2)
+	// we should have spent at least the allowance minus the cost of one more
+	// refresh, which is at least the minimum funding of a contract
+	refreshCost := clampContractFunding(types.ZeroCurrency, testAllowance)
 	expectedMinSpending := testAllowance.Funds.Sub(refreshCost)
 	if spent.Cmp(expectedMinSpending) < 0 {
 		t.Fatal("contractor spent to little money: spent", spent.HumanString(), "expected at least:", expectedMinSpending.HumanString())
@@ -652,6 +665,9 @@ func (ws *testWalletShim) NextAddress() (types.UnlockConditions, error) {
 	ws.nextAddressCalled = true
 	return types.UnlockConditions{}, nil
 }
+func (ws *testWalletShim) PrimarySeed() (modules.Seed, uint64, error) {
+	return modules.Seed{}, 0, nil
+}
 func (ws *testWalletShim) StartTransaction() (modules.TransactionBuilder, error) {
 	ws.startTxnCalled = true
 	return nil, nil
@@ -21,10 +21,12 @@ type (
 	// transactionBuilder.
 	walletShim interface {
 		NextAddress() (types.UnlockConditions, error)
+		PrimarySeed() (modules.Seed, uint64, error)
 		StartTransaction() (modules.TransactionBuilder, error)
 	}
 	wallet interface {
 		NextAddress() (types.UnlockConditions, error)
+		PrimarySeed() (modules.Seed, uint64, error)
 		StartTransaction() (transactionBuilder, error)
 	}
 	transactionBuilder interface {
@@ -50,13 +52,16 @@ type (
 	hostDB interface {
 		AllHosts() []modules.HostDBEntry
 		ActiveHosts() []modules.HostDBEntry
+		Blacklisted(types.SiaPublicKey) bool
 		CheckForIPViolations([]types.SiaPublicKey) []types.SiaPublicKey
 		Host(types.SiaPublicKey) (modules.HostDBEntry, bool)
 		IncrementSuccessfulInteractions(key types.SiaPublicKey)
 		IncrementFailedInteractions(key types.SiaPublicKey)
 		RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]modules.HostDBEntry, error)
+		RecordMisbehavior(key types.SiaPublicKey, misbehavior modules.HostMisbehavior)
 		ScoreBreakdown(modules.HostDBEntry) modules.HostScoreBreakdown
 		SetAllowance(allowance modules.Allowance) error
+		UpdateWorkingAddress(key types.SiaPublicKey, addr modules.NetAddress)
 	}
 
 	persister interface {
@@ -75,6 +80,9 @@ type WalletBridge struct {
 // NextAddress computes and returns the next address of the wallet.
 func (ws *WalletBridge) NextAddress() (types.UnlockConditions, error) { return ws.W.NextAddress() }
 
+// PrimarySeed returns the primary seed of the wallet.
+func (ws *WalletBridge) PrimarySeed() (modules.Seed, uint64, error) { return ws.W.PrimarySeed() }
+
 // StartTransaction creates a new transactionBuilder that can be used to create
 // and sign a transaction.
 func (ws *WalletBridge) StartTransaction() (transactionBuilder, error) { return ws.W.StartTransaction() }
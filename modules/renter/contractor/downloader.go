@@ -96,6 +96,7 @@ func (hd *hostDownloader) Sector(root crypto.Hash) ([]byte, error) {
 	if err != nil {
 		return nil, err
 	}
+	hd.contractor.managedRecordDownload(uint64(len(sector)))
 	return sector, nil
 }
 
@@ -108,6 +108,7 @@ func (he *hostEditor) Upload(data []byte) (_ crypto.Hash, err error) {
 	if err != nil {
 		return crypto.Hash{}, err
 	}
+	he.contractor.managedRecordUpload(modules.SectorSize)
 	return sectorRoot, nil
 }
 
@@ -165,7 +166,7 @@ func (c *Contractor) Editor(pk types.SiaPublicKey, cancel <-chan struct{}) (_ Ed
 		editor:     e,
 		endHeight:  contract.EndHeight,
 		id:         id,
-		netAddress: host.NetAddress,
+		netAddress: e.Address(),
 	}
 	c.mu.Lock()
 	c.editors[contract.ID] = he
@@ -316,6 +316,138 @@ func TestIntegrationUploadDownload(t *testing.T) {
 	}
 }
 
+// recoverContracts starts recovering the missing contracts of the contractor
+// and waits for the recovery to finish.
+func recoverContracts(c *Contractor) (modules.ContractRecoveryStatus, error) {
+	if err := c.RecoverContracts(); err != nil {
+		return modules.ContractRecoveryStatus{}, err
+	}
+	for i := 0; i < 100; i++ {
+		status := c.ContractRecoveryStatus()
+		if !status.Active {
+			if status.Error != "" {
+				return status, errors.New(status.Error)
+			}
+			return status, nil
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+	return modules.ContractRecoveryStatus{}, errors.New("contract recovery did not finish in time")
+}
+
+// TestIntegrationRecoverContracts tests that the contractor can recover a
+// contract that was lost from the contract set using the wallet seed.
+func TestIntegrationRecoverContracts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, m, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.mu.Lock()
+	c.contractIDToPubKey[contract.ID] = contract.HostPublicKey
+	c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+	c.mu.Unlock()
+
+	// upload a sector
+	editor, err := c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, ok = c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+
+	// mine a block to confirm the contract
+	if _, err = m.AddBlock(); err != nil {
+		t.Fatal(err)
+	}
+
+	// nothing should be recovered while the contract is in the set
+	if status, err := recoverContracts(c); err != nil {
+		t.Fatal(err)
+	} else if status.Found != 0 || status.Recovered != 0 {
+		t.Fatalf("expected 0 recovered contracts, got %v", status.Recovered)
+	}
+
+	// lose the contract
+	sc, ok := c.staticContracts.Acquire(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	c.staticContracts.Delete(sc)
+
+	// recover the contract
+	status, err := recoverContracts(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Found != 1 || status.Recovered != 1 {
+		t.Fatalf("expected 1 recovered contract, got %v", status.Recovered)
+	}
+	if status.ScannedHeight != c.blockHeight {
+		t.Fatalf("expected the blockchain to be scanned up to %v, got %v", c.blockHeight, status.ScannedHeight)
+	}
+	recovered, ok := c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("recovered contract not found")
+	}
+	if recovered.Transaction.FileContractRevisions[0].NewRevisionNumber != contract.Transaction.FileContractRevisions[0].NewRevisionNumber {
+		t.Fatal("recovered contract has the wrong revision")
+	}
+	if recovered.Transaction.FileContractRevisions[0].NewFileMerkleRoot != contract.Transaction.FileContractRevisions[0].NewFileMerkleRoot {
+		t.Fatal("recovered contract has the wrong Merkle root")
+	}
+	if !recovered.TotalCost.Equals(contract.TotalCost) || !recovered.ContractFee.Equals(contract.ContractFee) ||
+		!recovered.TxnFee.Equals(contract.TxnFee) || !recovered.SiafundFee.Equals(contract.SiafundFee) {
+		t.Fatal("recovered contract has the wrong costs")
+	}
+
+	// the recovered contract should be usable
+	downloader, err := c.Downloader(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := downloader.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, retrieved) {
+		t.Fatal("downloaded data does not match original")
+	}
+	err = downloader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
 // TestIntegrationRenew tests that the contractor can renew a previously-
 // formed file contract.
 func TestIntegrationRenew(t *testing.T) {
@@ -378,6 +510,11 @@ func TestIntegrationRenew(t *testing.T) {
 	if contract.EndHeight != c.blockHeight+200 {
 		t.Fatal(contract.EndHeight)
 	}
+	oldKey := oldContract.Metadata().Transaction.FileContractRevisions[0].UnlockConditions.PublicKeys[0]
+	newKey := contract.Transaction.FileContractRevisions[0].UnlockConditions.PublicKeys[0]
+	if oldKey.String() == newKey.String() {
+		t.Fatal("renewed contract should be protected by a new key")
+	}
 
 	// download the renewed contract
 	downloader, err := c.Downloader(contract.HostPublicKey, nil)
@@ -428,6 +565,106 @@ func TestIntegrationRenew(t *testing.T) {
 	}
 }
 
+// TestIntegrationRenterPolicy tests that a renter whose prices were
+// multiplied by the host can upload, download and renew.
+func TestIntegrationRenterPolicy(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	// create testing trio
+	h, c, _, err := newTestingTrio(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer h.Close()
+	defer c.Close()
+
+	// get the host's entry from the db
+	hostEntry, ok := c.hdb.Host(h.PublicKey())
+	if !ok {
+		t.Fatal("no entry for host in db")
+	}
+
+	// form a contract with the host
+	_, contract, err := c.managedNewContract(hostEntry, types.SiacoinPrecision.Mul64(50), c.blockHeight+100)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// triple the prices of the renter
+	renterKey := contract.Transaction.FileContractRevisions[0].UnlockConditions.PublicKeys[0]
+	err = h.SetRenterPolicy(renterKey, modules.RenterPolicy{PriceMultiplier: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := h.ExternalSettings()
+
+	// upload a sector at the multiplied prices
+	editor, err := c.Editor(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := fastrand.Bytes(int(modules.SectorSize))
+	root, err := editor.Upload(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = editor.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, ok = c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if contract.UploadSpending.Cmp(settings.UploadBandwidthPrice.Mul64(3*modules.SectorSize)) < 0 {
+		t.Fatal("upload was not paid at the multiplied price:", contract.UploadSpending)
+	}
+
+	// download the sector at the multiplied prices
+	downloader, err := c.Downloader(contract.HostPublicKey, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved, err := downloader.Sector(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(data, retrieved) {
+		t.Fatal("downloaded data does not match original")
+	}
+	err = downloader.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	contract, ok = c.staticContracts.View(contract.ID)
+	if !ok {
+		t.Fatal("contract not found")
+	}
+	if contract.DownloadSpending.Cmp(settings.DownloadBandwidthPrice.Mul64(3*modules.SectorSize)) < 0 {
+		t.Fatal("download was not paid at the multiplied price:", contract.DownloadSpending)
+	}
+
+	// renew the contract at the multiplied prices
+	err = c.managedUpdateContractUtility(contract.ID, modules.ContractUtility{GoodForRenew: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldContract, ok := c.staticContracts.Acquire(contract.ID)
+	if !ok {
+		t.Fatal("failed to acquire contract")
+	}
+	contract, err = c.managedRenew(oldContract, types.SiacoinPrecision.Mul64(50), c.blockHeight+200)
+	c.staticContracts.Return(oldContract)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if contract.ContractFee.Cmp(settings.ContractPrice.Mul64(2)) <= 0 {
+		t.Fatal("renewal was not paid at the multiplied price:", contract.ContractFee)
+	}
+}
+
 // TestIntegrationDownloaderCaching tests that downloaders are properly cached
 // by the contractor. When two downloaders are requested for the same
 // contract, only one underlying downloader should be created.
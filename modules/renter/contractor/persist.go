@@ -14,24 +14,28 @@ import (
 
 // contractorPersist defines what Contractor data persists across sessions.
 type contractorPersist struct {
-	Allowance     modules.Allowance               `json:"allowance"`
-	BlockHeight   types.BlockHeight               `json:"blockheight"`
-	CurrentPeriod types.BlockHeight               `json:"currentperiod"`
-	LastChange    modules.ConsensusChangeID       `json:"lastchange"`
-	OldContracts  []modules.RenterContract        `json:"oldcontracts"`
-	RenewedFrom   map[string]types.FileContractID `json:"renewedfrom"`
-	RenewedTo     map[string]types.FileContractID `json:"renewedto"`
+	Allowance      modules.Allowance               `json:"allowance"`
+	BlockHeight    types.BlockHeight               `json:"blockheight"`
+	CurrentPeriod  types.BlockHeight               `json:"currentperiod"`
+	LastChange     modules.ConsensusChangeID       `json:"lastchange"`
+	OldContracts   []modules.RenterContract        `json:"oldcontracts"`
+	PeriodDownload uint64                          `json:"perioddownload"`
+	PeriodUpload   uint64                          `json:"periodupload"`
+	RenewedFrom    map[string]types.FileContractID `json:"renewedfrom"`
+	RenewedTo      map[string]types.FileContractID `json:"renewedto"`
 }
 
 // persistData returns the data in the Contractor that will be saved to disk.
 func (c *Contractor) persistData() contractorPersist {
 	data := contractorPersist{
-		Allowance:     c.allowance,
-		BlockHeight:   c.blockHeight,
-		CurrentPeriod: c.currentPeriod,
-		LastChange:    c.lastChange,
-		RenewedFrom:   make(map[string]types.FileContractID),
-		RenewedTo:     make(map[string]types.FileContractID),
+		Allowance:      c.allowance,
+		BlockHeight:    c.blockHeight,
+		CurrentPeriod:  c.currentPeriod,
+		LastChange:     c.lastChange,
+		PeriodDownload: c.periodDownload,
+		PeriodUpload:   c.periodUpload,
+		RenewedFrom:    make(map[string]types.FileContractID),
+		RenewedTo:      make(map[string]types.FileContractID),
 	}
 	for k, v := range c.renewedFrom {
 		data.RenewedFrom[k.String()] = v
@@ -52,10 +56,13 @@ func (c *Contractor) load() error {
 	if err != nil {
 		return err
 	}
-	c.allowance = data.Allowance
+	// COMPATv1.3.7 allowances didn't specify the expected usage.
+	c.allowance = allowanceWithDefaultUsage(data.Allowance)
 	c.blockHeight = data.BlockHeight
 	c.currentPeriod = data.CurrentPeriod
 	c.lastChange = data.LastChange
+	c.periodDownload = data.PeriodDownload
+	c.periodUpload = data.PeriodUpload
 	var fcid types.FileContractID
 	for k, v := range data.RenewedFrom {
 		if err := fcid.LoadString(k); err != nil {
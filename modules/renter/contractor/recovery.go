@@ -0,0 +1,252 @@
+package contractor
+
+// recovery.go recovers contracts that are missing from the contract set, e.g.
+// because the contracts directory was lost. Contracts formed by the renter
+// are tagged with an identifier derived from the wallet seed, which allows
+// them to be found in the blockchain. The most recent revisions and the
+// sector roots of the contracts are then fetched from the hosts.
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/renter/proto"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// errRecoveryActive is returned if a contract recovery is started while
+// another one is still running.
+var errRecoveryActive = errors.New("contracts are already being recovered")
+
+// A recoveryScanner scans the blockchain for contracts that were formed using
+// the renter seed, and reports its progress to the contractor.
+type recoveryScanner struct {
+	blockHeight types.BlockHeight
+	contracts   map[types.FileContractID]proto.RecoverableContract
+	contractor  *Contractor
+	renterSeed  proto.RenterSeed
+}
+
+// newRecoveryScanner creates a recoveryScanner for the renter seed.
+func newRecoveryScanner(c *Contractor, rs proto.RenterSeed) *recoveryScanner {
+	return &recoveryScanner{
+		contracts:  make(map[types.FileContractID]proto.RecoverableContract),
+		contractor: c,
+		renterSeed: rs,
+	}
+}
+
+// contractIdentifier returns the contract identifier created with the renter
+// seed that the transaction contains, if any.
+func (rs *recoveryScanner) contractIdentifier(txn types.Transaction) ([]byte, bool) {
+	for _, arb := range txn.ArbitraryData {
+		if rs.renterSeed.IsContractIdentifier(arb) {
+			return append([]byte(nil), arb...), true
+		}
+	}
+	return nil, false
+}
+
+// ProcessConsensusChange collects the contracts of the renter seed that
+// appear in the change.
+func (rs *recoveryScanner) ProcessConsensusChange(cc modules.ConsensusChange) {
+	for _, block := range cc.RevertedBlocks {
+		if block.ID() != types.GenesisID {
+			rs.blockHeight--
+		}
+		for _, txn := range block.Transactions {
+			for i := range txn.FileContracts {
+				delete(rs.contracts, txn.FileContractID(uint64(i)))
+			}
+		}
+	}
+
+	// The renter funds its contracts first, with an output that holds
+	// exactly the funding of the contract. The output is created or spent in
+	// the change that contains the contract.
+	outputs := make(map[types.SiacoinOutputID]types.Currency)
+	for _, diff := range cc.SiacoinOutputDiffs {
+		outputs[diff.ID] = diff.SiacoinOutput.Value
+	}
+	for _, block := range cc.AppliedBlocks {
+		if block.ID() != types.GenesisID {
+			rs.blockHeight++
+		}
+		for _, txn := range block.Transactions {
+			identifier, ok := rs.contractIdentifier(txn)
+			if !ok {
+				continue
+			}
+			var funding, txnFee types.Currency
+			if len(txn.SiacoinInputs) > 0 {
+				funding = outputs[txn.SiacoinInputs[0].ParentID]
+			}
+			for _, fee := range txn.MinerFees {
+				txnFee = txnFee.Add(fee)
+			}
+			for i, fc := range txn.FileContracts {
+				id := txn.FileContractID(uint64(i))
+				rs.contracts[id] = proto.RecoverableContract{
+					FileContract: fc,
+					ID:           id,
+					Identifier:   identifier,
+					StartHeight:  rs.blockHeight,
+					Funding:      funding,
+					TxnFee:       txnFee,
+				}
+			}
+		}
+	}
+
+	rs.contractor.recoveryMu.Lock()
+	rs.contractor.recoveryStatus.ScannedHeight = rs.blockHeight
+	rs.contractor.recoveryMu.Unlock()
+}
+
+// scan subscribes rs to cs and scans the whole blockchain for contracts.
+func (rs *recoveryScanner) scan(cs consensusSet, cancel <-chan struct{}) error {
+	if err := cs.ConsensusSetSubscribe(rs, modules.ConsensusChangeBeginning, cancel); err != nil {
+		return err
+	}
+	cs.Unsubscribe(rs)
+	return nil
+}
+
+// managedRenterSeed returns the renter seed derived from the primary seed of
+// the wallet.
+func (c *Contractor) managedRenterSeed() (proto.RenterSeed, error) {
+	seed, _, err := c.wallet.PrimarySeed()
+	if err != nil {
+		return proto.RenterSeed{}, err
+	}
+	return proto.DeriveRenterSeed(seed), nil
+}
+
+// ContractRecoveryStatus returns the progress of the most recent contract
+// recovery.
+func (c *Contractor) ContractRecoveryStatus() modules.ContractRecoveryStatus {
+	c.recoveryMu.Lock()
+	defer c.recoveryMu.Unlock()
+	return c.recoveryStatus
+}
+
+// RecoverContracts starts recovering the contracts that were formed using the
+// wallet seed and that are missing from the contract set. Scanning the
+// blockchain takes a while, so the recovery runs in the background and its
+// progress is reported by ContractRecoveryStatus.
+func (c *Contractor) RecoverContracts() error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	renterSeed, err := c.managedRenterSeed()
+	if err != nil {
+		return err
+	}
+	c.recoveryMu.Lock()
+	defer c.recoveryMu.Unlock()
+	if c.recoveryStatus.Active {
+		return errRecoveryActive
+	}
+	c.recoveryStatus = modules.ContractRecoveryStatus{Active: true}
+	go c.threadedRecoverContracts(renterSeed)
+	return nil
+}
+
+// threadedRecoverContracts recovers the missing contracts and records the
+// outcome in the recovery status.
+func (c *Contractor) threadedRecoverContracts(renterSeed proto.RenterSeed) {
+	err := c.managedRecoverContracts(renterSeed)
+	if err != nil {
+		c.log.Println("WARN: contract recovery failed:", err)
+	}
+	c.recoveryMu.Lock()
+	c.recoveryStatus.Active = false
+	if err != nil {
+		c.recoveryStatus.Error = err.Error()
+	}
+	c.recoveryMu.Unlock()
+}
+
+// managedRecoverContracts scans the blockchain for contracts that were formed
+// using the wallet seed and that are missing from the contract set. The most
+// recent revisions and the sector roots of these contracts are fetched from
+// the hosts, and the contracts are added to the contract set. Only the most
+// recent unexpired contract with each host is recovered, and only if the
+// contractor doesn't have a contract with the host already.
+func (c *Contractor) managedRecoverContracts(renterSeed proto.RenterSeed) error {
+	if err := c.tg.Add(); err != nil {
+		return err
+	}
+	defer c.tg.Done()
+
+	scanner := newRecoveryScanner(c, renterSeed)
+	if err := scanner.scan(c.cs, c.tg.StopChan()); err != nil {
+		return err
+	}
+
+	// Determine the hosts that we already have contracts with.
+	haveContract := make(map[string]struct{})
+	for _, contract := range c.staticContracts.ViewAll() {
+		haveContract[contract.HostPublicKey.String()] = struct{}{}
+	}
+
+	// Find the host of each contract and keep the most recent contract with
+	// each host. Contracts that have expired or that were retired by the
+	// contractor are ignored.
+	type recovery struct {
+		host     modules.HostDBEntry
+		contract proto.RecoverableContract
+	}
+	recoveries := make(map[string]recovery)
+	hosts := c.hdb.AllHosts()
+	c.mu.RLock()
+	for _, rc := range scanner.contracts {
+		if rc.WindowStart <= c.blockHeight {
+			continue
+		}
+		if _, exists := c.oldContracts[rc.ID]; exists {
+			continue
+		}
+		for _, host := range hosts {
+			if !rc.IsContractHost(renterSeed, host.PublicKey) {
+				continue
+			}
+			key := host.PublicKey.String()
+			if _, exists := haveContract[key]; exists {
+				break
+			}
+			if r, exists := recoveries[key]; !exists || rc.StartHeight > r.contract.StartHeight {
+				recoveries[key] = recovery{host: host, contract: rc}
+			}
+			break
+		}
+	}
+	height := c.blockHeight
+	c.mu.RUnlock()
+	c.recoveryMu.Lock()
+	c.recoveryStatus.Found = len(recoveries)
+	c.recoveryMu.Unlock()
+
+	// Recover the contracts.
+	for _, r := range recoveries {
+		contract, err := c.staticContracts.RecoverContract(r.host, r.contract, renterSeed, height, c.hdb, c.tg.StopChan())
+		if err != nil {
+			c.log.Printf("WARN: unable to recover contract %v with host %v: %v", r.contract.ID, r.host.NetAddress, err)
+			continue
+		}
+		c.mu.Lock()
+		c.contractIDToPubKey[contract.ID] = contract.HostPublicKey
+		c.pubKeysToContractID[string(contract.HostPublicKey.Key)] = contract.ID
+		c.mu.Unlock()
+		c.log.Printf("Recovered contract %v with %v", contract.ID, r.host.NetAddress)
+		c.recoveryMu.Lock()
+		c.recoveryStatus.Recovered++
+		c.recoveryMu.Unlock()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveSync()
+}
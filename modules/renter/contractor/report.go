@@ -0,0 +1,85 @@
+package contractor
+
+// report.go breaks the spending of the contractor down by host and period, so
+// that the costs of the renter can be reconciled per host.
+
+import (
+	"sort"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// contractPeriodStart returns the start of the period in which a contract
+// that started at 'startHeight' was formed. Periods are aligned to the
+// current period of the contractor.
+func contractPeriodStart(startHeight, currentPeriod, period types.BlockHeight) types.BlockHeight {
+	if period == 0 {
+		return currentPeriod
+	}
+	if startHeight >= currentPeriod {
+		return currentPeriod + (startHeight-currentPeriod)/period*period
+	}
+	periods := (currentPeriod - startHeight + period - 1) / period
+	if periods*period > currentPeriod {
+		return 0
+	}
+	return currentPeriod - periods*period
+}
+
+// buildContractReport aggregates the spending of the contracts per host and
+// period. The report is sorted by period and then by host.
+func buildContractReport(contracts []modules.RenterContract, currentPeriod, period types.BlockHeight) []modules.HostSpendingReport {
+	type reportKey struct {
+		host        string
+		periodStart types.BlockHeight
+	}
+	reports := make(map[reportKey]*modules.HostSpendingReport)
+	for _, contract := range contracts {
+		periodStart := contractPeriodStart(contract.StartHeight, currentPeriod, period)
+		key := reportKey{
+			host:        contract.HostPublicKey.String(),
+			periodStart: periodStart,
+		}
+		report, exists := reports[key]
+		if !exists {
+			report = &modules.HostSpendingReport{
+				HostPublicKey: contract.HostPublicKey,
+				PeriodStart:   periodStart,
+				PeriodEnd:     periodStart + period,
+			}
+			reports[key] = report
+		}
+		report.Contracts++
+		report.DownloadSpending = report.DownloadSpending.Add(contract.DownloadSpending)
+		report.StorageSpending = report.StorageSpending.Add(contract.StorageSpending)
+		report.UploadSpending = report.UploadSpending.Add(contract.UploadSpending)
+		report.Fees = report.Fees.Add(contract.ContractFee).Add(contract.TxnFee).Add(contract.SiafundFee)
+		report.ReturnedFunds = report.ReturnedFunds.Add(contract.RenterFunds)
+		report.TotalCost = report.TotalCost.Add(contract.TotalCost)
+	}
+
+	report := make([]modules.HostSpendingReport, 0, len(reports))
+	for _, r := range reports {
+		report = append(report, *r)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].PeriodStart != report[j].PeriodStart {
+			return report[i].PeriodStart < report[j].PeriodStart
+		}
+		return report[i].HostPublicKey.String() < report[j].HostPublicKey.String()
+	})
+	return report
+}
+
+// ContractReport returns the spending of the contractor broken down by host
+// and period. Both the current contracts and the old contracts are included.
+func (c *Contractor) ContractReport() []modules.HostSpendingReport {
+	contracts := c.staticContracts.ViewAll()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, contract := range c.oldContracts {
+		contracts = append(contracts, contract)
+	}
+	return buildContractReport(contracts, c.currentPeriod, c.allowance.Period)
+}
@@ -0,0 +1,82 @@
+package contractor
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestContractPeriodStart tests that contracts are assigned to the correct
+// period.
+func TestContractPeriodStart(t *testing.T) {
+	tests := []struct {
+		startHeight   types.BlockHeight
+		currentPeriod types.BlockHeight
+		period        types.BlockHeight
+		periodStart   types.BlockHeight
+	}{
+		{100, 100, 50, 100},
+		{120, 100, 50, 100},
+		{150, 100, 50, 150},
+		{99, 100, 50, 50},
+		{50, 100, 50, 50},
+		{49, 100, 50, 0},
+		{10, 30, 50, 0},
+		{10, 30, 0, 30},
+	}
+	for _, test := range tests {
+		periodStart := contractPeriodStart(test.startHeight, test.currentPeriod, test.period)
+		if periodStart != test.periodStart {
+			t.Errorf("contract starting at %v in period %v of length %v: expected period start %v, got %v",
+				test.startHeight, test.currentPeriod, test.period, test.periodStart, periodStart)
+		}
+	}
+}
+
+// TestBuildContractReport tests that the spending of contracts is aggregated
+// per host and period.
+func TestBuildContractReport(t *testing.T) {
+	foo := types.SiaPublicKey{Key: []byte("foo")}
+	bar := types.SiaPublicKey{Key: []byte("bar")}
+	contracts := []modules.RenterContract{
+		// Two contracts with foo in the current period, e.g. because of a
+		// refresh.
+		{HostPublicKey: foo, StartHeight: 100, StorageSpending: types.NewCurrency64(1), TxnFee: types.NewCurrency64(2), RenterFunds: types.NewCurrency64(3), TotalCost: types.NewCurrency64(10)},
+		{HostPublicKey: foo, StartHeight: 120, UploadSpending: types.NewCurrency64(4), ContractFee: types.NewCurrency64(5), TotalCost: types.NewCurrency64(20)},
+		// A contract with foo in the previous period.
+		{HostPublicKey: foo, StartHeight: 60, DownloadSpending: types.NewCurrency64(6), TotalCost: types.NewCurrency64(30)},
+		// A contract with bar in the current period.
+		{HostPublicKey: bar, StartHeight: 100, SiafundFee: types.NewCurrency64(7), TotalCost: types.NewCurrency64(40)},
+	}
+	report := buildContractReport(contracts, 100, 50)
+	if len(report) != 3 {
+		t.Fatal("expected 3 entries, got", len(report))
+	}
+
+	// The previous period comes first.
+	if report[0].PeriodStart != 50 || report[0].PeriodEnd != 100 || report[0].HostPublicKey.String() != foo.String() {
+		t.Fatal("wrong first entry:", report[0])
+	}
+	if report[0].Contracts != 1 || !report[0].DownloadSpending.Equals64(6) || !report[0].TotalCost.Equals64(30) {
+		t.Fatal("wrong spending in first entry:", report[0])
+	}
+
+	// Hosts within a period are sorted by key.
+	if report[1].PeriodStart != 100 || report[1].HostPublicKey.String() != bar.String() {
+		t.Fatal("wrong second entry:", report[1])
+	}
+	if !report[1].Fees.Equals64(7) || !report[1].TotalCost.Equals64(40) {
+		t.Fatal("wrong spending in second entry:", report[1])
+	}
+
+	// Both contracts with foo in the current period are combined.
+	r := report[2]
+	if r.PeriodStart != 100 || r.HostPublicKey.String() != foo.String() || r.Contracts != 2 {
+		t.Fatal("wrong third entry:", r)
+	}
+	if !r.StorageSpending.Equals64(1) || !r.UploadSpending.Equals64(4) || !r.Fees.Equals64(7) ||
+		!r.ReturnedFunds.Equals64(3) || !r.TotalCost.Equals64(30) {
+		t.Fatal("wrong spending in third entry:", r)
+	}
+}
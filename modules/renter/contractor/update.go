@@ -45,6 +45,45 @@ func (c *Contractor) managedArchiveContracts() {
 	}
 }
 
+// managedRecordMissedProofs reports hosts that failed to submit a storage
+// proof for one of our contracts to the hostdb. A missed proof is detected by
+// the creation of the missed host payout of the contract.
+func (c *Contractor) managedRecordMissedProofs(cc modules.ConsensusChange) {
+	c.mu.RLock()
+	contracts := c.staticContracts.ViewAll()
+	for _, contract := range c.oldContracts {
+		contracts = append(contracts, contract)
+	}
+	blockHeight := c.blockHeight
+	c.mu.RUnlock()
+
+	// Contracts that don't store any data are skipped, since the host is not
+	// expected to submit a proof for them.
+	missedPayouts := make(map[types.SiacoinOutputID]modules.RenterContract)
+	for _, contract := range contracts {
+		revs := contract.Transaction.FileContractRevisions
+		if len(revs) == 0 || revs[0].NewFileSize == 0 {
+			continue
+		}
+		missedPayouts[contract.ID.StorageProofOutputID(types.ProofMissed, 1)] = contract
+	}
+	for _, dscod := range cc.DelayedSiacoinOutputDiffs {
+		if dscod.Direction != modules.DiffApply {
+			continue
+		}
+		contract, exists := missedPayouts[dscod.ID]
+		if !exists {
+			continue
+		}
+		c.hdb.RecordMisbehavior(contract.HostPublicKey, modules.HostMisbehavior{
+			Type:        modules.HostMisbehaviorMissedStorageProof,
+			ContractID:  contract.ID,
+			BlockHeight: blockHeight,
+			Description: "host did not submit a storage proof",
+		})
+	}
+}
+
 // ProcessConsensusChange will be called by the consensus set every time there
 // is a change in the blockchain. Updates will always be called in order.
 func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
@@ -63,6 +102,8 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	// If we have entered the next period, update currentPeriod
 	if c.blockHeight >= c.currentPeriod+c.allowance.Period {
 		c.currentPeriod += c.allowance.Period
+		c.periodUpload = 0
+		c.periodDownload = 0
 		// COMPATv1.0.4-lts
 		// if we were storing a special metrics contract, it will be invalid
 		// after we enter the next period.
@@ -76,6 +117,9 @@ func (c *Contractor) ProcessConsensusChange(cc modules.ConsensusChange) {
 	}
 	c.mu.Unlock()
 
+	// Report hosts that missed a storage proof for one of our contracts.
+	c.managedRecordMissedProofs(cc)
+
 	// Perform contract maintenance if our blockchain is synced. Use a separate
 	// goroutine so that the rest of the contractor is not blocked during
 	// maintenance.
@@ -25,6 +25,21 @@ var uptimeWindow = func() time.Duration {
 	panic("undefined uptimeWindow")
 }()
 
+// maxMaintenanceWindow is the longest maintenance window that a host can
+// announce. Hosts that announce a longer window are considered offline once
+// maxMaintenanceWindow has passed.
+var maxMaintenanceWindow = func() time.Duration {
+	switch build.Release {
+	case "dev":
+		return 1 * time.Hour
+	case "standard":
+		return 2 * 24 * time.Hour // 2 days.
+	case "testing":
+		return 30 * time.Second
+	}
+	panic("undefined maxMaintenanceWindow")
+}()
+
 // IsOffline indicates whether a contract's host should be considered offline,
 // based on its scan metrics.
 func (c *Contractor) IsOffline(pk types.SiaPublicKey) bool {
@@ -44,6 +59,11 @@ func isOffline(host modules.HostDBEntry) bool {
 		// No scan history, assume offline.
 		return true
 	}
+	// A host that announced a maintenance window is not offline during the
+	// window.
+	if inMaintenanceWindow(host, time.Now()) {
+		return false
+	}
 	// If we only have one scan in the history we return false if it was
 	// successful.
 	if len(host.ScanHistory) == 1 {
@@ -55,3 +75,28 @@ func isOffline(host modules.HostDBEntry) bool {
 	success2 := host.ScanHistory[len(host.ScanHistory)-2].Success
 	return !(success1 || success2)
 }
+
+// inMaintenanceWindow indicates whether a host is within the maintenance
+// window that it announced. The settings of the host are from its last
+// successful scan, the window can't extend beyond maxMaintenanceWindow after
+// that scan.
+func inMaintenanceWindow(host modules.HostDBEntry, now time.Time) bool {
+	if !host.Maintenance.Active || host.Maintenance.End == 0 {
+		return false
+	}
+	var announced time.Time
+	for i := len(host.ScanHistory) - 1; i >= 0; i-- {
+		if host.ScanHistory[i].Success {
+			announced = host.ScanHistory[i].Timestamp
+			break
+		}
+	}
+	if announced.IsZero() {
+		return false
+	}
+	end := time.Unix(int64(host.Maintenance.End), 0)
+	if end.Sub(announced) > maxMaintenanceWindow {
+		end = announced.Add(maxMaintenanceWindow)
+	}
+	return now.Before(end)
+}
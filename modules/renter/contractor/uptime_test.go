@@ -0,0 +1,54 @@
+package contractor
+
+import (
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestIsOfflineMaintenance checks that a host is not considered offline
+// during the maintenance window that it announced.
+func TestIsOfflineMaintenance(t *testing.T) {
+	now := time.Now()
+	var host modules.HostDBEntry
+	host.ScanHistory = modules.HostDBScans{
+		{Timestamp: now.Add(-3 * time.Second), Success: true},
+		{Timestamp: now.Add(-2 * time.Second), Success: false},
+		{Timestamp: now.Add(-1 * time.Second), Success: false},
+	}
+	if !isOffline(host) {
+		t.Fatal("host without maintenance should be offline")
+	}
+
+	// Announce a maintenance window.
+	host.Maintenance = modules.HostMaintenance{
+		Active: true,
+		End:    types.Timestamp(now.Add(10 * time.Second).Unix()),
+	}
+	if isOffline(host) {
+		t.Fatal("host should not be offline during its maintenance window")
+	}
+
+	// Without an end, there is no window.
+	host.Maintenance.End = 0
+	if !isOffline(host) {
+		t.Fatal("host without a maintenance window should be offline")
+	}
+
+	// After the window, the host is offline again.
+	host.Maintenance.End = types.Timestamp(now.Add(-time.Second).Unix())
+	if !isOffline(host) {
+		t.Fatal("host should be offline after its maintenance window")
+	}
+
+	// The window is capped.
+	host.Maintenance.End = types.Timestamp(now.Add(time.Hour).Unix())
+	if !inMaintenanceWindow(host, now) {
+		t.Fatal("host should be in its maintenance window")
+	}
+	if inMaintenanceWindow(host, now.Add(maxMaintenanceWindow)) {
+		t.Fatal("maintenance window should be capped")
+	}
+}
@@ -0,0 +1,155 @@
+package contractor
+
+// usage.go sizes contracts according to the usage that the allowance expects,
+// using the actual prices of the host. It also keeps track of the usage that
+// is observed during the current period, so that the renter can compare its
+// expectations to reality.
+
+import (
+	"errors"
+	"reflect"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// allowanceWithDefaultUsage returns the allowance with the default expected
+// usage if the allowance doesn't specify any expected usage. The empty
+// allowance is returned unchanged, since it cancels the allowance.
+func allowanceWithDefaultUsage(a modules.Allowance) modules.Allowance {
+	if reflect.DeepEqual(a, modules.Allowance{}) {
+		return a
+	}
+	return a.WithDefaultUsage()
+}
+
+// usageCost returns the amount of money that storing 'storage' bytes and
+// uploading and downloading 'upload' and 'download' bytes per block costs
+// with the host for 'duration' blocks.
+func usageCost(host modules.HostDBEntry, storage, upload, download uint64, duration types.BlockHeight) types.Currency {
+	storageCost := host.StoragePrice.Mul64(storage).Mul64(uint64(duration))
+	uploadCost := host.UploadBandwidthPrice.Mul64(upload).Mul64(uint64(duration))
+	downloadCost := host.DownloadBandwidthPrice.Mul64(download).Mul64(uint64(duration))
+	return storageCost.Add(uploadCost).Add(downloadCost)
+}
+
+// contractCost adds the contract price, the siafund fee, the transaction fees
+// and a margin of error of 33% to the expected spending of a contract.
+func contractCost(host modules.HostDBEntry, spending types.Currency, blockHeight types.BlockHeight, txnFees types.Currency) types.Currency {
+	beforeSiafundFees := spending.Add(host.ContractPrice)
+	cost := types.Tax(blockHeight, beforeSiafundFees).Add(beforeSiafundFees).Add(txnFees)
+	return cost.Add(cost.Div64(3))
+}
+
+// clampContractFunding makes sure that the funding of a contract is at least
+// 'fileContractMinimumFunding' of the contract's share of the allowance and at
+// most the contract's entire share.
+func clampContractFunding(funding types.Currency, allowance modules.Allowance) types.Currency {
+	share := allowance.Funds.Div64(allowance.Hosts)
+	minimum := share.MulFloat(fileContractMinimumFunding)
+	if funding.Cmp(minimum) < 0 {
+		return minimum
+	}
+	if funding.Cmp(share) > 0 {
+		return share
+	}
+	return funding
+}
+
+// managedTxnFees returns the transaction fees that are expected to be paid
+// when forming or renewing a contract.
+func (c *Contractor) managedTxnFees() types.Currency {
+	_, maxTxnFee := c.tpool.FeeEstimation()
+	return maxTxnFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
+}
+
+// managedEstimateFormationFundingRequirements estimates the amount of money
+// that a new contract with the host needs to cover the usage that the
+// allowance expects until the contract ends.
+func (c *Contractor) managedEstimateFormationFundingRequirements(host modules.HostDBEntry, blockHeight, endHeight types.BlockHeight, allowance modules.Allowance) types.Currency {
+	duration := types.BlockHeight(1)
+	if endHeight > blockHeight {
+		duration = endHeight - blockHeight
+	}
+	storage, upload, download := allowance.ExpectedContractUsage()
+	spending := usageCost(host, storage, upload, download, duration)
+	return clampContractFunding(contractCost(host, spending, blockHeight, c.managedTxnFees()), allowance)
+}
+
+// managedEstimateRefreshFundingRequirements estimates the amount of money that
+// a contract which ran out of funds needs for the rest of the period. The
+// estimate is based on whichever is larger: the usage that the allowance
+// expects, or the rate at which the contract has been spending money so far.
+func (c *Contractor) managedEstimateRefreshFundingRequirements(contract modules.RenterContract, blockHeight, endHeight types.BlockHeight, allowance modules.Allowance) (types.Currency, error) {
+	host, exists := c.hdb.Host(contract.HostPublicKey)
+	if !exists {
+		return types.ZeroCurrency, errors.New("could not find host in hostdb")
+	}
+	remaining := types.BlockHeight(1)
+	if endHeight > blockHeight {
+		remaining = endHeight - blockHeight
+	}
+	elapsed := types.BlockHeight(1)
+	if blockHeight > contract.StartHeight {
+		elapsed = blockHeight - contract.StartHeight
+	}
+
+	// Estimate the spending based on the expected usage.
+	storage, upload, download := allowance.ExpectedContractUsage()
+	spending := usageCost(host, storage, upload, download, remaining)
+
+	// Extrapolate the spending of the contract so far.
+	spent := contract.UploadSpending.Add(contract.DownloadSpending).Add(contract.StorageSpending)
+	observedSpending := spent.Mul64(uint64(remaining)).Div64(uint64(elapsed))
+	if observedSpending.Cmp(spending) > 0 {
+		spending = observedSpending
+	}
+	return clampContractFunding(contractCost(host, spending, blockHeight, c.managedTxnFees()), allowance), nil
+}
+
+// managedRecordUpload adds the uploaded bytes to the observed usage of the
+// current period.
+func (c *Contractor) managedRecordUpload(n uint64) {
+	c.mu.Lock()
+	c.periodUpload += n
+	c.mu.Unlock()
+}
+
+// managedRecordDownload adds the downloaded bytes to the observed usage of the
+// current period.
+func (c *Contractor) managedRecordDownload(n uint64) {
+	c.mu.Lock()
+	c.periodDownload += n
+	c.mu.Unlock()
+}
+
+// PeriodUsage compares the usage expected by the allowance with the usage
+// observed during the current period.
+func (c *Contractor) PeriodUsage() modules.ContractorUsage {
+	contracts := c.staticContracts.ViewAll()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Observed values include redundancy, which is removed using the expected
+	// redundancy of the allowance.
+	redundancy := c.allowance.ExpectedRedundancy
+	if redundancy == 0 {
+		redundancy = 1
+	}
+	elapsed := uint64(1)
+	if c.blockHeight > c.currentPeriod {
+		elapsed = uint64(c.blockHeight - c.currentPeriod)
+	}
+	var stored uint64
+	for _, contract := range contracts {
+		stored += contract.Transaction.FileContractRevisions[0].NewFileSize
+	}
+	return modules.ContractorUsage{
+		ExpectedStorage:  c.allowance.ExpectedStorage,
+		ObservedStorage:  uint64(float64(stored) / redundancy),
+		ExpectedUpload:   c.allowance.ExpectedUpload,
+		ObservedUpload:   uint64(float64(c.periodUpload)/redundancy) / elapsed,
+		ExpectedDownload: c.allowance.ExpectedDownload,
+		ObservedDownload: c.periodDownload / elapsed,
+	}
+}
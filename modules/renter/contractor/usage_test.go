@@ -0,0 +1,258 @@
+package contractor
+
+import (
+	"reflect"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// usageHostDB is a stubHostDB that knows a single host.
+type usageHostDB struct {
+	stubHostDB
+	host modules.HostDBEntry
+}
+
+func (hdb usageHostDB) Host(spk types.SiaPublicKey) (modules.HostDBEntry, bool) {
+	return hdb.host, spk.String() == hdb.host.PublicKey.String()
+}
+
+// usageTpool is a transaction pool stub with a fixed fee estimation.
+type usageTpool struct {
+	newStub
+	maxFee types.Currency
+}
+
+func (tp usageTpool) FeeEstimation() (types.Currency, types.Currency) {
+	return types.ZeroCurrency, tp.maxFee
+}
+
+// TestAllowanceWithDefaultUsage checks that the default usage is only added to
+// allowances that don't specify any usage, and never to the empty allowance.
+func TestAllowanceWithDefaultUsage(t *testing.T) {
+	// The empty allowance cancels the allowance and must stay empty.
+	if a := allowanceWithDefaultUsage(modules.Allowance{}); !reflect.DeepEqual(a, modules.Allowance{}) {
+		t.Fatal("empty allowance was changed:", a)
+	}
+
+	// An allowance without usage gets the default usage.
+	a := allowanceWithDefaultUsage(modules.Allowance{Funds: types.SiacoinPrecision, Hosts: 1})
+	if a.ExpectedStorage != modules.DefaultAllowance.ExpectedStorage ||
+		a.ExpectedUpload != modules.DefaultAllowance.ExpectedUpload ||
+		a.ExpectedDownload != modules.DefaultAllowance.ExpectedDownload ||
+		a.ExpectedRedundancy != modules.DefaultAllowance.ExpectedRedundancy {
+		t.Fatal("default usage was not added:", a)
+	}
+	if !a.Funds.Equals(types.SiacoinPrecision) || a.Hosts != 1 {
+		t.Fatal("allowance was changed:", a)
+	}
+
+	// An allowance which specifies some of the usage is left alone.
+	partial := modules.Allowance{Funds: types.SiacoinPrecision, Hosts: 1, ExpectedDownload: 10}
+	if a := allowanceWithDefaultUsage(partial); !reflect.DeepEqual(a, partial) {
+		t.Fatal("allowance with usage was changed:", a)
+	}
+}
+
+// TestUsageCost checks the cost of storing, uploading and downloading data
+// with a host.
+func TestUsageCost(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.StoragePrice = types.NewCurrency64(1)
+	host.UploadBandwidthPrice = types.NewCurrency64(2)
+	host.DownloadBandwidthPrice = types.NewCurrency64(3)
+
+	// 10*1*5 + 20*2*5 + 30*3*5
+	if cost := usageCost(host, 10, 20, 30, 5); !cost.Equals64(700) {
+		t.Fatal("wrong usage cost:", cost)
+	}
+	if cost := usageCost(host, 10, 20, 30, 0); !cost.IsZero() {
+		t.Fatal("usage cost should be zero without duration:", cost)
+	}
+}
+
+// TestContractCost checks that the contract price, the siafund fee, the
+// transaction fees and the margin of error are added to the spending.
+func TestContractCost(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.ContractPrice = types.SiacoinPrecision.Mul64(10)
+	spending := types.SiacoinPrecision.Mul64(290)
+	txnFees := types.SiacoinPrecision.Mul64(5)
+
+	beforeFees := types.SiacoinPrecision.Mul64(300)
+	expected := types.Tax(100, beforeFees).Add(beforeFees).Add(txnFees)
+	expected = expected.Add(expected.Div64(3))
+	if cost := contractCost(host, spending, 100, txnFees); !cost.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), cost.HumanString())
+	}
+	if cost := contractCost(host, spending, 100, txnFees); cost.Cmp(beforeFees.Add(txnFees)) <= 0 {
+		t.Fatal("contract cost should include a margin of error:", cost.HumanString())
+	}
+}
+
+// TestClampContractFunding checks that contract funding is kept between the
+// minimum funding and the contract's share of the allowance.
+func TestClampContractFunding(t *testing.T) {
+	allowance := modules.Allowance{
+		Funds: types.NewCurrency64(1000),
+		Hosts: 2,
+	}
+	share := types.NewCurrency64(500)
+	minimum := share.MulFloat(fileContractMinimumFunding)
+
+	tests := []struct {
+		funding  types.Currency
+		expected types.Currency
+	}{
+		{types.ZeroCurrency, minimum},
+		{minimum.Sub(types.NewCurrency64(1)), minimum},
+		{minimum, minimum},
+		{types.NewCurrency64(200), types.NewCurrency64(200)},
+		{share, share},
+		{share.Add(types.NewCurrency64(1)), share},
+		{allowance.Funds, share},
+	}
+	for _, test := range tests {
+		if funding := clampContractFunding(test.funding, allowance); !funding.Equals(test.expected) {
+			t.Errorf("clampContractFunding(%v): expected %v, got %v", test.funding, test.expected, funding)
+		}
+	}
+}
+
+// TestEstimateFormationFundingRequirements checks that new contracts are
+// funded according to the expected usage and the host's prices.
+func TestEstimateFormationFundingRequirements(t *testing.T) {
+	c := &Contractor{
+		tpool: usageTpool{maxFee: types.NewCurrency64(10)},
+	}
+	allowance := modules.Allowance{
+		Funds:              types.SiacoinPrecision.Mul64(1000),
+		Hosts:              10,
+		ExpectedStorage:    1e9,
+		ExpectedUpload:     1e3,
+		ExpectedDownload:   2e3,
+		ExpectedRedundancy: 2,
+	}
+	share := allowance.Funds.Div64(allowance.Hosts)
+	host := modules.HostDBEntry{}
+	host.StoragePrice = types.NewCurrency64(1e6)
+	host.UploadBandwidthPrice = types.NewCurrency64(1e9)
+	host.DownloadBandwidthPrice = types.NewCurrency64(1e9)
+	host.ContractPrice = types.SiacoinPrecision.Mul64(20)
+
+	// The funding should match the expected usage of a single contract.
+	storage, upload, download := allowance.ExpectedContractUsage()
+	spending := usageCost(host, storage, upload, download, 100)
+	expected := contractCost(host, spending, 50, c.managedTxnFees())
+	if expected.Cmp(share) >= 0 || expected.Cmp(share.MulFloat(fileContractMinimumFunding)) <= 0 {
+		t.Fatal("test parameters should not cause the funding to be clamped")
+	}
+	funding := c.managedEstimateFormationFundingRequirements(host, 50, 150, allowance)
+	if !funding.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), funding.HumanString())
+	}
+
+	// A contract that ends before the current height is funded for a single
+	// block.
+	spending = usageCost(host, storage, upload, download, 1)
+	expected = clampContractFunding(contractCost(host, spending, 50, c.managedTxnFees()), allowance)
+	if funding := c.managedEstimateFormationFundingRequirements(host, 50, 40, allowance); !funding.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), funding.HumanString())
+	}
+
+	// A cheap host gets the minimum funding and an expensive host gets the
+	// contract's entire share.
+	cheap := host
+	cheap.StoragePrice = types.ZeroCurrency
+	cheap.UploadBandwidthPrice = types.ZeroCurrency
+	cheap.DownloadBandwidthPrice = types.ZeroCurrency
+	cheap.ContractPrice = types.ZeroCurrency
+	if funding := c.managedEstimateFormationFundingRequirements(cheap, 50, 150, allowance); !funding.Equals(share.MulFloat(fileContractMinimumFunding)) {
+		t.Fatal("cheap host should get the minimum funding:", funding.HumanString())
+	}
+	expensive := host
+	expensive.StoragePrice = types.SiacoinPrecision
+	if funding := c.managedEstimateFormationFundingRequirements(expensive, 50, 150, allowance); !funding.Equals(share) {
+		t.Fatal("expensive host should get the contract's share:", funding.HumanString())
+	}
+
+	// An allowance without usage is sized with the default usage.
+	noUsage := allowance
+	noUsage.ExpectedStorage, noUsage.ExpectedUpload, noUsage.ExpectedDownload, noUsage.ExpectedRedundancy = 0, 0, 0, 0
+	storage, upload, download = noUsage.WithDefaultUsage().ExpectedContractUsage()
+	spending = usageCost(host, storage, upload, download, 100)
+	expected = clampContractFunding(contractCost(host, spending, 50, c.managedTxnFees()), noUsage)
+	if funding := c.managedEstimateFormationFundingRequirements(host, 50, 150, noUsage); !funding.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), funding.HumanString())
+	}
+}
+
+// TestEstimateRefreshFundingRequirements checks that refreshed contracts are
+// funded according to whichever is larger, the expected usage or the spending
+// that was observed so far.
+func TestEstimateRefreshFundingRequirements(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.PublicKey = types.SiaPublicKey{Key: []byte("host")}
+	host.StoragePrice = types.NewCurrency64(1e6)
+	host.UploadBandwidthPrice = types.NewCurrency64(1e9)
+	host.DownloadBandwidthPrice = types.NewCurrency64(1e9)
+	host.ContractPrice = types.SiacoinPrecision.Mul64(20)
+	c := &Contractor{
+		hdb:   usageHostDB{host: host},
+		tpool: usageTpool{maxFee: types.NewCurrency64(10)},
+	}
+	allowance := modules.Allowance{
+		Funds:              types.SiacoinPrecision.Mul64(1000),
+		Hosts:              10,
+		ExpectedStorage:    1e9,
+		ExpectedUpload:     1e3,
+		ExpectedDownload:   2e3,
+		ExpectedRedundancy: 2,
+	}
+	contract := modules.RenterContract{
+		HostPublicKey: host.PublicKey,
+		StartHeight:   50,
+	}
+
+	// Without any spending, the expected usage determines the funding for
+	// the remaining 75 blocks.
+	storage, upload, download := allowance.ExpectedContractUsage()
+	expected := contractCost(host, usageCost(host, storage, upload, download, 75), 75, c.managedTxnFees())
+	funding, err := c.managedEstimateRefreshFundingRequirements(contract, 75, 150, allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !funding.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), funding.HumanString())
+	}
+
+	// Heavy spending during the first 25 blocks is extrapolated to the
+	// remaining 75 blocks.
+	contract.UploadSpending = types.SiacoinPrecision.Mul64(5)
+	contract.StorageSpending = types.SiacoinPrecision.Mul64(5)
+	expected = contractCost(host, types.SiacoinPrecision.Mul64(30), 75, c.managedTxnFees())
+	funding, err = c.managedEstimateRefreshFundingRequirements(contract, 75, 150, allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !funding.Equals(expected) {
+		t.Fatalf("expected %v, got %v", expected.HumanString(), funding.HumanString())
+	}
+
+	// The funding can't exceed the contract's share.
+	contract.DownloadSpending = allowance.Funds
+	funding, err = c.managedEstimateRefreshFundingRequirements(contract, 75, 150, allowance)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if share := allowance.Funds.Div64(allowance.Hosts); !funding.Equals(share) {
+		t.Fatal("funding should be limited to the contract's share:", funding.HumanString())
+	}
+
+	// Contracts with unknown hosts can't be refreshed.
+	contract.HostPublicKey = types.SiaPublicKey{Key: []byte("unknown")}
+	if _, err := c.managedEstimateRefreshFundingRequirements(contract, 75, 150, allowance); err == nil {
+		t.Fatal("expected an error for an unknown host")
+	}
+}
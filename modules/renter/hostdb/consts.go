@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 const (
@@ -29,10 +30,20 @@ const (
 	// scan.
 	hostScanDeadline = 4 * time.Minute
 
+	// maxHostMisbehaviors is the maximum number of pieces of misbehavior
+	// evidence that are kept for a single host. Once the limit is reached,
+	// the oldest evidence is dropped.
+	maxHostMisbehaviors = 25
+
 	// maxHostDowntime specifies the maximum amount of time that a host is
 	// allowed to be offline while still being in the hostdb.
 	maxHostDowntime = 10 * 24 * time.Hour
 
+	// maxScanHistory is the maximum number of scans that are kept in the scan
+	// history of a host. Older scans are compressed into the historic uptime
+	// and downtime of the host.
+	maxScanHistory = 100
+
 	// maxSettingsLen indicates how long in bytes the host settings field is
 	// allowed to be before being ignored as a DoS attempt.
 	maxSettingsLen = 10e3
@@ -88,6 +99,16 @@ var (
 	}).(int)
 )
 
+var (
+	// defaultMisbehaviorBlacklistPeriod is the number of blocks that a host is
+	// excluded from host selection after it was caught misbehaving.
+	defaultMisbehaviorBlacklistPeriod = build.Select(build.Var{
+		Standard: types.BlockHeight(12096),
+		Dev:      types.BlockHeight(200),
+		Testing:  types.BlockHeight(20),
+	}).(types.BlockHeight)
+)
+
 var (
 	// maxScanSleep is the maximum amount of time that the hostdb will sleep
 	// between performing scans of the hosts.
@@ -36,6 +36,7 @@ var (
 type HostDB struct {
 	// dependencies
 	cs         modules.ConsensusSet
+	db         *persist.BoltDatabase
 	deps       modules.Dependencies
 	gateway    modules.Gateway
 	log        *persist.Logger
@@ -54,6 +55,10 @@ type HostDB struct {
 	// random.
 	hostTree *hosttree.HostTree
 
+	// dirtyHosts contains the hosts whose entries changed since the last
+	// save. Only these entries are written to the database during a save.
+	dirtyHosts map[string]types.SiaPublicKey
+
 	// the scanPool is a set of hosts that need to be scanned. There are a
 	// handful of goroutines constantly waiting on the channel for hosts to
 	// scan. The scan map is used to prevent duplicates from entering the scan
@@ -66,6 +71,13 @@ type HostDB struct {
 	scanWait                bool
 	scanningThreads         int
 
+	// misbehavingHosts maps the public keys of hosts that were caught
+	// misbehaving to the height of their most recent misbehavior. Hosts are
+	// excluded from host selection for misbehaviorBlacklistPeriod blocks
+	// after misbehaving.
+	misbehavingHosts           map[string]types.BlockHeight
+	misbehaviorBlacklistPeriod types.BlockHeight
+
 	blockHeight types.BlockHeight
 	lastChange  modules.ConsensusChangeID
 }
@@ -94,7 +106,10 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 		gateway:    g,
 		persistDir: persistDir,
 
-		scanMap: make(map[string]struct{}),
+		dirtyHosts:                 make(map[string]types.SiaPublicKey),
+		misbehavingHosts:           make(map[string]types.BlockHeight),
+		misbehaviorBlacklistPeriod: defaultMisbehaviorBlacklistPeriod,
+		scanMap:                    make(map[string]struct{}),
 	}
 
 	// Set the hostweight function.
@@ -128,6 +143,18 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 	// The host tree is used to manage hosts and query them at random.
 	hdb.hostTree = hosttree.New(hdb.weightFunc, deps.Resolver())
 
+	// Open the database.
+	err = hdb.openDB()
+	if err != nil {
+		return nil, err
+	}
+	err = hdb.tg.AfterStop(func() error {
+		return hdb.db.Close()
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	// Load the prior persistence structures.
 	hdb.mu.Lock()
 	err = hdb.load()
@@ -203,9 +230,12 @@ func NewCustomHostDB(g modules.Gateway, cs modules.ConsensusSet, persistDir stri
 }
 
 // ActiveHosts returns a list of hosts that are currently online, sorted by
-// weight.
+// weight. Hosts that are blacklisted due to misbehavior are not considered
+// active.
 func (hdb *HostDB) ActiveHosts() (activeHosts []modules.HostDBEntry) {
 	allHosts := hdb.hostTree.All()
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
 	for _, entry := range allHosts {
 		if len(entry.ScanHistory) == 0 {
 			continue
@@ -216,6 +246,9 @@ func (hdb *HostDB) ActiveHosts() (activeHosts []modules.HostDBEntry) {
 		if !entry.AcceptingContracts {
 			continue
 		}
+		if height, exists := hdb.misbehavingHosts[entry.PublicKey.String()]; exists && hdb.withinBlacklistPeriod(height) {
+			continue
+		}
 		activeHosts = append(activeHosts, entry)
 	}
 	return activeHosts
@@ -329,11 +362,13 @@ func (hdb *HostDB) IPViolationsCheck() bool {
 // RandomHosts implements the HostDB interface's RandomHosts() method. It takes
 // a number of hosts to return, and a slice of netaddresses to ignore, and
 // returns a slice of entries. If the IP violation check was disabled, the
-// addressBlacklist is ignored.
+// addressBlacklist is ignored. Hosts that are blacklisted due to misbehavior
+// are never returned.
 func (hdb *HostDB) RandomHosts(n int, blacklist, addressBlacklist []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
 	hdb.mu.RLock()
 	initialScanComplete := hdb.initialScanComplete
 	ipCheckDisabled := hdb.disableIPViolationCheck
+	blacklist = append(hdb.blacklistedHosts(), blacklist...)
 	hdb.mu.RUnlock()
 	if !initialScanComplete {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
@@ -359,6 +394,7 @@ func (hdb *HostDB) SetIPViolationCheck(enabled bool) {
 func (hdb *HostDB) RandomHostsWithAllowance(n int, blacklist, addressBlacklist []types.SiaPublicKey, allowance modules.Allowance) ([]modules.HostDBEntry, error) {
 	hdb.mu.RLock()
 	initialScanComplete := hdb.initialScanComplete
+	blacklist = append(hdb.blacklistedHosts(), blacklist...)
 	hdb.mu.RUnlock()
 	if !initialScanComplete {
 		return []modules.HostDBEntry{}, ErrInitialScanIncomplete
@@ -41,7 +41,9 @@ type hdbTester struct {
 // dependencies or scanning threads. It is only intended for use in unit tests.
 func bareHostDB() *HostDB {
 	hdb := &HostDB{
-		log: persist.NewLogger(ioutil.Discard),
+		dirtyHosts:       make(map[string]types.SiaPublicKey),
+		log:              persist.NewLogger(ioutil.Discard),
+		misbehavingHosts: make(map[string]types.BlockHeight),
 	}
 	hdb.weightFunc = hdb.calculateHostWeightFn(modules.DefaultAllowance)
 	hdb.hostTree = hosttree.New(hdb.weightFunc, &modules.ProductionResolver{})
@@ -441,6 +443,35 @@ func TestRemoveNonexistingHostFromHostTree(t *testing.T) {
 	}
 }
 
+// TestUpdateWorkingAddress checks that the address at which a host was reached
+// is stored in its entry.
+func TestUpdateWorkingAddress(t *testing.T) {
+	hdb := bareHostDB()
+	host := makeHostDBEntry()
+	host.NetAddress = "host.com:1234"
+	host.NetAddresses = []modules.NetAddress{"host.com:1234", "1.2.3.4:1234"}
+	if err := hdb.hostTree.Insert(host); err != nil {
+		t.Fatal(err)
+	}
+
+	// Update the address to the alternate address.
+	hdb.UpdateWorkingAddress(host.PublicKey, "1.2.3.4:1234")
+	entry, ok := hdb.Host(host.PublicKey)
+	if !ok {
+		t.Fatal("host not found")
+	}
+	if entry.WorkingAddress != "1.2.3.4:1234" {
+		t.Fatal("working address was not updated:", entry.WorkingAddress)
+	}
+
+	// Updating the address of an unknown host should be a no-op.
+	unknown := makeHostDBEntry()
+	hdb.UpdateWorkingAddress(unknown.PublicKey, "1.2.3.4:1234")
+	if _, ok := hdb.Host(unknown.PublicKey); ok {
+		t.Fatal("unknown host was added to the hostdb")
+	}
+}
+
 // TestUpdateHistoricInteractions is a simple check to ensure that incrementing
 // the recent and historic host interactions works
 func TestUpdateHistoricInteractions(t *testing.T) {
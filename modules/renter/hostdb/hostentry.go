@@ -89,7 +89,7 @@ func (hdb *HostDB) IncrementSuccessfulInteractions(key types.SiaPublicKey) {
 
 	// Increment the successful interactions
 	host.RecentSuccessfulInteractions++
-	hdb.hostTree.Modify(host)
+	hdb.modifyHost(host)
 }
 
 // IncrementFailedInteractions increments the number of failed interactions with
@@ -110,5 +110,23 @@ func (hdb *HostDB) IncrementFailedInteractions(key types.SiaPublicKey) {
 
 	// Increment the failed interactions
 	host.RecentFailedInteractions++
-	hdb.hostTree.Modify(host)
+	hdb.modifyHost(host)
+}
+
+// UpdateWorkingAddress sets the address at which the host with the given key
+// was last reached.
+func (hdb *HostDB) UpdateWorkingAddress(key types.SiaPublicKey, addr modules.NetAddress) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Fetch the host.
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost || host.WorkingAddress == addr {
+		return
+	}
+	if addr != host.NetAddress {
+		hdb.log.Debugf("Reached host %v at alternate address %v", key, addr)
+	}
+	host.WorkingAddress = addr
+	hdb.modifyHost(host)
 }
@@ -0,0 +1,110 @@
+package hostdb
+
+// misbehavior.go keeps track of hosts that have been caught misbehaving. Only
+// misbehavior that can be proven cryptographically is tracked, e.g. a host
+// serving data that doesn't match its Merkle root or a host missing a storage
+// proof. Unlike failed interactions, such evidence can't be the result of a
+// bad network connection, which is why these hosts are excluded from host
+// selection for a while instead of just receiving a lower score.
+
+import (
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// withinBlacklistPeriod returns true if a host whose most recent misbehavior
+// was recorded at the provided height is currently excluded from host
+// selection.
+func (hdb *HostDB) withinBlacklistPeriod(lastMisbehavior types.BlockHeight) bool {
+	return lastMisbehavior+hdb.misbehaviorBlacklistPeriod > hdb.blockHeight
+}
+
+// blacklistedHosts returns the public keys of all the hosts that are
+// currently excluded from host selection due to misbehavior.
+func (hdb *HostDB) blacklistedHosts() (keys []types.SiaPublicKey) {
+	for key, height := range hdb.misbehavingHosts {
+		if !hdb.withinBlacklistPeriod(height) {
+			continue
+		}
+		var spk types.SiaPublicKey
+		spk.LoadString(key)
+		keys = append(keys, spk)
+	}
+	return keys
+}
+
+// trackMisbehavior updates the in-memory index of misbehaving hosts with the
+// evidence of the provided entry.
+func (hdb *HostDB) trackMisbehavior(entry modules.HostDBEntry) {
+	for _, m := range entry.Misbehavior {
+		key := entry.PublicKey.String()
+		if height, exists := hdb.misbehavingHosts[key]; !exists || m.BlockHeight > height {
+			hdb.misbehavingHosts[key] = m.BlockHeight
+		}
+	}
+}
+
+// Blacklisted returns true if the host with the provided public key is
+// currently excluded from host selection due to misbehavior.
+func (hdb *HostDB) Blacklisted(key types.SiaPublicKey) bool {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	height, exists := hdb.misbehavingHosts[key.String()]
+	return exists && hdb.withinBlacklistPeriod(height)
+}
+
+// MisbehaviorBlacklistPeriod returns the number of blocks that a host is
+// excluded from host selection after being caught misbehaving.
+func (hdb *HostDB) MisbehaviorBlacklistPeriod() types.BlockHeight {
+	hdb.mu.RLock()
+	defer hdb.mu.RUnlock()
+	return hdb.misbehaviorBlacklistPeriod
+}
+
+// SetMisbehaviorBlacklistPeriod sets the number of blocks that a host is
+// excluded from host selection after being caught misbehaving.
+func (hdb *HostDB) SetMisbehaviorBlacklistPeriod(period types.BlockHeight) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+	hdb.misbehaviorBlacklistPeriod = period
+}
+
+// RecordMisbehavior adds a piece of misbehavior evidence to the entry of the
+// host with the provided public key. If the evidence doesn't specify a block
+// height or timestamp, the current ones are used.
+func (hdb *HostDB) RecordMisbehavior(key types.SiaPublicKey, misbehavior modules.HostMisbehavior) {
+	hdb.mu.Lock()
+	defer hdb.mu.Unlock()
+
+	// Fetch the host.
+	host, haveHost := hdb.hostTree.Select(key)
+	if !haveHost {
+		return
+	}
+	if misbehavior.BlockHeight == 0 {
+		misbehavior.BlockHeight = hdb.blockHeight
+	}
+	if misbehavior.Timestamp.IsZero() {
+		misbehavior.Timestamp = time.Now()
+	}
+
+	// Ignore evidence that was already recorded. This can happen if a block
+	// containing a missed storage proof is reverted and applied again.
+	for _, m := range host.Misbehavior {
+		if m.Type == misbehavior.Type && m.ContractID == misbehavior.ContractID && m.BlockHeight == misbehavior.BlockHeight {
+			return
+		}
+	}
+	host.Misbehavior = append(host.Misbehavior, misbehavior)
+	if len(host.Misbehavior) > maxHostMisbehaviors {
+		host.Misbehavior = host.Misbehavior[len(host.Misbehavior)-maxHostMisbehaviors:]
+	}
+	if err := hdb.modifyHost(host); err != nil {
+		hdb.log.Println("ERROR: unable to record misbehavior of host:", err)
+		return
+	}
+	hdb.trackMisbehavior(host)
+	hdb.log.Printf("Host %v misbehaved (%v) on contract %v: %v\n", key, misbehavior.Type, misbehavior.ContractID, misbehavior.Description)
+}
@@ -0,0 +1,99 @@
+package hostdb
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestRecordMisbehavior checks that hosts are excluded from host selection
+// after misbehaving and included again once the blacklist period is over.
+func TestRecordMisbehavior(t *testing.T) {
+	hdb := bareHostDB()
+	hdb.misbehaviorBlacklistPeriod = 10
+	hdb.blockHeight = 100
+	hdb.initialScanComplete = true
+
+	// Insert two hosts and let one of them misbehave.
+	good := makeHostDBEntry()
+	bad := makeHostDBEntry()
+	if err := hdb.hostTree.Insert(good); err != nil {
+		t.Fatal(err)
+	}
+	if err := hdb.hostTree.Insert(bad); err != nil {
+		t.Fatal(err)
+	}
+	misbehavior := modules.HostMisbehavior{
+		Type:        modules.HostMisbehaviorBadSectorData,
+		ContractID:  types.FileContractID{1},
+		Description: "host sent bad sector data",
+	}
+	hdb.RecordMisbehavior(bad.PublicKey, misbehavior)
+
+	// The evidence should be stored in the host's entry exactly once, even if
+	// it is recorded again.
+	hdb.RecordMisbehavior(bad.PublicKey, misbehavior)
+	entry, ok := hdb.Host(bad.PublicKey)
+	if !ok {
+		t.Fatal("host not found")
+	}
+	if len(entry.Misbehavior) != 1 {
+		t.Fatalf("expected 1 piece of evidence, got %v", len(entry.Misbehavior))
+	}
+	if entry.Misbehavior[0].BlockHeight != 100 || entry.Misbehavior[0].Timestamp.IsZero() {
+		t.Fatal("evidence was not timestamped:", entry.Misbehavior[0])
+	}
+
+	// The misbehaving host should be blacklisted.
+	if !hdb.Blacklisted(bad.PublicKey) || hdb.Blacklisted(good.PublicKey) {
+		t.Fatal("wrong host was blacklisted")
+	}
+	hosts, err := hdb.RandomHosts(2, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].PublicKey.String() != good.PublicKey.String() {
+		t.Fatal("misbehaving host was returned by RandomHosts")
+	}
+	if len(hdb.ActiveHosts()) != 1 {
+		t.Fatal("misbehaving host was returned by ActiveHosts")
+	}
+
+	// After the blacklist period, the host should be selectable again.
+	hdb.blockHeight += hdb.misbehaviorBlacklistPeriod
+	if hdb.Blacklisted(bad.PublicKey) {
+		t.Fatal("host is still blacklisted after the blacklist period")
+	}
+	hosts, err = hdb.RandomHosts(2, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 2 {
+		t.Fatal("expected both hosts to be returned, got", len(hosts))
+	}
+}
+
+// TestMisbehaviorEvidenceLimit checks that only the most recent evidence is
+// kept in a host's entry.
+func TestMisbehaviorEvidenceLimit(t *testing.T) {
+	hdb := bareHostDB()
+	host := makeHostDBEntry()
+	if err := hdb.hostTree.Insert(host); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxHostMisbehaviors+5; i++ {
+		hdb.RecordMisbehavior(host.PublicKey, modules.HostMisbehavior{
+			Type:        modules.HostMisbehaviorMissedStorageProof,
+			ContractID:  types.FileContractID{byte(i)},
+			BlockHeight: types.BlockHeight(i + 1),
+		})
+	}
+	entry, _ := hdb.Host(host.PublicKey)
+	if len(entry.Misbehavior) != maxHostMisbehaviors {
+		t.Fatalf("expected %v pieces of evidence, got %v", maxHostMisbehaviors, len(entry.Misbehavior))
+	}
+	if entry.Misbehavior[len(entry.Misbehavior)-1].BlockHeight != maxHostMisbehaviors+5 {
+		t.Fatal("most recent evidence was not kept")
+	}
+}
@@ -1,84 +1,275 @@
 package hostdb
 
 import (
+	"encoding/json"
+	"os"
 	"path/filepath"
 	"time"
 
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/persist"
 	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+
+	"github.com/coreos/bbolt"
 )
 
 var (
-	// persistFilename defines the name of the file that holds the hostdb's
+	// dbFilename defines the name of the database that holds the hostdb's
 	// persistence.
-	persistFilename = "hostdb.json"
+	dbFilename = "hostdb.db"
+
+	// dbMetadata defines the metadata of the hostdb database.
+	dbMetadata = persist.Metadata{
+		Header:  "HostDB Database",
+		Version: "1.3.7",
+	}
 
-	// persistMetadata defines the metadata that tags along with the most recent
-	// version of the hostdb persistence file.
-	persistMetadata = persist.Metadata{
+	// bucketHostDB contains the fields of the hostdb that are not related to
+	// a specific host.
+	bucketHostDB = []byte("HostDB")
+
+	// bucketHosts maps the public keys of the hosts to their entries. Entries
+	// are updated individually whenever they change, so the hostdb doesn't
+	// have to write every host on each save.
+	bucketHosts = []byte("Hosts")
+
+	// keyPersist is the key in bucketHostDB under which the hdbPersist
+	// object is stored.
+	keyPersist = []byte("Persist")
+)
+
+var (
+	// compatFilename defines the name of the file that held the hostdb's
+	// persistence before it was moved into a database.
+	compatFilename = "hostdb.json"
+
+	// compatMetadata defines the metadata of the old persistence file.
+	compatMetadata = persist.Metadata{
 		Header:  "HostDB Persistence",
 		Version: "0.5",
 	}
 )
 
-// hdbPersist defines what HostDB data persists across sessions.
+// hdbPersist defines what HostDB data persists across sessions, aside from
+// the host entries. MisbehaviorBlacklistPeriod is a pointer so that a period
+// of zero, which disables the blacklist, can be told apart from hostdbs that
+// didn't persist the period.
 type hdbPersist struct {
-	AllHosts                 []modules.HostDBEntry
-	BlockHeight              types.BlockHeight
-	DisableIPViolationsCheck bool
-	LastChange               modules.ConsensusChangeID
+	BlockHeight                types.BlockHeight
+	DisableIPViolationsCheck   bool
+	LastChange                 modules.ConsensusChangeID
+	MisbehaviorBlacklistPeriod *types.BlockHeight
+}
+
+// compat137Persist is the format of the persistence file that was used up to
+// v1.3.7, which contained all of the host entries.
+type compat137Persist struct {
+	AllHosts []modules.HostDBEntry
+	hdbPersist
 }
 
 // persistData returns the data in the hostdb that will be saved to disk.
 func (hdb *HostDB) persistData() (data hdbPersist) {
-	data.AllHosts = hdb.hostTree.All()
 	data.BlockHeight = hdb.blockHeight
 	data.DisableIPViolationsCheck = hdb.disableIPViolationCheck
 	data.LastChange = hdb.lastChange
+	period := hdb.misbehaviorBlacklistPeriod
+	data.MisbehaviorBlacklistPeriod = &period
 	return data
 }
 
-// saveSync saves the hostdb persistence data to disk and then syncs to disk.
+// markHostDirty marks the entry of a host as changed, so that it gets written
+// to the database during the next save.
+func (hdb *HostDB) markHostDirty(key types.SiaPublicKey) {
+	hdb.dirtyHosts[key.String()] = key
+}
+
+// insertHost inserts a host into the host tree and marks it as dirty.
+func (hdb *HostDB) insertHost(host modules.HostDBEntry) error {
+	if err := hdb.hostTree.Insert(host); err != nil {
+		return err
+	}
+	hdb.markHostDirty(host.PublicKey)
+	return nil
+}
+
+// modifyHost modifies a host in the host tree and marks it as dirty.
+func (hdb *HostDB) modifyHost(host modules.HostDBEntry) error {
+	if err := hdb.hostTree.Modify(host); err != nil {
+		return err
+	}
+	hdb.markHostDirty(host.PublicKey)
+	return nil
+}
+
+// removeHost removes a host from the host tree and marks it as dirty.
+func (hdb *HostDB) removeHost(key types.SiaPublicKey) error {
+	if err := hdb.hostTree.Remove(key); err != nil {
+		return err
+	}
+	hdb.markHostDirty(key)
+	return nil
+}
+
+// openDB opens the hostdb database and creates its buckets if necessary.
+func (hdb *HostDB) openDB() (err error) {
+	hdb.db, err = persist.OpenDatabase(dbMetadata, filepath.Join(hdb.persistDir, dbFilename))
+	if err != nil {
+		return err
+	}
+	err = hdb.db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{bucketHostDB, bucketHosts} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.Compose(err, hdb.db.Close())
+	}
+	return nil
+}
+
+// saveSync writes the hostdb persistence data and the entries of all hosts
+// that changed since the last save to the database. Committing the update
+// syncs the database to disk.
 func (hdb *HostDB) saveSync() error {
-	return hdb.deps.SaveFileSync(persistMetadata, hdb.persistData(), filepath.Join(hdb.persistDir, persistFilename))
+	err := hdb.db.Update(func(tx *bolt.Tx) error {
+		data, err := json.Marshal(hdb.persistData())
+		if err != nil {
+			return err
+		}
+		if err := tx.Bucket(bucketHostDB).Put(keyPersist, data); err != nil {
+			return err
+		}
+
+		hosts := tx.Bucket(bucketHosts)
+		for key, spk := range hdb.dirtyHosts {
+			host, exists := hdb.hostTree.Select(spk)
+			if !exists {
+				if err := hosts.Delete([]byte(key)); err != nil {
+					return err
+				}
+				continue
+			}
+			entry, err := json.Marshal(host)
+			if err != nil {
+				return err
+			}
+			if err := hosts.Put([]byte(key), entry); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	hdb.dirtyHosts = make(map[string]types.SiaPublicKey)
+	return nil
 }
 
-// load loads the hostdb persistence data from disk.
-func (hdb *HostDB) load() error {
-	// Fetch the data from the file.
-	var data hdbPersist
-	err := hdb.deps.LoadFile(persistMetadata, &data, filepath.Join(hdb.persistDir, persistFilename))
+// loadHost inserts a host that was loaded from disk into the host tree.
+func (hdb *HostDB) loadHost(host modules.HostDBEntry) error {
+	// COMPATv1.1.0
+	//
+	// The host did not always track its block height correctly, meaning
+	// that previously the FirstSeen values and the blockHeight values
+	// could get out of sync.
+	if hdb.blockHeight < host.FirstSeen {
+		host.FirstSeen = hdb.blockHeight
+	}
+	compressScanHistory(&host)
+
+	err := hdb.hostTree.Insert(host)
 	if err != nil {
 		return err
 	}
+	hdb.trackMisbehavior(host)
+
+	// Make sure that all hosts have gone through the initial scanning.
+	if len(host.ScanHistory) < 2 {
+		hdb.queueScan(host)
+	}
+	return nil
+}
 
-	// Set the hostdb internal values.
+// setPersistData sets the hostdb internal values from the persistence data.
+func (hdb *HostDB) setPersistData(data hdbPersist) {
 	hdb.blockHeight = data.BlockHeight
 	hdb.disableIPViolationCheck = data.DisableIPViolationsCheck
 	hdb.lastChange = data.LastChange
+	// COMPATv1.3.7
+	//
+	// Older hostdbs didn't persist the blacklist period, keep the default in
+	// that case.
+	if data.MisbehaviorBlacklistPeriod != nil {
+		hdb.misbehaviorBlacklistPeriod = *data.MisbehaviorBlacklistPeriod
+	}
+}
+
+// load loads the hostdb persistence data from the database. If the database
+// is empty, the hosts are imported from the old persistence file instead.
+func (hdb *HostDB) load() error {
+	var data []byte
+	err := hdb.db.View(func(tx *bolt.Tx) error {
+		data = tx.Bucket(bucketHostDB).Get(keyPersist)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if data == nil {
+		return hdb.convertPersistFrom137()
+	}
+
+	var persistData hdbPersist
+	if err := json.Unmarshal(data, &persistData); err != nil {
+		return err
+	}
+	hdb.setPersistData(persistData)
 
 	// Load each of the hosts into the host tree.
-	for _, host := range data.AllHosts {
-		// COMPATv1.1.0
-		//
-		// The host did not always track its block height correctly, meaning
-		// that previously the FirstSeen values and the blockHeight values
-		// could get out of sync.
-		if hdb.blockHeight < host.FirstSeen {
-			host.FirstSeen = hdb.blockHeight
-		}
+	return hdb.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(bucketHosts).ForEach(func(key, entry []byte) error {
+			var host modules.HostDBEntry
+			if err := json.Unmarshal(entry, &host); err != nil {
+				hdb.log.Println("ERROR: could not decode host while loading:", string(key), err)
+				return nil
+			}
+			if err := hdb.loadHost(host); err != nil {
+				hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
+			}
+			return nil
+		})
+	})
+}
 
-		err := hdb.hostTree.Insert(host)
-		if err != nil {
-			hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
-		}
+// convertPersistFrom137 imports the hosts from an old (pre-v1.3.8) hostdb.json
+// file into the database. The old file is kept as a backup.
+func (hdb *HostDB) convertPersistFrom137() error {
+	var data compat137Persist
+	err := hdb.deps.LoadFile(compatMetadata, &data, filepath.Join(hdb.persistDir, compatFilename))
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	hdb.setPersistData(data.hdbPersist)
 
-		// Make sure that all hosts have gone through the initial scanning.
-		if len(host.ScanHistory) < 2 {
-			hdb.queueScan(host)
+	for _, host := range data.AllHosts {
+		if err := hdb.loadHost(host); err != nil {
+			hdb.log.Debugln("ERROR: could not insert host while loading:", host.NetAddress)
+			continue
 		}
+		hdb.markHostDirty(host.PublicKey)
+	}
+	if err := hdb.saveSync(); err != nil {
+		return errors.AddContext(err, "unable to save imported hosts")
 	}
+	hdb.log.Printf("Imported %v hosts from %v", len(data.AllHosts), compatFilename)
 	return nil
 }
 
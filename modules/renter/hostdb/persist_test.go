@@ -1,10 +1,12 @@
 package hostdb
 
 import (
+	"os"
 	"path/filepath"
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/persist"
 )
 
 // quitAfterLoadDeps will quit startup in newHostDB
@@ -54,14 +56,15 @@ func TestSaveLoad(t *testing.T) {
 	host1.PublicKey.Key = []byte("foo")
 	host2.PublicKey.Key = []byte("bar")
 	host3.PublicKey.Key = []byte("baz")
-	hdbt.hdb.hostTree.Insert(host1)
-	hdbt.hdb.hostTree.Insert(host2)
-	hdbt.hdb.hostTree.Insert(host3)
 
 	// Save, close, and reload.
 	hdbt.hdb.mu.Lock()
+	hdbt.hdb.insertHost(host1)
+	hdbt.hdb.insertHost(host2)
+	hdbt.hdb.insertHost(host3)
 	hdbt.hdb.lastChange = modules.ConsensusChangeID{1, 2, 3}
 	hdbt.hdb.disableIPViolationCheck = true
+	hdbt.hdb.misbehaviorBlacklistPeriod = 0
 	stashedLC := hdbt.hdb.lastChange
 	err = hdbt.hdb.saveSync()
 	hdbt.hdb.mu.Unlock()
@@ -88,6 +91,20 @@ func TestSaveLoad(t *testing.T) {
 	if disableIPViolationCheck != true {
 		t.Error("disableIPViolationCheck should've been true but was false")
 	}
+	// A blacklist period of zero disables the blacklist and should survive a
+	// restart, while hostdbs that didn't persist the period use the default.
+	hdbt.hdb.mu.Lock()
+	period := hdbt.hdb.misbehaviorBlacklistPeriod
+	hdbt.hdb.misbehaviorBlacklistPeriod = defaultMisbehaviorBlacklistPeriod
+	hdbt.hdb.setPersistData(hdbPersist{})
+	defaultPeriod := hdbt.hdb.misbehaviorBlacklistPeriod
+	hdbt.hdb.mu.Unlock()
+	if period != 0 {
+		t.Error("misbehaviorBlacklistPeriod should've been 0 but was", period)
+	}
+	if defaultPeriod != defaultMisbehaviorBlacklistPeriod {
+		t.Error("misbehaviorBlacklistPeriod should've been the default but was", defaultPeriod)
+	}
 
 	// Check that AllHosts was loaded.
 	h1, ok0 := hdbt.hdb.hostTree.Select(host1.PublicKey)
@@ -107,6 +124,120 @@ func TestSaveLoad(t *testing.T) {
 	}
 }
 
+// TestSaveLoadIncremental tests that modified and removed hosts are persisted
+// by the hostdb.
+func TestSaveLoadIncremental(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Add two hosts and save.
+	host1 := makeHostDBEntry()
+	host2 := makeHostDBEntry()
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.insertHost(host1)
+	hdbt.hdb.insertHost(host2)
+	err = hdbt.hdb.saveSync()
+	hdbt.hdb.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modify one host and remove the other one.
+	host1.NetAddress = "foo.com:1234"
+	hdbt.hdb.mu.Lock()
+	hdbt.hdb.modifyHost(host1)
+	hdbt.hdb.removeHost(host2.PublicKey)
+	err = hdbt.hdb.saveSync()
+	hdbt.hdb.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hdbt.hdb.dirtyHosts) != 0 {
+		t.Fatal("dirty hosts weren't cleared by the save")
+	}
+
+	// Close and reload.
+	err = hdbt.hdb.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hdbt.hdb, err = NewCustomHostDB(hdbt.gateway, hdbt.cs, filepath.Join(hdbt.persistDir, modules.RenterDir), &quitAfterLoadDeps{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	if h, ok := hdbt.hdb.hostTree.Select(host1.PublicKey); !ok || h.NetAddress != host1.NetAddress {
+		t.Error("modified host was not persisted")
+	}
+	if _, ok := hdbt.hdb.hostTree.Select(host2.PublicKey); ok {
+		t.Error("removed host was loaded")
+	}
+}
+
+// TestConvertPersistFrom137 tests that the hostdb imports the hosts from the
+// old persistence file.
+func TestConvertPersistFrom137(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	t.Parallel()
+	hdbt, err := newHDBTester(t.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer hdbt.hdb.Close()
+
+	// Create an old persistence file in a new directory.
+	host1 := makeHostDBEntry()
+	host2 := makeHostDBEntry()
+	data := compat137Persist{
+		AllHosts: []modules.HostDBEntry{host1, host2},
+		hdbPersist: hdbPersist{
+			BlockHeight:              5,
+			DisableIPViolationsCheck: true,
+		},
+	}
+	dir := filepath.Join(hdbt.persistDir, "compat")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := persist.SaveJSON(compatMetadata, data, filepath.Join(dir, compatFilename)); err != nil {
+		t.Fatal(err)
+	}
+
+	// Load the hostdb from the directory twice. The second time, the hosts
+	// should be loaded from the database.
+	for i := 0; i < 2; i++ {
+		hdb, err := NewCustomHostDB(hdbt.gateway, hdbt.cs, dir, &quitAfterLoadDeps{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdb.blockHeight != 5 || !hdb.disableIPViolationCheck {
+			t.Error("hostdb fields were not imported")
+		}
+		if len(hdb.hostTree.All()) != 2 {
+			t.Error("expected 2 hosts, got", len(hdb.hostTree.All()))
+		}
+		if _, ok := hdb.hostTree.Select(host1.PublicKey); !ok {
+			t.Error("host was not imported")
+		}
+		if err := hdb.Close(); err != nil {
+			t.Fatal(err)
+		}
+		// Remove the old file to be sure that it isn't used again.
+		if err := os.Remove(filepath.Join(dir, compatFilename)); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+}
+
 // TestRescan tests that the hostdb will rescan the blockchain properly, picking
 // up new hosts which appear in an alternate past.
 func TestRescan(t *testing.T) {
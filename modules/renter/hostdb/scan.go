@@ -158,6 +158,46 @@ func (hdb *HostDB) queueScan(entry modules.HostDBEntry) {
 	}()
 }
 
+// compressHistoricScan compresses the oldest scan of the host's scan history
+// into the historic uptime or downtime.
+func compressHistoricScan(entry *modules.HostDBEntry) {
+	timePassed := entry.ScanHistory[1].Timestamp.Sub(entry.ScanHistory[0].Timestamp)
+	if entry.ScanHistory[0].Success {
+		entry.HistoricUptime += timePassed
+	} else {
+		entry.HistoricDowntime += timePassed
+	}
+	entry.ScanHistory = entry.ScanHistory[1:]
+}
+
+// compressScanHistory bounds the size of the host's scan history. Scans that
+// are older than maxHostDowntime are compressed into the historic uptime and
+// downtime. Of the remaining scans, all but the most recent minScans are
+// merged with their predecessor if they have the same result, which doesn't
+// change the uptime of the host because a scan accounts for the time until
+// the next scan. If the history is still longer than maxScanHistory, the
+// oldest scans are compressed into the historic values as well.
+func compressScanHistory(entry *modules.HostDBEntry) {
+	for len(entry.ScanHistory) > minScans && time.Now().Sub(entry.ScanHistory[0].Timestamp) > maxHostDowntime {
+		compressHistoricScan(entry)
+	}
+
+	if len(entry.ScanHistory) > minScans+1 {
+		old := entry.ScanHistory[:len(entry.ScanHistory)-minScans]
+		merged := modules.HostDBScans{old[0]}
+		for _, scan := range old[1:] {
+			if scan.Success != merged[len(merged)-1].Success {
+				merged = append(merged, scan)
+			}
+		}
+		entry.ScanHistory = append(merged, entry.ScanHistory[len(old):]...)
+	}
+
+	for len(entry.ScanHistory) > maxScanHistory {
+		compressHistoricScan(entry)
+	}
+}
+
 // updateEntry updates an entry in the hostdb after a scan has taken place.
 //
 // CAUTION: This function will automatically add multiple entries to a new host
@@ -235,7 +275,7 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 	// hostdb. Only delete if there have been enough scans over a long enough
 	// period to be confident that the host really is offline for good.
 	if time.Now().Sub(newEntry.ScanHistory[0].Timestamp) > maxHostDowntime && !recentUptime && len(newEntry.ScanHistory) >= minScans {
-		err := hdb.hostTree.Remove(newEntry.PublicKey)
+		err := hdb.removeHost(newEntry.PublicKey)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to remove host newEntry which has had a ton of downtime:", err)
 		}
@@ -245,27 +285,19 @@ func (hdb *HostDB) updateEntry(entry modules.HostDBEntry, netErr error) {
 		return
 	}
 
-	// Compress any old scans into the historic values.
-	for len(newEntry.ScanHistory) > minScans && time.Now().Sub(newEntry.ScanHistory[0].Timestamp) > maxHostDowntime {
-		timePassed := newEntry.ScanHistory[1].Timestamp.Sub(newEntry.ScanHistory[0].Timestamp)
-		if newEntry.ScanHistory[0].Success {
-			newEntry.HistoricUptime += timePassed
-		} else {
-			newEntry.HistoricDowntime += timePassed
-		}
-		newEntry.ScanHistory = newEntry.ScanHistory[1:]
-	}
+	// Compress the scan history into a bounded summary.
+	compressScanHistory(&newEntry)
 
 	// Add the updated entry
 	if !exists {
-		err := hdb.hostTree.Insert(newEntry)
+		err := hdb.insertHost(newEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to insert entry which is was thought to be new:", err)
 		} else {
 			hdb.log.Debugf("Adding host %v to the hostdb. Net error: %v\n", newEntry.PublicKey.String(), netErr)
 		}
 	} else {
-		err := hdb.hostTree.Modify(newEntry)
+		err := hdb.modifyHost(newEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to modify entry which is thought to exist:", err)
 		} else {
@@ -309,17 +341,13 @@ func (hdb *HostDB) managedLookupIPNets(address modules.NetAddress) (ipNets []str
 // managedScanHost will connect to a host and grab the settings, verifying
 // uptime and updating to the host's preferences.
 func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
-	// Request settings from the queued host entry.
-	netAddr := entry.NetAddress
+	// Request settings from the queued host entry. The addresses of the host
+	// are tried in order of priority.
+	hdb.mu.RLock()
+	netAddrs := entry.DialAddresses(hdb.blockHeight)
+	hdb.mu.RUnlock()
 	pubKey := entry.PublicKey
-	hdb.log.Debugf("Scanning host %v at %v", pubKey, netAddr)
-
-	// If we use a custom resolver for testing, we replace the custom domain
-	// with 127.0.0.1. Otherwise the scan will fail.
-	if hdb.deps.Disrupt("customResolver") {
-		port := netAddr.Port()
-		netAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", port))
-	}
+	hdb.log.Debugf("Scanning host %v at %v", pubKey, netAddrs)
 
 	// Resolve the host's used subnets and update the timestamp if they
 	// changed. We only update the timestamp if resolving the ipNets was
@@ -340,6 +368,7 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 
 	var settings modules.HostExternalSettings
 	var latency time.Duration
+	var workingAddr modules.NetAddress
 	err = func() error {
 		timeout := hostRequestTimeout
 		hdb.mu.RLock()
@@ -363,9 +392,24 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 			Cancel:  hdb.tg.StopChan(),
 			Timeout: timeout,
 		}
-		start := time.Now()
-		conn, err := dialer.Dial("tcp", string(netAddr))
-		latency = time.Since(start)
+		var conn net.Conn
+		var err error
+		for _, netAddr := range netAddrs {
+			// If we use a custom resolver for testing, we replace the custom
+			// domain with 127.0.0.1. Otherwise the scan will fail.
+			dialAddr := netAddr
+			if hdb.deps.Disrupt("customResolver") {
+				dialAddr = modules.NetAddress(fmt.Sprintf("127.0.0.1:%s", netAddr.Port()))
+			}
+			start := time.Now()
+			conn, err = dialer.Dial("tcp", string(dialAddr))
+			latency = time.Since(start)
+			if err == nil {
+				workingAddr = netAddr
+				break
+			}
+			hdb.log.Debugf("Unable to reach host %v at %v: %v", pubKey, netAddr, err)
+		}
 		if err != nil {
 			return err
 		}
@@ -389,11 +433,12 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 		return crypto.ReadSignedObject(conn, &settings, maxSettingsLen, pubkey)
 	}()
 	if err != nil {
-		hdb.log.Debugf("Scan of host at %v failed: %v", netAddr, err)
+		hdb.log.Debugf("Scan of host at %v failed: %v", netAddrs, err)
 
 	} else {
-		hdb.log.Debugf("Scan of host at %v succeeded.", netAddr)
+		hdb.log.Debugf("Scan of host at %v succeeded.", workingAddr)
 		entry.HostExternalSettings = settings
+		entry.WorkingAddress = workingAddr
 	}
 	success := err == nil
 
@@ -404,6 +449,8 @@ func (hdb *HostDB) managedScanHost(entry modules.HostDBEntry) {
 	oldEntry, exists := hdb.hostTree.Select(entry.PublicKey)
 	if exists {
 		entry.NetAddress = oldEntry.NetAddress
+		entry.NetAddresses = oldEntry.NetAddresses
+		entry.AnnouncementExpiry = oldEntry.AnnouncementExpiry
 	}
 	// Update the host tree to have a new entry, including the new error. Then
 	// delete the entry from the scan map as the scan has been successful.
@@ -182,3 +182,71 @@ func TestUpdateEntry(t *testing.T) {
 		t.Error("host not reporting historic uptime?")
 	}
 }
+
+// TestCompressScanHistory checks that the scan history of a host is bounded
+// without changing the uptime of the host.
+func TestCompressScanHistory(t *testing.T) {
+	// Create a scan history of alternating runs of successful and failed
+	// scans, one hour apart.
+	var entry modules.HostDBEntry
+	start := time.Now().Add(-time.Hour * 200)
+	for i := 0; i < 200; i++ {
+		entry.ScanHistory = append(entry.ScanHistory, modules.HostDBScan{
+			Timestamp: start.Add(time.Hour * time.Duration(i)),
+			Success:   (i/10)%2 == 0,
+		})
+	}
+	uptime := func(entry modules.HostDBEntry) (up, down time.Duration) {
+		up, down = entry.HistoricUptime, entry.HistoricDowntime
+		for i := 1; i < len(entry.ScanHistory); i++ {
+			timePassed := entry.ScanHistory[i].Timestamp.Sub(entry.ScanHistory[i-1].Timestamp)
+			if entry.ScanHistory[i-1].Success {
+				up += timePassed
+			} else {
+				down += timePassed
+			}
+		}
+		return up, down
+	}
+	up, down := uptime(entry)
+	recent := append(modules.HostDBScans(nil), entry.ScanHistory[len(entry.ScanHistory)-minScans:]...)
+
+	// Runs of identical results should be merged, which doesn't change the
+	// uptime or downtime.
+	compressScanHistory(&entry)
+	if len(entry.ScanHistory) > maxScanHistory {
+		t.Fatal("scan history is not bounded:", len(entry.ScanHistory))
+	}
+	if newUp, newDown := uptime(entry); newUp != up || newDown != down {
+		t.Fatal("compression changed the uptime", up, down, newUp, newDown)
+	}
+	if !entry.ScanHistory[0].Timestamp.Equal(start) {
+		t.Fatal("oldest scan should have been kept")
+	}
+	for i, scan := range entry.ScanHistory[len(entry.ScanHistory)-minScans:] {
+		if scan != recent[i] {
+			t.Fatal("recent scans should not have been merged")
+		}
+	}
+
+	// A history that can't be merged should be compressed into the historic
+	// values.
+	entry = modules.HostDBEntry{}
+	for i := 0; i < 2*maxScanHistory; i++ {
+		entry.ScanHistory = append(entry.ScanHistory, modules.HostDBScan{
+			Timestamp: start.Add(time.Hour * time.Duration(i)),
+			Success:   i%2 == 0,
+		})
+	}
+	up, down = uptime(entry)
+	compressScanHistory(&entry)
+	if len(entry.ScanHistory) != maxScanHistory {
+		t.Fatal("scan history is not bounded:", len(entry.ScanHistory))
+	}
+	if newUp, newDown := uptime(entry); newUp != up || newDown != down {
+		t.Fatal("compression changed the uptime", up, down, newUp, newDown)
+	}
+	if entry.HistoricUptime == 0 || entry.HistoricDowntime == 0 {
+		t.Fatal("old scans were not compressed into the historic values")
+	}
+}
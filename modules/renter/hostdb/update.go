@@ -16,15 +16,19 @@ func findHostAnnouncements(b types.Block) (announcements []modules.HostDBEntry)
 		// the HostAnnouncement must be prefaced by the standard host
 		// announcement string
 		for _, arb := range t.ArbitraryData {
-			addr, pubKey, err := modules.DecodeAnnouncement(arb)
+			addrs, expiry, pubKey, err := modules.DecodeVersionedAnnouncement(arb)
 			if err != nil {
 				continue
 			}
 
 			// Add the announcement to the slice being returned.
 			var host modules.HostDBEntry
-			host.NetAddress = addr
+			host.NetAddress = addrs[0]
 			host.PublicKey = pubKey
+			if len(addrs) > 1 {
+				host.NetAddresses = addrs
+				host.AnnouncementExpiry = expiry
+			}
 			announcements = append(announcements, host)
 		}
 	}
@@ -44,6 +48,21 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 	if build.Release == "standard" && host.NetAddress.IsLocal() {
 		return
 	}
+	// Drop the alternate addresses that are garbage or local.
+	var addrs []modules.NetAddress
+	for _, addr := range host.NetAddresses {
+		if addr.IsValid() != nil || (build.Release == "standard" && addr.IsLocal()) {
+			hdb.log.Debugf("WARN: host '%v' announced an invalid alternate address '%v'", host.NetAddress, addr)
+			continue
+		}
+		addrs = append(addrs, addr)
+	}
+	if len(addrs) > 1 {
+		host.NetAddresses = addrs
+	} else {
+		host.NetAddresses = nil
+		host.AnnouncementExpiry = 0
+	}
 
 	// Make sure the host gets into the host tree so it does not get dropped if
 	// shutdown occurs before a scan can be performed.
@@ -55,6 +74,8 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 		// first seen height of zero, but due to rescans hosts can end up with
 		// a zero-value FirstSeen field.
 		oldEntry.NetAddress = host.NetAddress
+		oldEntry.NetAddresses = host.NetAddresses
+		oldEntry.AnnouncementExpiry = host.AnnouncementExpiry
 		if oldEntry.FirstSeen == 0 {
 			oldEntry.FirstSeen = hdb.blockHeight
 		}
@@ -66,13 +87,13 @@ func (hdb *HostDB) insertBlockchainHost(host modules.HostDBEntry) {
 			oldEntry.IPNets = ipNets
 			oldEntry.LastIPNetChange = time.Now()
 		}
-		err = hdb.hostTree.Modify(oldEntry)
+		err = hdb.modifyHost(oldEntry)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to modify host entry of host tree after a blockchain scan:", err)
 		}
 	} else {
 		host.FirstSeen = hdb.blockHeight
-		err := hdb.hostTree.Insert(host)
+		err := hdb.insertHost(host)
 		if err != nil {
 			hdb.log.Println("ERROR: unable to insert host entry into host tree after a blockchain scan:", err)
 		}
@@ -52,3 +52,36 @@ func TestFindHostAnnouncements(t *testing.T) {
 		t.Error("host announcement found when there was an invalid encoding of a host announcement")
 	}
 }
+
+// TestFindVersionedHostAnnouncements checks that the alternate addresses of a
+// versioned announcement are found, and that they are only dialed until they
+// expire.
+func TestFindVersionedHostAnnouncements(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	addrs := []modules.NetAddress{"1.2.3.4:1234", "[2001:db8::1]:1234", "foo.com:1234"}
+	annBytes, err := modules.CreateVersionedAnnouncement(addrs, 10, types.Ed25519PublicKey(pk), sk)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := types.Block{
+		Transactions: []types.Transaction{
+			{
+				ArbitraryData: [][]byte{annBytes},
+			},
+		},
+	}
+	announcements := findHostAnnouncements(b)
+	if len(announcements) != 1 {
+		t.Fatal("host announcement not found in block")
+	}
+	host := announcements[0]
+	if host.NetAddress != addrs[0] || len(host.NetAddresses) != len(addrs) || host.AnnouncementExpiry != 10 {
+		t.Fatal("versioned announcement was not decoded:", host.NetAddress, host.NetAddresses)
+	}
+	if dial := host.DialAddresses(9); len(dial) != len(addrs) || dial[2] != addrs[2] {
+		t.Fatal("wrong dial addresses before expiry:", dial)
+	}
+	if dial := host.DialAddresses(10); len(dial) != 1 || dial[0] != addrs[0] {
+		t.Fatal("wrong dial addresses after expiry:", dial)
+	}
+}
@@ -1,6 +1,7 @@
 package proto
 
 import (
+	"fmt"
 	"net"
 	"sync"
 	"time"
@@ -13,9 +14,14 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// errInsufficientDownloadFunds is returned if a contract can't pay for the
+// download of a sector.
+var errInsufficientDownloadFunds = errors.New("contract has insufficient funds to support download")
+
 // A Downloader retrieves sectors by calling the download RPC on a host.
 // Downloaders are NOT thread- safe; calls to Sector must be serialized.
 type Downloader struct {
+	address     modules.NetAddress
 	closeChan   chan struct{}
 	conn        net.Conn
 	contractID  types.FileContractID
@@ -47,7 +53,25 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	// calculate price
 	sectorPrice := hd.host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
 	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, nil, errors.New("contract has insufficient funds to support download")
+		return modules.RenterContract{}, nil, errInsufficientDownloadFunds
+	}
+
+	// initiate download by confirming host settings. The download is paid at
+	// the price that the host sends, which can differ from the price in the
+	// hostdb, e.g. if the host operator has set a policy for the renter, but
+	// verifySettings rejects prices that no policy allows.
+	extendDeadline(hd.conn, modules.NegotiateSettingsTime)
+	host, err := verifySettings(hd.conn, hd.host)
+	if err != nil {
+		return modules.RenterContract{}, nil, err
+	}
+	sectorPrice = host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
+	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
+		modules.WriteNegotiationRejection(hd.conn, errInsufficientDownloadFunds) // error ignored to preserve type
+		return modules.RenterContract{}, nil, errInsufficientDownloadFunds
+	}
+	if err := modules.WriteNegotiationAcceptance(hd.conn); err != nil {
+		return modules.RenterContract{}, nil, err
 	}
 	// To mitigate small errors (e.g. differing block heights), fudge the
 	// price and collateral by 0.2%.
@@ -56,12 +80,6 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	// create the download revision
 	rev := newDownloadRevision(contract.LastRevision(), sectorPrice)
 
-	// initiate download by confirming host settings
-	extendDeadline(hd.conn, modules.NegotiateSettingsTime)
-	if err := startDownload(hd.conn, hd.host); err != nil {
-		return modules.RenterContract{}, nil, err
-	}
-
 	// record the change we are about to make to the contract. If we lose power
 	// mid-revision, this allows us to restore either the pre-revision or
 	// post-revision contract.
@@ -85,6 +103,7 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	defer func() {
 		if err != nil {
 			hd.hdb.IncrementFailedInteractions(contract.HostPublicKey())
+			recordMisbehavior(hd.hdb, contract.HostPublicKey(), hd.contractID, err)
 			err = errors.Extend(err, modules.ErrHostFault)
 		} else if err == nil {
 			hd.hdb.IncrementSuccessfulInteractions(contract.HostPublicKey())
@@ -126,8 +145,9 @@ func (hd *Downloader) Sector(root crypto.Hash) (_ modules.RenterContract, _ []by
 	sector := sectors[0]
 	if uint64(len(sector)) != modules.SectorSize {
 		return modules.RenterContract{}, nil, errors.New("host did not send enough sector data")
-	} else if crypto.MerkleRoot(sector) != root {
-		return modules.RenterContract{}, nil, errors.New("host sent bad sector data")
+	} else if sectorRoot := crypto.MerkleRoot(sector); sectorRoot != root {
+		return modules.RenterContract{}, nil, &hostMisbehaviorError{modules.HostMisbehaviorBadSectorData,
+			fmt.Errorf("host sent bad sector data: requested sector %v but received %v", root, sectorRoot)}
 	}
 
 	// update contract and metrics
@@ -148,6 +168,11 @@ func (hd *Downloader) shutdown() {
 	close(hd.closeChan)
 }
 
+// Address returns the address at which the host was reached.
+func (hd *Downloader) Address() modules.NetAddress {
+	return hd.address
+}
+
 // Close cleanly terminates the download loop with the host and closes the
 // connection.
 func (hd *Downloader) Close() error {
@@ -169,7 +194,7 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 	// check that contract has enough value to support a download
 	sectorPrice := host.DownloadBandwidthPrice.Mul64(modules.SectorSize)
 	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return nil, errors.New("contract has insufficient funds to support download")
+		return nil, errInsufficientDownloadFunds
 	}
 
 	// Increase Successful/Failed interactions accordingly
@@ -183,10 +208,12 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCDownload, cancel, cs.rl)
+	conn, closeChan, addr, err := initiateRevisionLoop(host, currentHeight, sc, modules.RPCDownload, cancel, cs.rl)
 	if err != nil {
+		recordMisbehavior(hdb, contract.HostPublicKey(), id, err)
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
+	hdb.UpdateWorkingAddress(contract.HostPublicKey(), addr)
 	// if we succeeded, we can safely discard the unappliedTxns
 	for _, txn := range sc.unappliedTxns {
 		txn.SignalUpdatesApplied()
@@ -195,6 +222,7 @@ func (cs *ContractSet) NewDownloader(host modules.HostDBEntry, id types.FileCont
 
 	// the host is now ready to accept revisions
 	return &Downloader{
+		address:     addr,
 		contractID:  id,
 		contractSet: cs,
 		host:        host,
@@ -27,6 +27,7 @@ func cachedMerkleRoot(roots []crypto.Hash) crypto.Hash {
 // A Editor modifies a Contract by calling the revise RPC on a host. It
 // Editors are NOT thread-safe; calls to Upload must happen in serial.
 type Editor struct {
+	address     modules.NetAddress
 	contractID  types.FileContractID
 	contractSet *ContractSet
 	conn        net.Conn
@@ -49,6 +50,11 @@ func (he *Editor) shutdown() {
 	close(he.closeChan)
 }
 
+// Address returns the address at which the host was reached.
+func (he *Editor) Address() modules.NetAddress {
+	return he.address
+}
+
 // Close cleanly terminates the revision loop with the host and closes the
 // connection.
 func (he *Editor) Close() error {
@@ -57,6 +63,38 @@ func (he *Editor) Close() error {
 	return he.conn.Close()
 }
 
+// uploadPrices returns the storage and bandwidth price of a sector that is
+// uploaded to the host, and the collateral that the host adds for it.
+func (he *Editor) uploadPrices(host modules.HostDBEntry, contract contractHeader) (storage, bandwidth, collateral types.Currency) {
+	// TODO: height is never updated, so we'll wind up overpaying on long-running uploads
+	blockBytes := types.NewCurrency64(modules.SectorSize * uint64(contract.LastRevision().NewWindowEnd-he.height))
+	storage = host.StoragePrice.Mul(blockBytes)
+	bandwidth = host.UploadBandwidthPrice.Mul64(modules.SectorSize)
+	collateral = host.Collateral.Mul(blockBytes)
+
+	// to mitigate small errors (e.g. differing block heights), fudge the
+	// price and collateral by 0.2%. This is only applied to hosts above
+	// v1.0.1; older hosts use stricter math.
+	if build.VersionCmp(host.Version, "1.0.1") > 0 {
+		storage = storage.MulFloat(1 + hostPriceLeeway)
+		bandwidth = bandwidth.MulFloat(1 + hostPriceLeeway)
+		collateral = collateral.MulFloat(1 - hostPriceLeeway)
+	}
+	return storage, bandwidth, collateral
+}
+
+// checkUploadFunds checks that the contract can pay for a sector and that the
+// host has enough collateral left in the contract to cover it.
+func checkUploadFunds(contract contractHeader, price, collateral types.Currency) error {
+	if contract.RenterFunds().Cmp(price) < 0 {
+		return errors.New("contract has insufficient funds to support upload")
+	}
+	if contract.LastRevision().NewMissedProofOutputs[1].Value.Cmp(collateral) < 0 {
+		return errors.New("contract has insufficient collateral to support upload")
+	}
+	return nil
+}
+
 // Upload negotiates a revision that adds a sector to a file contract.
 func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash, err error) {
 	// Acquire the contract.
@@ -68,46 +106,28 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 	contract := sc.header // for convenience
 
 	// calculate price
-	// TODO: height is never updated, so we'll wind up overpaying on long-running uploads
-	blockBytes := types.NewCurrency64(modules.SectorSize * uint64(contract.LastRevision().NewWindowEnd-he.height))
-	sectorStoragePrice := he.host.StoragePrice.Mul(blockBytes)
-	sectorBandwidthPrice := he.host.UploadBandwidthPrice.Mul64(modules.SectorSize)
-	sectorCollateral := he.host.Collateral.Mul(blockBytes)
-
-	// to mitigate small errors (e.g. differing block heights), fudge the
-	// price and collateral by 0.2%. This is only applied to hosts above
-	// v1.0.1; older hosts use stricter math.
-	if build.VersionCmp(he.host.Version, "1.0.1") > 0 {
-		sectorStoragePrice = sectorStoragePrice.MulFloat(1 + hostPriceLeeway)
-		sectorBandwidthPrice = sectorBandwidthPrice.MulFloat(1 + hostPriceLeeway)
-		sectorCollateral = sectorCollateral.MulFloat(1 - hostPriceLeeway)
-	}
-
-	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
-	if contract.RenterFunds().Cmp(sectorPrice) < 0 {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has insufficient funds to support upload")
-	}
-	if contract.LastRevision().NewMissedProofOutputs[1].Value.Cmp(sectorCollateral) < 0 {
-		return modules.RenterContract{}, crypto.Hash{}, errors.New("contract has insufficient collateral to support upload")
+	sectorStoragePrice, sectorBandwidthPrice, sectorCollateral := he.uploadPrices(he.host, contract)
+	if err := checkUploadFunds(contract, sectorStoragePrice.Add(sectorBandwidthPrice), sectorCollateral); err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
 	}
 
 	// calculate the new Merkle root
 	sectorRoot := crypto.MerkleRoot(data)
 	merkleRoot := sc.merkleRoots.checkNewRoot(sectorRoot)
 
-	// create the action and revision
+	// create the action
 	actions := []modules.RevisionAction{{
 		Type:        modules.ActionInsert,
 		SectorIndex: uint64(sc.merkleRoots.len()),
 		Data:        data,
 	}}
-	rev := newUploadRevision(contract.LastRevision(), merkleRoot, sectorPrice, sectorCollateral)
 
 	// run the revision iteration
 	defer func() {
 		// Increase Successful/Failed interactions accordingly
 		if err != nil {
 			he.hdb.IncrementFailedInteractions(he.host.PublicKey)
+			recordMisbehavior(he.hdb, he.host.PublicKey, he.contractID, err)
 			err = errors.Extend(err, modules.ErrHostFault)
 		} else {
 			he.hdb.IncrementSuccessfulInteractions(he.host.PublicKey)
@@ -117,11 +137,25 @@ func (he *Editor) Upload(data []byte) (_ modules.RenterContract, _ crypto.Hash,
 		extendDeadline(he.conn, time.Hour)
 	}()
 
-	// initiate revision
+	// initiate revision. The upload is paid at the prices that the host
+	// sends, which can differ from the prices in the hostdb, e.g. if the host
+	// operator has set a policy for the renter, but verifySettings rejects
+	// prices that no policy allows.
 	extendDeadline(he.conn, modules.NegotiateSettingsTime)
-	if err := startRevision(he.conn, he.host); err != nil {
+	host, err := verifySettings(he.conn, he.host)
+	if err != nil {
 		return modules.RenterContract{}, crypto.Hash{}, err
 	}
+	sectorStoragePrice, sectorBandwidthPrice, sectorCollateral = he.uploadPrices(host, contract)
+	sectorPrice := sectorStoragePrice.Add(sectorBandwidthPrice)
+	if err := checkUploadFunds(contract, sectorPrice, sectorCollateral); err != nil {
+		modules.WriteNegotiationRejection(he.conn, err) // error ignored to preserve type
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	if err := modules.WriteNegotiationAcceptance(he.conn); err != nil {
+		return modules.RenterContract{}, crypto.Hash{}, err
+	}
+	rev := newUploadRevision(contract.LastRevision(), merkleRoot, sectorPrice, sectorCollateral)
 
 	// record the change we are about to make to the contract. If we lose power
 	// mid-revision, this allows us to restore either the pre-revision or
@@ -190,10 +224,12 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 		}
 	}()
 
-	conn, closeChan, err := initiateRevisionLoop(host, sc, modules.RPCReviseContract, cancel, cs.rl)
+	conn, closeChan, addr, err := initiateRevisionLoop(host, currentHeight, sc, modules.RPCReviseContract, cancel, cs.rl)
 	if err != nil {
+		recordMisbehavior(hdb, contract.HostPublicKey(), id, err)
 		return nil, errors.AddContext(err, "failed to initiate revision loop")
 	}
+	hdb.UpdateWorkingAddress(contract.HostPublicKey(), addr)
 	// if we succeeded, we can safely discard the unappliedTxns
 	for _, txn := range sc.unappliedTxns {
 		txn.SignalUpdatesApplied()
@@ -202,6 +238,7 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 
 	// the host is now ready to accept revisions
 	return &Editor{
+		address:     addr,
 		host:        host,
 		hdb:         hdb,
 		contractID:  id,
@@ -214,15 +251,28 @@ func (cs *ContractSet) NewEditor(host modules.HostDBEntry, id types.FileContract
 	}, nil
 }
 
+// dialHost tries the addresses of the host in order of priority, returning a
+// connection and the address at which the host was reached.
+func dialHost(host modules.HostDBEntry, height types.BlockHeight, dialer *net.Dialer) (conn net.Conn, addr modules.NetAddress, err error) {
+	for _, addr = range host.DialAddresses(height) {
+		conn, err = dialer.Dial("tcp", string(addr))
+		if err == nil {
+			return conn, addr, nil
+		}
+	}
+	return nil, "", err
+}
+
 // initiateRevisionLoop initiates either the editor or downloader loop with
-// host, depending on which rpc was passed.
-func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}, rl *ratelimit.RateLimit) (net.Conn, chan struct{}, error) {
-	c, err := (&net.Dialer{
+// host, depending on which rpc was passed. It returns the address at which the
+// host was reached.
+func initiateRevisionLoop(host modules.HostDBEntry, height types.BlockHeight, contract *SafeContract, rpc types.Specifier, cancel <-chan struct{}, rl *ratelimit.RateLimit) (net.Conn, chan struct{}, modules.NetAddress, error) {
+	c, addr, err := dialHost(host, height, &net.Dialer{
 		Cancel:  cancel,
 		Timeout: 45 * time.Second, // TODO: Constant
-	}).Dial("tcp", string(host.NetAddress))
+	})
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, "", err
 	}
 	conn := ratelimit.NewRLConn(c, rl, cancel)
 
@@ -241,12 +291,12 @@ func initiateRevisionLoop(host modules.HostDBEntry, contract *SafeContract, rpc
 	if err := encoding.WriteObject(conn, rpc); err != nil {
 		conn.Close()
 		close(closeChan)
-		return nil, closeChan, errors.New("couldn't initiate RPC: " + err.Error())
+		return nil, closeChan, "", errors.New("couldn't initiate RPC: " + err.Error())
 	}
 	if err := verifyRecentRevision(conn, contract, host.Version); err != nil {
 		conn.Close() // TODO: close gracefully if host has entered revision loop
 		close(closeChan)
-		return nil, closeChan, err
+		return nil, closeChan, "", err
 	}
-	return conn, closeChan, nil
+	return conn, closeChan, addr, nil
 }
@@ -0,0 +1,48 @@
+package proto
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+)
+
+// TestDialHost checks that the addresses of a host are dialed in order of
+// priority, and that the address that worked is returned.
+func TestDialHost(t *testing.T) {
+	// Create an address that nobody listens on.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedAddr := modules.NetAddress(closed.Addr().String())
+	closed.Close()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	openAddr := modules.NetAddress(l.Addr().String())
+
+	host := modules.HostDBEntry{
+		NetAddresses:       []modules.NetAddress{closedAddr, openAddr},
+		AnnouncementExpiry: 10,
+	}
+	host.NetAddress = closedAddr
+	dialer := &net.Dialer{Timeout: time.Second}
+	conn, addr, err := dialHost(host, 5, dialer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+	if addr != openAddr {
+		t.Fatal("wrong address was used:", addr)
+	}
+
+	// After the expiry, only the primary address is dialed.
+	if _, _, err := dialHost(host, 10, dialer); err == nil {
+		t.Fatal("expired alternate address should not be dialed")
+	}
+}
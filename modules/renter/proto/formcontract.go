@@ -18,8 +18,12 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	// Extract vars from params, for convenience.
 	host, funding, startHeight, endHeight, refundAddress := params.Host, params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
 
-	// Create our key.
-	ourSK, ourPK := crypto.GenerateKeyPair()
+	// Create the identifier that allows the renter to find the contract in
+	// the blockchain when recovering contracts, and derive our key from the
+	// renter seed and the identifier, so that the contract can be recovered
+	// from the seed.
+	identifier := params.RenterSeed.ContractIdentifier()
+	ourSK, ourPK := params.RenterSeed.ContractKeys(host.PublicKey, identifier)
 	// Create unlock conditions.
 	uc := types.UnlockConditions{
 		PublicKeys: []types.SiaPublicKey{
@@ -35,7 +39,10 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 
 	// Calculate the payouts for the renter, host, and whole contract.
 	period := endHeight - startHeight
-	expectedStorage := modules.DefaultUsageGuideLines.ExpectedStorage
+	expectedStorage := params.ExpectedStorage
+	if expectedStorage == 0 {
+		expectedStorage, _, _ = modules.DefaultAllowance.ExpectedContractUsage()
+	}
 	renterPayout, hostPayout, _, err := modules.RenterPayoutsPreTax(host, funding, txnFee, types.ZeroCurrency, types.ZeroCurrency, period, expectedStorage)
 	if err != nil {
 		return modules.RenterContract{}, err
@@ -79,6 +86,8 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 	txnBuilder.AddFileContract(fc)
 	// Add miner fee.
 	txnBuilder.AddMinerFee(txnFee)
+	// Add the contract identifier.
+	txnBuilder.AddArbitraryData(identifier)
 
 	// Create initial transaction set.
 	txn, parentTxns := txnBuilder.View()
@@ -103,10 +112,11 @@ func (cs *ContractSet) FormContract(params ContractParams, txnBuilder transactio
 		Cancel:  cancel,
 		Timeout: connTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, addr, err := dialHost(host, startHeight, dialer)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	hdb.UpdateWorkingAddress(host.PublicKey, addr)
 	defer func() { _ = conn.Close() }()
 
 	// Allot time for sending RPC ID + verifySettings.
@@ -12,35 +12,32 @@ import (
 	"gitlab.com/NebulousLabs/errors"
 )
 
+// errHostPriceTooHigh is returned if the settings of a host contain a price
+// that the host can't charge the renter.
+var errHostPriceTooHigh = errors.New("host quoted a price above the maximum price of its policy")
+
 // extendDeadline is a helper function for extending the connection timeout.
 func extendDeadline(conn net.Conn, d time.Duration) { _ = conn.SetDeadline(time.Now().Add(d)) }
 
-// startRevision is run at the beginning of each revision iteration. It reads
-// the host's settings confirms that the values are acceptable, and writes an acceptance.
-func startRevision(conn net.Conn, host modules.HostDBEntry) error {
-	// verify the host's settings and confirm its identity
-	_, err := verifySettings(conn, host)
-	if err != nil {
-		return err
+// checkHostPrices checks that the storage and bandwidth prices that a host
+// sends are no higher than the known prices of the host times the largest
+// price multiplier that a renter policy can have, plus hostPriceLeeway.
+func checkHostPrices(known, recv modules.HostExternalSettings) error {
+	maxPrice := func(price types.Currency) types.Currency {
+		return price.MulFloat(modules.MaxRenterPriceMultiplier * (1 + hostPriceLeeway))
 	}
-	return modules.WriteNegotiationAcceptance(conn)
-}
-
-// startDownload is run at the beginning of each download iteration. It reads
-// the host's settings confirms that the values are acceptable, and writes an acceptance.
-func startDownload(conn net.Conn, host modules.HostDBEntry) error {
-	// verify the host's settings and confirm its identity
-	_, err := verifySettings(conn, host)
-	if err != nil {
-		return err
+	if recv.StoragePrice.Cmp(maxPrice(known.StoragePrice)) > 0 ||
+		recv.UploadBandwidthPrice.Cmp(maxPrice(known.UploadBandwidthPrice)) > 0 ||
+		recv.DownloadBandwidthPrice.Cmp(maxPrice(known.DownloadBandwidthPrice)) > 0 {
+		return errHostPriceTooHigh
 	}
-	return modules.WriteNegotiationAcceptance(conn)
+	return nil
 }
 
 // verifySettings reads a signed HostSettings object from conn, validates the
 // signature, and checks for discrepancies between the known settings and the
-// received settings. If there is a discrepancy, the hostDB is notified. The
-// received settings are returned.
+// received settings. If the received prices are too high, the settings are
+// rejected. The received settings are returned.
 func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntry, error) {
 	// convert host key (types.SiaPublicKey) to a crypto.PublicKey
 	if host.PublicKey.Algorithm != types.SignatureEd25519 || len(host.PublicKey.Key) != crypto.PublicKeySize {
@@ -55,8 +52,10 @@ func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntr
 	if err := crypto.ReadSignedObject(conn, &recvSettings, modules.NegotiateMaxHostExternalSettingsLen, pk); err != nil {
 		return modules.HostDBEntry{}, errors.New("couldn't read host's settings: " + err.Error())
 	}
-	// TODO: check recvSettings against host.HostExternalSettings. If there is
-	// a discrepancy, write the error to conn.
+	if err := checkHostPrices(host.HostExternalSettings, recvSettings); err != nil {
+		modules.WriteNegotiationRejection(conn, err) // error ignored to preserve type
+		return modules.HostDBEntry{}, err
+	}
 	if recvSettings.NetAddress != host.NetAddress {
 		// for now, just overwrite the NetAddress, since we know that
 		// host.NetAddress works (it was the one we dialed to get conn)
@@ -66,44 +65,54 @@ func verifySettings(conn net.Conn, host modules.HostDBEntry) (modules.HostDBEntr
 	return host, nil
 }
 
-// verifyRecentRevision confirms that the host and contractor agree upon the current
-// state of the contract being revised.
-func verifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion string) error {
+// getRecentRevision requests the most recent revision of a contract from the
+// host, proving ownership of the contract by signing the host's challenge.
+func getRecentRevision(conn net.Conn, id types.FileContractID, sk crypto.SecretKey, hostVersion string) (types.FileContractRevision, []types.TransactionSignature, error) {
 	// send contract ID
-	if err := encoding.WriteObject(conn, contract.header.ID()); err != nil {
-		return errors.New("couldn't send contract ID: " + err.Error())
+	if err := encoding.WriteObject(conn, id); err != nil {
+		return types.FileContractRevision{}, nil, errors.New("couldn't send contract ID: " + err.Error())
 	}
 	// read challenge
 	var challenge crypto.Hash
 	if err := encoding.ReadObject(conn, &challenge, 32); err != nil {
-		return errors.New("couldn't read challenge: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read challenge: " + err.Error())
 	}
 	if build.VersionCmp(hostVersion, "1.3.0") >= 0 {
 		crypto.SecureWipe(challenge[:16])
 	}
 	// sign and return
-	sig := crypto.SignHash(challenge, contract.header.SecretKey)
+	sig := crypto.SignHash(challenge, sk)
 	if err := encoding.WriteObject(conn, sig); err != nil {
-		return errors.New("couldn't send challenge response: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't send challenge response: " + err.Error())
 	}
 	// read acceptance
 	if err := modules.ReadNegotiationAcceptance(conn); err != nil {
-		return errors.New("host did not accept revision request: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("host did not accept revision request: " + err.Error())
 	}
 	// read last revision and signatures
 	var lastRevision types.FileContractRevision
 	var hostSignatures []types.TransactionSignature
 	if err := encoding.ReadObject(conn, &lastRevision, 2048); err != nil {
-		return errors.New("couldn't read last revision: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read last revision: " + err.Error())
 	}
 	if err := encoding.ReadObject(conn, &hostSignatures, 2048); err != nil {
-		return errors.New("couldn't read host signatures: " + err.Error())
+		return types.FileContractRevision{}, nil, errors.New("couldn't read host signatures: " + err.Error())
+	}
+	return lastRevision, hostSignatures, nil
+}
+
+// verifyRecentRevision confirms that the host and contractor agree upon the current
+// state of the contract being revised.
+func verifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion string) error {
+	lastRevision, hostSignatures, err := getRecentRevision(conn, contract.header.ID(), contract.header.SecretKey, hostVersion)
+	if err != nil {
+		return err
 	}
 	// Check that the unlock hashes match; if they do not, something is
 	// seriously wrong. Otherwise, check that the revision numbers match.
 	ourRev := contract.header.LastRevision()
 	if lastRevision.UnlockConditions.UnlockHash() != ourRev.UnlockConditions.UnlockHash() {
-		return errors.New("unlock conditions do not match")
+		return &hostMisbehaviorError{modules.HostMisbehaviorBadRevision, errors.New("unlock conditions do not match")}
 	} else if lastRevision.NewRevisionNumber != ourRev.NewRevisionNumber {
 		// If the revision number doesn't match try to commit potential
 		// unapplied transactions and check again.
@@ -118,7 +127,11 @@ func verifyRecentRevision(conn net.Conn, contract *SafeContract, hostVersion str
 	// NOTE: we can fake the blockheight here because it doesn't affect
 	// verification; it just needs to be above the fork height and below the
 	// contract expiration (which was checked earlier).
-	return modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, contract.header.EndHeight()-1)
+	err = modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, hostSignatures, contract.header.EndHeight()-1)
+	if err != nil {
+		return &hostMisbehaviorError{modules.HostMisbehaviorBadSignature, errors.AddContext(err, "host sent invalid revision signatures")}
+	}
+	return nil
 }
 
 // negotiateRevision sends a revision and actions to the host for approval,
@@ -168,7 +181,11 @@ func negotiateRevision(conn net.Conn, rev types.FileContractRevision, secretKey
 	// contract expiration (which was checked earlier).
 	verificationHeight := rev.NewWindowStart - 1
 	signedTxn.TransactionSignatures = append(signedTxn.TransactionSignatures, hostSig)
-	if err := signedTxn.StandaloneValid(verificationHeight); err != nil {
+	if err := signedTxn.StandaloneValid(verificationHeight); err == crypto.ErrInvalidSignature {
+		// Our own signature is valid, so the host must have sent an invalid
+		// one.
+		return types.Transaction{}, &hostMisbehaviorError{modules.HostMisbehaviorBadSignature, errors.AddContext(err, "host sent an invalid revision signature")}
+	} else if err != nil {
 		return types.Transaction{}, err
 	}
 
@@ -62,3 +62,54 @@ func TestNegotiateRevisionStopResponse(t *testing.T) {
 	}
 	rConn.Close()
 }
+
+// TestVerifySettingsPriceCap checks that the renter accepts the prices of a
+// renter policy, but rejects a host that quotes an inflated price.
+func TestVerifySettingsPriceCap(t *testing.T) {
+	sk, pk := crypto.GenerateKeyPair()
+	host := modules.HostDBEntry{PublicKey: types.Ed25519PublicKey(pk)}
+	host.StoragePrice = types.NewCurrency64(1e6)
+	host.UploadBandwidthPrice = types.NewCurrency64(1e9)
+	host.DownloadBandwidthPrice = types.NewCurrency64(1e9)
+
+	// verify simulates a host that sends its settings, and stores the
+	// response of the renter in hostErr.
+	var hostErr error
+	verify := func(settings modules.HostExternalSettings) (modules.HostDBEntry, error) {
+		rConn, hConn := net.Pipe()
+		defer rConn.Close()
+		errChan := make(chan error, 1)
+		go func() {
+			defer hConn.Close()
+			if err := crypto.WriteSignedObject(hConn, settings, sk); err != nil {
+				errChan <- err
+				return
+			}
+			errChan <- modules.ReadNegotiationAcceptance(hConn)
+		}()
+		recv, err := verifySettings(rConn, host)
+		if err == nil {
+			modules.WriteNegotiationAcceptance(rConn)
+		}
+		hostErr = <-errChan
+		return recv, err
+	}
+
+	// The largest multiplier of a renter policy is accepted.
+	settings := host.HostExternalSettings
+	settings.DownloadBandwidthPrice = host.DownloadBandwidthPrice.Mul64(modules.MaxRenterPriceMultiplier)
+	recv, err := verify(settings)
+	if err != nil || hostErr != nil {
+		t.Fatal("settings were rejected:", err, hostErr)
+	}
+	if !recv.DownloadBandwidthPrice.Equals(settings.DownloadBandwidthPrice) {
+		t.Fatal("wrong download price:", recv.DownloadBandwidthPrice)
+	}
+
+	// An inflated price is rejected, and the host is told why.
+	settings = host.HostExternalSettings
+	settings.UploadBandwidthPrice = host.UploadBandwidthPrice.Mul64(modules.MaxRenterPriceMultiplier * 2)
+	if _, err := verify(settings); err != errHostPriceTooHigh || hostErr == nil || hostErr.Error() != errHostPriceTooHigh.Error() {
+		t.Fatal("expected errHostPriceTooHigh, got", err, hostErr)
+	}
+}
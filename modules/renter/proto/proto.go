@@ -11,6 +11,7 @@ import (
 // Dependencies.
 type (
 	transactionBuilder interface {
+		AddArbitraryData([]byte) uint64
 		AddFileContract(types.FileContract) uint64
 		AddMinerFee(types.Currency) uint64
 		AddParents([]types.Transaction)
@@ -32,6 +33,8 @@ type (
 	hostDB interface {
 		IncrementSuccessfulInteractions(key types.SiaPublicKey)
 		IncrementFailedInteractions(key types.SiaPublicKey)
+		RecordMisbehavior(key types.SiaPublicKey, misbehavior modules.HostMisbehavior)
+		UpdateWorkingAddress(key types.SiaPublicKey, addr modules.NetAddress)
 	}
 )
 
@@ -42,7 +45,14 @@ type ContractParams struct {
 	StartHeight   types.BlockHeight
 	EndHeight     types.BlockHeight
 	RefundAddress types.UnlockHash
-	// TODO: add optional keypair
+	// ExpectedStorage is the amount of data that the renter expects to store
+	// in the contract. It limits the amount of collateral the host is asked
+	// to put into the contract.
+	ExpectedStorage uint64
+	// RenterSeed is used to derive the renter's contract keys and to tag the
+	// contract transaction, so that the contract can be recovered from the
+	// wallet seed.
+	RenterSeed RenterSeed
 }
 
 // A revisionSaver is called just before we send our revision signature to the host; this
@@ -65,3 +75,29 @@ func IsRevisionMismatch(err error) bool {
 	_, ok := err.(*recentRevisionError)
 	return ok
 }
+
+// A hostMisbehaviorError occurs if the host provides cryptographic evidence of
+// its own misbehavior, e.g. by sending data that doesn't match its Merkle root
+// or by providing an invalid signature.
+type hostMisbehaviorError struct {
+	misbehavior modules.HostMisbehaviorType
+	err         error
+}
+
+func (e *hostMisbehaviorError) Error() string {
+	return e.err.Error()
+}
+
+// recordMisbehavior reports the misbehavior to the hostdb if err was caused by
+// the host misbehaving.
+func recordMisbehavior(hdb hostDB, host types.SiaPublicKey, id types.FileContractID, err error) {
+	hme, ok := err.(*hostMisbehaviorError)
+	if !ok {
+		return
+	}
+	hdb.RecordMisbehavior(host, modules.HostMisbehavior{
+		Type:        hme.misbehavior,
+		ContractID:  id,
+		Description: hme.Error(),
+	})
+}
@@ -0,0 +1,160 @@
+package proto
+
+import (
+	"net"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"gitlab.com/NebulousLabs/errors"
+)
+
+// A RecoverableContract is a file contract that was found in the blockchain
+// and that was formed using the renter seed. Funding is the amount of money
+// that the renter put into the contract, it is zero if the funding couldn't
+// be found in the blockchain. TxnFee is the miner fee of the contract
+// transaction.
+type RecoverableContract struct {
+	types.FileContract
+	ID          types.FileContractID
+	Identifier  []byte
+	StartHeight types.BlockHeight
+	Funding     types.Currency
+	TxnFee      types.Currency
+}
+
+// contractUnlockConditions returns the unlock conditions of the contract with
+// the host that was formed using the renter seed and the identifier.
+func contractUnlockConditions(rs RenterSeed, hostKey types.SiaPublicKey, identifier []byte) types.UnlockConditions {
+	_, ourPK := rs.ContractKeys(hostKey, identifier)
+	return types.UnlockConditions{
+		PublicKeys: []types.SiaPublicKey{
+			types.Ed25519PublicKey(ourPK),
+			hostKey,
+		},
+		SignaturesRequired: 2,
+	}
+}
+
+// IsContractHost returns true if the contract was formed with the host using
+// the renter seed.
+func (rc RecoverableContract) IsContractHost(rs RenterSeed, hostKey types.SiaPublicKey) bool {
+	return contractUnlockConditions(rs, hostKey, rc.Identifier).UnlockHash() == rc.UnlockHash
+}
+
+// costs reconstructs the total cost and the fees of the contract. The renter
+// payout, the siafund fee and the miner fee follow from the contract
+// transaction, and the contract fee is the rest of the funding. For renewed
+// contracts, the contract fee includes the price of the storage that the
+// contract took over from the old contract. If the funding is unknown, the
+// total cost is the part of it that can be reconstructed and the contract fee
+// is zero.
+func (rc RecoverableContract) costs() (totalCost, contractFee, siafundFee types.Currency) {
+	// The siafund fee is the part of the payout that isn't paid out.
+	siafundFee = rc.Payout
+	for _, output := range rc.ValidProofOutputs {
+		siafundFee = siafundFee.Sub(output.Value)
+	}
+	renterPayout := rc.ValidProofOutputs[0].Value.Add(siafundFee)
+	known := renterPayout.Add(rc.TxnFee)
+	if rc.Funding.Cmp(known) <= 0 {
+		return known, types.ZeroCurrency, siafundFee
+	}
+	return rc.Funding, rc.Funding.Sub(known), siafundFee
+}
+
+// RecoverContract fetches the most recent revision and the sector roots of a
+// contract from its host and adds the contract to the set. The spending of
+// the contract can't be recovered, and its total cost and fees are
+// reconstructed from the contract transaction. The height is used to choose
+// the addresses at which the host is dialed.
+func (cs *ContractSet) RecoverContract(host modules.HostDBEntry, rc RecoverableContract, rs RenterSeed, height types.BlockHeight, hdb hostDB, cancel <-chan struct{}) (_ modules.RenterContract, err error) {
+	if !rc.IsContractHost(rs, host.PublicKey) {
+		return modules.RenterContract{}, errors.New("contract was not formed with the host using the renter seed")
+	}
+	if _, exists := cs.View(rc.ID); exists {
+		return modules.RenterContract{}, errors.New("contract is already in the contract set")
+	}
+	ourSK, _ := rs.ContractKeys(host.PublicKey, rc.Identifier)
+
+	// Increase Successful/Failed interactions accordingly
+	defer func() {
+		if err != nil {
+			hdb.IncrementFailedInteractions(host.PublicKey)
+			err = errors.Extend(err, modules.ErrHostFault)
+		} else {
+			hdb.IncrementSuccessfulInteractions(host.PublicKey)
+		}
+	}()
+
+	// initiate connection
+	dialer := &net.Dialer{
+		Cancel:  cancel,
+		Timeout: connTimeout,
+	}
+	conn, addr, err := dialHost(host, height, dialer)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	hdb.UpdateWorkingAddress(host.PublicKey, addr)
+	defer func() { _ = conn.Close() }()
+
+	// allot time for sending RPC ID and the recent revision exchange
+	extendDeadline(conn, modules.NegotiateRecentRevisionTime)
+	if err = encoding.WriteObject(conn, modules.RPCRecoverContract); err != nil {
+		return modules.RenterContract{}, errors.New("couldn't initiate RPC: " + err.Error())
+	}
+	lastRevision, signatures, err := getRecentRevision(conn, rc.ID, ourSK, host.Version)
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+
+	// Check that the revision belongs to the contract and that it was signed
+	// by both parties.
+	if lastRevision.ParentID != rc.ID || lastRevision.UnlockConditions.UnlockHash() != rc.UnlockHash {
+		err = &hostMisbehaviorError{modules.HostMisbehaviorBadRevision, errors.New("host sent the revision of a different contract")}
+		recordMisbehavior(hdb, host.PublicKey, rc.ID, err)
+		return modules.RenterContract{}, err
+	}
+	err = modules.VerifyFileContractRevisionTransactionSignatures(lastRevision, signatures, rc.WindowStart-1)
+	if err != nil {
+		err = &hostMisbehaviorError{modules.HostMisbehaviorBadSignature, errors.AddContext(err, "host sent invalid revision signatures")}
+		recordMisbehavior(hdb, host.PublicKey, rc.ID, err)
+		return modules.RenterContract{}, err
+	}
+
+	// Read the sector roots and check that they match the revision.
+	extendDeadline(conn, modules.NegotiateRecoverContractTime)
+	var roots []crypto.Hash
+	maxLen := 8 + uint64(crypto.HashSize)*(lastRevision.NewFileSize/modules.SectorSize+1)
+	if err = encoding.ReadObject(conn, &roots, maxLen); err != nil {
+		return modules.RenterContract{}, errors.New("couldn't read sector roots: " + err.Error())
+	}
+	if cachedMerkleRoot(roots) != lastRevision.NewFileMerkleRoot {
+		err = &hostMisbehaviorError{modules.HostMisbehaviorBadRevision, errors.New("sector roots don't match the Merkle root of the revision")}
+		recordMisbehavior(hdb, host.PublicKey, rc.ID, err)
+		return modules.RenterContract{}, err
+	}
+
+	// Construct contract header.
+	totalCost, contractFee, siafundFee := rc.costs()
+	header := contractHeader{
+		Transaction: types.Transaction{
+			FileContractRevisions: []types.FileContractRevision{lastRevision},
+			TransactionSignatures: signatures,
+		},
+		SecretKey:   ourSK,
+		StartHeight: rc.StartHeight,
+		TotalCost:   totalCost,
+		ContractFee: contractFee,
+		TxnFee:      rc.TxnFee,
+		SiafundFee:  siafundFee,
+		Utility: modules.ContractUtility{
+			GoodForUpload: true,
+			GoodForRenew:  true,
+		},
+	}
+	return cs.managedInsertContract(header, roots)
+}
@@ -19,81 +19,42 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	contract := oldContract.header
 
 	// Extract vars from params, for convenience.
-	host, funding, startHeight, endHeight, refundAddress := params.Host, params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
-	ourSK := contract.SecretKey
+	host, funding, startHeight := params.Host, params.Funding, params.StartHeight
 	lastRev := contract.LastRevision()
 
-	// Calculate additional basePrice and baseCollateral. If the contract height
-	// did not increase, basePrice and baseCollateral are zero.
-	var basePrice, baseCollateral types.Currency
-	if endHeight+host.WindowSize > lastRev.NewWindowEnd {
-		timeExtension := uint64((endHeight + host.WindowSize) - lastRev.NewWindowEnd)
-		basePrice = host.StoragePrice.Mul64(lastRev.NewFileSize).Mul64(timeExtension)    // cost of already uploaded data that needs to be covered by the renewed contract.
-		baseCollateral = host.Collateral.Mul64(lastRev.NewFileSize).Mul64(timeExtension) // same as basePrice.
+	// The renewed contract is a new contract, with its own identifier and
+	// keys derived from the renter seed, so that it can be recovered from the
+	// seed.
+	identifier := params.RenterSeed.ContractIdentifier()
+	ourSK, ourPK := params.RenterSeed.ContractKeys(host.PublicKey, identifier)
+	uc := types.UnlockConditions{
+		PublicKeys: []types.SiaPublicKey{
+			types.Ed25519PublicKey(ourPK),
+			host.PublicKey,
+		},
+		SignaturesRequired: 2,
 	}
 
 	// Calculate the anticipated transaction fee.
 	_, maxFee := tpool.FeeEstimation()
 	txnFee := maxFee.Mul64(modules.EstimatedFileContractTransactionSetSize)
 
-	// Calculate the payouts for the renter, host, and whole contract.
-	period := endHeight - startHeight
-	expectedStorage := modules.DefaultUsageGuideLines.ExpectedStorage
-	renterPayout, hostPayout, hostCollateral, err := modules.RenterPayoutsPreTax(host, funding, txnFee, basePrice, baseCollateral, period, expectedStorage)
-	if err != nil {
+	// Check that the contract can be renewed at the prices in the hostdb
+	// before contacting the host.
+	if _, _, err := renewFileContract(host, params, lastRev, uc.UnlockHash(), txnFee); err != nil {
 		return modules.RenterContract{}, err
 	}
-	totalPayout := renterPayout.Add(hostPayout)
-
-	// check for negative currency
-	if hostCollateral.Cmp(baseCollateral) < 0 {
-		baseCollateral = hostCollateral
-	}
-	if types.PostTax(startHeight, totalPayout).Cmp(hostPayout) < 0 {
-		return modules.RenterContract{}, errors.New("insufficient funds to pay both siafund fee and also host payout")
-	}
-
-	// create file contract
-	fc := types.FileContract{
-		FileSize:       lastRev.NewFileSize,
-		FileMerkleRoot: lastRev.NewFileMerkleRoot,
-		WindowStart:    endHeight,
-		WindowEnd:      endHeight + host.WindowSize,
-		Payout:         totalPayout,
-		UnlockHash:     lastRev.NewUnlockHash,
-		RevisionNumber: 0,
-		ValidProofOutputs: []types.SiacoinOutput{
-			// renter
-			{Value: types.PostTax(startHeight, totalPayout).Sub(hostPayout), UnlockHash: refundAddress},
-			// host
-			{Value: hostPayout, UnlockHash: host.UnlockHash},
-		},
-		MissedProofOutputs: []types.SiacoinOutput{
-			// renter
-			{Value: types.PostTax(startHeight, totalPayout).Sub(hostPayout), UnlockHash: refundAddress},
-			// host gets its unused collateral back, plus the contract price
-			{Value: hostCollateral.Sub(baseCollateral).Add(host.ContractPrice), UnlockHash: host.UnlockHash},
-			// void gets the spent storage fees, plus the collateral being risked
-			{Value: basePrice.Add(baseCollateral), UnlockHash: types.UnlockHash{}},
-		},
-	}
 
-	// build transaction containing fc
+	// Fund the transaction. The file contract is added once the host has
+	// sent its current prices.
 	err = txnBuilder.FundSiacoins(funding)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
-	txnBuilder.AddFileContract(fc)
 	// add miner fee
 	txnBuilder.AddMinerFee(txnFee)
-
-	// Create initial transaction set.
-	txn, parentTxns := txnBuilder.View()
-	unconfirmedParents, err := txnBuilder.UnconfirmedParents()
-	if err != nil {
-		return modules.RenterContract{}, err
-	}
-	txnSet := append(unconfirmedParents, append(parentTxns, txn)...)
+	// add the contract identifier
+	txnBuilder.AddArbitraryData(identifier)
 
 	// Increase Successful/Failed interactions accordingly
 	defer func() {
@@ -111,10 +72,11 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 		Cancel:  cancel,
 		Timeout: connTimeout,
 	}
-	conn, err := dialer.Dial("tcp", string(host.NetAddress))
+	conn, addr, err := dialHost(host, startHeight, dialer)
 	if err != nil {
 		return modules.RenterContract{}, err
 	}
+	hdb.UpdateWorkingAddress(host.PublicKey, addr)
 	defer func() { _ = conn.Close() }()
 
 	// allot time for sending RPC ID, verifyRecentRevision, and verifySettings
@@ -140,6 +102,23 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	// allot time for negotiation
 	extendDeadline(conn, modules.NegotiateRenewContractTime)
 
+	// create the file contract at the prices that the host sent, which can
+	// differ from the prices in the hostdb, e.g. if the host operator has set
+	// a policy for the renter.
+	fc, basePrice, err := renewFileContract(host, params, lastRev, uc.UnlockHash(), txnFee)
+	if err != nil {
+		return modules.RenterContract{}, modules.WriteNegotiationRejection(conn, err)
+	}
+	txnBuilder.AddFileContract(fc)
+
+	// Create initial transaction set.
+	txn, parentTxns := txnBuilder.View()
+	unconfirmedParents, err := txnBuilder.UnconfirmedParents()
+	if err != nil {
+		return modules.RenterContract{}, err
+	}
+	txnSet := append(unconfirmedParents, append(parentTxns, txn)...)
+
 	// send acceptance, txn signed by us, and pubkey
 	if err = modules.WriteNegotiationAcceptance(conn); err != nil {
 		return modules.RenterContract{}, errors.New("couldn't send initial acceptance: " + err.Error())
@@ -195,7 +174,7 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	// create initial (no-op) revision, transaction, and signature
 	initRevision := types.FileContractRevision{
 		ParentID:          signedTxnSet[len(signedTxnSet)-1].FileContractID(0),
-		UnlockConditions:  lastRev.UnlockConditions,
+		UnlockConditions:  uc,
 		NewRevisionNumber: 1,
 
 		NewFileSize:           fc.FileSize,
@@ -292,3 +271,68 @@ func (cs *ContractSet) Renew(oldContract *SafeContract, params ContractParams, t
 	}
 	return meta, nil
 }
+
+// renewFileContract creates the file contract that renews the contract with
+// the given last revision at the prices of the host, protected by the unlock
+// hash. The price of the storage
+// that the renewed contract takes over from the old contract is returned as
+// well.
+func renewFileContract(host modules.HostDBEntry, params ContractParams, lastRev types.FileContractRevision, unlockHash types.UnlockHash, txnFee types.Currency) (types.FileContract, types.Currency, error) {
+	// Extract vars from params, for convenience.
+	funding, startHeight, endHeight, refundAddress := params.Funding, params.StartHeight, params.EndHeight, params.RefundAddress
+
+	// Calculate additional basePrice and baseCollateral. If the contract height
+	// did not increase, basePrice and baseCollateral are zero.
+	var basePrice, baseCollateral types.Currency
+	if endHeight+host.WindowSize > lastRev.NewWindowEnd {
+		timeExtension := uint64((endHeight + host.WindowSize) - lastRev.NewWindowEnd)
+		basePrice = host.StoragePrice.Mul64(lastRev.NewFileSize).Mul64(timeExtension)    // cost of already uploaded data that needs to be covered by the renewed contract.
+		baseCollateral = host.Collateral.Mul64(lastRev.NewFileSize).Mul64(timeExtension) // same as basePrice.
+	}
+
+	// Calculate the payouts for the renter, host, and whole contract.
+	period := endHeight - startHeight
+	expectedStorage := params.ExpectedStorage
+	if expectedStorage == 0 {
+		expectedStorage, _, _ = modules.DefaultAllowance.ExpectedContractUsage()
+	}
+	renterPayout, hostPayout, hostCollateral, err := modules.RenterPayoutsPreTax(host, funding, txnFee, basePrice, baseCollateral, period, expectedStorage)
+	if err != nil {
+		return types.FileContract{}, types.Currency{}, err
+	}
+	totalPayout := renterPayout.Add(hostPayout)
+
+	// check for negative currency
+	if hostCollateral.Cmp(baseCollateral) < 0 {
+		baseCollateral = hostCollateral
+	}
+	if types.PostTax(startHeight, totalPayout).Cmp(hostPayout) < 0 {
+		return types.FileContract{}, types.Currency{}, errors.New("insufficient funds to pay both siafund fee and also host payout")
+	}
+
+	// create file contract
+	fc := types.FileContract{
+		FileSize:       lastRev.NewFileSize,
+		FileMerkleRoot: lastRev.NewFileMerkleRoot,
+		WindowStart:    endHeight,
+		WindowEnd:      endHeight + host.WindowSize,
+		Payout:         totalPayout,
+		UnlockHash:     unlockHash,
+		RevisionNumber: 0,
+		ValidProofOutputs: []types.SiacoinOutput{
+			// renter
+			{Value: types.PostTax(startHeight, totalPayout).Sub(hostPayout), UnlockHash: refundAddress},
+			// host
+			{Value: hostPayout, UnlockHash: host.UnlockHash},
+		},
+		MissedProofOutputs: []types.SiacoinOutput{
+			// renter
+			{Value: types.PostTax(startHeight, totalPayout).Sub(hostPayout), UnlockHash: refundAddress},
+			// host gets its unused collateral back, plus the contract price
+			{Value: hostCollateral.Sub(baseCollateral).Add(host.ContractPrice), UnlockHash: host.UnlockHash},
+			// void gets the spent storage fees, plus the collateral being risked
+			{Value: basePrice.Add(baseCollateral), UnlockHash: types.UnlockHash{}},
+		},
+	}
+	return fc, basePrice, nil
+}
@@ -0,0 +1,93 @@
+package proto
+
+import (
+	"bytes"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+const (
+	// contractIdentifierNonceSize is the size of the random nonce in a
+	// contract identifier.
+	contractIdentifierNonceSize = 16
+
+	// contractIdentifierChecksumSize is the size of the seed-derived checksum
+	// in a contract identifier.
+	contractIdentifierChecksumSize = 16
+
+	// contractIdentifierSize is the size of a contract identifier, including
+	// its prefix.
+	contractIdentifierSize = types.SpecifierLen + contractIdentifierNonceSize + contractIdentifierChecksumSize
+)
+
+var (
+	// specifierRenterSeed is used to derive the renter seed from the wallet
+	// seed.
+	specifierRenterSeed = types.Specifier{'r', 'e', 'n', 't', 'e', 'r'}
+
+	// specifierContractKey is used to derive the key pair that the renter
+	// uses for a contract with a host.
+	specifierContractKey = types.Specifier{'c', 'o', 'n', 't', 'r', 'a', 'c', 't', 'k', 'e', 'y'}
+
+	// specifierContractIdentifier is used to derive the checksum of a
+	// contract identifier.
+	specifierContractIdentifier = types.Specifier{'c', 'o', 'n', 't', 'r', 'a', 'c', 't', 'i', 'd'}
+)
+
+// A RenterSeed is derived from the wallet seed. The renter uses it to derive
+// the keys of its contracts and to tag the transactions that form them, which
+// allows the contracts to be recovered from the wallet seed alone.
+type RenterSeed [crypto.EntropySize]byte
+
+// DeriveRenterSeed derives the renter seed from the primary seed of the
+// wallet.
+func DeriveRenterSeed(walletSeed modules.Seed) RenterSeed {
+	return RenterSeed(crypto.HashAll(walletSeed, specifierRenterSeed))
+}
+
+// ContractKeys returns the key pair that the renter uses for the contract with
+// the host that is tagged with the contract identifier. Every contract has its
+// own identifier and therefore its own keys, which can be derived again from
+// the seed, the host and the identifier in the contract transaction when the
+// contract needs to be recovered.
+func (rs RenterSeed) ContractKeys(hostKey types.SiaPublicKey, identifier []byte) (crypto.SecretKey, crypto.PublicKey) {
+	return crypto.GenerateKeyPairDeterministic(crypto.HashAll(rs, specifierContractKey, hostKey, identifier))
+}
+
+// contractIdentifierChecksum returns the checksum of a contract identifier
+// with the given nonce.
+func (rs RenterSeed) contractIdentifierChecksum(nonce []byte) []byte {
+	h := crypto.HashAll(rs, specifierContractIdentifier, nonce)
+	return h[:contractIdentifierChecksumSize]
+}
+
+// ContractIdentifier returns a new identifier which is added to the arbitrary
+// data of contract transactions. It consists of a random nonce followed by a
+// checksum of the nonce that can only be computed with the renter seed, so
+// that the renter can recognize its own contracts in the blockchain without
+// revealing them to anyone else. The identifier uses the 'NonSia' prefix to
+// be considered standard by the transaction pool.
+func (rs RenterSeed) ContractIdentifier() []byte {
+	nonce := fastrand.Bytes(contractIdentifierNonceSize)
+	identifier := make([]byte, 0, contractIdentifierSize)
+	identifier = append(identifier, modules.PrefixNonSia[:]...)
+	identifier = append(identifier, nonce...)
+	return append(identifier, rs.contractIdentifierChecksum(nonce)...)
+}
+
+// IsContractIdentifier returns true if the arbitrary data is a contract
+// identifier that was created with the renter seed.
+func (rs RenterSeed) IsContractIdentifier(data []byte) bool {
+	if len(data) != contractIdentifierSize {
+		return false
+	}
+	if !bytes.Equal(data[:types.SpecifierLen], modules.PrefixNonSia[:]) {
+		return false
+	}
+	nonce := data[types.SpecifierLen : types.SpecifierLen+contractIdentifierNonceSize]
+	checksum := data[types.SpecifierLen+contractIdentifierNonceSize:]
+	return bytes.Equal(checksum, rs.contractIdentifierChecksum(nonce))
+}
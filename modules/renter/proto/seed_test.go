@@ -0,0 +1,132 @@
+package proto
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestContractIdentifier tests that contract identifiers are only recognized
+// by the renter seed that created them.
+func TestContractIdentifier(t *testing.T) {
+	var walletSeed, otherSeed modules.Seed
+	fastrand.Read(walletSeed[:])
+	fastrand.Read(otherSeed[:])
+	rs := DeriveRenterSeed(walletSeed)
+	other := DeriveRenterSeed(otherSeed)
+
+	id := rs.ContractIdentifier()
+	if !rs.IsContractIdentifier(id) {
+		t.Fatal("identifier not recognized by its seed")
+	}
+	if other.IsContractIdentifier(id) {
+		t.Fatal("identifier recognized by a different seed")
+	}
+	if string(id[:types.SpecifierLen]) != string(modules.PrefixNonSia[:]) {
+		t.Fatal("identifier doesn't use the NonSia prefix")
+	}
+
+	// Identifiers should be different every time.
+	if string(rs.ContractIdentifier()) == string(id) {
+		t.Fatal("identifiers should be random")
+	}
+
+	// Modified identifiers shouldn't be recognized.
+	if rs.IsContractIdentifier(id[:len(id)-1]) {
+		t.Fatal("truncated identifier recognized")
+	}
+	modified := append([]byte(nil), id...)
+	modified[types.SpecifierLen]++
+	if rs.IsContractIdentifier(modified) {
+		t.Fatal("modified identifier recognized")
+	}
+	if rs.IsContractIdentifier(append(modules.PrefixNonSia[:], fastrand.Bytes(contractIdentifierSize-types.SpecifierLen)...)) {
+		t.Fatal("random data recognized")
+	}
+}
+
+// TestContractKeys tests that the contract keys only depend on the renter
+// seed, the host and the contract identifier.
+func TestContractKeys(t *testing.T) {
+	var walletSeed modules.Seed
+	fastrand.Read(walletSeed[:])
+	rs := DeriveRenterSeed(walletSeed)
+	host1 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	host2 := types.SiaPublicKey{Algorithm: types.SignatureEd25519, Key: fastrand.Bytes(32)}
+	id1 := rs.ContractIdentifier()
+	id2 := rs.ContractIdentifier()
+
+	sk1, pk1 := rs.ContractKeys(host1, id1)
+	if sk1.PublicKey() != pk1 {
+		t.Fatal("secret key doesn't match public key")
+	}
+	if _, pk := DeriveRenterSeed(walletSeed).ContractKeys(host1, id1); pk != pk1 {
+		t.Fatal("keys are not deterministic")
+	}
+	if _, pk := rs.ContractKeys(host2, id1); pk == pk1 {
+		t.Fatal("keys should be different for different hosts")
+	}
+	if _, pk := rs.ContractKeys(host1, id2); pk == pk1 {
+		t.Fatal("keys should be different for different contracts")
+	}
+
+	// The unlock conditions should identify the host of a contract.
+	rc := RecoverableContract{
+		FileContract: types.FileContract{
+			UnlockHash: contractUnlockConditions(rs, host1, id1).UnlockHash(),
+		},
+		Identifier: id1,
+	}
+	if !rc.IsContractHost(rs, host1) {
+		t.Fatal("host of the contract not recognized")
+	}
+	if rc.IsContractHost(rs, host2) {
+		t.Fatal("wrong host recognized")
+	}
+	rc.Identifier = id2
+	if rc.IsContractHost(rs, host1) {
+		t.Fatal("host recognized with the identifier of a different contract")
+	}
+}
+
+// TestRecoverableContractCosts tests that the costs of a recovered contract
+// are reconstructed from the contract transaction.
+func TestRecoverableContractCosts(t *testing.T) {
+	host := modules.HostDBEntry{}
+	host.ContractPrice = types.SiacoinPrecision
+	host.StoragePrice = types.NewCurrency64(1)
+	host.Collateral = types.NewCurrency64(1)
+	host.MaxCollateral = types.SiacoinPrecision.Mul64(10)
+	funding := types.SiacoinPrecision.Mul64(50)
+	txnFee := types.SiacoinPrecision.Div64(10)
+	renterPayout, hostPayout, _, err := modules.RenterPayoutsPreTax(host, funding, txnFee, types.ZeroCurrency, types.ZeroCurrency, 100, modules.SectorSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payout := renterPayout.Add(hostPayout)
+	rc := RecoverableContract{
+		FileContract: types.FileContract{
+			Payout: payout,
+			ValidProofOutputs: []types.SiacoinOutput{
+				{Value: types.PostTax(10, payout).Sub(hostPayout)},
+				{Value: hostPayout},
+			},
+		},
+		StartHeight: 10,
+		Funding:     funding,
+		TxnFee:      txnFee,
+	}
+	totalCost, contractFee, siafundFee := rc.costs()
+	if !totalCost.Equals(funding) || !contractFee.Equals(host.ContractPrice) || !siafundFee.Equals(types.Tax(10, payout)) {
+		t.Fatal("wrong costs:", totalCost, contractFee, siafundFee)
+	}
+
+	// Without the funding, only the renter payout and the fees are known.
+	rc.Funding = types.ZeroCurrency
+	totalCost, contractFee, _ = rc.costs()
+	if !totalCost.Equals(renterPayout.Add(txnFee)) || !contractFee.IsZero() {
+		t.Fatal("wrong costs without funding:", totalCost, contractFee)
+	}
+}
@@ -75,6 +75,10 @@ type hostDB interface {
 	// enabled or not.
 	IPViolationsCheck() bool
 
+	// MisbehaviorBlacklistPeriod returns the number of blocks that a host is
+	// excluded from host selection after being caught misbehaving.
+	MisbehaviorBlacklistPeriod() types.BlockHeight
+
 	// RandomHosts returns a set of random hosts, weighted by their estimated
 	// usefulness / attractiveness to the renter. RandomHosts will not return
 	// any offline or inactive hosts.
@@ -93,6 +97,10 @@ type hostDB interface {
 	// hostdb.
 	SetIPViolationCheck(enabled bool)
 
+	// SetMisbehaviorBlacklistPeriod sets the number of blocks that a host is
+	// excluded from host selection after being caught misbehaving.
+	SetMisbehaviorBlacklistPeriod(types.BlockHeight)
+
 	// EstimateHostScore returns the estimated score breakdown of a host with the
 	// provided settings.
 	EstimateHostScore(modules.HostDBEntry, modules.Allowance) modules.HostScoreBreakdown
@@ -125,6 +133,10 @@ type hostContractor interface {
 	// ContractByPublicKey returns the contract associated with the host key.
 	ContractByPublicKey(types.SiaPublicKey) (modules.RenterContract, bool)
 
+	// ContractReport returns the spending of the contractor broken down by
+	// host and period.
+	ContractReport() []modules.HostSpendingReport
+
 	// ContractUtility returns the utility field for a given contract, along
 	// with a bool indicating if it exists.
 	ContractUtility(types.SiaPublicKey) (modules.ContractUtility, bool)
@@ -137,6 +149,18 @@ type hostContractor interface {
 	// billing period.
 	PeriodSpending() modules.ContractorSpending
 
+	// PeriodUsage compares the usage expected by the allowance with the usage
+	// observed during the current period.
+	PeriodUsage() modules.ContractorUsage
+
+	// RecoverContracts starts recovering contracts that were formed using
+	// the wallet seed from the blockchain and the hosts.
+	RecoverContracts() error
+
+	// ContractRecoveryStatus returns the progress of the most recent
+	// contract recovery.
+	ContractRecoveryStatus() modules.ContractRecoveryStatus
+
 	// Editor creates an Editor from the specified contract ID, allowing the
 	// insertion, deletion, and modification of sectors.
 	Editor(types.SiaPublicKey, <-chan struct{}) (contractor.Editor, error)
@@ -359,13 +383,13 @@ func (r *Renter) PriceEstimation(allowance modules.Allowance) (modules.RenterPri
 	var hostCollateral types.Currency
 	contractCostPerHost := totalContractCost.Div64(allowance.Hosts)
 	fundingPerHost := allowance.Funds.Div64(allowance.Hosts)
+	expectedStorage, _, _ := allowance.ExpectedContractUsage()
 	numHosts := uint64(0)
 	for _, host := range hosts {
 		// Assume that the ContractPrice equals contractCostPerHost and that
 		// the txnFee was zero. It doesn't matter since RenterPayoutsPreTax
 		// simply subtracts both values from the funding.
 		host.ContractPrice = contractCostPerHost
-		expectedStorage := modules.DefaultUsageGuideLines.ExpectedStorage
 		_, _, collateral, err := modules.RenterPayoutsPreTax(host, fundingPerHost, types.ZeroCurrency, types.ZeroCurrency, types.ZeroCurrency, allowance.Period, expectedStorage)
 		if err != nil {
 			continue
@@ -465,6 +489,9 @@ func (r *Renter) SetSettings(s modules.RenterSettings) error {
 	// Set IPViolationsCheck
 	r.hostDB.SetIPViolationCheck(s.IPViolationsCheck)
 
+	// Set MisbehaviorBlacklistPeriod
+	r.hostDB.SetMisbehaviorBlacklistPeriod(s.MisbehaviorBlacklistPeriod)
+
 	// Save the changes.
 	err = r.saveSync()
 	if err != nil {
@@ -559,6 +586,11 @@ func (r *Renter) OldContracts() []modules.RenterContract {
 	return r.hostContractor.OldContracts()
 }
 
+// ContractReport returns the host contractor's spending per host and period
+func (r *Renter) ContractReport() []modules.HostSpendingReport {
+	return r.hostContractor.ContractReport()
+}
+
 // CurrentPeriod returns the host contractor's current period
 func (r *Renter) CurrentPeriod() types.BlockHeight { return r.hostContractor.CurrentPeriod() }
 
@@ -571,15 +603,28 @@ func (r *Renter) ContractUtility(pk types.SiaPublicKey) (modules.ContractUtility
 // PeriodSpending returns the host contractor's period spending
 func (r *Renter) PeriodSpending() modules.ContractorSpending { return r.hostContractor.PeriodSpending() }
 
+// PeriodUsage returns the host contractor's period usage
+func (r *Renter) PeriodUsage() modules.ContractorUsage { return r.hostContractor.PeriodUsage() }
+
+// RecoverContracts starts recovering the host contractor's missing contracts
+func (r *Renter) RecoverContracts() error { return r.hostContractor.RecoverContracts() }
+
+// ContractRecoveryStatus returns the progress of the host contractor's
+// contract recovery
+func (r *Renter) ContractRecoveryStatus() modules.ContractRecoveryStatus {
+	return r.hostContractor.ContractRecoveryStatus()
+}
+
 // Settings returns the renter's allowance
 func (r *Renter) Settings() modules.RenterSettings {
 	download, upload, _ := r.hostContractor.RateLimits()
 	return modules.RenterSettings{
-		Allowance:         r.hostContractor.Allowance(),
-		IPViolationsCheck: r.hostDB.IPViolationsCheck(),
-		MaxDownloadSpeed:  download,
-		MaxUploadSpeed:    upload,
-		StreamCacheSize:   r.staticStreamCache.cacheSize,
+		Allowance:                  r.hostContractor.Allowance(),
+		IPViolationsCheck:          r.hostDB.IPViolationsCheck(),
+		MaxDownloadSpeed:           download,
+		MaxUploadSpeed:             upload,
+		MisbehaviorBlacklistPeriod: r.hostDB.MisbehaviorBlacklistPeriod(),
+		StreamCacheSize:            r.staticStreamCache.cacheSize,
 	}
 }
 
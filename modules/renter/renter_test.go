@@ -146,8 +146,9 @@ type pricesStub struct {
 	dbEntries []modules.HostDBEntry
 }
 
-func (pricesStub) InitialScanComplete() (bool, error) { return true, nil }
-func (pricesStub) IPViolationsCheck() bool            { return true }
+func (pricesStub) InitialScanComplete() (bool, error)            { return true, nil }
+func (pricesStub) IPViolationsCheck() bool                       { return true }
+func (pricesStub) MisbehaviorBlacklistPeriod() types.BlockHeight { return 0 }
 
 func (ps pricesStub) RandomHosts(_ int, _, _ []types.SiaPublicKey) ([]modules.HostDBEntry, error) {
 	return ps.dbEntries, nil
@@ -155,7 +156,8 @@ func (ps pricesStub) RandomHosts(_ int, _, _ []types.SiaPublicKey) ([]modules.Ho
 func (ps pricesStub) RandomHostsWithAllowance(_ int, _, _ []types.SiaPublicKey, _ modules.Allowance) ([]modules.HostDBEntry, error) {
 	return ps.dbEntries, nil
 }
-func (ps pricesStub) SetIPViolationCheck(enabled bool) { return }
+func (ps pricesStub) SetIPViolationCheck(enabled bool)                { return }
+func (ps pricesStub) SetMisbehaviorBlacklistPeriod(types.BlockHeight) { return }
 
 // TestRenterPricesVolatility verifies that the renter caches its price
 // estimation, and subsequent calls result in non-volatile results.
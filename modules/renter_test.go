@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/build"
@@ -225,3 +226,62 @@ func BenchmarkSliceCryptoHashSave(b *testing.B) {
 		}
 	}
 }
+
+// TestAllowanceWithDefaultUsage checks that the default usage is only used if
+// the allowance doesn't specify any usage.
+func TestAllowanceWithDefaultUsage(t *testing.T) {
+	a := Allowance{Hosts: 5}.WithDefaultUsage()
+	if a.ExpectedStorage != DefaultAllowance.ExpectedStorage ||
+		a.ExpectedUpload != DefaultAllowance.ExpectedUpload ||
+		a.ExpectedDownload != DefaultAllowance.ExpectedDownload ||
+		a.ExpectedRedundancy != DefaultAllowance.ExpectedRedundancy {
+		t.Fatal("default usage was not used:", a)
+	}
+	if a.Hosts != 5 {
+		t.Fatal("allowance was changed:", a)
+	}
+
+	// Each of the usage fields on its own disables the default usage.
+	for _, a := range []Allowance{
+		{ExpectedStorage: 1},
+		{ExpectedUpload: 1},
+		{ExpectedDownload: 1},
+		{ExpectedRedundancy: 1},
+	} {
+		if b := a.WithDefaultUsage(); !reflect.DeepEqual(a, b) {
+			t.Fatal("allowance with usage was changed:", b)
+		}
+	}
+}
+
+// TestAllowanceExpectedContractUsage checks that the expected usage is split
+// across the hosts of the allowance and that redundancy is applied to the
+// stored and uploaded data.
+func TestAllowanceExpectedContractUsage(t *testing.T) {
+	a := Allowance{
+		Hosts:              4,
+		ExpectedStorage:    100,
+		ExpectedUpload:     40,
+		ExpectedDownload:   20,
+		ExpectedRedundancy: 2,
+	}
+	storage, upload, download := a.ExpectedContractUsage()
+	if storage != 50 || upload != 20 || download != 5 {
+		t.Fatal("wrong contract usage:", storage, upload, download)
+	}
+
+	// An allowance without hosts or redundancy is treated as having one host
+	// and no redundancy.
+	a.Hosts = 0
+	a.ExpectedRedundancy = 0
+	storage, upload, download = a.ExpectedContractUsage()
+	if storage != 100 || upload != 40 || download != 20 {
+		t.Fatal("wrong contract usage:", storage, upload, download)
+	}
+
+	// An allowance without usage uses the default usage.
+	storage, _, _ = Allowance{Hosts: 1}.ExpectedContractUsage()
+	if expected := uint64(float64(DefaultAllowance.ExpectedStorage) * DefaultAllowance.ExpectedRedundancy); storage != expected {
+		t.Fatalf("expected %v, got %v", expected, storage)
+	}
+}
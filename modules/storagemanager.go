@@ -1,6 +1,8 @@
 package modules
 
 import (
+	"time"
+
 	"gitlab.com/NebulousLabs/Sia/crypto"
 )
 
@@ -42,6 +44,58 @@ type (
 		ProgressDenominator uint64
 	}
 
+	// AuditedSector is a stored sector whose reference count doesn't match
+	// the number of times that it is referenced by the storage obligations
+	// of the host. ID is the hex encoded id that the storage manager uses to
+	// refer to the sector.
+	AuditedSector struct {
+		ID            string `json:"id"`
+		StorageFolder uint16 `json:"storagefolder"`
+		Path          string `json:"path"`
+		Count         uint16 `json:"count"`
+		References    uint64 `json:"references"`
+	}
+
+	// StorageAudit is the result of comparing the reference counts of the
+	// stored sectors with the sector roots of the storage obligations.
+	// Orphaned sectors are not referenced by any obligation and leak storage,
+	// under-referenced sectors are removed before their last obligation ends,
+	// over-referenced sectors are kept after their last obligation ended.
+	// Missing sectors are referenced by an obligation but not stored at all,
+	// which can't be fixed. Fixed is set if the reference counts were
+	// corrected to match the obligations.
+	StorageAudit struct {
+		TotalSectors           uint64          `json:"totalsectors"`
+		OrphanedSectors        []AuditedSector `json:"orphanedsectors"`
+		UnderReferencedSectors []AuditedSector `json:"underreferencedsectors"`
+		OverReferencedSectors  []AuditedSector `json:"overreferencedsectors"`
+		MissingSectors         []crypto.Hash   `json:"missingsectors"`
+		Fixed                  bool            `json:"fixed"`
+	}
+
+	// CorruptSector describes a sector whose data could not be read or does
+	// not match its Merkle root. ID is the hex encoded id that the storage
+	// manager uses to refer to the sector.
+	CorruptSector struct {
+		ID            string    `json:"id"`
+		StorageFolder uint16    `json:"storagefolder"`
+		Path          string    `json:"path"`
+		DetectedAt    time.Time `json:"detectedat"`
+		Error         string    `json:"error"`
+	}
+
+	// StorageScrubStatus reports the progress and the results of the
+	// background scrub that verifies the integrity of the stored sectors.
+	// ScrubbedSectors and TotalSectors describe the progress of the current
+	// pass.
+	StorageScrubStatus struct {
+		LastPassCompleted time.Time       `json:"lastpasscompleted"`
+		PassesCompleted   uint64          `json:"passescompleted"`
+		ScrubbedSectors   uint64          `json:"scrubbedsectors"`
+		TotalSectors      uint64          `json:"totalsectors"`
+		CorruptSectors    []CorruptSector `json:"corruptsectors"`
+	}
+
 	// A StorageManager is responsible for managing storage folders and
 	// sectors. Sectors are the base unit of storage that gets moved between
 	// renters and hosts, and primarily is stored on the hosts.
@@ -69,9 +123,19 @@ type (
 		// gracefully handle running out of storage unexpectedly.
 		AddStorageFolder(path string, size uint64) error
 
+		// AuditSectors compares the reference count of every stored sector
+		// with the number of references that the caller expects for it. If
+		// fix is set, the reference counts are corrected, and sectors without
+		// any references are removed.
+		AuditSectors(references map[crypto.Hash]uint64, fix bool) (StorageAudit, error)
+
 		// The storage manager needs to be able to shut down.
 		Close() error
 
+		// CorruptSectors returns the roots of the given sectors that were
+		// found to be corrupted by the storage scrub.
+		CorruptSectors(sectorRoots []crypto.Hash) []crypto.Hash
+
 		// DeleteSector deletes a sector, meaning that the manager will be
 		// unable to upload that sector and be unable to provide a storage
 		// proof on that sector. DeleteSector is for removing the data
@@ -80,6 +144,13 @@ type (
 		// requests to remove data.
 		DeleteSector(sectorRoot crypto.Hash) error
 
+		// MigrateStorageFolder will move all of the sectors in the source
+		// storage folder into the destination storage folder. The sectors
+		// remain available while they are being moved, and no data is lost
+		// if the destination runs out of space. The migration is resumed if
+		// it is interrupted by an unclean shutdown.
+		MigrateStorageFolder(source, destination uint16) error
+
 		// ReadSector will read a sector from the storage manager, returning the
 		// bytes that match the input sector root.
 		ReadSector(sectorRoot crypto.Hash) ([]byte, error)
@@ -118,6 +189,10 @@ type (
 		// that data will be lost.
 		ResizeStorageFolder(index uint16, newSize uint64, force bool) error
 
+		// ScrubStatus returns the progress and the results of the background
+		// scrub that verifies the stored sectors against their Merkle roots.
+		ScrubStatus() StorageScrubStatus
+
 		// StorageFolders will return a list of storage folders tracked by the
 		// manager.
 		StorageFolders() []StorageFolderMetadata
@@ -23,6 +23,130 @@ const (
 	WalletDir = "wallet"
 )
 
+// The categories of wallet transactions. They are derived from the content of
+// the transactions.
+const (
+	// TransactionCategoryContractFormation is a transaction that forms or
+	// renews a file contract.
+	TransactionCategoryContractFormation TransactionCategory = "contract formation"
+
+	// TransactionCategoryContractRevision is a transaction that revises a
+	// file contract.
+	TransactionCategoryContractRevision TransactionCategory = "contract revision"
+
+	// TransactionCategoryFeeBump is a transaction that the wallet created to
+	// bump the fee of another transaction.
+	TransactionCategoryFeeBump TransactionCategory = "fee bump"
+
+	// TransactionCategoryMinerPayout is the set of miner payouts of a block.
+	TransactionCategoryMinerPayout TransactionCategory = "miner payout"
+
+	// TransactionCategorySiafundClaim is a transaction that spends siafunds,
+	// which pays out the siacoin claim of the siafunds.
+	TransactionCategorySiafundClaim TransactionCategory = "siafund claim"
+
+	// TransactionCategoryStorageProof is a transaction that submits a storage
+	// proof, which pays out the valid proof outputs of the file contract.
+	TransactionCategoryStorageProof TransactionCategory = "storage proof"
+)
+
+// The states of a payment request. A request is paid once the full amount has
+// been received, including unconfirmed payments, and confirmed once the
+// payments that cover the amount have the required number of confirmations.
+// Requests that are not paid by their expiry height expire. Confirmed requests
+// are no longer updated, while expired requests still track late payments for
+// a limited number of blocks.
+const (
+	// PaymentRequestUnpaid is a payment request that has not received any
+	// payment.
+	PaymentRequestUnpaid PaymentRequestStatus = "unpaid"
+
+	// PaymentRequestPartiallyPaid is a payment request that has received
+	// less than the requested amount.
+	PaymentRequestPartiallyPaid PaymentRequestStatus = "partially paid"
+
+	// PaymentRequestPaid is a payment request that has received the
+	// requested amount, but not with enough confirmations yet.
+	PaymentRequestPaid PaymentRequestStatus = "paid"
+
+	// PaymentRequestConfirmed is a payment request whose payments have the
+	// required number of confirmations.
+	PaymentRequestConfirmed PaymentRequestStatus = "confirmed"
+
+	// PaymentRequestExpired is a payment request that was not paid before
+	// its expiry height.
+	PaymentRequestExpired PaymentRequestStatus = "expired"
+)
+
+const (
+	// DefaultPaymentRequestExpiry is the default number of blocks after
+	// which an unpaid payment request expires, which is about a day.
+	DefaultPaymentRequestExpiry types.BlockHeight = 144
+
+	// DefaultPaymentRequestConfirmations is the default number of
+	// confirmations that the payments of a payment request need.
+	DefaultPaymentRequestConfirmations = 1
+)
+
+// The states of a scheduled payment. Active payments are made when they are
+// due and the wallet is unlocked. Payments that exceed the approval threshold
+// or the daily limit are held until they are approved.
+const (
+	// ScheduledPaymentActive is a scheduled payment that is made when it is
+	// due.
+	ScheduledPaymentActive ScheduledPaymentStatus = "active"
+
+	// ScheduledPaymentHeld is a scheduled payment that is due, but waits for
+	// the approval of the user.
+	ScheduledPaymentHeld ScheduledPaymentStatus = "held"
+
+	// ScheduledPaymentCompleted is a scheduled payment whose payments have
+	// all been made.
+	ScheduledPaymentCompleted ScheduledPaymentStatus = "completed"
+
+	// ScheduledPaymentCancelled is a scheduled payment that was cancelled by
+	// the user.
+	ScheduledPaymentCancelled ScheduledPaymentStatus = "cancelled"
+)
+
+// The roles of the two parties of an atomic swap. The sender locks siacoins
+// in an output that needs the signatures of both parties, and receives the
+// adaptor secret of the receiver when the receiver claims the siacoins.
+const (
+	// AtomicSwapSender is the party that funds the atomic swap.
+	AtomicSwapSender AtomicSwapRole = "sender"
+
+	// AtomicSwapReceiver is the party that knows the adaptor secret and
+	// claims the siacoins of the atomic swap.
+	AtomicSwapReceiver AtomicSwapRole = "receiver"
+)
+
+// The states of an atomic swap. A swap is negotiated until the sender has
+// the refund transaction signed by the receiver, and funded once the shared
+// output is confirmed. The shared output is spent either by the claim of the
+// receiver or, once the refund height is reached, by the refund of the
+// sender.
+const (
+	// AtomicSwapNegotiating is an atomic swap whose refund transaction has
+	// not been signed yet.
+	AtomicSwapNegotiating AtomicSwapStatus = "negotiating"
+
+	// AtomicSwapFunding is an atomic swap whose shared output has not been
+	// confirmed yet.
+	AtomicSwapFunding AtomicSwapStatus = "funding"
+
+	// AtomicSwapFunded is an atomic swap whose shared output is confirmed.
+	AtomicSwapFunded AtomicSwapStatus = "funded"
+
+	// AtomicSwapClaimed is an atomic swap whose shared output was spent by
+	// the claim of the receiver.
+	AtomicSwapClaimed AtomicSwapStatus = "claimed"
+
+	// AtomicSwapRefunded is an atomic swap whose shared output was spent by
+	// the refund of the sender.
+	AtomicSwapRefunded AtomicSwapStatus = "refunded"
+)
+
 var (
 	// ErrBadEncryptionKey is returned if the incorrect encryption key to a
 	// file is provided.
@@ -55,6 +179,158 @@ type (
 	// WalletTransactionID is a unique identifier for a wallet transaction.
 	WalletTransactionID crypto.Hash
 
+	// TransactionCategory describes what a wallet transaction was for.
+	TransactionCategory string
+
+	// AddressLabel is a label that the user gave to an address, e.g. the
+	// name of the customer that the address belongs to.
+	AddressLabel struct {
+		Address types.UnlockHash `json:"address"`
+		Label   string           `json:"label"`
+	}
+
+	// PaymentRequestStatus describes how far a payment request has been
+	// paid.
+	PaymentRequestStatus string
+
+	// A PaymentRequest asks for a payment of Amount to a dedicated address
+	// of the wallet. The wallet tracks the payments to the address and
+	// updates the status of the request as they arrive and confirm. If a
+	// WebhookURL is set, every event of the request is posted to it.
+	PaymentRequest struct {
+		Address               types.UnlockHash      `json:"address"`
+		Amount                types.Currency        `json:"amount"`
+		Memo                  string                `json:"memo"`
+		WebhookURL            string                `json:"webhookurl"`
+		CreationHeight        types.BlockHeight     `json:"creationheight"`
+		ExpiryHeight          types.BlockHeight     `json:"expiryheight"`
+		RequiredConfirmations uint64                `json:"requiredconfirmations"`
+		Status                PaymentRequestStatus  `json:"status"`
+		Received              types.Currency        `json:"received"`
+		ReceivedConfirmed     types.Currency        `json:"receivedconfirmed"`
+		Confirmations         uint64                `json:"confirmations"`
+		TransactionIDs        []types.TransactionID `json:"transactionids"`
+	}
+
+	// A PaymentRequestEvent records a status change of a payment request, or
+	// a late payment to an expired request.
+	// Events are numbered by a sequence that increases with every event of
+	// the wallet, so that clients can poll for the events they haven't seen.
+	PaymentRequestEvent struct {
+		Sequence       uint64               `json:"sequence"`
+		Timestamp      types.Timestamp      `json:"timestamp"`
+		PreviousStatus PaymentRequestStatus `json:"previousstatus"`
+		PaymentRequest PaymentRequest       `json:"paymentrequest"`
+	}
+
+	// ScheduledPaymentStatus describes whether a scheduled payment is still
+	// made.
+	ScheduledPaymentStatus string
+
+	// A PaymentSchedule describes when a scheduled payment is made. Exactly
+	// one of Height and Time is set. Interval is the number of blocks
+	// between the payments of a Height schedule, or the number of seconds
+	// between the payments of a Time schedule. A schedule without an
+	// Interval is made once. Count limits the number of payments of a
+	// recurring schedule; zero means no limit.
+	PaymentSchedule struct {
+		Height   types.BlockHeight `json:"height"`
+		Time     types.Timestamp   `json:"time"`
+		Interval uint64            `json:"interval"`
+		Count    uint64            `json:"count"`
+	}
+
+	// A ScheduledPayment sends Outputs whenever it is due. The Schedule
+	// holds the height or time of the next payment, and is advanced after
+	// every payment.
+	ScheduledPayment struct {
+		ID         uint64                 `json:"id"`
+		Outputs    []types.SiacoinOutput  `json:"outputs"`
+		Memo       string                 `json:"memo"`
+		Schedule   PaymentSchedule        `json:"schedule"`
+		Payments   uint64                 `json:"payments"`
+		Status     ScheduledPaymentStatus `json:"status"`
+		HoldReason string                 `json:"holdreason"`
+	}
+
+	// A ScheduledPaymentExecution records an attempt to make a scheduled
+	// payment. Executions are numbered by a sequence that increases with
+	// every execution of the wallet. Failed attempts have an Error and no
+	// transactions.
+	ScheduledPaymentExecution struct {
+		Sequence       uint64                `json:"sequence"`
+		PaymentID      uint64                `json:"paymentid"`
+		Timestamp      types.Timestamp       `json:"timestamp"`
+		Height         types.BlockHeight     `json:"height"`
+		Amount         types.Currency        `json:"amount"`
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+		Error          string                `json:"error"`
+	}
+
+	// ScheduledPaymentLimits restrict the scheduled payments that the wallet
+	// makes without approval. A payment whose amount exceeds the
+	// ApprovalThreshold, or that would bring the amount of the scheduled
+	// payments of the last 24 hours over the DailyLimit, is held. Zero
+	// values mean no limit.
+	ScheduledPaymentLimits struct {
+		ApprovalThreshold types.Currency `json:"approvalthreshold"`
+		DailyLimit        types.Currency `json:"dailylimit"`
+	}
+
+	// AtomicSwapRole is the role of the wallet in an atomic swap.
+	AtomicSwapRole string
+
+	// AtomicSwapStatus describes how far an atomic swap has progressed.
+	AtomicSwapStatus string
+
+	// An AtomicSwapRequest is sent by the receiver of an atomic swap to the
+	// sender. It contains the key of the receiver, the adaptor point whose
+	// secret is revealed by the claim, and the address that the claim pays
+	// to.
+	AtomicSwapRequest struct {
+		ReceiverKey  types.SiaPublicKey  `json:"receiverkey"`
+		AdaptorPoint crypto.AdaptorPoint `json:"adaptorpoint"`
+		ClaimAddress types.UnlockHash    `json:"claimaddress"`
+	}
+
+	// An AtomicSwapOffer is the answer of the sender to an AtomicSwapRequest.
+	// The shared output OutputID holds Amount plus Fee, the miner fee of the
+	// claim and refund transactions. The refund transaction pays to the
+	// RefundAddress and is valid from the RefundHeight on.
+	AtomicSwapOffer struct {
+		ID            crypto.Hash           `json:"id"`
+		SenderKey     types.SiaPublicKey    `json:"senderkey"`
+		Amount        types.Currency        `json:"amount"`
+		Fee           types.Currency        `json:"fee"`
+		RefundHeight  types.BlockHeight     `json:"refundheight"`
+		RefundAddress types.UnlockHash      `json:"refundaddress"`
+		OutputID      types.SiacoinOutputID `json:"outputid"`
+	}
+
+	// An AtomicSwap is an atomic swap of the wallet, in either role. The
+	// sender keeps the transactions that fund the swap and the signed refund
+	// transaction. The Secret is known to the sender once the swap is
+	// claimed, and to the receiver once it claims the swap.
+	AtomicSwap struct {
+		ID                    crypto.Hash             `json:"id"`
+		Role                  AtomicSwapRole          `json:"role"`
+		Status                AtomicSwapStatus        `json:"status"`
+		SenderKey             types.SiaPublicKey      `json:"senderkey"`
+		ReceiverKey           types.SiaPublicKey      `json:"receiverkey"`
+		AdaptorPoint          crypto.AdaptorPoint     `json:"adaptorpoint"`
+		Amount                types.Currency          `json:"amount"`
+		Fee                   types.Currency          `json:"fee"`
+		RefundHeight          types.BlockHeight       `json:"refundheight"`
+		ClaimAddress          types.UnlockHash        `json:"claimaddress"`
+		RefundAddress         types.UnlockHash        `json:"refundaddress"`
+		OutputID              types.SiacoinOutputID   `json:"outputid"`
+		FundingTransactions   []types.Transaction     `json:"fundingtransactions"`
+		RefundTransaction     types.Transaction       `json:"refundtransaction"`
+		ClaimAdaptorSignature crypto.AdaptorSignature `json:"claimadaptorsignature"`
+		SpendTransactionID    types.TransactionID     `json:"spendtransactionid"`
+		Secret                crypto.AdaptorSecret    `json:"secret"`
+	}
+
 	// A ProcessedInput represents funding to a transaction. The input is
 	// coming from an address and going to the outputs. The fund types are
 	// 'SiacoinInput', 'SiafundInput'.
@@ -102,6 +378,18 @@ type (
 
 		Inputs  []ProcessedInput  `json:"inputs"`
 		Outputs []ProcessedOutput `json:"outputs"`
+
+		// BumpsTransactionID is the ID of the unconfirmed transaction whose
+		// fee this transaction bumps by spending one of its outputs, or zero
+		// if the wallet didn't create it to bump a fee. BumpedBy contains
+		// the IDs of the transactions that bump the fee of this one.
+		BumpsTransactionID types.TransactionID   `json:"bumpstransactionid"`
+		BumpedBy           []types.TransactionID `json:"bumpedby"`
+
+		// Note is the note that the user added to the transaction, and
+		// Categories are derived from its content.
+		Note       string                `json:"note"`
+		Categories []TransactionCategory `json:"categories"`
 	}
 
 	// A UnspentOutput is a SiacoinOutput or SiafundOutput that the wallet
@@ -115,6 +403,63 @@ type (
 		IsWatchOnly        bool              `json:"iswatchonly"`
 	}
 
+	// MultisigAddress is an M-of-N address that the wallet tracks. The public
+	// keys of the unlock conditions are a mix of keys of the wallet and keys
+	// of co-signers, LocalKeys contains the indices of the keys of the
+	// wallet. The balances only include confirmed outputs.
+	MultisigAddress struct {
+		Address          types.UnlockHash       `json:"address"`
+		UnlockConditions types.UnlockConditions `json:"unlockconditions"`
+		LocalKeys        []uint64               `json:"localkeys"`
+		SiacoinBalance   types.Currency         `json:"siacoinbalance"`
+		SiafundBalance   types.Currency         `json:"siafundbalance"`
+	}
+
+	// MultisigTransaction is a transaction that spends the outputs of a
+	// multisig address, together with whether it has collected enough valid
+	// signatures to be broadcast.
+	MultisigTransaction struct {
+		Transaction types.Transaction `json:"transaction"`
+		Complete    bool              `json:"complete"`
+	}
+
+	// A WatchOnlyKeySet contains public keys of the primary seed of a cold
+	// wallet, in the order of their seed index starting at StartIndex. A
+	// watch-only wallet tracks the standard single-signature addresses of the
+	// keys without ever learning the secret keys.
+	WatchOnlyKeySet struct {
+		StartIndex uint64               `json:"startindex"`
+		PublicKeys []types.SiaPublicKey `json:"publickeys"`
+	}
+
+	// WatchOnlyStatus reports the state of the watch-only keys of a wallet.
+	// Keys is the number of imported keys and Progress the number of keys
+	// that have been used, which the wallet tracks. The wallet also tracks
+	// the Lookahead keys that follow them, to notice when they are used.
+	// KeysNeeded is the number of keys that the cold wallet should export to
+	// fill the lookahead. The balances only include confirmed outputs.
+	// WatchOnlyWallet is true if the wallet has no seed of its own.
+	WatchOnlyStatus struct {
+		WatchOnlyWallet bool           `json:"watchonlywallet"`
+		Keys            uint64         `json:"keys"`
+		Progress        uint64         `json:"progress"`
+		Lookahead       uint64         `json:"lookahead"`
+		KeysNeeded      uint64         `json:"keysneeded"`
+		SiacoinBalance  types.Currency `json:"siacoinbalance"`
+		SiafundBalance  types.Currency `json:"siafundbalance"`
+	}
+
+	// SiacoinSelection describes the outputs that fund a transaction when
+	// they are chosen by the caller instead of the wallet. Outputs are spent
+	// largest first until the amount is covered, or all of them are spent if
+	// SpendAll is set. The change is sent to ChangeAddress, or to a new
+	// address of the wallet if it is the zero address.
+	SiacoinSelection struct {
+		Outputs       []types.SiacoinOutputID `json:"outputs"`
+		ChangeAddress types.UnlockHash        `json:"changeaddress"`
+		SpendAll      bool                    `json:"spendall"`
+	}
+
 	// TransactionBuilder is used to construct custom transactions. A transaction
 	// builder is initialized via 'RegisterTransaction' and then can be modified by
 	// adding funds or other fields. The transaction is completed by calling
@@ -135,6 +480,14 @@ type (
 		// transaction failed.
 		FundSiacoins(amount types.Currency) error
 
+		// FundSiacoinsFromOutputs adds the selected outputs of the wallet as
+		// siacoin inputs of the transaction, and a change output for
+		// anything they hold beyond 'amount'. Unlike FundSiacoins, no parent
+		// transaction is created, so the inputs are not mixed with other
+		// outputs of the wallet. The inputs will not be signed until 'Sign'
+		// is called on the transaction builder.
+		FundSiacoinsFromOutputs(amount types.Currency, selection SiacoinSelection) error
+
 		// FundSiafunds will add a siafund input of exactly 'amount' to the
 		// transaction. A parent transaction may be needed to achieve an input
 		// with the correct value. The siafund input will not be signed until
@@ -349,12 +702,194 @@ type (
 		SweepSeed(seed Seed) (coins, funds types.Currency, err error)
 	}
 
+	// MultisigManager creates M-of-N addresses from public keys of the wallet
+	// and of co-signers, and builds and co-signs transactions that spend
+	// from them. Outputs of multisig addresses are tracked by the wallet, but
+	// they are never used to fund regular transactions.
+	MultisigManager interface {
+		// CreateMultisigAddress creates an address that requires the given
+		// number of signatures from the public keys, and starts tracking it.
+		// At least one of the keys must belong to the wallet. All co-signers
+		// must use the same keys in the same order. If the address has not
+		// appeared in the blockchain, the unused flag may be set to true.
+		// Otherwise, the wallet rescans the blockchain.
+		CreateMultisigAddress(required uint64, publicKeys []types.SiaPublicKey, unused bool) (MultisigAddress, error)
+
+		// MergeMultisigTransactions combines the signatures of copies of the
+		// same transaction that were signed by different co-signers.
+		MergeMultisigTransactions(txns []types.Transaction) (MultisigTransaction, error)
+
+		// MultisigAddresses returns the multisig addresses that the wallet
+		// tracks.
+		MultisigAddresses() ([]MultisigAddress, error)
+
+		// MultisigPublicKey returns a new public key of the wallet that can
+		// be shared with co-signers to create a multisig address.
+		MultisigPublicKey() (types.SiaPublicKey, error)
+
+		// NewMultisigTransaction creates a transaction that sends the outputs
+		// from the multisig address, paying the fee and returning the change
+		// to the address, and signs it with the keys of the wallet.
+		NewMultisigTransaction(addr types.UnlockHash, outputs []types.SiacoinOutput, fee types.Currency) (MultisigTransaction, error)
+
+		// SignMultisigTransaction adds the signatures of the wallet to the
+		// inputs of the transaction that spend from multisig addresses.
+		SignMultisigTransaction(txn types.Transaction) (MultisigTransaction, error)
+	}
+
+	// PartialTransactionSigner creates, signs, combines and finalizes
+	// partially signed transactions, which carry the metadata that offline
+	// and multi-party signers need to check what they sign.
+	PartialTransactionSigner interface {
+		// CombinePartialTransactions combines copies of the same partially
+		// signed transaction that were signed by different parties.
+		CombinePartialTransactions(pts []types.PartialTransaction) (types.PartialTransaction, error)
+
+		// FinalizePartialTransaction checks that the partially signed
+		// transaction is fully signed and valid, and returns it together
+		// with its parents, ready to be broadcast.
+		FinalizePartialTransaction(pt types.PartialTransaction) ([]types.Transaction, error)
+
+		// NewPartialTransaction creates a partially signed transaction for
+		// txn, and fills in the values, parents and key hints of the inputs
+		// that the wallet knows.
+		NewPartialTransaction(txn types.Transaction, parents []types.Transaction) (types.PartialTransaction, error)
+
+		// SignPartialTransaction checks the metadata of the inputs that the
+		// wallet knows, and adds the signatures of the wallet to every input
+		// that it holds keys for.
+		SignPartialTransaction(pt types.PartialTransaction) (types.PartialTransaction, error)
+	}
+
+	// WatchOnlyManager exports the public keys of a cold wallet, and tracks
+	// them in a watch-only wallet that builds unsigned transactions for the
+	// cold wallet to sign. Outputs of watch-only keys are reported by
+	// WatchOnlyStatus; they are not part of the regular balance and are never
+	// used to fund regular transactions.
+	WatchOnlyManager interface {
+		// ExportWatchOnlyKeys returns n public keys of the primary seed,
+		// starting at seed index start. The keys are marked as used, so
+		// that the wallet can sign for all of them.
+		ExportWatchOnlyKeys(start, n uint64) (WatchOnlyKeySet, error)
+
+		// ImportWatchOnlyKeys adds keys exported by a cold wallet to the
+		// watch-only keys of the wallet. The keys have to continue or
+		// overlap the imported keys. If none of the keys have appeared in
+		// the blockchain, the unused flag may be set to true. Otherwise,
+		// the wallet rescans the blockchain.
+		ImportWatchOnlyKeys(keys WatchOnlyKeySet, unused bool) error
+
+		// InitWatchOnly initializes the wallet as a watch-only wallet,
+		// which has no seed and never holds secret keys. It can only
+		// track imported watch-only keys.
+		InitWatchOnly(masterKey crypto.TwofishKey) error
+
+		// NewWatchOnlyTransaction creates an unsigned transaction that sends
+		// the outputs from the watch-only keys, paying the fee and returning
+		// the change to the next watch-only key. The cold wallet signs it.
+		NewWatchOnlyTransaction(outputs []types.SiacoinOutput, fee types.Currency) (types.PartialTransaction, error)
+
+		// WatchOnlyStatus reports the state of the watch-only keys.
+		WatchOnlyStatus() (WatchOnlyStatus, error)
+	}
+
+	// PaymentRequestManager creates payment requests and tracks their
+	// payments.
+	PaymentRequestManager interface {
+		// NewPaymentRequest creates a payment request for amount to a new
+		// address of the wallet. The request expires if it is not paid
+		// within expiry blocks, and is confirmed once its payments have the
+		// required number of confirmations. If webhookURL is not empty,
+		// status changes are posted to it.
+		NewPaymentRequest(amount types.Currency, expiry types.BlockHeight, confirmations uint64, memo, webhookURL string) (PaymentRequest, error)
+
+		// PaymentRequest returns the payment request of an address.
+		PaymentRequest(addr types.UnlockHash) (PaymentRequest, error)
+
+		// PaymentRequests returns all payment requests, oldest first.
+		PaymentRequests() ([]PaymentRequest, error)
+
+		// PaymentRequestEvents returns the recent status changes of the
+		// payment requests with a sequence number greater than since.
+		PaymentRequestEvents(since uint64) ([]PaymentRequestEvent, error)
+	}
+
+	// ScheduledPaymentManager stores scheduled and recurring payments, and
+	// makes them when they are due and the wallet is unlocked.
+	ScheduledPaymentManager interface {
+		// ApproveScheduledPayment makes a held scheduled payment.
+		ApproveScheduledPayment(id uint64) (ScheduledPaymentExecution, error)
+
+		// CancelScheduledPayment cancels a scheduled payment.
+		CancelScheduledPayment(id uint64) error
+
+		// NewScheduledPayment schedules a payment of the outputs.
+		NewScheduledPayment(outputs []types.SiacoinOutput, schedule PaymentSchedule, memo string) (ScheduledPayment, error)
+
+		// ScheduledPaymentExecutions returns the recent attempts to make
+		// scheduled payments with a sequence number greater than since.
+		ScheduledPaymentExecutions(since uint64) ([]ScheduledPaymentExecution, error)
+
+		// ScheduledPaymentLimits returns the limits of the scheduled
+		// payments.
+		ScheduledPaymentLimits() (ScheduledPaymentLimits, error)
+
+		// ScheduledPayments returns all scheduled payments, oldest first.
+		ScheduledPayments() ([]ScheduledPayment, error)
+
+		// SetScheduledPaymentLimits sets the limits of the scheduled
+		// payments.
+		SetScheduledPaymentLimits(ScheduledPaymentLimits) error
+	}
+
+	// AtomicSwapManager negotiates atomic swaps through a 2-of-2 output with
+	// a timelocked refund, which the receiver claims with an adaptor
+	// signature that reveals its secret to the sender.
+	AtomicSwapManager interface {
+		// AcceptAtomicSwap accepts the offer of a sender to a request of
+		// the wallet, and returns the signature of the wallet for the
+		// refund transaction.
+		AcceptAtomicSwap(offer AtomicSwapOffer) (crypto.Signature, error)
+
+		// AtomicSwap returns the atomic swap with the given ID.
+		AtomicSwap(id crypto.Hash) (AtomicSwap, error)
+
+		// AtomicSwaps returns all atomic swaps of the wallet.
+		AtomicSwaps() ([]AtomicSwap, error)
+
+		// ClaimAtomicSwap completes the adaptor signature of the sender and
+		// broadcasts the claim transaction.
+		ClaimAtomicSwap(id crypto.Hash, asig crypto.AdaptorSignature) (types.Transaction, error)
+
+		// FundAtomicSwap adds the refund signature of the receiver to an
+		// offer of the wallet and broadcasts the funding transactions.
+		FundAtomicSwap(id crypto.Hash, refundSig crypto.Signature) error
+
+		// OfferAtomicSwap offers to send amount to the receiver of the
+		// request, refundable from refundHeight on.
+		OfferAtomicSwap(req AtomicSwapRequest, amount types.Currency, refundHeight types.BlockHeight) (AtomicSwapOffer, error)
+
+		// PresignAtomicSwapClaim returns the adaptor signature of the
+		// wallet for the claim transaction of a funded swap.
+		PresignAtomicSwapClaim(id crypto.Hash) (crypto.AdaptorSignature, error)
+
+		// RequestAtomicSwap starts an atomic swap in which the wallet
+		// receives siacoins.
+		RequestAtomicSwap() (AtomicSwapRequest, error)
+	}
+
 	// Wallet stores and manages siacoins and siafunds. The wallet file is
 	// encrypted using a user-specified password. Common addresses are all
 	// derived from a single address seed.
 	Wallet interface {
+		AtomicSwapManager
 		EncryptionManager
 		KeyManager
+		MultisigManager
+		PartialTransactionSigner
+		PaymentRequestManager
+		ScheduledPaymentManager
+		WatchOnlyManager
 
 		// AddUnlockConditions adds a set of UnlockConditions to the wallet database.
 		AddUnlockConditions(uc types.UnlockConditions) error
@@ -406,6 +941,17 @@ type (
 		// relative to the wallet.
 		UnconfirmedTransactions() ([]ProcessedTransaction, error)
 
+		// AddressLabels returns the labels of all labeled addresses.
+		AddressLabels() ([]AddressLabel, error)
+
+		// SetAddressLabel sets the label of an address, which doesn't have
+		// to belong to the wallet. An empty label removes the label.
+		SetAddressLabel(addr types.UnlockHash, label string) error
+
+		// SetTransactionNote sets the note of a confirmed or unconfirmed
+		// transaction of the wallet. An empty note removes the note.
+		SetTransactionNote(txid types.TransactionID, note string) error
+
 		// RegisterTransaction takes a transaction and its parents and returns
 		// a TransactionBuilder which can be used to expand the transaction.
 		RegisterTransaction(t types.Transaction, parents []types.Transaction) (TransactionBuilder, error)
@@ -440,6 +986,21 @@ type (
 		// SendSiacoinsMulti sends coins to multiple addresses.
 		SendSiacoinsMulti(outputs []types.SiacoinOutput) ([]types.Transaction, error)
 
+		// SendSiacoinsFromOutputs is like SendSiacoins, but the transaction
+		// is funded by the selected outputs only.
+		SendSiacoinsFromOutputs(amount types.Currency, dest types.UnlockHash, selection SiacoinSelection) ([]types.Transaction, error)
+
+		// SendSiacoinsMultiFromOutputs is like SendSiacoinsMulti, but the
+		// transaction is funded by the selected outputs only.
+		SendSiacoinsMultiFromOutputs(outputs []types.SiacoinOutput, selection SiacoinSelection) ([]types.Transaction, error)
+
+		// BumpTransaction raises the fee of an unconfirmed transaction of the
+		// wallet by broadcasting a child transaction that spends one of its
+		// outputs, or an output of its unconfirmed ancestors, with a higher
+		// fee. The child is returned together with its
+		// unconfirmed ancestors.
+		BumpTransaction(txid types.TransactionID) ([]types.Transaction, error)
+
 		// SendSiafunds is a tool for sending siafunds from the wallet to an
 		// address. Sending money usually results in multiple transactions. The
 		// transactions are automatically given to the transaction pool, and
@@ -474,6 +1035,41 @@ func CalculateWalletTransactionID(tid types.TransactionID, oid types.OutputID) W
 	return WalletTransactionID(crypto.HashAll(tid, oid))
 }
 
+// TransactionCategories derives the categories of a wallet transaction from
+// its content.
+func TransactionCategories(pt ProcessedTransaction) (categories []TransactionCategory) {
+	txn := pt.Transaction
+	if len(txn.FileContracts) > 0 {
+		categories = append(categories, TransactionCategoryContractFormation)
+	}
+	if len(txn.FileContractRevisions) > 0 {
+		categories = append(categories, TransactionCategoryContractRevision)
+	}
+	if pt.BumpsTransactionID != (types.TransactionID{}) {
+		categories = append(categories, TransactionCategoryFeeBump)
+	}
+	for _, output := range pt.Outputs {
+		if output.FundType == types.SpecifierMinerPayout {
+			categories = append(categories, TransactionCategoryMinerPayout)
+			break
+		}
+	}
+	for _, output := range pt.Outputs {
+		if output.FundType == types.SpecifierClaimOutput {
+			categories = append(categories, TransactionCategorySiafundClaim)
+			break
+		}
+	}
+	proof := len(txn.StorageProofs) > 0
+	for _, output := range pt.Outputs {
+		proof = proof || output.FundType == types.SpecifierStorageProofOutput
+	}
+	if proof {
+		categories = append(categories, TransactionCategoryStorageProof)
+	}
+	return categories
+}
+
 // SeedToString converts a wallet seed to a human friendly string.
 func SeedToString(seed Seed, did mnemonics.DictionaryID) (string, error) {
 	fullChecksum := crypto.HashObject(seed)
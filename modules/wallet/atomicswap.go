@@ -0,0 +1,671 @@
+package wallet
+
+import (
+	"bytes"
+	"sort"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+
+	"github.com/coreos/bbolt"
+)
+
+// An atomic swap locks the siacoins of the sender in a shared output that
+// needs the signatures of both parties. Before the sender funds the output,
+// the receiver signs a refund transaction whose signatures are timelocked to
+// the refund height, so the sender can't lose its siacoins if the receiver
+// disappears. Consensus has no hash-lock, so the claim of the receiver is tied
+// to its secret through an adaptor signature: the sender signs the claim
+// transaction encrypted with the adaptor point of the receiver, which the
+// receiver can only complete with its secret. Once the claim is published,
+// the sender recovers the secret from the completed signature, and can use
+// it to claim the other side of the swap on another chain.
+
+const (
+	// atomicSwapTransactionSize is the estimated size in bytes of the claim
+	// and refund transactions of an atomic swap, which is used to estimate
+	// their miner fee.
+	atomicSwapTransactionSize = 500
+)
+
+var (
+	// atomicSwapSecretSpecifier is used to derive the adaptor secret of the
+	// receiver from the secret key of its swap key.
+	atomicSwapSecretSpecifier = types.Specifier{'a', 't', 'o', 'm', 'i', 'c', ' ', 's', 'w', 'a', 'p'}
+)
+
+var (
+	// errAtomicSwapExists is returned if the wallet already has an atomic
+	// swap with the ID of a new swap.
+	errAtomicSwapExists = errors.New("atomic swap already exists")
+
+	// errAtomicSwapExpired is returned when claiming an atomic swap, or
+	// presigning its claim, once its refund height is reached.
+	errAtomicSwapExpired = errors.New("atomic swap has reached its refund height")
+
+	// errAtomicSwapKey is returned if the key of an atomic swap is not a
+	// valid ed25519 key.
+	errAtomicSwapKey = errors.New("atomic swap keys must be ed25519 keys")
+
+	// errAtomicSwapNotFunded is returned when claiming an atomic swap whose
+	// shared output is not confirmed.
+	errAtomicSwapNotFunded = errors.New("atomic swap is not funded")
+
+	// errAtomicSwapRefundHeight is returned if the refund height of an
+	// atomic swap is not in the future.
+	errAtomicSwapRefundHeight = errors.New("refund height must be in the future")
+
+	// errAtomicSwapRole is returned if the wallet doesn't have the role in
+	// an atomic swap that the action needs.
+	errAtomicSwapRole = errors.New("wallet has the wrong role in the atomic swap")
+
+	// errAtomicSwapStatus is returned if an atomic swap is not in the status
+	// that the action needs.
+	errAtomicSwapStatus = errors.New("atomic swap is not in the right status")
+
+	// errInvalidAtomicSwapOffer is returned if an offer doesn't match the
+	// request of the wallet.
+	errInvalidAtomicSwapOffer = errors.New("atomic swap offer does not match the request")
+
+	// errInvalidRefundSignature is returned if the receiver's signature of
+	// the refund transaction is invalid.
+	errInvalidRefundSignature = errors.New("invalid refund signature")
+
+	// errUnknownAtomicSwap is returned if the wallet has no atomic swap with
+	// an ID.
+	errUnknownAtomicSwap = errors.New("no atomic swap with that id")
+
+	// errZeroAtomicSwapAmount is returned if an atomic swap has no value.
+	errZeroAtomicSwapAmount = errors.New("atomic swap amount must be greater than zero")
+)
+
+// atomicSwapID returns the ID of the atomic swap of a request.
+func atomicSwapID(req modules.AtomicSwapRequest) crypto.Hash {
+	return crypto.HashAll(req.ReceiverKey, req.AdaptorPoint)
+}
+
+// atomicSwapUnlockConditions returns the unlock conditions of the shared
+// output of an atomic swap. The sender signs with the first key, the receiver
+// with the second.
+func atomicSwapUnlockConditions(swap modules.AtomicSwap) types.UnlockConditions {
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{swap.SenderKey, swap.ReceiverKey},
+		SignaturesRequired: 2,
+	}
+}
+
+// atomicSwapTransaction returns the unsigned transaction that spends the
+// shared output of an atomic swap to dest. The claim and refund transactions
+// only differ in their destination and the timelock of their signatures, so
+// both parties can build them independently.
+func atomicSwapTransaction(swap modules.AtomicSwap, dest types.UnlockHash, timelock types.BlockHeight) types.Transaction {
+	parentID := crypto.Hash(swap.OutputID)
+	return types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         swap.OutputID,
+			UnlockConditions: atomicSwapUnlockConditions(swap),
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      swap.Amount,
+			UnlockHash: dest,
+		}},
+		MinerFees: []types.Currency{swap.Fee},
+		TransactionSignatures: []types.TransactionSignature{
+			{ParentID: parentID, PublicKeyIndex: 0, Timelock: timelock, CoveredFields: types.FullCoveredFields},
+			{ParentID: parentID, PublicKeyIndex: 1, Timelock: timelock, CoveredFields: types.FullCoveredFields},
+		},
+	}
+}
+
+// atomicSwapClaim returns the unsigned claim transaction of an atomic swap.
+func atomicSwapClaim(swap modules.AtomicSwap) types.Transaction {
+	return atomicSwapTransaction(swap, swap.ClaimAddress, 0)
+}
+
+// atomicSwapRefund returns the unsigned refund transaction of an atomic swap.
+func atomicSwapRefund(swap modules.AtomicSwap) types.Transaction {
+	return atomicSwapTransaction(swap, swap.RefundAddress, swap.RefundHeight)
+}
+
+// atomicSwapPublicKey returns the ed25519 public key of a swap key.
+func atomicSwapPublicKey(spk types.SiaPublicKey) (pk crypto.PublicKey, err error) {
+	if spk.Algorithm != types.SignatureEd25519 || len(spk.Key) != crypto.PublicKeySize {
+		return crypto.PublicKey{}, errAtomicSwapKey
+	}
+	copy(pk[:], spk.Key)
+	return pk, nil
+}
+
+// atomicSwapSecret derives the adaptor secret of the receiver from the secret
+// key of its swap key, so that the secret doesn't have to be stored.
+func atomicSwapSecret(sk crypto.SecretKey) (crypto.AdaptorSecret, crypto.AdaptorPoint) {
+	return crypto.GenerateAdaptorSecret(crypto.HashAll(atomicSwapSecretSpecifier, sk))
+}
+
+// newAtomicSwapKey returns a new seed key of the wallet to sign an atomic
+// swap with, and the address of the key, which the swap pays to.
+func (w *Wallet) newAtomicSwapKey(tx *bolt.Tx) (types.SiaPublicKey, crypto.SecretKey, types.UnlockHash, error) {
+	uc, err := w.nextPrimarySeedAddress(tx)
+	if err != nil {
+		return types.SiaPublicKey{}, crypto.SecretKey{}, types.UnlockHash{}, err
+	}
+	spk := uc.PublicKeys[0]
+	sk, ok := w.multisigSecretKey(spk)
+	if !ok {
+		return types.SiaPublicKey{}, crypto.SecretKey{}, types.UnlockHash{}, errNoLocalMultisigKey
+	}
+	return spk, sk, uc.UnlockHash(), nil
+}
+
+// atomicSwapSecretKey returns the secret key of the wallet's swap key.
+func (w *Wallet) atomicSwapSecretKey(swap modules.AtomicSwap) (crypto.SecretKey, error) {
+	spk := swap.SenderKey
+	if swap.Role == modules.AtomicSwapReceiver {
+		spk = swap.ReceiverKey
+	}
+	sk, ok := w.multisigSecretKey(spk)
+	if !ok {
+		return crypto.SecretKey{}, errNoLocalMultisigKey
+	}
+	return sk, nil
+}
+
+// managedAtomicSwap loads an atomic swap of the wallet with the role for an
+// action. It also returns the secret key of the swap and the consensus
+// height.
+func (w *Wallet) managedAtomicSwap(id crypto.Hash, role modules.AtomicSwapRole) (modules.AtomicSwap, crypto.SecretKey, types.BlockHeight, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, modules.ErrLockedWallet
+	}
+	swap, err := dbGetAtomicSwap(w.dbTx, id)
+	if err == errNoKey {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, errUnknownAtomicSwap
+	} else if err != nil {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, err
+	} else if swap.Role != role {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, errAtomicSwapRole
+	}
+	sk, err := w.atomicSwapSecretKey(swap)
+	if err != nil {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, err
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.AtomicSwap{}, crypto.SecretKey{}, 0, err
+	}
+	return swap, sk, height, nil
+}
+
+// managedPutAtomicSwap stores an atomic swap.
+func (w *Wallet) managedPutAtomicSwap(swap modules.AtomicSwap) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if err := dbPutAtomicSwap(w.dbTx, swap); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// spendAtomicSwap updates an atomic swap whose shared output was spent by
+// txn. If the swap was claimed, the sender recovers the adaptor secret from
+// the completed signature of the claim.
+func (w *Wallet) spendAtomicSwap(swap *modules.AtomicSwap, txn types.Transaction) {
+	swap.Status = modules.AtomicSwapRefunded
+	swap.SpendTransactionID = txn.ID()
+	for _, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == swap.ClaimAddress {
+			swap.Status = modules.AtomicSwapClaimed
+		}
+	}
+	if swap.Status != modules.AtomicSwapClaimed || swap.Role != modules.AtomicSwapSender {
+		return
+	}
+	for _, ts := range txn.TransactionSignatures {
+		if ts.ParentID != crypto.Hash(swap.OutputID) || ts.PublicKeyIndex != 0 || len(ts.Signature) != crypto.SignatureSize {
+			continue
+		}
+		var sig crypto.Signature
+		copy(sig[:], ts.Signature)
+		secret, err := swap.ClaimAdaptorSignature.RecoverSecret(sig, swap.AdaptorPoint)
+		if err != nil {
+			w.log.Printf("Unable to recover the secret of atomic swap %v: %v", swap.ID, err)
+			continue
+		}
+		swap.Secret = secret
+		w.log.Printf("Atomic swap %v was claimed, recovered its secret", swap.ID)
+	}
+}
+
+// updateAtomicSwaps updates the atomic swaps whose shared output was created
+// or spent by the blocks of a consensus change.
+func (w *Wallet) updateAtomicSwaps(tx *bolt.Tx, cc modules.ConsensusChange) error {
+	swaps := make(map[types.SiacoinOutputID]*modules.AtomicSwap)
+	err := dbForEachAtomicSwap(tx, func(_ crypto.Hash, swap modules.AtomicSwap) {
+		if swap.Status != modules.AtomicSwapNegotiating {
+			swaps[swap.OutputID] = &swap
+		}
+	})
+	if err != nil || len(swaps) == 0 {
+		return err
+	}
+	changed := make(map[types.SiacoinOutputID]struct{})
+	for _, block := range cc.RevertedBlocks {
+		for _, txn := range block.Transactions {
+			for _, sci := range txn.SiacoinInputs {
+				if swap, ok := swaps[sci.ParentID]; ok {
+					swap.Status, swap.SpendTransactionID = modules.AtomicSwapFunded, types.TransactionID{}
+					changed[sci.ParentID] = struct{}{}
+				}
+			}
+			for i := range txn.SiacoinOutputs {
+				if swap, ok := swaps[txn.SiacoinOutputID(uint64(i))]; ok {
+					swap.Status = modules.AtomicSwapFunding
+					changed[swap.OutputID] = struct{}{}
+				}
+			}
+		}
+	}
+	for _, block := range cc.AppliedBlocks {
+		for _, txn := range block.Transactions {
+			for i, sco := range txn.SiacoinOutputs {
+				swap, ok := swaps[txn.SiacoinOutputID(uint64(i))]
+				if !ok {
+					continue
+				}
+				// The shared output has to pay the full amount to the
+				// 2-of-2 address, otherwise the claim and refund
+				// transactions are invalid.
+				if sco.UnlockHash != atomicSwapUnlockConditions(*swap).UnlockHash() || !sco.Value.Equals(swap.Amount.Add(swap.Fee)) {
+					w.log.Printf("Shared output of atomic swap %v does not match the swap", swap.ID)
+					continue
+				}
+				swap.Status = modules.AtomicSwapFunded
+				changed[swap.OutputID] = struct{}{}
+			}
+			for _, sci := range txn.SiacoinInputs {
+				if swap, ok := swaps[sci.ParentID]; ok {
+					w.spendAtomicSwap(swap, txn)
+					changed[sci.ParentID] = struct{}{}
+				}
+			}
+		}
+	}
+	for id := range changed {
+		if err := dbPutAtomicSwap(tx, *swaps[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// threadedRefundAtomicSwaps broadcasts the refund transactions of the funded
+// atomic swaps of the wallet that have reached their refund height.
+func (w *Wallet) threadedRefundAtomicSwaps() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	w.mu.Lock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		w.mu.Unlock()
+		w.log.Println("Unable to get the consensus height:", err)
+		return
+	}
+	var refunds []modules.AtomicSwap
+	err = dbForEachAtomicSwap(w.dbTx, func(_ crypto.Hash, swap modules.AtomicSwap) {
+		if swap.Role == modules.AtomicSwapSender && swap.Status == modules.AtomicSwapFunded && height >= swap.RefundHeight {
+			refunds = append(refunds, swap)
+		}
+	})
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("Unable to load the atomic swaps:", err)
+		return
+	}
+
+	for _, swap := range refunds {
+		err := w.tpool.AcceptTransactionSet([]types.Transaction{swap.RefundTransaction})
+		if err == modules.ErrDuplicateTransactionSet {
+			continue
+		} else if err != nil {
+			w.log.Printf("Unable to broadcast the refund of atomic swap %v: %v", swap.ID, err)
+			continue
+		}
+		w.log.Printf("Broadcast the refund of atomic swap %v in transaction %v", swap.ID, swap.RefundTransaction.ID())
+	}
+}
+
+// AcceptAtomicSwap accepts the offer of a sender to a request of the wallet.
+// It returns the signature of the wallet for the refund transaction, which the
+// sender needs before it funds the swap.
+func (w *Wallet) AcceptAtomicSwap(offer modules.AtomicSwapOffer) (crypto.Signature, error) {
+	if err := w.tg.Add(); err != nil {
+		return crypto.Signature{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if _, err := atomicSwapPublicKey(offer.SenderKey); err != nil {
+		return crypto.Signature{}, err
+	} else if offer.Amount.IsZero() {
+		return crypto.Signature{}, errZeroAtomicSwapAmount
+	}
+
+	swap, sk, height, err := w.managedAtomicSwap(offer.ID, modules.AtomicSwapReceiver)
+	if err != nil {
+		return crypto.Signature{}, err
+	} else if swap.Status != modules.AtomicSwapNegotiating {
+		return crypto.Signature{}, errAtomicSwapStatus
+	} else if offer.RefundHeight <= height {
+		return crypto.Signature{}, errAtomicSwapRefundHeight
+	} else if bytes.Equal(offer.SenderKey.Key, swap.ReceiverKey.Key) || offer.RefundAddress == swap.ClaimAddress {
+		return crypto.Signature{}, errInvalidAtomicSwapOffer
+	}
+	swap.SenderKey = offer.SenderKey
+	swap.Amount = offer.Amount
+	swap.Fee = offer.Fee
+	swap.RefundHeight = offer.RefundHeight
+	swap.RefundAddress = offer.RefundAddress
+	swap.OutputID = offer.OutputID
+	swap.Status = modules.AtomicSwapFunding
+
+	refund := atomicSwapRefund(swap)
+	sig := crypto.SignHash(refund.SigHash(1, height), sk)
+	if err := w.managedPutAtomicSwap(swap); err != nil {
+		return crypto.Signature{}, err
+	}
+	return sig, nil
+}
+
+// AtomicSwap returns the atomic swap with the given ID.
+func (w *Wallet) AtomicSwap(id crypto.Hash) (modules.AtomicSwap, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.AtomicSwap{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	swap, err := dbGetAtomicSwap(w.dbTx, id)
+	if err == errNoKey {
+		return modules.AtomicSwap{}, errUnknownAtomicSwap
+	}
+	return swap, err
+}
+
+// AtomicSwaps returns all atomic swaps of the wallet, ordered by their refund
+// height.
+func (w *Wallet) AtomicSwaps() ([]modules.AtomicSwap, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var swaps []modules.AtomicSwap
+	err := dbForEachAtomicSwap(w.dbTx, func(_ crypto.Hash, swap modules.AtomicSwap) {
+		swaps = append(swaps, swap)
+	})
+	sort.SliceStable(swaps, func(i, j int) bool {
+		return swaps[i].RefundHeight < swaps[j].RefundHeight
+	})
+	return swaps, err
+}
+
+// ClaimAtomicSwap completes the adaptor signature of the sender for the claim
+// transaction of a funded swap with the secret of the wallet, and broadcasts
+// the claim. Publishing the claim reveals the secret to the sender.
+func (w *Wallet) ClaimAtomicSwap(id crypto.Hash, asig crypto.AdaptorSignature) (types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.Transaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	swap, sk, height, err := w.managedAtomicSwap(id, modules.AtomicSwapReceiver)
+	if err != nil {
+		return types.Transaction{}, err
+	} else if swap.Status != modules.AtomicSwapFunded {
+		return types.Transaction{}, errAtomicSwapNotFunded
+	} else if height >= swap.RefundHeight {
+		return types.Transaction{}, errAtomicSwapExpired
+	}
+	senderKey, err := atomicSwapPublicKey(swap.SenderKey)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	claim := atomicSwapClaim(swap)
+	if err := crypto.VerifyAdaptorHash(claim.SigHash(0, height), senderKey, swap.AdaptorPoint, asig); err != nil {
+		return types.Transaction{}, err
+	}
+	secret, _ := atomicSwapSecret(sk)
+	senderSig, err := asig.Complete(secret)
+	if err != nil {
+		return types.Transaction{}, err
+	}
+	receiverSig := crypto.SignHash(claim.SigHash(1, height), sk)
+	claim.TransactionSignatures[0].Signature = senderSig[:]
+	claim.TransactionSignatures[1].Signature = receiverSig[:]
+
+	swap.ClaimAdaptorSignature, swap.Secret = asig, secret
+	if err := w.managedPutAtomicSwap(swap); err != nil {
+		return types.Transaction{}, err
+	}
+	if err := w.tpool.AcceptTransactionSet([]types.Transaction{claim}); err != nil {
+		return types.Transaction{}, err
+	}
+	w.log.Printf("Broadcast the claim of atomic swap %v in transaction %v", swap.ID, claim.ID())
+	return claim, nil
+}
+
+// FundAtomicSwap verifies the signature of the receiver for the refund
+// transaction of an offer of the wallet, signs the refund, and broadcasts the
+// funding transactions of the offer. The refund is broadcast automatically
+// once the swap reaches its refund height without being claimed.
+func (w *Wallet) FundAtomicSwap(id crypto.Hash, refundSig crypto.Signature) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	swap, sk, height, err := w.managedAtomicSwap(id, modules.AtomicSwapSender)
+	if err != nil {
+		return err
+	} else if swap.Status != modules.AtomicSwapNegotiating {
+		return errAtomicSwapStatus
+	}
+	receiverKey, err := atomicSwapPublicKey(swap.ReceiverKey)
+	if err != nil {
+		return err
+	}
+	refund := atomicSwapRefund(swap)
+	if crypto.VerifyHash(refund.SigHash(1, height), receiverKey, refundSig) != nil {
+		return errInvalidRefundSignature
+	}
+	senderSig := crypto.SignHash(refund.SigHash(0, height), sk)
+	refund.TransactionSignatures[0].Signature = senderSig[:]
+	refund.TransactionSignatures[1].Signature = refundSig[:]
+
+	// Store the refund before the funding transactions are broadcast, so
+	// that the siacoins can't be locked without a way to get them back.
+	swap.RefundTransaction, swap.Status = refund, modules.AtomicSwapFunding
+	if err := w.managedPutAtomicSwap(swap); err != nil {
+		return err
+	}
+	if err := w.tpool.AcceptTransactionSet(swap.FundingTransactions); err != nil {
+		swap.Status = modules.AtomicSwapNegotiating
+		return errors.Compose(err, w.managedPutAtomicSwap(swap))
+	}
+	w.log.Printf("Funded atomic swap %v with output %v", swap.ID, swap.OutputID)
+	return nil
+}
+
+// OfferAtomicSwap answers the request of a receiver with an offer to send
+// amount, which the wallet can refund from refundHeight on. The transactions
+// that fund the shared output are signed, but only broadcast by FundAtomicSwap
+// once the receiver has signed the refund.
+func (w *Wallet) OfferAtomicSwap(req modules.AtomicSwapRequest, amount types.Currency, refundHeight types.BlockHeight) (_ modules.AtomicSwapOffer, err error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.AtomicSwapOffer{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if _, err := atomicSwapPublicKey(req.ReceiverKey); err != nil {
+		return modules.AtomicSwapOffer{}, err
+	} else if err := req.AdaptorPoint.Validate(); err != nil {
+		return modules.AtomicSwapOffer{}, err
+	} else if amount.IsZero() {
+		return modules.AtomicSwapOffer{}, errZeroAtomicSwapAmount
+	}
+
+	w.mu.Lock()
+	if !w.unlocked {
+		w.mu.Unlock()
+		return modules.AtomicSwapOffer{}, modules.ErrLockedWallet
+	}
+	id := atomicSwapID(req)
+	_, err = dbGetAtomicSwap(w.dbTx, id)
+	if err == nil {
+		w.mu.Unlock()
+		return modules.AtomicSwapOffer{}, errAtomicSwapExists
+	} else if err != errNoKey {
+		w.mu.Unlock()
+		return modules.AtomicSwapOffer{}, err
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		w.mu.Unlock()
+		return modules.AtomicSwapOffer{}, err
+	} else if refundHeight <= height {
+		w.mu.Unlock()
+		return modules.AtomicSwapOffer{}, errAtomicSwapRefundHeight
+	}
+	senderKey, _, refundAddress, err := w.newAtomicSwapKey(w.dbTx)
+	w.mu.Unlock()
+	if err != nil {
+		return modules.AtomicSwapOffer{}, err
+	}
+
+	_, tpoolFee := w.tpool.FeeEstimation()
+	swap := modules.AtomicSwap{
+		ID:            id,
+		Role:          modules.AtomicSwapSender,
+		Status:        modules.AtomicSwapNegotiating,
+		SenderKey:     senderKey,
+		ReceiverKey:   req.ReceiverKey,
+		AdaptorPoint:  req.AdaptorPoint,
+		Amount:        amount,
+		Fee:           tpoolFee.Mul64(atomicSwapTransactionSize),
+		RefundHeight:  refundHeight,
+		ClaimAddress:  req.ClaimAddress,
+		RefundAddress: refundAddress,
+	}
+	if swap.ClaimAddress == swap.RefundAddress {
+		return modules.AtomicSwapOffer{}, errInvalidAtomicSwapOffer
+	}
+
+	fundingFee := tpoolFee.Mul64(750) // Estimated transaction size in bytes
+	output := types.SiacoinOutput{
+		Value:      swap.Amount.Add(swap.Fee),
+		UnlockHash: atomicSwapUnlockConditions(swap).UnlockHash(),
+	}
+	txnBuilder, err := w.StartTransaction()
+	if err != nil {
+		return modules.AtomicSwapOffer{}, err
+	}
+	defer func() {
+		if err != nil {
+			txnBuilder.Drop()
+		}
+	}()
+	if err = txnBuilder.FundSiacoins(output.Value.Add(fundingFee)); err != nil {
+		return modules.AtomicSwapOffer{}, err
+	}
+	txnBuilder.AddMinerFee(fundingFee)
+	index := txnBuilder.AddSiacoinOutput(output)
+	txnSet, err := txnBuilder.Sign(true)
+	if err != nil {
+		return modules.AtomicSwapOffer{}, err
+	}
+	swap.FundingTransactions = txnSet
+	swap.OutputID = txnSet[len(txnSet)-1].SiacoinOutputID(index)
+	if err = w.managedPutAtomicSwap(swap); err != nil {
+		return modules.AtomicSwapOffer{}, err
+	}
+	return modules.AtomicSwapOffer{
+		ID:            swap.ID,
+		SenderKey:     swap.SenderKey,
+		Amount:        swap.Amount,
+		Fee:           swap.Fee,
+		RefundHeight:  swap.RefundHeight,
+		RefundAddress: swap.RefundAddress,
+		OutputID:      swap.OutputID,
+	}, nil
+}
+
+// PresignAtomicSwapClaim returns the adaptor signature of the wallet for the
+// claim transaction of a funded swap. The receiver can only complete it with
+// its secret, which the wallet recovers once the claim is confirmed.
+func (w *Wallet) PresignAtomicSwapClaim(id crypto.Hash) (crypto.AdaptorSignature, error) {
+	if err := w.tg.Add(); err != nil {
+		return crypto.AdaptorSignature{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	swap, sk, height, err := w.managedAtomicSwap(id, modules.AtomicSwapSender)
+	if err != nil {
+		return crypto.AdaptorSignature{}, err
+	} else if swap.Status != modules.AtomicSwapFunded {
+		return crypto.AdaptorSignature{}, errAtomicSwapNotFunded
+	} else if height >= swap.RefundHeight {
+		return crypto.AdaptorSignature{}, errAtomicSwapExpired
+	}
+	claim := atomicSwapClaim(swap)
+	asig, err := crypto.AdaptorSignHash(claim.SigHash(0, height), sk, swap.AdaptorPoint)
+	if err != nil {
+		return crypto.AdaptorSignature{}, err
+	}
+	swap.ClaimAdaptorSignature = asig
+	if err := w.managedPutAtomicSwap(swap); err != nil {
+		return crypto.AdaptorSignature{}, err
+	}
+	return asig, nil
+}
+
+// RequestAtomicSwap starts an atomic swap in which the wallet receives
+// siacoins. The request contains a new key of the wallet and the adaptor
+// point of a secret that is derived from it.
+func (w *Wallet) RequestAtomicSwap() (modules.AtomicSwapRequest, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.AtomicSwapRequest{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.AtomicSwapRequest{}, modules.ErrLockedWallet
+	}
+
+	receiverKey, sk, claimAddress, err := w.newAtomicSwapKey(w.dbTx)
+	if err != nil {
+		return modules.AtomicSwapRequest{}, err
+	}
+	_, point := atomicSwapSecret(sk)
+	req := modules.AtomicSwapRequest{
+		ReceiverKey:  receiverKey,
+		AdaptorPoint: point,
+		ClaimAddress: claimAddress,
+	}
+	swap := modules.AtomicSwap{
+		ID:           atomicSwapID(req),
+		Role:         modules.AtomicSwapReceiver,
+		Status:       modules.AtomicSwapNegotiating,
+		ReceiverKey:  receiverKey,
+		AdaptorPoint: point,
+		ClaimAddress: claimAddress,
+	}
+	if err := dbPutAtomicSwap(w.dbTx, swap); err != nil {
+		return modules.AtomicSwapRequest{}, err
+	}
+	return req, w.syncDB()
+}
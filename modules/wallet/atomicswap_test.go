@@ -0,0 +1,357 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/modules/miner"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// swapCounterparty is the other party of an atomic swap with the wallet. It
+// only uses the transaction pool and the crypto primitives.
+type swapCounterparty struct {
+	sk     crypto.SecretKey
+	pk     crypto.PublicKey
+	secret crypto.AdaptorSecret
+	point  crypto.AdaptorPoint
+}
+
+// newSwapCounterparty creates a counterparty with a new key and adaptor
+// secret.
+func newSwapCounterparty() swapCounterparty {
+	var sc swapCounterparty
+	sc.sk, sc.pk = crypto.GenerateKeyPair()
+	var entropy [crypto.EntropySize]byte
+	fastrand.Read(entropy[:])
+	sc.secret, sc.point = crypto.GenerateAdaptorSecret(entropy)
+	return sc
+}
+
+// unlockConditions returns the unlock conditions of the counterparty's key.
+func (sc swapCounterparty) unlockConditions() types.UnlockConditions {
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{types.Ed25519PublicKey(sc.pk)},
+		SignaturesRequired: 1,
+	}
+}
+
+// sign signs the transaction signature i of txn.
+func (sc swapCounterparty) sign(txn *types.Transaction, i int, height types.BlockHeight) {
+	sig := crypto.SignHash(txn.SigHash(i, height), sc.sk)
+	txn.TransactionSignatures[i].Signature = sig[:]
+}
+
+// waitForAtomicSwap waits until an atomic swap of the wallet has the status.
+func waitForAtomicSwap(w *Wallet, id crypto.Hash, status modules.AtomicSwapStatus) (swap modules.AtomicSwap, err error) {
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		swap, err = w.AtomicSwap(id)
+		if err != nil {
+			return err
+		} else if swap.Status != status {
+			return fmt.Errorf("atomic swap has status %v, expected %v", swap.Status, status)
+		}
+		return nil
+	})
+	return
+}
+
+// TestAtomicSwapSender checks that the wallet can fund an atomic swap, that
+// it recovers the secret of the receiver from the claim, and that it refunds
+// swaps that aren't claimed, also after a restart.
+func TestAtomicSwapSender(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		// The wallet is replaced when it is restarted.
+		wt.closeWt()
+	}()
+
+	// Refunds are only broadcast once the wallet is synced. Transactions that
+	// are signed right at the hardfork height can be invalid once they reach
+	// the transaction pool.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !wt.cs.Synced() {
+			return errors.New("consensus set is not synced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		wt.addBlockNoPayout()
+	}
+
+	// The receiver requests two swaps, one that it claims, and one that is
+	// refunded.
+	amount := types.SiacoinPrecision.Mul64(100)
+	receiver := newSwapCounterparty()
+	claimed := modules.AtomicSwapRequest{
+		ReceiverKey:  types.Ed25519PublicKey(receiver.pk),
+		AdaptorPoint: receiver.point,
+		ClaimAddress: receiver.unlockConditions().UnlockHash(),
+	}
+	_, otherPoint := crypto.GenerateAdaptorSecret(crypto.HashObject(receiver.point))
+	refunded := claimed
+	refunded.AdaptorPoint = otherPoint
+	if _, err := wt.wallet.OfferAtomicSwap(claimed, amount, wt.cs.Height()); err != errAtomicSwapRefundHeight {
+		t.Fatal("expected errAtomicSwapRefundHeight, got", err)
+	}
+	invalid := claimed
+	invalid.AdaptorPoint = crypto.AdaptorPoint{1} // the identity point
+	if _, err := wt.wallet.OfferAtomicSwap(invalid, amount, wt.cs.Height()+10); err != crypto.ErrInvalidAdaptorPoint {
+		t.Fatal("expected ErrInvalidAdaptorPoint, got", err)
+	}
+
+	// offer makes an offer for a request and funds it with the receiver's
+	// refund signature.
+	offer := func(req modules.AtomicSwapRequest, refundHeight types.BlockHeight) modules.AtomicSwap {
+		t.Helper()
+		offer, err := wt.wallet.OfferAtomicSwap(req, amount, refundHeight)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.wallet.OfferAtomicSwap(req, amount, refundHeight); err != errAtomicSwapExists {
+			t.Fatal("expected errAtomicSwapExists, got", err)
+		}
+		swap, err := wt.wallet.AtomicSwap(offer.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if swap.Status != modules.AtomicSwapNegotiating || swap.OutputID != offer.OutputID || !swap.Amount.Equals(amount) {
+			t.Fatal("wrong atomic swap:", swap)
+		}
+		refund := atomicSwapRefund(swap)
+		if err := wt.wallet.FundAtomicSwap(offer.ID, crypto.Signature{}); err != errInvalidRefundSignature {
+			t.Fatal("expected errInvalidRefundSignature, got", err)
+		}
+		receiver.sign(&refund, 1, wt.cs.Height())
+		var sig crypto.Signature
+		copy(sig[:], refund.TransactionSignatures[1].Signature)
+		if err := wt.wallet.FundAtomicSwap(offer.ID, sig); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.wallet.PresignAtomicSwapClaim(offer.ID); err != errAtomicSwapNotFunded {
+			t.Fatal("expected errAtomicSwapNotFunded, got", err)
+		}
+		return swap
+	}
+	claimSwap := offer(claimed, wt.cs.Height()+20)
+	refundSwap := offer(refunded, wt.cs.Height()+3)
+	if err := wt.addBlockNoPayout(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := waitForAtomicSwap(wt.wallet, claimSwap.ID, modules.AtomicSwapFunded); err != nil {
+		t.Fatal(err)
+	}
+
+	// The receiver completes the presigned claim with its secret, which
+	// reveals the secret to the wallet.
+	asig, err := wt.wallet.PresignAtomicSwapClaim(claimSwap.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim := atomicSwapClaim(claimSwap)
+	senderKey, _ := atomicSwapPublicKey(claimSwap.SenderKey)
+	if err := crypto.VerifyAdaptorHash(claim.SigHash(0, wt.cs.Height()), senderKey, receiver.point, asig); err != nil {
+		t.Fatal(err)
+	}
+	sig, err := asig.Complete(receiver.secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim.TransactionSignatures[0].Signature = sig[:]
+	receiver.sign(&claim, 1, wt.cs.Height())
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{claim}); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	swap, err := waitForAtomicSwap(wt.wallet, claimSwap.ID, modules.AtomicSwapClaimed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap.Secret != receiver.secret || swap.SpendTransactionID != claim.ID() {
+		t.Fatal("wallet did not recover the secret from the claim")
+	}
+
+	// Restart the wallet. The unclaimed swap is refunded once it reaches its
+	// refund height.
+	if err := wt.wallet.Close(); err != nil {
+		t.Fatal(err)
+	}
+	wt.wallet, err = New(wt.cs, wt.tpool, filepath.Join(wt.persistDir, modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	wt.miner, err = miner.New(wt.cs, wt.tpool, wt.wallet, wt.wallet.persistDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	swaps, err := wt.wallet.AtomicSwaps()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(swaps) != 2 || swaps[0].ID != refundSwap.ID || swaps[1].Status != modules.AtomicSwapClaimed {
+		t.Fatal("atomic swaps were not persisted:", swaps)
+	}
+	for wt.cs.Height() < refundSwap.RefundHeight {
+		wt.addBlockNoPayout()
+	}
+	err = build.Retry(50, 100*time.Millisecond, func() error {
+		if _, _, exists := wt.tpool.Transaction(swaps[0].RefundTransaction.ID()); !exists {
+			return errors.New("refund was not broadcast")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	swap, err = waitForAtomicSwap(wt.wallet, refundSwap.ID, modules.AtomicSwapRefunded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap.SpendTransactionID != swap.RefundTransaction.ID() || swap.Secret != (crypto.AdaptorSecret{}) {
+		t.Fatal("atomic swap was not refunded:", swap)
+	}
+}
+
+// TestAtomicSwapReceiver checks that the wallet can claim an atomic swap that
+// is funded by another party, and that the claim reveals its secret.
+func TestAtomicSwapReceiver(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		wt.addBlockNoPayout()
+	}
+
+	// Give the sender the siacoins that it locks in the swap.
+	sender := newSwapCounterparty()
+	senderUC := sender.unlockConditions()
+	value := types.SiacoinPrecision.Mul64(200)
+	txns, err := wt.wallet.SendSiacoins(value, senderUC.UnlockHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	var parentID types.SiacoinOutputID
+	txn := txns[len(txns)-1]
+	for i, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == senderUC.UnlockHash() {
+			parentID = txn.SiacoinOutputID(uint64(i))
+		}
+	}
+
+	// The sender funds the shared output of the request of the wallet.
+	req, err := wt.wallet.RequestAtomicSwap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	swap := modules.AtomicSwap{
+		SenderKey:     types.Ed25519PublicKey(sender.pk),
+		ReceiverKey:   req.ReceiverKey,
+		Amount:        types.SiacoinPrecision.Mul64(100),
+		Fee:           types.SiacoinPrecision,
+		RefundHeight:  wt.cs.Height() + 20,
+		ClaimAddress:  req.ClaimAddress,
+		RefundAddress: senderUC.UnlockHash(),
+	}
+	funding := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{ParentID: parentID, UnlockConditions: senderUC}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      swap.Amount.Add(swap.Fee),
+			UnlockHash: atomicSwapUnlockConditions(swap).UnlockHash(),
+		}},
+		MinerFees: []types.Currency{value.Sub(swap.Amount.Add(swap.Fee))},
+		TransactionSignatures: []types.TransactionSignature{{
+			ParentID:      crypto.Hash(parentID),
+			CoveredFields: types.FullCoveredFields,
+		}},
+	}
+	sender.sign(&funding, 0, wt.cs.Height())
+	swap.OutputID = funding.SiacoinOutputID(0)
+	offer := modules.AtomicSwapOffer{
+		ID:            atomicSwapID(req),
+		SenderKey:     swap.SenderKey,
+		Amount:        swap.Amount,
+		Fee:           swap.Fee,
+		RefundHeight:  swap.RefundHeight,
+		RefundAddress: swap.RefundAddress,
+		OutputID:      swap.OutputID,
+	}
+	refundSig, err := wt.wallet.AcceptAtomicSwap(offer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	receiverKey, _ := atomicSwapPublicKey(req.ReceiverKey)
+	refund := atomicSwapRefund(swap)
+	if err := crypto.VerifyHash(refund.SigHash(1, wt.cs.Height()), receiverKey, refundSig); err != nil {
+		t.Fatal("invalid refund signature:", err)
+	}
+	if _, err := wt.wallet.AcceptAtomicSwap(offer); err != errAtomicSwapStatus {
+		t.Fatal("expected errAtomicSwapStatus, got", err)
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{funding}); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	if _, err := waitForAtomicSwap(wt.wallet, offer.ID, modules.AtomicSwapFunded); err != nil {
+		t.Fatal(err)
+	}
+
+	// The wallet only claims the swap with a valid adaptor signature.
+	claim := atomicSwapClaim(swap)
+	asig, err := crypto.AdaptorSignHash(claim.SigHash(0, wt.cs.Height()), sender.sk, sender.point)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.ClaimAtomicSwap(offer.ID, asig); err != crypto.ErrInvalidAdaptorSignature {
+		t.Fatal("expected ErrInvalidAdaptorSignature, got", err)
+	}
+	asig, err = crypto.AdaptorSignHash(claim.SigHash(0, wt.cs.Height()), sender.sk, req.AdaptorPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claim, err = wt.wallet.ClaimAtomicSwap(offer.ID, asig)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The sender recovers the secret from the claim.
+	var sig crypto.Signature
+	copy(sig[:], claim.TransactionSignatures[0].Signature)
+	secret, err := asig.RecoverSecret(sig, req.AdaptorPoint)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	swap, err = waitForAtomicSwap(wt.wallet, offer.ID, modules.AtomicSwapClaimed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if swap.Secret != secret || swap.SpendTransactionID != claim.ID() {
+		t.Fatal("wrong claimed atomic swap:", swap)
+	}
+}
@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// bumpTransactionSize is the estimated size in bytes of a transaction
+	// that bumps the fee of another transaction. It has a single input and
+	// output.
+	bumpTransactionSize = 500
+)
+
+var (
+	// errNoBumpableOutput is returned if an unconfirmed transaction has no
+	// unspent output of the wallet that a child transaction could spend.
+	errNoBumpableOutput = errors.New("transaction has no unspent output of the wallet to bump its fee with")
+
+	// errTransactionNotUnconfirmed is returned if the transaction to bump is
+	// not an unconfirmed transaction of the wallet.
+	errTransactionNotUnconfirmed = errors.New("transaction is not an unconfirmed transaction of the wallet")
+)
+
+// A bumpingTransaction is a child transaction that the wallet created to bump
+// the fee of a parent transaction.
+type bumpingTransaction struct {
+	ID     types.TransactionID
+	Height types.BlockHeight
+}
+
+// annotateBumps fills in the fee bumping relationships of pt that the wallet
+// recorded when it created the bumping transactions.
+func annotateBumps(tx *bolt.Tx, pt *modules.ProcessedTransaction) {
+	if parent, err := dbGetBumpedTransaction(tx, pt.TransactionID); err == nil {
+		pt.BumpsTransactionID = parent
+	}
+	children, _ := dbGetBumpingTransactions(tx, pt.TransactionID)
+	for _, child := range children {
+		pt.BumpedBy = append(pt.BumpedBy, child.ID)
+	}
+}
+
+// putBumpedTransaction records that child bumps the fee of parent.
+func putBumpedTransaction(tx *bolt.Tx, child, parent types.TransactionID, height types.BlockHeight) error {
+	children, err := dbGetBumpingTransactions(tx, parent)
+	if err != nil && err != errNoKey {
+		return err
+	}
+	children = append(children, bumpingTransaction{ID: child, Height: height})
+	if err := dbPutBumpingTransactions(tx, parent, children); err != nil {
+		return err
+	}
+	return dbPutBumpedTransaction(tx, child, parent)
+}
+
+// deleteBumpedTransaction removes the record that child bumps the fee of its
+// parent.
+func deleteBumpedTransaction(tx *bolt.Tx, child types.TransactionID) error {
+	parent, err := dbGetBumpedTransaction(tx, child)
+	if err == errNoKey {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	children, err := dbGetBumpingTransactions(tx, parent)
+	if err != nil && err != errNoKey {
+		return err
+	}
+	var kept []bumpingTransaction
+	for _, c := range children {
+		if c.ID != child {
+			kept = append(kept, c)
+		}
+	}
+	if len(kept) == 0 {
+		err = dbDeleteBumpingTransactions(tx, parent)
+	} else {
+		err = dbPutBumpingTransactions(tx, parent, kept)
+	}
+	if err != nil {
+		return err
+	}
+	return dbDeleteBumpedTransaction(tx, child)
+}
+
+// pruneBumpedTransactions removes the fee bumping relationships that are no
+// longer needed for annotating transactions: those whose parent and child are
+// both confirmed, which keep their annotations in the processed transactions,
+// and, if the wallet is synced, those whose child was neither confirmed nor
+// kept in the transaction pool within RespendTimeout blocks.
+func (w *Wallet) pruneBumpedTransactions(tx *bolt.Tx, synced bool) error {
+	height, err := dbGetConsensusHeight(tx)
+	if err != nil {
+		return err
+	}
+	confirmed := func(txid types.TransactionID) bool {
+		_, err := dbGetTransactionIndex(tx, txid)
+		return err == nil
+	}
+	unconfirmed := make(map[types.TransactionID]struct{})
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		unconfirmed[upt.TransactionID] = struct{}{}
+	}
+
+	var prune []types.TransactionID
+	err = dbForEachBumpingTransactions(tx, func(parent types.TransactionID, children []bumpingTransaction) {
+		parentConfirmed := confirmed(parent)
+		for _, child := range children {
+			childConfirmed := confirmed(child.ID)
+			_, pending := unconfirmed[child.ID]
+			dropped := synced && !childConfirmed && !pending && height >= child.Height+RespendTimeout
+			if (parentConfirmed && childConfirmed) || dropped {
+				prune = append(prune, child.ID)
+			}
+		}
+	})
+	if err != nil {
+		return err
+	}
+	for _, child := range prune {
+		if err := deleteBumpedTransaction(tx, child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unconfirmedAncestors returns the unconfirmed transactions of the wallet
+// that txn depends on, followed by txn itself, ordered so that every
+// transaction comes after its parents.
+func (w *Wallet) unconfirmedAncestors(txn types.Transaction) []types.Transaction {
+	creators := make(map[types.SiacoinOutputID]types.Transaction)
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		for i := range upt.Transaction.SiacoinOutputs {
+			creators[upt.Transaction.SiacoinOutputID(uint64(i))] = upt.Transaction
+		}
+	}
+	var set []types.Transaction
+	added := make(map[types.TransactionID]struct{})
+	var visit func(types.Transaction)
+	visit = func(txn types.Transaction) {
+		if _, exists := added[txn.ID()]; exists {
+			return
+		}
+		added[txn.ID()] = struct{}{}
+		for _, sci := range txn.SiacoinInputs {
+			if parent, exists := creators[sci.ParentID]; exists {
+				visit(parent)
+			}
+		}
+		set = append(set, txn)
+	}
+	visit(txn)
+	return set
+}
+
+// BumpTransaction raises the fee of an unconfirmed transaction of the wallet
+// by creating a child transaction that spends one of its outputs, or one of
+// the outputs of its unconfirmed ancestors, back to the wallet, and pays
+// enough fees for the whole set (child-pays-for-parent). The child is
+// broadcast together with its unconfirmed ancestors, and the resulting
+// transaction set is returned.
+func (w *Wallet) BumpTransaction(txid types.TransactionID) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return nil, err
+	}
+	_, tpoolFee := w.tpool.FeeEstimation()
+
+	w.mu.Lock()
+	if !w.unlocked {
+		w.mu.Unlock()
+		return nil, modules.ErrLockedWallet
+	}
+	txnSet, child, err := w.createBumpTransaction(txid, tpoolFee, dustThreshold)
+	w.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	// Submit the child together with its ancestors, so that the transaction
+	// pool considers their fees together.
+	txnSet = append(txnSet, child)
+	err = w.tpool.AcceptTransactionSet(txnSet)
+	if err != nil {
+		w.mu.Lock()
+		if err := dbDeleteSpentOutput(w.dbTx, types.OutputID(child.SiacoinInputs[0].ParentID)); err != nil {
+			w.log.Println("Unable to unmark the output spent by the rejected bump transaction:", err)
+		}
+		if err := deleteBumpedTransaction(w.dbTx, child.ID()); err != nil {
+			w.log.Println("Unable to delete the record of the rejected bump transaction:", err)
+		}
+		w.mu.Unlock()
+		w.log.Println("Attempt to bump the fee of a transaction has failed - transaction pool rejected transaction:", err)
+		return nil, build.ExtendErr("unable to get transaction accepted", err)
+	}
+	w.log.Println("Submitted transaction", child.ID(), "bumping the fee of", txid, "with fees", child.MinerFees[0].HumanString())
+	return txnSet, nil
+}
+
+// createBumpTransaction creates and signs a child transaction that bumps the
+// fee of the unconfirmed transaction txid, and returns it together with the
+// unconfirmed ancestors of the child. The output spent by the child is marked
+// as spent, and the relationship between the transactions is recorded.
+func (w *Wallet) createBumpTransaction(txid types.TransactionID, feePerByte, dustThreshold types.Currency) ([]types.Transaction, types.Transaction, error) {
+	var stuck types.Transaction
+	found := false
+	spent := make(map[types.SiacoinOutputID]struct{})
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		if upt.TransactionID == txid {
+			stuck, found = upt.Transaction, true
+		}
+		for _, sci := range upt.Transaction.SiacoinInputs {
+			spent[sci.ParentID] = struct{}{}
+		}
+	}
+	if !found {
+		return nil, types.Transaction{}, errTransactionNotUnconfirmed
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return nil, types.Transaction{}, err
+	}
+
+	// Pick the largest output of the transaction or its unconfirmed
+	// ancestors that the wallet can spend. Transactions that send coins
+	// elsewhere usually keep their change in a parent.
+	txnSet := w.unconfirmedAncestors(stuck)
+	var scoid types.SiacoinOutputID
+	var sco types.SiacoinOutput
+	for _, txn := range txnSet {
+		for i, output := range txn.SiacoinOutputs {
+			id := txn.SiacoinOutputID(uint64(i))
+			_, owned := w.keys[output.UnlockHash]
+			_, multisig := w.multisigAddrs[output.UnlockHash]
+			_, spentUnconfirmed := spent[id]
+			_, spendErr := dbGetSpentOutput(w.dbTx, types.OutputID(id))
+			if !owned || multisig || spentUnconfirmed || spendErr == nil {
+				continue
+			}
+			if output.Value.Cmp(sco.Value) > 0 {
+				scoid, sco = id, output
+			}
+		}
+	}
+	if sco.Value.IsZero() {
+		return nil, types.Transaction{}, errNoBumpableOutput
+	}
+
+	// The child pays for the size of the whole set at the current fee rate,
+	// minus the fees that the ancestors pay already.
+	setSize := uint64(bumpTransactionSize)
+	var setFees types.Currency
+	for _, txn := range txnSet {
+		setSize += uint64(len(encoding.Marshal(txn)))
+		for _, fee := range txn.MinerFees {
+			setFees = setFees.Add(fee)
+		}
+	}
+	fee := feePerByte.Mul64(bumpTransactionSize)
+	if required := feePerByte.Mul64(setSize); required.Cmp(setFees.Add(fee)) > 0 {
+		fee = required.Sub(setFees)
+	}
+	if sco.Value.Cmp(fee.Add(dustThreshold)) < 0 {
+		return nil, types.Transaction{}, modules.ErrLowBalance
+	}
+
+	// Create and sign the child.
+	refundUnlockConditions, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return nil, types.Transaction{}, err
+	}
+	uc := w.keys[sco.UnlockHash].UnlockConditions
+	child := types.Transaction{
+		SiacoinInputs: []types.SiacoinInput{{
+			ParentID:         scoid,
+			UnlockConditions: uc,
+		}},
+		SiacoinOutputs: []types.SiacoinOutput{{
+			Value:      sco.Value.Sub(fee),
+			UnlockHash: refundUnlockConditions.UnlockHash(),
+		}},
+		MinerFees: []types.Currency{fee},
+	}
+	addSignatures(&child, types.FullCoveredFields, uc, crypto.Hash(scoid), w.keys[sco.UnlockHash], consensusHeight)
+
+	if err := dbPutSpentOutput(w.dbTx, types.OutputID(scoid), consensusHeight); err != nil {
+		return nil, types.Transaction{}, err
+	}
+	if err := putBumpedTransaction(w.dbTx, child.ID(), txid, consensusHeight); err != nil {
+		return nil, types.Transaction{}, err
+	}
+	return txnSet, child, nil
+}
@@ -0,0 +1,142 @@
+package wallet
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestBumpTransaction checks that the wallet can bump the fee of an
+// unconfirmed transaction with a child transaction, and that the
+// relationship between the transactions is recorded.
+func TestBumpTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	if _, err := wt.wallet.BumpTransaction(types.TransactionID{1}); err != errTransactionNotUnconfirmed {
+		t.Fatal("expected errTransactionNotUnconfirmed, got", err)
+	}
+
+	// Send coins to another address. The change of the transaction is kept
+	// in its parent.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), types.UnlockHash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stuck := txns[len(txns)-1]
+	bumpSet, err := wt.wallet.BumpTransaction(stuck.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	child := bumpSet[len(bumpSet)-1]
+	if len(bumpSet) != len(txns)+1 || bumpSet[len(bumpSet)-2].ID() != stuck.ID() {
+		t.Fatal("child should be broadcast together with its ancestors")
+	}
+	_, tpoolFee := wt.tpool.FeeEstimation()
+	if child.MinerFees[0].Cmp(tpoolFee.Mul64(bumpTransactionSize)) < 0 {
+		t.Fatal("child fee is too low:", child.MinerFees[0].HumanString())
+	}
+
+	// The relationship is shown in the processed transactions.
+	upts, err := wt.wallet.UnconfirmedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bumpedBy []types.TransactionID
+	var bumps types.TransactionID
+	for _, upt := range upts {
+		if upt.TransactionID == stuck.ID() {
+			bumpedBy = upt.BumpedBy
+		} else if upt.TransactionID == child.ID() {
+			bumps = upt.BumpsTransactionID
+		}
+	}
+	if bumps != stuck.ID() {
+		t.Fatal("child should refer to the bumped transaction, got", bumps)
+	}
+	if len(bumpedBy) != 1 || bumpedBy[0] != child.ID() {
+		t.Fatal("bumped transaction should refer to the child, got", bumpedBy)
+	}
+
+	// The relationship survives the confirmation of the transactions.
+	wt.addBlockNoPayout()
+	pt, ok, err := wt.wallet.Transaction(stuck.ID())
+	if err != nil || !ok {
+		t.Fatal("bumped transaction was not confirmed:", err)
+	}
+	if len(pt.BumpedBy) != 1 || pt.BumpedBy[0] != child.ID() {
+		t.Fatal("confirmed transaction should still refer to the child:", pt.BumpedBy)
+	}
+	pt, ok, err = wt.wallet.Transaction(child.ID())
+	if err != nil || !ok || pt.BumpsTransactionID != stuck.ID() {
+		t.Fatal("confirmed child should still refer to the bumped transaction:", err)
+	}
+	if _, err := wt.wallet.BumpTransaction(stuck.ID()); err != errTransactionNotUnconfirmed {
+		t.Fatal("expected errTransactionNotUnconfirmed, got", err)
+	}
+
+	// Once both transactions are confirmed, the relationship is no longer
+	// recorded separately.
+	wt.wallet.mu.Lock()
+	_, childErr := dbGetBumpedTransaction(wt.wallet.dbTx, child.ID())
+	_, parentErr := dbGetBumpingTransactions(wt.wallet.dbTx, stuck.ID())
+	wt.wallet.mu.Unlock()
+	if childErr != errNoKey || parentErr != errNoKey {
+		t.Fatal("confirmed bump was not pruned:", childErr, parentErr)
+	}
+}
+
+// TestPruneDroppedBumps checks that the relationship of a bump whose child
+// never made it into a block is removed after RespendTimeout blocks.
+func TestPruneDroppedBumps(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	parent, child, other := types.TransactionID{1}, types.TransactionID{2}, types.TransactionID{3}
+	wt.wallet.mu.Lock()
+	height, err := dbGetConsensusHeight(wt.wallet.dbTx)
+	if err == nil {
+		err = putBumpedTransaction(wt.wallet.dbTx, child, parent, height)
+	}
+	if err == nil {
+		err = putBumpedTransaction(wt.wallet.dbTx, other, parent, height+RespendTimeout)
+	}
+	wt.wallet.mu.Unlock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < RespendTimeout; i++ {
+		wt.addBlockNoPayout()
+	}
+
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	if _, err := dbGetBumpedTransaction(wt.wallet.dbTx, child); err != errNoKey {
+		t.Fatal("dropped bump was not pruned:", err)
+	}
+	children, err := dbGetBumpingTransactions(wt.wallet.dbTx, parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 1 || children[0].ID != other {
+		t.Fatal("wrong remaining bumps:", children)
+	}
+	pt := modules.ProcessedTransaction{TransactionID: parent}
+	annotateBumps(wt.wallet.dbTx, &pt)
+	if len(pt.BumpedBy) != 1 || pt.BumpedBy[0] != other {
+		t.Fatal("wrong annotation:", pt.BumpedBy)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"reflect"
 	"time"
 
+	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/types"
@@ -16,6 +17,31 @@ import (
 )
 
 var (
+	// bucketAddressLabels maps an UnlockHash to the label that the user gave
+	// it.
+	bucketAddressLabels = []byte("bucketAddressLabels")
+	// bucketAtomicSwaps maps the ID of an atomic swap to the AtomicSwap.
+	bucketAtomicSwaps = []byte("bucketAtomicSwaps")
+	// bucketBumpedTransactions maps the ID of a transaction that the wallet
+	// created to bump the fee of an unconfirmed transaction to the ID of that
+	// transaction. Entries are removed together with those of
+	// bucketBumpingTransactions.
+	bucketBumpedTransactions = []byte("bucketBumpedTransactions")
+	// bucketBumpingTransactions maps the ID of a transaction whose fee the
+	// wallet bumped to the bumpingTransactions that bump it. Entries are
+	// removed once the transactions are confirmed or the bump was dropped.
+	bucketBumpingTransactions = []byte("bucketBumpingTransactions")
+	// bucketMultisigAddresses maps the UnlockHash of a multisig address to
+	// its UnlockConditions. The wallet tracks the outputs of these addresses
+	// and co-signs transactions that spend them.
+	bucketMultisigAddresses = []byte("bucketMultisigAddresses")
+	// bucketPaymentRequestEvents maps the sequence number of a status change
+	// of a payment request to the PaymentRequestEvent. Only the most recent
+	// events are kept.
+	bucketPaymentRequestEvents = []byte("bucketPaymentRequestEvents")
+	// bucketPaymentRequests maps the UnlockHash of a payment request to the
+	// PaymentRequest.
+	bucketPaymentRequests = []byte("bucketPaymentRequests")
 	// bucketProcessedTransactions stores ProcessedTransactions in
 	// chronological order. Only transactions relevant to the wallet are
 	// stored. The key of this bucket is an autoincrementing integer.
@@ -26,6 +52,16 @@ var (
 	// bucketAddrTransactions maps an UnlockHash to the
 	// ProcessedTransactions that it appears in.
 	bucketAddrTransactions = []byte("bucketAddrTransactions")
+	// bucketScheduledPaymentExecutions maps the sequence number of an
+	// attempt to make a scheduled payment to the ScheduledPaymentExecution.
+	// Only the most recent executions are kept.
+	bucketScheduledPaymentExecutions = []byte("bucketScheduledPaymentExecutions")
+	// bucketScheduledPayments maps the ID of a scheduled payment to the
+	// ScheduledPayment.
+	bucketScheduledPayments = []byte("bucketScheduledPayments")
+	// bucketScheduledPaymentsInFlight maps the ID of a scheduled payment to
+	// the inFlightScheduledPayment whose transactions are being broadcast.
+	bucketScheduledPaymentsInFlight = []byte("bucketScheduledPaymentsInFlight")
 	// bucketSiacoinOutputs maps a SiacoinOutputID to its SiacoinOutput. Only
 	// outputs that the wallet controls are stored. The wallet uses these
 	// outputs to fund transactions.
@@ -39,6 +75,9 @@ var (
 	// these outputs so that it can reuse them if they are not confirmed on
 	// the blockchain.
 	bucketSpentOutputs = []byte("bucketSpentOutputs")
+	// bucketTransactionNotes maps a TransactionID to the note that the user
+	// added to it.
+	bucketTransactionNotes = []byte("bucketTransactionNotes")
 	// bucketUnlockConditions maps an UnlockHash to its UnlockConditions. It
 	// is used to track UnlockConditions manually stored by the user,
 	// typically with an offline wallet.
@@ -46,31 +85,53 @@ var (
 	// bucketWallet contains various fields needed by the wallet, such as its
 	// UID, EncryptionVerification, and PrimarySeedFile.
 	bucketWallet = []byte("bucketWallet")
+	// bucketWatchOnlyKeys maps the seed index of a watch-only key to its
+	// public key.
+	bucketWatchOnlyKeys = []byte("bucketWatchOnlyKeys")
 
 	dbBuckets = [][]byte{
+		bucketAddressLabels,
+		bucketAtomicSwaps,
+		bucketBumpedTransactions,
+		bucketBumpingTransactions,
+		bucketMultisigAddresses,
+		bucketPaymentRequestEvents,
+		bucketPaymentRequests,
 		bucketProcessedTransactions,
 		bucketProcessedTxnIndex,
 		bucketAddrTransactions,
+		bucketScheduledPaymentExecutions,
+		bucketScheduledPayments,
+		bucketScheduledPaymentsInFlight,
 		bucketSiacoinOutputs,
 		bucketSiafundOutputs,
 		bucketSpentOutputs,
+		bucketTransactionNotes,
 		bucketUnlockConditions,
 		bucketWallet,
+		bucketWatchOnlyKeys,
 	}
 
 	errNoKey = errors.New("key does not exist")
 
 	// these keys are used in bucketWallet
-	keyAuxiliarySeedFiles     = []byte("keyAuxiliarySeedFiles")
-	keyConsensusChange        = []byte("keyConsensusChange")
-	keyConsensusHeight        = []byte("keyConsensusHeight")
-	keyEncryptionVerification = []byte("keyEncryptionVerification")
-	keyPrimarySeedFile        = []byte("keyPrimarySeedFile")
-	keyPrimarySeedProgress    = []byte("keyPrimarySeedProgress")
-	keySiafundPool            = []byte("keySiafundPool")
-	keySpendableKeyFiles      = []byte("keySpendableKeyFiles")
-	keyUID                    = []byte("keyUID")
-	keyWatchedAddrs           = []byte("keyWatchedAddrs")
+	keyAuxiliarySeedFiles         = []byte("keyAuxiliarySeedFiles")
+	keyConsensusChange            = []byte("keyConsensusChange")
+	keyConsensusHeight            = []byte("keyConsensusHeight")
+	keyEncryptionVerification     = []byte("keyEncryptionVerification")
+	keyPaymentRequestEvents       = []byte("keyPaymentRequestEvents")
+	keyPrimarySeedFile            = []byte("keyPrimarySeedFile")
+	keyPrimarySeedProgress        = []byte("keyPrimarySeedProgress")
+	keyScheduledPaymentExecutions = []byte("keyScheduledPaymentExecutions")
+	keyScheduledPaymentLimits     = []byte("keyScheduledPaymentLimits")
+	keyScheduledPaymentSpending   = []byte("keyScheduledPaymentSpending")
+	keyScheduledPayments          = []byte("keyScheduledPayments")
+	keySiafundPool                = []byte("keySiafundPool")
+	keySpendableKeyFiles          = []byte("keySpendableKeyFiles")
+	keyUID                        = []byte("keyUID")
+	keyWatchOnlyProgress          = []byte("keyWatchOnlyProgress")
+	keyWatchOnlyWallet            = []byte("keyWatchOnlyWallet")
+	keyWatchedAddrs               = []byte("keyWatchedAddrs")
 )
 
 // threadedDBUpdate commits the active database transaction and starts a new
@@ -255,6 +316,159 @@ func dbGetUnlockConditions(tx *bolt.Tx, addr types.UnlockHash) (uc types.UnlockC
 	return
 }
 
+func dbPutAddressLabel(tx *bolt.Tx, addr types.UnlockHash, label string) error {
+	return dbPut(tx.Bucket(bucketAddressLabels), addr, label)
+}
+func dbDeleteAddressLabel(tx *bolt.Tx, addr types.UnlockHash) error {
+	return dbDelete(tx.Bucket(bucketAddressLabels), addr)
+}
+func dbForEachAddressLabel(tx *bolt.Tx, fn func(types.UnlockHash, string)) error {
+	return dbForEach(tx.Bucket(bucketAddressLabels), fn)
+}
+
+func dbPutPaymentRequest(tx *bolt.Tx, pr modules.PaymentRequest) error {
+	return dbPut(tx.Bucket(bucketPaymentRequests), pr.Address, pr)
+}
+func dbGetPaymentRequest(tx *bolt.Tx, addr types.UnlockHash) (pr modules.PaymentRequest, err error) {
+	err = dbGet(tx.Bucket(bucketPaymentRequests), addr, &pr)
+	return
+}
+func dbForEachPaymentRequest(tx *bolt.Tx, fn func(types.UnlockHash, modules.PaymentRequest)) error {
+	return dbForEach(tx.Bucket(bucketPaymentRequests), fn)
+}
+
+func dbPutPaymentRequestEvent(tx *bolt.Tx, event modules.PaymentRequestEvent) error {
+	return dbPut(tx.Bucket(bucketPaymentRequestEvents), event.Sequence, event)
+}
+func dbDeletePaymentRequestEvent(tx *bolt.Tx, sequence uint64) error {
+	return dbDelete(tx.Bucket(bucketPaymentRequestEvents), sequence)
+}
+func dbForEachPaymentRequestEvent(tx *bolt.Tx, fn func(uint64, modules.PaymentRequestEvent)) error {
+	return dbForEach(tx.Bucket(bucketPaymentRequestEvents), fn)
+}
+
+func dbPutScheduledPayment(tx *bolt.Tx, sp modules.ScheduledPayment) error {
+	return dbPut(tx.Bucket(bucketScheduledPayments), sp.ID, sp)
+}
+func dbGetScheduledPayment(tx *bolt.Tx, id uint64) (sp modules.ScheduledPayment, err error) {
+	err = dbGet(tx.Bucket(bucketScheduledPayments), id, &sp)
+	return
+}
+func dbForEachScheduledPayment(tx *bolt.Tx, fn func(uint64, modules.ScheduledPayment)) error {
+	return dbForEach(tx.Bucket(bucketScheduledPayments), fn)
+}
+
+func dbPutScheduledPaymentExecution(tx *bolt.Tx, execution modules.ScheduledPaymentExecution) error {
+	return dbPut(tx.Bucket(bucketScheduledPaymentExecutions), execution.Sequence, execution)
+}
+func dbDeleteScheduledPaymentExecution(tx *bolt.Tx, sequence uint64) error {
+	return dbDelete(tx.Bucket(bucketScheduledPaymentExecutions), sequence)
+}
+func dbForEachScheduledPaymentExecution(tx *bolt.Tx, fn func(uint64, modules.ScheduledPaymentExecution)) error {
+	return dbForEach(tx.Bucket(bucketScheduledPaymentExecutions), fn)
+}
+
+// In-flight scheduled payments are decoded from a copy of their bytes for the
+// same reason as atomic swaps, because their transactions are rebroadcast.
+func dbPutScheduledPaymentInFlight(tx *bolt.Tx, id uint64, inFlight inFlightScheduledPayment) error {
+	return dbPut(tx.Bucket(bucketScheduledPaymentsInFlight), id, inFlight)
+}
+func dbDeleteScheduledPaymentInFlight(tx *bolt.Tx, id uint64) error {
+	return dbDelete(tx.Bucket(bucketScheduledPaymentsInFlight), id)
+}
+func dbForEachScheduledPaymentInFlight(tx *bolt.Tx, fn func(uint64, inFlightScheduledPayment)) error {
+	return tx.Bucket(bucketScheduledPaymentsInFlight).ForEach(func(k, v []byte) error {
+		var id uint64
+		var inFlight inFlightScheduledPayment
+		if err := encoding.Unmarshal(k, &id); err != nil {
+			return err
+		} else if err := encoding.Unmarshal(append([]byte(nil), v...), &inFlight); err != nil {
+			return err
+		}
+		fn(id, inFlight)
+		return nil
+	})
+}
+
+// Atomic swaps contain transactions, whose byte slices are decoded without
+// copying them. The swaps are decoded from a copy of their bytes, because
+// the memory of the database can be reused once the transaction is
+// committed, while the transactions of a swap are passed to the
+// transaction pool.
+func dbPutAtomicSwap(tx *bolt.Tx, swap modules.AtomicSwap) error {
+	return dbPut(tx.Bucket(bucketAtomicSwaps), swap.ID, swap)
+}
+func dbGetAtomicSwap(tx *bolt.Tx, id crypto.Hash) (swap modules.AtomicSwap, err error) {
+	b := tx.Bucket(bucketAtomicSwaps).Get(encoding.Marshal(id))
+	if b == nil {
+		return modules.AtomicSwap{}, errNoKey
+	}
+	err = encoding.Unmarshal(append([]byte(nil), b...), &swap)
+	return
+}
+func dbForEachAtomicSwap(tx *bolt.Tx, fn func(crypto.Hash, modules.AtomicSwap)) error {
+	return tx.Bucket(bucketAtomicSwaps).ForEach(func(k, v []byte) error {
+		var id crypto.Hash
+		var swap modules.AtomicSwap
+		if err := encoding.Unmarshal(k, &id); err != nil {
+			return err
+		} else if err := encoding.Unmarshal(append([]byte(nil), v...), &swap); err != nil {
+			return err
+		}
+		fn(id, swap)
+		return nil
+	})
+}
+
+func dbPutBumpedTransaction(tx *bolt.Tx, child, parent types.TransactionID) error {
+	return dbPut(tx.Bucket(bucketBumpedTransactions), child, parent)
+}
+func dbGetBumpedTransaction(tx *bolt.Tx, child types.TransactionID) (parent types.TransactionID, err error) {
+	err = dbGet(tx.Bucket(bucketBumpedTransactions), child, &parent)
+	return
+}
+func dbDeleteBumpedTransaction(tx *bolt.Tx, child types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketBumpedTransactions), child)
+}
+func dbPutBumpingTransactions(tx *bolt.Tx, parent types.TransactionID, children []bumpingTransaction) error {
+	return dbPut(tx.Bucket(bucketBumpingTransactions), parent, children)
+}
+func dbGetBumpingTransactions(tx *bolt.Tx, parent types.TransactionID) (children []bumpingTransaction, err error) {
+	err = dbGet(tx.Bucket(bucketBumpingTransactions), parent, &children)
+	return
+}
+func dbDeleteBumpingTransactions(tx *bolt.Tx, parent types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketBumpingTransactions), parent)
+}
+func dbForEachBumpingTransactions(tx *bolt.Tx, fn func(types.TransactionID, []bumpingTransaction)) error {
+	return dbForEach(tx.Bucket(bucketBumpingTransactions), fn)
+}
+
+func dbPutTransactionNote(tx *bolt.Tx, txid types.TransactionID, note string) error {
+	return dbPut(tx.Bucket(bucketTransactionNotes), txid, note)
+}
+func dbGetTransactionNote(tx *bolt.Tx, txid types.TransactionID) (note string, err error) {
+	err = dbGet(tx.Bucket(bucketTransactionNotes), txid, &note)
+	return
+}
+func dbDeleteTransactionNote(tx *bolt.Tx, txid types.TransactionID) error {
+	return dbDelete(tx.Bucket(bucketTransactionNotes), txid)
+}
+
+func dbPutMultisigAddress(tx *bolt.Tx, uc types.UnlockConditions) error {
+	return dbPut(tx.Bucket(bucketMultisigAddresses), uc.UnlockHash(), uc)
+}
+func dbForEachMultisigAddress(tx *bolt.Tx, fn func(types.UnlockHash, types.UnlockConditions)) error {
+	return dbForEach(tx.Bucket(bucketMultisigAddresses), fn)
+}
+
+func dbPutWatchOnlyKey(tx *bolt.Tx, index uint64, pk types.SiaPublicKey) error {
+	return dbPut(tx.Bucket(bucketWatchOnlyKeys), index, pk)
+}
+func dbForEachWatchOnlyKey(tx *bolt.Tx, fn func(uint64, types.SiaPublicKey)) error {
+	return dbForEach(tx.Bucket(bucketWatchOnlyKeys), fn)
+}
+
 // dbAddAddrTransaction appends a single transaction index to the set of
 // transactions associated with addr. If the index is already in the set, it is
 // not added again.
@@ -302,6 +516,13 @@ func dbAddProcessedTransactionAddrs(tx *bolt.Tx, pt modules.ProcessedTransaction
 func decodeProcessedTransaction(ptBytes []byte, pt *modules.ProcessedTransaction) error {
 	err := encoding.Unmarshal(ptBytes, pt)
 	if err != nil {
+		// COMPATv1.3.7: try decoding into the type without the fee bumping,
+		// note and category fields
+		var v137pt v137ProcessedTransaction
+		if err = encoding.Unmarshal(ptBytes, &v137pt); err == nil {
+			*pt = convertV137ProcessedTransaction(v137pt)
+			return nil
+		}
 		// COMPATv1.2.1: try decoding into old transaction type
 		var oldpt v121ProcessedTransaction
 		err = encoding.Unmarshal(ptBytes, &oldpt)
@@ -459,6 +680,109 @@ func dbPutPrimarySeedProgress(tx *bolt.Tx, progress uint64) error {
 	return tx.Bucket(bucketWallet).Put(keyPrimarySeedProgress, encoding.Marshal(progress))
 }
 
+// dbGetWatchOnlyProgress returns the number of watch-only keys that have
+// been used. Wallets that never imported watch-only keys have no progress.
+func dbGetWatchOnlyProgress(tx *bolt.Tx) (progress uint64, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyWatchOnlyProgress)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b, &progress)
+	return
+}
+
+// dbPutWatchOnlyProgress sets the number of watch-only keys that have been
+// used.
+func dbPutWatchOnlyProgress(tx *bolt.Tx, progress uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyWatchOnlyProgress, encoding.Marshal(progress))
+}
+
+// dbGetPaymentRequestEvents returns the number of payment request events
+// that the wallet has recorded, which is the sequence number of the latest
+// event.
+func dbGetPaymentRequestEvents(tx *bolt.Tx) (n uint64, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyPaymentRequestEvents)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b, &n)
+	return
+}
+
+// dbPutPaymentRequestEvents sets the number of payment request events that
+// the wallet has recorded.
+func dbPutPaymentRequestEvents(tx *bolt.Tx, n uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyPaymentRequestEvents, encoding.Marshal(n))
+}
+
+// dbGetScheduledPayments returns the number of scheduled payments that the
+// wallet has created, which is the ID of the latest scheduled payment.
+func dbGetScheduledPayments(tx *bolt.Tx) (n uint64, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyScheduledPayments)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b, &n)
+	return
+}
+
+// dbPutScheduledPayments sets the number of scheduled payments that the
+// wallet has created.
+func dbPutScheduledPayments(tx *bolt.Tx, n uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyScheduledPayments, encoding.Marshal(n))
+}
+
+// dbGetScheduledPaymentExecutions returns the number of scheduled payment
+// executions that the wallet has recorded, which is the sequence number of
+// the latest execution.
+func dbGetScheduledPaymentExecutions(tx *bolt.Tx) (n uint64, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyScheduledPaymentExecutions)
+	if b == nil {
+		return 0, nil
+	}
+	err = encoding.Unmarshal(b, &n)
+	return
+}
+
+// dbPutScheduledPaymentExecutions sets the number of scheduled payment
+// executions that the wallet has recorded.
+func dbPutScheduledPaymentExecutions(tx *bolt.Tx, n uint64) error {
+	return tx.Bucket(bucketWallet).Put(keyScheduledPaymentExecutions, encoding.Marshal(n))
+}
+
+// dbGetScheduledPaymentLimits returns the limits of the scheduled payments.
+// Wallets that never set limits have none.
+func dbGetScheduledPaymentLimits(tx *bolt.Tx) (limits modules.ScheduledPaymentLimits, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyScheduledPaymentLimits)
+	if b == nil {
+		return modules.ScheduledPaymentLimits{}, nil
+	}
+	err = encoding.Unmarshal(b, &limits)
+	return
+}
+
+// dbPutScheduledPaymentLimits sets the limits of the scheduled payments.
+func dbPutScheduledPaymentLimits(tx *bolt.Tx, limits modules.ScheduledPaymentLimits) error {
+	return tx.Bucket(bucketWallet).Put(keyScheduledPaymentLimits, encoding.Marshal(limits))
+}
+
+// dbGetScheduledPaymentSpending returns the scheduled payments that were made
+// within the limit period.
+func dbGetScheduledPaymentSpending(tx *bolt.Tx) (spending []scheduledPaymentSpend, err error) {
+	b := tx.Bucket(bucketWallet).Get(keyScheduledPaymentSpending)
+	if b == nil {
+		return nil, nil
+	}
+	err = encoding.Unmarshal(b, &spending)
+	return
+}
+
+// dbPutScheduledPaymentSpending sets the scheduled payments that were made
+// within the limit period.
+func dbPutScheduledPaymentSpending(tx *bolt.Tx, spending []scheduledPaymentSpend) error {
+	return tx.Bucket(bucketWallet).Put(keyScheduledPaymentSpending, encoding.Marshal(spending))
+}
+
 // dbGetConsensusChangeID returns the ID of the last ConsensusChange processed by the wallet.
 func dbGetConsensusChangeID(tx *bolt.Tx) (cc modules.ConsensusChangeID) {
 	copy(cc[:], tx.Bucket(bucketWallet).Get(keyConsensusChange))
@@ -550,3 +874,25 @@ func convertProcessedTransaction(oldpt v121ProcessedTransaction) (pt modules.Pro
 	}
 	return
 }
+
+// COMPATv137: this type was stored in the db in v1.3.7 and earlier, before
+// the fee bumping, note and category fields were added.
+type v137ProcessedTransaction struct {
+	Transaction           types.Transaction
+	TransactionID         types.TransactionID
+	ConfirmationHeight    types.BlockHeight
+	ConfirmationTimestamp types.Timestamp
+	Inputs                []modules.ProcessedInput
+	Outputs               []modules.ProcessedOutput
+}
+
+func convertV137ProcessedTransaction(oldpt v137ProcessedTransaction) modules.ProcessedTransaction {
+	return modules.ProcessedTransaction{
+		Transaction:           oldpt.Transaction,
+		TransactionID:         oldpt.TransactionID,
+		ConfirmationHeight:    oldpt.ConfirmationHeight,
+		ConfirmationTimestamp: oldpt.ConfirmationTimestamp,
+		Inputs:                oldpt.Inputs,
+		Outputs:               oldpt.Outputs,
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"testing"
 
 	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
 
 	"github.com/coreos/bbolt"
 )
@@ -39,3 +41,33 @@ func TestDBOpen(t *testing.T) {
 	})
 	w.db.Close()
 }
+
+// TestDecodeV137ProcessedTransaction checks that processed transactions that
+// were stored before the fee bumping fields were added can still be decoded.
+func TestDecodeV137ProcessedTransaction(t *testing.T) {
+	old := v137ProcessedTransaction{
+		TransactionID:      types.TransactionID{1},
+		ConfirmationHeight: 5,
+		Outputs: []modules.ProcessedOutput{{
+			FundType: types.SpecifierMinerFee,
+			Value:    types.NewCurrency64(10),
+		}},
+	}
+	var pt modules.ProcessedTransaction
+	if err := decodeProcessedTransaction(encoding.Marshal(old), &pt); err != nil {
+		t.Fatal(err)
+	}
+	if pt.TransactionID != old.TransactionID || pt.ConfirmationHeight != 5 || len(pt.Outputs) != 1 || !pt.Outputs[0].Value.Equals64(10) {
+		t.Fatal("processed transaction was not decoded correctly:", pt)
+	}
+
+	// The current format keeps the fee bumping fields.
+	pt.BumpedBy = []types.TransactionID{{2}}
+	var decoded modules.ProcessedTransaction
+	if err := decodeProcessedTransaction(encoding.Marshal(pt), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.BumpedBy) != 1 || decoded.BumpedBy[0] != pt.BumpedBy[0] {
+		t.Fatal("fee bumping fields were not decoded:", decoded.BumpedBy)
+	}
+}
@@ -33,6 +33,9 @@ func (w *Wallet) managedCreateDefragTransaction() ([]types.Transaction, error) {
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, multisig := w.multisigAddrs[sco.UnlockHash]; multisig || w.isWatchOnlyKeyAddress(sco.UnlockHash) {
+			return
+		}
 		if w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
 			so.ids = append(so.ids, scoid)
 			so.outputs = append(so.outputs, sco)
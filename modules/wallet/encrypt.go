@@ -110,6 +110,9 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
 	var watchedAddrs []types.UnlockHash
+	var multisigAddrs []types.UnlockConditions
+	var watchOnlyKeys []types.SiaPublicKey
+	var watchOnlyProgress uint64
 	err := func() error {
 		w.mu.Lock()
 		defer w.mu.Unlock()
@@ -123,11 +126,14 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		// lastChange
 		lastChange = dbGetConsensusChangeID(w.dbTx)
 
-		// primarySeedFile + primarySeedProgress
+		// primarySeedFile + primarySeedProgress. Watch-only wallets have no
+		// primary seed.
 		wb := w.dbTx.Bucket(bucketWallet)
-		err = encoding.Unmarshal(wb.Get(keyPrimarySeedFile), &primarySeedFile)
-		if err != nil {
-			return err
+		if !w.watchOnly {
+			err = encoding.Unmarshal(wb.Get(keyPrimarySeedFile), &primarySeedFile)
+			if err != nil {
+				return err
+			}
 		}
 		err = encoding.Unmarshal(wb.Get(keyPrimarySeedProgress), &primarySeedProgress)
 		if err != nil {
@@ -152,7 +158,26 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 			return err
 		}
 
-		return nil
+		// multisigAddrs
+		err = dbForEachMultisigAddress(w.dbTx, func(_ types.UnlockHash, uc types.UnlockConditions) {
+			multisigAddrs = append(multisigAddrs, uc)
+		})
+		if err != nil {
+			return err
+		}
+
+		// watchOnlyKeys + watchOnlyProgress
+		err = dbForEachWatchOnlyKey(w.dbTx, func(index uint64, pk types.SiaPublicKey) {
+			for uint64(len(watchOnlyKeys)) <= index {
+				watchOnlyKeys = append(watchOnlyKeys, types.SiaPublicKey{})
+			}
+			watchOnlyKeys[index] = pk
+		})
+		if err != nil {
+			return err
+		}
+		watchOnlyProgress, err = dbGetWatchOnlyProgress(w.dbTx)
+		return err
 	}()
 	if err != nil {
 		return err
@@ -164,13 +189,15 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 		defer w.mu.Unlock()
 
 		// primarySeedFile
-		primarySeed, err := decryptSeedFile(masterKey, primarySeedFile)
-		if err != nil {
-			return err
+		if !w.watchOnly {
+			primarySeed, err := decryptSeedFile(masterKey, primarySeedFile)
+			if err != nil {
+				return err
+			}
+			w.integrateSeed(primarySeed, primarySeedProgress)
+			w.primarySeed = primarySeed
+			w.regenerateLookahead(primarySeedProgress)
 		}
-		w.integrateSeed(primarySeed, primarySeedProgress)
-		w.primarySeed = primarySeed
-		w.regenerateLookahead(primarySeedProgress)
 
 		// auxiliarySeedFiles
 		for _, sf := range auxiliarySeedFiles {
@@ -196,6 +223,17 @@ func (w *Wallet) managedUnlock(masterKey crypto.TwofishKey) error {
 			w.watchedAddrs[addr] = struct{}{}
 		}
 
+		// multisigAddrs
+		for _, uc := range multisigAddrs {
+			w.multisigAddrs[uc.UnlockHash()] = uc
+		}
+
+		// watchOnlyKeys
+		w.watchOnlyKeys = nil
+		w.watchOnlyIndices = make(map[types.UnlockHash]uint64)
+		w.integrateWatchOnlyKeys(0, watchOnlyKeys)
+		w.watchOnlyProgress = watchOnlyProgress
+
 		return nil
 	}()
 	if err != nil {
@@ -354,11 +392,15 @@ func (w *Wallet) Reset() error {
 	w.wipeSecrets()
 	w.keys = make(map[types.UnlockHash]spendableKey)
 	w.lookahead = make(map[types.UnlockHash]uint64)
+	w.watchOnlyKeys = nil
+	w.watchOnlyIndices = make(map[types.UnlockHash]uint64)
+	w.watchOnlyProgress = 0
 	w.seeds = []modules.Seed{}
 	w.unconfirmedProcessedTransactions = []modules.ProcessedTransaction{}
 	w.unlocked = false
 	w.encrypted = false
 	w.subscribed = false
+	w.watchOnly = false
 
 	return nil
 }
@@ -407,6 +449,47 @@ func (w *Wallet) InitFromSeed(masterKey crypto.TwofishKey, seed modules.Seed) er
 	return err
 }
 
+// InitWatchOnly initializes the wallet as a watch-only wallet. A watch-only
+// wallet has no seed and never holds secret keys; it only tracks the
+// watch-only keys that are imported from a cold wallet, and builds unsigned
+// transactions for the cold wallet to sign. Since there is no seed to derive
+// the masterKey from, it can't be blank.
+func (w *Wallet) InitWatchOnly(masterKey crypto.TwofishKey) error {
+	if err := w.tg.Add(); err != nil {
+		return err
+	}
+	defer w.tg.Done()
+	if masterKey == (crypto.TwofishKey{}) {
+		return errWatchOnlyBlankKey
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	wb := w.dbTx.Bucket(bucketWallet)
+	if wb.Get(keyEncryptionVerification) != nil {
+		return errReencrypt
+	}
+	err := wb.Put(keyWatchOnlyWallet, encoding.Marshal(true))
+	if err != nil {
+		return err
+	}
+	err = wb.Put(keyPrimarySeedProgress, encoding.Marshal(uint64(0)))
+	if err != nil {
+		return err
+	}
+
+	// Establish the encryption verification using the masterKey. After this
+	// point, the wallet is encrypted.
+	uk := uidEncryptionKey(masterKey, dbGetWalletUID(w.dbTx))
+	err = wb.Put(keyEncryptionVerification, uk.EncryptBytes(verificationPlaintext))
+	if err != nil {
+		return err
+	}
+	w.encrypted = true
+	w.watchOnly = true
+	return nil
+}
+
 // Unlocked indicates whether the wallet is locked or unlocked.
 func (w *Wallet) Unlocked() (bool, error) {
 	if err := w.tg.Add(); err != nil {
@@ -475,6 +558,7 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 	}
 
 	// grab the current seed files
+	var watchOnly bool
 	var primarySeedFile seedFile
 	var auxiliarySeedFiles []seedFile
 	var unseededKeyFiles []spendableKeyFile
@@ -492,9 +576,12 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 		wb := w.dbTx.Bucket(bucketWallet)
 
 		// primarySeedFile
-		err = encoding.Unmarshal(wb.Get(keyPrimarySeedFile), &primarySeedFile)
-		if err != nil {
-			return err
+		watchOnly = w.watchOnly
+		if !watchOnly {
+			err = encoding.Unmarshal(wb.Get(keyPrimarySeedFile), &primarySeedFile)
+			if err != nil {
+				return err
+			}
 		}
 
 		// auxiliarySeedFiles
@@ -520,9 +607,11 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 	var auxiliarySeeds []modules.Seed
 	var spendableKeys []spendableKey
 
-	primarySeed, err = decryptSeedFile(masterKey, primarySeedFile)
-	if err != nil {
-		return err
+	if !watchOnly {
+		primarySeed, err = decryptSeedFile(masterKey, primarySeedFile)
+		if err != nil {
+			return err
+		}
 	}
 	for _, sf := range auxiliarySeedFiles {
 		auxSeed, err := decryptSeedFile(masterKey, sf)
@@ -566,9 +655,11 @@ func (w *Wallet) managedChangeKey(masterKey crypto.TwofishKey, newKey crypto.Two
 
 		wb := w.dbTx.Bucket(bucketWallet)
 
-		err = wb.Put(keyPrimarySeedFile, encoding.Marshal(newPrimarySeedFile))
-		if err != nil {
-			return err
+		if !watchOnly {
+			err = wb.Put(keyPrimarySeedFile, encoding.Marshal(newPrimarySeedFile))
+			if err != nil {
+				return err
+			}
 		}
 		err = wb.Put(keyAuxiliarySeedFiles, encoding.Marshal(newAuxiliarySeedFiles))
 		if err != nil {
@@ -0,0 +1,114 @@
+package wallet
+
+import (
+	"errors"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// maxLabelLength is the maximum length in bytes of an address label.
+	maxLabelLength = 256
+
+	// maxNoteLength is the maximum length in bytes of a transaction note.
+	maxNoteLength = 4096
+)
+
+var (
+	// errLabelTooLong is returned if an address label exceeds
+	// maxLabelLength.
+	errLabelTooLong = errors.New("address label is too long")
+
+	// errNoteTooLong is returned if a transaction note exceeds maxNoteLength.
+	errNoteTooLong = errors.New("transaction note is too long")
+
+	// errUnknownTransaction is returned when adding a note to a transaction
+	// that is not a transaction of the wallet.
+	errUnknownTransaction = errors.New("transaction is not a transaction of the wallet")
+)
+
+// annotateTransaction fills in the note and the categories of pt.
+func annotateTransaction(tx *bolt.Tx, pt *modules.ProcessedTransaction) {
+	pt.Note, _ = dbGetTransactionNote(tx, pt.TransactionID)
+	pt.Categories = modules.TransactionCategories(*pt)
+}
+
+// AddressLabels returns the labels of all labeled addresses.
+func (w *Wallet) AddressLabels() ([]modules.AddressLabel, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var labels []modules.AddressLabel
+	err := dbForEachAddressLabel(w.dbTx, func(addr types.UnlockHash, label string) {
+		labels = append(labels, modules.AddressLabel{
+			Address: addr,
+			Label:   label,
+		})
+	})
+	return labels, err
+}
+
+// SetAddressLabel sets the label of an address. The address doesn't have to
+// belong to the wallet, so that e.g. the addresses of customers can be
+// labeled as well. An empty label removes the label.
+func (w *Wallet) SetAddressLabel(addr types.UnlockHash, label string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(label) > maxLabelLength {
+		return errLabelTooLong
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var err error
+	if label == "" {
+		err = dbDeleteAddressLabel(w.dbTx, addr)
+	} else {
+		err = dbPutAddressLabel(w.dbTx, addr, label)
+	}
+	if err != nil {
+		return err
+	}
+	return w.syncDB()
+}
+
+// SetTransactionNote sets the note of a confirmed or unconfirmed transaction
+// of the wallet. An empty note removes the note.
+func (w *Wallet) SetTransactionNote(txid types.TransactionID, note string) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(note) > maxNoteLength {
+		return errNoteTooLong
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	_, err := dbGetTransactionIndex(w.dbTx, txid)
+	known := err == nil
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		known = known || upt.TransactionID == txid
+	}
+	if !known {
+		return errUnknownTransaction
+	}
+	if note == "" {
+		err = dbDeleteTransactionNote(w.dbTx, txid)
+	} else {
+		err = dbPutTransactionNote(w.dbTx, txid, note)
+	}
+	if err != nil {
+		return err
+	}
+	return w.syncDB()
+}
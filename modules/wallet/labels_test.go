@@ -0,0 +1,103 @@
+package wallet
+
+import (
+	"strings"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestAddressLabels checks that address labels are stored and removed.
+func TestAddressLabels(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	addr := types.UnlockHash{1}
+	if err := wt.wallet.SetAddressLabel(addr, strings.Repeat("a", maxLabelLength+1)); err != errLabelTooLong {
+		t.Fatal("expected errLabelTooLong, got", err)
+	}
+	if err := wt.wallet.SetAddressLabel(addr, "customer"); err != nil {
+		t.Fatal(err)
+	}
+	labels, err := wt.wallet.AddressLabels()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(labels) != 1 || labels[0].Address != addr || labels[0].Label != "customer" {
+		t.Fatal("wrong labels:", labels)
+	}
+	if err := wt.wallet.SetAddressLabel(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	if labels, err := wt.wallet.AddressLabels(); err != nil || len(labels) != 0 {
+		t.Fatal("label was not removed:", labels, err)
+	}
+}
+
+// TestTransactionNotes checks that notes can be added to unconfirmed and
+// confirmed transactions, and that transactions are categorized.
+func TestTransactionNotes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	if err := wt.wallet.SetTransactionNote(types.TransactionID{1}, "note"); err != errUnknownTransaction {
+		t.Fatal("expected errUnknownTransaction, got", err)
+	}
+
+	// Add a note to an unconfirmed transaction.
+	txns, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(100), types.UnlockHash{1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := txns[len(txns)-1].ID()
+	if err := wt.wallet.SetTransactionNote(txid, "invoice 42"); err != nil {
+		t.Fatal(err)
+	}
+	upts, err := wt.wallet.UnconfirmedTransactions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found bool
+	for _, upt := range upts {
+		found = found || (upt.TransactionID == txid && upt.Note == "invoice 42")
+	}
+	if !found {
+		t.Fatal("unconfirmed transaction should have the note")
+	}
+
+	// The note is kept when the transaction is confirmed.
+	wt.addBlockNoPayout()
+	pt, ok, err := wt.wallet.Transaction(txid)
+	if err != nil || !ok {
+		t.Fatal("transaction was not confirmed:", err)
+	}
+	if pt.Note != "invoice 42" || len(pt.Categories) != 0 {
+		t.Fatal("wrong note or categories:", pt.Note, pt.Categories)
+	}
+
+	// Miner payouts are categorized.
+	height, err := wt.wallet.Height()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pts, err := wt.wallet.Transactions(0, height)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pts[0].Categories) != 1 || pts[0].Categories[0] != modules.TransactionCategoryMinerPayout {
+		t.Fatal("miner payout was not categorized:", pts[0].Categories)
+	}
+}
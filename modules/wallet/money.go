@@ -49,7 +49,13 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 		return
 	}
 
+	// The outputs of multisig addresses are shared with the co-signers and
+	// the outputs of watch-only keys are held by a cold wallet, so neither
+	// are part of the balance.
 	dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, multisig := w.multisigAddrs[sco.UnlockHash]; multisig || w.isWatchOnlyKeyAddress(sco.UnlockHash) {
+			return
+		}
 		if sco.Value.Cmp(dustThreshold) > 0 {
 			siacoinBalance = siacoinBalance.Add(sco.Value)
 		}
@@ -60,6 +66,9 @@ func (w *Wallet) ConfirmedBalance() (siacoinBalance types.Currency, siafundBalan
 		return
 	}
 	dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if _, multisig := w.multisigAddrs[sfo.UnlockHash]; multisig || w.isWatchOnlyKeyAddress(sfo.UnlockHash) {
+			return
+		}
 		siafundBalance = siafundBalance.Add(sfo.Value)
 		if sfo.ClaimStart.Cmp(siafundPool) > 0 {
 			// Skip claims larger than the siafund pool. This should only
@@ -92,11 +101,17 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 
 	for _, upt := range w.unconfirmedProcessedTransactions {
 		for _, input := range upt.Inputs {
+			if _, multisig := w.multisigAddrs[input.RelatedAddress]; multisig || w.isWatchOnlyKeyAddress(input.RelatedAddress) {
+				continue
+			}
 			if input.FundType == types.SpecifierSiacoinInput && input.WalletAddress {
 				outgoingSiacoins = outgoingSiacoins.Add(input.Value)
 			}
 		}
 		for _, output := range upt.Outputs {
+			if _, multisig := w.multisigAddrs[output.RelatedAddress]; multisig || w.isWatchOnlyKeyAddress(output.RelatedAddress) {
+				continue
+			}
 			if output.FundType == types.SpecifierSiacoinOutput && output.WalletAddress && output.Value.Cmp(dustThreshold) > 0 {
 				incomingSiacoins = incomingSiacoins.Add(output.Value)
 			}
@@ -108,6 +123,19 @@ func (w *Wallet) UnconfirmedBalance() (outgoingSiacoins types.Currency, incoming
 // SendSiacoins creates a transaction sending 'amount' to 'dest'. The transaction
 // is submitted to the transaction pool and is also returned.
 func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txns []types.Transaction, err error) {
+	return w.managedSendSiacoins(amount, dest, nil)
+}
+
+// SendSiacoinsFromOutputs creates a transaction sending 'amount' to 'dest'
+// that is funded by the selected outputs only. The transaction is submitted
+// to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsFromOutputs(amount types.Currency, dest types.UnlockHash, selection modules.SiacoinSelection) (txns []types.Transaction, err error) {
+	return w.managedSendSiacoins(amount, dest, &selection)
+}
+
+// managedSendSiacoins sends 'amount' to 'dest', funding the transaction from
+// the selected outputs, or from any outputs of the wallet if selection is nil.
+func (w *Wallet) managedSendSiacoins(amount types.Currency, dest types.UnlockHash, selection *modules.SiacoinSelection) (txns []types.Transaction, err error) {
 	if err := w.tg.Add(); err != nil {
 		err = modules.ErrWalletShutdown
 		return nil, err
@@ -123,7 +151,11 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 	}
 
 	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
+	if selection == nil {
+		tpoolFee = tpoolFee.Mul64(750) // Estimated transaction size in bytes
+	} else {
+		tpoolFee = tpoolFee.Mul64(500 + 250*uint64(len(selection.Outputs))) // Estimated transaction size in bytes
+	}
 	output := types.SiacoinOutput{
 		Value:      amount,
 		UnlockHash: dest,
@@ -138,7 +170,11 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 			txnBuilder.Drop()
 		}
 	}()
-	err = txnBuilder.FundSiacoins(amount.Add(tpoolFee))
+	if selection == nil {
+		err = txnBuilder.FundSiacoins(amount.Add(tpoolFee))
+	} else {
+		err = txnBuilder.FundSiacoinsFromOutputs(amount.Add(tpoolFee), *selection)
+	}
 	if err != nil {
 		w.log.Println("Attempt to send coins has failed - failed to fund transaction:", err)
 		return nil, build.ExtendErr("unable to fund transaction", err)
@@ -169,6 +205,21 @@ func (w *Wallet) SendSiacoins(amount types.Currency, dest types.UnlockHash) (txn
 // outputs. The transaction is submitted to the transaction pool and is also
 // returned.
 func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.Transaction, err error) {
+	return w.managedSendSiacoinsMulti(outputs, nil, nil)
+}
+
+// SendSiacoinsMultiFromOutputs creates a transaction that includes the
+// specified outputs and is funded by the selected outputs only. The
+// transaction is submitted to the transaction pool and is also returned.
+func (w *Wallet) SendSiacoinsMultiFromOutputs(outputs []types.SiacoinOutput, selection modules.SiacoinSelection) (txns []types.Transaction, err error) {
+	return w.managedSendSiacoinsMulti(outputs, &selection, nil)
+}
+
+// managedSendSiacoinsMulti sends the outputs, funding the transaction from
+// the selected outputs, or from any outputs of the wallet if selection is nil.
+// If beforeBroadcast is not nil, it is called with the signed transaction set
+// before the set is broadcast, and the set is dropped if it returns an error.
+func (w *Wallet) managedSendSiacoinsMulti(outputs []types.SiacoinOutput, selection *modules.SiacoinSelection, beforeBroadcast func([]types.Transaction) error) (txns []types.Transaction, err error) {
 	w.log.Println("Beginning call to SendSiacoinsMulti")
 	if err := w.tg.Add(); err != nil {
 		err = modules.ErrWalletShutdown
@@ -195,8 +246,12 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 
 	// Add estimated transaction fee.
 	_, tpoolFee := w.tpool.FeeEstimation()
-	tpoolFee = tpoolFee.Mul64(2)                              // We don't want send-to-many transactions to fail.
-	tpoolFee = tpoolFee.Mul64(1000 + 60*uint64(len(outputs))) // Estimated transaction size in bytes
+	tpoolFee = tpoolFee.Mul64(2)              // We don't want send-to-many transactions to fail.
+	txnSize := 1000 + 60*uint64(len(outputs)) // Estimated transaction size in bytes
+	if selection != nil {
+		txnSize += 250 * uint64(len(selection.Outputs)) // Selected outputs are spent directly
+	}
+	tpoolFee = tpoolFee.Mul64(txnSize)
 	txnBuilder.AddMinerFee(tpoolFee)
 
 	// Calculate total cost to wallet.
@@ -208,7 +263,11 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	for _, sco := range outputs {
 		totalCost = totalCost.Add(sco.Value)
 	}
-	err = txnBuilder.FundSiacoins(totalCost)
+	if selection == nil {
+		err = txnBuilder.FundSiacoins(totalCost)
+	} else {
+		err = txnBuilder.FundSiacoinsFromOutputs(totalCost, *selection)
+	}
 	if err != nil {
 		return nil, build.ExtendErr("unable to fund transaction", err)
 	}
@@ -225,6 +284,11 @@ func (w *Wallet) SendSiacoinsMulti(outputs []types.SiacoinOutput) (txns []types.
 	if w.deps.Disrupt("SendSiacoinsInterrupted") {
 		return nil, errors.New("failed to accept transaction set (SendSiacoinsInterrupted)")
 	}
+	if beforeBroadcast != nil {
+		if err = beforeBroadcast(txnSet); err != nil {
+			return nil, err
+		}
+	}
 	w.log.Println("Attempting to broadcast a multi-send over the network")
 	err = w.tpool.AcceptTransactionSet(txnSet)
 	if err != nil {
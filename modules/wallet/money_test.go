@@ -275,3 +275,102 @@ func TestSendSiacoinsAcceptTxnSetFailed(t *testing.T) {
 		t.Fatalf("SendSiacoins failed: %v", err)
 	}
 }
+
+// TestSendSiacoinsFromOutputs checks that the wallet only spends the selected
+// outputs when the caller chooses them, and sends the change to the chosen
+// address.
+func TestSendSiacoinsFromOutputs(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Split the outputs of the wallet, and pick two of them.
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.SendSiacoins(types.SiacoinPrecision.Mul64(1000), uc.UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []types.SiacoinOutputID
+	values := make(map[types.SiacoinOutputID]types.Currency)
+	for _, uo := range outputs {
+		if uo.FundType == types.SpecifierSiacoinOutput {
+			ids = append(ids, types.SiacoinOutputID(uo.ID))
+			values[types.SiacoinOutputID(uo.ID)] = uo.Value
+		}
+	}
+	if len(ids) < 2 {
+		t.Fatal("wallet should have at least two siacoin outputs, has", len(ids))
+	}
+
+	// Invalid selections are rejected by the transaction builder.
+	amount := types.SiacoinPrecision.Mul64(100)
+	dest := types.UnlockHash{1}
+	tb, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.FundSiacoinsFromOutputs(amount, modules.SiacoinSelection{}); err != errNoSelectedOutputs {
+		t.Fatal("expected errNoSelectedOutputs, got", err)
+	}
+	dup := modules.SiacoinSelection{Outputs: []types.SiacoinOutputID{ids[0], ids[0]}}
+	if err := tb.FundSiacoinsFromOutputs(amount, dup); err != errDuplicateSelectedOutput {
+		t.Fatal("expected errDuplicateSelectedOutput, got", err)
+	}
+	unknown := modules.SiacoinSelection{Outputs: []types.SiacoinOutputID{{1}}}
+	if err := tb.FundSiacoinsFromOutputs(amount, unknown); err != errUnknownSelectedOutput {
+		t.Fatal("expected errUnknownSelectedOutput, got", err)
+	}
+	tb.Drop()
+
+	// Spend a single output, sending the change to a chosen address.
+	change := types.UnlockHash{2}
+	selection := modules.SiacoinSelection{Outputs: ids[:1], ChangeAddress: change}
+	txns, err := wt.wallet.SendSiacoinsFromOutputs(amount, dest, selection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns) != 1 {
+		t.Fatal("selected outputs should be spent without a parent transaction")
+	}
+	txn := txns[0]
+	if len(txn.SiacoinInputs) != 1 || txn.SiacoinInputs[0].ParentID != ids[0] {
+		t.Fatal("transaction did not spend exactly the selected output:", txn.SiacoinInputs)
+	}
+	var changeValue types.Currency
+	for _, sco := range txn.SiacoinOutputs {
+		if sco.UnlockHash == change {
+			changeValue = sco.Value
+		}
+	}
+	if !changeValue.Add(amount).Add(txn.MinerFees[0]).Equals(values[ids[0]]) {
+		t.Fatal("wrong change:", changeValue)
+	}
+	// The output can't be selected again until the transaction confirms.
+	if _, err := wt.wallet.SendSiacoinsFromOutputs(amount, dest, selection); err == nil {
+		t.Fatal("spent output should not be spendable")
+	}
+
+	// Spend all of the remaining selected outputs, even though one of them
+	// would cover the amount.
+	selection = modules.SiacoinSelection{Outputs: ids[1:], SpendAll: true}
+	txns, err = wt.wallet.SendSiacoinsMultiFromOutputs([]types.SiacoinOutput{{Value: amount, UnlockHash: dest}}, selection)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txns[0].SiacoinInputs) != len(ids)-1 {
+		t.Fatal("not all of the selected outputs were spent")
+	}
+	wt.addBlockNoPayout()
+}
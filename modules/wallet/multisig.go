@@ -0,0 +1,468 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errDuplicateMultisigKey is returned if a public key appears more than
+	// once in the unlock conditions of a multisig address.
+	errDuplicateMultisigKey = errors.New("multisig address contains a public key more than once")
+
+	// errInvalidMultisigKey is returned if a public key of a multisig
+	// address is not an ed25519 key.
+	errInvalidMultisigKey = errors.New("multisig address keys must be ed25519 keys")
+
+	// errInvalidSignaturesRequired is returned if the number of required
+	// signatures of a multisig address is zero or exceeds the number of
+	// public keys.
+	errInvalidSignaturesRequired = errors.New("number of required signatures must be between 1 and the number of public keys")
+
+	// errMultisigTransactionMismatch is returned if the transactions that
+	// should be merged differ in anything but their signatures.
+	errMultisigTransactionMismatch = errors.New("transactions can only be merged if they are the same transaction")
+
+	// errNoLocalMultisigKey is returned if none of the public keys of a
+	// multisig address belong to the wallet.
+	errNoLocalMultisigKey = errors.New("none of the public keys belong to the wallet")
+
+	// errNoMultisigInputs is returned if a transaction doesn't spend any
+	// outputs of the multisig addresses of the wallet.
+	errNoMultisigInputs = errors.New("transaction does not spend from a multisig address of the wallet")
+
+	// errNoTransactionsToMerge is returned if no transactions were provided
+	// to merge.
+	errNoTransactionsToMerge = errors.New("no transactions to merge")
+
+	// errUnknownMultisigAddress is returned if the wallet doesn't track the
+	// multisig address.
+	errUnknownMultisigAddress = errors.New("address is not a multisig address of the wallet")
+)
+
+// multisigSecretKey returns the secret key of the wallet that belongs to the
+// public key. The keys of a multisig address are regular seed keys, which the
+// wallet tracks by their single-signature address.
+func (w *Wallet) multisigSecretKey(pk types.SiaPublicKey) (crypto.SecretKey, bool) {
+	uc := types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}
+	sk, ok := w.keys[uc.UnlockHash()]
+	if !ok || len(sk.SecretKeys) != 1 {
+		return crypto.SecretKey{}, false
+	}
+	pubKey := sk.SecretKeys[0].PublicKey()
+	return sk.SecretKeys[0], bytes.Equal(pk.Key, pubKey[:])
+}
+
+// multisigLocalKeys returns the indices of the public keys of the unlock
+// conditions that belong to the wallet.
+func (w *Wallet) multisigLocalKeys(uc types.UnlockConditions) []uint64 {
+	var local []uint64
+	for i, pk := range uc.PublicKeys {
+		if _, ok := w.multisigSecretKey(pk); ok {
+			local = append(local, uint64(i))
+		}
+	}
+	return local
+}
+
+// addWholeSignatures adds signatures that cover the whole transaction to the
+// input with the given parent, using the keys returned by secretKey, until
+// the input has the number of signatures that its unlock conditions require.
+// Whole transaction signatures don't cover the other signatures, so that the
+// co-signers can add their signatures in any order.
+func addWholeSignatures(txn *types.Transaction, parentID crypto.Hash, uc types.UnlockConditions, height types.BlockHeight, secretKey func(types.UnlockConditions, uint64) (crypto.SecretKey, bool)) {
+	signed := make(map[uint64]struct{})
+	for _, sig := range txn.TransactionSignatures {
+		if sig.ParentID == parentID {
+			signed[sig.PublicKeyIndex] = struct{}{}
+		}
+	}
+	for i := range uc.PublicKeys {
+		if uint64(len(signed)) >= uc.SignaturesRequired {
+			return
+		}
+		if _, ok := signed[uint64(i)]; ok {
+			continue
+		}
+		sk, ok := secretKey(uc, uint64(i))
+		if !ok {
+			continue
+		}
+		txn.TransactionSignatures = append(txn.TransactionSignatures, types.TransactionSignature{
+			ParentID:       parentID,
+			CoveredFields:  types.CoveredFields{WholeTransaction: true},
+			PublicKeyIndex: uint64(i),
+		})
+		sigIndex := len(txn.TransactionSignatures) - 1
+		encodedSig := crypto.SignHash(txn.SigHash(sigIndex, height), sk)
+		txn.TransactionSignatures[sigIndex].Signature = encodedSig[:]
+		signed[uint64(i)] = struct{}{}
+	}
+}
+
+// signMultisigInputs adds the signatures of the wallet to every input of the
+// transaction that spends from a multisig address of the wallet, until the
+// input has the required number of signatures.
+func (w *Wallet) signMultisigInputs(txn *types.Transaction, height types.BlockHeight) error {
+	secretKey := func(uc types.UnlockConditions, i uint64) (crypto.SecretKey, bool) {
+		return w.multisigSecretKey(uc.PublicKeys[i])
+	}
+	var found bool
+	for _, sci := range txn.SiacoinInputs {
+		if _, ok := w.multisigAddrs[sci.UnlockConditions.UnlockHash()]; ok {
+			found = true
+			addWholeSignatures(txn, crypto.Hash(sci.ParentID), sci.UnlockConditions, height, secretKey)
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		if _, ok := w.multisigAddrs[sfi.UnlockConditions.UnlockHash()]; ok {
+			found = true
+			addWholeSignatures(txn, crypto.Hash(sfi.ParentID), sfi.UnlockConditions, height, secretKey)
+		}
+	}
+	if !found {
+		return errNoMultisigInputs
+	}
+	return nil
+}
+
+// mergeSignatures combines the signatures of copies of the same transaction.
+// Duplicate signatures are dropped, and each input keeps at most the number
+// of signatures that it requires, since consensus rejects transactions with
+// extra signatures.
+func mergeSignatures(txns []types.Transaction) (types.Transaction, error) {
+	if len(txns) == 0 {
+		return types.Transaction{}, errNoTransactionsToMerge
+	}
+	// The id of a transaction doesn't include its signatures.
+	merged := txns[0]
+	merged.TransactionSignatures = nil
+	id := merged.ID()
+	required := make(map[crypto.Hash]uint64)
+	for _, sci := range merged.SiacoinInputs {
+		required[crypto.Hash(sci.ParentID)] = sci.UnlockConditions.SignaturesRequired
+	}
+	for _, sfi := range merged.SiafundInputs {
+		required[crypto.Hash(sfi.ParentID)] = sfi.UnlockConditions.SignaturesRequired
+	}
+
+	type sigKey struct {
+		parentID       crypto.Hash
+		publicKeyIndex uint64
+	}
+	seen := make(map[sigKey]struct{})
+	counts := make(map[crypto.Hash]uint64)
+	for _, txn := range txns {
+		if txn.ID() != id {
+			return types.Transaction{}, errMultisigTransactionMismatch
+		}
+		for _, sig := range txn.TransactionSignatures {
+			key := sigKey{sig.ParentID, sig.PublicKeyIndex}
+			if _, exists := seen[key]; exists {
+				continue
+			}
+			if n, isInput := required[sig.ParentID]; isInput && counts[sig.ParentID] >= n {
+				continue
+			}
+			seen[key] = struct{}{}
+			counts[sig.ParentID]++
+			merged.TransactionSignatures = append(merged.TransactionSignatures, sig)
+		}
+	}
+	return merged, nil
+}
+
+// multisigTransaction wraps the transaction with whether it is valid at the
+// provided height, which is the case once every input has enough signatures.
+func multisigTransaction(txn types.Transaction, height types.BlockHeight) modules.MultisigTransaction {
+	return modules.MultisigTransaction{
+		Transaction: txn,
+		Complete:    txn.StandaloneValid(height) == nil,
+	}
+}
+
+// CreateMultisigAddress creates an address that requires the given number of
+// signatures from the public keys, and starts tracking it. At least one of
+// the keys must belong to the wallet. If the address has not appeared in the
+// blockchain, the unused flag may be set to true. Otherwise, the wallet
+// rescans the blockchain to find the outputs of the address.
+func (w *Wallet) CreateMultisigAddress(required uint64, publicKeys []types.SiaPublicKey, unused bool) (modules.MultisigAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigAddress{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	if required == 0 || required > uint64(len(publicKeys)) {
+		return modules.MultisigAddress{}, errInvalidSignaturesRequired
+	}
+	seen := make(map[string]struct{})
+	for _, pk := range publicKeys {
+		if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+			return modules.MultisigAddress{}, errInvalidMultisigKey
+		}
+		if _, exists := seen[pk.String()]; exists {
+			return modules.MultisigAddress{}, errDuplicateMultisigKey
+		}
+		seen[pk.String()] = struct{}{}
+	}
+	uc := types.UnlockConditions{
+		PublicKeys:         append([]types.SiaPublicKey(nil), publicKeys...),
+		SignaturesRequired: required,
+	}
+
+	var ma modules.MultisigAddress
+	var rescan bool
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		localKeys := w.multisigLocalKeys(uc)
+		if len(localKeys) == 0 {
+			return errNoLocalMultisigKey
+		}
+		ma = modules.MultisigAddress{
+			Address:          uc.UnlockHash(),
+			UnlockConditions: uc,
+			LocalKeys:        localKeys,
+		}
+		if _, exists := w.multisigAddrs[ma.Address]; exists {
+			return nil
+		}
+
+		if err := dbPutMultisigAddress(w.dbTx, uc); err != nil {
+			return err
+		}
+		w.multisigAddrs[ma.Address] = uc
+		if !unused {
+			// prepare to rescan
+			if err := w.prepareRescan(); err != nil {
+				return err
+			}
+			rescan = true
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return modules.MultisigAddress{}, err
+	}
+
+	if rescan {
+		// rescan the blockchain
+		if err := w.managedRescan(); err != nil {
+			return modules.MultisigAddress{}, err
+		}
+	}
+	return ma, nil
+}
+
+// MultisigAddresses returns the multisig addresses that the wallet tracks,
+// together with their confirmed balances.
+func (w *Wallet) MultisigAddresses() ([]modules.MultisigAddress, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return nil, modules.ErrLockedWallet
+	}
+
+	siacoins := make(map[types.UnlockHash]types.Currency)
+	siafunds := make(map[types.UnlockHash]types.Currency)
+	err := dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if _, multisig := w.multisigAddrs[sco.UnlockHash]; multisig {
+			siacoins[sco.UnlockHash] = siacoins[sco.UnlockHash].Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if _, multisig := w.multisigAddrs[sfo.UnlockHash]; multisig {
+			siafunds[sfo.UnlockHash] = siafunds[sfo.UnlockHash].Add(sfo.Value)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := make([]modules.MultisigAddress, 0, len(w.multisigAddrs))
+	for addr, uc := range w.multisigAddrs {
+		uc.PublicKeys = append([]types.SiaPublicKey(nil), uc.PublicKeys...)
+		addrs = append(addrs, modules.MultisigAddress{
+			Address:          addr,
+			UnlockConditions: uc,
+			LocalKeys:        w.multisigLocalKeys(uc),
+			SiacoinBalance:   siacoins[addr],
+			SiafundBalance:   siafunds[addr],
+		})
+	}
+	sort.Slice(addrs, func(i, j int) bool {
+		return addrs[i].Address.String() < addrs[j].Address.String()
+	})
+	return addrs, nil
+}
+
+// MultisigPublicKey returns the public key of a new address of the primary
+// seed. The key can be shared with co-signers to create a multisig address.
+func (w *Wallet) MultisigPublicKey() (types.SiaPublicKey, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.SiaPublicKey{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	uc, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	if err := w.syncDB(); err != nil {
+		return types.SiaPublicKey{}, err
+	}
+	return uc.PublicKeys[0], nil
+}
+
+// NewMultisigTransaction creates a transaction that sends the outputs from
+// the multisig address, paying the fee and returning the change to the
+// address. The transaction is signed with the keys of the wallet, the
+// remaining signatures have to be added by the co-signers. The spent outputs
+// are not used for other multisig transactions of the wallet until
+// RespendTimeout blocks have passed.
+func (w *Wallet) NewMultisigTransaction(addr types.UnlockHash, outputs []types.SiacoinOutput, fee types.Currency) (modules.MultisigTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.MultisigTransaction{}, modules.ErrLockedWallet
+	}
+	uc, exists := w.multisigAddrs[addr]
+	if !exists {
+		return modules.MultisigTransaction{}, errUnknownMultisigAddress
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+
+	amount := fee
+	for _, sco := range outputs {
+		amount = amount.Add(sco.Value)
+	}
+
+	// Collect a value-sorted set of the outputs of the address.
+	var so sortedOutputs
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if sco.UnlockHash == addr && w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+			so.ids = append(so.ids, scoid)
+			so.outputs = append(so.outputs, sco)
+		}
+	})
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	var txn types.Transaction
+	var fund types.Currency
+	for i := range so.ids {
+		if fund.Cmp(amount) >= 0 {
+			break
+		}
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: uc,
+		})
+		fund = fund.Add(so.outputs[i].Value)
+	}
+	if fund.Cmp(amount) < 0 {
+		return modules.MultisigTransaction{}, modules.ErrLowBalance
+	}
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs, outputs...)
+	if !fund.Equals(amount) {
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+			Value:      fund.Sub(amount),
+			UnlockHash: addr,
+		})
+	}
+	if !fee.IsZero() {
+		txn.MinerFees = append(txn.MinerFees, fee)
+	}
+
+	if err := w.signMultisigInputs(&txn, consensusHeight); err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+	// Mark the outputs as spent, so that they aren't used by another
+	// multisig transaction.
+	for _, sci := range txn.SiacoinInputs {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+			return modules.MultisigTransaction{}, err
+		}
+	}
+	return multisigTransaction(txn, consensusHeight), nil
+}
+
+// SignMultisigTransaction adds the signatures of the wallet to the inputs of
+// the transaction that spend from its multisig addresses. Inputs that already
+// have enough signatures are not signed again.
+func (w *Wallet) SignMultisigTransaction(txn types.Transaction) (modules.MultisigTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.MultisigTransaction{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+
+	txn.TransactionSignatures = append([]types.TransactionSignature(nil), txn.TransactionSignatures...)
+	if err := w.signMultisigInputs(&txn, consensusHeight); err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+	return multisigTransaction(txn, consensusHeight), nil
+}
+
+// MergeMultisigTransactions combines the signatures of copies of the same
+// transaction that were signed by different co-signers.
+func (w *Wallet) MergeMultisigTransactions(txns []types.Transaction) (modules.MultisigTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.MultisigTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	merged, err := mergeSignatures(txns)
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+	w.mu.Lock()
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	w.mu.Unlock()
+	if err != nil {
+		return modules.MultisigTransaction{}, err
+	}
+	return multisigTransaction(merged, consensusHeight), nil
+}
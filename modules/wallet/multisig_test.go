@@ -0,0 +1,182 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestMultisig checks that two wallets can create a 2-of-3 address, and
+// co-sign a transaction that spends from it.
+func TestMultisig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// A wallet can only be initialized from a seed once the consensus set
+	// is synced.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !wt.cs.Synced() {
+			return errors.New("consensus set is not synced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Create the wallet of the co-signer.
+	w2, err := New(wt.cs, wt.tpool, build.TempDir(modules.WalletDir, t.Name()+"2", modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	if err := w2.InitFromSeed(crypto.TwofishKey{}, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.Unlock(crypto.TwofishKey(crypto.HashObject(seed))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create the address from a key of each wallet, and a third key that
+	// belongs to neither.
+	pk1, err := wt.wallet.MultisigPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk2, err := w2.MultisigPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, pk := crypto.GenerateKeyPair()
+	pk3 := types.Ed25519PublicKey(pk)
+	keys := []types.SiaPublicKey{pk1, pk2, pk3}
+	if _, err := wt.wallet.CreateMultisigAddress(0, keys, true); err != errInvalidSignaturesRequired {
+		t.Fatal("expected errInvalidSignaturesRequired, got", err)
+	}
+	if _, err := wt.wallet.CreateMultisigAddress(1, []types.SiaPublicKey{pk2, pk3}, true); err != errNoLocalMultisigKey {
+		t.Fatal("expected errNoLocalMultisigKey, got", err)
+	}
+	ma1, err := wt.wallet.CreateMultisigAddress(2, keys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ma2, err := w2.CreateMultisigAddress(2, keys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ma1.Address != ma2.Address {
+		t.Fatal("co-signers created different addresses")
+	}
+	if len(ma1.LocalKeys) != 1 || ma1.LocalKeys[0] != 0 || len(ma2.LocalKeys) != 1 || ma2.LocalKeys[0] != 1 {
+		t.Fatal("wrong local keys:", ma1.LocalKeys, ma2.LocalKeys)
+	}
+
+	// Fund the address. The outputs are tracked, but they are not part of
+	// the balance of the wallets.
+	funding := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(funding, ma1.Address); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	addrs, err := w2.MultisigAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].SiacoinBalance.Equals(funding) {
+		t.Fatal("co-signer did not track the funding of the address:", addrs)
+	}
+	balance, _, _, err := w2.ConfirmedBalance()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !balance.IsZero() {
+		t.Fatal("multisig funds should not be part of the balance:", balance)
+	}
+
+	// One signature is not enough.
+	dest := types.UnlockHash{1}
+	value := types.SiacoinPrecision.Mul64(10)
+	fee := types.SiacoinPrecision
+	mt, err := wt.wallet.NewMultisigTransaction(ma1.Address, []types.SiacoinOutput{{Value: value, UnlockHash: dest}}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mt.Complete || len(mt.Transaction.TransactionSignatures) != 1 {
+		t.Fatal("transaction should only have the signature of the first wallet:", mt)
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{mt.Transaction}); err == nil {
+		t.Fatal("transaction with one signature should be rejected")
+	}
+
+	// The co-signer signs a copy of the transaction, and the copies are
+	// merged.
+	unsigned := mt.Transaction
+	unsigned.TransactionSignatures = nil
+	signed, err := w2.SignMultisigTransaction(unsigned)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.Complete {
+		t.Fatal("copy should only have the signature of the co-signer")
+	}
+	other := unsigned
+	other.ArbitraryData = [][]byte{{1}}
+	if _, err := wt.wallet.MergeMultisigTransactions([]types.Transaction{mt.Transaction, other}); err != errMultisigTransactionMismatch {
+		t.Fatal("expected errMultisigTransactionMismatch, got", err)
+	}
+	merged, err := wt.wallet.MergeMultisigTransactions([]types.Transaction{mt.Transaction, signed.Transaction, mt.Transaction})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !merged.Complete || len(merged.Transaction.TransactionSignatures) != 2 {
+		t.Fatal("merged transaction should be complete:", merged)
+	}
+
+	// Signing the partially signed transaction directly also completes it.
+	cosigned, err := w2.SignMultisigTransaction(mt.Transaction)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cosigned.Complete {
+		t.Fatal("co-signed transaction should be complete")
+	}
+	if err := wt.tpool.AcceptTransactionSet([]types.Transaction{merged.Transaction}); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	addrs, err = wt.wallet.MultisigAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || !addrs[0].SiacoinBalance.Equals(funding.Sub(value).Sub(fee)) {
+		t.Fatal("wrong balance after spending from the address:", addrs)
+	}
+
+	// The multisig address survives a restart of the wallet.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err = wt.wallet.MultisigAddresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 || addrs[0].Address != ma1.Address || len(addrs[0].LocalKeys) != 1 {
+		t.Fatal("multisig address was not reloaded:", addrs)
+	}
+}
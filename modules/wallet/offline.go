@@ -100,7 +100,7 @@ outer:
 	// mark the watch-only outputs
 	for i, o := range outputs {
 		_, ok := w.watchedAddrs[o.UnlockHash]
-		outputs[i].IsWatchOnly = ok
+		outputs[i].IsWatchOnly = ok || w.isWatchOnlyKeyAddress(o.UnlockHash)
 	}
 
 	return outputs, nil
@@ -285,6 +285,39 @@ func signTransaction(txn *types.Transaction, keys map[types.UnlockHash]spendable
 	return nil
 }
 
+// prepareRescan deletes the processed transactions of the wallet and resets
+// its consensus change, so that the next subscription to the consensus set
+// rebuilds the transaction history. It must be called with a write-lock.
+func (w *Wallet) prepareRescan() error {
+	if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
+		return err
+	}
+	if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
+		return err
+	}
+	w.unconfirmedProcessedTransactions = nil
+	if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
+		return err
+	}
+	return dbPutConsensusHeight(w.dbTx, 0)
+}
+
+// managedRescan resubscribes the wallet to the consensus set and the
+// transaction pool, rescanning the blockchain from the beginning.
+func (w *Wallet) managedRescan() error {
+	w.cs.Unsubscribe(w)
+	w.tpool.Unsubscribe(w)
+
+	done := make(chan struct{})
+	go w.rescanMessage(done)
+	defer close(done)
+	if err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan()); err != nil {
+		return err
+	}
+	w.tpool.TransactionPoolSubscribe(w)
+	return nil
+}
+
 // AddWatchAddresses instructs the wallet to begin tracking a set of
 // addresses, in addition to the addresses it was previously tracking. If none
 // of the addresses have appeared in the blockchain, the unused flag may be
@@ -318,17 +351,7 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
 		if !unused {
 			// prepare to rescan
-			if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			w.unconfirmedProcessedTransactions = nil
-			if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
-				return err
-			}
-			if err := dbPutConsensusHeight(w.dbTx, 0); err != nil {
+			if err := w.prepareRescan(); err != nil {
 				return err
 			}
 		}
@@ -340,18 +363,8 @@ func (w *Wallet) AddWatchAddresses(addrs []types.UnlockHash, unused bool) error
 
 	if !unused {
 		// rescan the blockchain
-		w.cs.Unsubscribe(w)
-		w.tpool.Unsubscribe(w)
-
-		done := make(chan struct{})
-		go w.rescanMessage(done)
-		defer close(done)
-		if err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan()); err != nil {
-			return err
-		}
-		w.tpool.TransactionPoolSubscribe(w)
+		return w.managedRescan()
 	}
-
 	return nil
 }
 
@@ -402,17 +415,7 @@ func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) err
 			}
 
 			// prepare to rescan
-			if err := w.dbTx.DeleteBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			if _, err := w.dbTx.CreateBucket(bucketProcessedTransactions); err != nil {
-				return err
-			}
-			w.unconfirmedProcessedTransactions = nil
-			if err := dbPutConsensusChangeID(w.dbTx, modules.ConsensusChangeBeginning); err != nil {
-				return err
-			}
-			if err := dbPutConsensusHeight(w.dbTx, 0); err != nil {
+			if err := w.prepareRescan(); err != nil {
 				return err
 			}
 		}
@@ -424,18 +427,8 @@ func (w *Wallet) RemoveWatchAddresses(addrs []types.UnlockHash, unused bool) err
 
 	if !unused {
 		// rescan the blockchain
-		w.cs.Unsubscribe(w)
-		w.tpool.Unsubscribe(w)
-
-		done := make(chan struct{})
-		go w.rescanMessage(done)
-		defer close(done)
-		if err := w.cs.ConsensusSetSubscribe(w, modules.ConsensusChangeBeginning, w.tg.StopChan()); err != nil {
-			return err
-		}
-		w.tpool.TransactionPoolSubscribe(w)
+		return w.managedRescan()
 	}
-
 	return nil
 }
 
@@ -0,0 +1,298 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+var (
+	// errNoPartialTransactionKeys is returned if the wallet holds none of
+	// the keys that can sign the inputs of a partially signed transaction.
+	errNoPartialTransactionKeys = errors.New("wallet has no keys for the inputs of the transaction")
+
+	// errNoPartialTransactions is returned if no partially signed
+	// transactions were provided to combine.
+	errNoPartialTransactions = errors.New("no partially signed transactions to combine")
+
+	// errPartialTransactionIncomplete is returned when finalizing a partially
+	// signed transaction that is still missing signatures.
+	errPartialTransactionIncomplete = errors.New("transaction is missing signatures")
+
+	// errPartialValueMismatch is returned if the value of an input differs
+	// from the value of the spent output that the wallet knows, or between
+	// copies of a partially signed transaction.
+	errPartialValueMismatch = errors.New("value of an input doesn't match the spent output")
+)
+
+// inputSecretKey returns the secret key of the wallet for the public key at
+// index i of the unlock conditions. Keys of multi-key addresses that were
+// loaded as a whole, e.g. siag keys, are tracked by the address itself;
+// every other key is tracked by its own single-signature address.
+func (w *Wallet) inputSecretKey(uc types.UnlockConditions, i uint64) (crypto.SecretKey, bool) {
+	pk := uc.PublicKeys[i]
+	if sk, ok := w.keys[uc.UnlockHash()]; ok {
+		for _, key := range sk.SecretKeys {
+			pubKey := key.PublicKey()
+			if bytes.Equal(pk.Key, pubKey[:]) {
+				return key, true
+			}
+		}
+	}
+	return w.multisigSecretKey(pk)
+}
+
+// annotatePartialTransaction fills in the values, parents and key hints of
+// the inputs that the wallet knows. Values that were set by another party
+// are checked against the spent outputs that the wallet knows.
+func (w *Wallet) annotatePartialTransaction(pt *types.PartialTransaction) error {
+	siafundInputs := make(map[crypto.Hash]struct{})
+	for _, sfi := range pt.Transaction.SiafundInputs {
+		siafundInputs[crypto.Hash(sfi.ParentID)] = struct{}{}
+	}
+	parents := make(map[types.TransactionID]struct{})
+	for _, parent := range pt.Parents {
+		parents[parent.ID()] = struct{}{}
+	}
+
+	for i := range pt.Inputs {
+		input := &pt.Inputs[i]
+		_, isSiafund := siafundInputs[input.ParentID]
+
+		// Look for the spent output in the confirmed outputs of the wallet
+		// first, then in the parents and then in the unconfirmed
+		// transactions of the wallet. The id of a spent output commits to
+		// the transaction that created it, so the parents can be trusted.
+		var value types.Currency
+		known := false
+		if isSiafund {
+			sfo, err := dbGetSiafundOutput(w.dbTx, types.SiafundOutputID(input.ParentID))
+			value, known = sfo.Value, err == nil
+		} else {
+			sco, err := dbGetSiacoinOutput(w.dbTx, types.SiacoinOutputID(input.ParentID))
+			value, known = sco.Value, err == nil
+		}
+		for _, parent := range pt.Parents {
+			for j, sco := range parent.SiacoinOutputs {
+				if !known && !isSiafund && crypto.Hash(parent.SiacoinOutputID(uint64(j))) == input.ParentID {
+					value, known = sco.Value, true
+				}
+			}
+			for j, sfo := range parent.SiafundOutputs {
+				if !known && isSiafund && crypto.Hash(parent.SiafundOutputID(uint64(j))) == input.ParentID {
+					value, known = sfo.Value, true
+				}
+			}
+		}
+		for _, upt := range w.unconfirmedProcessedTransactions {
+			if known {
+				break
+			}
+			txn := upt.Transaction
+			for j, sco := range txn.SiacoinOutputs {
+				if !isSiafund && crypto.Hash(txn.SiacoinOutputID(uint64(j))) == input.ParentID {
+					value, known = sco.Value, true
+				}
+			}
+			for j, sfo := range txn.SiafundOutputs {
+				if isSiafund && crypto.Hash(txn.SiafundOutputID(uint64(j))) == input.ParentID {
+					value, known = sfo.Value, true
+				}
+			}
+			if _, exists := parents[upt.TransactionID]; known && !exists {
+				pt.Parents = append(pt.Parents, txn)
+				parents[upt.TransactionID] = struct{}{}
+			}
+		}
+		if known {
+			if !input.Value.IsZero() && !input.Value.Equals(value) {
+				return errPartialValueMismatch
+			}
+			input.Value = value
+		}
+
+		// Add hints for the keys that the wallet holds.
+		hinted := make(map[uint64]struct{})
+		for _, hint := range input.KeyHints {
+			hinted[hint.PublicKeyIndex] = struct{}{}
+		}
+		for j := range input.UnlockConditions.PublicKeys {
+			if _, exists := hinted[uint64(j)]; exists {
+				continue
+			}
+			if _, ok := w.inputSecretKey(input.UnlockConditions, uint64(j)); ok {
+				input.KeyHints = append(input.KeyHints, types.PartialTransactionKeyHint{
+					PublicKeyIndex: uint64(j),
+					KeyAddress:     types.KeyAddress(input.UnlockConditions, uint64(j)),
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// CombinePartialTransactions combines copies of the same partially signed
+// transaction that were signed by different parties. The signatures are
+// merged like those of multisig transactions, and the parents, values and
+// key hints of the copies are merged as well.
+func (w *Wallet) CombinePartialTransactions(pts []types.PartialTransaction) (types.PartialTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.PartialTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if len(pts) == 0 {
+		return types.PartialTransaction{}, errNoPartialTransactions
+	}
+
+	txns := make([]types.Transaction, len(pts))
+	for i, pt := range pts {
+		if err := pt.Validate(); err != nil {
+			return types.PartialTransaction{}, err
+		}
+		txns[i] = pt.Transaction
+	}
+	merged, err := mergeSignatures(txns)
+	if err != nil {
+		return types.PartialTransaction{}, err
+	}
+	combined := types.NewPartialTransaction(merged)
+
+	parents := make(map[types.TransactionID]struct{})
+	inputs := make(map[crypto.Hash]*types.PartialTransactionInput)
+	for i := range combined.Inputs {
+		inputs[combined.Inputs[i].ParentID] = &combined.Inputs[i]
+	}
+	for _, pt := range pts {
+		for _, parent := range pt.Parents {
+			if _, exists := parents[parent.ID()]; !exists {
+				combined.Parents = append(combined.Parents, parent)
+				parents[parent.ID()] = struct{}{}
+			}
+		}
+		for _, input := range pt.Inputs {
+			ci := inputs[input.ParentID]
+			if !input.Value.IsZero() {
+				if !ci.Value.IsZero() && !ci.Value.Equals(input.Value) {
+					return types.PartialTransaction{}, errPartialValueMismatch
+				}
+				ci.Value = input.Value
+			}
+			for _, hint := range input.KeyHints {
+				exists := false
+				for _, h := range ci.KeyHints {
+					exists = exists || h.PublicKeyIndex == hint.PublicKeyIndex
+				}
+				if !exists {
+					ci.KeyHints = append(ci.KeyHints, hint)
+				}
+			}
+		}
+	}
+	return combined, combined.Validate()
+}
+
+// FinalizePartialTransaction checks that the partially signed transaction is
+// fully signed and valid at the current height, and returns the transaction
+// set that consists of its parents and the transaction.
+func (w *Wallet) FinalizePartialTransaction(pt types.PartialTransaction) ([]types.Transaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := pt.Validate(); err != nil {
+		return nil, err
+	}
+	if pt.SignaturesNeeded() > 0 {
+		return nil, errPartialTransactionIncomplete
+	}
+	w.mu.RLock()
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	w.mu.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if err := pt.Transaction.StandaloneValid(consensusHeight); err != nil {
+		return nil, err
+	}
+	return append(append([]types.Transaction(nil), pt.Parents...), pt.Transaction), nil
+}
+
+// NewPartialTransaction creates a partially signed transaction for txn. The
+// parents are included as provided; the wallet adds the unconfirmed
+// transactions that create outputs spent by txn, and fills in the values and
+// key hints of the inputs that it knows.
+func (w *Wallet) NewPartialTransaction(txn types.Transaction, parents []types.Transaction) (types.PartialTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.PartialTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	pt := types.NewPartialTransaction(txn)
+	pt.Parents = append([]types.Transaction(nil), parents...)
+	if err := pt.Validate(); err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.PartialTransaction{}, modules.ErrLockedWallet
+	}
+	if err := w.annotatePartialTransaction(&pt); err != nil {
+		return types.PartialTransaction{}, err
+	}
+	return pt, pt.Validate()
+}
+
+// SignPartialTransaction adds the signatures of the wallet to every input of
+// the partially signed transaction that it holds keys for. Before signing,
+// the values of the inputs that the wallet knows are checked, so that a
+// party can't misrepresent how many coins the transaction spends from the
+// wallet.
+func (w *Wallet) SignPartialTransaction(pt types.PartialTransaction) (types.PartialTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.PartialTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if err := pt.Validate(); err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.PartialTransaction{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	// Copy the slices that are modified, so that the caller's copy isn't
+	// changed.
+	pt.Parents = append([]types.Transaction(nil), pt.Parents...)
+	pt.Inputs = append([]types.PartialTransactionInput(nil), pt.Inputs...)
+	for i := range pt.Inputs {
+		pt.Inputs[i].KeyHints = append([]types.PartialTransactionKeyHint(nil), pt.Inputs[i].KeyHints...)
+	}
+	pt.Transaction.TransactionSignatures = append([]types.TransactionSignature(nil), pt.Transaction.TransactionSignatures...)
+	if err := w.annotatePartialTransaction(&pt); err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	var found bool
+	for _, input := range pt.Inputs {
+		for _, hint := range input.KeyHints {
+			if _, ok := w.inputSecretKey(input.UnlockConditions, hint.PublicKeyIndex); ok {
+				found = true
+			}
+		}
+		addWholeSignatures(&pt.Transaction, input.ParentID, input.UnlockConditions, consensusHeight, w.inputSecretKey)
+	}
+	if !found {
+		return types.PartialTransaction{}, errNoPartialTransactionKeys
+	}
+	return pt, nil
+}
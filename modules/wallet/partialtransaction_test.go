@@ -0,0 +1,200 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestPartialTransaction checks that the wallet creates, signs and finalizes
+// a partially signed transaction that spends its own outputs.
+func TestPartialTransaction(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Fund a transaction without signing it.
+	value := types.SiacoinPrecision.Mul64(100)
+	tb, err := wt.wallet.StartTransaction()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tb.FundSiacoins(value); err != nil {
+		t.Fatal(err)
+	}
+	tb.AddSiacoinOutput(types.SiacoinOutput{Value: value, UnlockHash: types.UnlockHash{1}})
+	txn, parents := tb.View()
+
+	pt, err := wt.wallet.NewPartialTransaction(txn, parents)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pt.SiacoinInputSum().Equals(value) {
+		t.Fatal("wrong input sum:", pt.SiacoinInputSum().HumanString())
+	}
+	for _, input := range pt.Inputs {
+		if len(input.KeyHints) != 1 || input.KeyHints[0].KeyAddress != input.UnlockConditions.UnlockHash() {
+			t.Fatal("wallet did not add a hint for its key:", input.KeyHints)
+		}
+	}
+	if _, err := wt.wallet.FinalizePartialTransaction(pt); err != errPartialTransactionIncomplete {
+		t.Fatal("expected errPartialTransactionIncomplete, got", err)
+	}
+
+	// Pass the transaction through its string encoding, as an offline
+	// signer would receive it.
+	var decoded types.PartialTransaction
+	if err := decoded.LoadString(pt.String()); err != nil {
+		t.Fatal(err)
+	}
+	signed, err := wt.wallet.SignPartialTransaction(decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed.SignaturesNeeded() != 0 || len(pt.Transaction.TransactionSignatures) != 0 {
+		t.Fatal("transaction should be fully signed, without modifying the input")
+	}
+	txnSet, err := wt.wallet.FinalizePartialTransaction(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(txnSet) != len(parents)+1 {
+		t.Fatal("transaction set should include the parents")
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestPartialTransactionMultisig checks that two co-signers can sign copies
+// of a partially signed transaction that spends from a multisig address, and
+// combine them.
+func TestPartialTransactionMultisig(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	// A wallet can only be initialized from a seed once the consensus set
+	// is synced.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !wt.cs.Synced() {
+			return errors.New("consensus set is not synced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w2, err := New(wt.cs, wt.tpool, build.TempDir(modules.WalletDir, t.Name()+"2", modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w2.Close()
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	if err := w2.InitFromSeed(crypto.TwofishKey{}, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := w2.Unlock(crypto.TwofishKey(crypto.HashObject(seed))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Create and fund a 2-of-2 address.
+	pk1, err := wt.wallet.MultisigPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pk2, err := w2.MultisigPublicKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keys := []types.SiaPublicKey{pk1, pk2}
+	ma, err := wt.wallet.CreateMultisigAddress(2, keys, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w2.CreateMultisigAddress(2, keys, true); err != nil {
+		t.Fatal(err)
+	}
+	funding := types.SiacoinPrecision.Mul64(100)
+	fundingTxns, err := wt.wallet.SendSiacoins(funding, ma.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	fundingTxn := fundingTxns[len(fundingTxns)-1]
+	var parentID types.SiacoinOutputID
+	for i, sco := range fundingTxn.SiacoinOutputs {
+		if sco.UnlockHash == ma.Address {
+			parentID = fundingTxn.SiacoinOutputID(uint64(i))
+		}
+	}
+
+	// Spend the output, and let each co-signer sign a copy.
+	txn := types.Transaction{
+		SiacoinInputs:  []types.SiacoinInput{{ParentID: parentID, UnlockConditions: ma.UnlockConditions}},
+		SiacoinOutputs: []types.SiacoinOutput{{Value: funding.Sub(types.SiacoinPrecision), UnlockHash: types.UnlockHash{1}}},
+		MinerFees:      []types.Currency{types.SiacoinPrecision},
+	}
+	pt, err := wt.wallet.NewPartialTransaction(txn, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pt.Inputs[0].Value.Equals(funding) || len(pt.Inputs[0].KeyHints) != 1 || pt.Inputs[0].KeyHints[0].PublicKeyIndex != 0 {
+		t.Fatal("wrong metadata of the multisig input:", pt.Inputs[0])
+	}
+
+	// A co-signer refuses to sign if another party lied about the value of
+	// the input.
+	lie := pt
+	lie.Inputs = append([]types.PartialTransactionInput(nil), pt.Inputs...)
+	lie.Inputs[0].Value = funding.Mul64(2)
+	if _, err := w2.SignPartialTransaction(lie); err != errPartialValueMismatch {
+		t.Fatal("expected errPartialValueMismatch, got", err)
+	}
+
+	signed1, err := wt.wallet.SignPartialTransaction(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed2, err := w2.SignPartialTransaction(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signed1.SignaturesNeeded() != 1 || signed2.SignaturesNeeded() != 1 {
+		t.Fatal("each co-signer should only add one signature")
+	}
+	if _, err := wt.wallet.FinalizePartialTransaction(signed1); err != errPartialTransactionIncomplete {
+		t.Fatal("expected errPartialTransactionIncomplete, got", err)
+	}
+
+	combined, err := wt.wallet.CombinePartialTransactions([]types.PartialTransaction{signed1, signed2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if combined.SignaturesNeeded() != 0 || len(combined.Inputs[0].KeyHints) != 2 {
+		t.Fatal("combined transaction should have the signatures and hints of both co-signers:", combined.Inputs[0])
+	}
+	txnSet, err := wt.wallet.FinalizePartialTransaction(combined)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+}
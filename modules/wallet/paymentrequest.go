@@ -0,0 +1,452 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// maxPaymentRequestEvents is the number of recent payment request events
+	// that the wallet keeps.
+	maxPaymentRequestEvents = 1000
+
+	// maxPaymentRequestMemoLength is the maximum length in bytes of the memo
+	// of a payment request.
+	maxPaymentRequestMemoLength = 4096
+
+	// paymentRequestWebhookAttempts is the number of times the wallet tries
+	// to deliver an event to a webhook.
+	paymentRequestWebhookAttempts = 3
+
+	// paymentRequestWebhookTimeout is the timeout of a webhook call.
+	paymentRequestWebhookTimeout = 30 * time.Second
+)
+
+var (
+	// paymentRequestLatePaymentWindow is the number of blocks after its
+	// expiry height during which an expired payment request still tracks late
+	// payments. After that, the request is final.
+	paymentRequestLatePaymentWindow = build.Select(build.Var{
+		Dev:      types.BlockHeight(144),
+		Standard: types.BlockHeight(1008),
+		Testing:  types.BlockHeight(5),
+	}).(types.BlockHeight)
+
+	// paymentRequestWebhookRetryInterval is the time the wallet waits before
+	// retrying a failed webhook call.
+	paymentRequestWebhookRetryInterval = build.Select(build.Var{
+		Dev:      10 * time.Second,
+		Standard: time.Minute,
+		Testing:  100 * time.Millisecond,
+	}).(time.Duration)
+)
+
+var (
+	// errInvalidWebhookURL is returned if the webhook of a payment request is
+	// not an http or https URL.
+	errInvalidWebhookURL = errors.New("webhook must be an http or https URL")
+
+	// errMemoTooLong is returned if the memo of a payment request exceeds
+	// maxPaymentRequestMemoLength.
+	errMemoTooLong = errors.New("payment request memo is too long")
+
+	// errUnknownPaymentRequest is returned if the wallet has no payment
+	// request for an address.
+	errUnknownPaymentRequest = errors.New("address has no payment request")
+
+	// errZeroConfirmations is returned if a payment request requires no
+	// confirmations.
+	errZeroConfirmations = errors.New("payment request must require at least one confirmation")
+
+	// errZeroExpiry is returned if a payment request expires immediately.
+	errZeroExpiry = errors.New("payment request expiry must be at least one block")
+
+	// errZeroPaymentAmount is returned if a payment request asks for
+	// nothing.
+	errZeroPaymentAmount = errors.New("payment request amount must be greater than zero")
+)
+
+// checkWebhookURL checks that a webhook is an absolute http or https URL.
+func checkWebhookURL(webhookURL string) error {
+	u, err := url.Parse(webhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errInvalidWebhookURL
+	}
+	return nil
+}
+
+// paymentRequestProgress orders the statuses of a payment request by how far
+// it has been paid.
+var paymentRequestProgress = map[modules.PaymentRequestStatus]int{
+	modules.PaymentRequestUnpaid:        0,
+	modules.PaymentRequestPartiallyPaid: 1,
+	modules.PaymentRequestPaid:          2,
+	modules.PaymentRequestConfirmed:     3,
+}
+
+// paymentRequestFinal returns true if the status of a payment request no
+// longer changes at the provided height. Expired requests can still be paid
+// late, so they only become final once the late payment window has passed.
+func paymentRequestFinal(pr modules.PaymentRequest, height types.BlockHeight) bool {
+	switch pr.Status {
+	case modules.PaymentRequestConfirmed:
+		return true
+	case modules.PaymentRequestExpired:
+		return height > pr.ExpiryHeight+paymentRequestLatePaymentWindow
+	default:
+		return false
+	}
+}
+
+// evaluatePaymentRequest updates the received amounts, confirmations and
+// status of a payment request from the confirmed and unconfirmed transactions
+// of the wallet at the provided height.
+func (w *Wallet) evaluatePaymentRequest(tx *bolt.Tx, pr *modules.PaymentRequest, height types.BlockHeight) error {
+	type payment struct {
+		height types.BlockHeight
+		value  types.Currency
+	}
+	var payments []payment
+	var txids []types.TransactionID
+	addPayments := func(pt modules.ProcessedTransaction) {
+		var value types.Currency
+		for _, output := range pt.Outputs {
+			if output.FundType == types.SpecifierSiacoinOutput && output.RelatedAddress == pr.Address {
+				value = value.Add(output.Value)
+			}
+		}
+		if !value.IsZero() {
+			payments = append(payments, payment{pt.ConfirmationHeight, value})
+			txids = append(txids, pt.TransactionID)
+		}
+	}
+
+	txnIndices, err := dbGetAddrTransactions(tx, pr.Address)
+	if err != nil && err != errNoKey {
+		return err
+	}
+	for _, i := range txnIndices {
+		pt, err := dbGetProcessedTransaction(tx, i)
+		if err != nil {
+			return err
+		}
+		addPayments(pt)
+	}
+	confirmed := len(payments)
+	for _, upt := range w.unconfirmedProcessedTransactions {
+		addPayments(upt)
+	}
+	sort.SliceStable(payments[:confirmed], func(i, j int) bool {
+		return payments[i].height < payments[j].height
+	})
+
+	// The request is confirmed at the height of the confirmed payment that
+	// completes the amount.
+	pr.Received, pr.ReceivedConfirmed, pr.Confirmations = types.ZeroCurrency, types.ZeroCurrency, 0
+	for i, p := range payments {
+		pr.Received = pr.Received.Add(p.value)
+		if i >= confirmed {
+			continue
+		}
+		pr.ReceivedConfirmed = pr.ReceivedConfirmed.Add(p.value)
+		if pr.Confirmations == 0 && pr.ReceivedConfirmed.Cmp(pr.Amount) >= 0 && height >= p.height {
+			pr.Confirmations = uint64(height-p.height) + 1
+		}
+	}
+	pr.TransactionIDs = txids
+
+	switch {
+	case pr.Confirmations >= pr.RequiredConfirmations:
+		pr.Status = modules.PaymentRequestConfirmed
+	case pr.Received.Cmp(pr.Amount) >= 0:
+		pr.Status = modules.PaymentRequestPaid
+	case height > pr.ExpiryHeight:
+		pr.Status = modules.PaymentRequestExpired
+	case !pr.Received.IsZero():
+		pr.Status = modules.PaymentRequestPartiallyPaid
+	default:
+		pr.Status = modules.PaymentRequestUnpaid
+	}
+	return nil
+}
+
+// updatePaymentRequests re-evaluates the payment requests that are not final
+// and records an event for every status change, and for every late payment to
+// an expired request within the late payment window. The recorded events are returned, so that their
+// webhooks can be called once the lock is released.
+//
+// The transaction pool drops confirmed transactions before the wallet
+// processes the block that confirms them, so unless downgrade is set, a
+// request keeps its status and received amount if they would fall back.
+func (w *Wallet) updatePaymentRequests(tx *bolt.Tx, downgrade bool) ([]modules.PaymentRequestEvent, error) {
+	height, err := dbGetConsensusHeight(tx)
+	if err != nil {
+		return nil, err
+	}
+	var open []modules.PaymentRequest
+	err = dbForEachPaymentRequest(tx, func(_ types.UnlockHash, pr modules.PaymentRequest) {
+		if !paymentRequestFinal(pr, height) {
+			open = append(open, pr)
+		}
+	})
+	if err != nil || len(open) == 0 {
+		return nil, err
+	}
+	sequence, err := dbGetPaymentRequestEvents(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []modules.PaymentRequestEvent
+	for _, pr := range open {
+		previous, previousReceived := pr.Status, pr.Received
+		if err := w.evaluatePaymentRequest(tx, &pr, height); err != nil {
+			return nil, err
+		}
+		if !downgrade && (paymentRequestProgress[pr.Status] < paymentRequestProgress[previous] || pr.Received.Cmp(previousReceived) < 0) {
+			continue
+		}
+		if err := dbPutPaymentRequest(tx, pr); err != nil {
+			return nil, err
+		}
+		latePayment := pr.Status == modules.PaymentRequestExpired && pr.Received.Cmp(previousReceived) > 0
+		if pr.Status == previous && !latePayment {
+			continue
+		}
+		sequence++
+		event := modules.PaymentRequestEvent{
+			Sequence:       sequence,
+			Timestamp:      types.CurrentTimestamp(),
+			PreviousStatus: previous,
+			PaymentRequest: pr,
+		}
+		if err := dbPutPaymentRequestEvent(tx, event); err != nil {
+			return nil, err
+		}
+		if sequence > maxPaymentRequestEvents {
+			if err := dbDeletePaymentRequestEvent(tx, sequence-maxPaymentRequestEvents); err != nil {
+				return nil, err
+			}
+		}
+		if latePayment {
+			w.log.Printf("Expired payment request %v received a late payment", pr.Address)
+		} else {
+			w.log.Printf("Payment request %v changed from %v to %v", pr.Address, previous, pr.Status)
+		}
+		events = append(events, event)
+	}
+	if len(events) == 0 {
+		return nil, nil
+	}
+	return events, dbPutPaymentRequestEvents(tx, sequence)
+}
+
+// notifyPaymentRequests is a helper for the consensus and transaction pool
+// subscriptions, which updates the payment requests and queues the events
+// that have a webhook for delivery. It must be called with a write-lock.
+func (w *Wallet) notifyPaymentRequests(downgrade bool) {
+	events, err := w.updatePaymentRequests(w.dbTx, downgrade)
+	if err != nil {
+		w.log.Severe("ERROR: failed to update payment requests:", err)
+		w.dbRollback = true
+		return
+	}
+	for _, event := range events {
+		if event.PaymentRequest.WebhookURL != "" {
+			w.paymentRequestWebhooks = append(w.paymentRequestWebhooks, event)
+		}
+	}
+	if len(w.paymentRequestWebhooks) > 0 && !w.paymentRequestWebhooksActive {
+		w.paymentRequestWebhooksActive = true
+		go w.threadedCallPaymentRequestWebhooks()
+	}
+}
+
+// managedNextPaymentRequestWebhook removes the next event from the webhook
+// queue. If the queue is empty, it returns false and marks the delivery
+// thread as stopped.
+func (w *Wallet) managedNextPaymentRequestWebhook() (modules.PaymentRequestEvent, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.paymentRequestWebhooks) == 0 {
+		w.paymentRequestWebhooksActive = false
+		return modules.PaymentRequestEvent{}, false
+	}
+	event := w.paymentRequestWebhooks[0]
+	w.paymentRequestWebhooks = w.paymentRequestWebhooks[1:]
+	return event, true
+}
+
+// threadedCallPaymentRequestWebhooks posts the queued events to the webhooks
+// of their payment requests, one at a time and in sequence order, until the
+// queue is empty. Failed calls are retried a few times.
+func (w *Wallet) threadedCallPaymentRequestWebhooks() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+
+	client := &http.Client{Timeout: paymentRequestWebhookTimeout}
+	for {
+		event, ok := w.managedNextPaymentRequestWebhook()
+		if !ok {
+			return
+		}
+		body, err := json.Marshal(event)
+		if err != nil {
+			w.log.Println("Unable to encode payment request event:", err)
+			continue
+		}
+		for attempt := 1; ; attempt++ {
+			err = callPaymentRequestWebhook(client, event.PaymentRequest.WebhookURL, body)
+			if err == nil {
+				break
+			}
+			w.log.Printf("Webhook call %v of %v for payment request %v failed: %v", attempt, paymentRequestWebhookAttempts, event.PaymentRequest.Address, err)
+			if attempt >= paymentRequestWebhookAttempts {
+				break
+			}
+			select {
+			case <-w.tg.StopChan():
+				return
+			case <-time.After(paymentRequestWebhookRetryInterval):
+			}
+		}
+	}
+}
+
+// callPaymentRequestWebhook posts the encoded event to the webhook. Any
+// response other than 2xx is an error.
+func callPaymentRequestWebhook(client *http.Client, webhookURL string, body []byte) error {
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.New("webhook returned " + resp.Status)
+	}
+	return nil
+}
+
+// NewPaymentRequest creates a payment request for amount to a new address of
+// the primary seed. The request expires if it is not paid within expiry
+// blocks, and is confirmed once the payments that cover the amount have the
+// required number of confirmations.
+func (w *Wallet) NewPaymentRequest(amount types.Currency, expiry types.BlockHeight, confirmations uint64, memo, webhookURL string) (modules.PaymentRequest, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PaymentRequest{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	switch {
+	case amount.IsZero():
+		return modules.PaymentRequest{}, errZeroPaymentAmount
+	case expiry == 0:
+		return modules.PaymentRequest{}, errZeroExpiry
+	case confirmations == 0:
+		return modules.PaymentRequest{}, errZeroConfirmations
+	case len(memo) > maxPaymentRequestMemoLength:
+		return modules.PaymentRequest{}, errMemoTooLong
+	}
+	if webhookURL != "" {
+		if err := checkWebhookURL(webhookURL); err != nil {
+			return modules.PaymentRequest{}, err
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.PaymentRequest{}, modules.ErrLockedWallet
+	}
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.PaymentRequest{}, err
+	}
+	uc, err := w.nextPrimarySeedAddress(w.dbTx)
+	if err != nil {
+		return modules.PaymentRequest{}, err
+	}
+	pr := modules.PaymentRequest{
+		Address:               uc.UnlockHash(),
+		Amount:                amount,
+		Memo:                  memo,
+		WebhookURL:            webhookURL,
+		CreationHeight:        height,
+		ExpiryHeight:          height + expiry,
+		RequiredConfirmations: confirmations,
+		Status:                modules.PaymentRequestUnpaid,
+	}
+	if err := dbPutPaymentRequest(w.dbTx, pr); err != nil {
+		return modules.PaymentRequest{}, err
+	}
+	return pr, w.syncDB()
+}
+
+// PaymentRequest returns the payment request of an address.
+func (w *Wallet) PaymentRequest(addr types.UnlockHash) (modules.PaymentRequest, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.PaymentRequest{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	pr, err := dbGetPaymentRequest(w.dbTx, addr)
+	if err == errNoKey {
+		return modules.PaymentRequest{}, errUnknownPaymentRequest
+	}
+	return pr, err
+}
+
+// PaymentRequests returns all payment requests of the wallet, oldest first.
+func (w *Wallet) PaymentRequests() ([]modules.PaymentRequest, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var prs []modules.PaymentRequest
+	err := dbForEachPaymentRequest(w.dbTx, func(_ types.UnlockHash, pr modules.PaymentRequest) {
+		prs = append(prs, pr)
+	})
+	sort.SliceStable(prs, func(i, j int) bool {
+		return prs[i].CreationHeight < prs[j].CreationHeight
+	})
+	return prs, err
+}
+
+// PaymentRequestEvents returns the recent status changes of the payment
+// requests with a sequence number greater than since, in order.
+func (w *Wallet) PaymentRequestEvents(since uint64) ([]modules.PaymentRequestEvent, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var events []modules.PaymentRequestEvent
+	err := dbForEachPaymentRequestEvent(w.dbTx, func(sequence uint64, event modules.PaymentRequestEvent) {
+		if sequence > since {
+			events = append(events, event)
+		}
+	})
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].Sequence < events[j].Sequence
+	})
+	return events, err
+}
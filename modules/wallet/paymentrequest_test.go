@@ -0,0 +1,262 @@
+package wallet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestPaymentRequests checks that payment requests move through their states
+// as payments arrive and confirm, and that the status changes are recorded
+// and posted to the webhook.
+func TestPaymentRequests(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Collect the events posted to the webhook.
+	hookEvents := make(chan modules.PaymentRequestEvent, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var event modules.PaymentRequestEvent
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		hookEvents <- event
+	}))
+	defer server.Close()
+
+	amount := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.NewPaymentRequest(types.ZeroCurrency, 10, 2, "", ""); err != errZeroPaymentAmount {
+		t.Fatal("expected errZeroPaymentAmount, got", err)
+	}
+	if _, err := wt.wallet.NewPaymentRequest(amount, 10, 2, "", "ftp://example.com"); err != errInvalidWebhookURL {
+		t.Fatal("expected errInvalidWebhookURL, got", err)
+	}
+	pr, err := wt.wallet.NewPaymentRequest(amount, 10, 2, "invoice 42", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != modules.PaymentRequestUnpaid {
+		t.Fatal("new payment request should be unpaid:", pr.Status)
+	}
+
+	// checkStatus checks the status of the request and the latest event.
+	checkStatus := func(status modules.PaymentRequestStatus) {
+		t.Helper()
+		pr, err := wt.wallet.PaymentRequest(pr.Address)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pr.Status != status {
+			t.Fatalf("expected status %v, got %v", status, pr.Status)
+		}
+		select {
+		case event := <-hookEvents:
+			if event.PaymentRequest.Status != status {
+				t.Fatalf("expected webhook event for %v, got %v", status, event.PaymentRequest.Status)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not called for", status)
+		}
+	}
+
+	// Pay half of the amount, then the rest.
+	if _, err := wt.wallet.SendSiacoins(amount.Div64(2), pr.Address); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(modules.PaymentRequestPartiallyPaid)
+	wt.addBlockNoPayout()
+	if _, err := wt.wallet.SendSiacoins(amount.Div64(2), pr.Address); err != nil {
+		t.Fatal(err)
+	}
+	checkStatus(modules.PaymentRequestPaid)
+
+	// The request needs two confirmations.
+	wt.addBlockNoPayout()
+	pr, err = wt.wallet.PaymentRequest(pr.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.Status != modules.PaymentRequestPaid || pr.Confirmations != 1 || !pr.ReceivedConfirmed.Equals(amount) || len(pr.TransactionIDs) != 2 {
+		t.Fatal("wrong payment request after one confirmation:", pr)
+	}
+	wt.addBlockNoPayout()
+	checkStatus(modules.PaymentRequestConfirmed)
+
+	// A request that isn't paid expires.
+	expiring, err := wt.wallet.NewPaymentRequest(amount, 1, 1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	wt.addBlockNoPayout()
+	expiring, err = wt.wallet.PaymentRequest(expiring.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiring.Status != modules.PaymentRequestExpired {
+		t.Fatal("payment request should have expired:", expiring.Status)
+	}
+
+	// Late payments to the expired request are still reported.
+	if _, err := wt.wallet.SendSiacoins(amount.Div64(2), expiring.Address); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	expiring, err = wt.wallet.PaymentRequest(expiring.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiring.Status != modules.PaymentRequestExpired || !expiring.ReceivedConfirmed.Equals(amount.Div64(2)) {
+		t.Fatal("wrong payment request after a late partial payment:", expiring)
+	}
+	if _, err := wt.wallet.SendSiacoins(amount.Div64(2), expiring.Address); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	expiring, err = wt.wallet.PaymentRequest(expiring.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expiring.Status != modules.PaymentRequestConfirmed {
+		t.Fatal("late paid request should be confirmed:", expiring.Status)
+	}
+
+	// The events can be polled.
+	events, err := wt.wallet.PaymentRequestEvents(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 7 {
+		t.Fatal("expected 7 events, got", len(events))
+	}
+	for i, event := range events {
+		if event.Sequence != uint64(i+1) {
+			t.Fatal("events are not in order:", events)
+		}
+	}
+	if events[3].PaymentRequest.Address != expiring.Address || events[3].PreviousStatus != modules.PaymentRequestUnpaid {
+		t.Fatal("wrong expiry event:", events[3])
+	}
+	if events[4].PreviousStatus != modules.PaymentRequestExpired || events[4].PaymentRequest.Status != modules.PaymentRequestExpired {
+		t.Fatal("wrong late payment event:", events[4])
+	}
+	if events[5].PreviousStatus != modules.PaymentRequestExpired || events[5].PaymentRequest.Status != modules.PaymentRequestPaid {
+		t.Fatal("wrong late paid event:", events[5])
+	}
+	events, err = wt.wallet.PaymentRequestEvents(6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatal("expected 1 event, got", len(events))
+	}
+	prs, err := wt.wallet.PaymentRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prs) != 2 || prs[0].Memo != "invoice 42" {
+		t.Fatal("wrong payment requests:", prs)
+	}
+
+	// Once the late payment window has passed, an expired request is no
+	// longer updated.
+	final, err := wt.wallet.NewPaymentRequest(amount, 1, 1, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := types.BlockHeight(0); i < paymentRequestLatePaymentWindow+2; i++ {
+		wt.addBlockNoPayout()
+	}
+	if _, err := wt.wallet.SendSiacoins(amount, final.Address); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	final, err = wt.wallet.PaymentRequest(final.Address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if final.Status != modules.PaymentRequestExpired || !final.Received.IsZero() {
+		t.Fatal("payment request should not be updated after the late payment window:", final)
+	}
+	events, err = wt.wallet.PaymentRequestEvents(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].PaymentRequest.Address != final.Address || events[0].PaymentRequest.Status != modules.PaymentRequestExpired {
+		t.Fatal("expected only the expiry event, got", events)
+	}
+}
+
+// TestPaymentRequestWebhookOrder checks that the webhook events are delivered
+// one at a time and in sequence order, even if the webhook is slow.
+func TestPaymentRequestWebhookOrder(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// The webhook records the sequence numbers and fails if it is called
+	// concurrently.
+	var mu sync.Mutex
+	var active bool
+	sequences := make(chan uint64, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		concurrent := active
+		active = true
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			active = false
+			mu.Unlock()
+		}()
+		var event modules.PaymentRequestEvent
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil || concurrent {
+			http.Error(w, "bad call", http.StatusBadRequest)
+			sequences <- 0
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+		sequences <- event.Sequence
+	}))
+	defer server.Close()
+
+	// Pay three requests, each in a separate update.
+	amount := types.SiacoinPrecision.Mul64(100)
+	for i := 0; i < 3; i++ {
+		pr, err := wt.wallet.NewPaymentRequest(amount, 10, 1, "", server.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := wt.wallet.SendSiacoins(amount, pr.Address); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for i := uint64(1); i <= 3; i++ {
+		select {
+		case sequence := <-sequences:
+			if sequence != i {
+				t.Fatalf("expected event %v, got %v", i, sequence)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("webhook was not called for event", i)
+		}
+	}
+}
@@ -91,6 +91,7 @@ func (w *Wallet) openDB(filename string) (err error) {
 
 		// check whether wallet is encrypted
 		w.encrypted = tx.Bucket(bucketWallet).Get(keyEncryptionVerification) != nil
+		w.watchOnly = tx.Bucket(bucketWallet).Get(keyWatchOnlyWallet) != nil
 		return nil
 	})
 	return err
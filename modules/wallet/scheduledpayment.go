@@ -0,0 +1,554 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// maxScheduledPaymentExecutions is the number of recent scheduled
+	// payment executions that the wallet keeps.
+	maxScheduledPaymentExecutions = 1000
+
+	// maxScheduledPaymentMemoLength is the maximum length in bytes of the
+	// memo of a scheduled payment.
+	maxScheduledPaymentMemoLength = 4096
+
+	// scheduledPaymentLimitPeriod is the period that the daily limit of the
+	// scheduled payments applies to.
+	scheduledPaymentLimitPeriod = 24 * time.Hour
+)
+
+var (
+	// errInvalidSchedule is returned if a payment schedule doesn't set
+	// exactly one of a height and a time, or limits the number of payments
+	// of a one-off payment.
+	errInvalidSchedule = errors.New("payment schedule must have either a height or a time, and only recurring schedules can have a count")
+
+	// errNoScheduledOutputs is returned if a scheduled payment has no
+	// outputs.
+	errNoScheduledOutputs = errors.New("scheduled payment must have at least one output")
+
+	// errScheduledPaymentFinal is returned when cancelling a scheduled
+	// payment that is completed or already cancelled.
+	errScheduledPaymentFinal = errors.New("scheduled payment is completed or cancelled")
+
+	// errScheduledPaymentMemoTooLong is returned if the memo of a scheduled
+	// payment exceeds maxScheduledPaymentMemoLength.
+	errScheduledPaymentMemoTooLong = errors.New("scheduled payment memo is too long")
+
+	// errScheduledPaymentNotHeld is returned when approving a scheduled
+	// payment that is not held.
+	errScheduledPaymentNotHeld = errors.New("scheduled payment is not held for approval")
+
+	// errUnknownScheduledPayment is returned if the wallet has no scheduled
+	// payment with an ID.
+	errUnknownScheduledPayment = errors.New("no scheduled payment with that id")
+
+	// errZeroScheduledOutput is returned if an output of a scheduled payment
+	// has no value.
+	errZeroScheduledOutput = errors.New("scheduled payment outputs must have a value")
+)
+
+// An inFlightScheduledPayment records the transactions of a scheduled payment
+// before they are broadcast. If the wallet stops before the result of the
+// broadcast is recorded, the payment is reconciled from this record, so that
+// it is neither made twice nor lost.
+type inFlightScheduledPayment struct {
+	Amount       types.Currency
+	Transactions []types.Transaction
+}
+
+// A scheduledPaymentSpend records the amount of a scheduled payment that was
+// made. The spends of the limit period are kept separately from the
+// executions, so that failed attempts can't push them out of the pruned
+// execution log.
+type scheduledPaymentSpend struct {
+	Timestamp types.Timestamp
+	Amount    types.Currency
+}
+
+// scheduledPaymentAmount returns the sum of the outputs of a scheduled
+// payment.
+func scheduledPaymentAmount(sp modules.ScheduledPayment) (amount types.Currency) {
+	for _, sco := range sp.Outputs {
+		amount = amount.Add(sco.Value)
+	}
+	return amount
+}
+
+// scheduledPaymentDue returns true if an active scheduled payment is due at
+// the provided height and time.
+func scheduledPaymentDue(sp modules.ScheduledPayment, height types.BlockHeight, now types.Timestamp) bool {
+	if sp.Status != modules.ScheduledPaymentActive {
+		return false
+	}
+	if sp.Schedule.Time != 0 {
+		return sp.Schedule.Time <= now
+	}
+	return sp.Schedule.Height <= height
+}
+
+// advanceScheduledPayment moves the schedule of a scheduled payment that was
+// just made to its next payment, or completes it. Payments that were missed
+// while the wallet was locked are made one at a time.
+func advanceScheduledPayment(sp *modules.ScheduledPayment) {
+	sp.Payments++
+	sp.Status, sp.HoldReason = modules.ScheduledPaymentActive, ""
+	switch {
+	case sp.Schedule.Interval == 0, sp.Schedule.Count != 0 && sp.Payments >= sp.Schedule.Count:
+		sp.Status = modules.ScheduledPaymentCompleted
+	case sp.Schedule.Time != 0:
+		sp.Schedule.Time += types.Timestamp(sp.Schedule.Interval)
+	default:
+		sp.Schedule.Height += types.BlockHeight(sp.Schedule.Interval)
+	}
+}
+
+// recordScheduledPaymentExecution stores an execution under the next
+// sequence number and prunes the oldest executions.
+func recordScheduledPaymentExecution(tx *bolt.Tx, execution *modules.ScheduledPaymentExecution) error {
+	sequence, err := dbGetScheduledPaymentExecutions(tx)
+	if err != nil {
+		return err
+	}
+	sequence++
+	execution.Sequence = sequence
+	if err := dbPutScheduledPaymentExecution(tx, *execution); err != nil {
+		return err
+	}
+	if sequence > maxScheduledPaymentExecutions {
+		if err := dbDeleteScheduledPaymentExecution(tx, sequence-maxScheduledPaymentExecutions); err != nil {
+			return err
+		}
+	}
+	return dbPutScheduledPaymentExecutions(tx, sequence)
+}
+
+// recordScheduledPaymentSpend adds a payment that was made to the spending of
+// the limit period and prunes the spends that are older than the period.
+func recordScheduledPaymentSpend(tx *bolt.Tx, timestamp types.Timestamp, amount types.Currency) error {
+	spending, err := dbGetScheduledPaymentSpending(tx)
+	if err != nil {
+		return err
+	}
+	since := timestamp - types.Timestamp(scheduledPaymentLimitPeriod.Seconds())
+	var kept []scheduledPaymentSpend
+	for _, spend := range spending {
+		if spend.Timestamp >= since {
+			kept = append(kept, spend)
+		}
+	}
+	kept = append(kept, scheduledPaymentSpend{Timestamp: timestamp, Amount: amount})
+	return dbPutScheduledPaymentSpending(tx, kept)
+}
+
+// scheduledPaymentsSpent returns the amount of the scheduled payments that
+// were made since the provided time.
+func scheduledPaymentsSpent(tx *bolt.Tx, since types.Timestamp) (spent types.Currency, err error) {
+	spending, err := dbGetScheduledPaymentSpending(tx)
+	for _, spend := range spending {
+		if spend.Timestamp >= since {
+			spent = spent.Add(spend.Amount)
+		}
+	}
+	return spent, err
+}
+
+// threadedExecuteScheduledPayments makes the scheduled payments that are due.
+// Payments that exceed the limits are held for approval instead. It is called
+// for every block once the wallet is synced, so payments on a time schedule
+// are made with the first block after their time.
+func (w *Wallet) threadedExecuteScheduledPayments() {
+	if err := w.tg.Add(); err != nil {
+		return
+	}
+	defer w.tg.Done()
+	w.scheduledPaymentLock.Lock()
+	defer w.scheduledPaymentLock.Unlock()
+
+	w.mu.RLock()
+	unlocked := w.unlocked
+	w.mu.RUnlock()
+	if !unlocked {
+		return
+	}
+	if err := w.managedReconcileScheduledPayments(); err != nil {
+		w.log.Println("Unable to reconcile in-flight scheduled payments:", err)
+		return
+	}
+
+	w.mu.Lock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		w.mu.Unlock()
+		w.log.Println("Unable to get the consensus height:", err)
+		return
+	}
+	now := types.CurrentTimestamp()
+	var due []modules.ScheduledPayment
+	err = dbForEachScheduledPayment(w.dbTx, func(_ uint64, sp modules.ScheduledPayment) {
+		if scheduledPaymentDue(sp, height, now) {
+			due = append(due, sp)
+		}
+	})
+	if err != nil {
+		w.mu.Unlock()
+		w.log.Println("Unable to load the scheduled payments:", err)
+		return
+	}
+	limits, err := dbGetScheduledPaymentLimits(w.dbTx)
+	if err != nil {
+		w.mu.Unlock()
+		w.log.Println("Unable to load the scheduled payment limits:", err)
+		return
+	}
+	spent, err := scheduledPaymentsSpent(w.dbTx, now-types.Timestamp(scheduledPaymentLimitPeriod.Seconds()))
+	w.mu.Unlock()
+	if err != nil {
+		w.log.Println("Unable to load the scheduled payment executions:", err)
+		return
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].ID < due[j].ID
+	})
+
+	for _, sp := range due {
+		amount := scheduledPaymentAmount(sp)
+		var reason string
+		if !limits.ApprovalThreshold.IsZero() && amount.Cmp(limits.ApprovalThreshold) > 0 {
+			reason = "amount exceeds the approval threshold"
+		} else if !limits.DailyLimit.IsZero() && spent.Add(amount).Cmp(limits.DailyLimit) > 0 {
+			reason = "daily limit reached"
+		}
+		if reason != "" {
+			if err := w.managedHoldScheduledPayment(sp.ID, reason); err != nil {
+				w.log.Println("Unable to hold scheduled payment:", err)
+			}
+			continue
+		}
+		if execution, err := w.managedExecuteScheduledPayment(sp); err != nil {
+			w.log.Println("Unable to record scheduled payment:", err)
+		} else if execution.Error == "" {
+			spent = spent.Add(amount)
+		}
+	}
+}
+
+// managedHoldScheduledPayment holds a due scheduled payment for approval.
+func (w *Wallet) managedHoldScheduledPayment(id uint64, reason string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	sp, err := dbGetScheduledPayment(w.dbTx, id)
+	if err != nil || sp.Status != modules.ScheduledPaymentActive {
+		return err
+	}
+	sp.Status, sp.HoldReason = modules.ScheduledPaymentHeld, reason
+	if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+		return err
+	}
+	w.log.Printf("Scheduled payment %v of %v is held for approval: %v", sp.ID, scheduledPaymentAmount(sp).HumanString(), reason)
+	return w.syncDB()
+}
+
+// managedExecuteScheduledPayment sends the outputs of a scheduled payment and
+// records the result. The schedule is only advanced if the payment was made;
+// failed payments are retried with the next block. The transactions are
+// recorded as in flight before they are broadcast.
+func (w *Wallet) managedExecuteScheduledPayment(sp modules.ScheduledPayment) (modules.ScheduledPaymentExecution, error) {
+	amount := scheduledPaymentAmount(sp)
+	txns, sendErr := w.managedSendSiacoinsMulti(sp.Outputs, nil, func(txnSet []types.Transaction) error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		err := dbPutScheduledPaymentInFlight(w.dbTx, sp.ID, inFlightScheduledPayment{
+			Amount:       amount,
+			Transactions: txnSet,
+		})
+		if err != nil {
+			return err
+		}
+		return w.syncDB()
+	})
+	return w.managedRecordScheduledPayment(sp.ID, amount, txns, sendErr)
+}
+
+// managedRecordScheduledPayment records the result of an attempt to make a
+// scheduled payment and clears its in-flight record. If the payment was
+// made, its schedule is advanced and its amount counts towards the daily
+// limit.
+func (w *Wallet) managedRecordScheduledPayment(id uint64, amount types.Currency, txns []types.Transaction, sendErr error) (modules.ScheduledPaymentExecution, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	height, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return modules.ScheduledPaymentExecution{}, err
+	}
+	execution := modules.ScheduledPaymentExecution{
+		PaymentID: id,
+		Timestamp: types.CurrentTimestamp(),
+		Height:    height,
+		Amount:    amount,
+	}
+	if sendErr != nil {
+		execution.Error = sendErr.Error()
+		w.log.Printf("Scheduled payment %v of %v failed: %v", id, amount.HumanString(), sendErr)
+	} else {
+		for _, txn := range txns {
+			execution.TransactionIDs = append(execution.TransactionIDs, txn.ID())
+		}
+		w.log.Printf("Scheduled payment %v of %v was made in transactions %v", id, amount.HumanString(), execution.TransactionIDs)
+		// Reload the payment, in case it was cancelled while the coins were
+		// sent.
+		sp, err := dbGetScheduledPayment(w.dbTx, id)
+		if err != nil {
+			return modules.ScheduledPaymentExecution{}, err
+		}
+		cancelled := sp.Status == modules.ScheduledPaymentCancelled
+		advanceScheduledPayment(&sp)
+		if cancelled {
+			sp.Status = modules.ScheduledPaymentCancelled
+		}
+		if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+			return modules.ScheduledPaymentExecution{}, err
+		}
+		if err := recordScheduledPaymentSpend(w.dbTx, execution.Timestamp, amount); err != nil {
+			return modules.ScheduledPaymentExecution{}, err
+		}
+	}
+	if err := dbDeleteScheduledPaymentInFlight(w.dbTx, id); err != nil {
+		return modules.ScheduledPaymentExecution{}, err
+	}
+	if err := recordScheduledPaymentExecution(w.dbTx, &execution); err != nil {
+		return modules.ScheduledPaymentExecution{}, err
+	}
+	return execution, w.syncDB()
+}
+
+// managedReconcileScheduledPayments records the result of the scheduled
+// payments that were in flight when the wallet stopped. A payment was made if
+// the wallet knows its transactions, or if the transaction pool still accepts
+// them; otherwise it failed and is retried like any failed payment. It must
+// be called while holding scheduledPaymentLock.
+func (w *Wallet) managedReconcileScheduledPayments() error {
+	inFlight := make(map[uint64]inFlightScheduledPayment)
+	w.mu.Lock()
+	err := dbForEachScheduledPaymentInFlight(w.dbTx, func(id uint64, ifp inFlightScheduledPayment) {
+		inFlight[id] = ifp
+	})
+	w.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for id, ifp := range inFlight {
+		if len(ifp.Transactions) == 0 {
+			build.Critical("in-flight scheduled payment has no transactions")
+			continue
+		}
+		txid := ifp.Transactions[len(ifp.Transactions)-1].ID()
+		w.mu.Lock()
+		_, err := dbGetTransactionIndex(w.dbTx, txid)
+		known := err == nil
+		for _, upt := range w.unconfirmedProcessedTransactions {
+			known = known || upt.TransactionID == txid
+		}
+		w.mu.Unlock()
+
+		var sendErr error
+		if !known {
+			sendErr = w.tpool.AcceptTransactionSet(ifp.Transactions)
+			if sendErr == modules.ErrDuplicateTransactionSet {
+				sendErr = nil
+			}
+		}
+		if sendErr != nil {
+			sendErr = build.ExtendErr("interrupted payment could not be broadcast", sendErr)
+		}
+		if _, err := w.managedRecordScheduledPayment(id, ifp.Amount, ifp.Transactions, sendErr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApproveScheduledPayment makes a scheduled payment that is held for
+// approval, regardless of the limits. If the payment fails, it remains held.
+func (w *Wallet) ApproveScheduledPayment(id uint64) (modules.ScheduledPaymentExecution, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ScheduledPaymentExecution{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.scheduledPaymentLock.Lock()
+	defer w.scheduledPaymentLock.Unlock()
+
+	w.mu.Lock()
+	if !w.unlocked {
+		w.mu.Unlock()
+		return modules.ScheduledPaymentExecution{}, modules.ErrLockedWallet
+	}
+	sp, err := dbGetScheduledPayment(w.dbTx, id)
+	w.mu.Unlock()
+	if err == errNoKey {
+		return modules.ScheduledPaymentExecution{}, errUnknownScheduledPayment
+	} else if err != nil {
+		return modules.ScheduledPaymentExecution{}, err
+	} else if sp.Status != modules.ScheduledPaymentHeld {
+		return modules.ScheduledPaymentExecution{}, errScheduledPaymentNotHeld
+	}
+	return w.managedExecuteScheduledPayment(sp)
+}
+
+// CancelScheduledPayment cancels a scheduled payment. Payments that were
+// already made are not affected.
+func (w *Wallet) CancelScheduledPayment(id uint64) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	sp, err := dbGetScheduledPayment(w.dbTx, id)
+	if err == errNoKey {
+		return errUnknownScheduledPayment
+	} else if err != nil {
+		return err
+	}
+	if sp.Status == modules.ScheduledPaymentCompleted || sp.Status == modules.ScheduledPaymentCancelled {
+		return errScheduledPaymentFinal
+	}
+	sp.Status, sp.HoldReason = modules.ScheduledPaymentCancelled, ""
+	if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+		return err
+	}
+	w.log.Printf("Scheduled payment %v was cancelled", id)
+	return w.syncDB()
+}
+
+// NewScheduledPayment schedules a payment of the outputs. The payment is made
+// with the first block at or after the height or time of the schedule, and
+// repeated every interval if the schedule is recurring.
+func (w *Wallet) NewScheduledPayment(outputs []types.SiacoinOutput, schedule modules.PaymentSchedule, memo string) (modules.ScheduledPayment, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ScheduledPayment{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	switch {
+	case len(outputs) == 0:
+		return modules.ScheduledPayment{}, errNoScheduledOutputs
+	case (schedule.Height == 0) == (schedule.Time == 0):
+		return modules.ScheduledPayment{}, errInvalidSchedule
+	case schedule.Interval == 0 && schedule.Count > 1:
+		return modules.ScheduledPayment{}, errInvalidSchedule
+	case len(memo) > maxScheduledPaymentMemoLength:
+		return modules.ScheduledPayment{}, errScheduledPaymentMemoTooLong
+	}
+	for _, sco := range outputs {
+		if sco.Value.IsZero() {
+			return modules.ScheduledPayment{}, errZeroScheduledOutput
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ScheduledPayment{}, modules.ErrLockedWallet
+	}
+	id, err := dbGetScheduledPayments(w.dbTx)
+	if err != nil {
+		return modules.ScheduledPayment{}, err
+	}
+	id++
+	sp := modules.ScheduledPayment{
+		ID:       id,
+		Outputs:  outputs,
+		Memo:     memo,
+		Schedule: schedule,
+		Status:   modules.ScheduledPaymentActive,
+	}
+	if err := dbPutScheduledPayment(w.dbTx, sp); err != nil {
+		return modules.ScheduledPayment{}, err
+	}
+	if err := dbPutScheduledPayments(w.dbTx, id); err != nil {
+		return modules.ScheduledPayment{}, err
+	}
+	return sp, w.syncDB()
+}
+
+// ScheduledPaymentExecutions returns the recent attempts to make scheduled
+// payments with a sequence number greater than since, in order.
+func (w *Wallet) ScheduledPaymentExecutions(since uint64) ([]modules.ScheduledPaymentExecution, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var executions []modules.ScheduledPaymentExecution
+	err := dbForEachScheduledPaymentExecution(w.dbTx, func(sequence uint64, execution modules.ScheduledPaymentExecution) {
+		if sequence > since {
+			executions = append(executions, execution)
+		}
+	})
+	sort.Slice(executions, func(i, j int) bool {
+		return executions[i].Sequence < executions[j].Sequence
+	})
+	return executions, err
+}
+
+// ScheduledPaymentLimits returns the limits of the scheduled payments.
+func (w *Wallet) ScheduledPaymentLimits() (modules.ScheduledPaymentLimits, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.ScheduledPaymentLimits{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return dbGetScheduledPaymentLimits(w.dbTx)
+}
+
+// ScheduledPayments returns all scheduled payments of the wallet, oldest
+// first.
+func (w *Wallet) ScheduledPayments() ([]modules.ScheduledPayment, error) {
+	if err := w.tg.Add(); err != nil {
+		return nil, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var sps []modules.ScheduledPayment
+	err := dbForEachScheduledPayment(w.dbTx, func(_ uint64, sp modules.ScheduledPayment) {
+		sps = append(sps, sp)
+	})
+	sort.Slice(sps, func(i, j int) bool {
+		return sps[i].ID < sps[j].ID
+	})
+	return sps, err
+}
+
+// SetScheduledPaymentLimits sets the limits of the scheduled payments. The
+// limits apply to payments that become due afterwards.
+func (w *Wallet) SetScheduledPaymentLimits(limits modules.ScheduledPaymentLimits) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.ErrLockedWallet
+	}
+	if err := dbPutScheduledPaymentLimits(w.dbTx, limits); err != nil {
+		return err
+	}
+	return w.syncDB()
+}
@@ -0,0 +1,305 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestScheduledPayments checks that scheduled payments are made when they are
+// due, that recurring payments repeat, and that payments over the approval
+// threshold are held until they are approved.
+func TestScheduledPayments(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// Scheduled payments are only made once the wallet is synced.
+	// Transactions that are signed right at the hardfork height can be
+	// invalid once they reach the transaction pool.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !wt.cs.Synced() {
+			return errors.New("consensus set is not synced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		wt.addBlockNoPayout()
+	}
+
+	dest := types.UnlockHash{1}
+	amount := types.SiacoinPrecision.Mul64(100)
+	outputs := []types.SiacoinOutput{{Value: amount, UnlockHash: dest}}
+	if _, err := wt.wallet.NewScheduledPayment(outputs, modules.PaymentSchedule{}, ""); err != errInvalidSchedule {
+		t.Fatal("expected errInvalidSchedule, got", err)
+	}
+	if _, err := wt.wallet.NewScheduledPayment(outputs, modules.PaymentSchedule{Height: 1, Count: 2}, ""); err != errInvalidSchedule {
+		t.Fatal("expected errInvalidSchedule, got", err)
+	}
+	if _, err := wt.wallet.NewScheduledPayment([]types.SiacoinOutput{{UnlockHash: dest}}, modules.PaymentSchedule{Height: 1}, ""); err != errZeroScheduledOutput {
+		t.Fatal("expected errZeroScheduledOutput, got", err)
+	}
+	limits := modules.ScheduledPaymentLimits{ApprovalThreshold: amount.Mul64(2)}
+	if err := wt.wallet.SetScheduledPaymentLimits(limits); err != nil {
+		t.Fatal(err)
+	}
+
+	// Schedule a recurring payment that is made twice, one on a time
+	// schedule, one that is cancelled, and one that exceeds the approval
+	// threshold.
+	height := wt.cs.Height()
+	recurring, err := wt.wallet.NewScheduledPayment(outputs, modules.PaymentSchedule{Height: height + 1, Interval: 1, Count: 2}, "hosting")
+	if err != nil {
+		t.Fatal(err)
+	}
+	timed, err := wt.wallet.NewScheduledPayment(outputs, modules.PaymentSchedule{Time: types.CurrentTimestamp()}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cancelled, err := wt.wallet.NewScheduledPayment(outputs, modules.PaymentSchedule{Height: height + 1}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.CancelScheduledPayment(cancelled.ID); err != nil {
+		t.Fatal(err)
+	}
+	large, err := wt.wallet.NewScheduledPayment([]types.SiacoinOutput{{Value: amount.Mul64(3), UnlockHash: dest}}, modules.PaymentSchedule{Height: height + 1}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// checkPayments waits until the scheduled payments have the expected
+	// number of payments and statuses.
+	checkPayments := func(payments []uint64, statuses []modules.ScheduledPaymentStatus) {
+		t.Helper()
+		err := build.Retry(50, 100*time.Millisecond, func() error {
+			sps, err := wt.wallet.ScheduledPayments()
+			if err != nil {
+				return err
+			}
+			for i, sp := range sps {
+				if sp.Payments != payments[i] || sp.Status != statuses[i] {
+					return fmt.Errorf("scheduled payment %v has %v payments and status %v", sp.ID, sp.Payments, sp.Status)
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	wt.addBlockNoPayout()
+	checkPayments([]uint64{1, 1, 0, 0}, []modules.ScheduledPaymentStatus{
+		modules.ScheduledPaymentActive, modules.ScheduledPaymentCompleted, modules.ScheduledPaymentCancelled, modules.ScheduledPaymentHeld,
+	})
+	wt.addBlockNoPayout()
+	checkPayments([]uint64{2, 1, 0, 0}, []modules.ScheduledPaymentStatus{
+		modules.ScheduledPaymentCompleted, modules.ScheduledPaymentCompleted, modules.ScheduledPaymentCancelled, modules.ScheduledPaymentHeld,
+	})
+
+	// Approve the held payment.
+	if _, err := wt.wallet.ApproveScheduledPayment(timed.ID); err != errScheduledPaymentNotHeld {
+		t.Fatal("expected errScheduledPaymentNotHeld, got", err)
+	}
+	execution, err := wt.wallet.ApproveScheduledPayment(large.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if execution.Error != "" || len(execution.TransactionIDs) == 0 || !execution.Amount.Equals(amount.Mul64(3)) {
+		t.Fatal("wrong execution of the approved payment:", execution)
+	}
+	if err := wt.wallet.CancelScheduledPayment(large.ID); err != errScheduledPaymentFinal {
+		t.Fatal("expected errScheduledPaymentFinal, got", err)
+	}
+
+	// The executions are recorded with their transactions, which are
+	// confirmed with the next block.
+	executions, err := wt.wallet.ScheduledPaymentExecutions(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 4 {
+		t.Fatal("expected 4 executions, got", executions)
+	}
+	paid := make(map[uint64]int)
+	for i, e := range executions {
+		if e.Sequence != uint64(i+1) || e.Error != "" || len(e.TransactionIDs) == 0 {
+			t.Fatal("wrong execution:", e)
+		}
+		paid[e.PaymentID]++
+	}
+	if paid[recurring.ID] != 2 || paid[timed.ID] != 1 || executions[3].PaymentID != large.ID {
+		t.Fatal("wrong executions:", executions)
+	}
+	executions, err = wt.wallet.ScheduledPaymentExecutions(3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 1 {
+		t.Fatal("expected 1 execution, got", len(executions))
+	}
+	wt.addBlockNoPayout()
+	ids := executions[0].TransactionIDs
+	pt, found, err := wt.wallet.Transaction(ids[len(ids)-1])
+	if err != nil || !found {
+		t.Fatal("transaction of the scheduled payment was not found:", err)
+	}
+	if pt.ConfirmationHeight != wt.cs.Height() {
+		t.Fatal("transaction of the scheduled payment was not confirmed")
+	}
+}
+
+// TestScheduledPaymentsInFlight checks that scheduled payments whose
+// transactions were recorded as in flight, but whose result was not recorded,
+// are reconciled without paying twice.
+func TestScheduledPaymentsInFlight(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	for wt.cs.Height() <= types.ASICHardforkHeight {
+		wt.addBlockNoPayout()
+	}
+
+	// Schedule two payments that are not due yet.
+	amount := types.SiacoinPrecision.Mul64(100)
+	outputs := []types.SiacoinOutput{{Value: amount, UnlockHash: types.UnlockHash{1}}}
+	schedule := modules.PaymentSchedule{Height: wt.cs.Height() + 100}
+	broadcast, err := wt.wallet.NewScheduledPayment(outputs, schedule, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interrupted, err := wt.wallet.NewScheduledPayment(outputs, schedule, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Record both payments as in flight, as if the wallet stopped right
+	// before or right after broadcasting them.
+	errStop := errors.New("wallet stopped")
+	sendInFlight := func(sp modules.ScheduledPayment, stop error) {
+		t.Helper()
+		_, err := wt.wallet.managedSendSiacoinsMulti(outputs, nil, func(set []types.Transaction) error {
+			wt.wallet.mu.Lock()
+			defer wt.wallet.mu.Unlock()
+			err := dbPutScheduledPaymentInFlight(wt.wallet.dbTx, sp.ID, inFlightScheduledPayment{
+				Amount:       amount,
+				Transactions: set,
+			})
+			if err != nil {
+				return err
+			}
+			return stop
+		})
+		if err != stop {
+			t.Fatal(err)
+		}
+	}
+	sendInFlight(broadcast, nil)
+	sendInFlight(interrupted, errStop)
+
+	// Reconciling makes the interrupted payment and records both, but
+	// doesn't pay the broadcast one again.
+	if err := wt.wallet.managedReconcileScheduledPayments(); err != nil {
+		t.Fatal(err)
+	}
+	executions, err := wt.wallet.ScheduledPaymentExecutions(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(executions) != 2 {
+		t.Fatal("expected 2 executions, got", executions)
+	}
+	for _, e := range executions {
+		if e.Error != "" || len(e.TransactionIDs) == 0 {
+			t.Fatal("wrong execution:", e)
+		}
+	}
+	sps, err := wt.wallet.ScheduledPayments()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, sp := range sps {
+		if sp.Payments != 1 || sp.Status != modules.ScheduledPaymentCompleted {
+			t.Fatal("wrong scheduled payment after reconciling:", sp)
+		}
+	}
+	if len(wt.tpool.TransactionList()) != len(executions[0].TransactionIDs)+len(executions[1].TransactionIDs) {
+		t.Fatal("expected both payments in the transaction pool, got", len(wt.tpool.TransactionList()))
+	}
+	if err := wt.wallet.managedReconcileScheduledPayments(); err != nil {
+		t.Fatal(err)
+	}
+	if executions, _ := wt.wallet.ScheduledPaymentExecutions(0); len(executions) != 2 {
+		t.Fatal("in-flight payments were reconciled twice")
+	}
+}
+
+// TestScheduledPaymentsSpent checks that the daily limit counts the payments
+// that were made within the limit period, regardless of how many failed
+// attempts were recorded since.
+func TestScheduledPaymentsSpent(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+	wt.wallet.mu.Lock()
+	defer wt.wallet.mu.Unlock()
+	tx := wt.wallet.dbTx
+
+	now := types.CurrentTimestamp()
+	period := types.Timestamp(scheduledPaymentLimitPeriod.Seconds())
+	amount := types.SiacoinPrecision
+	if err := recordScheduledPaymentSpend(tx, now-period-1, amount.Mul64(5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := recordScheduledPaymentSpend(tx, now, amount); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < maxScheduledPaymentExecutions+1; i++ {
+		execution := modules.ScheduledPaymentExecution{PaymentID: 1, Timestamp: now, Amount: amount, Error: "not enough money"}
+		if err := recordScheduledPaymentExecution(tx, &execution); err != nil {
+			t.Fatal(err)
+		}
+	}
+	spent, err := scheduledPaymentsSpent(tx, now-period)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !spent.Equals(amount) {
+		t.Fatal("expected the recent payment to be spent, got", spent)
+	}
+
+	// Spends older than the period are pruned.
+	if err := recordScheduledPaymentSpend(tx, now+period, amount); err != nil {
+		t.Fatal(err)
+	}
+	spending, err := dbGetScheduledPaymentSpending(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(spending) != 2 {
+		t.Fatal("expected old spends to be pruned, got", spending)
+	}
+}
@@ -117,6 +117,8 @@ func (w *Wallet) nextPrimarySeedAddresses(tx *bolt.Tx, n uint64) ([]types.Unlock
 	// Check that the wallet has been unlocked.
 	if !w.unlocked {
 		return []types.UnlockConditions{}, modules.ErrLockedWallet
+	} else if w.watchOnly {
+		return []types.UnlockConditions{}, errWatchOnlyWallet
 	}
 
 	// Fetch and increment the seed progress.
@@ -157,6 +159,8 @@ func (w *Wallet) AllSeeds() ([]modules.Seed, error) {
 	defer w.mu.Unlock()
 	if !w.unlocked {
 		return nil, modules.ErrLockedWallet
+	} else if w.watchOnly {
+		return nil, errWatchOnlyWallet
 	}
 	return append([]modules.Seed{w.primarySeed}, w.seeds...), nil
 }
@@ -168,6 +172,8 @@ func (w *Wallet) PrimarySeed() (modules.Seed, uint64, error) {
 	defer w.mu.Unlock()
 	if !w.unlocked {
 		return modules.Seed{}, 0, modules.ErrLockedWallet
+	} else if w.watchOnly {
+		return modules.Seed{}, 0, errWatchOnlyWallet
 	}
 	progress, err := dbGetPrimarySeedProgress(w.dbTx)
 	if err != nil {
@@ -245,6 +251,9 @@ func (w *Wallet) LoadSeed(masterKey crypto.TwofishKey, seed modules.Seed) error
 	if !w.unlocked {
 		w.mu.RUnlock()
 		return modules.ErrLockedWallet
+	} else if w.watchOnly {
+		w.mu.RUnlock()
+		return errWatchOnlyWallet
 	}
 	for _, wSeed := range append([]modules.Seed{w.primarySeed}, w.seeds...) {
 		if seed == wSeed {
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"sort"
 
+	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/modules"
@@ -22,12 +23,24 @@ var (
 	// errDustOutput indicates an output is not spendable because it is dust.
 	errDustOutput = errors.New("output is too small")
 
+	// errDuplicateSelectedOutput indicates that an output was selected more
+	// than once.
+	errDuplicateSelectedOutput = errors.New("output was selected more than once")
+
+	// errNoSelectedOutputs indicates that coin control was requested without
+	// selecting any outputs.
+	errNoSelectedOutputs = errors.New("no outputs were selected")
+
 	// errOutputTimelock indicates an output's timelock is still active.
 	errOutputTimelock = errors.New("wallet consensus set height is lower than the output timelock")
 
 	// errSpendHeightTooHigh indicates an output's spend height is greater than
 	// the allowed height.
 	errSpendHeightTooHigh = errors.New("output spend height exceeds the allowed height")
+
+	// errUnknownSelectedOutput indicates that a selected output doesn't exist
+	// or can't be spent by the wallet alone.
+	errUnknownSelectedOutput = errors.New("selected output is not a spendable output of the wallet")
 )
 
 // transactionBuilder allows transactions to be manually constructed, including
@@ -134,6 +147,11 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	// Collect a value-sorted set of siacoin outputs.
 	var so sortedOutputs
 	err = dbForEachSiacoinOutput(tb.wallet.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		// Outputs of multisig addresses and watch-only keys can't be signed
+		// by the wallet alone.
+		if _, multisig := tb.wallet.multisigAddrs[sco.UnlockHash]; multisig || tb.wallet.isWatchOnlyKeyAddress(sco.UnlockHash) {
+			return
+		}
 		so.ids = append(so.ids, scoid)
 		so.outputs = append(so.outputs, sco)
 	})
@@ -254,6 +272,118 @@ func (tb *transactionBuilder) FundSiacoins(amount types.Currency) error {
 	return nil
 }
 
+// FundSiacoinsFromOutputs adds the selected outputs of the wallet as siacoin
+// inputs of the transaction. The outputs are spent largest first until they
+// cover 'amount', or all of them are spent if the selection asks for it.
+// Anything beyond 'amount' is sent to the change address by an output of the
+// transaction itself, so that no other outputs of the wallet are involved.
+// The siacoin inputs will not be signed until 'Sign' is called on the
+// transaction builder.
+func (tb *transactionBuilder) FundSiacoinsFromOutputs(amount types.Currency, selection modules.SiacoinSelection) error {
+	if len(selection.Outputs) == 0 {
+		return errNoSelectedOutputs
+	}
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := tb.wallet.DustThreshold()
+	if err != nil {
+		return err
+	}
+
+	tb.wallet.mu.Lock()
+	defer tb.wallet.mu.Unlock()
+
+	consensusHeight, err := dbGetConsensusHeight(tb.wallet.dbTx)
+	if err != nil {
+		return err
+	}
+
+	// Look up the selected outputs among the confirmed and unconfirmed
+	// outputs of the wallet.
+	unconfirmed := make(map[types.SiacoinOutputID]types.SiacoinOutput)
+	for _, upt := range tb.wallet.unconfirmedProcessedTransactions {
+		for i, sco := range upt.Transaction.SiacoinOutputs {
+			unconfirmed[upt.Transaction.SiacoinOutputID(uint64(i))] = sco
+		}
+	}
+	var so sortedOutputs
+	selected := make(map[types.SiacoinOutputID]struct{})
+	for _, scoid := range selection.Outputs {
+		if _, exists := selected[scoid]; exists {
+			return errDuplicateSelectedOutput
+		}
+		selected[scoid] = struct{}{}
+
+		sco, err := dbGetSiacoinOutput(tb.wallet.dbTx, scoid)
+		if err == errNoKey {
+			var exists bool
+			if sco, exists = unconfirmed[scoid]; !exists {
+				return errUnknownSelectedOutput
+			}
+		} else if err != nil {
+			return err
+		}
+		// Outputs of multisig addresses can't be signed by the wallet alone.
+		_, owned := tb.wallet.keys[sco.UnlockHash]
+		_, multisig := tb.wallet.multisigAddrs[sco.UnlockHash]
+		if !owned || multisig {
+			return errUnknownSelectedOutput
+		}
+		if err := tb.wallet.checkOutput(tb.wallet.dbTx, consensusHeight, scoid, sco, dustThreshold); err != nil {
+			return build.ExtendErr("cannot spend output "+scoid.String(), err)
+		}
+		so.ids = append(so.ids, scoid)
+		so.outputs = append(so.outputs, sco)
+	}
+	sort.Sort(sort.Reverse(so))
+
+	// Spend the selected outputs directly from the transaction.
+	var fund types.Currency
+	var spent int
+	for i := range so.ids {
+		if !selection.SpendAll && fund.Cmp(amount) >= 0 {
+			break
+		}
+		fund = fund.Add(so.outputs[i].Value)
+		spent++
+	}
+	if fund.Cmp(amount) < 0 {
+		return modules.ErrLowBalance
+	}
+	for i := 0; i < spent; i++ {
+		sci := types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: tb.wallet.keys[so.outputs[i].UnlockHash].UnlockConditions,
+		}
+		tb.siacoinInputs = append(tb.siacoinInputs, len(tb.transaction.SiacoinInputs))
+		tb.transaction.SiacoinInputs = append(tb.transaction.SiacoinInputs, sci)
+	}
+
+	// Return the remainder to the change address.
+	if !amount.Equals(fund) {
+		changeAddress := selection.ChangeAddress
+		if changeAddress == (types.UnlockHash{}) {
+			changeUnlockConditions, err := tb.wallet.nextPrimarySeedAddress(tb.wallet.dbTx)
+			if err != nil {
+				return err
+			}
+			changeAddress = changeUnlockConditions.UnlockHash()
+		}
+		tb.transaction.SiacoinOutputs = append(tb.transaction.SiacoinOutputs, types.SiacoinOutput{
+			Value:      fund.Sub(amount),
+			UnlockHash: changeAddress,
+		})
+	}
+
+	// Mark all outputs that were spent as spent.
+	for _, scoid := range so.ids[:spent] {
+		err = dbPutSpentOutput(tb.wallet.dbTx, types.OutputID(scoid), consensusHeight)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FundSiafunds will add a siafund input of exactly 'amount' to the
 // transaction. A parent transaction may be needed to achieve an input with the
 // correct value. The siafund input will not be signed until 'Sign' is called
@@ -283,6 +413,12 @@ func (tb *transactionBuilder) FundSiafunds(amount types.Currency) error {
 			return err
 		}
 
+		// Outputs of multisig addresses and watch-only keys can't be signed
+		// by the wallet alone.
+		if _, multisig := tb.wallet.multisigAddrs[sfo.UnlockHash]; multisig || tb.wallet.isWatchOnlyKeyAddress(sfo.UnlockHash) {
+			continue
+		}
+
 		// Check that this output has not recently been spent by the wallet.
 		spendHeight, err := dbGetSpentOutput(tb.wallet.dbTx, types.OutputID(sfoid))
 		if err != nil {
@@ -7,7 +7,6 @@ import (
 	"sort"
 
 	"gitlab.com/NebulousLabs/Sia/build"
-	"gitlab.com/NebulousLabs/Sia/encoding"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/types"
 )
@@ -36,6 +35,7 @@ func (w *Wallet) AddressTransactions(uh types.UnlockHash) (pts []modules.Process
 		if err != nil {
 			continue
 		}
+		annotateTransaction(w.dbTx, &pt)
 		pts = append(pts, pt)
 	}
 	return pts, nil
@@ -72,6 +72,7 @@ func (w *Wallet) AddressUnconfirmedTransactions(uh types.UnlockHash) (pts []modu
 			}
 		}
 		if relevant {
+			annotateTransaction(w.dbTx, &pt)
 			pts = append(pts, pt)
 		}
 	}
@@ -99,7 +100,8 @@ func (w *Wallet) Transaction(txid types.TransactionID) (pt modules.ProcessedTran
 	}
 
 	// Retrieve the transaction
-	found = encoding.Unmarshal(w.dbTx.Bucket(bucketProcessedTransactions).Get(keyBytes), &pt) == nil
+	found = decodeProcessedTransaction(w.dbTx.Bucket(bucketProcessedTransactions).Get(keyBytes), &pt) == nil
+	annotateTransaction(w.dbTx, &pt)
 	return
 }
 
@@ -191,6 +193,7 @@ func (w *Wallet) Transactions(startHeight, endHeight types.BlockHeight) (pts []m
 		if build.DEBUG && pt.ConfirmationHeight < startHeight {
 			build.Critical("wallet processed transactions are not sorted")
 		}
+		annotateTransaction(w.dbTx, &pt)
 		pts = append(pts, pt)
 
 		// Get next processed transaction
@@ -216,5 +219,9 @@ func (w *Wallet) UnconfirmedTransactions() ([]modules.ProcessedTransaction, erro
 	defer w.tg.Done()
 	w.mu.RLock()
 	defer w.mu.RUnlock()
-	return w.unconfirmedProcessedTransactions, nil
+	pts := append([]modules.ProcessedTransaction(nil), w.unconfirmedProcessedTransactions...)
+	for i := range pts {
+		annotateTransaction(w.dbTx, &pts[i])
+	}
+	return pts, nil
 }
@@ -87,6 +87,8 @@ func (w *Wallet) loadSpendableKey(masterKey crypto.TwofishKey, sk spendableKey)
 	// the wallet is locked, correct deduplication is uncertain.
 	if !w.unlocked {
 		return modules.ErrLockedWallet
+	} else if w.watchOnly {
+		return errWatchOnlyWallet
 	}
 
 	// Check for duplicates.
@@ -13,6 +13,7 @@ import (
 type (
 	spentSiacoinOutputSet map[types.SiacoinOutputID]types.SiacoinOutput
 	spentSiafundOutputSet map[types.SiafundOutputID]types.SiafundOutput
+	closedFileContractSet map[types.FileContractID]types.FileContract
 )
 
 // threadedResetSubscriptions unsubscribes the wallet from the consensus set and transaction pool
@@ -71,11 +72,14 @@ func (w *Wallet) advanceSeedLookahead(index uint64) (bool, error) {
 }
 
 // isWalletAddress is a helper function that checks if an UnlockHash is
-// derived from one of the wallet's spendable keys or is being explicitly watched.
+// derived from one of the wallet's spendable keys, is a multisig address of
+// the wallet, belongs to a used watch-only key or is being explicitly
+// watched.
 func (w *Wallet) isWalletAddress(uh types.UnlockHash) bool {
 	_, spendable := w.keys[uh]
 	_, watchonly := w.watchedAddrs[uh]
-	return spendable || watchonly
+	_, multisig := w.multisigAddrs[uh]
+	return spendable || watchonly || multisig || w.isWatchOnlyKeyAddress(uh)
 }
 
 // updateLookahead uses a consensus change to update the seed progress if one of the outputs
@@ -96,11 +100,15 @@ func (w *Wallet) updateLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool,
 			}
 		}
 	}
+	var rescan bool
 	if largestIndex > 0 {
-		return w.advanceSeedLookahead(largestIndex)
+		var err error
+		if rescan, err = w.advanceSeedLookahead(largestIndex); err != nil {
+			return false, err
+		}
 	}
-
-	return false, nil
+	watchOnlyRescan, err := w.updateWatchOnlyLookahead(tx, cc)
+	return rescan || watchOnlyRescan, err
 }
 
 // updateConfirmedSet uses a consensus change to update the confirmed set of
@@ -239,10 +247,23 @@ func computeSpentSiafundOutputSet(diffs []modules.SiafundOutputDiff) spentSiafun
 	return outputs
 }
 
+// computeClosedFileContractSet scans a slice of file contract diffs for
+// removed file contracts and collects them in a map of FileContractID ->
+// FileContract. If a contract was revised, the last revision is kept.
+func computeClosedFileContractSet(diffs []modules.FileContractDiff) closedFileContractSet {
+	contracts := make(closedFileContractSet)
+	for _, diff := range diffs {
+		if diff.Direction == modules.DiffRevert {
+			contracts[diff.ID] = diff.FileContract
+		}
+	}
+	return contracts
+}
+
 // computeProcessedTransactionsFromBlock searches all the miner payouts and
 // transactions in a block and computes a ProcessedTransaction slice containing
 // all of the transactions processed for the given block.
-func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.Block, spentSiacoinOutputs spentSiacoinOutputSet, spentSiafundOutputs spentSiafundOutputSet, consensusHeight types.BlockHeight) []modules.ProcessedTransaction {
+func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.Block, spentSiacoinOutputs spentSiacoinOutputSet, spentSiafundOutputs spentSiafundOutputSet, closedFileContracts closedFileContractSet, consensusHeight types.BlockHeight) []modules.ProcessedTransaction {
 	var pts []modules.ProcessedTransaction
 
 	// Find ProcessedTransactions from miner payouts.
@@ -289,6 +310,11 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 		for _, sfo := range txn.SiafundOutputs {
 			relevant = relevant || w.isWalletAddress(sfo.UnlockHash)
 		}
+		for _, sp := range txn.StorageProofs {
+			for _, sco := range closedFileContracts[sp.ParentID].ValidProofOutputs {
+				relevant = relevant || w.isWalletAddress(sco.UnlockHash)
+			}
+		}
 
 		// Only create a ProcessedTransaction if transaction is relevant.
 		if !relevant {
@@ -388,6 +414,24 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 			}
 		}
 
+		for _, sp := range txn.StorageProofs {
+			for i, sco := range closedFileContracts[sp.ParentID].ValidProofOutputs {
+				po := modules.ProcessedOutput{
+					ID:             types.OutputID(sp.ParentID.StorageProofOutputID(types.ProofValid, uint64(i))),
+					FundType:       types.SpecifierStorageProofOutput,
+					MaturityHeight: consensusHeight + types.MaturityDelay,
+					WalletAddress:  w.isWalletAddress(sco.UnlockHash),
+					RelatedAddress: sco.UnlockHash,
+					Value:          sco.Value,
+				}
+				pt.Outputs = append(pt.Outputs, po)
+				// Log any wallet-relevant outputs.
+				if po.WalletAddress {
+					w.log.Println("\tStorage Proof Output:", po.ID, "::", po.Value.HumanString())
+				}
+			}
+		}
+
 		for _, fee := range txn.MinerFees {
 			pt.Outputs = append(pt.Outputs, modules.ProcessedOutput{
 				FundType:       types.SpecifierMinerFee,
@@ -395,6 +439,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 				Value:          fee,
 			})
 		}
+		annotateBumps(tx, &pt)
 		pts = append(pts, pt)
 	}
 	return pts
@@ -405,6 +450,7 @@ func (w *Wallet) computeProcessedTransactionsFromBlock(tx *bolt.Tx, block types.
 func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 	spentSiacoinOutputs := computeSpentSiacoinOutputSet(cc.SiacoinOutputDiffs)
 	spentSiafundOutputs := computeSpentSiafundOutputSet(cc.SiafundOutputDiffs)
+	closedFileContracts := computeClosedFileContractSet(cc.FileContractDiffs)
 
 	for _, block := range cc.AppliedBlocks {
 		consensusHeight, err := dbGetConsensusHeight(tx)
@@ -420,7 +466,7 @@ func (w *Wallet) applyHistory(tx *bolt.Tx, cc modules.ConsensusChange) error {
 			}
 		}
 
-		pts := w.computeProcessedTransactionsFromBlock(tx, block, spentSiacoinOutputs, spentSiafundOutputs, consensusHeight)
+		pts := w.computeProcessedTransactionsFromBlock(tx, block, spentSiacoinOutputs, spentSiafundOutputs, closedFileContracts, consensusHeight)
 		for _, pt := range pts {
 			err := dbAppendProcessedTransaction(tx, pt)
 			if err != nil {
@@ -461,13 +507,26 @@ func (w *Wallet) ProcessConsensusChange(cc modules.ConsensusChange) {
 		w.log.Severe("ERROR: failed to apply consensus change:", err)
 		w.dbRollback = true
 	}
+	if err := w.updateAtomicSwaps(w.dbTx, cc); err != nil {
+		w.log.Severe("ERROR: failed to update atomic swaps:", err)
+		w.dbRollback = true
+	}
+	if err := w.pruneBumpedTransactions(w.dbTx, cc.Synced); err != nil {
+		w.log.Severe("ERROR: failed to prune bumped transactions:", err)
+		w.dbRollback = true
+	}
 	if err := dbPutConsensusChangeID(w.dbTx, cc.ID); err != nil {
 		w.log.Severe("ERROR: failed to update consensus change ID:", err)
 		w.dbRollback = true
 	}
 
+	// Payment requests are only updated at the tip of the blockchain, so that
+	// a rescan doesn't replay their status changes.
 	if cc.Synced {
+		w.notifyPaymentRequests(true)
 		go w.threadedDefragWallet()
+		go w.threadedExecuteScheduledPayments()
+		go w.threadedRefundAtomicSwaps()
 	}
 }
 
@@ -580,7 +639,9 @@ func (w *Wallet) ReceiveUpdatedUnconfirmedTransactions(diff *modules.Transaction
 					Value:    fee,
 				})
 			}
+			annotateBumps(w.dbTx, &pt)
 			w.unconfirmedProcessedTransactions = append(w.unconfirmedProcessedTransactions, pt)
 		}
 	}
+	w.notifyPaymentRequests(false)
 }
@@ -93,3 +93,63 @@ func TestUpdate(t *testing.T) {
 		t.Fatal("transaction was not removed")
 	}
 }
+
+// TestStorageProofPayouts checks that the valid proof outputs of a file
+// contract that pay the wallet are recorded with the storage proof.
+func TestStorageProofPayouts(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	uc, err := wt.wallet.NextAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fcid := types.FileContractID{1}
+	payout := types.SiacoinPrecision.Mul64(10)
+	fc := types.FileContract{
+		ValidProofOutputs: []types.SiacoinOutput{
+			{Value: payout, UnlockHash: uc.UnlockHash()},
+			{Value: payout, UnlockHash: types.UnlockHash{1}},
+		},
+	}
+	proof := types.Transaction{StorageProofs: []types.StorageProof{{ParentID: fcid}}}
+	block := types.Block{ParentID: wt.cs.CurrentBlock().ID(), Transactions: []types.Transaction{proof}}
+	wt.wallet.ProcessConsensusChange(modules.ConsensusChange{
+		AppliedBlocks: []types.Block{block},
+		FileContractDiffs: []modules.FileContractDiff{{
+			Direction:    modules.DiffRevert,
+			ID:           fcid,
+			FileContract: fc,
+		}},
+	})
+
+	pt, ok, err := wt.wallet.Transaction(proof.ID())
+	if err != nil || !ok {
+		t.Fatal("storage proof paying the wallet was not recorded:", err)
+	}
+	if len(pt.Outputs) != 2 {
+		t.Fatal("expected 2 outputs, got", pt.Outputs)
+	}
+	po := pt.Outputs[0]
+	if po.FundType != types.SpecifierStorageProofOutput || po.ID != types.OutputID(fcid.StorageProofOutputID(types.ProofValid, 0)) || !po.WalletAddress || !po.Value.Equals(payout) {
+		t.Fatal("wrong storage proof output:", po)
+	}
+	if pt.Outputs[1].WalletAddress {
+		t.Fatal("output to another address should not belong to the wallet")
+	}
+	categories := modules.TransactionCategories(pt)
+	if len(categories) != 1 || categories[0] != modules.TransactionCategoryStorageProof {
+		t.Fatal("wrong categories:", categories)
+	}
+
+	wt.wallet.ProcessConsensusChange(modules.ConsensusChange{RevertedBlocks: []types.Block{block}})
+	if _, ok, _ := wt.wallet.Transaction(proof.ID()); ok {
+		t.Fatal("storage proof was not removed after its block was reverted")
+	}
+}
@@ -54,10 +54,12 @@ type Wallet struct {
 	// has subscribed to the consensus set yet - the wallet is unable to
 	// subscribe to the consensus set until it has been unlocked for the first
 	// time. The primary seed is used to generate new addresses for the
-	// wallet.
+	// wallet. watchOnly indicates whether the wallet was initialized without
+	// a seed by InitWatchOnly, in which case it never holds secret keys.
 	encrypted   bool
 	unlocked    bool
 	subscribed  bool
+	watchOnly   bool
 	primarySeed modules.Seed
 
 	// The wallet's dependencies.
@@ -76,6 +78,21 @@ type Wallet struct {
 	lookahead    map[types.UnlockHash]uint64
 	watchedAddrs map[types.UnlockHash]struct{}
 
+	// multisigAddrs contains the unlock conditions of the multisig addresses
+	// that the wallet tracks. The wallet holds some, but not necessarily
+	// enough, of the keys that are needed to spend from them.
+	multisigAddrs map[types.UnlockHash]types.UnlockConditions
+
+	// watchOnlyKeys contains the unlock conditions of the watch-only keys
+	// that were imported from a cold wallet, by seed index, and
+	// watchOnlyIndices maps their addresses to their index. The keys below
+	// watchOnlyProgress have been used and are tracked like the keys of the
+	// seeds. The keys that follow them, up to maxLookahead, are tracked like
+	// the lookahead of the primary seed.
+	watchOnlyKeys     []types.UnlockConditions
+	watchOnlyIndices  map[types.UnlockHash]uint64
+	watchOnlyProgress uint64
+
 	// unconfirmedProcessedTransactions tracks unconfirmed transactions.
 	//
 	// TODO: Replace this field with a linked list. Currently when a new
@@ -105,6 +122,17 @@ type Wallet struct {
 	// initialization.
 	scanLock siasync.TryMutex
 
+	// scheduledPaymentLock prevents scheduled payments from being made
+	// concurrently, which could make a payment twice.
+	scheduledPaymentLock sync.Mutex
+
+	// paymentRequestWebhooks queues the payment request events whose
+	// webhooks haven't been called yet, in sequence order. A single thread
+	// delivers them while paymentRequestWebhooksActive is set, so that every
+	// webhook receives its events in order. Both are protected by mu.
+	paymentRequestWebhooks       []modules.PaymentRequestEvent
+	paymentRequestWebhooksActive bool
+
 	// The wallet's ThreadGroup tells tracked functions to shut down and
 	// blocks until they have all exited before returning from Close.
 	tg threadgroup.ThreadGroup
@@ -162,6 +190,10 @@ func NewCustomWallet(cs modules.ConsensusSet, tpool modules.TransactionPool, per
 		lookahead:    make(map[types.UnlockHash]uint64),
 		watchedAddrs: make(map[types.UnlockHash]struct{}),
 
+		multisigAddrs: make(map[types.UnlockHash]types.UnlockConditions),
+
+		watchOnlyIndices: make(map[types.UnlockHash]uint64),
+
 		unconfirmedSets: make(map[modules.TransactionSetID][]types.TransactionID),
 
 		persistDir: persistDir,
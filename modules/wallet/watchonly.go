@@ -0,0 +1,381 @@
+package wallet
+
+import (
+	"errors"
+	"sort"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+
+	"github.com/coreos/bbolt"
+)
+
+const (
+	// maxWatchOnlyExportKeys is the maximum number of keys that can be
+	// exported at once.
+	maxWatchOnlyExportKeys = 100e3
+)
+
+var (
+	// errInvalidWatchOnlyKey is returned if an imported watch-only key is not
+	// an ed25519 key.
+	errInvalidWatchOnlyKey = errors.New("watch-only keys must be ed25519 keys")
+
+	// errNoWatchOnlyKeys is returned if the wallet has no unused watch-only
+	// key left to send change to.
+	errNoWatchOnlyKeys = errors.New("wallet has no unused watch-only keys, export more keys from the cold wallet")
+
+	// errTooManyWatchOnlyKeys is returned if more than
+	// maxWatchOnlyExportKeys keys are exported at once, or if the exported
+	// keys start more than maxWatchOnlyExportKeys keys past the primary seed
+	// progress.
+	errTooManyWatchOnlyKeys = errors.New("too many watch-only keys requested")
+
+	// errWatchOnlyBlankKey is returned if a watch-only wallet is initialized
+	// without a password.
+	errWatchOnlyBlankKey = errors.New("a watch-only wallet must be encrypted with a password")
+
+	// errWatchOnlyKeyGap is returned if imported watch-only keys don't
+	// continue or overlap the keys that the wallet has already imported.
+	errWatchOnlyKeyGap = errors.New("watch-only keys must continue the imported keys without a gap")
+
+	// errWatchOnlyKeyMismatch is returned if imported watch-only keys differ
+	// from keys that were already imported at the same index, which happens
+	// when they were exported by a different wallet.
+	errWatchOnlyKeyMismatch = errors.New("watch-only keys don't match the imported keys of the same index")
+
+	// errWatchOnlyWallet is returned by operations that need a seed or
+	// secret keys when the wallet is a watch-only wallet.
+	errWatchOnlyWallet = errors.New("watch-only wallet has no seed or secret keys")
+)
+
+// watchOnlyUnlockConditions returns the standard unlock conditions of a
+// watch-only key, which match the unlock conditions that the cold wallet
+// generates for the key.
+func watchOnlyUnlockConditions(pk types.SiaPublicKey) types.UnlockConditions {
+	return types.UnlockConditions{
+		PublicKeys:         []types.SiaPublicKey{pk},
+		SignaturesRequired: 1,
+	}
+}
+
+// integrateWatchOnlyKeys adds the keys, starting at seed index start, to the
+// in-memory watch-only keys. Keys that are already known are skipped.
+func (w *Wallet) integrateWatchOnlyKeys(start uint64, keys []types.SiaPublicKey) {
+	for i, pk := range keys {
+		index := start + uint64(i)
+		if index < uint64(len(w.watchOnlyKeys)) {
+			continue
+		}
+		uc := watchOnlyUnlockConditions(pk)
+		w.watchOnlyKeys = append(w.watchOnlyKeys, uc)
+		w.watchOnlyIndices[uc.UnlockHash()] = index
+	}
+}
+
+// isWatchOnlyKeyAddress returns true if the address belongs to a watch-only
+// key that has been used. The unused keys are only part of the lookahead.
+func (w *Wallet) isWatchOnlyKeyAddress(uh types.UnlockHash) bool {
+	index, exists := w.watchOnlyIndices[uh]
+	return exists && index < w.watchOnlyProgress
+}
+
+// updateWatchOnlyLookahead uses a consensus change to update the watch-only
+// progress if one of the outputs belongs to a watch-only key in the
+// lookahead. Returns true if a blockchain rescan is required.
+func (w *Wallet) updateWatchOnlyLookahead(tx *bolt.Tx, cc modules.ConsensusChange) (bool, error) {
+	if len(w.watchOnlyKeys) == 0 {
+		return false, nil
+	}
+	progress := w.watchOnlyProgress
+	newProgress := progress
+	checkIndex := func(uh types.UnlockHash) {
+		index, exists := w.watchOnlyIndices[uh]
+		if exists && index >= newProgress && index < maxLookahead(progress) {
+			newProgress = index + 1
+		}
+	}
+	for _, diff := range cc.SiacoinOutputDiffs {
+		checkIndex(diff.SiacoinOutput.UnlockHash)
+	}
+	for _, diff := range cc.SiafundOutputDiffs {
+		checkIndex(diff.SiafundOutput.UnlockHash)
+	}
+	if newProgress == progress {
+		return false, nil
+	}
+	if err := dbPutWatchOnlyProgress(tx, newProgress); err != nil {
+		return false, err
+	}
+	w.watchOnlyProgress = newProgress
+
+	// If more than lookaheadRescanThreshold keys were skipped also
+	// initialize a rescan just to be safe.
+	return newProgress-progress > lookaheadRescanThreshold, nil
+}
+
+// ExportWatchOnlyKeys returns n public keys of the primary seed, starting at
+// seed index start. The primary seed progress is advanced past the exported
+// keys, so that the wallet tracks and can sign for all of them.
+func (w *Wallet) ExportWatchOnlyKeys(start, n uint64) (modules.WatchOnlyKeySet, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WatchOnlyKeySet{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	if n > maxWatchOnlyExportKeys {
+		return modules.WatchOnlyKeySet{}, errTooManyWatchOnlyKeys
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.WatchOnlyKeySet{}, modules.ErrLockedWallet
+	} else if w.watchOnly {
+		return modules.WatchOnlyKeySet{}, errWatchOnlyWallet
+	}
+
+	progress, err := dbGetPrimarySeedProgress(w.dbTx)
+	if err != nil {
+		return modules.WatchOnlyKeySet{}, err
+	}
+	// The keys up to start are generated and tracked as well, so start is
+	// bounded like n. This also keeps start+n from overflowing.
+	if start > progress+maxWatchOnlyExportKeys {
+		return modules.WatchOnlyKeySet{}, errTooManyWatchOnlyKeys
+	}
+	if start+n > progress {
+		if _, err := w.nextPrimarySeedAddresses(w.dbTx, start+n-progress); err != nil {
+			return modules.WatchOnlyKeySet{}, err
+		}
+	}
+	keys := modules.WatchOnlyKeySet{
+		StartIndex: start,
+		PublicKeys: make([]types.SiaPublicKey, 0, n),
+	}
+	for _, sk := range generateKeys(w.primarySeed, start, n) {
+		keys.PublicKeys = append(keys.PublicKeys, sk.UnlockConditions.PublicKeys[0])
+	}
+	return keys, w.syncDB()
+}
+
+// ImportWatchOnlyKeys adds keys exported by a cold wallet to the watch-only
+// keys of the wallet. The keys have to continue or overlap the imported keys,
+// and overlapping keys have to match. If none of the keys have appeared in
+// the blockchain, the unused flag may be set to true. Otherwise, the wallet
+// rescans the blockchain to find the outputs of the keys.
+func (w *Wallet) ImportWatchOnlyKeys(keys modules.WatchOnlyKeySet, unused bool) error {
+	if err := w.tg.Add(); err != nil {
+		return modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	for _, pk := range keys.PublicKeys {
+		if pk.Algorithm != types.SignatureEd25519 || len(pk.Key) != crypto.PublicKeySize {
+			return errInvalidWatchOnlyKey
+		}
+	}
+
+	var rescan bool
+	err := func() error {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if !w.unlocked {
+			return modules.ErrLockedWallet
+		}
+		if keys.StartIndex > uint64(len(w.watchOnlyKeys)) {
+			return errWatchOnlyKeyGap
+		}
+		for i, pk := range keys.PublicKeys {
+			index := keys.StartIndex + uint64(i)
+			if index >= uint64(len(w.watchOnlyKeys)) {
+				break
+			}
+			if w.watchOnlyKeys[index].PublicKeys[0].String() != pk.String() {
+				return errWatchOnlyKeyMismatch
+			}
+		}
+
+		known := uint64(len(w.watchOnlyKeys))
+		for i, pk := range keys.PublicKeys {
+			index := keys.StartIndex + uint64(i)
+			if index < known {
+				continue
+			}
+			if err := dbPutWatchOnlyKey(w.dbTx, index, pk); err != nil {
+				return err
+			}
+		}
+		w.integrateWatchOnlyKeys(keys.StartIndex, keys.PublicKeys)
+		if uint64(len(w.watchOnlyKeys)) == known {
+			return nil
+		}
+		if !unused {
+			// prepare to rescan
+			if err := w.prepareRescan(); err != nil {
+				return err
+			}
+			rescan = true
+		}
+		return w.syncDB()
+	}()
+	if err != nil {
+		return err
+	}
+
+	if rescan {
+		// rescan the blockchain
+		return w.managedRescan()
+	}
+	return nil
+}
+
+// NewWatchOnlyTransaction creates an unsigned transaction that sends the
+// outputs from the watch-only keys, paying the fee and returning the change
+// to the next unused watch-only key. The transaction is returned as a
+// partially signed transaction with key hints for every input, so that the
+// cold wallet can sign it. The spent outputs are not used for other
+// watch-only transactions until RespendTimeout blocks have passed.
+func (w *Wallet) NewWatchOnlyTransaction(outputs []types.SiacoinOutput, fee types.Currency) (types.PartialTransaction, error) {
+	if err := w.tg.Add(); err != nil {
+		return types.PartialTransaction{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+
+	// dustThreshold has to be obtained separate from the lock
+	dustThreshold, err := w.DustThreshold()
+	if err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return types.PartialTransaction{}, modules.ErrLockedWallet
+	}
+	consensusHeight, err := dbGetConsensusHeight(w.dbTx)
+	if err != nil {
+		return types.PartialTransaction{}, err
+	}
+
+	amount := fee
+	for _, sco := range outputs {
+		amount = amount.Add(sco.Value)
+	}
+
+	// Collect a value-sorted set of the outputs of the watch-only keys.
+	var so sortedOutputs
+	err = dbForEachSiacoinOutput(w.dbTx, func(scoid types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if w.isWatchOnlyKeyAddress(sco.UnlockHash) && w.checkOutput(w.dbTx, consensusHeight, scoid, sco, dustThreshold) == nil {
+			so.ids = append(so.ids, scoid)
+			so.outputs = append(so.outputs, sco)
+		}
+	})
+	if err != nil {
+		return types.PartialTransaction{}, err
+	}
+	sort.Sort(sort.Reverse(so))
+
+	var txn types.Transaction
+	var fund types.Currency
+	for i := range so.ids {
+		if fund.Cmp(amount) >= 0 {
+			break
+		}
+		txn.SiacoinInputs = append(txn.SiacoinInputs, types.SiacoinInput{
+			ParentID:         so.ids[i],
+			UnlockConditions: w.watchOnlyKeys[w.watchOnlyIndices[so.outputs[i].UnlockHash]],
+		})
+		fund = fund.Add(so.outputs[i].Value)
+	}
+	if fund.Cmp(amount) < 0 {
+		return types.PartialTransaction{}, modules.ErrLowBalance
+	}
+
+	txn.SiacoinOutputs = append(txn.SiacoinOutputs, outputs...)
+	if !fee.IsZero() {
+		txn.MinerFees = []types.Currency{fee}
+	}
+	if change := fund.Sub(amount); !change.IsZero() {
+		if w.watchOnlyProgress >= uint64(len(w.watchOnlyKeys)) {
+			return types.PartialTransaction{}, errNoWatchOnlyKeys
+		}
+		changeUC := w.watchOnlyKeys[w.watchOnlyProgress]
+		if err := dbPutWatchOnlyProgress(w.dbTx, w.watchOnlyProgress+1); err != nil {
+			return types.PartialTransaction{}, err
+		}
+		w.watchOnlyProgress++
+		txn.SiacoinOutputs = append(txn.SiacoinOutputs, types.SiacoinOutput{
+			Value:      change,
+			UnlockHash: changeUC.UnlockHash(),
+		})
+	}
+	for _, sci := range txn.SiacoinInputs {
+		if err := dbPutSpentOutput(w.dbTx, types.OutputID(sci.ParentID), consensusHeight); err != nil {
+			return types.PartialTransaction{}, err
+		}
+	}
+
+	pt := types.NewPartialTransaction(txn)
+	if err := w.annotatePartialTransaction(&pt); err != nil {
+		return types.PartialTransaction{}, err
+	}
+	for i := range pt.Inputs {
+		pt.Inputs[i].KeyHints = []types.PartialTransactionKeyHint{{
+			PublicKeyIndex: 0,
+			KeyAddress:     types.KeyAddress(pt.Inputs[i].UnlockConditions, 0),
+		}}
+	}
+	if err := w.syncDB(); err != nil {
+		return types.PartialTransaction{}, err
+	}
+	return pt, pt.Validate()
+}
+
+// WatchOnlyStatus reports the state of the watch-only keys of the wallet,
+// together with the confirmed balances of the used keys.
+func (w *Wallet) WatchOnlyStatus() (modules.WatchOnlyStatus, error) {
+	if err := w.tg.Add(); err != nil {
+		return modules.WatchOnlyStatus{}, modules.ErrWalletShutdown
+	}
+	defer w.tg.Done()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.unlocked {
+		return modules.WatchOnlyStatus{}, modules.ErrLockedWallet
+	}
+
+	keys := uint64(len(w.watchOnlyKeys))
+	progress := w.watchOnlyProgress
+	lookaheadEnd := maxLookahead(progress)
+	status := modules.WatchOnlyStatus{
+		WatchOnlyWallet: w.watchOnly,
+		Keys:            keys,
+		Progress:        progress,
+	}
+	if keys > progress {
+		status.Lookahead = keys - progress
+		if keys > lookaheadEnd {
+			status.Lookahead = lookaheadEnd - progress
+		}
+	}
+	if lookaheadEnd > keys {
+		status.KeysNeeded = lookaheadEnd - keys
+	}
+
+	err := dbForEachSiacoinOutput(w.dbTx, func(_ types.SiacoinOutputID, sco types.SiacoinOutput) {
+		if w.isWatchOnlyKeyAddress(sco.UnlockHash) {
+			status.SiacoinBalance = status.SiacoinBalance.Add(sco.Value)
+		}
+	})
+	if err != nil {
+		return modules.WatchOnlyStatus{}, err
+	}
+	err = dbForEachSiafundOutput(w.dbTx, func(_ types.SiafundOutputID, sfo types.SiafundOutput) {
+		if w.isWatchOnlyKeyAddress(sfo.UnlockHash) {
+			status.SiafundBalance = status.SiafundBalance.Add(sfo.Value)
+		}
+	})
+	if err != nil {
+		return modules.WatchOnlyStatus{}, err
+	}
+	return status, nil
+}
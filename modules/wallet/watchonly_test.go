@@ -0,0 +1,291 @@
+package wallet
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"gitlab.com/NebulousLabs/Sia/build"
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
+	"gitlab.com/NebulousLabs/Sia/types"
+	"gitlab.com/NebulousLabs/errors"
+	"gitlab.com/NebulousLabs/fastrand"
+)
+
+// TestWatchOnlyKeys checks that a wallet can track the keys exported by a cold
+// wallet, and build a transaction that the cold wallet signs.
+func TestWatchOnlyKeys(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	// A wallet can only be initialized from a seed once the consensus set
+	// is synced.
+	err = build.Retry(100, 100*time.Millisecond, func() error {
+		if !wt.cs.Synced() {
+			return errors.New("consensus set is not synced")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Create the cold wallet. It shares the consensus set in this test, but
+	// it only has to sign.
+	cold, err := New(wt.cs, wt.tpool, build.TempDir(modules.WalletDir, t.Name()+"cold", modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cold.Close()
+	var seed modules.Seed
+	fastrand.Read(seed[:])
+	if err := cold.InitFromSeed(crypto.TwofishKey{}, seed); err != nil {
+		t.Fatal(err)
+	}
+	if err := cold.Unlock(crypto.TwofishKey(crypto.HashObject(seed))); err != nil {
+		t.Fatal(err)
+	}
+
+	// Export keys from the cold wallet and import them into the watch-only
+	// wallet.
+	keys, err := cold.ExportWatchOnlyKeys(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys.PublicKeys) != 5 {
+		t.Fatal("wrong number of exported keys:", len(keys.PublicKeys))
+	}
+	if _, err := cold.ExportWatchOnlyKeys(0, maxWatchOnlyExportKeys+1); err != errTooManyWatchOnlyKeys {
+		t.Fatal("expected errTooManyWatchOnlyKeys, got", err)
+	}
+	if _, err := cold.ExportWatchOnlyKeys(math.MaxUint64, 5); err != errTooManyWatchOnlyKeys {
+		t.Fatal("expected errTooManyWatchOnlyKeys, got", err)
+	}
+	if err := wt.wallet.ImportWatchOnlyKeys(keys, true); err != nil {
+		t.Fatal(err)
+	}
+	shifted := modules.WatchOnlyKeySet{StartIndex: 1, PublicKeys: keys.PublicKeys}
+	if err := wt.wallet.ImportWatchOnlyKeys(shifted, true); err != errWatchOnlyKeyMismatch {
+		t.Fatal("expected errWatchOnlyKeyMismatch, got", err)
+	}
+	gap := modules.WatchOnlyKeySet{StartIndex: 6, PublicKeys: keys.PublicKeys}
+	if err := wt.wallet.ImportWatchOnlyKeys(gap, true); err != errWatchOnlyKeyGap {
+		t.Fatal("expected errWatchOnlyKeyGap, got", err)
+	}
+	status, err := wt.wallet.WatchOnlyStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Keys != 5 || status.Progress != 0 || status.Lookahead != 5 || status.KeysNeeded != maxLookahead(0)-5 {
+		t.Fatal("wrong status after import:", status)
+	}
+
+	// Fund a key in the lookahead. The watch-only wallet notices the output
+	// and advances its progress, but the output is not part of its regular
+	// balance.
+	funding := types.SiacoinPrecision.Mul64(100)
+	addr := watchOnlyUnlockConditions(keys.PublicKeys[2]).UnlockHash()
+	if _, err := wt.wallet.SendSiacoins(funding, addr); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	status, err = wt.wallet.WatchOnlyStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Progress != 3 || !status.SiacoinBalance.Equals(funding) {
+		t.Fatal("watch-only wallet did not track the funding:", status)
+	}
+	outputs, err := wt.wallet.UnspentOutputs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, o := range outputs {
+		if o.UnlockHash == addr && !o.IsWatchOnly {
+			t.Fatal("output of a watch-only key should be marked as watch-only")
+		}
+	}
+
+	// Build the transaction and sign it with the cold wallet.
+	dest := types.UnlockHash{1}
+	value := types.SiacoinPrecision.Mul64(10)
+	fee := types.SiacoinPrecision
+	pt, err := wt.wallet.NewWatchOnlyTransaction([]types.SiacoinOutput{{Value: value, UnlockHash: dest}}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pt.SignaturesNeeded() != 1 || len(pt.Inputs[0].KeyHints) != 1 || !pt.SiacoinInputSum().Equals(funding) {
+		t.Fatal("transaction should need the signature of the cold wallet:", pt)
+	}
+	change := pt.Transaction.SiacoinOutputs[1]
+	if change.UnlockHash != watchOnlyUnlockConditions(keys.PublicKeys[3]).UnlockHash() {
+		t.Fatal("change should go to the next watch-only key")
+	}
+	signed, err := cold.SignPartialTransaction(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := wt.wallet.FinalizePartialTransaction(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+
+	// The watch-only keys survive a restart of the wallet.
+	if err := wt.wallet.Lock(); err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.wallet.Unlock(wt.walletMasterKey); err != nil {
+		t.Fatal(err)
+	}
+	status, err = wt.wallet.WatchOnlyStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Keys != 5 || status.Progress != 4 || !status.SiacoinBalance.Equals(funding.Sub(value).Sub(fee)) {
+		t.Fatal("wrong status after spending from the watch-only keys:", status)
+	}
+
+	// The outputs spent by a watch-only transaction are not spent again,
+	// even though the transaction was never broadcast.
+	if _, err := wt.wallet.NewWatchOnlyTransaction([]types.SiacoinOutput{{Value: value, UnlockHash: dest}}, fee); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.wallet.NewWatchOnlyTransaction([]types.SiacoinOutput{{Value: value, UnlockHash: dest}}, fee); err != modules.ErrLowBalance {
+		t.Fatal("expected ErrLowBalance, got", err)
+	}
+}
+
+// TestWatchOnlyWallet checks that a wallet initialized by InitWatchOnly tracks
+// and spends from the keys of a cold wallet without a seed of its own.
+func TestWatchOnlyWallet(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+	wt, err := createWalletTester(t.Name(), modules.ProdDependencies)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer wt.closeWt()
+
+	cold, err := New(wt.cs, wt.tpool, build.TempDir(modules.WalletDir, t.Name()+"cold", modules.WalletDir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cold.Close()
+	coldKey := crypto.GenerateTwofishKey()
+	if _, err := cold.Encrypt(coldKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := cold.Unlock(coldKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// Initialize the watch-only wallet. It needs a password, and it can't
+	// generate addresses or reveal a seed.
+	watchDir := build.TempDir(modules.WalletDir, t.Name()+"watch", modules.WalletDir)
+	watch, err := New(wt.cs, wt.tpool, watchDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watch.InitWatchOnly(crypto.TwofishKey{}); err != errWatchOnlyBlankKey {
+		t.Fatal("expected errWatchOnlyBlankKey, got", err)
+	}
+	masterKey := crypto.GenerateTwofishKey()
+	if err := watch.InitWatchOnly(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watch.Encrypt(masterKey); err != errReencrypt {
+		t.Fatal("expected errReencrypt, got", err)
+	}
+	if err := watch.Unlock(masterKey); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := watch.NextAddress(); !errors.Contains(err, errWatchOnlyWallet) {
+		t.Fatal("expected errWatchOnlyWallet, got", err)
+	}
+	if _, _, err := watch.PrimarySeed(); err != errWatchOnlyWallet {
+		t.Fatal("expected errWatchOnlyWallet, got", err)
+	}
+	if _, err := watch.ExportWatchOnlyKeys(0, 1); err != errWatchOnlyWallet {
+		t.Fatal("expected errWatchOnlyWallet, got", err)
+	}
+
+	// Track the keys of the cold wallet and fund one of them.
+	keys, err := cold.ExportWatchOnlyKeys(0, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := watch.ImportWatchOnlyKeys(keys, true); err != nil {
+		t.Fatal(err)
+	}
+	funding := types.SiacoinPrecision.Mul64(100)
+	if _, err := wt.wallet.SendSiacoins(funding, watchOnlyUnlockConditions(keys.PublicKeys[0]).UnlockHash()); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+	status, err := watch.WatchOnlyStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.WatchOnlyWallet || status.Progress != 1 || !status.SiacoinBalance.Equals(funding) {
+		t.Fatal("watch-only wallet did not track the funding:", status)
+	}
+
+	// Spend from the key with a signature of the cold wallet.
+	value := types.SiacoinPrecision.Mul64(10)
+	fee := types.SiacoinPrecision
+	pt, err := watch.NewWatchOnlyTransaction([]types.SiacoinOutput{{Value: value, UnlockHash: types.UnlockHash{1}}}, fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed, err := cold.SignPartialTransaction(pt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txnSet, err := watch.FinalizePartialTransaction(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := wt.tpool.AcceptTransactionSet(txnSet); err != nil {
+		t.Fatal(err)
+	}
+	wt.addBlockNoPayout()
+
+	// The wallet stays a watch-only wallet after a restart and a change of
+	// the password.
+	if err := watch.Close(); err != nil {
+		t.Fatal(err)
+	}
+	watch, err = New(wt.cs, wt.tpool, watchDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer watch.Close()
+	newKey := crypto.GenerateTwofishKey()
+	if err := watch.ChangeKey(masterKey, newKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := watch.Unlock(newKey); err != nil {
+		t.Fatal(err)
+	}
+	status, err = watch.WatchOnlyStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !status.WatchOnlyWallet || status.Progress != 2 || !status.SiacoinBalance.Equals(funding.Sub(value).Sub(fee)) {
+		t.Fatal("wrong status after restart:", status)
+	}
+	if _, err := watch.NextAddress(); !errors.Contains(err, errWatchOnlyWallet) {
+		t.Fatal("expected errWatchOnlyWallet after restart, got", err)
+	}
+}
@@ -0,0 +1,54 @@
+package modules
+
+import (
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/types"
+)
+
+// TestTransactionCategories checks that the categories of wallet transactions
+// are derived from their content.
+func TestTransactionCategories(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pt       ProcessedTransaction
+		expected []TransactionCategory
+	}{
+		{ProcessedTransaction{}, nil},
+		{
+			ProcessedTransaction{Transaction: types.Transaction{FileContracts: []types.FileContract{{}}}},
+			[]TransactionCategory{TransactionCategoryContractFormation},
+		},
+		{
+			ProcessedTransaction{Transaction: types.Transaction{StorageProofs: []types.StorageProof{{}}}},
+			[]TransactionCategory{TransactionCategoryStorageProof},
+		},
+		{
+			ProcessedTransaction{Outputs: []ProcessedOutput{{FundType: types.SpecifierStorageProofOutput}}},
+			[]TransactionCategory{TransactionCategoryStorageProof},
+		},
+		{
+			ProcessedTransaction{Outputs: []ProcessedOutput{{FundType: types.SpecifierMinerPayout}, {FundType: types.SpecifierMinerPayout}}},
+			[]TransactionCategory{TransactionCategoryMinerPayout},
+		},
+		{
+			ProcessedTransaction{
+				BumpsTransactionID: types.TransactionID{1},
+				Outputs:            []ProcessedOutput{{FundType: types.SpecifierClaimOutput}},
+			},
+			[]TransactionCategory{TransactionCategoryFeeBump, TransactionCategorySiafundClaim},
+		},
+	}
+	for i, test := range tests {
+		categories := TransactionCategories(test.pt)
+		if len(categories) != len(test.expected) {
+			t.Fatalf("test %v: expected %v, got %v", i, test.expected, categories)
+		}
+		for j := range categories {
+			if categories[j] != test.expected[j] {
+				t.Fatalf("test %v: expected %v, got %v", i, test.expected, categories)
+			}
+		}
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"fmt"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/node/api"
+	"gitlab.com/NebulousLabs/Sia/types"
 )
 
 // HostParam is a parameter in the host's settings that can be changed via the
@@ -36,6 +39,11 @@ const (
 	// HostParamAcceptingContracts indicates if the host is accepting new
 	// contracts.
 	HostParamAcceptingContracts = HostParam("acceptingcontracts")
+	// HostParamMaintenanceMode indicates if the host is in maintenance mode.
+	HostParamMaintenanceMode = HostParam("maintenancemode")
+	// HostParamMaintenanceEnd is the unix timestamp at which the host expects
+	// to leave maintenance mode.
+	HostParamMaintenanceEnd = HostParam("maintenanceend")
 	// HostParamMaxDuration is the max duration of a contract in blocks.
 	HostParamMaxDuration = HostParam("maxduration")
 	// HostParamWindowSize is the size of the proof window in blocks.
@@ -47,6 +55,42 @@ const (
 	HostParamMaxReviseBatchSize = HostParam("maxrevisebatchsize")
 	// HostParamNetAddress is the announced netaddress of the host.
 	HostParamNetAddress = HostParam("netaddress")
+	// HostParamMaxContractBandwidth is the maximum number of bytes that can be
+	// transferred using a single contract per bandwidth quota period.
+	HostParamMaxContractBandwidth = HostParam("maxcontractbandwidth")
+	// HostParamMaxDownloadSpeed is the maximum number of bytes per second
+	// that the host receives.
+	HostParamMaxDownloadSpeed = HostParam("maxdownloadspeed")
+	// HostParamMaxUploadSpeed is the maximum number of bytes per second that
+	// the host sends.
+	HostParamMaxUploadSpeed = HostParam("maxuploadspeed")
+	// HostParamAutoPricing indicates if the host sets its prices
+	// automatically.
+	HostParamAutoPricing = HostParam("autopricing")
+	// HostParamContractPriceCeiling is the highest contract price that is set
+	// by automatic pricing in hastings.
+	HostParamContractPriceCeiling = HostParam("contractpriceceiling")
+	// HostParamContractPriceFloor is the lowest contract price that is set by
+	// automatic pricing in hastings.
+	HostParamContractPriceFloor = HostParam("contractpricefloor")
+	// HostParamDownloadBandwidthPriceCeiling is the highest download
+	// bandwidth price that is set by automatic pricing in hastings/byte.
+	HostParamDownloadBandwidthPriceCeiling = HostParam("downloadbandwidthpriceceiling")
+	// HostParamDownloadBandwidthPriceFloor is the lowest download bandwidth
+	// price that is set by automatic pricing in hastings/byte.
+	HostParamDownloadBandwidthPriceFloor = HostParam("downloadbandwidthpricefloor")
+	// HostParamStoragePriceCeiling is the highest storage price that is set
+	// by automatic pricing in hastings/byte/block.
+	HostParamStoragePriceCeiling = HostParam("storagepriceceiling")
+	// HostParamStoragePriceFloor is the lowest storage price that is set by
+	// automatic pricing in hastings/byte/block.
+	HostParamStoragePriceFloor = HostParam("storagepricefloor")
+	// HostParamUploadBandwidthPriceCeiling is the highest upload bandwidth
+	// price that is set by automatic pricing in hastings/byte.
+	HostParamUploadBandwidthPriceCeiling = HostParam("uploadbandwidthpriceceiling")
+	// HostParamUploadBandwidthPriceFloor is the lowest upload bandwidth price
+	// that is set by automatic pricing in hastings/byte.
+	HostParamUploadBandwidthPriceFloor = HostParam("uploadbandwidthpricefloor")
 )
 
 // HostAnnouncePost uses the /host/announce endpoint to announce the host to
@@ -63,6 +107,21 @@ func (c *Client) HostAnnounceAddrPost(address modules.NetAddress) (err error) {
 	return
 }
 
+// HostAnnounceAddressesPost uses the /host/announce endpoint to announce the
+// host to the network using the provided addresses in priority order, which
+// expire at the provided height.
+func (c *Client) HostAnnounceAddressesPost(addrs []modules.NetAddress, expiry types.BlockHeight) (err error) {
+	strs := make([]string, 0, len(addrs))
+	for _, addr := range addrs {
+		strs = append(strs, string(addr))
+	}
+	values := url.Values{}
+	values.Set("netaddresses", strings.Join(strs, ","))
+	values.Set("expiry", fmt.Sprint(expiry))
+	err = c.post("/host/announce", values.Encode(), nil)
+	return
+}
+
 // HostContractInfoGet uses the /host/contracts endpoint to get information
 // about contracts on the host.
 func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
@@ -70,6 +129,24 @@ func (c *Client) HostContractInfoGet() (cg api.ContractInfoGET, err error) {
 	return
 }
 
+// HostContractHistoryGet uses the /host/contracts/history endpoint to get the
+// outcomes of the contracts that the host resolved between start and end. Zero
+// times and an empty status don't filter the history.
+func (c *Client) HostContractHistoryGet(start, end time.Time, status string) (chg api.ContractHistoryGET, err error) {
+	values := url.Values{}
+	if !start.IsZero() {
+		values.Set("start", strconv.FormatInt(start.Unix(), 10))
+	}
+	if !end.IsZero() {
+		values.Set("end", strconv.FormatInt(end.Unix(), 10))
+	}
+	if status != "" {
+		values.Set("status", status)
+	}
+	err = c.get("/host/contracts/history?"+values.Encode(), &chg)
+	return
+}
+
 // HostEstimateScoreGet requests the /host/estimatescore endpoint.
 func (c *Client) HostEstimateScoreGet(param, value string) (eg api.HostEstimateScoreGET, err error) {
 	err = c.get(fmt.Sprintf("/host/estimatescore?%v=%v", param, value), &eg)
@@ -89,6 +166,40 @@ func (c *Client) HostModifySettingPost(param HostParam, value interface{}) (err
 	return
 }
 
+// HostMaintenancePost uses the /host endpoint to enable or disable maintenance
+// mode. A zero end means that the host doesn't announce a maintenance window.
+func (c *Client) HostMaintenancePost(enabled bool, end types.Timestamp) (err error) {
+	values := url.Values{}
+	values.Set(string(HostParamMaintenanceMode), strconv.FormatBool(enabled))
+	values.Set(string(HostParamMaintenanceEnd), fmt.Sprint(end))
+	err = c.post("/host", values.Encode(), nil)
+	return
+}
+
+// HostPricingGet requests the /host/pricing endpoint.
+func (c *Client) HostPricingGet() (hpg api.HostPricingGET, err error) {
+	err = c.get("/host/pricing", &hpg)
+	return
+}
+
+// HostRentersGet requests the /host/renters endpoint.
+func (c *Client) HostRentersGet() (hrg api.HostRentersGET, err error) {
+	err = c.get("/host/renters", &hrg)
+	return
+}
+
+// HostRentersPolicyPost uses the /host/renters/policy endpoint to set the
+// policy of a renter.
+func (c *Client) HostRentersPolicyPost(renter types.SiaPublicKey, policy modules.RenterPolicy) (err error) {
+	values := url.Values{}
+	values.Set("publickey", renter.String())
+	values.Set("deny", strconv.FormatBool(policy.Deny))
+	values.Set("maxstorage", strconv.FormatUint(policy.MaxStorage, 10))
+	values.Set("pricemultiplier", strconv.FormatFloat(policy.PriceMultiplier, 'f', -1, 64))
+	err = c.post("/host/renters/policy", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersAddPost uses the /host/storage/folders/add api endpoint to
 // add a storage folder to a host
 func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error) {
@@ -99,6 +210,17 @@ func (c *Client) HostStorageFoldersAddPost(path string, size uint64) (err error)
 	return
 }
 
+// HostStorageFoldersMigratePost uses the /host/storage/folders/migrate api
+// endpoint to move the sectors of a storage folder into another storage
+// folder.
+func (c *Client) HostStorageFoldersMigratePost(source, destination string) (err error) {
+	values := url.Values{}
+	values.Set("source", source)
+	values.Set("destination", destination)
+	err = c.post("/host/storage/folders/migrate", values.Encode(), nil)
+	return
+}
+
 // HostStorageFoldersRemovePost uses the /host/storage/folders/remove api
 // endpoint to remove a storage folder from a host.
 func (c *Client) HostStorageFoldersRemovePost(path string) (err error) {
@@ -124,6 +246,38 @@ func (c *Client) HostStorageGet() (sg api.StorageGET, err error) {
 	return
 }
 
+// HostStorageAuditGet requests the /host/storage/audit endpoint.
+func (c *Client) HostStorageAuditGet() (sag api.StorageAuditGET, err error) {
+	err = c.get("/host/storage/audit", &sag)
+	return
+}
+
+// HostStorageAuditPost uses the /host/storage/audit endpoint to correct the
+// reference counts of the sectors that don't match the storage obligations.
+func (c *Client) HostStorageAuditPost() (sag api.StorageAuditGET, err error) {
+	err = c.post("/host/storage/audit", "", &sag)
+	return
+}
+
+// HostStorageProofsGet requests the /host/storage/proofs endpoint.
+func (c *Client) HostStorageProofsGet() (spg api.StorageProofsGET, err error) {
+	err = c.get("/host/storage/proofs", &spg)
+	return
+}
+
+// HostStorageProofsPost uses the /host/storage/proofs endpoint to simulate
+// the storage proofs of the host.
+func (c *Client) HostStorageProofsPost() (spg api.StorageProofsGET, err error) {
+	err = c.post("/host/storage/proofs", "", &spg)
+	return
+}
+
+// HostStorageScrubGet requests the /host/storage/scrub endpoint.
+func (c *Client) HostStorageScrubGet() (ssg api.StorageScrubGET, err error) {
+	err = c.get("/host/storage/scrub", &ssg)
+	return
+}
+
 // HostStorageSectorsDeletePost uses the /host/storage/sectors/delete endpoint
 // to delete a sector from the host.
 func (c *Client) HostStorageSectorsDeletePost(root crypto.Hash) (err error) {
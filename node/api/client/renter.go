@@ -64,6 +64,26 @@ func (c *Client) RenterExpiredContractsGet() (rc api.RenterContracts, err error)
 	return
 }
 
+// RenterContractsRecoverGet uses the /renter/contracts/recover endpoint to get
+// the progress of the most recent contract recovery.
+func (c *Client) RenterContractsRecoverGet() (rcr api.RenterRecoverContractsGET, err error) {
+	err = c.get("/renter/contracts/recover", &rcr)
+	return
+}
+
+// RenterContractsRecoverPost uses the /renter/contracts/recover endpoint to
+// start recovering the contracts that were formed using the wallet seed.
+func (c *Client) RenterContractsRecoverPost() (err error) {
+	err = c.post("/renter/contracts/recover", "", nil)
+	return
+}
+
+// RenterContractsReportGet requests the /renter/contracts/report resource
+func (c *Client) RenterContractsReportGet() (rcr api.RenterContractsReport, err error) {
+	err = c.get("/renter/contracts/report", &rcr)
+	return
+}
+
 // RenterDeletePost uses the /renter/delete endpoint to delete a file.
 func (c *Client) RenterDeletePost(siaPath string) (err error) {
 	siaPath = escapeSiaPath(trimSiaPath(siaPath))
@@ -175,6 +195,18 @@ func (c *Client) RenterPostAllowance(allowance modules.Allowance) (err error) {
 	values.Set("hosts", fmt.Sprint(allowance.Hosts))
 	values.Set("period", fmt.Sprint(uint64(allowance.Period)))
 	values.Set("renewwindow", fmt.Sprint(uint64(allowance.RenewWindow)))
+	if allowance.ExpectedStorage != 0 {
+		values.Set("expectedstorage", fmt.Sprint(allowance.ExpectedStorage))
+	}
+	if allowance.ExpectedUpload != 0 {
+		values.Set("expectedupload", fmt.Sprint(allowance.ExpectedUpload))
+	}
+	if allowance.ExpectedDownload != 0 {
+		values.Set("expecteddownload", fmt.Sprint(allowance.ExpectedDownload))
+	}
+	if allowance.ExpectedRedundancy != 0 {
+		values.Set("expectedredundancy", fmt.Sprint(allowance.ExpectedRedundancy))
+	}
 	err = c.post("/renter", values.Encode(), nil)
 	return
 }
@@ -230,6 +262,15 @@ func (c *Client) RenterSetCheckIPViolationPost(enabled bool) (err error) {
 	return
 }
 
+// RenterSetMisbehaviorBlacklistPeriodPost uses the /renter endpoint to set the
+// number of blocks a host is blacklisted after being caught misbehaving.
+func (c *Client) RenterSetMisbehaviorBlacklistPeriodPost(period types.BlockHeight) (err error) {
+	values := url.Values{}
+	values.Set("misbehaviorblacklistperiod", fmt.Sprint(period))
+	err = c.post("/renter", values.Encode(), nil)
+	return
+}
+
 // RenterStreamGet uses the /renter/stream endpoint to download data as a
 // stream.
 func (c *Client) RenterStreamGet(siaPath string) (resp []byte, err error) {
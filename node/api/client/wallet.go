@@ -7,6 +7,7 @@ import (
 	"strconv"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/modules"
 	"gitlab.com/NebulousLabs/Sia/node/api"
 	"gitlab.com/NebulousLabs/Sia/types"
 )
@@ -44,6 +45,16 @@ func (c *Client) WalletInitPost(password string, force bool) (wip api.WalletInit
 	return
 }
 
+// WalletInitWatchOnlyPost uses the /wallet/init/watchonly endpoint to
+// initialize a watch-only wallet, which has no seed of its own.
+func (c *Client) WalletInitWatchOnlyPost(password string, force bool) (err error) {
+	values := url.Values{}
+	values.Set("encryptionpassword", password)
+	values.Set("force", strconv.FormatBool(force))
+	err = c.post("/wallet/init/watchonly", values.Encode(), nil)
+	return
+}
+
 // WalletInitSeedPost uses the /wallet/init/seed endpoint to initialize and
 // encrypt a wallet using a given seed.
 func (c *Client) WalletInitSeedPost(seed, password string, force bool) (err error) {
@@ -61,12 +72,355 @@ func (c *Client) WalletGet() (wg api.WalletGET, err error) {
 	return
 }
 
+// WalletExportGet requests the /wallet/export endpoint and returns the records
+// of the confirmed transactions in the height and time range. A zero endTime
+// doesn't limit the time range.
+func (c *Client) WalletExportGet(startHeight, endHeight types.BlockHeight, startTime, endTime types.Timestamp) (weg api.WalletExportGET, err error) {
+	err = c.get("/wallet/export?"+walletExportValues(startHeight, endHeight, startTime, endTime, "json").Encode(), &weg)
+	return
+}
+
+// WalletExportCSVGet requests the /wallet/export endpoint and returns the
+// records of the confirmed transactions in the height and time range as CSV.
+func (c *Client) WalletExportCSVGet(startHeight, endHeight types.BlockHeight, startTime, endTime types.Timestamp) ([]byte, error) {
+	return c.getRawResponse("/wallet/export?" + walletExportValues(startHeight, endHeight, startTime, endTime, "csv").Encode())
+}
+
+// walletExportValues encodes the parameters of a call to /wallet/export.
+func walletExportValues(startHeight, endHeight types.BlockHeight, startTime, endTime types.Timestamp, format string) url.Values {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(startHeight))
+	values.Set("endheight", fmt.Sprint(endHeight))
+	values.Set("starttime", fmt.Sprint(startTime))
+	if endTime != 0 {
+		values.Set("endtime", fmt.Sprint(endTime))
+	}
+	values.Set("format", format)
+	return values
+}
+
+// WalletLabelPost uses the /wallet/label endpoint to set the label of an
+// address. An empty label removes the label.
+func (c *Client) WalletLabelPost(addr types.UnlockHash, label string) (err error) {
+	values := url.Values{}
+	values.Set("address", addr.String())
+	values.Set("label", label)
+	err = c.post("/wallet/label", values.Encode(), nil)
+	return
+}
+
+// WalletLabelsGet requests the /wallet/labels endpoint and returns the labels
+// of the labeled addresses.
+func (c *Client) WalletLabelsGet() (wlg api.WalletLabelsGET, err error) {
+	err = c.get("/wallet/labels", &wlg)
+	return
+}
+
 // WalletLockPost uses the /wallet/lock endpoint to lock the wallet.
 func (c *Client) WalletLockPost() (err error) {
 	err = c.post("/wallet/lock", "", nil)
 	return
 }
 
+// WalletMultisigAddressesGet requests the /wallet/multisig/addresses endpoint
+// and returns the multisig addresses that the wallet tracks.
+func (c *Client) WalletMultisigAddressesGet() (wmag api.WalletMultisigAddressesGET, err error) {
+	err = c.get("/wallet/multisig/addresses", &wmag)
+	return
+}
+
+// WalletMultisigAddressesPost uses the /wallet/multisig/addresses endpoint to
+// create a multisig address that requires the given number of signatures
+// from the public keys.
+func (c *Client) WalletMultisigAddressesPost(required uint64, publicKeys []types.SiaPublicKey, unused bool) (ma modules.MultisigAddress, err error) {
+	json, err := json.Marshal(api.WalletMultisigAddressesPOST{
+		Required:   required,
+		PublicKeys: publicKeys,
+		Unused:     unused,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/addresses", string(json), &ma)
+	return
+}
+
+// WalletMultisigKeyGet requests the /wallet/multisig/key endpoint and returns
+// a public key of the wallet that can be shared with co-signers.
+func (c *Client) WalletMultisigKeyGet() (wmkg api.WalletMultisigKeyGET, err error) {
+	err = c.get("/wallet/multisig/key", &wmkg)
+	return
+}
+
+// WalletMultisigMergePost uses the /wallet/multisig/merge endpoint to combine
+// the signatures of copies of a transaction.
+func (c *Client) WalletMultisigMergePost(txns []types.Transaction) (mt modules.MultisigTransaction, err error) {
+	json, err := json.Marshal(api.WalletMultisigMergePOST{
+		Transactions: txns,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/merge", string(json), &mt)
+	return
+}
+
+// WalletMultisigSignPost uses the /wallet/multisig/sign endpoint to add the
+// signatures of the wallet to a transaction that spends from multisig
+// addresses.
+func (c *Client) WalletMultisigSignPost(txn types.Transaction) (mt modules.MultisigTransaction, err error) {
+	json, err := json.Marshal(api.WalletMultisigSignPOST{
+		Transaction: txn,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/sign", string(json), &mt)
+	return
+}
+
+// WalletMultisigTransactionPost uses the /wallet/multisig/transaction
+// endpoint to create and sign a transaction that spends from a multisig
+// address.
+func (c *Client) WalletMultisigTransactionPost(addr types.UnlockHash, outputs []types.SiacoinOutput, fee types.Currency) (mt modules.MultisigTransaction, err error) {
+	json, err := json.Marshal(api.WalletMultisigTransactionPOST{
+		Address: addr,
+		Outputs: outputs,
+		Fee:     fee,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/multisig/transaction", string(json), &mt)
+	return
+}
+
+// WalletPaymentRequestGet requests the /wallet/paymentrequest/:addr endpoint
+// and returns the payment request of the address.
+func (c *Client) WalletPaymentRequestGet(addr types.UnlockHash) (pr modules.PaymentRequest, err error) {
+	err = c.get("/wallet/paymentrequest/"+addr.String(), &pr)
+	return
+}
+
+// WalletPaymentRequestsGet requests the /wallet/paymentrequests endpoint and
+// returns the payment requests of the wallet.
+func (c *Client) WalletPaymentRequestsGet() (wprg api.WalletPaymentRequestsGET, err error) {
+	err = c.get("/wallet/paymentrequests", &wprg)
+	return
+}
+
+// WalletPaymentRequestsPost uses the /wallet/paymentrequests endpoint to
+// create a payment request for amount that expires after expiry blocks and
+// needs the given number of confirmations. memo and webhook are optional.
+func (c *Client) WalletPaymentRequestsPost(amount types.Currency, expiry types.BlockHeight, confirmations uint64, memo, webhook string) (pr modules.PaymentRequest, err error) {
+	values := url.Values{}
+	values.Set("amount", amount.String())
+	values.Set("expiry", fmt.Sprint(expiry))
+	values.Set("confirmations", strconv.FormatUint(confirmations, 10))
+	values.Set("memo", memo)
+	values.Set("webhook", webhook)
+	err = c.post("/wallet/paymentrequests", values.Encode(), &pr)
+	return
+}
+
+// WalletPaymentRequestEventsGet requests the /wallet/paymentrequests/events
+// endpoint and returns the status changes of the payment requests with a
+// sequence number greater than since.
+func (c *Client) WalletPaymentRequestEventsGet(since uint64) (wpreg api.WalletPaymentRequestEventsGET, err error) {
+	err = c.get("/wallet/paymentrequests/events?since="+strconv.FormatUint(since, 10), &wpreg)
+	return
+}
+
+// WalletScheduledPaymentApprovePost uses the
+// /wallet/scheduledpayment/:id/approve endpoint to make a held scheduled
+// payment.
+func (c *Client) WalletScheduledPaymentApprovePost(id uint64) (execution modules.ScheduledPaymentExecution, err error) {
+	err = c.post(fmt.Sprintf("/wallet/scheduledpayment/%v/approve", id), "", &execution)
+	return
+}
+
+// WalletScheduledPaymentCancelPost uses the
+// /wallet/scheduledpayment/:id/cancel endpoint to cancel a scheduled payment.
+func (c *Client) WalletScheduledPaymentCancelPost(id uint64) (err error) {
+	err = c.post(fmt.Sprintf("/wallet/scheduledpayment/%v/cancel", id), "", nil)
+	return
+}
+
+// WalletScheduledPaymentsGet requests the /wallet/scheduledpayments endpoint
+// and returns the scheduled payments of the wallet.
+func (c *Client) WalletScheduledPaymentsGet() (wspg api.WalletScheduledPaymentsGET, err error) {
+	err = c.get("/wallet/scheduledpayments", &wspg)
+	return
+}
+
+// WalletScheduledPaymentsPost uses the /wallet/scheduledpayments endpoint to
+// schedule a payment of the outputs.
+func (c *Client) WalletScheduledPaymentsPost(outputs []types.SiacoinOutput, schedule modules.PaymentSchedule, memo string) (sp modules.ScheduledPayment, err error) {
+	json, err := json.Marshal(api.WalletScheduledPaymentsPOST{
+		Outputs:  outputs,
+		Schedule: schedule,
+		Memo:     memo,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/scheduledpayments", string(json), &sp)
+	return
+}
+
+// WalletScheduledPaymentExecutionsGet requests the
+// /wallet/scheduledpayments/executions endpoint and returns the attempts to
+// make scheduled payments with a sequence number greater than since.
+func (c *Client) WalletScheduledPaymentExecutionsGet(since uint64) (wspeg api.WalletScheduledPaymentExecutionsGET, err error) {
+	err = c.get("/wallet/scheduledpayments/executions?since="+strconv.FormatUint(since, 10), &wspeg)
+	return
+}
+
+// WalletScheduledPaymentLimitsGet requests the
+// /wallet/scheduledpayments/limits endpoint and returns the limits of the
+// scheduled payments.
+func (c *Client) WalletScheduledPaymentLimitsGet() (limits modules.ScheduledPaymentLimits, err error) {
+	err = c.get("/wallet/scheduledpayments/limits", &limits)
+	return
+}
+
+// WalletScheduledPaymentLimitsPost uses the /wallet/scheduledpayments/limits
+// endpoint to set the limits of the scheduled payments.
+func (c *Client) WalletScheduledPaymentLimitsPost(limits modules.ScheduledPaymentLimits) (err error) {
+	values := url.Values{}
+	values.Set("approvalthreshold", limits.ApprovalThreshold.String())
+	values.Set("dailylimit", limits.DailyLimit.String())
+	err = c.post("/wallet/scheduledpayments/limits", values.Encode(), nil)
+	return
+}
+
+// WalletAtomicSwapGet requests the /wallet/atomicswap/:id endpoint and
+// returns the atomic swap.
+func (c *Client) WalletAtomicSwapGet(id crypto.Hash) (swap modules.AtomicSwap, err error) {
+	err = c.get("/wallet/atomicswap/"+id.String(), &swap)
+	return
+}
+
+// WalletAtomicSwapClaimPost uses the /wallet/atomicswap/:id/claim endpoint to
+// claim a funded atomic swap with the adaptor signature of the sender.
+func (c *Client) WalletAtomicSwapClaimPost(id crypto.Hash, asig crypto.AdaptorSignature) (wascp api.WalletAtomicSwapClaimPOST, err error) {
+	values := url.Values{}
+	values.Set("adaptorsignature", asig.String())
+	err = c.post(fmt.Sprintf("/wallet/atomicswap/%v/claim", id), values.Encode(), &wascp)
+	return
+}
+
+// WalletAtomicSwapFundPost uses the /wallet/atomicswap/:id/fund endpoint to
+// fund an offered atomic swap with the refund signature of the receiver,
+// which is hex-encoded.
+func (c *Client) WalletAtomicSwapFundPost(id crypto.Hash, refundSignature string) (err error) {
+	values := url.Values{}
+	values.Set("refundsignature", refundSignature)
+	err = c.post(fmt.Sprintf("/wallet/atomicswap/%v/fund", id), values.Encode(), nil)
+	return
+}
+
+// WalletAtomicSwapPresignPost uses the /wallet/atomicswap/:id/presign
+// endpoint to get the adaptor signature of the claim of a funded atomic swap.
+func (c *Client) WalletAtomicSwapPresignPost(id crypto.Hash) (waspp api.WalletAtomicSwapPresignPOST, err error) {
+	err = c.post(fmt.Sprintf("/wallet/atomicswap/%v/presign", id), "", &waspp)
+	return
+}
+
+// WalletAtomicSwapsGet requests the /wallet/atomicswaps endpoint and returns
+// the atomic swaps of the wallet.
+func (c *Client) WalletAtomicSwapsGet() (wasg api.WalletAtomicSwapsGET, err error) {
+	err = c.get("/wallet/atomicswaps", &wasg)
+	return
+}
+
+// WalletAtomicSwapsAcceptPost uses the /wallet/atomicswaps/accept endpoint to
+// accept the offer of a sender.
+func (c *Client) WalletAtomicSwapsAcceptPost(offer modules.AtomicSwapOffer) (wasap api.WalletAtomicSwapAcceptPOST, err error) {
+	json, err := json.Marshal(offer)
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/atomicswaps/accept", string(json), &wasap)
+	return
+}
+
+// WalletAtomicSwapsOfferPost uses the /wallet/atomicswaps/offer endpoint to
+// offer to send amount to the receiver of an atomic swap request.
+func (c *Client) WalletAtomicSwapsOfferPost(req modules.AtomicSwapRequest, amount types.Currency, refundHeight types.BlockHeight) (offer modules.AtomicSwapOffer, err error) {
+	json, err := json.Marshal(api.WalletAtomicSwapOfferPOST{
+		Request:      req,
+		Amount:       amount,
+		RefundHeight: refundHeight,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/atomicswaps/offer", string(json), &offer)
+	return
+}
+
+// WalletAtomicSwapsRequestPost uses the /wallet/atomicswaps/request endpoint
+// to start an atomic swap in which the wallet receives siacoins.
+func (c *Client) WalletAtomicSwapsRequestPost() (req modules.AtomicSwapRequest, err error) {
+	err = c.post("/wallet/atomicswaps/request", "", &req)
+	return
+}
+
+// WalletPSTxPost uses the /wallet/pstx endpoint to create a partially signed
+// transaction.
+func (c *Client) WalletPSTxPost(txn types.Transaction, parents []types.Transaction) (wpr api.WalletPSTxResp, err error) {
+	json, err := json.Marshal(api.WalletPSTxPOST{
+		Transaction: txn,
+		Parents:     parents,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/pstx", string(json), &wpr)
+	return
+}
+
+// WalletPSTxCombinePost uses the /wallet/pstx/combine endpoint to combine
+// copies of a partially signed transaction.
+func (c *Client) WalletPSTxCombinePost(pts []types.PartialTransaction) (wpr api.WalletPSTxResp, err error) {
+	json, err := json.Marshal(api.WalletPSTxCombinePOST{
+		PartialTransactions: pts,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/pstx/combine", string(json), &wpr)
+	return
+}
+
+// WalletPSTxFinalizePost uses the /wallet/pstx/finalize endpoint to finalize
+// a partially signed transaction, and optionally broadcast it.
+func (c *Client) WalletPSTxFinalizePost(pt types.PartialTransaction, broadcast bool) (wpfp api.WalletPSTxFinalizePOSTResp, err error) {
+	json, err := json.Marshal(api.WalletPSTxFinalizePOST{
+		PartialTransaction: pt,
+		Broadcast:          broadcast,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/pstx/finalize", string(json), &wpfp)
+	return
+}
+
+// WalletPSTxSignPost uses the /wallet/pstx/sign endpoint to sign a partially
+// signed transaction.
+func (c *Client) WalletPSTxSignPost(pt types.PartialTransaction) (wpr api.WalletPSTxResp, err error) {
+	json, err := json.Marshal(api.WalletPSTxSignPOST{
+		PartialTransaction: pt,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/pstx/sign", string(json), &wpr)
+	return
+}
+
 // WalletSeedPost uses the /wallet/seed endpoint to add a seed to the wallet's list
 // of seeds.
 func (c *Client) WalletSeedPost(seed, password string) (err error) {
@@ -107,6 +461,51 @@ func (c *Client) WalletSiacoinsPost(amount types.Currency, destination types.Unl
 	return
 }
 
+// WalletSiacoinsFromOutputsPost uses the /wallet/siacoins api endpoint to
+// send money to a single address, spending only the selected outputs.
+func (c *Client) WalletSiacoinsFromOutputsPost(amount types.Currency, destination types.UnlockHash, selection modules.SiacoinSelection) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := siacoinSelectionValues(selection)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("amount", amount.String())
+	values.Set("destination", destination.String())
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// WalletSiacoinsMultiFromOutputsPost uses the /wallet/siacoins api endpoint to
+// send money to multiple addresses, spending only the selected outputs.
+func (c *Client) WalletSiacoinsMultiFromOutputsPost(outputs []types.SiacoinOutput, selection modules.SiacoinSelection) (wsp api.WalletSiacoinsPOST, err error) {
+	values, err := siacoinSelectionValues(selection)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	marshaledOutputs, err := json.Marshal(outputs)
+	if err != nil {
+		return api.WalletSiacoinsPOST{}, err
+	}
+	values.Set("outputs", string(marshaledOutputs))
+	err = c.post("/wallet/siacoins", values.Encode(), &wsp)
+	return
+}
+
+// siacoinSelectionValues encodes the selected outputs as parameters of the
+// /wallet/siacoins api endpoint.
+func siacoinSelectionValues(selection modules.SiacoinSelection) (url.Values, error) {
+	values := url.Values{}
+	marshaledInputs, err := json.Marshal(selection.Outputs)
+	if err != nil {
+		return nil, err
+	}
+	values.Set("inputs", string(marshaledInputs))
+	if selection.ChangeAddress != (types.UnlockHash{}) {
+		values.Set("changeaddress", selection.ChangeAddress.String())
+	}
+	values.Set("spendall", strconv.FormatBool(selection.SpendAll))
+	return values, nil
+}
+
 // WalletSignPost uses the /wallet/sign api endpoint to sign a transaction.
 func (c *Client) WalletSignPost(txn types.Transaction, toSign []crypto.Hash) (wspr api.WalletSignPOSTResp, err error) {
 	json, err := json.Marshal(api.WalletSignPOSTParams{
@@ -157,6 +556,20 @@ func (c *Client) WalletTransactionsGet(startHeight types.BlockHeight, endHeight
 	return
 }
 
+// WalletTransactionsFilterGet requests the /wallet/transactions api resource
+// for a certain startheight and endheight, and returns only the transactions
+// of a category and with addresses that have a label. Empty filters match
+// every transaction.
+func (c *Client) WalletTransactionsFilterGet(startHeight types.BlockHeight, endHeight types.BlockHeight, category modules.TransactionCategory, label string) (wtg api.WalletTransactionsGET, err error) {
+	values := url.Values{}
+	values.Set("startheight", fmt.Sprint(startHeight))
+	values.Set("endheight", fmt.Sprint(endHeight))
+	values.Set("category", string(category))
+	values.Set("label", label)
+	err = c.get("/wallet/transactions?"+values.Encode(), &wtg)
+	return
+}
+
 // WalletTransactionGet requests the /wallet/transaction/:id api resource for a
 // certain TransactionID.
 func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTransactionGETid, err error) {
@@ -164,6 +577,22 @@ func (c *Client) WalletTransactionGet(id types.TransactionID) (wtg api.WalletTra
 	return
 }
 
+// WalletTransactionBumpPost uses the /wallet/transaction/:id/bump endpoint to
+// bump the fee of an unconfirmed transaction.
+func (c *Client) WalletTransactionBumpPost(id types.TransactionID) (wtbp api.WalletTransactionBumpPOST, err error) {
+	err = c.post("/wallet/transaction/"+id.String()+"/bump", "", &wtbp)
+	return
+}
+
+// WalletTransactionNotePost uses the /wallet/transaction/:id/note endpoint to
+// set the note of a transaction. An empty note removes the note.
+func (c *Client) WalletTransactionNotePost(id types.TransactionID, note string) (err error) {
+	values := url.Values{}
+	values.Set("note", note)
+	err = c.post("/wallet/transaction/"+id.String()+"/note", values.Encode(), nil)
+	return
+}
+
 // WalletUnlockPost uses the /wallet/unlock endpoint to unlock the wallet with
 // a given encryption key. Per default this key is the seed.
 func (c *Client) WalletUnlockPost(password string) (err error) {
@@ -224,6 +653,53 @@ func (c *Client) WalletWatchRemovePost(addrs []types.UnlockHash, unused bool) er
 	return c.post("/wallet/watch", string(json), nil)
 }
 
+// WalletWatchOnlyGet requests the /wallet/watchonly endpoint and returns the
+// state of the watch-only keys of the wallet.
+func (c *Client) WalletWatchOnlyGet() (status modules.WatchOnlyStatus, err error) {
+	err = c.get("/wallet/watchonly", &status)
+	return
+}
+
+// WalletWatchOnlyExportGet requests the /wallet/watchonly/export endpoint and
+// returns count public keys of the primary seed, starting at seed index
+// start.
+func (c *Client) WalletWatchOnlyExportGet(start, count uint64) (keys modules.WatchOnlyKeySet, err error) {
+	values := url.Values{}
+	values.Set("start", strconv.FormatUint(start, 10))
+	values.Set("count", strconv.FormatUint(count, 10))
+	err = c.get("/wallet/watchonly/export?"+values.Encode(), &keys)
+	return
+}
+
+// WalletWatchOnlyImportPost uses the /wallet/watchonly/import endpoint to add
+// keys exported by a cold wallet to the watch-only keys. The unused flag
+// should be set to true if the keys have never appeared in the blockchain.
+func (c *Client) WalletWatchOnlyImportPost(keys modules.WatchOnlyKeySet, unused bool) error {
+	json, err := json.Marshal(api.WalletWatchOnlyImportPOST{
+		KeySet: keys,
+		Unused: unused,
+	})
+	if err != nil {
+		return err
+	}
+	return c.post("/wallet/watchonly/import", string(json), nil)
+}
+
+// WalletWatchOnlyTransactionPost uses the /wallet/watchonly/transaction
+// endpoint to create an unsigned transaction that spends from the watch-only
+// keys.
+func (c *Client) WalletWatchOnlyTransactionPost(outputs []types.SiacoinOutput, fee types.Currency) (wpr api.WalletPSTxResp, err error) {
+	json, err := json.Marshal(api.WalletWatchOnlyTransactionPOST{
+		Outputs: outputs,
+		Fee:     fee,
+	})
+	if err != nil {
+		return
+	}
+	err = c.post("/wallet/watchonly/transaction", string(json), &wpr)
+	return
+}
+
 // Wallet033xPost uses the /wallet/033x endpoint to load a v0.3.3.x wallet into
 // the current wallet.
 func (c *Client) Wallet033xPost(path, password string) (err error) {
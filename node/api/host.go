@@ -4,6 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"gitlab.com/NebulousLabs/Sia/build"
 	"gitlab.com/NebulousLabs/Sia/modules"
@@ -30,6 +33,13 @@ type (
 		Contracts []modules.StorageObligation `json:"contracts"`
 	}
 
+	// ContractHistoryGET contains the information that is returned after a
+	// GET request to /host/contracts/history - the outcomes of the storage
+	// obligations that the host has resolved.
+	ContractHistoryGET struct {
+		Contracts []modules.ArchivedStorageObligation `json:"contracts"`
+	}
+
 	// HostGET contains the information that is returned after a GET request to
 	// /host - a bunch of information about the status of the host.
 	HostGET struct {
@@ -48,6 +58,43 @@ type (
 		ConversionRate float64        `json:"conversionrate"`
 	}
 
+	// HostPricingGET contains the information that is returned after a GET
+	// request to /host/pricing - whether automatic pricing is enabled and the
+	// history of automatic price changes.
+	HostPricingGET struct {
+		AutoPricing bool                      `json:"autopricing"`
+		History     []modules.HostPriceChange `json:"history"`
+	}
+
+	// HostRentersGET contains the information that is returned after a GET
+	// request to /host/renters - the reputations and the policies of the
+	// renters of the host.
+	HostRentersGET struct {
+		Renters []modules.RenterReputation `json:"renters"`
+	}
+
+	// StorageAuditGET contains the information that is returned after a
+	// request to /host/storage/audit - the sectors whose reference counts
+	// don't match the storage obligations of the host.
+	StorageAuditGET struct {
+		modules.StorageAudit
+	}
+
+	// StorageProofsGET contains the information that is returned after a
+	// request to /host/storage/proofs - the simulated storage proofs of the
+	// unresolved storage obligations of the host.
+	StorageProofsGET struct {
+		modules.StorageProofReport
+	}
+
+	// StorageScrubGET contains the information that is returned after a GET
+	// request to /host/storage/scrub - the results of the storage integrity
+	// scrub and the storage obligations that are at risk because of them.
+	StorageScrubGET struct {
+		AtRiskObligations []modules.AtRiskStorageObligation `json:"atriskobligations"`
+		Scrub             modules.StorageScrubStatus        `json:"scrub"`
+	}
+
 	// StorageGET contains the information that is returned after a GET request
 	// to /host/storage - a bunch of information about the status of storage
 	// management on the host.
@@ -76,6 +123,39 @@ func (api *API) hostContractInfoHandler(w http.ResponseWriter, req *http.Request
 	WriteJSON(w, cg)
 }
 
+// hostContractHistoryHandler handles the API call to get the outcomes of the
+// storage obligations that the host has resolved, optionally filtered by their
+// resolution time and their final status.
+func (api *API) hostContractHistoryHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, end time.Time
+	if req.FormValue("start") != "" {
+		unix, err := strconv.ParseInt(req.FormValue("start"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		start = time.Unix(unix, 0)
+	}
+	if req.FormValue("end") != "" {
+		unix, err := strconv.ParseInt(req.FormValue("end"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse end: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		// Include the obligations that were resolved during the last second.
+		end = time.Unix(unix, int64(time.Second-1))
+	}
+
+	history, err := api.host.StorageObligationHistory(start, end, req.FormValue("status"))
+	if err != nil {
+		WriteError(w, Error{"failed to get the contract history: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, ContractHistoryGET{
+		Contracts: history,
+	})
+}
+
 // hostHandlerGET handles GET requests to the /host API endpoint, returning key
 // information about the host.
 func (api *API) hostHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -110,6 +190,22 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.AcceptingContracts = x
 	}
+	if req.FormValue("maintenancemode") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("maintenancemode"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceMode = x
+	}
+	if req.FormValue("maintenanceend") != "" {
+		var x types.Timestamp
+		_, err := fmt.Sscan(req.FormValue("maintenanceend"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaintenanceEnd = x
+	}
 	if req.FormValue("maxdownloadbatchsize") != "" {
 		var x uint64
 		_, err := fmt.Sscan(req.FormValue("maxdownloadbatchsize"), &x)
@@ -118,6 +214,22 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MaxDownloadBatchSize = x
 	}
+	if req.FormValue("maxcontractbandwidth") != "" {
+		var x uint64
+		_, err := fmt.Sscan(req.FormValue("maxcontractbandwidth"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxContractBandwidth = x
+	}
+	if req.FormValue("maxdownloadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("maxdownloadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxDownloadSpeed = x
+	}
 	if req.FormValue("maxduration") != "" {
 		var x types.BlockHeight
 		_, err := fmt.Sscan(req.FormValue("maxduration"), &x)
@@ -134,6 +246,14 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		}
 		settings.MaxReviseBatchSize = x
 	}
+	if req.FormValue("maxuploadspeed") != "" {
+		var x int64
+		_, err := fmt.Sscan(req.FormValue("maxuploadspeed"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.MaxUploadSpeed = x
+	}
 	if req.FormValue("netaddress") != "" {
 		var x modules.NetAddress
 		_, err := fmt.Sscan(req.FormValue("netaddress"), &x)
@@ -209,6 +329,79 @@ func (api *API) parseHostSettings(req *http.Request) (modules.HostInternalSettin
 		settings.MinUploadBandwidthPrice = x
 	}
 
+	if req.FormValue("autopricing") != "" {
+		var x bool
+		_, err := fmt.Sscan(req.FormValue("autopricing"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.AutoPricing = x
+	}
+	if req.FormValue("contractpriceceiling") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("contractpriceceiling"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ContractPriceCeiling = x
+	}
+	if req.FormValue("contractpricefloor") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("contractpricefloor"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.ContractPriceFloor = x
+	}
+	if req.FormValue("downloadbandwidthpriceceiling") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("downloadbandwidthpriceceiling"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DownloadBandwidthPriceCeiling = x
+	}
+	if req.FormValue("downloadbandwidthpricefloor") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("downloadbandwidthpricefloor"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.DownloadBandwidthPriceFloor = x
+	}
+	if req.FormValue("storagepriceceiling") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("storagepriceceiling"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.StoragePriceCeiling = x
+	}
+	if req.FormValue("storagepricefloor") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("storagepricefloor"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.StoragePriceFloor = x
+	}
+	if req.FormValue("uploadbandwidthpriceceiling") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("uploadbandwidthpriceceiling"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.UploadBandwidthPriceCeiling = x
+	}
+	if req.FormValue("uploadbandwidthpricefloor") != "" {
+		var x types.Currency
+		_, err := fmt.Sscan(req.FormValue("uploadbandwidthpricefloor"), &x)
+		if err != nil {
+			return modules.HostInternalSettings{}, err
+		}
+		settings.UploadBandwidthPriceFloor = x
+	}
+
 	return settings, nil
 }
 
@@ -284,10 +477,28 @@ func (api *API) hostHandlerPOST(w http.ResponseWriter, req *http.Request, _ http
 // hostAnnounceHandler handles the API call to get the host to announce itself
 // to the network.
 func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var expiry types.BlockHeight
+	if req.FormValue("expiry") != "" {
+		_, err := fmt.Sscan(req.FormValue("expiry"), &expiry)
+		if err != nil {
+			WriteError(w, Error{"unable to parse expiry: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	addr := modules.NetAddress(req.FormValue("netaddress"))
 	var err error
-	if addr := req.FormValue("netaddress"); addr != "" {
-		err = api.host.AnnounceAddress(modules.NetAddress(addr))
-	} else {
+	switch {
+	case req.FormValue("netaddresses") != "":
+		var addrs []modules.NetAddress
+		for _, a := range strings.Split(req.FormValue("netaddresses"), ",") {
+			addrs = append(addrs, modules.NetAddress(strings.TrimSpace(a)))
+		}
+		err = api.host.AnnounceAddresses(addrs, expiry)
+	case addr != "" && expiry != 0:
+		err = api.host.AnnounceAddresses([]modules.NetAddress{addr}, expiry)
+	case addr != "":
+		err = api.host.AnnounceAddress(addr)
+	default:
 		err = api.host.Announce()
 	}
 	if err != nil {
@@ -297,6 +508,73 @@ func (api *API) hostAnnounceHandler(w http.ResponseWriter, req *http.Request, _
 	WriteSuccess(w)
 }
 
+// hostPricingHandlerGET handles GET requests to the /host/pricing API
+// endpoint, returning the history of automatic price changes.
+func (api *API) hostPricingHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	history, err := api.host.PriceHistory()
+	if err != nil {
+		WriteError(w, Error{"failed to get the price history: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostPricingGET{
+		AutoPricing: api.host.InternalSettings().AutoPricing,
+		History:     history,
+	})
+}
+
+// hostRentersHandlerGET handles GET requests to the /host/renters API
+// endpoint, returning the reputations and the policies of the renters.
+func (api *API) hostRentersHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	renters, err := api.host.RenterReputations()
+	if err != nil {
+		WriteError(w, Error{"failed to get the renter reputations: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, HostRentersGET{
+		Renters: renters,
+	})
+}
+
+// hostRentersPolicyHandlerPOST handles POST requests to the
+// /host/renters/policy API endpoint, which sets the policy of a renter.
+func (api *API) hostRentersPolicyHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var renter types.SiaPublicKey
+	renter.LoadString(req.FormValue("publickey"))
+	if renter.Key == nil {
+		WriteError(w, Error{"unable to parse publickey"}, http.StatusBadRequest)
+		return
+	}
+
+	var policy modules.RenterPolicy
+	var err error
+	policy.Deny, err = scanBool(req.FormValue("deny"))
+	if err != nil {
+		WriteError(w, Error{"unable to parse deny: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if req.FormValue("maxstorage") != "" {
+		policy.MaxStorage, err = strconv.ParseUint(req.FormValue("maxstorage"), 10, 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse maxstorage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if req.FormValue("pricemultiplier") != "" {
+		policy.PriceMultiplier, err = strconv.ParseFloat(req.FormValue("pricemultiplier"), 64)
+		if err != nil {
+			WriteError(w, Error{"unable to parse pricemultiplier: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+
+	err = api.host.SetRenterPolicy(renter, policy)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageHandler returns a bunch of information about storage management on
 // the host.
 func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
@@ -305,6 +583,60 @@ func (api *API) storageHandler(w http.ResponseWriter, req *http.Request, _ httpr
 	})
 }
 
+// storageAuditHandlerGET compares the reference counts of the stored sectors
+// with the storage obligations of the host.
+func (api *API) storageAuditHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	audit, err := api.host.AuditStorage(false)
+	if err != nil {
+		WriteError(w, Error{"failed to audit the storage: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, StorageAuditGET{audit})
+}
+
+// storageAuditHandlerPOST compares the reference counts of the stored sectors
+// with the storage obligations of the host, and corrects the reference counts
+// that don't match.
+func (api *API) storageAuditHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	audit, err := api.host.AuditStorage(true)
+	if err != nil {
+		WriteError(w, Error{"failed to fix the storage: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, StorageAuditGET{audit})
+}
+
+// storageProofsHandlerGET returns the report of the most recent storage proof
+// simulation of the host.
+func (api *API) storageProofsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, StorageProofsGET{api.host.StorageProofReport()})
+}
+
+// storageProofsHandlerPOST simulates the storage proofs of the unresolved
+// storage obligations of the host and returns the report.
+func (api *API) storageProofsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	report, err := api.host.SimulateStorageProofs()
+	if err != nil {
+		WriteError(w, Error{"failed to simulate the storage proofs: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, StorageProofsGET{report})
+}
+
+// storageScrubHandler returns the results of the storage integrity scrub and
+// the storage obligations that are at risk of a failed storage proof.
+func (api *API) storageScrubHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	atRisk, err := api.host.AtRiskStorageObligations()
+	if err != nil {
+		WriteError(w, Error{"failed to get the at risk storage obligations: " + err.Error()}, http.StatusInternalServerError)
+		return
+	}
+	WriteJSON(w, StorageScrubGET{
+		AtRiskObligations: atRisk,
+		Scrub:             api.host.ScrubStatus(),
+	})
+}
+
 // storageFoldersAddHandler adds a storage folder to the storage manager.
 func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -322,6 +654,36 @@ func (api *API) storageFoldersAddHandler(w http.ResponseWriter, req *http.Reques
 	WriteSuccess(w)
 }
 
+// storageFoldersMigrateHandler moves the sectors of a storage folder into
+// another storage folder.
+func (api *API) storageFoldersMigrateHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sourcePath := req.FormValue("source")
+	destinationPath := req.FormValue("destination")
+	if sourcePath == "" || destinationPath == "" {
+		WriteError(w, Error{"source and destination parameters are required"}, http.StatusBadRequest)
+		return
+	}
+
+	storageFolders := api.host.StorageFolders()
+	sourceIndex, err := folderIndex(sourcePath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	destinationIndex, err := folderIndex(destinationPath, storageFolders)
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+
+	err = api.host.MigrateStorageFolder(uint16(sourceIndex), uint16(destinationIndex))
+	if err != nil {
+		WriteError(w, Error{err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // storageFoldersResizeHandler resizes a storage folder in the storage manager.
 func (api *API) storageFoldersResizeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	folderPath := req.FormValue("path")
@@ -72,6 +72,7 @@ type (
 		Settings         modules.RenterSettings     `json:"settings"`
 		FinancialMetrics modules.ContractorSpending `json:"financialmetrics"`
 		CurrentPeriod    types.BlockHeight          `json:"currentperiod"`
+		Usage            modules.ContractorUsage    `json:"usage"`
 	}
 
 	// RenterContract represents a contract formed by the renter.
@@ -124,6 +125,26 @@ type (
 		ExpiredContracts  []RenterContract `json:"expiredcontracts"`
 	}
 
+	// RenterContractsReport contains the spending of the renter broken down
+	// by host and period.
+	RenterContractsReport struct {
+		Report []RenterHostSpendingReport `json:"report"`
+	}
+
+	// RenterHostSpendingReport contains the spending of the renter with a
+	// single host during a single period.
+	RenterHostSpendingReport struct {
+		modules.HostSpendingReport
+		// Address of the host the spending report refers to.
+		NetAddress modules.NetAddress `json:"netaddress"`
+	}
+
+	// RenterRecoverContractsGET contains the progress of the most recent
+	// contract recovery.
+	RenterRecoverContractsGET struct {
+		modules.ContractRecoveryStatus
+	}
+
 	// RenterDownloadQueue contains the renter's download queue.
 	RenterDownloadQueue struct {
 		Downloads []DownloadInfo `json:"downloads"`
@@ -183,6 +204,7 @@ func (api *API) renterHandlerGET(w http.ResponseWriter, req *http.Request, _ htt
 		Settings:         settings,
 		FinancialMetrics: api.renter.PeriodSpending(),
 		CurrentPeriod:    periodStart,
+		Usage:            api.renter.PeriodUsage(),
 	})
 }
 
@@ -243,6 +265,50 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		// Sane defaults if renew window hasn't been set before.
 		settings.Allowance.RenewWindow = settings.Allowance.Period / 2
 	}
+	// Scan the expected storage. (optional parameter)
+	if es := req.FormValue("expectedstorage"); es != "" {
+		var expectedStorage uint64
+		if _, err := fmt.Sscan(es, &expectedStorage); err != nil {
+			WriteError(w, Error{"unable to parse expectedstorage: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ExpectedStorage = expectedStorage
+	}
+	// Scan the expected upload. (optional parameter)
+	if eu := req.FormValue("expectedupload"); eu != "" {
+		var expectedUpload uint64
+		if _, err := fmt.Sscan(eu, &expectedUpload); err != nil {
+			WriteError(w, Error{"unable to parse expectedupload: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ExpectedUpload = expectedUpload
+	}
+	// Scan the expected download. (optional parameter)
+	if ed := req.FormValue("expecteddownload"); ed != "" {
+		var expectedDownload uint64
+		if _, err := fmt.Sscan(ed, &expectedDownload); err != nil {
+			WriteError(w, Error{"unable to parse expecteddownload: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ExpectedDownload = expectedDownload
+	}
+	// Scan the expected redundancy. (optional parameter)
+	if er := req.FormValue("expectedredundancy"); er != "" {
+		var expectedRedundancy float64
+		if _, err := fmt.Sscan(er, &expectedRedundancy); err != nil {
+			WriteError(w, Error{"unable to parse expectedredundancy: " + err.Error()}, http.StatusBadRequest)
+			return
+		} else if expectedRedundancy != 0 && expectedRedundancy < 1 {
+			WriteError(w, Error{"expectedredundancy must be at least 1"}, http.StatusBadRequest)
+			return
+		}
+		settings.Allowance.ExpectedRedundancy = expectedRedundancy
+	}
+	// An allowance without funds, hosts, period and renew window cancels the
+	// allowance, which also clears the expected usage.
+	if settings.Allowance.Funds.IsZero() && settings.Allowance.Hosts == 0 && settings.Allowance.Period == 0 && settings.Allowance.RenewWindow == 0 {
+		settings.Allowance = modules.Allowance{}
+	}
 	// Scan the download speed limit. (optional parameter)
 	if d := req.FormValue("maxdownloadspeed"); d != "" {
 		var downloadSpeed int64
@@ -279,6 +345,15 @@ func (api *API) renterHandlerPOST(w http.ResponseWriter, req *http.Request, _ ht
 		}
 		settings.IPViolationsCheck = ipviolationcheck
 	}
+	// Scan the misbehavior blacklist period.
+	if mbp := req.FormValue("misbehaviorblacklistperiod"); mbp != "" {
+		var period types.BlockHeight
+		if _, err := fmt.Sscan(mbp, &period); err != nil {
+			WriteError(w, Error{"unable to parse misbehaviorblacklistperiod: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		settings.MisbehaviorBlacklistPeriod = period
+	}
 
 	// Set the settings in the renter.
 	err := api.renter.SetSettings(settings)
@@ -425,6 +500,47 @@ func (api *API) renterContractsHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// renterContractsReportHandler handles the API call to request the Renter's
+// spending broken down by host and period.
+func (api *API) renterContractsReportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	report := []RenterHostSpendingReport{}
+	for _, r := range api.renter.ContractReport() {
+		// Fetch host address
+		var netAddress modules.NetAddress
+		hdbe, exists := api.renter.Host(r.HostPublicKey)
+		if exists {
+			netAddress = hdbe.NetAddress
+		}
+		report = append(report, RenterHostSpendingReport{
+			HostSpendingReport: r,
+			NetAddress:         netAddress,
+		})
+	}
+	WriteJSON(w, RenterContractsReport{
+		Report: report,
+	})
+}
+
+// renterContractsRecoverHandlerGET handles the API call to get the progress of
+// the most recent contract recovery.
+func (api *API) renterContractsRecoverHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	WriteJSON(w, RenterRecoverContractsGET{
+		ContractRecoveryStatus: api.renter.ContractRecoveryStatus(),
+	})
+}
+
+// renterContractsRecoverHandlerPOST handles the API call to start recovering
+// the contracts that were formed using the wallet seed but are missing from
+// the renter's contracts.
+func (api *API) renterContractsRecoverHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	err := api.renter.RecoverContracts()
+	if err != nil {
+		WriteError(w, Error{"unable to recover contracts: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // renterClearDownloadsHandler handles the API call to request to clear the download queue.
 func (api *API) renterClearDownloadsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var afterTime time.Time
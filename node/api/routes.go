@@ -46,14 +46,24 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/host", RequirePassword(api.hostHandlerPOST, requiredPassword))              // Change the settings of the host.
 		router.POST("/host/announce", RequirePassword(api.hostAnnounceHandler, requiredPassword)) // Announce the host to the network.
 		router.GET("/host/contracts", api.hostContractInfoHandler)                                // Get info about contracts.
+		router.GET("/host/contracts/history", api.hostContractHistoryHandler)                     // Get the outcomes of resolved contracts.
 		router.GET("/host/estimatescore", api.hostEstimateScoreGET)
+		router.GET("/host/pricing", api.hostPricingHandlerGET) // Get the history of automatic price changes.
+		router.GET("/host/renters", api.hostRentersHandlerGET) // Get the reputations of the renters.
+		router.POST("/host/renters/policy", RequirePassword(api.hostRentersPolicyHandlerPOST, requiredPassword))
 
 		// Calls pertaining to the storage manager that the host uses.
 		router.GET("/host/storage", api.storageHandler)
+		router.GET("/host/storage/audit", api.storageAuditHandlerGET)
+		router.POST("/host/storage/audit", RequirePassword(api.storageAuditHandlerPOST, requiredPassword))
 		router.POST("/host/storage/folders/add", RequirePassword(api.storageFoldersAddHandler, requiredPassword))
+		router.POST("/host/storage/folders/migrate", RequirePassword(api.storageFoldersMigrateHandler, requiredPassword))
 		router.POST("/host/storage/folders/remove", RequirePassword(api.storageFoldersRemoveHandler, requiredPassword))
 		router.POST("/host/storage/folders/resize", RequirePassword(api.storageFoldersResizeHandler, requiredPassword))
+		router.GET("/host/storage/proofs", api.storageProofsHandlerGET)
+		router.POST("/host/storage/proofs", RequirePassword(api.storageProofsHandlerPOST, requiredPassword))
 		router.POST("/host/storage/sectors/delete/:merkleroot", RequirePassword(api.storageSectorsDeleteHandler, requiredPassword))
+		router.GET("/host/storage/scrub", api.storageScrubHandler)
 	}
 
 	// Miner API Calls
@@ -71,6 +81,9 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/renter", RequirePassword(api.renterHandlerPOST, requiredPassword))
 		router.POST("/renter/contract/cancel", RequirePassword(api.renterContractCancelHandler, requiredPassword))
 		router.GET("/renter/contracts", api.renterContractsHandler)
+		router.GET("/renter/contracts/recover", api.renterContractsRecoverHandlerGET)
+		router.POST("/renter/contracts/recover", RequirePassword(api.renterContractsRecoverHandlerPOST, requiredPassword))
+		router.GET("/renter/contracts/report", api.renterContractsReportHandler)
 		router.GET("/renter/downloads", api.renterDownloadsHandler)
 		router.POST("/renter/downloads/clear", RequirePassword(api.renterClearDownloadsHandler, requiredPassword))
 		router.GET("/renter/files", api.renterFilesHandler)
@@ -116,10 +129,43 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/wallet/033x", RequirePassword(api.wallet033xHandler, requiredPassword))
 		router.GET("/wallet/address", RequirePassword(api.walletAddressHandler, requiredPassword))
 		router.GET("/wallet/addresses", api.walletAddressesHandler)
+		router.GET("/wallet/atomicswap/:id", RequirePassword(api.walletAtomicSwapHandler, requiredPassword))
+		router.POST("/wallet/atomicswap/:id/claim", RequirePassword(api.walletAtomicSwapClaimHandler, requiredPassword))
+		router.POST("/wallet/atomicswap/:id/fund", RequirePassword(api.walletAtomicSwapFundHandler, requiredPassword))
+		router.POST("/wallet/atomicswap/:id/presign", RequirePassword(api.walletAtomicSwapPresignHandler, requiredPassword))
+		router.GET("/wallet/atomicswaps", RequirePassword(api.walletAtomicSwapsHandler, requiredPassword))
+		router.POST("/wallet/atomicswaps/accept", RequirePassword(api.walletAtomicSwapsAcceptHandler, requiredPassword))
+		router.POST("/wallet/atomicswaps/offer", RequirePassword(api.walletAtomicSwapsOfferHandler, requiredPassword))
+		router.POST("/wallet/atomicswaps/request", RequirePassword(api.walletAtomicSwapsRequestHandler, requiredPassword))
 		router.GET("/wallet/backup", RequirePassword(api.walletBackupHandler, requiredPassword))
+		router.GET("/wallet/export", RequirePassword(api.walletExportHandler, requiredPassword))
 		router.POST("/wallet/init", RequirePassword(api.walletInitHandler, requiredPassword))
 		router.POST("/wallet/init/seed", RequirePassword(api.walletInitSeedHandler, requiredPassword))
+		router.POST("/wallet/init/watchonly", RequirePassword(api.walletInitWatchOnlyHandler, requiredPassword))
+		router.POST("/wallet/label", RequirePassword(api.walletLabelHandler, requiredPassword))
+		router.GET("/wallet/labels", RequirePassword(api.walletLabelsHandler, requiredPassword))
 		router.POST("/wallet/lock", RequirePassword(api.walletLockHandler, requiredPassword))
+		router.GET("/wallet/multisig/addresses", RequirePassword(api.walletMultisigAddressesHandlerGET, requiredPassword))
+		router.POST("/wallet/multisig/addresses", RequirePassword(api.walletMultisigAddressesHandlerPOST, requiredPassword))
+		router.GET("/wallet/multisig/key", RequirePassword(api.walletMultisigKeyHandler, requiredPassword))
+		router.POST("/wallet/multisig/merge", RequirePassword(api.walletMultisigMergeHandler, requiredPassword))
+		router.POST("/wallet/multisig/sign", RequirePassword(api.walletMultisigSignHandler, requiredPassword))
+		router.POST("/wallet/multisig/transaction", RequirePassword(api.walletMultisigTransactionHandler, requiredPassword))
+		router.GET("/wallet/paymentrequest/:addr", RequirePassword(api.walletPaymentRequestHandler, requiredPassword))
+		router.GET("/wallet/paymentrequests", RequirePassword(api.walletPaymentRequestsHandlerGET, requiredPassword))
+		router.POST("/wallet/paymentrequests", RequirePassword(api.walletPaymentRequestsHandlerPOST, requiredPassword))
+		router.GET("/wallet/paymentrequests/events", RequirePassword(api.walletPaymentRequestEventsHandler, requiredPassword))
+		router.POST("/wallet/pstx", RequirePassword(api.walletPSTxHandler, requiredPassword))
+		router.POST("/wallet/pstx/combine", RequirePassword(api.walletPSTxCombineHandler, requiredPassword))
+		router.POST("/wallet/pstx/finalize", RequirePassword(api.walletPSTxFinalizeHandler, requiredPassword))
+		router.POST("/wallet/pstx/sign", RequirePassword(api.walletPSTxSignHandler, requiredPassword))
+		router.POST("/wallet/scheduledpayment/:id/approve", RequirePassword(api.walletScheduledPaymentApproveHandler, requiredPassword))
+		router.POST("/wallet/scheduledpayment/:id/cancel", RequirePassword(api.walletScheduledPaymentCancelHandler, requiredPassword))
+		router.GET("/wallet/scheduledpayments", RequirePassword(api.walletScheduledPaymentsHandlerGET, requiredPassword))
+		router.POST("/wallet/scheduledpayments", RequirePassword(api.walletScheduledPaymentsHandlerPOST, requiredPassword))
+		router.GET("/wallet/scheduledpayments/executions", RequirePassword(api.walletScheduledPaymentExecutionsHandler, requiredPassword))
+		router.GET("/wallet/scheduledpayments/limits", RequirePassword(api.walletScheduledPaymentLimitsHandlerGET, requiredPassword))
+		router.POST("/wallet/scheduledpayments/limits", RequirePassword(api.walletScheduledPaymentLimitsHandlerPOST, requiredPassword))
 		router.POST("/wallet/seed", RequirePassword(api.walletSeedHandler, requiredPassword))
 		router.GET("/wallet/seeds", RequirePassword(api.walletSeedsHandler, requiredPassword))
 		router.POST("/wallet/siacoins", RequirePassword(api.walletSiacoinsHandler, requiredPassword))
@@ -127,6 +173,8 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/wallet/siagkey", RequirePassword(api.walletSiagkeyHandler, requiredPassword))
 		router.POST("/wallet/sweep/seed", RequirePassword(api.walletSweepSeedHandler, requiredPassword))
 		router.GET("/wallet/transaction/:id", api.walletTransactionHandler)
+		router.POST("/wallet/transaction/:id/bump", RequirePassword(api.walletTransactionBumpHandler, requiredPassword))
+		router.POST("/wallet/transaction/:id/note", RequirePassword(api.walletTransactionNoteHandler, requiredPassword))
 		router.GET("/wallet/transactions", api.walletTransactionsHandler)
 		router.GET("/wallet/transactions/:addr", api.walletTransactionsAddrHandler)
 		router.GET("/wallet/verify/address/:addr", api.walletVerifyAddressHandler)
@@ -138,6 +186,10 @@ func (api *API) buildHTTPRoutes(requiredUserAgent string, requiredPassword strin
 		router.POST("/wallet/sign", RequirePassword(api.walletSignHandler, requiredPassword))
 		router.GET("/wallet/watch", RequirePassword(api.walletWatchHandlerGET, requiredPassword))
 		router.POST("/wallet/watch", RequirePassword(api.walletWatchHandlerPOST, requiredPassword))
+		router.GET("/wallet/watchonly", RequirePassword(api.walletWatchOnlyHandler, requiredPassword))
+		router.GET("/wallet/watchonly/export", RequirePassword(api.walletWatchOnlyExportHandler, requiredPassword))
+		router.POST("/wallet/watchonly/import", RequirePassword(api.walletWatchOnlyImportHandler, requiredPassword))
+		router.POST("/wallet/watchonly/transaction", RequirePassword(api.walletWatchOnlyTransactionHandler, requiredPassword))
 	}
 
 	// Apply UserAgent middleware and return the Router
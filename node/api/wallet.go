@@ -1,13 +1,19 @@
 package api
 
 import (
+	"bytes"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"math"
+	"math/big"
 	"net/http"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"gitlab.com/NebulousLabs/Sia/crypto"
 	"gitlab.com/NebulousLabs/Sia/modules"
@@ -47,6 +53,37 @@ type (
 		Addresses []types.UnlockHash `json:"addresses"`
 	}
 
+	// WalletAtomicSwapAcceptPOST contains the signature of the refund
+	// transaction of an accepted atomic swap, hex-encoded.
+	WalletAtomicSwapAcceptPOST struct {
+		RefundSignature string `json:"refundsignature"`
+	}
+
+	// WalletAtomicSwapClaimPOST contains the claim transaction of an atomic
+	// swap.
+	WalletAtomicSwapClaimPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
+	// WalletAtomicSwapOfferPOST contains the parameters of an atomic swap
+	// offer.
+	WalletAtomicSwapOfferPOST struct {
+		Request      modules.AtomicSwapRequest `json:"request"`
+		Amount       types.Currency            `json:"amount"`
+		RefundHeight types.BlockHeight         `json:"refundheight"`
+	}
+
+	// WalletAtomicSwapPresignPOST contains the adaptor signature of the claim
+	// transaction of an atomic swap.
+	WalletAtomicSwapPresignPOST struct {
+		AdaptorSignature crypto.AdaptorSignature `json:"adaptorsignature"`
+	}
+
+	// WalletAtomicSwapsGET contains the atomic swaps of the wallet.
+	WalletAtomicSwapsGET struct {
+		AtomicSwaps []modules.AtomicSwap `json:"atomicswaps"`
+	}
+
 	// WalletInitPOST contains the primary seed that gets generated during a
 	// POST call to /wallet/init.
 	WalletInitPOST struct {
@@ -65,6 +102,46 @@ type (
 		TransactionIDs []types.TransactionID `json:"transactionids"`
 	}
 
+	// WalletMultisigAddressesGET contains the multisig addresses that the
+	// wallet tracks.
+	WalletMultisigAddressesGET struct {
+		Addresses []modules.MultisigAddress `json:"addresses"`
+	}
+
+	// WalletMultisigAddressesPOST contains the parameters of a new multisig
+	// address.
+	WalletMultisigAddressesPOST struct {
+		Required   uint64               `json:"required"`
+		PublicKeys []types.SiaPublicKey `json:"publickeys"`
+		Unused     bool                 `json:"unused"`
+	}
+
+	// WalletMultisigKeyGET contains a public key of the wallet that can be
+	// used in a multisig address.
+	WalletMultisigKeyGET struct {
+		PublicKey types.SiaPublicKey `json:"publickey"`
+	}
+
+	// WalletMultisigMergePOST contains the copies of a transaction that were
+	// signed by different co-signers.
+	WalletMultisigMergePOST struct {
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// WalletMultisigSignPOST contains a transaction that spends from
+	// multisig addresses.
+	WalletMultisigSignPOST struct {
+		Transaction types.Transaction `json:"transaction"`
+	}
+
+	// WalletMultisigTransactionPOST contains the parameters of a transaction
+	// that spends from a multisig address.
+	WalletMultisigTransactionPOST struct {
+		Address types.UnlockHash      `json:"address"`
+		Outputs []types.SiacoinOutput `json:"outputs"`
+		Fee     types.Currency        `json:"fee"`
+	}
+
 	// WalletSignPOSTParams contains the unsigned transaction and a set of
 	// inputs to sign.
 	WalletSignPOSTParams struct {
@@ -77,6 +154,91 @@ type (
 		Transaction types.Transaction `json:"transaction"`
 	}
 
+	// WalletPSTxPOST contains the transaction and parents of a new partially
+	// signed transaction.
+	WalletPSTxPOST struct {
+		Transaction types.Transaction   `json:"transaction"`
+		Parents     []types.Transaction `json:"parents"`
+	}
+
+	// WalletPSTxCombinePOST contains the copies of a partially signed
+	// transaction that were signed by different parties.
+	WalletPSTxCombinePOST struct {
+		PartialTransactions []types.PartialTransaction `json:"partialtransactions"`
+	}
+
+	// WalletPSTxFinalizePOST contains a fully signed partially signed
+	// transaction, and whether it should be broadcast.
+	WalletPSTxFinalizePOST struct {
+		PartialTransaction types.PartialTransaction `json:"partialtransaction"`
+		Broadcast          bool                     `json:"broadcast"`
+	}
+
+	// WalletPSTxFinalizePOSTResp contains the transaction set of a finalized
+	// partially signed transaction.
+	WalletPSTxFinalizePOSTResp struct {
+		Transactions []types.Transaction `json:"transactions"`
+	}
+
+	// WalletPSTxResp contains a partially signed transaction, and a summary
+	// of it.
+	WalletPSTxResp struct {
+		PartialTransaction types.PartialTransaction `json:"partialtransaction"`
+		Encoded            string                   `json:"encoded"`
+		SiacoinInputSum    types.Currency           `json:"siacoininputsum"`
+		SignaturesNeeded   uint64                   `json:"signaturesneeded"`
+	}
+
+	// WalletPSTxSignPOST contains a partially signed transaction to sign.
+	WalletPSTxSignPOST struct {
+		PartialTransaction types.PartialTransaction `json:"partialtransaction"`
+	}
+
+	// WalletWatchOnlyImportPOST contains watch-only keys exported by a cold
+	// wallet.
+	WalletWatchOnlyImportPOST struct {
+		KeySet modules.WatchOnlyKeySet `json:"keyset"`
+		Unused bool                    `json:"unused"`
+	}
+
+	// WalletWatchOnlyTransactionPOST contains the parameters of a transaction
+	// that spends from the watch-only keys.
+	WalletWatchOnlyTransactionPOST struct {
+		Outputs []types.SiacoinOutput `json:"outputs"`
+		Fee     types.Currency        `json:"fee"`
+	}
+
+	// WalletPaymentRequestEventsGET contains the recent status changes of the
+	// payment requests of the wallet.
+	WalletPaymentRequestEventsGET struct {
+		Events []modules.PaymentRequestEvent `json:"events"`
+	}
+
+	// WalletPaymentRequestsGET contains the payment requests of the wallet.
+	WalletPaymentRequestsGET struct {
+		PaymentRequests []modules.PaymentRequest `json:"paymentrequests"`
+	}
+
+	// WalletScheduledPaymentExecutionsGET contains the recent attempts to
+	// make scheduled payments.
+	WalletScheduledPaymentExecutionsGET struct {
+		Executions []modules.ScheduledPaymentExecution `json:"executions"`
+	}
+
+	// WalletScheduledPaymentsGET contains the scheduled payments of the
+	// wallet.
+	WalletScheduledPaymentsGET struct {
+		ScheduledPayments []modules.ScheduledPayment `json:"scheduledpayments"`
+	}
+
+	// WalletScheduledPaymentsPOST contains the parameters of a scheduled
+	// payment.
+	WalletScheduledPaymentsPOST struct {
+		Outputs  []types.SiacoinOutput   `json:"outputs"`
+		Schedule modules.PaymentSchedule `json:"schedule"`
+		Memo     string                  `json:"memo"`
+	}
+
 	// WalletSeedsGET contains the seeds used by the wallet.
 	WalletSeedsGET struct {
 		PrimarySeed        string   `json:"primaryseed"`
@@ -97,6 +259,45 @@ type (
 		Transaction modules.ProcessedTransaction `json:"transaction"`
 	}
 
+	// WalletTransactionBumpPOST contains the IDs of the transaction set that
+	// was broadcast to bump the fee of a transaction. The last transaction
+	// is the child that pays the fee.
+	WalletTransactionBumpPOST struct {
+		TransactionIDs []types.TransactionID `json:"transactionids"`
+	}
+
+	// WalletExportGET contains the records of the exported transactions.
+	WalletExportGET struct {
+		Records []WalletTransactionRecord `json:"records"`
+	}
+
+	// WalletLabelsGET contains the labels of the labeled addresses.
+	WalletLabelsGET struct {
+		Labels []modules.AddressLabel `json:"labels"`
+	}
+
+	// WalletTransactionRecord summarizes a confirmed ProcessedTransaction for
+	// bookkeeping. The net values are signed decimal strings; they are
+	// negative if the wallet spent more than it received. Fees only contains
+	// the miner fees if the wallet funded the transaction. Counterparties are
+	// the addresses of the inputs and outputs that don't belong to the
+	// wallet.
+	WalletTransactionRecord struct {
+		TransactionID         types.TransactionID           `json:"transactionid"`
+		ConfirmationHeight    types.BlockHeight             `json:"confirmationheight"`
+		ConfirmationTimestamp types.Timestamp               `json:"confirmationtimestamp"`
+		IncomingSiacoins      types.Currency                `json:"incomingsiacoins"`
+		OutgoingSiacoins      types.Currency                `json:"outgoingsiacoins"`
+		NetSiacoins           string                        `json:"netsiacoins"`
+		Fees                  types.Currency                `json:"fees"`
+		IncomingSiafunds      types.Currency                `json:"incomingsiafunds"`
+		OutgoingSiafunds      types.Currency                `json:"outgoingsiafunds"`
+		NetSiafunds           string                        `json:"netsiafunds"`
+		Counterparties        []types.UnlockHash            `json:"counterparties"`
+		Categories            []modules.TransactionCategory `json:"categories"`
+		Note                  string                        `json:"note"`
+	}
+
 	// WalletTransactionsGET contains the specified set of confirmed and
 	// unconfirmed transactions.
 	WalletTransactionsGET struct {
@@ -316,6 +517,27 @@ func (api *API) walletInitHandler(w http.ResponseWriter, req *http.Request, _ ht
 	})
 }
 
+// walletInitWatchOnlyHandler handles API calls to /wallet/init/watchonly.
+func (api *API) walletInitWatchOnlyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	if req.FormValue("encryptionpassword") == "" {
+		WriteError(w, Error{"a watch-only wallet must be encrypted with a password"}, http.StatusBadRequest)
+		return
+	}
+	encryptionKey := crypto.TwofishKey(crypto.HashObject(req.FormValue("encryptionpassword")))
+	if req.FormValue("force") == "true" {
+		err := api.wallet.Reset()
+		if err != nil {
+			WriteError(w, Error{"error when calling /wallet/init/watchonly: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if err := api.wallet.InitWatchOnly(encryptionKey); err != nil {
+		WriteError(w, Error{"error when calling /wallet/init/watchonly: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
 // walletInitSeedHandler handles API calls to /wallet/init/seed.
 func (api *API) walletInitSeedHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	var encryptionKey crypto.TwofishKey
@@ -457,6 +679,32 @@ func (api *API) walletSeedsHandler(w http.ResponseWriter, req *http.Request, _ h
 
 // walletSiacoinsHandler handles API calls to /wallet/siacoins.
 func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the outputs that were selected to fund the transaction, if any.
+	var selection *modules.SiacoinSelection
+	if req.FormValue("inputs") != "" {
+		selection = new(modules.SiacoinSelection)
+		err := json.Unmarshal([]byte(req.FormValue("inputs")), &selection.Outputs)
+		if err != nil {
+			WriteError(w, Error{"could not decode inputs: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+		if req.FormValue("changeaddress") != "" {
+			selection.ChangeAddress, err = scanAddress(req.FormValue("changeaddress"))
+			if err != nil {
+				WriteError(w, Error{"could not read change address from POST call to /wallet/siacoins"}, http.StatusBadRequest)
+				return
+			}
+		}
+		selection.SpendAll, err = scanBool(req.FormValue("spendall"))
+		if err != nil {
+			WriteError(w, Error{"could not read spendall from POST call to /wallet/siacoins: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	} else if req.FormValue("changeaddress") != "" || req.FormValue("spendall") != "" {
+		WriteError(w, Error{"'changeaddress' and 'spendall' can only be supplied together with 'inputs'"}, http.StatusBadRequest)
+		return
+	}
+
 	var txns []types.Transaction
 	if req.FormValue("outputs") != "" {
 		// multiple amounts + destinations
@@ -471,7 +719,11 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			WriteError(w, Error{"could not decode outputs: " + err.Error()}, http.StatusInternalServerError)
 			return
 		}
-		txns, err = api.wallet.SendSiacoinsMulti(outputs)
+		if selection != nil {
+			txns, err = api.wallet.SendSiacoinsMultiFromOutputs(outputs, *selection)
+		} else {
+			txns, err = api.wallet.SendSiacoinsMulti(outputs)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -489,7 +741,11 @@ func (api *API) walletSiacoinsHandler(w http.ResponseWriter, req *http.Request,
 			return
 		}
 
-		txns, err = api.wallet.SendSiacoins(amount, dest)
+		if selection != nil {
+			txns, err = api.wallet.SendSiacoinsFromOutputs(amount, dest, *selection)
+		} else {
+			txns, err = api.wallet.SendSiacoins(amount, dest)
+		}
 		if err != nil {
 			WriteError(w, Error{"error when calling /wallet/siacoins: " + err.Error()}, http.StatusInternalServerError)
 			return
@@ -557,6 +813,179 @@ func (api *API) walletSweepSeedHandler(w http.ResponseWriter, req *http.Request,
 	})
 }
 
+// newWalletTransactionRecord summarizes a wallet transaction for bookkeeping.
+func newWalletTransactionRecord(pt modules.ProcessedTransaction) WalletTransactionRecord {
+	tr := WalletTransactionRecord{
+		TransactionID:         pt.TransactionID,
+		ConfirmationHeight:    pt.ConfirmationHeight,
+		ConfirmationTimestamp: pt.ConfirmationTimestamp,
+		Categories:            pt.Categories,
+		Note:                  pt.Note,
+	}
+	seen := make(map[types.UnlockHash]struct{})
+	addCounterparty := func(addr types.UnlockHash) {
+		if _, exists := seen[addr]; !exists && addr != (types.UnlockHash{}) {
+			seen[addr] = struct{}{}
+			tr.Counterparties = append(tr.Counterparties, addr)
+		}
+	}
+
+	funded := false
+	for _, input := range pt.Inputs {
+		if !input.WalletAddress {
+			addCounterparty(input.RelatedAddress)
+			continue
+		}
+		funded = true
+		switch input.FundType {
+		case types.SpecifierSiacoinInput:
+			tr.OutgoingSiacoins = tr.OutgoingSiacoins.Add(input.Value)
+		case types.SpecifierSiafundInput:
+			tr.OutgoingSiafunds = tr.OutgoingSiafunds.Add(input.Value)
+		}
+	}
+	for _, output := range pt.Outputs {
+		switch {
+		case output.FundType == types.SpecifierMinerFee:
+			if funded {
+				tr.Fees = tr.Fees.Add(output.Value)
+			}
+		case output.FundType == types.SpecifierMinerPayout:
+			tr.IncomingSiacoins = tr.IncomingSiacoins.Add(output.Value)
+		case !output.WalletAddress:
+			addCounterparty(output.RelatedAddress)
+		case output.FundType == types.SpecifierSiafundOutput:
+			tr.IncomingSiafunds = tr.IncomingSiafunds.Add(output.Value)
+		default:
+			tr.IncomingSiacoins = tr.IncomingSiacoins.Add(output.Value)
+		}
+	}
+	tr.NetSiacoins = new(big.Int).Sub(tr.IncomingSiacoins.Big(), tr.OutgoingSiacoins.Big()).String()
+	tr.NetSiafunds = new(big.Int).Sub(tr.IncomingSiafunds.Big(), tr.OutgoingSiafunds.Big()).String()
+	return tr
+}
+
+// writeWalletTransactionRecordsCSV writes the records as CSV with a header row.
+// Siacoin amounts are written in SC instead of hastings, and the
+// confirmation time is written both as a unix timestamp and as an RFC 3339
+// date in UTC. Lists are separated by semicolons.
+func writeWalletTransactionRecordsCSV(w io.Writer, records []WalletTransactionRecord) error {
+	siacoins := func(hastings *big.Int) string {
+		sc := new(big.Rat).SetFrac(hastings, types.SiacoinPrecision.Big()).FloatString(24)
+		return strings.TrimSuffix(strings.TrimRight(sc, "0"), ".")
+	}
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"txid", "height", "timestamp", "date", "incoming_sc", "outgoing_sc", "net_sc", "fees_sc",
+		"incoming_sf", "outgoing_sf", "net_sf", "counterparties", "categories", "note"})
+	for _, tr := range records {
+		netSiacoins := new(big.Int).Sub(tr.IncomingSiacoins.Big(), tr.OutgoingSiacoins.Big())
+		counterparties := make([]string, len(tr.Counterparties))
+		for i, addr := range tr.Counterparties {
+			counterparties[i] = addr.String()
+		}
+		categories := make([]string, len(tr.Categories))
+		for i, c := range tr.Categories {
+			categories[i] = string(c)
+		}
+		cw.Write([]string{
+			tr.TransactionID.String(),
+			fmt.Sprint(tr.ConfirmationHeight),
+			fmt.Sprint(tr.ConfirmationTimestamp),
+			time.Unix(int64(tr.ConfirmationTimestamp), 0).UTC().Format(time.RFC3339),
+			siacoins(tr.IncomingSiacoins.Big()),
+			siacoins(tr.OutgoingSiacoins.Big()),
+			siacoins(netSiacoins),
+			siacoins(tr.Fees.Big()),
+			tr.IncomingSiafunds.String(),
+			tr.OutgoingSiafunds.String(),
+			tr.NetSiafunds,
+			strings.Join(counterparties, ";"),
+			strings.Join(categories, ";"),
+			tr.Note,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// walletExportHandler handles API calls to /wallet/export.
+func (api *API) walletExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	// Parse the optional height and time range. The end of the range is
+	// unbounded by default.
+	bounds := []uint64{0, math.MaxUint64, 0, math.MaxUint64}
+	for i, param := range []string{"startheight", "endheight", "starttime", "endtime"} {
+		str := req.FormValue(param)
+		if str == "" || (str == "-1" && i%2 == 1) {
+			continue
+		}
+		var err error
+		if bounds[i], err = strconv.ParseUint(str, 10, 64); err != nil {
+			WriteError(w, Error{"parsing integer value for parameter `" + param + "` failed: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	format := req.FormValue("format")
+	if format != "" && format != "csv" && format != "json" {
+		WriteError(w, Error{"format must be 'csv' or 'json'"}, http.StatusBadRequest)
+		return
+	}
+
+	pts, err := api.wallet.Transactions(types.BlockHeight(bounds[0]), types.BlockHeight(bounds[1]))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/export: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	records := []WalletTransactionRecord{}
+	for _, pt := range pts {
+		timestamp := uint64(pt.ConfirmationTimestamp)
+		if timestamp >= bounds[2] && timestamp <= bounds[3] {
+			records = append(records, newWalletTransactionRecord(pt))
+		}
+	}
+
+	if format == "csv" {
+		// Buffer the CSV so that an error can still be written as a regular
+		// error response instead of being appended to a partial body.
+		var buf bytes.Buffer
+		if err := writeWalletTransactionRecordsCSV(&buf, records); err != nil {
+			WriteError(w, Error{"error when calling /wallet/export: " + err.Error()}, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		buf.WriteTo(w)
+		return
+	}
+	WriteJSON(w, WalletExportGET{
+		Records: records,
+	})
+}
+
+// walletLabelHandler handles API calls to /wallet/label.
+func (api *API) walletLabelHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addr, err := scanAddress(req.FormValue("address"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.SetAddressLabel(addr, req.FormValue("label")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/label: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletLabelsHandler handles API calls to /wallet/labels.
+func (api *API) walletLabelsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	labels, err := api.wallet.AddressLabels()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/labels: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletLabelsGET{
+		Labels: labels,
+	})
+}
+
 // walletTransactionHandler handles API calls to /wallet/transaction/:id.
 func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
 	// Parse the id from the url.
@@ -582,6 +1011,71 @@ func (api *API) walletTransactionHandler(w http.ResponseWriter, req *http.Reques
 	})
 }
 
+// walletTransactionBumpHandler handles API calls to
+// /wallet/transaction/:id/bump.
+func (api *API) walletTransactionBumpHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	if err := id.UnmarshalJSON([]byte(jsonID)); err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/bump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txns, err := api.wallet.BumpTransaction(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/bump: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var txids []types.TransactionID
+	for _, txn := range txns {
+		txids = append(txids, txn.ID())
+	}
+	WriteJSON(w, WalletTransactionBumpPOST{
+		TransactionIDs: txids,
+	})
+}
+
+// walletTransactionNoteHandler handles API calls to
+// /wallet/transaction/:id/note.
+func (api *API) walletTransactionNoteHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	var id types.TransactionID
+	jsonID := "\"" + ps.ByName("id") + "\""
+	if err := id.UnmarshalJSON([]byte(jsonID)); err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/note: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.SetTransactionNote(id, req.FormValue("note")); err != nil {
+		WriteError(w, Error{"error when calling /wallet/transaction/:id/note: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// filterTransactions returns the transactions that have the category, and
+// that have an input or output of a labeled address. A nil map of labeled
+// addresses and an empty category match every transaction.
+func filterTransactions(pts []modules.ProcessedTransaction, category modules.TransactionCategory, labeled map[types.UnlockHash]struct{}) []modules.ProcessedTransaction {
+	var filtered []modules.ProcessedTransaction
+	for _, pt := range pts {
+		hasCategory := category == ""
+		for _, c := range pt.Categories {
+			hasCategory = hasCategory || c == category
+		}
+		hasLabel := labeled == nil
+		for _, input := range pt.Inputs {
+			_, exists := labeled[input.RelatedAddress]
+			hasLabel = hasLabel || exists
+		}
+		for _, output := range pt.Outputs {
+			_, exists := labeled[output.RelatedAddress]
+			hasLabel = hasLabel || exists
+		}
+		if hasCategory && hasLabel {
+			filtered = append(filtered, pt)
+		}
+	}
+	return filtered
+}
+
 // walletTransactionsHandler handles API calls to /wallet/transactions.
 func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
 	startheightStr, endheightStr := req.FormValue("startheight"), req.FormValue("endheight")
@@ -618,6 +1112,28 @@ func (api *API) walletTransactionsHandler(w http.ResponseWriter, req *http.Reque
 		return
 	}
 
+	// Filter the transactions by category and by the label of their
+	// addresses.
+	category := modules.TransactionCategory(req.FormValue("category"))
+	if label := req.FormValue("label"); category != "" || label != "" {
+		var labeled map[types.UnlockHash]struct{}
+		if label != "" {
+			labels, err := api.wallet.AddressLabels()
+			if err != nil {
+				WriteError(w, Error{"error when calling /wallet/transactions: " + err.Error()}, http.StatusBadRequest)
+				return
+			}
+			labeled = make(map[types.UnlockHash]struct{})
+			for _, al := range labels {
+				if al.Label == label {
+					labeled[al.Address] = struct{}{}
+				}
+			}
+		}
+		confirmedTxns = filterTransactions(confirmedTxns, category, labeled)
+		unconfirmedTxns = filterTransactions(unconfirmedTxns, category, labeled)
+	}
+
 	WriteJSON(w, WalletTransactionsGET{
 		ConfirmedTransactions:   confirmedTxns,
 		UnconfirmedTransactions: unconfirmedTxns,
@@ -797,3 +1313,594 @@ func (api *API) walletWatchHandlerPOST(w http.ResponseWriter, req *http.Request,
 	}
 	WriteSuccess(w)
 }
+
+// walletMultisigAddressesHandlerGET handles GET calls to
+// /wallet/multisig/addresses.
+func (api *API) walletMultisigAddressesHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	addrs, err := api.wallet.MultisigAddresses()
+	if err != nil {
+		WriteError(w, Error{"failed to get multisig addresses: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigAddressesGET{
+		Addresses: addrs,
+	})
+}
+
+// walletMultisigAddressesHandlerPOST handles POST calls to
+// /wallet/multisig/addresses.
+func (api *API) walletMultisigAddressesHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigAddressesPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	addr, err := api.wallet.CreateMultisigAddress(params.Required, params.PublicKeys, params.Unused)
+	if err != nil {
+		WriteError(w, Error{"failed to create multisig address: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, addr)
+}
+
+// walletMultisigKeyHandler handles GET calls to /wallet/multisig/key.
+func (api *API) walletMultisigKeyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	pk, err := api.wallet.MultisigPublicKey()
+	if err != nil {
+		WriteError(w, Error{"failed to get multisig key: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletMultisigKeyGET{
+		PublicKey: pk,
+	})
+}
+
+// walletMultisigMergeHandler handles POST calls to /wallet/multisig/merge.
+func (api *API) walletMultisigMergeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigMergePOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	mt, err := api.wallet.MergeMultisigTransactions(params.Transactions)
+	if err != nil {
+		WriteError(w, Error{"failed to merge transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, mt)
+}
+
+// walletMultisigSignHandler handles POST calls to /wallet/multisig/sign.
+func (api *API) walletMultisigSignHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigSignPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	mt, err := api.wallet.SignMultisigTransaction(params.Transaction)
+	if err != nil {
+		WriteError(w, Error{"failed to sign transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, mt)
+}
+
+// walletMultisigTransactionHandler handles POST calls to
+// /wallet/multisig/transaction.
+func (api *API) walletMultisigTransactionHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletMultisigTransactionPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	mt, err := api.wallet.NewMultisigTransaction(params.Address, params.Outputs, params.Fee)
+	if err != nil {
+		WriteError(w, Error{"failed to create transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, mt)
+}
+
+// writePSTx writes the partially signed transaction and its summary.
+func writePSTx(w http.ResponseWriter, pt types.PartialTransaction) {
+	WriteJSON(w, WalletPSTxResp{
+		PartialTransaction: pt,
+		Encoded:            pt.String(),
+		SiacoinInputSum:    pt.SiacoinInputSum(),
+		SignaturesNeeded:   pt.SignaturesNeeded(),
+	})
+}
+
+// walletPSTxHandler handles POST calls to /wallet/pstx.
+func (api *API) walletPSTxHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSTxPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pt, err := api.wallet.NewPartialTransaction(params.Transaction, params.Parents)
+	if err != nil {
+		WriteError(w, Error{"failed to create partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writePSTx(w, pt)
+}
+
+// walletPSTxCombineHandler handles POST calls to /wallet/pstx/combine.
+func (api *API) walletPSTxCombineHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSTxCombinePOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pt, err := api.wallet.CombinePartialTransactions(params.PartialTransactions)
+	if err != nil {
+		WriteError(w, Error{"failed to combine partially signed transactions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writePSTx(w, pt)
+}
+
+// walletPSTxFinalizeHandler handles POST calls to /wallet/pstx/finalize.
+func (api *API) walletPSTxFinalizeHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSTxFinalizePOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	txnSet, err := api.wallet.FinalizePartialTransaction(params.PartialTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to finalize partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if params.Broadcast {
+		api.tpool.Broadcast(txnSet)
+		err = api.tpool.AcceptTransactionSet(txnSet)
+		if err != nil && err != modules.ErrDuplicateTransactionSet {
+			WriteError(w, Error{"error accepting transaction set: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	WriteJSON(w, WalletPSTxFinalizePOSTResp{
+		Transactions: txnSet,
+	})
+}
+
+// walletPSTxSignHandler handles POST calls to /wallet/pstx/sign.
+func (api *API) walletPSTxSignHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletPSTxSignPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pt, err := api.wallet.SignPartialTransaction(params.PartialTransaction)
+	if err != nil {
+		WriteError(w, Error{"failed to sign partially signed transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writePSTx(w, pt)
+}
+
+// walletWatchOnlyHandler handles GET calls to /wallet/watchonly.
+func (api *API) walletWatchOnlyHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	status, err := api.wallet.WatchOnlyStatus()
+	if err != nil {
+		WriteError(w, Error{"failed to get watch-only status: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, status)
+}
+
+// walletWatchOnlyExportHandler handles GET calls to /wallet/watchonly/export.
+func (api *API) walletWatchOnlyExportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var start, count uint64
+	if s := req.FormValue("start"); s != "" {
+		if _, err := fmt.Sscan(s, &start); err != nil {
+			WriteError(w, Error{"unable to parse start: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	if _, err := fmt.Sscan(req.FormValue("count"), &count); err != nil {
+		WriteError(w, Error{"unable to parse count: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	keys, err := api.wallet.ExportWatchOnlyKeys(start, count)
+	if err != nil {
+		WriteError(w, Error{"failed to export watch-only keys: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, keys)
+}
+
+// walletWatchOnlyImportHandler handles POST calls to /wallet/watchonly/import.
+func (api *API) walletWatchOnlyImportHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletWatchOnlyImportPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.ImportWatchOnlyKeys(params.KeySet, params.Unused); err != nil {
+		WriteError(w, Error{"failed to import watch-only keys: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletWatchOnlyTransactionHandler handles POST calls to
+// /wallet/watchonly/transaction.
+func (api *API) walletWatchOnlyTransactionHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletWatchOnlyTransactionPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pt, err := api.wallet.NewWatchOnlyTransaction(params.Outputs, params.Fee)
+	if err != nil {
+		WriteError(w, Error{"failed to create transaction: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	writePSTx(w, pt)
+}
+
+// walletPaymentRequestHandler handles GET calls to
+// /wallet/paymentrequest/:addr.
+func (api *API) walletPaymentRequestHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	addr, err := scanAddress(ps.ByName("addr"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/paymentrequest: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	pr, err := api.wallet.PaymentRequest(addr)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/paymentrequest: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, pr)
+}
+
+// walletPaymentRequestsHandlerGET handles GET calls to
+// /wallet/paymentrequests.
+func (api *API) walletPaymentRequestsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	prs, err := api.wallet.PaymentRequests()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/paymentrequests: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPaymentRequestsGET{
+		PaymentRequests: prs,
+	})
+}
+
+// walletPaymentRequestsHandlerPOST handles POST calls to
+// /wallet/paymentrequests.
+func (api *API) walletPaymentRequestsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	amount, ok := scanAmount(req.FormValue("amount"))
+	if !ok {
+		WriteError(w, Error{"could not read amount from POST call to /wallet/paymentrequests"}, http.StatusBadRequest)
+		return
+	}
+	expiry := modules.DefaultPaymentRequestExpiry
+	if s := req.FormValue("expiry"); s != "" {
+		if _, err := fmt.Sscan(s, &expiry); err != nil {
+			WriteError(w, Error{"could not read expiry from POST call to /wallet/paymentrequests: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	confirmations := uint64(modules.DefaultPaymentRequestConfirmations)
+	if s := req.FormValue("confirmations"); s != "" {
+		if _, err := fmt.Sscan(s, &confirmations); err != nil {
+			WriteError(w, Error{"could not read confirmations from POST call to /wallet/paymentrequests: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	pr, err := api.wallet.NewPaymentRequest(amount, expiry, confirmations, req.FormValue("memo"), req.FormValue("webhook"))
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/paymentrequests: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, pr)
+}
+
+// walletPaymentRequestEventsHandler handles GET calls to
+// /wallet/paymentrequests/events.
+func (api *API) walletPaymentRequestEventsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since uint64
+	if s := req.FormValue("since"); s != "" {
+		if _, err := fmt.Sscan(s, &since); err != nil {
+			WriteError(w, Error{"could not read since from GET call to /wallet/paymentrequests/events: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	events, err := api.wallet.PaymentRequestEvents(since)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/paymentrequests/events: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletPaymentRequestEventsGET{
+		Events: events,
+	})
+}
+
+// scanAtomicSwapID parses the id of an atomic swap from the path.
+func scanAtomicSwapID(ps httprouter.Params) (id crypto.Hash, err error) {
+	err = id.LoadString(ps.ByName("id"))
+	return
+}
+
+// walletAtomicSwapHandler handles GET calls to /wallet/atomicswap/:id.
+func (api *API) walletAtomicSwapHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanAtomicSwapID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from GET call to /wallet/atomicswap/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	swap, err := api.wallet.AtomicSwap(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswap/:id: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, swap)
+}
+
+// walletAtomicSwapClaimHandler handles POST calls to
+// /wallet/atomicswap/:id/claim.
+func (api *API) walletAtomicSwapClaimHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanAtomicSwapID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from POST call to /wallet/atomicswap/:id/claim: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var asig crypto.AdaptorSignature
+	b, err := hex.DecodeString(req.FormValue("adaptorsignature"))
+	if err != nil || len(b) != len(asig) {
+		WriteError(w, Error{"could not read adaptorsignature from POST call to /wallet/atomicswap/:id/claim"}, http.StatusBadRequest)
+		return
+	}
+	copy(asig[:], b)
+	txn, err := api.wallet.ClaimAtomicSwap(id, asig)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswap/:id/claim: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAtomicSwapClaimPOST{
+		Transaction: txn,
+	})
+}
+
+// walletAtomicSwapFundHandler handles POST calls to
+// /wallet/atomicswap/:id/fund.
+func (api *API) walletAtomicSwapFundHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanAtomicSwapID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from POST call to /wallet/atomicswap/:id/fund: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	var sig crypto.Signature
+	b, err := hex.DecodeString(req.FormValue("refundsignature"))
+	if err != nil || len(b) != len(sig) {
+		WriteError(w, Error{"could not read refundsignature from POST call to /wallet/atomicswap/:id/fund"}, http.StatusBadRequest)
+		return
+	}
+	copy(sig[:], b)
+	if err := api.wallet.FundAtomicSwap(id, sig); err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswap/:id/fund: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletAtomicSwapPresignHandler handles POST calls to
+// /wallet/atomicswap/:id/presign.
+func (api *API) walletAtomicSwapPresignHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanAtomicSwapID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from POST call to /wallet/atomicswap/:id/presign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	asig, err := api.wallet.PresignAtomicSwapClaim(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswap/:id/presign: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAtomicSwapPresignPOST{
+		AdaptorSignature: asig,
+	})
+}
+
+// walletAtomicSwapsHandler handles GET calls to /wallet/atomicswaps.
+func (api *API) walletAtomicSwapsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	swaps, err := api.wallet.AtomicSwaps()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswaps: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAtomicSwapsGET{
+		AtomicSwaps: swaps,
+	})
+}
+
+// walletAtomicSwapsAcceptHandler handles POST calls to
+// /wallet/atomicswaps/accept.
+func (api *API) walletAtomicSwapsAcceptHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var offer modules.AtomicSwapOffer
+	err := json.NewDecoder(req.Body).Decode(&offer)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sig, err := api.wallet.AcceptAtomicSwap(offer)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswaps/accept: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletAtomicSwapAcceptPOST{
+		RefundSignature: hex.EncodeToString(sig[:]),
+	})
+}
+
+// walletAtomicSwapsOfferHandler handles POST calls to
+// /wallet/atomicswaps/offer.
+func (api *API) walletAtomicSwapsOfferHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletAtomicSwapOfferPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	offer, err := api.wallet.OfferAtomicSwap(params.Request, params.Amount, params.RefundHeight)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswaps/offer: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, offer)
+}
+
+// walletAtomicSwapsRequestHandler handles POST calls to
+// /wallet/atomicswaps/request.
+func (api *API) walletAtomicSwapsRequestHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	swapReq, err := api.wallet.RequestAtomicSwap()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/atomicswaps/request: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, swapReq)
+}
+
+// scanScheduledPaymentID parses the id of a scheduled payment from the path.
+func scanScheduledPaymentID(ps httprouter.Params) (uint64, error) {
+	return strconv.ParseUint(ps.ByName("id"), 10, 64)
+}
+
+// walletScheduledPaymentApproveHandler handles POST calls to
+// /wallet/scheduledpayment/:id/approve.
+func (api *API) walletScheduledPaymentApproveHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanScheduledPaymentID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from POST call to /wallet/scheduledpayment/:id/approve: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	execution, err := api.wallet.ApproveScheduledPayment(id)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayment/:id/approve: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, execution)
+}
+
+// walletScheduledPaymentCancelHandler handles POST calls to
+// /wallet/scheduledpayment/:id/cancel.
+func (api *API) walletScheduledPaymentCancelHandler(w http.ResponseWriter, req *http.Request, ps httprouter.Params) {
+	id, err := scanScheduledPaymentID(ps)
+	if err != nil {
+		WriteError(w, Error{"could not read id from POST call to /wallet/scheduledpayment/:id/cancel: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if err := api.wallet.CancelScheduledPayment(id); err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayment/:id/cancel: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
+
+// walletScheduledPaymentsHandlerGET handles GET calls to
+// /wallet/scheduledpayments.
+func (api *API) walletScheduledPaymentsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	sps, err := api.wallet.ScheduledPayments()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletScheduledPaymentsGET{
+		ScheduledPayments: sps,
+	})
+}
+
+// walletScheduledPaymentsHandlerPOST handles POST calls to
+// /wallet/scheduledpayments.
+func (api *API) walletScheduledPaymentsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var params WalletScheduledPaymentsPOST
+	err := json.NewDecoder(req.Body).Decode(&params)
+	if err != nil {
+		WriteError(w, Error{"invalid parameters: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	sp, err := api.wallet.NewScheduledPayment(params.Outputs, params.Schedule, params.Memo)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, sp)
+}
+
+// walletScheduledPaymentExecutionsHandler handles GET calls to
+// /wallet/scheduledpayments/executions.
+func (api *API) walletScheduledPaymentExecutionsHandler(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	var since uint64
+	if s := req.FormValue("since"); s != "" {
+		if _, err := fmt.Sscan(s, &since); err != nil {
+			WriteError(w, Error{"could not read since from GET call to /wallet/scheduledpayments/executions: " + err.Error()}, http.StatusBadRequest)
+			return
+		}
+	}
+	executions, err := api.wallet.ScheduledPaymentExecutions(since)
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments/executions: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, WalletScheduledPaymentExecutionsGET{
+		Executions: executions,
+	})
+}
+
+// walletScheduledPaymentLimitsHandlerGET handles GET calls to
+// /wallet/scheduledpayments/limits.
+func (api *API) walletScheduledPaymentLimitsHandlerGET(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limits, err := api.wallet.ScheduledPaymentLimits()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments/limits: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteJSON(w, limits)
+}
+
+// walletScheduledPaymentLimitsHandlerPOST handles POST calls to
+// /wallet/scheduledpayments/limits. Limits that are not provided keep their
+// current value.
+func (api *API) walletScheduledPaymentLimitsHandlerPOST(w http.ResponseWriter, req *http.Request, _ httprouter.Params) {
+	limits, err := api.wallet.ScheduledPaymentLimits()
+	if err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments/limits: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	if s := req.FormValue("approvalthreshold"); s != "" {
+		threshold, ok := scanAmount(s)
+		if !ok {
+			WriteError(w, Error{"could not read approvalthreshold from POST call to /wallet/scheduledpayments/limits"}, http.StatusBadRequest)
+			return
+		}
+		limits.ApprovalThreshold = threshold
+	}
+	if s := req.FormValue("dailylimit"); s != "" {
+		dailyLimit, ok := scanAmount(s)
+		if !ok {
+			WriteError(w, Error{"could not read dailylimit from POST call to /wallet/scheduledpayments/limits"}, http.StatusBadRequest)
+			return
+		}
+		limits.DailyLimit = dailyLimit
+	}
+	if err := api.wallet.SetScheduledPaymentLimits(limits); err != nil {
+		WriteError(w, Error{"error when calling /wallet/scheduledpayments/limits: " + err.Error()}, http.StatusBadRequest)
+		return
+	}
+	WriteSuccess(w)
+}
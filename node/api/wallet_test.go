@@ -1,6 +1,8 @@
 package api
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net/url"
@@ -1762,3 +1764,84 @@ func TestWalletTransactionsGetAddr(t *testing.T) {
 		t.Errorf("There should be exactly 0 unconfirmed and 1 confirmed related txns")
 	}
 }
+
+// TestNewWalletTransactionRecord checks that the records of wallet transactions
+// carry the net value, the fees and the counterparties.
+func TestNewWalletTransactionRecord(t *testing.T) {
+	t.Parallel()
+
+	// The wallet spends 10 SC and receives 3.5 SC of change while sending 5.5
+	// SC to a counterparty and paying a fee of 1 SC.
+	counterparty := types.UnlockHash{1}
+	pt := modules.ProcessedTransaction{
+		TransactionID:         types.TransactionID{2},
+		ConfirmationHeight:    10,
+		ConfirmationTimestamp: 1500000000,
+		Inputs: []modules.ProcessedInput{{
+			FundType:       types.SpecifierSiacoinInput,
+			WalletAddress:  true,
+			RelatedAddress: types.UnlockHash{3},
+			Value:          types.SiacoinPrecision.Mul64(10),
+		}},
+		Outputs: []modules.ProcessedOutput{{
+			FundType:       types.SpecifierSiacoinOutput,
+			WalletAddress:  true,
+			RelatedAddress: types.UnlockHash{4},
+			Value:          types.SiacoinPrecision.Mul64(7).Div64(2),
+		}, {
+			FundType:       types.SpecifierSiacoinOutput,
+			RelatedAddress: counterparty,
+			Value:          types.SiacoinPrecision.Mul64(11).Div64(2),
+		}, {
+			FundType: types.SpecifierMinerFee,
+			Value:    types.SiacoinPrecision,
+		}},
+		Note:       "rent",
+		Categories: []modules.TransactionCategory{modules.TransactionCategoryContractFormation},
+	}
+	tr := newWalletTransactionRecord(pt)
+	if tr.TransactionID != pt.TransactionID || tr.ConfirmationHeight != 10 || tr.ConfirmationTimestamp != 1500000000 {
+		t.Fatal("record has wrong transaction info", tr)
+	}
+	if !tr.IncomingSiacoins.Equals(types.SiacoinPrecision.Mul64(7).Div64(2)) || !tr.OutgoingSiacoins.Equals(types.SiacoinPrecision.Mul64(10)) {
+		t.Fatal("record has wrong siacoin flow", tr.IncomingSiacoins, tr.OutgoingSiacoins)
+	}
+	if tr.NetSiacoins != "-"+types.SiacoinPrecision.Mul64(13).Div64(2).String() {
+		t.Fatal("record has wrong net value", tr.NetSiacoins)
+	}
+	if !tr.Fees.Equals(types.SiacoinPrecision) {
+		t.Fatal("record has wrong fees", tr.Fees)
+	}
+	if tr.NetSiafunds != "0" {
+		t.Fatal("record has wrong net siafunds", tr.NetSiafunds)
+	}
+	if len(tr.Counterparties) != 1 || tr.Counterparties[0] != counterparty {
+		t.Fatal("record has wrong counterparties", tr.Counterparties)
+	}
+
+	// Fees of transactions funded by others are not paid by the wallet.
+	pt.Inputs[0].WalletAddress = false
+	if tr := newWalletTransactionRecord(pt); !tr.Fees.IsZero() || len(tr.Counterparties) != 2 {
+		t.Fatal("fees or counterparties of incoming transaction are wrong", tr.Fees, tr.Counterparties)
+	}
+
+	// Write the record as CSV.
+	var buf bytes.Buffer
+	if err := writeWalletTransactionRecordsCSV(&buf, []WalletTransactionRecord{tr}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 2 {
+		t.Fatal("expected a header and a row, got", len(rows))
+	}
+	expected := []string{pt.TransactionID.String(), "10", "1500000000", "2017-07-14T02:40:00Z", "3.5", "10", "-6.5", "1",
+		"0", "0", "0", counterparty.String(), "contract formation", "rent"}
+	for i := range expected {
+		if rows[1][i] != expected[i] {
+			t.Errorf("column %v: expected %q, got %q", rows[0][i], expected[i], rows[1][i])
+		}
+	}
+}
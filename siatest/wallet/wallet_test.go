@@ -430,3 +430,90 @@ func TestUnspentOutputs(t *testing.T) {
 		t.Fatal("shouldn't see addr in UnspentOutputs")
 	}
 }
+
+// TestLabelsAndNotes tests labeling addresses, adding notes to transactions
+// and filtering the transactions by category and label.
+func TestLabelsAndNotes(t *testing.T) {
+	if testing.Short() {
+		t.SkipNow()
+	}
+
+	// Create a new server
+	testNode, err := siatest.NewNode(node.AllModules(siatest.TestDir(t.Name())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if err := testNode.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	// Label a dummy address and send coins to it.
+	addr := types.UnlockHash{1}
+	if err := testNode.WalletLabelPost(addr, "customer"); err != nil {
+		t.Fatal(err)
+	}
+	wlg, err := testNode.WalletLabelsGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wlg.Labels) != 1 || wlg.Labels[0].Address != addr || wlg.Labels[0].Label != "customer" {
+		t.Fatal("unexpected labels", wlg.Labels)
+	}
+	wsp, err := testNode.WalletSiacoinsPost(types.SiacoinPrecision, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	txid := wsp.TransactionIDs[len(wsp.TransactionIDs)-1]
+	if err := testNode.WalletTransactionNotePost(txid, "invoice 42"); err != nil {
+		t.Fatal(err)
+	}
+	if err := testNode.MineBlock(); err != nil {
+		t.Fatal(err)
+	}
+	cg, err := testNode.ConsensusGet()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the transaction should match the label, and it should have its
+	// note.
+	wtg, err := testNode.WalletTransactionsFilterGet(0, cg.Height, "", "customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wtg.ConfirmedTransactions) != 1 || wtg.ConfirmedTransactions[0].TransactionID != txid {
+		t.Fatal("expected only the labeled transaction", wtg.ConfirmedTransactions)
+	}
+	if wtg.ConfirmedTransactions[0].Note != "invoice 42" {
+		t.Fatal("transaction has wrong note", wtg.ConfirmedTransactions[0].Note)
+	}
+
+	// The transaction is not a miner payout.
+	wtg, err = testNode.WalletTransactionsFilterGet(0, cg.Height, modules.TransactionCategoryMinerPayout, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wtg.ConfirmedTransactions) == 0 {
+		t.Fatal("expected miner payouts")
+	}
+	for _, pt := range wtg.ConfirmedTransactions {
+		if pt.TransactionID == txid {
+			t.Fatal("transaction should not be a miner payout")
+		}
+	}
+
+	// Removing the label should remove the transaction from the filtered
+	// results.
+	if err := testNode.WalletLabelPost(addr, ""); err != nil {
+		t.Fatal(err)
+	}
+	wtg, err = testNode.WalletTransactionsFilterGet(0, cg.Height, "", "customer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(wtg.ConfirmedTransactions) != 0 {
+		t.Fatal("expected no labeled transactions", wtg.ConfirmedTransactions)
+	}
+}
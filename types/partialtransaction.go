@@ -0,0 +1,254 @@
+package types
+
+// partialtransaction.go defines a container for transactions that are signed
+// by more than one party, or on a different machine than the one that built
+// them. Besides the transaction, the container carries the metadata that a
+// signer needs to check what it signs: the transactions that create the
+// spent outputs, the values of the spent outputs, and hints about which keys
+// are expected to sign each input.
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+)
+
+const (
+	// PartialTransactionVersion is the current version of the partially
+	// signed transaction format.
+	PartialTransactionVersion = 1
+)
+
+var (
+	// SpecifierPartialTransaction is the prefix of encoded partially signed
+	// transactions.
+	SpecifierPartialTransaction = Specifier{'p', 'a', 'r', 't', 'i', 'a', 'l', ' ', 't', 'x', 'n'}
+
+	// ErrInvalidPartialTransaction is returned when decoding a string that
+	// is not an encoded partially signed transaction.
+	ErrInvalidPartialTransaction = errors.New("not a partially signed transaction")
+
+	// ErrUnsupportedPartialTransactionVersion is returned when decoding a
+	// partially signed transaction of an unknown version.
+	ErrUnsupportedPartialTransactionVersion = errors.New("unsupported partially signed transaction version")
+
+	// errPartialInputMismatch is returned if the inputs of a partially
+	// signed transaction don't match the inputs of its transaction.
+	errPartialInputMismatch = errors.New("inputs of the partially signed transaction don't match its transaction")
+
+	// errPartialKeyHintOutOfRange is returned if a key hint refers to a
+	// public key that the unlock conditions of the input don't have.
+	errPartialKeyHintOutOfRange = errors.New("key hint refers to a public key that doesn't exist")
+
+	// errPartialParentMismatch is returned if a parent transaction creates a
+	// spent output with a different value or address than the input claims.
+	errPartialParentMismatch = errors.New("parent transaction doesn't match the spent output")
+)
+
+type (
+	// A PartialTransaction is a transaction that is not fully signed yet,
+	// together with the metadata that signers need to check it.
+	PartialTransaction struct {
+		Version     uint64                    `json:"version"`
+		Transaction Transaction               `json:"transaction"`
+		Parents     []Transaction             `json:"parents"`
+		Inputs      []PartialTransactionInput `json:"inputs"`
+	}
+
+	// A PartialTransactionInput describes a siacoin or siafund input of a
+	// partially signed transaction. The Value is the number of hastings or
+	// siafunds of the spent output, or zero if it is unknown to the parties
+	// that have handled the transaction so far.
+	PartialTransactionInput struct {
+		ParentID         crypto.Hash                 `json:"parentid"`
+		UnlockConditions UnlockConditions            `json:"unlockconditions"`
+		Value            Currency                    `json:"value"`
+		KeyHints         []PartialTransactionKeyHint `json:"keyhints"`
+	}
+
+	// A PartialTransactionKeyHint names a key that is expected to sign an
+	// input. KeyAddress is the address of the public key on its own; wallets
+	// track their keys by this address, so a signer can tell from the hint
+	// whether it holds the key.
+	PartialTransactionKeyHint struct {
+		PublicKeyIndex uint64     `json:"publickeyindex"`
+		KeyAddress     UnlockHash `json:"keyaddress"`
+	}
+)
+
+// NewPartialTransaction creates a partially signed transaction for txn. The
+// metadata of each input is initialized from the unlock conditions of the
+// input; values, parents and key hints have to be added by the caller.
+func NewPartialTransaction(txn Transaction) PartialTransaction {
+	pt := PartialTransaction{
+		Version:     PartialTransactionVersion,
+		Transaction: txn,
+	}
+	for _, sci := range txn.SiacoinInputs {
+		pt.Inputs = append(pt.Inputs, PartialTransactionInput{
+			ParentID:         crypto.Hash(sci.ParentID),
+			UnlockConditions: sci.UnlockConditions,
+		})
+	}
+	for _, sfi := range txn.SiafundInputs {
+		pt.Inputs = append(pt.Inputs, PartialTransactionInput{
+			ParentID:         crypto.Hash(sfi.ParentID),
+			UnlockConditions: sfi.UnlockConditions,
+		})
+	}
+	return pt
+}
+
+// KeyAddress returns the address of the public key at index i of the unlock
+// conditions on its own, which is what PartialTransactionKeyHint refers to.
+func KeyAddress(uc UnlockConditions, i uint64) UnlockHash {
+	return UnlockConditions{
+		PublicKeys:         []SiaPublicKey{uc.PublicKeys[i]},
+		SignaturesRequired: 1,
+	}.UnlockHash()
+}
+
+// SignaturesNeeded returns the number of signatures that are still missing
+// before every input of the transaction has as many signatures as its unlock
+// conditions require.
+func (pt PartialTransaction) SignaturesNeeded() (needed uint64) {
+	counts := make(map[crypto.Hash]uint64)
+	for _, sig := range pt.Transaction.TransactionSignatures {
+		counts[sig.ParentID]++
+	}
+	for _, input := range pt.Inputs {
+		if n := counts[input.ParentID]; n < input.UnlockConditions.SignaturesRequired {
+			needed += input.UnlockConditions.SignaturesRequired - n
+		}
+	}
+	return needed
+}
+
+// SiacoinInputSum returns the sum of the known values of the siacoin inputs
+// of the transaction. Together with the outputs and miner fees of the
+// transaction, it lets a signer check how many coins the transaction moves.
+func (pt PartialTransaction) SiacoinInputSum() (sum Currency) {
+	siacoinInputs := make(map[crypto.Hash]struct{})
+	for _, sci := range pt.Transaction.SiacoinInputs {
+		siacoinInputs[crypto.Hash(sci.ParentID)] = struct{}{}
+	}
+	for _, input := range pt.Inputs {
+		if _, ok := siacoinInputs[input.ParentID]; ok {
+			sum = sum.Add(input.Value)
+		}
+	}
+	return sum
+}
+
+// Validate checks that the metadata of the partially signed transaction is
+// consistent with its transaction. Every input of the transaction needs
+// metadata with the same unlock conditions, key hints must refer to existing
+// public keys, and parents that create a spent output must agree with the
+// value and address of the input.
+func (pt PartialTransaction) Validate() error {
+	if pt.Version != PartialTransactionVersion {
+		return ErrUnsupportedPartialTransactionVersion
+	}
+	txn := pt.Transaction
+	if len(pt.Inputs) != len(txn.SiacoinInputs)+len(txn.SiafundInputs) {
+		return errPartialInputMismatch
+	}
+	inputs := make(map[crypto.Hash]PartialTransactionInput)
+	for _, input := range pt.Inputs {
+		if _, exists := inputs[input.ParentID]; exists {
+			return errPartialInputMismatch
+		}
+		for _, hint := range input.KeyHints {
+			if hint.PublicKeyIndex >= uint64(len(input.UnlockConditions.PublicKeys)) {
+				return errPartialKeyHintOutOfRange
+			}
+		}
+		inputs[input.ParentID] = input
+	}
+	for _, sci := range txn.SiacoinInputs {
+		input, exists := inputs[crypto.Hash(sci.ParentID)]
+		if !exists || input.UnlockConditions.UnlockHash() != sci.UnlockConditions.UnlockHash() {
+			return errPartialInputMismatch
+		}
+	}
+	for _, sfi := range txn.SiafundInputs {
+		input, exists := inputs[crypto.Hash(sfi.ParentID)]
+		if !exists || input.UnlockConditions.UnlockHash() != sfi.UnlockConditions.UnlockHash() {
+			return errPartialInputMismatch
+		}
+	}
+
+	// Check the spent outputs that are created by the parents.
+	for _, parent := range pt.Parents {
+		for i, sco := range parent.SiacoinOutputs {
+			input, exists := inputs[crypto.Hash(parent.SiacoinOutputID(uint64(i)))]
+			if !exists {
+				continue
+			}
+			if sco.UnlockHash != input.UnlockConditions.UnlockHash() || (!input.Value.IsZero() && !input.Value.Equals(sco.Value)) {
+				return errPartialParentMismatch
+			}
+		}
+		for i, sfo := range parent.SiafundOutputs {
+			input, exists := inputs[crypto.Hash(parent.SiafundOutputID(uint64(i)))]
+			if !exists {
+				continue
+			}
+			if sfo.UnlockHash != input.UnlockConditions.UnlockHash() || (!input.Value.IsZero() && !input.Value.Equals(sfo.Value)) {
+				return errPartialParentMismatch
+			}
+		}
+	}
+	return nil
+}
+
+// MarshalSia implements the encoding.SiaMarshaler interface. The version is
+// encoded first, so that decoders can reject unknown versions before reading
+// the rest.
+func (pt PartialTransaction) MarshalSia(w io.Writer) error {
+	e := encoding.NewEncoder(w)
+	e.WriteUint64(pt.Version)
+	e.Encode(pt.Transaction)
+	e.Encode(pt.Parents)
+	e.Encode(pt.Inputs)
+	return e.Err()
+}
+
+// UnmarshalSia implements the encoding.SiaUnmarshaler interface.
+func (pt *PartialTransaction) UnmarshalSia(r io.Reader) error {
+	d := encoding.NewDecoder(r)
+	pt.Version = d.NextUint64()
+	if err := d.Err(); err != nil {
+		return err
+	}
+	if pt.Version != PartialTransactionVersion {
+		return ErrUnsupportedPartialTransactionVersion
+	}
+	return d.DecodeAll(&pt.Transaction, &pt.Parents, &pt.Inputs)
+}
+
+// String encodes the partially signed transaction as base64, prefixed with
+// SpecifierPartialTransaction, so that it can be passed between signers as
+// text.
+func (pt PartialTransaction) String() string {
+	return base64.StdEncoding.EncodeToString(encoding.MarshalAll(SpecifierPartialTransaction, pt))
+}
+
+// LoadString is the inverse of PartialTransaction.String().
+func (pt *PartialTransaction) LoadString(s string) error {
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil || len(b) < SpecifierLen || !bytes.Equal(b[:SpecifierLen], SpecifierPartialTransaction[:]) {
+		return ErrInvalidPartialTransaction
+	}
+	// Check the version here as well, since the decoder wraps the errors of
+	// UnmarshalSia.
+	b = b[SpecifierLen:]
+	if len(b) >= 8 && encoding.DecUint64(b[:8]) != PartialTransactionVersion {
+		return ErrUnsupportedPartialTransactionVersion
+	}
+	return encoding.Unmarshal(b, pt)
+}
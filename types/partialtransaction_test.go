@@ -0,0 +1,105 @@
+package types
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"gitlab.com/NebulousLabs/Sia/crypto"
+	"gitlab.com/NebulousLabs/Sia/encoding"
+)
+
+// TestPartialTransactionEncoding checks that partially signed transactions
+// survive the round trip through their string encoding, and that other
+// strings and unknown versions are rejected.
+func TestPartialTransactionEncoding(t *testing.T) {
+	_, pk := crypto.GenerateKeyPair()
+	uc := UnlockConditions{
+		PublicKeys:         []SiaPublicKey{Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	parent := Transaction{
+		SiacoinOutputs: []SiacoinOutput{{Value: NewCurrency64(10), UnlockHash: uc.UnlockHash()}},
+	}
+	txn := Transaction{
+		SiacoinInputs:  []SiacoinInput{{ParentID: parent.SiacoinOutputID(0), UnlockConditions: uc}},
+		SiacoinOutputs: []SiacoinOutput{{Value: NewCurrency64(9)}},
+		MinerFees:      []Currency{NewCurrency64(1)},
+	}
+	pt := NewPartialTransaction(txn)
+	pt.Parents = []Transaction{parent}
+	pt.Inputs[0].Value = NewCurrency64(10)
+	pt.Inputs[0].KeyHints = []PartialTransactionKeyHint{{PublicKeyIndex: 0, KeyAddress: KeyAddress(uc, 0)}}
+	if err := pt.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	var decoded PartialTransaction
+	if err := decoded.LoadString(pt.String()); err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != pt.String() || decoded.Transaction.ID() != txn.ID() || decoded.Inputs[0].KeyHints[0].KeyAddress != uc.UnlockHash() {
+		t.Fatal("partially signed transaction changed during the round trip")
+	}
+	if !decoded.SiacoinInputSum().Equals64(10) || decoded.SignaturesNeeded() != 1 {
+		t.Fatal("wrong input sum or number of missing signatures")
+	}
+
+	// A transaction is not a partially signed transaction.
+	if err := decoded.LoadString(base64.StdEncoding.EncodeToString(encoding.Marshal(txn))); err != ErrInvalidPartialTransaction {
+		t.Fatal("expected ErrInvalidPartialTransaction, got", err)
+	}
+	// Unknown versions are rejected.
+	pt.Version++
+	if err := decoded.LoadString(pt.String()); err != ErrUnsupportedPartialTransactionVersion {
+		t.Fatal("expected ErrUnsupportedPartialTransactionVersion, got", err)
+	}
+	if err := pt.Validate(); err != ErrUnsupportedPartialTransactionVersion {
+		t.Fatal("expected ErrUnsupportedPartialTransactionVersion, got", err)
+	}
+}
+
+// TestPartialTransactionValidate checks that Validate catches metadata that
+// is inconsistent with the transaction.
+func TestPartialTransactionValidate(t *testing.T) {
+	_, pk := crypto.GenerateKeyPair()
+	uc := UnlockConditions{
+		PublicKeys:         []SiaPublicKey{Ed25519PublicKey(pk)},
+		SignaturesRequired: 1,
+	}
+	parent := Transaction{
+		SiacoinOutputs: []SiacoinOutput{{Value: NewCurrency64(10), UnlockHash: uc.UnlockHash()}},
+	}
+	txn := Transaction{
+		SiacoinInputs: []SiacoinInput{{ParentID: parent.SiacoinOutputID(0), UnlockConditions: uc}},
+	}
+	valid := func() PartialTransaction {
+		pt := NewPartialTransaction(txn)
+		pt.Parents = []Transaction{parent}
+		pt.Inputs[0].Value = NewCurrency64(10)
+		return pt
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	pt := valid()
+	pt.Inputs = nil
+	if err := pt.Validate(); err != errPartialInputMismatch {
+		t.Fatal("expected errPartialInputMismatch, got", err)
+	}
+	pt = valid()
+	pt.Inputs[0].UnlockConditions.Timelock = 1
+	if err := pt.Validate(); err != errPartialInputMismatch {
+		t.Fatal("expected errPartialInputMismatch, got", err)
+	}
+	pt = valid()
+	pt.Inputs[0].KeyHints = []PartialTransactionKeyHint{{PublicKeyIndex: 1}}
+	if err := pt.Validate(); err != errPartialKeyHintOutOfRange {
+		t.Fatal("expected errPartialKeyHintOutOfRange, got", err)
+	}
+	pt = valid()
+	pt.Inputs[0].Value = NewCurrency64(11)
+	if err := pt.Validate(); err != errPartialParentMismatch {
+		t.Fatal("expected errPartialParentMismatch, got", err)
+	}
+}